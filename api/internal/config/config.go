@@ -73,6 +73,9 @@ func Init() *storage.Store {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	// The master key may come from the secret manager or .env, so load it afterwards.
+	secrets.InitMasterKey()
+
 	initViper()
 
 	AppConfig = types.Config{}
@@ -219,6 +222,10 @@ func setupEnvVarMappings() {
 	// Proxy
 	viper.BindEnv("proxy.caddy_port", "CADDY_PORT")
 	viper.SetDefault("proxy.caddy_port", "2019")
+	viper.BindEnv("proxy.wake_port", "WAKE_PORT")
+	viper.BindEnv("proxy.wake_dial", "WAKE_DIAL")
+	viper.BindEnv("proxy.wake_host", "WAKE_HOST")
+	viper.SetDefault("proxy.wake_host", "127.0.0.1")
 
 	// CORS
 	viper.BindEnv("cors.allowed_origin", "ALLOWED_ORIGIN")
@@ -256,6 +263,16 @@ func setupEnvVarMappings() {
 
 	viper.SetDefault("s3.use_ssl", true)
 
+	// Image scanning (SBOM and vulnerability matching)
+	viper.BindEnv("image_scan.syft_image", "IMAGE_SCAN_SYFT_IMAGE")
+	viper.BindEnv("image_scan.grype_image", "IMAGE_SCAN_GRYPE_IMAGE")
+	viper.BindEnv("image_scan.db_dir", "IMAGE_SCAN_DB_DIR")
+	viper.BindEnv("image_scan.db_update_url", "IMAGE_SCAN_DB_UPDATE_URL")
+
+	viper.SetDefault("image_scan.syft_image", "anchore/syft:latest")
+	viper.SetDefault("image_scan.grype_image", "anchore/grype:latest")
+	viper.SetDefault("image_scan.db_dir", "/etc/nixopus/grype-db")
+
 	// Set default for free deployments limit
 	viper.SetDefault("stripe.free_deployments_limit", 1)
 	viper.SetDefault("app.deploy_domain", "nixopus.com")
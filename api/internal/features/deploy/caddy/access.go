@@ -0,0 +1,314 @@
+package caddy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// AccessPolicy is the protection Caddy puts in front of the upstream of a
+// domain.
+type AccessPolicy struct {
+	AllowedIPs     []string
+	DeniedIPs      []string
+	BasicAuthRealm string
+	BasicAuth      []BasicAuthAccount
+	ForwardAuth    *ForwardAuth
+}
+
+// BasicAuthAccount is a basic auth user with its bcrypt password hash.
+type BasicAuthAccount struct {
+	Username     string
+	PasswordHash string
+}
+
+// ForwardAuth asks an auth service whether a request may pass.
+type ForwardAuth struct {
+	Dial string
+	TLS  bool
+	// URI is the path and query the auth service is asked at.
+	URI string
+	// CopyHeaders are the canonical names of the headers copied from a
+	// 2xx answer onto the request, sorted.
+	CopyHeaders []string
+}
+
+func (p *AccessPolicy) equal(other *AccessPolicy) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return slices.Equal(p.AllowedIPs, other.AllowedIPs) &&
+		slices.Equal(p.DeniedIPs, other.DeniedIPs) &&
+		p.BasicAuthRealm == other.BasicAuthRealm &&
+		slices.Equal(p.BasicAuth, other.BasicAuth) &&
+		p.ForwardAuth.equal(other.ForwardAuth)
+}
+
+func (f *ForwardAuth) equal(other *ForwardAuth) bool {
+	if f == nil || other == nil {
+		return f == other
+	}
+	return f.Dial == other.Dial && f.TLS == other.TLS && f.URI == other.URI &&
+		slices.Equal(f.CopyHeaders, other.CopyHeaders)
+}
+
+// ParseForwardAuthURL returns the forward auth that asks the http or https
+// URL rawURL, copying copyHeaders from its answers. Braces are rejected,
+// since Caddy would read them as placeholders.
+func ParseForwardAuthURL(rawURL string, copyHeaders []string) (*ForwardAuth, error) {
+	if strings.ContainsAny(rawURL, "{}") {
+		return nil, fmt.Errorf("forward auth url cannot contain braces")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("forward auth url must use http or https")
+	}
+	if u.Hostname() == "" || u.User != nil || u.Fragment != "" {
+		return nil, fmt.Errorf("forward auth url must have a host and no user info or fragment")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	uri := u.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	if u.RawQuery != "" {
+		uri += "?" + u.RawQuery
+	}
+
+	headers := make([]string, 0, len(copyHeaders))
+	for _, h := range copyHeaders {
+		if name := http.CanonicalHeaderKey(strings.TrimSpace(h)); name != "" && !slices.Contains(headers, name) {
+			headers = append(headers, name)
+		}
+	}
+	slices.Sort(headers)
+
+	return &ForwardAuth{
+		Dial:        net.JoinHostPort(u.Hostname(), port),
+		TLS:         u.Scheme == "https",
+		URI:         uri,
+		CopyHeaders: headers,
+	}, nil
+}
+
+// AccessFromPolicy converts a stored access policy to the protection Caddy
+// serves. It returns nil for a policy that checks nothing.
+func AccessFromPolicy(policy shared_types.ApplicationAccessPolicy) (*AccessPolicy, error) {
+	access := &AccessPolicy{
+		AllowedIPs:     slices.Clone(policy.AllowedIPs),
+		DeniedIPs:      slices.Clone(policy.DeniedIPs),
+		BasicAuthRealm: policy.BasicAuthRealm,
+	}
+	for _, user := range policy.BasicAuthUsers {
+		access.BasicAuth = append(access.BasicAuth, BasicAuthAccount{Username: user.Username, PasswordHash: user.PasswordHash})
+	}
+	if policy.ForwardAuthURL != "" {
+		forward, err := ParseForwardAuthURL(policy.ForwardAuthURL, policy.ForwardAuthCopyHeaders)
+		if err != nil {
+			return nil, err
+		}
+		access.ForwardAuth = forward
+	}
+	if len(access.AllowedIPs) == 0 && len(access.DeniedIPs) == 0 && len(access.BasicAuth) == 0 && access.ForwardAuth == nil {
+		return nil, nil
+	}
+	return access, nil
+}
+
+// AccessByDomain returns the access policy of every protected domain of the
+// organization. A domain policy takes precedence over the policy of its
+// application.
+func AccessByDomain(store storage.DeployRepository, organizationID uuid.UUID) (map[string]*AccessPolicy, error) {
+	policies, err := store.GetOrganizationAccessPolicies(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	type appPolicies struct {
+		app     *shared_types.ApplicationAccessPolicy
+		domains map[string]shared_types.ApplicationAccessPolicy
+	}
+	byApp := make(map[uuid.UUID]*appPolicies)
+	for _, p := range policies {
+		a := byApp[p.ApplicationID]
+		if a == nil {
+			a = &appPolicies{domains: make(map[string]shared_types.ApplicationAccessPolicy)}
+			byApp[p.ApplicationID] = a
+		}
+		if p.Domain == "" {
+			a.app = &p
+		} else {
+			a.domains[strings.ToLower(p.Domain)] = p
+		}
+	}
+
+	access := make(map[string]*AccessPolicy)
+	for appID, a := range byApp {
+		domains, err := store.GetApplicationDomains(appID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			name := strings.ToLower(d.Route())
+			policy, ok := a.domains[name]
+			if !ok {
+				if a.app == nil {
+					continue
+				}
+				policy = *a.app
+			}
+			guard, err := AccessFromPolicy(policy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid access policy of %s: %w", name, err)
+			}
+			if guard != nil {
+				access[name] = guard
+			}
+		}
+	}
+	return access, nil
+}
+
+// accessSubroutes reject the requests of denied IPs, and of IPs outside
+// the allowlist, with 403.
+func accessSubroutes(access *AccessPolicy) []customSubroute {
+	forbidden := []customHandler{{Handler: "static_response", StatusCode: http.StatusForbidden}}
+	var subroutes []customSubroute
+	if len(access.DeniedIPs) > 0 {
+		subroutes = append(subroutes, customSubroute{
+			Match:  []customMatch{{ClientIP: &customIPMatch{Ranges: access.DeniedIPs}}},
+			Handle: forbidden,
+		})
+	}
+	if len(access.AllowedIPs) > 0 {
+		subroutes = append(subroutes, customSubroute{
+			Match:  []customMatch{{Not: []customMatch{{ClientIP: &customIPMatch{Ranges: access.AllowedIPs}}}}},
+			Handle: forbidden,
+		})
+	}
+	return subroutes
+}
+
+// accessHandlers returns the basic auth and forward auth handlers that run
+// before the upstream.
+func accessHandlers(access *AccessPolicy) []customHandler {
+	var handlers []customHandler
+	if len(access.BasicAuth) > 0 {
+		basic := &customBasicAuth{
+			Hash:  map[string]string{"algorithm": "bcrypt"},
+			Realm: access.BasicAuthRealm,
+		}
+		for _, account := range access.BasicAuth {
+			basic.Accounts = append(basic.Accounts, customAccount{
+				Username: account.Username,
+				Password: base64.StdEncoding.EncodeToString([]byte(account.PasswordHash)),
+			})
+		}
+		handlers = append(handlers, customHandler{
+			Handler:   "authentication",
+			Providers: &customAuthProviders{HTTPBasic: basic},
+		})
+	}
+
+	if forward := access.ForwardAuth; forward != nil {
+		// Clients must not be able to send the headers the auth service
+		// vouches for.
+		if len(forward.CopyHeaders) > 0 {
+			handlers = append(handlers, customHandler{
+				Handler: "headers",
+				Request: &customHeaderOps{Delete: forward.CopyHeaders},
+			})
+		}
+		// A 2xx answer needs at least one route to continue to the
+		// upstream, as in the forward_auth Caddyfile directive.
+		passed := []customSubroute{{Handle: []customHandler{{Handler: "vars"}}}}
+		for _, name := range forward.CopyHeaders {
+			placeholder := "{http.reverse_proxy.header." + name + "}"
+			passed = append(passed, customSubroute{
+				Match:  []customMatch{{Not: []customMatch{{Vars: map[string][]string{placeholder: {""}}}}}},
+				Handle: []customHandler{{Handler: "headers", Request: &customHeaderOps{Set: map[string][]string{name: {placeholder}}}}},
+			})
+		}
+		proxy := customHandler{
+			Handler:   "reverse_proxy",
+			Upstreams: []map[string]string{{"dial": forward.Dial}},
+			Rewrite:   &customRewrite{Method: http.MethodGet, URI: forward.URI},
+			Headers: caddyconfig.JSON(map[string]any{"request": customHeaderOps{Set: map[string][]string{
+				"Host":               {"{http.reverse_proxy.upstream.hostport}"},
+				"X-Forwarded-Method": {"{http.request.method}"},
+				"X-Forwarded-Uri":    {"{http.request.uri}"},
+			}}}, nil),
+			HandleResponse: []customResponseHandler{{
+				Match:  &customResponseMatch{StatusCode: []int{2}},
+				Routes: passed,
+			}},
+		}
+		if forward.TLS {
+			proxy.Transport = &customTransport{Protocol: "http", TLS: &struct{}{}}
+		}
+		handlers = append(handlers, proxy)
+	}
+	return handlers
+}
+
+// parseForwardAuth reads back a forward auth handler made by
+// accessHandlers.
+func parseForwardAuth(h customHandler) *ForwardAuth {
+	forward := &ForwardAuth{TLS: h.Transport != nil && h.Transport.TLS != nil}
+	if len(h.Upstreams) > 0 {
+		forward.Dial = h.Upstreams[0]["dial"]
+	}
+	if h.Rewrite != nil {
+		forward.URI = h.Rewrite.URI
+	}
+	for _, response := range h.HandleResponse {
+		for _, route := range response.Routes {
+			for _, handler := range route.Handle {
+				if handler.Handler == "headers" && handler.Request != nil {
+					for name := range handler.Request.Set {
+						forward.CopyHeaders = append(forward.CopyHeaders, name)
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(forward.CopyHeaders)
+	return forward
+}
+
+// parseBasicAuth reads back the accounts and realm of a basic auth handler
+// made by accessHandlers.
+func parseBasicAuth(h customHandler, access *AccessPolicy) {
+	if h.Providers == nil || h.Providers.HTTPBasic == nil {
+		return
+	}
+	access.BasicAuthRealm = h.Providers.HTTPBasic.Realm
+	for _, account := range h.Providers.HTTPBasic.Accounts {
+		hash, err := base64.StdEncoding.DecodeString(account.Password)
+		if err != nil {
+			hash = []byte(account.Password)
+		}
+		access.BasicAuth = append(access.BasicAuth, BasicAuthAccount{Username: account.Username, PasswordHash: string(hash)})
+	}
+}
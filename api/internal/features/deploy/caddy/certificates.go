@@ -0,0 +1,259 @@
+package caddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// customCertTagPrefix marks the loaded certificates and TLS connection
+// policies that serve uploaded certificates.
+const customCertTagPrefix = "nixopus_cert:"
+
+// CustomCertificate is an uploaded certificate Caddy serves for Domain.
+type CustomCertificate struct {
+	Domain         string
+	CertificatePEM string
+	KeyPEM         string
+	Fingerprint    string
+}
+
+// CustomCertificatesFrom converts stored certificates to the ones Caddy
+// loads.
+func CustomCertificatesFrom(certificates []shared_types.ApplicationCertificate) []CustomCertificate {
+	custom := make([]CustomCertificate, 0, len(certificates))
+	for _, c := range certificates {
+		custom = append(custom, CustomCertificate{
+			Domain:         strings.ToLower(c.Domain),
+			CertificatePEM: string(c.CertificatePEM),
+			KeyPEM:         string(c.PrivateKeyPEM),
+			Fingerprint:    c.Fingerprint,
+		})
+	}
+	return custom
+}
+
+// tag identifies the certificate in Caddy. It changes with the certificate,
+// so a replaced certificate is never selected by a stale policy.
+func (c CustomCertificate) tag() string {
+	fingerprint := c.Fingerprint
+	if len(fingerprint) > 16 {
+		fingerprint = fingerprint[:16]
+	}
+	return customCertTagPrefix + c.Domain + ":" + fingerprint
+}
+
+// customCertDomain returns the domain of a tag written by tag, or "".
+func customCertDomain(tag string) string {
+	rest, ok := strings.CutPrefix(tag, customCertTagPrefix)
+	if !ok {
+		return ""
+	}
+	domain, _, _ := strings.Cut(rest, ":")
+	return domain
+}
+
+// ApplyCustomCertificates makes Caddy serve certificates for their domains
+// instead of getting them from ACME, and hands the domains of removed
+// certificates back to on-demand ACME. It loads the new config only when
+// something changed.
+func ApplyCustomCertificates(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, certificates []CustomCertificate) error {
+	changed, err := updateConfig(ctx, sshClient, lgr, shared_types.CaddySnapshotCertificates, func(config *caddy.Config) (bool, error) {
+		return applyCustomCertificates(config, certificates)
+	})
+	if err != nil && changed {
+		return fmt.Errorf("failed to load custom certificates: %w", err)
+	}
+	return err
+}
+
+// storedCustomCertificates returns the custom certificates of the
+// organization in ctx. ok is false when they could not be read, so callers
+// leave the certificates in Caddy as they are.
+func storedCustomCertificates(ctx context.Context, l logger.Logger) ([]CustomCertificate, bool) {
+	orgID := orgIDFromContext(ctx)
+	if domainStore == nil || orgID == uuid.Nil {
+		return nil, false
+	}
+	certificates, err := domainStore.GetOrganizationCertificates(orgID)
+	if err != nil {
+		l.Log(logger.Warning, "failed to read custom certificates", err.Error())
+		return nil, false
+	}
+	return CustomCertificatesFrom(certificates), true
+}
+
+// applyCustomCertificates rebuilds the certificates Nixopus loads into
+// config and the nixopus server's connection policies that select them.
+// It reports whether config changed.
+func applyCustomCertificates(config *caddy.Config, certificates []CustomCertificate) (bool, error) {
+	if config.AppsRaw == nil {
+		config.AppsRaw = make(caddy.ModuleMap)
+	}
+
+	var httpApp caddyhttp.App
+	if raw, ok := config.AppsRaw["http"]; ok {
+		if err := json.Unmarshal(raw, &httpApp); err != nil {
+			return false, fmt.Errorf("failed to unmarshal http app: %w", err)
+		}
+	}
+	server := httpApp.Servers["nixopus"]
+	if server == nil {
+		if len(certificates) == 0 {
+			return false, nil
+		}
+		return false, fmt.Errorf("nixopus server not found in caddy config")
+	}
+	var tlsApp caddytls.TLS
+	if raw, ok := config.AppsRaw["tls"]; ok {
+		if err := json.Unmarshal(raw, &tlsApp); err != nil {
+			return false, fmt.Errorf("failed to unmarshal tls app: %w", err)
+		}
+	}
+	httpBefore, err := json.Marshal(httpApp)
+	if err != nil {
+		return false, err
+	}
+	tlsBefore, err := json.Marshal(tlsApp)
+	if err != nil {
+		return false, err
+	}
+
+	custom := make(map[string]bool, len(certificates))
+	for _, c := range certificates {
+		custom[c.Domain] = true
+	}
+
+	// Loaded certificates.
+	var pairs []caddytls.CertKeyPEMPair
+	if raw, ok := tlsApp.CertificatesRaw["load_pem"]; ok {
+		if err := json.Unmarshal(raw, &pairs); err != nil {
+			return false, fmt.Errorf("failed to unmarshal loaded certificates: %w", err)
+		}
+	}
+	released := make(map[string]bool)
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		if domain := ownedCertDomain(pair.Tags); domain != "" {
+			released[domain] = true
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	for _, c := range certificates {
+		kept = append(kept, caddytls.CertKeyPEMPair{
+			CertificatePEM: c.CertificatePEM,
+			KeyPEM:         c.KeyPEM,
+			Tags:           []string{c.tag()},
+		})
+		delete(released, c.Domain)
+	}
+	if len(kept) > 0 {
+		if tlsApp.CertificatesRaw == nil {
+			tlsApp.CertificatesRaw = make(caddy.ModuleMap)
+		}
+		tlsApp.CertificatesRaw["load_pem"] = caddyconfig.JSON(kept, nil)
+	} else if tlsApp.CertificatesRaw != nil {
+		delete(tlsApp.CertificatesRaw, "load_pem")
+	}
+
+	// Connection policies are matched in order, so the ones selecting a
+	// custom certificate go first, and a catch-all keeps every other
+	// domain on its managed certificate.
+	var connPolicies caddytls.ConnectionPolicies
+	for _, c := range certificates {
+		connPolicies = append(connPolicies, &caddytls.ConnectionPolicy{
+			MatchersRaw:   caddy.ModuleMap{"sni": caddyconfig.JSON([]string{c.Domain}, nil)},
+			CertSelection: &caddytls.CustomCertSelectionPolicy{AnyTag: []string{c.tag()}},
+		})
+	}
+	catchAll := false
+	for _, policy := range server.TLSConnPolicies {
+		if policy.CertSelection != nil && ownedCertDomain(policy.CertSelection.AnyTag) != "" {
+			continue
+		}
+		if len(policy.MatchersRaw) == 0 {
+			catchAll = true
+		}
+		connPolicies = append(connPolicies, policy)
+	}
+	if len(certificates) > 0 && !catchAll {
+		connPolicies = append(connPolicies, &caddytls.ConnectionPolicy{})
+	}
+	server.TLSConnPolicies = connPolicies
+
+	// ACME must not manage custom domains, and the domains of removed
+	// certificates go back to on-demand certificates.
+	var onDemand *caddytls.AutomationPolicy
+	if tlsApp.Automation != nil {
+		policies := tlsApp.Automation.Policies[:0]
+		for _, policy := range tlsApp.Automation.Policies {
+			hadSubjects := len(policy.SubjectsRaw) > 0
+			policy.SubjectsRaw = slices.DeleteFunc(policy.SubjectsRaw, func(s string) bool {
+				return custom[strings.ToLower(s)]
+			})
+			// A policy without subjects applies to every domain.
+			if hadSubjects && len(policy.SubjectsRaw) == 0 && !policy.OnDemand {
+				continue
+			}
+			if policy.OnDemand && onDemand == nil {
+				onDemand = policy
+			}
+			policies = append(policies, policy)
+		}
+		tlsApp.Automation.Policies = policies
+	}
+	if len(released) > 0 {
+		if onDemand == nil {
+			onDemand = onDemandPolicy(&tlsApp)
+		}
+		domains := make([]string, 0, len(released))
+		for domain := range released {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			if !slices.Contains(onDemand.SubjectsRaw, domain) {
+				onDemand.SubjectsRaw = append(onDemand.SubjectsRaw, domain)
+			}
+		}
+	}
+
+	httpAfter, err := json.Marshal(httpApp)
+	if err != nil {
+		return false, err
+	}
+	tlsAfter, err := json.Marshal(tlsApp)
+	if err != nil {
+		return false, err
+	}
+	if string(httpBefore) == string(httpAfter) && string(tlsBefore) == string(tlsAfter) {
+		return false, nil
+	}
+	config.AppsRaw["http"] = httpAfter
+	config.AppsRaw["tls"] = tlsAfter
+	return true, nil
+}
+
+// ownedCertDomain returns the domain of the first custom certificate tag in
+// tags, or "".
+func ownedCertDomain(tags []string) string {
+	for _, tag := range tags {
+		if domain := customCertDomain(tag); domain != "" {
+			return domain
+		}
+	}
+	return ""
+}
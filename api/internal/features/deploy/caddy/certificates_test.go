@@ -0,0 +1,88 @@
+package caddy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+)
+
+func TestApplyCustomCertificates(t *testing.T) {
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[
+			{"match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31000"}]}],"terminal":true},
+			{"match":[{"host":["other.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31001"}]}],"terminal":true}
+		]}}}`),
+		"tls": json.RawMessage(`{"automation":{"policies":[
+			{"subjects":["app.example.com","other.example.com"],"on_demand":true,"key_type":"p384"}
+		]}}`),
+	}}
+	certificate := CustomCertificate{
+		Domain:         "app.example.com",
+		CertificatePEM: "CERT",
+		KeyPEM:         "KEY",
+		Fingerprint:    "0123456789abcdef0123456789abcdef",
+	}
+
+	changed, err := applyCustomCertificates(config, []CustomCertificate{certificate})
+	if err != nil {
+		t.Fatalf("applyCustomCertificates: %v", err)
+	}
+	if !changed {
+		t.Fatal("expected the config to change")
+	}
+
+	tlsApp, server := certificateApps(t, config)
+	var pairs []caddytls.CertKeyPEMPair
+	if err := json.Unmarshal(tlsApp.CertificatesRaw["load_pem"], &pairs); err != nil {
+		t.Fatalf("unmarshal load_pem: %v", err)
+	}
+	tag := "nixopus_cert:app.example.com:0123456789abcdef"
+	if len(pairs) != 1 || pairs[0].CertificatePEM != "CERT" || !slices.Equal(pairs[0].Tags, []string{tag}) {
+		t.Errorf("loaded certificates = %+v", pairs)
+	}
+	policies := server.TLSConnPolicies
+	if len(policies) != 2 || !slices.Equal(policies[0].CertSelection.AnyTag, []string{tag}) || len(policies[1].MatchersRaw) != 0 {
+		t.Errorf("connection policies = %+v", policies)
+	}
+	if subjects := tlsApp.Automation.Policies[0].SubjectsRaw; !slices.Equal(subjects, []string{"other.example.com"}) {
+		t.Errorf("on-demand subjects = %v", subjects)
+	}
+
+	if changed, err := applyCustomCertificates(config, []CustomCertificate{certificate}); err != nil || changed {
+		t.Errorf("second apply changed = %v, err = %v", changed, err)
+	}
+
+	// Removing the certificate hands the domain back to ACME.
+	if _, err := applyCustomCertificates(config, nil); err != nil {
+		t.Fatalf("applyCustomCertificates without certificates: %v", err)
+	}
+	tlsApp, server = certificateApps(t, config)
+	if _, ok := tlsApp.CertificatesRaw["load_pem"]; ok {
+		t.Error("load_pem should be removed")
+	}
+	for _, policy := range server.TLSConnPolicies {
+		if policy.CertSelection != nil {
+			t.Errorf("stale connection policy: %+v", policy)
+		}
+	}
+	if subjects := tlsApp.Automation.Policies[0].SubjectsRaw; !slices.Equal(subjects, []string{"other.example.com", "app.example.com"}) {
+		t.Errorf("on-demand subjects after removal = %v", subjects)
+	}
+}
+
+func certificateApps(t *testing.T, config *caddy.Config) (caddytls.TLS, *caddyhttp.Server) {
+	t.Helper()
+	var tlsApp caddytls.TLS
+	if err := json.Unmarshal(config.AppsRaw["tls"], &tlsApp); err != nil {
+		t.Fatalf("unmarshal tls app: %v", err)
+	}
+	var httpApp caddyhttp.App
+	if err := json.Unmarshal(config.AppsRaw["http"], &httpApp); err != nil {
+		t.Fatalf("unmarshal http app: %v", err)
+	}
+	return tlsApp, httpApp.Servers["nixopus"]
+}
@@ -0,0 +1,493 @@
+package caddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+)
+
+// customRouteGroup marks the routes that serve redirects, access checks,
+// path mounts or a maintenance page, so they can be told apart from plain reverse proxy
+// routes when read back.
+const customRouteGroup = "nixopus_custom"
+
+// custom reports whether route needs more than a plain reverse proxy route.
+func (r DomainRoute) custom() bool {
+	return r.Maintenance != nil || len(r.Redirects) > 0 || r.Access != nil || r.Proxy != nil ||
+		len(r.Mounts) > 0
+}
+
+// matches reports whether actual already serves route. The upstream of a
+// maintenance page, and the access policy and timeouts in front of it, only
+// matter when some IPs may reach it.
+func (r DomainRoute) matches(actual DomainRoute) bool {
+	if r.PathPrefix != actual.PathPrefix || r.StripPathPrefix != actual.StripPathPrefix ||
+		!slices.EqualFunc(r.Mounts, actual.Mounts, DomainRoute.matches) {
+		return false
+	}
+	if !r.Maintenance.equal(actual.Maintenance) || !slices.Equal(r.Redirects, actual.Redirects) ||
+		!r.Proxy.equal(actual.Proxy) {
+		return false
+	}
+	if r.Maintenance != nil && len(r.Maintenance.AllowedIPs) == 0 {
+		return true
+	}
+	if r.proxied() && (!r.Access.equal(actual.Access) || r.Proxy.timeouts() != actual.Proxy.timeouts()) {
+		return false
+	}
+	return r.UpstreamDial == actual.UpstreamDial
+}
+
+// proxied reports whether any request of route reaches its upstream.
+func (r DomainRoute) proxied() bool {
+	return r.UpstreamDial != "" && (r.Maintenance == nil || len(r.Maintenance.AllowedIPs) > 0)
+}
+
+// withCustomRoutes returns the routes of domains that have redirects, an
+// access policy, proxy settings or are in maintenance, with those set. Routes that already
+// carry them are returned as they are. Unreadable access policies are an
+// error, so a protected domain is never routed without its policy.
+func withCustomRoutes(ctx context.Context, domains []DomainRoute, l logger.Logger) ([]DomainRoute, error) {
+	var pages map[string]*MaintenancePage
+	var access map[string]*AccessPolicy
+	var proxies map[string]*ProxySettings
+	redirects := &RedirectSet{}
+	if orgID := orgIDFromContext(ctx); domainStore != nil && orgID != uuid.Nil {
+		var err error
+		access, err = AccessByDomain(domainStore, orgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read access policies: %w", err)
+		}
+		pages, err = MaintenanceByDomain(domainStore, orgID)
+		if err != nil {
+			l.Log(logger.Warning, "failed to read maintenance settings", err.Error())
+		}
+		if set, err := RedirectsByDomain(domainStore, orgID); err != nil {
+			l.Log(logger.Warning, "failed to read redirects", err.Error())
+		} else {
+			redirects = set
+		}
+		if proxies, err = ProxyByDomain(domainStore, orgID); err != nil {
+			l.Log(logger.Warning, "failed to read proxy settings", err.Error())
+		}
+	}
+
+	var routes []DomainRoute
+	for _, d := range domains {
+		name := d.key()
+		if d.Maintenance == nil {
+			d.Maintenance = pages[name]
+		}
+		if d.Redirects == nil {
+			d.Redirects = redirects.ByDomain[name]
+		}
+		if d.Access == nil {
+			d.Access = access[name]
+		}
+		if d.Proxy == nil {
+			d.Proxy = proxies[name]
+		}
+		if d.custom() {
+			routes = append(routes, d)
+		}
+	}
+	return routes, nil
+}
+
+// ApplyCustomRoutes replaces the routes of the given domains with routes
+// that serve their redirects, access checks and maintenance pages, and
+// loads the new config.
+func ApplyCustomRoutes(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, routes []DomainRoute) error {
+	if len(routes) == 0 {
+		return nil
+	}
+	config, err := GetCaddyConfig(ctx, sshClient, lgr)
+	if err != nil {
+		return err
+	}
+	if err := applyCustomRoutes(config, routes); err != nil {
+		return err
+	}
+	if err := RestoreCaddyConfig(ctx, sshClient, lgr, config); err != nil {
+		return fmt.Errorf("failed to load custom routes: %w", err)
+	}
+	return nil
+}
+
+// applyCustomRoutes swaps the routes of the given domains in the nixopus
+// server for custom routes, and makes sure each domain still gets a
+// certificate on demand.
+func applyCustomRoutes(config *caddy.Config, routes []DomainRoute) error {
+	if config.AppsRaw == nil {
+		config.AppsRaw = make(caddy.ModuleMap)
+	}
+
+	var httpApp caddyhttp.App
+	if raw, ok := config.AppsRaw["http"]; ok {
+		if err := json.Unmarshal(raw, &httpApp); err != nil {
+			return fmt.Errorf("failed to unmarshal http app: %w", err)
+		}
+	}
+	if httpApp.Servers == nil {
+		httpApp.Servers = make(map[string]*caddyhttp.Server)
+	}
+	server := httpApp.Servers["nixopus"]
+	if server == nil {
+		return fmt.Errorf("nixopus server not found in caddy config")
+	}
+
+	replace := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		replace[r.Domain] = true
+	}
+	kept := server.Routes[:0]
+	for _, route := range server.Routes {
+		if !replace[extractDomainFromRoute(route)] {
+			kept = append(kept, route)
+		}
+	}
+	server.Routes = kept
+
+	var tlsApp caddytls.TLS
+	if raw, ok := config.AppsRaw["tls"]; ok {
+		if err := json.Unmarshal(raw, &tlsApp); err != nil {
+			return fmt.Errorf("failed to unmarshal tls app: %w", err)
+		}
+	}
+	onDemand := onDemandPolicy(&tlsApp)
+
+	for _, r := range routes {
+		route, err := buildCustomRoute(r)
+		if err != nil {
+			return err
+		}
+		server.Routes = append(server.Routes, route)
+		if !slices.Contains(onDemand.SubjectsRaw, r.Domain) {
+			onDemand.SubjectsRaw = append(onDemand.SubjectsRaw, r.Domain)
+		}
+	}
+
+	httpRaw, err := json.Marshal(httpApp)
+	if err != nil {
+		return err
+	}
+	tlsRaw, err := json.Marshal(tlsApp)
+	if err != nil {
+		return err
+	}
+	config.AppsRaw["http"] = httpRaw
+	config.AppsRaw["tls"] = tlsRaw
+	return nil
+}
+
+func onDemandPolicy(tlsApp *caddytls.TLS) *caddytls.AutomationPolicy {
+	if tlsApp.Automation == nil {
+		tlsApp.Automation = &caddytls.AutomationConfig{}
+	}
+	for _, policy := range tlsApp.Automation.Policies {
+		if policy.OnDemand {
+			return policy
+		}
+	}
+	policy := &caddytls.AutomationPolicy{OnDemand: true, KeyType: "p384", SubjectsRaw: []string{}}
+	tlsApp.Automation.Policies = append(tlsApp.Automation.Policies, policy)
+	return policy
+}
+
+// customHandler is the subset of the Caddy handlers a custom route is made
+// of. Headers holds the response headers of a static_response and the
+// header operations of a reverse_proxy.
+type customHandler struct {
+	Handler         string                     `json:"handler"`
+	Routes          []customSubroute           `json:"routes,omitempty"`
+	StripPathPrefix string                     `json:"strip_path_prefix,omitempty"`
+	StatusCode      int                        `json:"status_code,omitempty"`
+	Headers         json.RawMessage            `json:"headers,omitempty"`
+	Body            string                     `json:"body,omitempty"`
+	Upstreams       []map[string]string        `json:"upstreams,omitempty"`
+	Rewrite         *customRewrite             `json:"rewrite,omitempty"`
+	Transport       *customTransport           `json:"transport,omitempty"`
+	HandleResponse  []customResponseHandler    `json:"handle_response,omitempty"`
+	Providers       *customAuthProviders       `json:"providers,omitempty"`
+	Request         *customHeaderOps           `json:"request,omitempty"`
+	Response        *customHeaderOps           `json:"response,omitempty"`
+	RateLimits      map[string]customRateLimit `json:"rate_limits,omitempty"`
+	MaxSize         int64                      `json:"max_size,omitempty"`
+	Encodings       map[string]json.RawMessage `json:"encodings,omitempty"`
+	Prefer          []string                   `json:"prefer,omitempty"`
+}
+
+// responseHeaders returns the headers of a static_response handler.
+func (h customHandler) responseHeaders() map[string][]string {
+	var headers map[string][]string
+	if len(h.Headers) > 0 {
+		_ = json.Unmarshal(h.Headers, &headers)
+	}
+	return headers
+}
+
+type customSubroute struct {
+	Match  []customMatch   `json:"match,omitempty"`
+	Handle []customHandler `json:"handle"`
+}
+
+type customMatch struct {
+	ClientIP *customIPMatch      `json:"client_ip,omitempty"`
+	Path     []string            `json:"path,omitempty"`
+	Not      []customMatch       `json:"not,omitempty"`
+	Vars     map[string][]string `json:"vars,omitempty"`
+}
+
+type customIPMatch struct {
+	Ranges []string `json:"ranges"`
+}
+
+type customRewrite struct {
+	Method string `json:"method,omitempty"`
+	URI    string `json:"uri,omitempty"`
+}
+
+type customTransport struct {
+	Protocol              string    `json:"protocol"`
+	TLS                   *struct{} `json:"tls,omitempty"`
+	DialTimeout           string    `json:"dial_timeout,omitempty"`
+	ResponseHeaderTimeout string    `json:"response_header_timeout,omitempty"`
+}
+
+type customResponseHandler struct {
+	Match  *customResponseMatch `json:"match,omitempty"`
+	Routes []customSubroute     `json:"routes,omitempty"`
+}
+
+type customResponseMatch struct {
+	StatusCode []int `json:"status_code,omitempty"`
+}
+
+type customAuthProviders struct {
+	HTTPBasic *customBasicAuth `json:"http_basic,omitempty"`
+}
+
+type customBasicAuth struct {
+	Hash     map[string]string `json:"hash,omitempty"`
+	Accounts []customAccount   `json:"accounts"`
+	Realm    string            `json:"realm,omitempty"`
+}
+
+type customAccount struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type customHeaderOps struct {
+	Set      map[string][]string `json:"set,omitempty"`
+	Delete   []string            `json:"delete,omitempty"`
+	Deferred bool                `json:"deferred,omitempty"`
+}
+
+type customRateLimit struct {
+	Key       string `json:"key"`
+	Window    string `json:"window"`
+	MaxEvents int    `json:"max_events"`
+}
+
+// buildCustomRoute returns a route that serves the mounts of route under
+// their paths, applies its proxy settings to every other request and
+// answers its redirects first. Other requests pass its access checks and
+// reach its upstream, or, in maintenance, only those of the allowed IPs do
+// and everyone else gets the maintenance page. A domain without an
+// upstream answers them with 404.
+func buildCustomRoute(route DomainRoute) (caddyhttp.Route, error) {
+	handler, err := json.Marshal(customHandler{Handler: "subroute", Routes: customSubroutes(route)})
+	if err != nil {
+		return caddyhttp.Route{}, err
+	}
+	return caddyhttp.Route{
+		Group: customRouteGroup,
+		MatcherSetsRaw: []caddy.ModuleMap{
+			{"host": caddyconfig.JSON(caddyhttp.MatchHost{route.Domain}, nil)},
+		},
+		HandlersRaw: []json.RawMessage{handler},
+		Terminal:    true,
+	}, nil
+}
+
+// customSubroutes returns the subroutes buildCustomRoute puts behind the
+// host matcher of route.
+func customSubroutes(route DomainRoute) []customSubroute {
+	var subroutes []customSubroute
+	for _, m := range route.Mounts {
+		subroutes = append(subroutes, mountSubroute(m))
+	}
+	if route.Proxy != nil {
+		if settings, ok := settingsSubroute(route.Domain+route.PathPrefix, route.Proxy); ok {
+			subroutes = append(subroutes, settings)
+		}
+	}
+	for _, r := range route.Redirects {
+		subroutes = append(subroutes, redirectSubroute(r))
+	}
+
+	proxy := []customHandler{{
+		Handler:   "reverse_proxy",
+		Upstreams: []map[string]string{{"dial": route.UpstreamDial}},
+		Transport: upstreamTransport(route.Proxy),
+	}}
+	if route.Access != nil && route.proxied() {
+		subroutes = append(subroutes, accessSubroutes(route.Access)...)
+		proxy = append(accessHandlers(route.Access), proxy...)
+	}
+
+	switch page := route.Maintenance; {
+	case page != nil:
+		headers := map[string][]string{
+			"Content-Type":  {"text/html; charset=utf-8"},
+			"Cache-Control": {"no-store"},
+		}
+		if page.RetryAfterSeconds > 0 {
+			headers["Retry-After"] = []string{strconv.Itoa(page.RetryAfterSeconds)}
+		}
+		if route.proxied() {
+			subroutes = append(subroutes, customSubroute{
+				Match:  []customMatch{{ClientIP: &customIPMatch{Ranges: page.AllowedIPs}}},
+				Handle: proxy,
+			})
+		}
+		subroutes = append(subroutes, customSubroute{
+			Handle: []customHandler{{
+				Handler:    "static_response",
+				StatusCode: 503,
+				Headers:    caddyconfig.JSON(headers, nil),
+				Body:       escapePlaceholders(page.HTMLBody),
+			}},
+		})
+	case route.UpstreamDial != "":
+		subroutes = append(subroutes, customSubroute{Handle: proxy})
+	default:
+		subroutes = append(subroutes, customSubroute{
+			Handle: []customHandler{{Handler: "static_response", StatusCode: 404}},
+		})
+	}
+	return subroutes
+}
+
+// redirectSubroute answers the requests under the path prefix of r with a
+// redirect. A preserved path is stripped of the prefix first, so the rest
+// of it lands below the target path.
+func redirectSubroute(r Redirect) customSubroute {
+	var sub customSubroute
+	if r.PathPrefix != "" {
+		sub.Match = []customMatch{{Path: []string{r.PathPrefix, r.PathPrefix + "/*"}}}
+		if r.PreservePath {
+			sub.Handle = append(sub.Handle, customHandler{Handler: "rewrite", StripPathPrefix: r.PathPrefix})
+		}
+	}
+	sub.Handle = append(sub.Handle, customHandler{
+		Handler:    "static_response",
+		StatusCode: r.StatusCode,
+		Headers:    caddyconfig.JSON(map[string][]string{"Location": {r.location()}}, nil),
+	})
+	return sub
+}
+
+// parseCustomRoute reads back the upstream, mounts, redirects and
+// maintenance page of a route made by buildCustomRoute. It reports false
+// for any other route.
+func parseCustomRoute(route caddyhttp.Route) (DomainRoute, bool) {
+	if route.Group != customRouteGroup || len(route.HandlersRaw) == 0 {
+		return DomainRoute{}, false
+	}
+	var handler customHandler
+	if err := json.Unmarshal(route.HandlersRaw[0], &handler); err != nil || handler.Handler != "subroute" {
+		return DomainRoute{}, false
+	}
+	return parseSubroutes(handler.Routes), true
+}
+
+// parseSubroutes reads back the subroutes made by customSubroutes.
+func parseSubroutes(subroutes []customSubroute) DomainRoute {
+	var parsed DomainRoute
+	access := &AccessPolicy{}
+	proxy := &ProxySettings{}
+	for _, sub := range subroutes {
+		if mount, ok := parseMount(sub); ok {
+			parsed.Mounts = append(parsed.Mounts, mount)
+			continue
+		}
+		for _, h := range sub.Handle {
+			if parseSettingsHandler(h, proxy) {
+				continue
+			}
+			switch h.Handler {
+			case "authentication":
+				parseBasicAuth(h, access)
+			case "reverse_proxy":
+				if len(h.HandleResponse) > 0 {
+					access.ForwardAuth = parseForwardAuth(h)
+					continue
+				}
+				if len(h.Upstreams) > 0 {
+					parsed.UpstreamDial = h.Upstreams[0]["dial"]
+				}
+				proxy.Timeouts = parseTransport(h.Transport)
+				for _, m := range sub.Match {
+					if m.ClientIP != nil {
+						if parsed.Maintenance == nil {
+							parsed.Maintenance = &MaintenancePage{}
+						}
+						parsed.Maintenance.AllowedIPs = append(parsed.Maintenance.AllowedIPs, m.ClientIP.Ranges...)
+					}
+				}
+			case "static_response":
+				headers := h.responseHeaders()
+				if values := headers["Location"]; len(values) > 0 {
+					redirect := Redirect{StatusCode: h.StatusCode}
+					if len(sub.Match) > 0 && len(sub.Match[0].Path) > 0 {
+						redirect.PathPrefix = sub.Match[0].Path[0]
+					}
+					if redirect.parseLocation(values[0]) {
+						parsed.Redirects = append(parsed.Redirects, redirect)
+					}
+					continue
+				}
+				switch h.StatusCode {
+				case 403:
+					for _, m := range sub.Match {
+						if m.ClientIP != nil {
+							access.DeniedIPs = append(access.DeniedIPs, m.ClientIP.Ranges...)
+						}
+						for _, not := range m.Not {
+							if not.ClientIP != nil {
+								access.AllowedIPs = append(access.AllowedIPs, not.ClientIP.Ranges...)
+							}
+						}
+					}
+				case 503:
+					if parsed.Maintenance == nil {
+						parsed.Maintenance = &MaintenancePage{}
+					}
+					parsed.Maintenance.HTMLBody = unescapePlaceholders(h.Body)
+					if values := headers["Retry-After"]; len(values) > 0 {
+						parsed.Maintenance.RetryAfterSeconds, _ = strconv.Atoi(values[0])
+					}
+				}
+			}
+		}
+	}
+	if len(access.AllowedIPs) > 0 || len(access.DeniedIPs) > 0 || len(access.BasicAuth) > 0 || access.ForwardAuth != nil {
+		parsed.Access = access
+	}
+	if !proxy.equal(nil) || proxy.Timeouts != (UpstreamTimeouts{}) {
+		parsed.Proxy = proxy
+	}
+	return parsed
+}
@@ -0,0 +1,251 @@
+package caddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// acmeIssuer is the part of an ACME issuer that tells a DNS-01 policy
+// written by Nixopus apart from other automation policies.
+type acmeIssuer struct {
+	Module     string `json:"module"`
+	Challenges *struct {
+		DNS *struct {
+			Provider json.RawMessage `json:"provider"`
+		} `json:"dns,omitempty"`
+	} `json:"challenges,omitempty"`
+}
+
+// isDNSChallengePolicy reports whether policy gets its certificates from
+// ACME with DNS-01 challenges.
+func isDNSChallengePolicy(policy *caddytls.AutomationPolicy) bool {
+	if policy.OnDemand || len(policy.IssuersRaw) != 1 {
+		return false
+	}
+	var issuer acmeIssuer
+	if err := json.Unmarshal(policy.IssuersRaw[0], &issuer); err != nil {
+		return false
+	}
+	return issuer.Module == "acme" && issuer.Challenges != nil && issuer.Challenges.DNS != nil
+}
+
+// dnsChallengeIssuer returns the ACME issuer that solves DNS-01 challenges
+// through provider.
+func dnsChallengeIssuer(provider shared_types.OrganizationDNSProvider) (json.RawMessage, error) {
+	fields := map[string]string{}
+	if provider.Credentials != "" {
+		if err := json.Unmarshal([]byte(provider.Credentials), &fields); err != nil {
+			return nil, fmt.Errorf("invalid credentials for dns provider %s: %w", provider.Name, err)
+		}
+	}
+	fields["name"] = string(provider.Provider)
+	return json.Marshal(map[string]any{
+		"module": "acme",
+		"challenges": map[string]any{
+			"dns": map[string]any{"provider": fields},
+		},
+	})
+}
+
+// DNSProviderFor returns the index of the provider whose zone is the
+// closest parent of domain, or -1 when no provider serves it. A wildcard
+// domain is served by the zone of its parent.
+func DNSProviderFor(providers []shared_types.OrganizationDNSProvider, domain string) int {
+	name := strings.ToLower(strings.TrimPrefix(domain, "*."))
+	match, matchLen := -1, 0
+	for i, p := range providers {
+		for _, zone := range p.Zones {
+			zone = strings.ToLower(zone)
+			if (name == zone || strings.HasSuffix(name, "."+zone)) && len(zone) > matchLen {
+				match, matchLen = i, len(zone)
+			}
+		}
+	}
+	return match
+}
+
+// ApplyDNSChallenges moves the certificates of the domains under a zone of
+// providers from on-demand HTTP challenges to DNS-01 challenges through
+// their provider, and back when no provider serves them anymore. It loads
+// the new config only when something changed.
+func ApplyDNSChallenges(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, providers []shared_types.OrganizationDNSProvider) error {
+	changed, err := updateConfig(ctx, sshClient, lgr, shared_types.CaddySnapshotDNSChallenges, func(config *caddy.Config) (bool, error) {
+		return applyDNSChallenges(config, providers)
+	})
+	if err != nil && changed {
+		return fmt.Errorf("failed to load dns challenge policies: %w", err)
+	}
+	return err
+}
+
+// storedDNSProviders returns the DNS providers of the organization in ctx.
+// ok is false when they could not be read, so callers leave the policies
+// in Caddy as they are.
+func storedDNSProviders(ctx context.Context, l logger.Logger) ([]shared_types.OrganizationDNSProvider, bool) {
+	orgID := orgIDFromContext(ctx)
+	if domainStore == nil || orgID == uuid.Nil {
+		return nil, false
+	}
+	providers, err := domainStore.GetOrganizationDNSProviders(orgID)
+	if err != nil {
+		l.Log(logger.Warning, "failed to read dns providers", err.Error())
+		return nil, false
+	}
+	return providers, true
+}
+
+// applyDNSChallenges rebuilds the DNS-01 automation policies of config
+// from providers and orders wildcard routes after exact hosts, so that a
+// domain with its own route is not shadowed by a wildcard. It reports
+// whether config changed.
+func applyDNSChallenges(config *caddy.Config, providers []shared_types.OrganizationDNSProvider) (bool, error) {
+	if config.AppsRaw == nil {
+		config.AppsRaw = make(caddy.ModuleMap)
+	}
+
+	httpChanged, err := orderWildcardRoutes(config)
+	if err != nil {
+		return false, err
+	}
+
+	var tlsApp caddytls.TLS
+	if raw, ok := config.AppsRaw["tls"]; ok {
+		if err := json.Unmarshal(raw, &tlsApp); err != nil {
+			return false, fmt.Errorf("failed to unmarshal tls app: %w", err)
+		}
+	}
+	before, err := json.Marshal(tlsApp)
+	if err != nil {
+		return false, err
+	}
+
+	var subjects []string
+	var kept []*caddytls.AutomationPolicy
+	var onDemand *caddytls.AutomationPolicy
+	if tlsApp.Automation != nil {
+		for _, policy := range tlsApp.Automation.Policies {
+			switch {
+			case isDNSChallengePolicy(policy):
+				subjects = append(subjects, policy.SubjectsRaw...)
+				continue
+			case policy.OnDemand && onDemand == nil:
+				onDemand = policy
+				subjects = append(subjects, policy.SubjectsRaw...)
+			}
+			kept = append(kept, policy)
+		}
+	}
+	if len(subjects) == 0 {
+		return httpChanged, nil
+	}
+
+	byProvider := make([][]string, len(providers))
+	var rest []string
+	for _, subject := range subjects {
+		if i := DNSProviderFor(providers, subject); i >= 0 {
+			if !slices.Contains(byProvider[i], subject) {
+				byProvider[i] = append(byProvider[i], subject)
+			}
+		} else if !slices.Contains(rest, subject) {
+			rest = append(rest, subject)
+		}
+	}
+
+	// Policies are matched in order, so the DNS-01 policies go first.
+	var policies []*caddytls.AutomationPolicy
+	for i, provider := range providers {
+		if len(byProvider[i]) == 0 {
+			continue
+		}
+		issuer, err := dnsChallengeIssuer(provider)
+		if err != nil {
+			return false, err
+		}
+		policies = append(policies, &caddytls.AutomationPolicy{
+			SubjectsRaw: byProvider[i],
+			IssuersRaw:  []json.RawMessage{issuer},
+			KeyType:     "p384",
+		})
+	}
+	if onDemand == nil && len(rest) > 0 {
+		onDemand = &caddytls.AutomationPolicy{OnDemand: true, KeyType: "p384"}
+		kept = append(kept, onDemand)
+	}
+	if onDemand != nil {
+		if rest == nil {
+			rest = []string{}
+		}
+		onDemand.SubjectsRaw = rest
+	}
+	tlsApp.Automation.Policies = append(policies, kept...)
+
+	after, err := json.Marshal(tlsApp)
+	if err != nil {
+		return false, err
+	}
+	if string(before) == string(after) {
+		return httpChanged, nil
+	}
+	config.AppsRaw["tls"] = after
+	return true, nil
+}
+
+// orderWildcardRoutes moves the wildcard host routes of the nixopus server
+// after its exact host routes. Routes without a host keep their position.
+func orderWildcardRoutes(config *caddy.Config) (bool, error) {
+	raw, ok := config.AppsRaw["http"]
+	if !ok {
+		return false, nil
+	}
+	var httpApp caddyhttp.App
+	if err := json.Unmarshal(raw, &httpApp); err != nil {
+		return false, fmt.Errorf("failed to unmarshal http app: %w", err)
+	}
+	server := httpApp.Servers["nixopus"]
+	if server == nil {
+		return false, nil
+	}
+
+	var slots []int
+	var exact, wildcard []caddyhttp.Route
+	for i, route := range server.Routes {
+		domain := extractDomainFromRoute(route)
+		switch {
+		case domain == "":
+			continue
+		case strings.HasPrefix(domain, "*."):
+			wildcard = append(wildcard, route)
+		default:
+			if len(wildcard) == 0 {
+				// Exact routes before the first wildcard are in place.
+				continue
+			}
+			exact = append(exact, route)
+		}
+		slots = append(slots, i)
+	}
+	if len(exact) == 0 {
+		return false, nil
+	}
+	for i, route := range append(exact, wildcard...) {
+		server.Routes[slots[i]] = route
+	}
+
+	httpRaw, err := json.Marshal(httpApp)
+	if err != nil {
+		return false, err
+	}
+	config.AppsRaw["http"] = httpRaw
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package caddy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestDNSProviderFor(t *testing.T) {
+	providers := []shared_types.OrganizationDNSProvider{
+		{Name: "apex", Zones: []string{"example.com"}},
+		{Name: "preview", Zones: []string{"preview.example.com"}},
+	}
+	tests := map[string]int{
+		"example.com":                0,
+		"app.example.com":            0,
+		"*.example.com":              0,
+		"a.preview.example.com":      1,
+		"*.preview.example.com":      1,
+		"preview.example.com":        1,
+		"notexample.com":             -1,
+		"app.example.org":            -1,
+		"*.PREVIEW.Example.com":      1,
+		"deep.a.preview.example.com": 1,
+		"example.com.attacker.net":   -1,
+	}
+	for domain, want := range tests {
+		if got := DNSProviderFor(providers, domain); got != want {
+			t.Errorf("DNSProviderFor(%q) = %d, want %d", domain, got, want)
+		}
+	}
+}
+
+func TestApplyDNSChallenges(t *testing.T) {
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[
+			{"match":[{"host":["*.preview.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31000"}]}],"terminal":true},
+			{"match":[{"host":["app.example.org"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31001"}]}],"terminal":true},
+			{"match":[{"host":["pr-1.preview.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31002"}]}],"terminal":true}
+		]}}}`),
+		"tls": json.RawMessage(`{"automation":{"policies":[
+			{"subjects":["*.preview.example.com","app.example.org","pr-1.preview.example.com"],"on_demand":true,"key_type":"p384"}
+		]}}`),
+	}}
+	providers := []shared_types.OrganizationDNSProvider{{
+		Name:        "cloudflare",
+		Provider:    shared_types.DNSProviderCloudflare,
+		Zones:       []string{"example.com"},
+		Credentials: `{"api_token":"secret"}`,
+	}}
+
+	changed, err := applyDNSChallenges(config, providers)
+	if err != nil {
+		t.Fatalf("applyDNSChallenges: %v", err)
+	}
+	if !changed {
+		t.Fatal("expected the config to change")
+	}
+
+	policies := tlsPolicies(t, config)
+	if len(policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(policies))
+	}
+	dns, onDemand := policies[0], policies[1]
+	if !isDNSChallengePolicy(dns) {
+		t.Fatalf("first policy should solve DNS-01 challenges: %s", dns.IssuersRaw)
+	}
+	if !slices.Equal(dns.SubjectsRaw, []string{"*.preview.example.com", "pr-1.preview.example.com"}) {
+		t.Errorf("dns policy subjects = %v", dns.SubjectsRaw)
+	}
+	var issuer struct {
+		Challenges struct {
+			DNS struct {
+				Provider map[string]string `json:"provider"`
+			} `json:"dns"`
+		} `json:"challenges"`
+	}
+	if err := json.Unmarshal(dns.IssuersRaw[0], &issuer); err != nil {
+		t.Fatalf("unmarshal issuer: %v", err)
+	}
+	if p := issuer.Challenges.DNS.Provider; p["name"] != "cloudflare" || p["api_token"] != "secret" {
+		t.Errorf("dns provider = %v", p)
+	}
+	if !onDemand.OnDemand || !slices.Equal(onDemand.SubjectsRaw, []string{"app.example.org"}) {
+		t.Errorf("on-demand policy = %+v", onDemand)
+	}
+
+	routes, err := extractDomainRoutes(config)
+	if err != nil {
+		t.Fatalf("extractDomainRoutes: %v", err)
+	}
+	var order []string
+	for _, r := range routes {
+		order = append(order, r.Domain)
+	}
+	if want := []string{"app.example.org", "pr-1.preview.example.com", "*.preview.example.com"}; !slices.Equal(order, want) {
+		t.Errorf("route order = %v, want %v", order, want)
+	}
+
+	if changed, err := applyDNSChallenges(config, providers); err != nil || changed {
+		t.Errorf("second apply changed = %v, err = %v", changed, err)
+	}
+
+	// Without providers the domains go back to on-demand certificates.
+	if _, err := applyDNSChallenges(config, nil); err != nil {
+		t.Fatalf("applyDNSChallenges without providers: %v", err)
+	}
+	policies = tlsPolicies(t, config)
+	if len(policies) != 1 || !policies[0].OnDemand || len(policies[0].SubjectsRaw) != 3 {
+		t.Errorf("policies without providers = %+v", policies)
+	}
+}
+
+func tlsPolicies(t *testing.T, config *caddy.Config) []*caddytls.AutomationPolicy {
+	t.Helper()
+	var tlsApp caddytls.TLS
+	if err := json.Unmarshal(config.AppsRaw["tls"], &tlsApp); err != nil {
+		t.Fatalf("unmarshal tls app: %v", err)
+	}
+	return tlsApp.Automation.Policies
+}
@@ -0,0 +1,140 @@
+package caddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// layer4ServerPrefix marks the layer4 servers managed by Nixopus. Servers
+// with other names are left untouched.
+const layer4ServerPrefix = "nixopus-l4-"
+
+// Layer4Route forwards a raw TCP or UDP port on the Caddy host to an upstream.
+// It requires a Caddy build that includes the layer4 app.
+type Layer4Route struct {
+	Protocol     string // tcp or udp
+	ListenPort   int
+	UpstreamDial string // host:port format
+}
+
+func (r Layer4Route) serverName() string {
+	return layer4ServerPrefix + r.Protocol + "-" + strconv.Itoa(r.ListenPort)
+}
+
+type layer4App struct {
+	Servers map[string]json.RawMessage `json:"servers,omitempty"`
+}
+
+type layer4Server struct {
+	Listen []string            `json:"listen"`
+	Routes []layer4ServerRoute `json:"routes"`
+}
+
+type layer4ServerRoute struct {
+	Handle []layer4Handler `json:"handle"`
+}
+
+type layer4Handler struct {
+	Handler   string           `json:"handler"`
+	Upstreams []layer4Upstream `json:"upstreams"`
+}
+
+type layer4Upstream struct {
+	Dial []string `json:"dial"`
+}
+
+func (r Layer4Route) server() layer4Server {
+	dial := r.UpstreamDial
+	if r.Protocol == "udp" {
+		dial = "udp/" + dial
+	}
+	return layer4Server{
+		Listen: []string{r.Protocol + "/:" + strconv.Itoa(r.ListenPort)},
+		Routes: []layer4ServerRoute{{
+			Handle: []layer4Handler{{
+				Handler:   "proxy",
+				Upstreams: []layer4Upstream{{Dial: []string{dial}}},
+			}},
+		}},
+	}
+}
+
+// applyLayer4Routes replaces the Nixopus managed servers of the layer4 app in
+// config with one server per route. It reports whether the config changed.
+func applyLayer4Routes(config *caddy.Config, routes []Layer4Route) (bool, error) {
+	var app layer4App
+	if raw, ok := config.AppsRaw["layer4"]; ok {
+		if err := json.Unmarshal(raw, &app); err != nil {
+			return false, fmt.Errorf("failed to decode layer4 app: %w", err)
+		}
+	}
+
+	current := make(map[string]layer4Server)
+	for name, raw := range app.Servers {
+		if !strings.HasPrefix(name, layer4ServerPrefix) {
+			continue
+		}
+		var srv layer4Server
+		if err := json.Unmarshal(raw, &srv); err != nil {
+			return false, fmt.Errorf("failed to decode layer4 server %s: %w", name, err)
+		}
+		current[name] = srv
+	}
+
+	desired := make(map[string]layer4Server, len(routes))
+	for _, r := range routes {
+		desired[r.serverName()] = r.server()
+	}
+	if reflect.DeepEqual(current, desired) {
+		return false, nil
+	}
+
+	servers := make(map[string]json.RawMessage)
+	for name, raw := range app.Servers {
+		if !strings.HasPrefix(name, layer4ServerPrefix) {
+			servers[name] = raw
+		}
+	}
+	for name, srv := range desired {
+		raw, err := json.Marshal(srv)
+		if err != nil {
+			return false, err
+		}
+		servers[name] = raw
+	}
+
+	if config.AppsRaw == nil {
+		config.AppsRaw = caddy.ModuleMap{}
+	}
+	if len(servers) == 0 {
+		delete(config.AppsRaw, "layer4")
+		return true, nil
+	}
+	raw, err := json.Marshal(layer4App{Servers: servers})
+	if err != nil {
+		return false, err
+	}
+	config.AppsRaw["layer4"] = raw
+	return true, nil
+}
+
+// SyncLayer4Routes makes the Nixopus managed layer4 servers match routes and
+// loads the new config when anything changed.
+func SyncLayer4Routes(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, routes []Layer4Route) (bool, error) {
+	changed, err := updateConfig(ctx, sshClient, lgr, shared_types.CaddySnapshotLayer4, func(config *caddy.Config) (bool, error) {
+		return applyLayer4Routes(config, routes)
+	})
+	if err != nil && changed {
+		return false, fmt.Errorf("failed to load layer4 routes: %w", err)
+	}
+	return changed, err
+}
@@ -0,0 +1,120 @@
+package caddy
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// DefaultMaintenanceHTML is served when a maintenance setting has no body.
+const DefaultMaintenanceHTML = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Down for maintenance</title></head>
+<body style="font-family: sans-serif; text-align: center; padding: 4rem;">
+<h1>Down for maintenance</h1>
+<p>We are making some improvements and will be back shortly.</p>
+</body>
+</html>
+`
+
+// MaintenancePage is the static response served by a domain in maintenance.
+type MaintenancePage struct {
+	HTMLBody          string
+	RetryAfterSeconds int
+	// AllowedIPs are the client IPs and CIDR ranges that still reach the
+	// upstream.
+	AllowedIPs []string
+}
+
+func (p *MaintenancePage) equal(other *MaintenancePage) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.HTMLBody == other.HTMLBody &&
+		p.RetryAfterSeconds == other.RetryAfterSeconds &&
+		slices.Equal(p.AllowedIPs, other.AllowedIPs)
+}
+
+// placeholderEscaper escapes braces so Caddy serves them as they are
+// instead of expanding placeholders such as {env.*} or {file.*}.
+var placeholderEscaper = strings.NewReplacer("{", `\{`, "}", `\}`)
+
+var placeholderUnescaper = strings.NewReplacer(`\{`, "{", `\}`, "}")
+
+func escapePlaceholders(s string) string {
+	return placeholderEscaper.Replace(s)
+}
+
+func unescapePlaceholders(s string) string {
+	return placeholderUnescaper.Replace(s)
+}
+
+// MaintenanceFromSetting converts a stored maintenance setting to the page
+// Caddy serves.
+func MaintenanceFromSetting(setting shared_types.ApplicationMaintenance) *MaintenancePage {
+	body := setting.HTMLBody
+	if body == "" {
+		body = DefaultMaintenanceHTML
+	}
+	return &MaintenancePage{
+		HTMLBody:          body,
+		RetryAfterSeconds: setting.RetryAfterSeconds,
+		AllowedIPs:        slices.Clone(setting.AllowedIPs),
+	}
+}
+
+// MaintenanceByDomain returns the maintenance page of every domain of the
+// organization that is in maintenance. A domain setting takes precedence
+// over the setting of its application.
+func MaintenanceByDomain(store storage.DeployRepository, organizationID uuid.UUID) (map[string]*MaintenancePage, error) {
+	settings, err := store.GetOrganizationMaintenance(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	type appSettings struct {
+		app     *shared_types.ApplicationMaintenance
+		domains map[string]shared_types.ApplicationMaintenance
+	}
+	byApp := make(map[uuid.UUID]*appSettings)
+	for _, s := range settings {
+		a := byApp[s.ApplicationID]
+		if a == nil {
+			a = &appSettings{domains: make(map[string]shared_types.ApplicationMaintenance)}
+			byApp[s.ApplicationID] = a
+		}
+		if s.Domain == "" {
+			a.app = &s
+		} else {
+			a.domains[strings.ToLower(s.Domain)] = s
+		}
+	}
+
+	pages := make(map[string]*MaintenancePage)
+	for appID, a := range byApp {
+		domains, err := store.GetApplicationDomains(appID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			name := strings.ToLower(d.Route())
+			setting, ok := a.domains[name]
+			if !ok {
+				if a.app == nil {
+					continue
+				}
+				setting = *a.app
+			}
+			if setting.Enabled {
+				pages[name] = MaintenanceFromSetting(setting)
+			}
+		}
+	}
+	return pages, nil
+}
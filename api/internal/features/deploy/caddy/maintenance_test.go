@@ -0,0 +1,94 @@
+package caddy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestMaintenanceRouteRoundTrip(t *testing.T) {
+	route := DomainRoute{
+		Domain:       "app.example.com",
+		UpstreamDial: "10.0.0.5:31000",
+		Maintenance: &MaintenancePage{
+			HTMLBody:          "<h1>Back soon</h1>",
+			RetryAfterSeconds: 120,
+			AllowedIPs:        []string{"203.0.113.7", "10.0.0.0/8"},
+		},
+	}
+
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[
+			{"match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31000"}]}],"terminal":true},
+			{"match":[{"host":["other.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31001"}]}],"terminal":true}
+		]}}}`),
+	}}
+	if err := applyCustomRoutes(config, []DomainRoute{route}); err != nil {
+		t.Fatalf("applyCustomRoutes: %v", err)
+	}
+
+	routes, err := extractDomainRoutes(config)
+	if err != nil {
+		t.Fatalf("extractDomainRoutes: %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	byDomain := map[string]DomainRoute{}
+	for _, r := range routes {
+		byDomain[r.Domain] = r
+	}
+
+	got := byDomain["app.example.com"]
+	if !route.matches(got) {
+		t.Errorf("maintenance route did not round trip: %+v %+v", got, got.Maintenance)
+	}
+	if other := byDomain["other.example.com"]; other.Maintenance != nil || other.UpstreamDial != "10.0.0.5:31001" {
+		t.Errorf("unrelated route changed: %+v", other)
+	}
+
+	plain := DomainRoute{Domain: "app.example.com", UpstreamDial: "10.0.0.5:31000"}
+	if plain.matches(got) {
+		t.Error("plain route should not match a maintenance route")
+	}
+}
+
+func TestMaintenanceRouteWithoutAllowlist(t *testing.T) {
+	page := &MaintenancePage{HTMLBody: DefaultMaintenanceHTML, RetryAfterSeconds: 300}
+	built, err := buildCustomRoute(DomainRoute{Domain: "app.example.com", UpstreamDial: "10.0.0.5:31000", Maintenance: page})
+	if err != nil {
+		t.Fatalf("buildCustomRoute: %v", err)
+	}
+	parsed, ok := parseCustomRoute(built)
+	if !ok || parsed.Maintenance == nil {
+		t.Fatal("expected a maintenance page")
+	}
+	if parsed.UpstreamDial != "" {
+		t.Errorf("expected no upstream without an allowlist, got %q", parsed.UpstreamDial)
+	}
+	desired := DomainRoute{Domain: "app.example.com", UpstreamDial: "10.0.0.9:32000", Maintenance: page}
+	if !desired.matches(DomainRoute{Domain: "app.example.com", Maintenance: parsed.Maintenance}) {
+		t.Error("upstream should not matter without an allowlist")
+	}
+}
+
+func TestMaintenanceBodyEscapesPlaceholders(t *testing.T) {
+	body := `<style>h1 { color: red; }</style><p>{file./config/caddy/autosave.json} {env.SECRET} \{</p>`
+	built, err := buildCustomRoute(DomainRoute{Domain: "app.example.com", Maintenance: &MaintenancePage{HTMLBody: body}})
+	if err != nil {
+		t.Fatalf("buildCustomRoute: %v", err)
+	}
+	raw, err := json.Marshal(built)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `\\{file.`) || !strings.Contains(string(raw), `\\{env.SECRET\\}`) {
+		t.Errorf("placeholders were not escaped: %s", raw)
+	}
+	parsed, ok := parseCustomRoute(built)
+	if !ok || parsed.Maintenance == nil || parsed.Maintenance.HTMLBody != body {
+		t.Errorf("maintenance body did not round trip: %+v", parsed.Maintenance)
+	}
+}
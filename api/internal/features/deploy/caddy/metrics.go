@@ -0,0 +1,135 @@
+package caddy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// requestsTotalMetric is the Caddy counter of handled HTTP requests. With
+// per-host metrics enabled it carries a host label.
+const requestsTotalMetric = "caddy_http_requests_total"
+
+// EnsurePerHostMetrics enables Caddy's HTTP metrics with a host label so
+// request counts can be read per domain. It is a no-op when already enabled.
+func EnsurePerHostMetrics(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger) error {
+	client, err := GetCaddyClient(ctx, sshClient, lgr)
+	if err != nil {
+		return fmt.Errorf("failed to get caddy client: %w", err)
+	}
+
+	resp, err := client.HTTPClient.Get(client.BaseURL + "/config/apps/http/metrics")
+	if err != nil {
+		return fmt.Errorf("failed to get caddy metrics config: %w", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 400 && strings.Contains(string(body), `"per_host":true`) {
+		return nil
+	}
+
+	recordSnapshot(ctx, domainStore, sshClient, lgr, nil, shared_types.CaddySnapshotMetrics)
+	resp, err = client.HTTPClient.Post(client.BaseURL+"/config/apps/http/metrics", "application/json", jsonReader([]byte(`{"per_host":true}`)))
+	if err != nil {
+		return fmt.Errorf("failed to enable caddy metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("enabling caddy metrics failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetHostRequestCounts scrapes the Caddy admin metrics endpoint and returns
+// the total number of requests handled per host.
+func GetHostRequestCounts(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger) (map[string]float64, error) {
+	client, err := GetCaddyClient(ctx, sshClient, lgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caddy client: %w", err)
+	}
+
+	resp, err := client.HTTPClient.Get(client.BaseURL + "/metrics")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caddy metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("caddy metrics request failed with status %d", resp.StatusCode)
+	}
+	return parseHostRequestCounts(resp.Body)
+}
+
+// parseHostRequestCounts sums caddy_http_requests_total samples of a
+// Prometheus text exposition by their host label. Hosts are lowercased.
+func parseHostRequestCounts(r io.Reader) (map[string]float64, error) {
+	counts := make(map[string]float64)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, requestsTotalMetric+"{") {
+			continue
+		}
+		end := strings.LastIndex(line, "}")
+		if end < 0 {
+			continue
+		}
+		host := metricLabel(line[len(requestsTotalMetric)+1:end], "host")
+		if host == "" {
+			continue
+		}
+		fields := strings.Fields(line[end+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		counts[strings.ToLower(host)] += value
+	}
+	return counts, scanner.Err()
+}
+
+// metricLabel returns the value of label name in a Prometheus label set such
+// as `host="example.com",server="srv0"`.
+func metricLabel(labels, name string) string {
+	for labels != "" {
+		eq := strings.Index(labels, `="`)
+		if eq < 0 {
+			return ""
+		}
+		key := strings.TrimSpace(labels[:eq])
+		rest := labels[eq+2:]
+
+		var value strings.Builder
+		i := 0
+		for ; i < len(rest); i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+				value.WriteByte(rest[i])
+				continue
+			}
+			if rest[i] == '"' {
+				break
+			}
+			value.WriteByte(rest[i])
+		}
+		if key == name {
+			return value.String()
+		}
+		if i >= len(rest) {
+			return ""
+		}
+		labels = strings.TrimPrefix(rest[i+1:], ",")
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package caddy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHostRequestCounts(t *testing.T) {
+	exposition := `# HELP caddy_http_requests_total Counter of HTTP(S) requests made.
+# TYPE caddy_http_requests_total counter
+caddy_http_requests_total{handler="reverse_proxy",host="App.example.com",server="nixopus"} 12
+caddy_http_requests_total{handler="subroute",host="app.example.com",server="nixopus"} 3
+caddy_http_requests_total{handler="reverse_proxy",host="api.example.com",server="nixopus"} 1.5e+01
+caddy_http_requests_total{handler="reverse_proxy",server="nixopus"} 99
+caddy_http_requests_in_flight{handler="reverse_proxy",host="app.example.com",server="nixopus"} 4
+`
+	counts, err := parseHostRequestCounts(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatalf("parseHostRequestCounts: %v", err)
+	}
+	if len(counts) != 2 {
+		t.Fatalf("expected 2 hosts, got %v", counts)
+	}
+	if counts["app.example.com"] != 15 {
+		t.Errorf("app.example.com = %v, want 15", counts["app.example.com"])
+	}
+	if counts["api.example.com"] != 15 {
+		t.Errorf("api.example.com = %v, want 15", counts["api.example.com"])
+	}
+}
+
+func TestMetricLabel(t *testing.T) {
+	labels := `handler="a\"b",host="x.example.com",server="nixopus"`
+	if got := metricLabel(labels, "host"); got != "x.example.com" {
+		t.Errorf("host = %q", got)
+	}
+	if got := metricLabel(labels, "handler"); got != `a"b` {
+		t.Errorf("handler = %q", got)
+	}
+	if got := metricLabel(labels, "missing"); got != "" {
+		t.Errorf("missing = %q", got)
+	}
+}
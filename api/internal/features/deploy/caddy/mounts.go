@@ -0,0 +1,128 @@
+package caddy
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// mergeMounts turns the routes with a path prefix into mounts of the route
+// of their domain, longest prefix first, so the most specific one takes a
+// request. A domain whose root path belongs to no application answers
+// everything outside its mounts with 404.
+func mergeMounts(routes []DomainRoute) []DomainRoute {
+	var merged []DomainRoute
+	hosts := make(map[string]int)
+	for _, r := range routes {
+		if r.PathPrefix != "" {
+			continue
+		}
+		if _, ok := hosts[strings.ToLower(r.Domain)]; !ok {
+			hosts[strings.ToLower(r.Domain)] = len(merged)
+		}
+		merged = append(merged, r)
+	}
+	for _, r := range routes {
+		if r.PathPrefix == "" {
+			continue
+		}
+		i, ok := hosts[strings.ToLower(r.Domain)]
+		if !ok {
+			i = len(merged)
+			hosts[strings.ToLower(r.Domain)] = i
+			merged = append(merged, DomainRoute{Domain: r.Domain})
+		}
+		merged[i].Mounts = append(merged[i].Mounts, r)
+	}
+	for i := range merged {
+		slices.SortFunc(merged[i].Mounts, func(a, b DomainRoute) int {
+			if c := cmp.Compare(len(b.PathPrefix), len(a.PathPrefix)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.PathPrefix, b.PathPrefix)
+		})
+	}
+	return merged
+}
+
+// mountSubroute serves the requests under the path prefix of mount with
+// the subroutes of mount, after stripping the prefix when asked to.
+func mountSubroute(mount DomainRoute) customSubroute {
+	sub := customSubroute{
+		Match: []customMatch{{Path: []string{mount.PathPrefix, mount.PathPrefix + "/*"}}},
+	}
+	if mount.StripPathPrefix {
+		sub.Handle = append(sub.Handle, customHandler{Handler: "rewrite", StripPathPrefix: mount.PathPrefix})
+	}
+	sub.Handle = append(sub.Handle, customHandler{Handler: "subroute", Routes: customSubroutes(mount)})
+	return sub
+}
+
+// parseMount reads back a subroute made by mountSubroute. It reports false
+// for any other subroute.
+func parseMount(sub customSubroute) (DomainRoute, bool) {
+	if len(sub.Match) != 1 || len(sub.Match[0].Path) == 0 {
+		return DomainRoute{}, false
+	}
+	var mount DomainRoute
+	var strip, found bool
+	for _, h := range sub.Handle {
+		switch h.Handler {
+		case "rewrite":
+			strip = h.StripPathPrefix != ""
+		case "subroute":
+			mount, found = parseSubroutes(h.Routes), true
+		}
+	}
+	if !found {
+		return DomainRoute{}, false
+	}
+	mount.StripPathPrefix = strip
+	mount.PathPrefix = sub.Match[0].Path[0]
+	return mount, true
+}
+
+// hostDomains returns the application domains the organization in ctx
+// stores under host, of every path prefix. Without a store or an
+// organization there are none.
+func hostDomains(ctx context.Context, host string) ([]shared_types.ApplicationDomain, error) {
+	orgID := orgIDFromContext(ctx)
+	if domainStore == nil || orgID == uuid.Nil {
+		return nil, nil
+	}
+	domains, err := domainStore.GetDomainsByHost(host, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the applications of %s: %w", host, err)
+	}
+	return domains, nil
+}
+
+func hasMounts(domains []shared_types.ApplicationDomain) bool {
+	return slices.ContainsFunc(domains, func(d shared_types.ApplicationDomain) bool {
+		return d.PathPrefix != ""
+	})
+}
+
+// reconcileMounts routes the domains of the organization in ctx through
+// the reconciler. Applications mounted under paths of one domain share a
+// single Caddy route, which only the reconciler sees all of.
+func reconcileMounts(ctx context.Context, l logger.Logger) error {
+	orgID := orgIDFromContext(ctx)
+	if domainStore == nil || orgID == uuid.Nil {
+		return fmt.Errorf("cannot route a shared domain without its organization")
+	}
+	result, err := NewReconciler(domainStore, l).ReconcileOrganization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		l.Log(logger.Warning, "proxy reconciliation reported errors", strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
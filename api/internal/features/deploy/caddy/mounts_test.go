@@ -0,0 +1,95 @@
+package caddy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestMergeMounts(t *testing.T) {
+	merged := mergeMounts([]DomainRoute{
+		{Domain: "example.com", PathPrefix: "/api", UpstreamDial: "10.0.0.5:31001"},
+		{Domain: "example.com", UpstreamDial: "10.0.0.5:31000"},
+		{Domain: "Example.com", PathPrefix: "/api/v2", StripPathPrefix: true, UpstreamDial: "10.0.0.5:31002"},
+		{Domain: "other.example.com", UpstreamDial: "10.0.0.5:31003"},
+		{Domain: "docs.example.com", PathPrefix: "/guide", UpstreamDial: "10.0.0.5:31004"},
+	})
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 routes, got %+v", merged)
+	}
+
+	root := merged[0]
+	if root.Domain != "example.com" || root.UpstreamDial != "10.0.0.5:31000" {
+		t.Errorf("root route = %+v", root)
+	}
+	var prefixes []string
+	for _, m := range root.Mounts {
+		prefixes = append(prefixes, m.PathPrefix)
+	}
+	if !slices.Equal(prefixes, []string{"/api/v2", "/api"}) {
+		t.Errorf("mounts should be longest prefix first, got %v", prefixes)
+	}
+	if !root.custom() {
+		t.Error("a route with mounts needs a custom route")
+	}
+
+	docs := merged[2]
+	if docs.Domain != "docs.example.com" || docs.UpstreamDial != "" || len(docs.Mounts) != 1 {
+		t.Errorf("a domain with only mounts should have no upstream, got %+v", docs)
+	}
+}
+
+func TestMountRouteRoundTrip(t *testing.T) {
+	routes := mergeMounts([]DomainRoute{
+		{Domain: "example.com", UpstreamDial: "10.0.0.5:31000", Redirects: []Redirect{{PathPrefix: "/old", StatusCode: 301, Path: "/new"}}},
+		{
+			Domain:          "example.com",
+			PathPrefix:      "/api",
+			StripPathPrefix: true,
+			UpstreamDial:    "10.0.0.5:31001",
+			Access:          &AccessPolicy{AllowedIPs: []string{"10.0.0.0/8"}},
+		},
+		{
+			Domain:       "example.com",
+			PathPrefix:   "/status",
+			UpstreamDial: "10.0.0.5:31002",
+			Maintenance:  &MaintenancePage{HTMLBody: DefaultMaintenanceHTML},
+		},
+	})
+
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[]}}}`),
+	}}
+	if err := applyCustomRoutes(config, routes); err != nil {
+		t.Fatalf("applyCustomRoutes: %v", err)
+	}
+
+	actual, err := extractDomainRoutes(config)
+	if err != nil {
+		t.Fatalf("extractDomainRoutes: %v", err)
+	}
+	if len(actual) != 1 {
+		t.Fatalf("expected one route for the shared domain, got %d", len(actual))
+	}
+	if !routes[0].matches(actual[0]) {
+		t.Errorf("route did not round trip: %+v", actual[0])
+	}
+	if actual[0].Mounts[0].Domain != "example.com" || !actual[0].Mounts[1].StripPathPrefix {
+		t.Errorf("mounts = %+v", actual[0].Mounts)
+	}
+
+	moved := routes[0]
+	moved.Mounts = slices.Clone(moved.Mounts)
+	moved.Mounts[1].UpstreamDial = "10.0.0.5:32000"
+	if moved.matches(actual[0]) {
+		t.Error("route with a moved mount should not match")
+	}
+	unstripped := routes[0]
+	unstripped.Mounts = slices.Clone(unstripped.Mounts)
+	unstripped.Mounts[1].StripPathPrefix = false
+	if unstripped.matches(actual[0]) {
+		t.Error("route with another strip setting should not match")
+	}
+}
@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
 	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
 	"github.com/raghavyuva/caddygo"
 )
 
@@ -18,25 +22,107 @@ import (
 type DomainRoute struct {
 	Domain       string
 	UpstreamDial string // host:port format
+	// Maintenance is set when the domain serves a maintenance page. Only
+	// its allowed IPs reach UpstreamDial, which may then be empty.
+	Maintenance *MaintenancePage
+	// Redirects are answered before the request reaches UpstreamDial. An
+	// alias domain only serves redirects and has no upstream.
+	Redirects []Redirect
+	// Access is checked before a request reaches UpstreamDial.
+	Access *AccessPolicy
+	// Proxy holds the headers, compression and limits of the domain.
+	Proxy *ProxySettings
+	// PathPrefix limits the route to the requests under it, with the
+	// prefix stripped first when StripPathPrefix is set. mergeMounts turns
+	// such routes into mounts of the route of their domain.
+	PathPrefix      string
+	StripPathPrefix bool
+	// Mounts are the routes of other applications under a path of the
+	// domain, longest prefix first. They take requests before the rest of
+	// the route.
+	Mounts []DomainRoute
+}
+
+// key returns the lowercase domain and path prefix the settings of route
+// are stored under.
+func (r DomainRoute) key() string {
+	return strings.ToLower(r.Domain + r.PathPrefix)
+}
+
+// domainStore reads the maintenance settings, redirects, access policies,
+// proxy settings, certificates and DNS providers of domain writes made
+// outside the reconciler. It is nil until SetDomainStore is called.
+var domainStore storage.DeployRepository
+
+// SetDomainStore lets AddDomainsWithRetry keep the maintenance pages,
+// redirects, access policies and custom or DNS-01 certificates of the
+// domains it updates, and find the domains shared by path mounts.
+func SetDomainStore(store storage.DeployRepository) {
+	domainStore = store
 }
 
 // AddDomainsWithRetry adds multiple domains to Caddy with retry and tunnel
 // recovery. All domains are added, then a single Reload is issued. On failure
-// the stale tunnel is invalidated before retry.
-func AddDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, domains []DomainRoute) error {
+// the stale tunnel is invalidated before retry. Domains with applications
+// mounted under their paths are routed by the reconciler instead.
+func AddDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, domains []DomainRoute) (err error) {
 	if len(domains) == 0 {
 		return nil
 	}
 
 	l := resolveLogger(lgr)
 
+	// Domains shared by applications mounted under paths are left to the
+	// reconciler, which routes all of them at once.
+	var plain []DomainRoute
+	mounted := false
+	for _, d := range domains {
+		host, pathPrefix, _ := shared_types.SplitDomainRoute(d.Domain + d.PathPrefix)
+		stored, err := hostDomains(ctx, host)
+		if err != nil {
+			return err
+		}
+		if pathPrefix != "" || hasMounts(stored) {
+			mounted = true
+			continue
+		}
+		plain = append(plain, d)
+	}
+	if mounted {
+		defer func() {
+			if err == nil {
+				err = reconcileMounts(ctx, l)
+			}
+		}()
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	domains = plain
+
+	recordSnapshot(ctx, domainStore, sshClient, lgr, nil, shared_types.CaddySnapshotAddDomains)
 	return WithRetry(func() error {
 		client, err := GetCaddyClient(ctx, sshClient, lgr)
 		if err != nil {
 			return fmt.Errorf("failed to get caddy client: %w", err)
 		}
 
+		// Domains with redirects, an access policy or in maintenance keep
+		// them, with the new upstream behind them. They never get a plain
+		// route, which would leave a protected domain open until replaced.
+		custom, err := withCustomRoutes(ctx, domains, l)
+		if err != nil {
+			return err
+		}
+		isCustom := make(map[string]bool, len(custom))
+		for _, d := range custom {
+			isCustom[d.Domain] = true
+		}
+
 		for _, d := range domains {
+			if isCustom[d.Domain] {
+				continue
+			}
 			host, port, parseErr := parseDial(d.UpstreamDial)
 			if parseErr != nil {
 				return fmt.Errorf("invalid upstream %s: %w", d.UpstreamDial, parseErr)
@@ -46,6 +132,21 @@ func AddDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Lo
 			}
 		}
 
+		if err := ApplyCustomRoutes(ctx, sshClient, lgr, custom); err != nil {
+			return err
+		}
+
+		if certificates, ok := storedCustomCertificates(ctx, l); ok {
+			if err := ApplyCustomCertificates(ctx, sshClient, lgr, certificates); err != nil {
+				return err
+			}
+		}
+		if providers, ok := storedDNSProviders(ctx, l); ok {
+			if err := ApplyDNSChallenges(ctx, sshClient, lgr, providers); err != nil {
+				return err
+			}
+		}
+
 		if err := client.Reload(); err != nil {
 			return fmt.Errorf("failed to reload caddy: %w", err)
 		}
@@ -56,20 +157,50 @@ func AddDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Lo
 }
 
 // RemoveDomainsWithRetry removes multiple domains from Caddy with retry.
-func RemoveDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, domains []string) error {
+// A domain followed by a path prefix, or one that other applications are
+// still mounted on, is routed again by the reconciler instead of removed.
+func RemoveDomainsWithRetry(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, domains []string) (err error) {
 	if len(domains) == 0 {
 		return nil
 	}
 
 	l := resolveLogger(lgr)
 
+	var removed []string
+	mounted := false
+	for _, domain := range domains {
+		host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
+		stored, err := hostDomains(ctx, host)
+		if err != nil {
+			return err
+		}
+		if (pathPrefix != "" || hasMounts(stored)) && len(stored) > 0 {
+			mounted = true
+			continue
+		}
+		if !slices.Contains(removed, host) {
+			removed = append(removed, host)
+		}
+	}
+	if mounted {
+		defer func() {
+			if err == nil {
+				err = reconcileMounts(ctx, l)
+			}
+		}()
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	recordSnapshot(ctx, domainStore, sshClient, lgr, nil, shared_types.CaddySnapshotRemoveDomains)
 	return WithRetry(func() error {
 		client, err := GetCaddyClient(ctx, sshClient, lgr)
 		if err != nil {
 			return fmt.Errorf("failed to get caddy client: %w", err)
 		}
 
-		for _, domain := range domains {
+		for _, domain := range removed {
 			if err := client.DeleteDomain(domain); err != nil {
 				return fmt.Errorf("failed to remove domain %s: %w", domain, err)
 			}
@@ -265,6 +396,15 @@ func extractDomainRoutes(config *caddy.Config) ([]DomainRoute, error) {
 			continue
 		}
 
+		if custom, ok := parseCustomRoute(route); ok {
+			custom.Domain = domain
+			for i := range custom.Mounts {
+				custom.Mounts[i].Domain = domain
+			}
+			routes = append(routes, custom)
+			continue
+		}
+
 		upstream := extractUpstreamFromRoute(route)
 		if upstream == "" {
 			continue
@@ -0,0 +1,233 @@
+package caddy
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// rateLimitKey identifies clients for the rate_limit handler of the
+// caddy-ratelimit module. The client IP honours trusted proxies, so clients
+// behind a load balancer are not limited as one.
+const rateLimitKey = "{http.request.client_ip}"
+
+// ProxySettings are the headers, compression and limits Caddy applies to
+// the requests of a domain.
+type ProxySettings struct {
+	SetHeaders    map[string]string
+	RemoveHeaders []string
+	Encodings     []string
+	// MaxRequestBodyBytes rejects larger request bodies with 413.
+	MaxRequestBodyBytes int64
+	// RateLimitRequests per RateLimitWindow are allowed to each client IP.
+	RateLimitRequests int
+	RateLimitWindow   time.Duration
+	// Timeouts only apply to requests that reach the upstream.
+	Timeouts UpstreamTimeouts
+}
+
+// UpstreamTimeouts bound connecting to the upstream and waiting for its
+// response headers.
+type UpstreamTimeouts struct {
+	Dial           time.Duration
+	ResponseHeader time.Duration
+}
+
+// equal compares the settings that apply to every request of a domain. A
+// nil p stands for no settings.
+func (p *ProxySettings) equal(other *ProxySettings) bool {
+	var a, b ProxySettings
+	if p != nil {
+		a = *p
+	}
+	if other != nil {
+		b = *other
+	}
+	return maps.Equal(a.SetHeaders, b.SetHeaders) &&
+		slices.Equal(a.RemoveHeaders, b.RemoveHeaders) &&
+		slices.Equal(a.Encodings, b.Encodings) &&
+		a.MaxRequestBodyBytes == b.MaxRequestBodyBytes &&
+		a.RateLimitRequests == b.RateLimitRequests &&
+		a.RateLimitWindow == b.RateLimitWindow
+}
+
+func (p *ProxySettings) timeouts() UpstreamTimeouts {
+	if p == nil {
+		return UpstreamTimeouts{}
+	}
+	return p.Timeouts
+}
+
+// ProxyFromSettings converts the stored proxy settings of an application to
+// the settings Caddy applies. It returns nil when they change nothing.
+func ProxyFromSettings(settings shared_types.ApplicationProxySettings) *ProxySettings {
+	proxy := &ProxySettings{
+		Encodings:           slices.Clone(settings.Encodings),
+		MaxRequestBodyBytes: settings.MaxRequestBodyBytes,
+		Timeouts: UpstreamTimeouts{
+			Dial:           time.Duration(settings.DialTimeoutSeconds) * time.Second,
+			ResponseHeader: time.Duration(settings.ResponseTimeoutSeconds) * time.Second,
+		},
+	}
+	if len(settings.ResponseHeaders) > 0 {
+		proxy.SetHeaders = make(map[string]string, len(settings.ResponseHeaders))
+		for name, value := range settings.ResponseHeaders {
+			proxy.SetHeaders[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	for _, name := range settings.RemoveResponseHeaders {
+		proxy.RemoveHeaders = append(proxy.RemoveHeaders, http.CanonicalHeaderKey(name))
+	}
+	slices.Sort(proxy.RemoveHeaders)
+	if settings.RateLimitRequests > 0 && settings.RateLimitWindowSeconds > 0 {
+		proxy.RateLimitRequests = settings.RateLimitRequests
+		proxy.RateLimitWindow = time.Duration(settings.RateLimitWindowSeconds) * time.Second
+	}
+	if proxy.equal(nil) && proxy.Timeouts == (UpstreamTimeouts{}) {
+		return nil
+	}
+	return proxy
+}
+
+// ProxyByDomain returns the proxy settings of every domain of the
+// organization's applications that have any.
+func ProxyByDomain(store storage.DeployRepository, organizationID uuid.UUID) (map[string]*ProxySettings, error) {
+	all, err := store.GetOrganizationProxySettings(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	proxies := make(map[string]*ProxySettings)
+	for _, settings := range all {
+		proxy := ProxyFromSettings(settings)
+		if proxy == nil {
+			continue
+		}
+		domains, err := store.GetApplicationDomains(settings.ApplicationID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			proxies[strings.ToLower(d.Route())] = proxy
+		}
+	}
+	return proxies, nil
+}
+
+// settingsSubroute returns a route that wraps every later route of domain
+// in the rate limit, body limit, headers and encoding of proxy, or false
+// when proxy sets none of them.
+func settingsSubroute(domain string, proxy *ProxySettings) (customSubroute, bool) {
+	var handlers []customHandler
+	if proxy.RateLimitRequests > 0 {
+		handlers = append(handlers, customHandler{
+			Handler: "rate_limit",
+			RateLimits: map[string]customRateLimit{
+				"nixopus_" + domain: {
+					Key:       rateLimitKey,
+					Window:    proxy.RateLimitWindow.String(),
+					MaxEvents: proxy.RateLimitRequests,
+				},
+			},
+		})
+	}
+	if proxy.MaxRequestBodyBytes > 0 {
+		handlers = append(handlers, customHandler{Handler: "request_body", MaxSize: proxy.MaxRequestBodyBytes})
+	}
+	if len(proxy.SetHeaders) > 0 || len(proxy.RemoveHeaders) > 0 {
+		response := &customHeaderOps{Delete: proxy.RemoveHeaders, Deferred: true}
+		if len(proxy.SetHeaders) > 0 {
+			response.Set = make(map[string][]string, len(proxy.SetHeaders))
+			for name, value := range proxy.SetHeaders {
+				response.Set[name] = []string{value}
+			}
+		}
+		handlers = append(handlers, customHandler{Handler: "headers", Response: response})
+	}
+	if len(proxy.Encodings) > 0 {
+		encodings := make(map[string]json.RawMessage, len(proxy.Encodings))
+		for _, name := range proxy.Encodings {
+			encodings[name] = json.RawMessage("{}")
+		}
+		handlers = append(handlers, customHandler{Handler: "encode", Encodings: encodings, Prefer: proxy.Encodings})
+	}
+	if len(handlers) == 0 {
+		return customSubroute{}, false
+	}
+	return customSubroute{Handle: handlers}, true
+}
+
+// upstreamTransport returns the transport of the upstream reverse proxy,
+// or nil for the defaults.
+func upstreamTransport(proxy *ProxySettings) *customTransport {
+	timeouts := proxy.timeouts()
+	if timeouts == (UpstreamTimeouts{}) {
+		return nil
+	}
+	transport := &customTransport{Protocol: "http"}
+	if timeouts.Dial > 0 {
+		transport.DialTimeout = timeouts.Dial.String()
+	}
+	if timeouts.ResponseHeader > 0 {
+		transport.ResponseHeaderTimeout = timeouts.ResponseHeader.String()
+	}
+	return transport
+}
+
+// parseSettingsHandler reads back a handler made by settingsSubroute into
+// proxy. It reports false for other handlers.
+func parseSettingsHandler(h customHandler, proxy *ProxySettings) bool {
+	switch h.Handler {
+	case "rate_limit":
+		for _, limit := range h.RateLimits {
+			proxy.RateLimitRequests = limit.MaxEvents
+			proxy.RateLimitWindow = parseDuration(limit.Window)
+		}
+	case "request_body":
+		proxy.MaxRequestBodyBytes = h.MaxSize
+	case "encode":
+		proxy.Encodings = slices.Clone(h.Prefer)
+	case "headers":
+		if h.Response == nil {
+			return false
+		}
+		for name, values := range h.Response.Set {
+			if proxy.SetHeaders == nil {
+				proxy.SetHeaders = make(map[string]string)
+			}
+			proxy.SetHeaders[name] = strings.Join(values, ", ")
+		}
+		proxy.RemoveHeaders = slices.Sorted(slices.Values(h.Response.Delete))
+	default:
+		return false
+	}
+	return true
+}
+
+// parseTransport reads the timeouts of an upstream transport back.
+func parseTransport(transport *customTransport) UpstreamTimeouts {
+	if transport == nil {
+		return UpstreamTimeouts{}
+	}
+	return UpstreamTimeouts{
+		Dial:           parseDuration(transport.DialTimeout),
+		ResponseHeader: parseDuration(transport.ResponseHeaderTimeout),
+	}
+}
+
+// parseDuration reads a duration written by settingsSubroute or
+// upstreamTransport. An empty value is zero.
+func parseDuration(value string) time.Duration {
+	d, _ := time.ParseDuration(value)
+	return d
+}
@@ -0,0 +1,98 @@
+package caddy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestProxyFromSettings(t *testing.T) {
+	if proxy := ProxyFromSettings(shared_types.ApplicationProxySettings{RateLimitWindowSeconds: 60}); proxy != nil {
+		t.Errorf("settings that change nothing should give nil, got %+v", proxy)
+	}
+
+	proxy := ProxyFromSettings(shared_types.ApplicationProxySettings{
+		ResponseHeaders:        map[string]string{"strict-transport-security": "max-age=31536000"},
+		RemoveResponseHeaders:  []string{"x-powered-by", "Server"},
+		RateLimitRequests:      100,
+		RateLimitWindowSeconds: 60,
+		DialTimeoutSeconds:     5,
+	})
+	if proxy == nil {
+		t.Fatal("expected proxy settings")
+	}
+	if proxy.SetHeaders["Strict-Transport-Security"] != "max-age=31536000" {
+		t.Errorf("headers = %v", proxy.SetHeaders)
+	}
+	if !slices.Equal(proxy.RemoveHeaders, []string{"Server", "X-Powered-By"}) {
+		t.Errorf("removed headers = %v", proxy.RemoveHeaders)
+	}
+	if proxy.RateLimitWindow != time.Minute || proxy.Timeouts.Dial != 5*time.Second {
+		t.Errorf("limits = %+v", proxy)
+	}
+}
+
+func TestProxySettingsRouteRoundTrip(t *testing.T) {
+	settings := &ProxySettings{
+		SetHeaders:          map[string]string{"Content-Security-Policy": "default-src 'self'"},
+		RemoveHeaders:       []string{"Server"},
+		Encodings:           []string{"zstd", "gzip"},
+		MaxRequestBodyBytes: 10 << 20,
+		RateLimitRequests:   50,
+		RateLimitWindow:     30 * time.Second,
+		Timeouts:            UpstreamTimeouts{Dial: 5 * time.Second, ResponseHeader: time.Minute},
+	}
+	routes := []DomainRoute{
+		{Domain: "app.example.com", UpstreamDial: "10.0.0.5:31000", Proxy: settings},
+		{
+			Domain:       "admin.example.com",
+			UpstreamDial: "10.0.0.5:31000",
+			Proxy:        settings,
+			Access:       &AccessPolicy{AllowedIPs: []string{"10.0.0.0/8"}},
+		},
+		{
+			Domain:       "down.example.com",
+			UpstreamDial: "10.0.0.5:31000",
+			Proxy:        &ProxySettings{Encodings: []string{"gzip"}, Timeouts: UpstreamTimeouts{Dial: time.Second}},
+			Maintenance:  &MaintenancePage{HTMLBody: DefaultMaintenanceHTML, RetryAfterSeconds: 60},
+		},
+	}
+
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[]}}}`),
+	}}
+	if err := applyCustomRoutes(config, routes); err != nil {
+		t.Fatalf("applyCustomRoutes: %v", err)
+	}
+
+	actual, err := extractDomainRoutes(config)
+	if err != nil {
+		t.Fatalf("extractDomainRoutes: %v", err)
+	}
+	if len(actual) != len(routes) {
+		t.Fatalf("expected %d routes, got %d", len(routes), len(actual))
+	}
+	for _, want := range routes {
+		idx := slices.IndexFunc(actual, func(r DomainRoute) bool { return r.Domain == want.Domain })
+		if idx < 0 {
+			t.Fatalf("route %s not found", want.Domain)
+		}
+		if !want.matches(actual[idx]) {
+			t.Errorf("route %s did not round trip: %+v", want.Domain, actual[idx].Proxy)
+		}
+	}
+
+	idx := slices.IndexFunc(actual, func(r DomainRoute) bool { return r.Domain == "app.example.com" })
+	slower := *settings
+	slower.Timeouts.ResponseHeader = 2 * time.Minute
+	if (DomainRoute{Domain: "app.example.com", UpstreamDial: "10.0.0.5:31000", Proxy: &slower}).matches(actual[idx]) {
+		t.Error("route with other timeouts should not match")
+	}
+	if (DomainRoute{Domain: "app.example.com", UpstreamDial: "10.0.0.5:31000"}).matches(actual[idx]) {
+		t.Error("route without proxy settings should not match")
+	}
+}
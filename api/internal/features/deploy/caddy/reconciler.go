@@ -3,12 +3,16 @@ package caddy
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
@@ -81,25 +85,41 @@ func (r *Reconciler) ReconcileOrganization(ctx context.Context, organizationID u
 		return nil, fmt.Errorf("failed to build desired state: %w", err)
 	}
 
-	actual, err := GetCurrentDomains(orgCtx, nil, &r.Logger)
+	config, err := GetCaddyConfig(orgCtx, nil, &r.Logger)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read caddy state, attempting full rebuild", err.Error())
+		return r.fullRebuild(orgCtx, desired)
+	}
+	// Recorded before any change below. Later writes of the certificates,
+	// DNS challenges and layer4 routes record their own snapshots.
+	recordSnapshot(orgCtx, r.Storage, nil, &r.Logger, config, shared_types.CaddySnapshotReconcile)
+	actual, err := extractDomainRoutes(config)
 	if err != nil {
 		r.Logger.Log(logger.Warning, "failed to read caddy state, attempting full rebuild", err.Error())
 		return r.fullRebuild(orgCtx, desired)
 	}
 
-	actualMap := make(map[string]string)
+	actualMap := make(map[string]DomainRoute)
 	for _, route := range actual {
-		actualMap[route.Domain] = route.UpstreamDial
+		actualMap[route.Domain] = route
 	}
 
 	var toAdd []DomainRoute
 	var toUpdate []DomainRoute
+	var custom []DomainRoute
 
 	for _, route := range desired {
-		actualDial, exists := actualMap[route.Domain]
-		if !exists {
+		actualRoute, exists := actualMap[route.Domain]
+		switch {
+		case exists && route.matches(actualRoute):
+			continue
+		case route.custom():
+			// Redirects and maintenance pages are written in one config
+			// load below.
+			custom = append(custom, route)
+		case !exists:
 			toAdd = append(toAdd, route)
-		} else if actualDial != route.UpstreamDial {
+		default:
 			toUpdate = append(toUpdate, route)
 		}
 	}
@@ -111,7 +131,7 @@ func (r *Reconciler) ReconcileOrganization(ctx context.Context, organizationID u
 		r.Logger.Log(logger.Warning, "failed to read pending removals", pendingErr.Error())
 	}
 
-	needsReload := len(toAdd) > 0 || len(toUpdate) > 0 || len(pendingRemovals) > 0
+	needsReload := len(toAdd) > 0 || len(toUpdate) > 0 || len(custom) > 0 || len(pendingRemovals) > 0
 
 	if needsReload {
 		client, err := GetCaddyClient(orgCtx, nil, &r.Logger)
@@ -145,6 +165,14 @@ func (r *Reconciler) ReconcileOrganization(ctx context.Context, organizationID u
 			}
 		}
 
+		if err := ApplyCustomRoutes(orgCtx, nil, &r.Logger, custom); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to apply custom routes: %v", err))
+		} else {
+			for _, route := range custom {
+				result.Updated = append(result.Updated, route.Domain)
+			}
+		}
+
 		for _, domain := range pendingRemovals {
 			if err := client.DeleteDomain(domain); err != nil {
 				result.Errors = append(result.Errors, fmt.Sprintf("pending removal failed for %s: %v", domain, err))
@@ -161,6 +189,10 @@ func (r *Reconciler) ReconcileOrganization(ctx context.Context, organizationID u
 		}
 	}
 
+	r.applyCustomCertificates(orgCtx, organizationID, result)
+	r.applyDNSChallenges(orgCtx, organizationID, result)
+	r.reconcileLayer4(orgCtx, organizationID, result)
+
 	r.Logger.Log(logger.Info,
 		fmt.Sprintf("reconciliation complete: added=%d updated=%d removed=%d errors=%d",
 			len(result.Added), len(result.Updated), len(result.Removed), len(result.Errors)),
@@ -169,6 +201,136 @@ func (r *Reconciler) ReconcileOrganization(ctx context.Context, organizationID u
 	return result, nil
 }
 
+// applyCustomCertificates serves the organization's uploaded certificates.
+// It runs before applyDNSChallenges, which then picks up the domains of
+// removed certificates.
+func (r *Reconciler) applyCustomCertificates(ctx context.Context, organizationID uuid.UUID, result *ReconcileResult) {
+	certificates, err := r.Storage.GetOrganizationCertificates(organizationID)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to read custom certificates: %v", err))
+		return
+	}
+	if err := ApplyCustomCertificates(ctx, nil, &r.Logger, CustomCertificatesFrom(certificates)); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to apply custom certificates: %v", err))
+	}
+}
+
+// applyDNSChallenges gets the certificates of the organization's domains
+// through its DNS providers. Provider changes do not change any route, so
+// this runs on every reconciliation.
+func (r *Reconciler) applyDNSChallenges(ctx context.Context, organizationID uuid.UUID, result *ReconcileResult) {
+	providers, err := r.Storage.GetOrganizationDNSProviders(organizationID)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to read dns providers: %v", err))
+		return
+	}
+	if err := ApplyDNSChallenges(ctx, nil, &r.Logger, providers); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to apply dns challenges: %v", err))
+	}
+}
+
+// reconcileLayer4 routes the organization's proxied TCP/UDP ports through the
+// Caddy layer4 app of the server each port is published on, dialing that
+// server. Unlike domains, every layer4 server named by Nixopus is owned by
+// it, so ports removed from the DB are removed from Caddy too, which is why
+// servers left without ports are synced as well.
+func (r *Reconciler) reconcileLayer4(ctx context.Context, organizationID uuid.UUID, result *ReconcileResult) {
+	ports, err := r.Storage.GetProxiedApplicationPorts(organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read proxied application ports", err.Error())
+		return
+	}
+	servers, err := r.Storage.GetActiveServerIDs(organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read servers", err.Error())
+		return
+	}
+	defaultServer, err := r.Storage.GetDefaultServerID(organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read default server", err.Error())
+		return
+	}
+
+	byServer := make(map[uuid.UUID][]shared_types.ApplicationPort)
+	appServers := make(map[uuid.UUID][]uuid.UUID)
+	for _, p := range ports {
+		for _, serverID := range r.portServers(p, defaultServer, appServers) {
+			byServer[serverID] = append(byServer[serverID], p)
+		}
+	}
+
+	for _, serverID := range servers {
+		r.syncServerLayer4(ctx, organizationID, serverID, byServer[serverID], result)
+	}
+}
+
+// portServers returns the servers port is published on: its own server, or
+// every server of its application, which is the default server when none
+// are assigned. appServers caches the servers of each application.
+func (r *Reconciler) portServers(port shared_types.ApplicationPort, defaultServer uuid.UUID, appServers map[uuid.UUID][]uuid.UUID) []uuid.UUID {
+	if port.ServerID != nil {
+		return []uuid.UUID{*port.ServerID}
+	}
+	ids, ok := appServers[port.ApplicationID]
+	if !ok {
+		servers, err := r.Storage.GetApplicationServers(port.ApplicationID)
+		if err != nil {
+			r.Logger.Log(logger.Warning, "failed to read application servers", err.Error())
+		}
+		for _, s := range servers {
+			ids = append(ids, s.ServerID)
+		}
+		if len(ids) == 0 {
+			ids = []uuid.UUID{defaultServer}
+		}
+		appServers[port.ApplicationID] = ids
+	}
+	return ids
+}
+
+// syncServerLayer4 writes the layer4 routes of ports to the Caddy of a
+// server, dialing the host port each one was published on on that server.
+// Ports not deployed on the server yet are left out.
+func (r *Reconciler) syncServerLayer4(ctx context.Context, organizationID uuid.UUID, serverID uuid.UUID, ports []shared_types.ApplicationPort, result *ReconcileResult) {
+	manager, err := ssh.GetSSHManagerForServer(ctx, organizationID, serverID)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("layer4 sync of server %s failed: %v", serverID, err))
+		return
+	}
+	sshClient, err := manager.GetDefaultSSH()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("layer4 sync of server %s failed: %v", serverID, err))
+		return
+	}
+	upstreamHost, err := manager.GetUpstreamHost()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("layer4 sync of server %s failed: %v", serverID, err))
+		return
+	}
+
+	routes := make([]Layer4Route, 0, len(ports))
+	for _, p := range ports {
+		upstreamPort := p.UpstreamPortOn(serverID)
+		if upstreamPort == 0 {
+			continue
+		}
+		routes = append(routes, Layer4Route{
+			Protocol:     string(p.Protocol),
+			ListenPort:   p.PublishedPort,
+			UpstreamDial: FormatDial(upstreamHost, upstreamPort),
+		})
+	}
+
+	changed, err := SyncLayer4Routes(ctx, sshClient, &r.Logger, routes)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("layer4 sync of server %s failed: %v", serverID, err))
+		return
+	}
+	if changed {
+		result.Updated = append(result.Updated, fmt.Sprintf("layer4 on %s (%d ports)", sshClient.Host, len(routes)))
+	}
+}
+
 func (r *Reconciler) buildDesiredState(ctx context.Context, organizationID uuid.UUID, upstreamHost string) ([]DomainRoute, error) {
 	var desired []DomainRoute
 
@@ -196,6 +358,17 @@ func (r *Reconciler) buildDeployDomains(ctx context.Context, organizationID uuid
 		return nil, fmt.Errorf("failed to get deployed apps: %w", err)
 	}
 
+	// Applications scaled to zero route to the wake listener, which scales
+	// them back up on the first request.
+	wakeDial := config.AppConfig.Proxy.WakeDial
+	var sleeping map[uuid.UUID]bool
+	if wakeDial != "" {
+		sleeping, err = r.Storage.GetSleepingApplicationIDs(organizationID)
+		if err != nil {
+			r.Logger.Log(logger.Warning, "failed to get sleeping applications", err.Error())
+		}
+	}
+
 	var routes []DomainRoute
 
 	for _, app := range apps {
@@ -203,16 +376,156 @@ func (r *Reconciler) buildDeployDomains(ctx context.Context, organizationID uuid
 			continue
 		}
 
-		if app.BuildPack == shared_types.DockerCompose {
-			routes = append(routes, r.buildComposeRoutes(app, upstreamHost)...)
-		} else {
-			routes = append(routes, r.buildSwarmRoutes(ctx, app, upstreamHost)...)
+		if sleeping[app.ID] && !app.IsComposeStack() && app.BuildPack != shared_types.DockerCompose {
+			for _, d := range app.Domains {
+				if d.Domain != "" {
+					routes = append(routes, domainRoute(d, wakeDial))
+				}
+			}
+			continue
+		}
+
+		appCtx, appHost := r.applicationServer(ctx, app, upstreamHost)
+		switch {
+		case app.IsComposeStack():
+			routes = append(routes, r.buildStackRoutes(appCtx, app, appHost)...)
+		case app.BuildPack == shared_types.DockerCompose:
+			routes = append(routes, r.buildComposeRoutes(app, appHost)...)
+		default:
+			routes = append(routes, r.buildSwarmRoutes(appCtx, app.Name, app.Domains, appHost)...)
 		}
 	}
 
+	routes = r.applyMaintenance(organizationID, apps, routes)
+	routes = r.applyRedirects(organizationID, routes)
+	routes = r.applyProxySettings(organizationID, routes)
+	routes, err = r.applyAccess(organizationID, routes)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMounts(routes), nil
+}
+
+// domainRoute returns the route of an application domain to dial.
+func domainRoute(d *shared_types.ApplicationDomain, dial string) DomainRoute {
+	return DomainRoute{
+		Domain:          d.Domain,
+		PathPrefix:      d.PathPrefix,
+		StripPathPrefix: d.StripPathPrefix,
+		UpstreamDial:    dial,
+	}
+}
+
+// applyMaintenance sets the maintenance page of the routes whose domain is
+// in maintenance. Domains in maintenance whose upstream could not be
+// resolved, such as a stopped service, still get their maintenance page.
+func (r *Reconciler) applyMaintenance(organizationID uuid.UUID, apps []shared_types.Application, routes []DomainRoute) []DomainRoute {
+	pages, err := MaintenanceByDomain(r.Storage, organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read maintenance settings", err.Error())
+		return routes
+	}
+	if len(pages) == 0 {
+		return routes
+	}
+
+	routed := make(map[string]bool, len(routes))
+	for i := range routes {
+		name := routes[i].key()
+		routes[i].Maintenance = pages[name]
+		routed[name] = true
+	}
+	for _, app := range apps {
+		for _, d := range app.Domains {
+			name := strings.ToLower(d.Route())
+			if page := pages[name]; page != nil && !routed[name] {
+				route := domainRoute(d, "")
+				route.Maintenance = page
+				routes = append(routes, route)
+				routed[name] = true
+			}
+		}
+	}
+	return routes
+}
+
+// applyProxySettings sets the proxy settings of the routes whose
+// application has any.
+func (r *Reconciler) applyProxySettings(organizationID uuid.UUID, routes []DomainRoute) []DomainRoute {
+	proxies, err := ProxyByDomain(r.Storage, organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read proxy settings", err.Error())
+		return routes
+	}
+	for i := range routes {
+		routes[i].Proxy = proxies[routes[i].key()]
+	}
+	return routes
+}
+
+// applyAccess sets the access policy of the routes whose domain is
+// protected. Unlike redirects, a policy that cannot be read fails the
+// reconcile, so a protected domain is never served unguarded.
+func (r *Reconciler) applyAccess(organizationID uuid.UUID, routes []DomainRoute) ([]DomainRoute, error) {
+	access, err := AccessByDomain(r.Storage, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access policies: %w", err)
+	}
+	for i := range routes {
+		routes[i].Access = access[routes[i].key()]
+	}
 	return routes, nil
 }
 
+// applyRedirects sets the redirects of the routes whose domain has any, and
+// adds a route for every alias domain, which only serves its redirects.
+func (r *Reconciler) applyRedirects(organizationID uuid.UUID, routes []DomainRoute) []DomainRoute {
+	set, err := RedirectsByDomain(r.Storage, organizationID)
+	if err != nil {
+		r.Logger.Log(logger.Warning, "failed to read redirects", err.Error())
+		return routes
+	}
+	if len(set.ByDomain) == 0 {
+		return routes
+	}
+
+	routed := make(map[string]bool, len(routes))
+	for i := range routes {
+		name := routes[i].key()
+		routes[i].Redirects = set.ByDomain[name]
+		routed[name] = true
+	}
+	for _, alias := range slices.Sorted(maps.Keys(set.Aliases)) {
+		if !routed[alias] {
+			routes = append(routes, DomainRoute{Domain: alias, Redirects: set.ByDomain[alias]})
+		}
+	}
+	return routes
+}
+
+// applicationServer returns the context and upstream host of the primary
+// server of app. Applications on the organization's default server, or
+// without a server assignment, keep ctx and upstreamHost.
+func (r *Reconciler) applicationServer(ctx context.Context, app shared_types.Application, upstreamHost string) (context.Context, string) {
+	servers, err := r.Storage.GetApplicationServers(app.ID)
+	if err != nil || len(servers) == 0 {
+		return ctx, upstreamHost
+	}
+	primary := servers[0]
+	if primary.Server == nil || primary.Server.IsDefault {
+		return ctx, upstreamHost
+	}
+
+	serverCtx := context.WithValue(ctx, shared_types.ServerIDKey, primary.ServerID.String())
+	host, err := getSSHHostForOrg(serverCtx)
+	if err != nil {
+		r.Logger.Log(logger.Warning,
+			fmt.Sprintf("failed to get upstream host of server %s for %s", primary.ServerID, app.Name), err.Error())
+		return ctx, upstreamHost
+	}
+	return serverCtx, host
+}
+
 // buildComposeRoutes resolves ports from the domain's linked ComposeService or
 // port override. Orphaned domains (no service, no override) are skipped.
 func (r *Reconciler) buildComposeRoutes(app shared_types.Application, upstreamHost string) []DomainRoute {
@@ -229,31 +542,57 @@ func (r *Reconciler) buildComposeRoutes(app shared_types.Application, upstreamHo
 			continue
 		}
 
-		routes = append(routes, DomainRoute{
-			Domain:       d.Domain,
-			UpstreamDial: fmt.Sprintf("%s:%d", upstreamHost, port),
-		})
+		routes = append(routes, domainRoute(d, fmt.Sprintf("%s:%d", upstreamHost, port)))
 	}
 	return routes
 }
 
-// buildSwarmRoutes uses Swarm service discovery to resolve published ports.
-func (r *Reconciler) buildSwarmRoutes(ctx context.Context, app shared_types.Application, upstreamHost string) []DomainRoute {
-	publishedPort, err := r.getPublishedPort(ctx, app.Name)
+// buildStackRoutes routes the domains of a compose application deployed as a
+// Swarm stack. Domains linked to a service resolve through Swarm service
+// discovery; domains with only a port override dial that port directly.
+func (r *Reconciler) buildStackRoutes(ctx context.Context, app shared_types.Application, upstreamHost string) []DomainRoute {
+	byService := make(map[string][]*shared_types.ApplicationDomain)
+	var services []string
+	var unlinked []*shared_types.ApplicationDomain
+	for _, d := range app.Domains {
+		if d.ComposeService == nil {
+			unlinked = append(unlinked, d)
+			continue
+		}
+		name := app.StackServiceName(d.ComposeService.ServiceName)
+		if _, ok := byService[name]; !ok {
+			services = append(services, name)
+		}
+		byService[name] = append(byService[name], d)
+	}
+
+	var routes []DomainRoute
+	for _, name := range services {
+		routes = append(routes, r.buildSwarmRoutes(ctx, name, byService[name], upstreamHost)...)
+	}
+	unlinkedApp := app
+	unlinkedApp.Domains = unlinked
+	return append(routes, r.buildComposeRoutes(unlinkedApp, upstreamHost)...)
+}
+
+// buildSwarmRoutes uses Swarm service discovery to resolve the published port
+// of serviceName and routes all of domains to it.
+func (r *Reconciler) buildSwarmRoutes(ctx context.Context, serviceName string, domains []*shared_types.ApplicationDomain, upstreamHost string) []DomainRoute {
+	publishedPort, err := r.getPublishedPort(ctx, serviceName)
 	if err != nil {
 		r.Logger.Log(logger.Warning,
-			fmt.Sprintf("service %s unreachable, skipping %d domain(s)", app.Name, len(app.Domains)),
+			fmt.Sprintf("service %s unreachable, skipping %d domain(s)", serviceName, len(domains)),
 			err.Error())
 		return nil
 	}
 
 	dial := fmt.Sprintf("%s:%d", upstreamHost, publishedPort)
 	var routes []DomainRoute
-	for _, d := range app.Domains {
+	for _, d := range domains {
 		if d.Domain == "" {
 			continue
 		}
-		routes = append(routes, DomainRoute{Domain: d.Domain, UpstreamDial: dial})
+		routes = append(routes, domainRoute(d, dial))
 	}
 	return routes
 }
@@ -276,9 +615,15 @@ func (r *Reconciler) getPublishedPort(ctx context.Context, serviceName string) (
 }
 
 func extractPublishedPort(svc swarm.Service) (int, error) {
+	// Services can publish TCP/UDP ports next to the HTTP port, which is
+	// always the first port of the spec.
+	var httpTarget uint32
+	if svc.Spec.EndpointSpec != nil && len(svc.Spec.EndpointSpec.Ports) > 0 {
+		httpTarget = svc.Spec.EndpointSpec.Ports[0].TargetPort
+	}
 	if svc.Endpoint.Ports != nil {
 		for _, p := range svc.Endpoint.Ports {
-			if p.PublishedPort > 0 {
+			if p.PublishedPort > 0 && (httpTarget == 0 || p.TargetPort == httpTarget) {
 				return int(p.PublishedPort), nil
 			}
 		}
@@ -302,12 +647,20 @@ func (r *Reconciler) fullRebuild(ctx context.Context, desired []DomainRoute) (*R
 		return result, nil
 	}
 
+	// The config could not be read before the rebuild, but a snapshot is
+	// still attempted in case the server came back since.
+	recordSnapshot(ctx, r.Storage, nil, &r.Logger, nil, shared_types.CaddySnapshotFullRebuild)
 	client, err := GetCaddyClient(ctx, nil, &r.Logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get caddy client for rebuild: %w", err)
 	}
 
+	var custom []DomainRoute
 	for _, route := range desired {
+		if route.custom() {
+			custom = append(custom, route)
+			continue
+		}
 		host, port, parseErr := parseDial(route.UpstreamDial)
 		if parseErr != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("invalid dial for %s: %v", route.Domain, parseErr))
@@ -320,9 +673,19 @@ func (r *Reconciler) fullRebuild(ctx context.Context, desired []DomainRoute) (*R
 		}
 	}
 
+	if err := ApplyCustomRoutes(ctx, nil, &r.Logger, custom); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to apply custom routes: %v", err))
+	} else {
+		for _, route := range custom {
+			result.Added = append(result.Added, route.Domain)
+		}
+	}
+
 	if err := client.Reload(); err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("reload failed: %v", err))
 	}
+	r.applyCustomCertificates(ctx, orgIDFromContext(ctx), result)
+	r.applyDNSChallenges(ctx, orgIDFromContext(ctx), result)
 
 	r.Logger.Log(logger.Info,
 		fmt.Sprintf("full rebuild complete: added=%d errors=%d", len(result.Added), len(result.Errors)), "")
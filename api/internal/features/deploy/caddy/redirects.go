@@ -0,0 +1,171 @@
+package caddy
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	// requestHostPlaceholder and requestURIPlaceholder are the Caddy
+	// placeholders of the requested host and of the path and query.
+	requestHostPlaceholder = "{http.request.host}"
+	requestURIPlaceholder  = "{http.request.uri}"
+)
+
+// Redirect sends the requests of a domain under PathPrefix to another
+// location.
+type Redirect struct {
+	// PathPrefix limits the redirect to a path and the paths below it.
+	// Empty redirects every request.
+	PathPrefix string
+	// Host is the host redirected to, empty for the requested host.
+	Host string
+	// Path is the path redirected to. It has no trailing slash when
+	// PreservePath is set.
+	Path       string
+	StatusCode int
+	// PreservePath appends the rest of the requested path, after
+	// PathPrefix, and the query string to Path.
+	PreservePath bool
+}
+
+// location returns the Location header Caddy answers the redirect with.
+func (r Redirect) location() string {
+	host := r.Host
+	if host == "" {
+		host = requestHostPlaceholder
+	}
+	location := "https://" + host + r.Path
+	if r.PreservePath {
+		location += requestURIPlaceholder
+	}
+	return location
+}
+
+// parseLocation sets the target of r from a Location header made by
+// location. It reports false for any other header.
+func (r *Redirect) parseLocation(location string) bool {
+	rest, ok := strings.CutPrefix(location, "https://")
+	if !ok {
+		return false
+	}
+	rest, r.PreservePath = strings.CutSuffix(rest, requestURIPlaceholder)
+	r.Host, r.Path = rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		r.Host, r.Path = rest[:i], rest[i:]
+	}
+	if r.Host == requestHostPlaceholder {
+		r.Host = ""
+	}
+	return r.Host != "" || r.Path != "" || r.PreservePath
+}
+
+// RedirectFromRule converts a stored redirect rule to the redirect Caddy
+// serves.
+func RedirectFromRule(rule shared_types.ApplicationRedirect) Redirect {
+	path := rule.TargetPath
+	if rule.PreservePath {
+		path = strings.TrimSuffix(path, "/")
+	} else if path == "" {
+		path = "/"
+	}
+	return Redirect{
+		PathPrefix:   rule.SourcePath,
+		Host:         strings.ToLower(rule.TargetDomain),
+		Path:         path,
+		StatusCode:   rule.StatusCode,
+		PreservePath: rule.PreservePath,
+	}
+}
+
+// RedirectSet holds the redirects of the domains of an organization.
+type RedirectSet struct {
+	// ByDomain holds the redirects of each domain, longest path prefix
+	// first.
+	ByDomain map[string][]Redirect
+	// Aliases are the domains that belong to no application and only serve
+	// redirects.
+	Aliases map[string]bool
+}
+
+// RedirectsByDomain returns the redirects of every domain of the
+// organization. A rule of a domain takes precedence over an application
+// wide rule with the same source path.
+func RedirectsByDomain(store storage.DeployRepository, organizationID uuid.UUID) (*RedirectSet, error) {
+	rules, err := store.GetOrganizationRedirects(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	set := &RedirectSet{ByDomain: make(map[string][]Redirect), Aliases: make(map[string]bool)}
+	if len(rules) == 0 {
+		return set, nil
+	}
+
+	byApp := make(map[uuid.UUID][]shared_types.ApplicationRedirect)
+	for _, rule := range rules {
+		byApp[rule.ApplicationID] = append(byApp[rule.ApplicationID], rule)
+	}
+
+	byPath := make(map[string]map[string]Redirect)
+	add := func(domain string, rule shared_types.ApplicationRedirect) {
+		if byPath[domain] == nil {
+			byPath[domain] = make(map[string]Redirect)
+		}
+		byPath[domain][rule.SourcePath] = RedirectFromRule(rule)
+	}
+
+	for appID, appRules := range byApp {
+		domains, err := store.GetApplicationDomains(appID)
+		if err != nil {
+			return nil, err
+		}
+		owned := make(map[string]bool, len(domains))
+		for _, d := range domains {
+			owned[strings.ToLower(d.Route())] = true
+		}
+
+		for _, rule := range appRules {
+			if rule.SourceDomain != "" {
+				continue
+			}
+			for name := range owned {
+				// An application wide host redirect skips the domain it
+				// redirects to.
+				if rule.SourcePath == "" && strings.EqualFold(rule.TargetDomain, name) {
+					continue
+				}
+				add(name, rule)
+			}
+		}
+		for _, rule := range appRules {
+			if rule.SourceDomain == "" {
+				continue
+			}
+			name := strings.ToLower(rule.SourceDomain)
+			add(name, rule)
+			if !owned[name] {
+				set.Aliases[name] = true
+			}
+		}
+	}
+
+	for domain, paths := range byPath {
+		redirects := make([]Redirect, 0, len(paths))
+		for _, r := range paths {
+			redirects = append(redirects, r)
+		}
+		slices.SortFunc(redirects, func(a, b Redirect) int {
+			if c := cmp.Compare(len(b.PathPrefix), len(a.PathPrefix)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.PathPrefix, b.PathPrefix)
+		})
+		set.ByDomain[domain] = redirects
+	}
+	return set, nil
+}
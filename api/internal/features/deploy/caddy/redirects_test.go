@@ -0,0 +1,101 @@
+package caddy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestRedirectFromRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     shared_types.ApplicationRedirect
+		location string
+	}{
+		{
+			name:     "host redirect keeps the path",
+			rule:     shared_types.ApplicationRedirect{TargetDomain: "Example.com", StatusCode: 301, PreservePath: true},
+			location: "https://example.com{http.request.uri}",
+		},
+		{
+			name:     "host redirect to the root",
+			rule:     shared_types.ApplicationRedirect{TargetDomain: "example.com", StatusCode: 302},
+			location: "https://example.com/",
+		},
+		{
+			name:     "path redirect on the same host",
+			rule:     shared_types.ApplicationRedirect{SourcePath: "/docs", TargetPath: "/guide/", StatusCode: 308, PreservePath: true},
+			location: "https://{http.request.host}/guide{http.request.uri}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redirect := RedirectFromRule(tt.rule)
+			if got := redirect.location(); got != tt.location {
+				t.Fatalf("location = %q, want %q", got, tt.location)
+			}
+			parsed := Redirect{PathPrefix: redirect.PathPrefix, StatusCode: redirect.StatusCode}
+			if !parsed.parseLocation(redirect.location()) || parsed != redirect {
+				t.Errorf("location did not round trip: %+v, want %+v", parsed, redirect)
+			}
+		})
+	}
+}
+
+func TestRedirectRouteRoundTrip(t *testing.T) {
+	routes := []DomainRoute{
+		{
+			Domain:       "example.com",
+			UpstreamDial: "10.0.0.5:31000",
+			Redirects: []Redirect{
+				{PathPrefix: "/blog", Host: "blog.example.com", StatusCode: 301, PreservePath: true},
+				{PathPrefix: "/old", Path: "/new", StatusCode: 307},
+			},
+		},
+		{
+			Domain:    "www.example.com",
+			Redirects: []Redirect{{Host: "example.com", StatusCode: 308, PreservePath: true}},
+		},
+		{
+			Domain:       "status.example.com",
+			UpstreamDial: "10.0.0.5:31001",
+			Maintenance:  &MaintenancePage{HTMLBody: DefaultMaintenanceHTML, RetryAfterSeconds: 60, AllowedIPs: []string{"10.0.0.0/8"}},
+			Redirects:    []Redirect{{PathPrefix: "/health", Path: "/healthz", StatusCode: 302, PreservePath: true}},
+		},
+	}
+
+	config := &caddy.Config{AppsRaw: caddy.ModuleMap{
+		"http": json.RawMessage(`{"servers":{"nixopus":{"listen":[":443"],"routes":[
+			{"match":[{"host":["example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.0.5:31000"}]}],"terminal":true}
+		]}}}`),
+	}}
+	if err := applyCustomRoutes(config, routes); err != nil {
+		t.Fatalf("applyCustomRoutes: %v", err)
+	}
+
+	actual, err := extractDomainRoutes(config)
+	if err != nil {
+		t.Fatalf("extractDomainRoutes: %v", err)
+	}
+	if len(actual) != len(routes) {
+		t.Fatalf("expected %d routes, got %d", len(routes), len(actual))
+	}
+	for _, want := range routes {
+		idx := slices.IndexFunc(actual, func(r DomainRoute) bool { return r.Domain == want.Domain })
+		if idx < 0 {
+			t.Fatalf("route %s not found", want.Domain)
+		}
+		if !want.matches(actual[idx]) {
+			t.Errorf("route %s did not round trip: %+v", want.Domain, actual[idx])
+		}
+	}
+
+	plain := DomainRoute{Domain: "example.com", UpstreamDial: "10.0.0.5:31000"}
+	idx := slices.IndexFunc(actual, func(r DomainRoute) bool { return r.Domain == "example.com" })
+	if plain.matches(actual[idx]) {
+		t.Error("route without redirects should not match a route with redirects")
+	}
+}
@@ -0,0 +1,89 @@
+package caddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// maxConfigSnapshots is how many Caddy config snapshots are kept per
+// organization and server.
+const maxConfigSnapshots = 50
+
+// ConfigServerHost returns the host of the server whose Caddy config the
+// organization in ctx changes, which its snapshots are kept under.
+func ConfigServerHost(ctx context.Context, sshClient *ssh.SSH) (string, error) {
+	s, _, err := caddySSH(ctx, sshClient)
+	if err != nil {
+		return "", err
+	}
+	if s.Host == "" {
+		return "", fmt.Errorf("SSH host is required for Caddy tunnel")
+	}
+	return s.Host, nil
+}
+
+// RecordConfigSnapshot stores config, or the current config of the server
+// when nil, as the latest snapshot of the organization in ctx. It is
+// returned unchanged when the latest snapshot already holds the same config.
+func RecordConfigSnapshot(ctx context.Context, store storage.DeployRepository, sshClient *ssh.SSH, lgr *logger.Logger, config *caddy.Config, snapshot shared_types.CaddyConfigSnapshot) (*shared_types.CaddyConfigSnapshot, error) {
+	orgID := orgIDFromContext(ctx)
+	if store == nil || orgID == uuid.Nil {
+		return nil, fmt.Errorf("cannot snapshot the proxy config without its organization")
+	}
+	host, err := ConfigServerHost(ctx, sshClient)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		if config, err = GetCaddyConfig(ctx, sshClient, lgr); err != nil {
+			return nil, err
+		}
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal caddy config: %w", err)
+	}
+
+	snapshot.OrganizationID = orgID
+	snapshot.ServerHost = host
+	snapshot.Config = shared_types.EncryptedString(raw)
+	return store.RecordCaddyConfigSnapshot(&snapshot, maxConfigSnapshots)
+}
+
+// recordSnapshot keeps the config of the server before a change made for
+// reason. A failure is logged and does not stop the change.
+func recordSnapshot(ctx context.Context, store storage.DeployRepository, sshClient *ssh.SSH, lgr *logger.Logger, config *caddy.Config, reason shared_types.CaddySnapshotReason) {
+	if store == nil || orgIDFromContext(ctx) == uuid.Nil {
+		return
+	}
+	_, err := RecordConfigSnapshot(ctx, store, sshClient, lgr, config, shared_types.CaddyConfigSnapshot{Reason: reason})
+	if err != nil {
+		l := resolveLogger(lgr)
+		l.Log(logger.Warning, "failed to snapshot proxy config", err.Error())
+	}
+}
+
+// updateConfig reads the config of the server, records it for reason and
+// lets apply change it. The result is loaded only when apply reports a
+// change. Recording every read is cheap, as unchanged configs are not
+// stored again.
+func updateConfig(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger, reason shared_types.CaddySnapshotReason, apply func(*caddy.Config) (bool, error)) (bool, error) {
+	config, err := GetCaddyConfig(ctx, sshClient, lgr)
+	if err != nil {
+		return false, err
+	}
+	recordSnapshot(ctx, domainStore, sshClient, lgr, config, reason)
+	changed, err := apply(config)
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, RestoreCaddyConfig(ctx, sshClient, lgr, config)
+}
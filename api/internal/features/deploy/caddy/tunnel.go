@@ -261,6 +261,27 @@ func orgIDFromContext(ctx context.Context) uuid.UUID {
 	}
 }
 
+// caddySSH returns the SSH connection Caddy is reached through: sshClient
+// when given, else the default server of the organization in ctx.
+func caddySSH(ctx context.Context, sshClient *ssh.SSH) (*ssh.SSH, uuid.UUID, error) {
+	orgID := orgIDFromContext(ctx)
+	if sshClient != nil {
+		return sshClient, orgID, nil
+	}
+	if orgID == uuid.Nil {
+		return nil, uuid.Nil, fmt.Errorf("organization ID or SSH client required for Caddy")
+	}
+	manager, err := ssh.GetSSHManagerForOrganization(ctx, orgID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to get SSH manager: %w", err)
+	}
+	s, err := manager.GetDefaultSSH()
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to get SSH client: %w", err)
+	}
+	return s, orgID, nil
+}
+
 // GetCaddyClient returns a caddygo client that uses an SSH tunnel to reach
 // the Caddy admin API on the given host. Uses existing SSH config (ctx org or sshClient).
 // Caches tunnel per host+port for reuse.
@@ -270,24 +291,9 @@ func GetCaddyClient(ctx context.Context, sshClient *ssh.SSH, lgr *logger.Logger)
 		return nil, err
 	}
 
-	var s *ssh.SSH
-	var orgID uuid.UUID
-	if sshClient != nil {
-		s = sshClient
-		orgID = orgIDFromContext(ctx)
-	} else {
-		orgID = orgIDFromContext(ctx)
-		if orgID == uuid.Nil {
-			return nil, fmt.Errorf("organization ID or SSH client required for Caddy")
-		}
-		manager, err := ssh.GetSSHManagerForOrganization(ctx, orgID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get SSH manager: %w", err)
-		}
-		s, err = manager.GetDefaultSSH()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get SSH client: %w", err)
-		}
+	s, orgID, err := caddySSH(ctx, sshClient)
+	if err != nil {
+		return nil, err
 	}
 
 	key := s.Host + ":" + remotePort
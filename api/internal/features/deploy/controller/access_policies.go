@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetAccessPolicies lists the access policies of an application.
+func (c *DeployController) GetAccessPolicies(f fuego.ContextNoBody) (*types.AccessPoliciesResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := c.service.ListAccessPolicies(appID, organizationID)
+	if err != nil {
+		return nil, c.accessPolicyError(err)
+	}
+
+	return &types.AccessPoliciesResponse{
+		Status:  "success",
+		Message: "Access policies retrieved successfully",
+		Data:    policies,
+	}, nil
+}
+
+// UpdateAccessPolicy sets the access policy of an application or one of its
+// domains and puts it in front of its Caddy routes.
+func (c *DeployController) UpdateAccessPolicy(f fuego.ContextWithBody[types.UpdateAccessPolicyRequest]) (*types.AccessPolicyResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	policy, err := c.taskService.UpdateAccessPolicy(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		return nil, c.accessPolicyError(err)
+	}
+
+	return &types.AccessPolicyResponse{
+		Status:  "success",
+		Message: "Access policy updated successfully",
+		Data:    *policy,
+	}, nil
+}
+
+// DeleteAccessPolicy removes the access policy of an application, or the
+// override of one of its domains.
+func (c *DeployController) DeleteAccessPolicy(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.taskService.DeleteAccessPolicy(f.Request().Context(), appID, f.QueryParam("domain"), organizationID); err != nil {
+		return nil, c.accessPolicyError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Access policy removed successfully",
+	}, nil
+}
+
+func (c *DeployController) accessPolicyError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrAccessPolicyNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDomainNotInApplication),
+		errors.Is(err, types.ErrInvalidAccessIP),
+		errors.Is(err, types.ErrInvalidBasicAuthUser),
+		errors.Is(err, types.ErrInvalidBasicAuthRealm),
+		errors.Is(err, types.ErrInvalidForwardAuthURL),
+		errors.Is(err, types.ErrInvalidForwardAuthHeader),
+		errors.Is(err, types.ErrEmptyAccessPolicy):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -17,11 +17,14 @@ import (
 	"github.com/nixopus/nixopus/api/internal/utils"
 )
 
-// AddApplicationDomainRequest represents a request to add a domain to an application
+// AddApplicationDomainRequest represents a request to add a domain to an application.
+// Domain may end in a path prefix, such as example.com/api, to mount the
+// application under that path; StripPathPrefix removes it before proxying.
 type AddApplicationDomainRequest struct {
-	Domain      string `json:"domain"`
-	ServiceName string `json:"service_name,omitempty"`
-	Port        *int   `json:"port,omitempty"`
+	Domain          string `json:"domain"`
+	ServiceName     string `json:"service_name,omitempty"`
+	Port            *int   `json:"port,omitempty"`
+	StripPathPrefix bool   `json:"strip_path_prefix,omitempty"`
 }
 
 // RemoveApplicationDomainRequest represents a request to remove a domain from an application
@@ -54,6 +57,14 @@ func (c *DeployController) AddApplicationDomain(f fuego.ContextWithBody[AddAppli
 			Err:    types.ErrMissingDomain,
 		}
 	}
+	host, pathPrefix, ok := shared_types.SplitDomainRoute(data.Domain)
+	if !ok {
+		return nil, fuego.BadRequestError{
+			Detail: types.ErrInvalidPathPrefix.Error(),
+			Err:    types.ErrInvalidPathPrefix,
+		}
+	}
+	data.Domain = host + pathPrefix
 
 	user := utils.GetUser(f.Response(), f.Request())
 	if user == nil {
@@ -98,7 +109,7 @@ func (c *DeployController) AddApplicationDomain(f fuego.ContextWithBody[AddAppli
 
 	// Check for duplicate domain
 	for _, existingDomain := range existingDomains {
-		if existingDomain.Domain == data.Domain {
+		if existingDomain.Route() == data.Domain {
 			return nil, fuego.BadRequestError{
 				Detail: types.ErrDomainAlreadyExists.Error(),
 				Err:    types.ErrDomainAlreadyExists,
@@ -132,6 +143,9 @@ func (c *DeployController) AddApplicationDomain(f fuego.ContextWithBody[AddAppli
 	}
 
 	err = c.storage.AddApplicationDomainWithService(appID, data.Domain, composeServiceID, data.Port)
+	if err == nil && pathPrefix != "" && data.StripPathPrefix {
+		err = c.storage.SetApplicationDomainStripPathPrefix(appID, data.Domain, true)
+	}
 	if err != nil {
 		c.logger.Log(logger.Error, "failed to add domain", err.Error())
 		return nil, fuego.HTTPError{
@@ -273,7 +287,7 @@ func (c *DeployController) syncApplicationDomains(appID uuid.UUID, organizationI
 
 	existingSet := make(map[string]string) // lowercase -> actual
 	for _, d := range existingDomains {
-		existingSet[strings.ToLower(d.Domain)] = d.Domain
+		existingSet[strings.ToLower(d.Route())] = d.Route()
 	}
 
 	// Remove domains that are no longer desired
@@ -348,7 +362,7 @@ func (c *DeployController) syncComposeApplicationDomains(appID uuid.UUID, organi
 
 	existingSet := make(map[string]string) // lowercase -> actual domain
 	for _, d := range existingDomains {
-		existingSet[strings.ToLower(d.Domain)] = d.Domain
+		existingSet[strings.ToLower(d.Route())] = d.Route()
 	}
 
 	for existingLower, actualDomain := range existingSet {
@@ -531,7 +545,7 @@ func (c *DeployController) GetComposeServices(f fuego.ContextNoBody) (*types.Com
 	}
 
 	// Verify application exists and user has access
-	_, err = c.service.GetApplicationById(applicationID, organizationID)
+	application, err := c.service.GetApplicationById(applicationID, organizationID)
 	if err != nil {
 		return nil, fuego.NotFoundError{
 			Detail: err.Error(),
@@ -549,6 +563,12 @@ func (c *DeployController) GetComposeServices(f fuego.ContextNoBody) (*types.Com
 		}
 	}
 
+	application.ComposeServices = make([]*shared_types.ComposeService, len(services))
+	for i := range services {
+		application.ComposeServices[i] = &services[i]
+	}
+	c.attachComposeServiceStatus(f.Request(), &application)
+
 	return &types.ComposeServicesResponse{
 		Status:  "success",
 		Message: "Compose services fetched successfully",
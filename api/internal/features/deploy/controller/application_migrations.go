@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// MigrateApplication starts moving an application and its data to another server.
+func (c *DeployController) MigrateApplication(f fuego.ContextWithBody[types.MigrateApplicationRequest]) (*types.ApplicationMigrationResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		c.logger.Log(logger.Error, "user not found", "")
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		c.logger.Log(logger.Error, "organization not found", "")
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	migration, err := c.taskService.StartMigration(f.Request().Context(), &data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.migrationError(err)
+	}
+
+	return &types.ApplicationMigrationResponse{
+		Status:  "success",
+		Message: "Application migration started",
+		Data:    *migration,
+	}, nil
+}
+
+// GetApplicationMigrations lists the migrations of an application, newest first.
+func (c *DeployController) GetApplicationMigrations(f fuego.ContextNoBody) (*types.ApplicationMigrationsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	if _, err := c.storage.GetApplicationById(appID.String(), organizationID); err != nil {
+		return nil, fuego.NotFoundError{Detail: "application not found"}
+	}
+
+	migrations, err := c.storage.GetApplicationMigrations(appID)
+	if err != nil {
+		return nil, c.migrationError(err)
+	}
+
+	return &types.ApplicationMigrationsResponse{
+		Status:  "success",
+		Message: "Application migrations retrieved successfully",
+		Data:    migrations,
+	}, nil
+}
+
+// GetApplicationMigration returns a migration with the progress of its steps.
+func (c *DeployController) GetApplicationMigration(f fuego.ContextNoBody) (*types.ApplicationMigrationResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	migrationID, err := uuid.Parse(f.PathParam("migration_id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid migration id",
+			Err:    err,
+		}
+	}
+
+	migration, err := c.storage.GetApplicationMigration(migrationID, organizationID)
+	if err != nil {
+		return nil, fuego.NotFoundError{Detail: types.ErrMigrationNotFound.Error()}
+	}
+
+	return &types.ApplicationMigrationResponse{
+		Status:  "success",
+		Message: "Application migration retrieved successfully",
+		Data:    *migration,
+	}, nil
+}
+
+// ConfirmApplicationMigration removes the application and its copied data
+// from the source server of a migration.
+func (c *DeployController) ConfirmApplicationMigration(f fuego.ContextWithBody[types.MigrationActionRequest]) (*types.ApplicationMigrationResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	migration, err := c.taskService.ConfirmMigration(data.ID, organizationID)
+	if err != nil {
+		return nil, c.migrationError(err)
+	}
+
+	return &types.ApplicationMigrationResponse{
+		Status:  "success",
+		Message: "Application migration confirmed, cleaning up the source server",
+		Data:    *migration,
+	}, nil
+}
+
+// RollbackApplicationMigration moves a migrated application back to its source server.
+func (c *DeployController) RollbackApplicationMigration(f fuego.ContextWithBody[types.MigrationActionRequest]) (*types.ApplicationMigrationResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	migration, err := c.taskService.RollbackMigration(data.ID, organizationID)
+	if err != nil {
+		return nil, c.migrationError(err)
+	}
+
+	return &types.ApplicationMigrationResponse{
+		Status:  "success",
+		Message: "Application migration is rolling back",
+		Data:    *migration,
+	}, nil
+}
+
+func (c *DeployController) migrationError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound):
+		return fuego.NotFoundError{Detail: "application not found"}
+	case errors.Is(err, types.ErrMigrationNotFound), errors.Is(err, types.ErrServerNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrMigrationInProgress),
+		errors.Is(err, types.ErrMigrationNotAwaitingConfirmation),
+		errors.Is(err, types.ErrMigrationCannotRollback):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrMigrationSameServer),
+		errors.Is(err, types.ErrMigrationTargetAssigned),
+		errors.Is(err, types.ErrInvalidMigrationTransfer),
+		errors.Is(err, types.ErrS3NotConfigured),
+		errors.Is(err, types.ErrNoImageToMigrate):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationPorts lists the TCP/UDP ports published by an application.
+func (c *DeployController) GetApplicationPorts(f fuego.ContextNoBody) (*types.ApplicationPortsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	ports, err := c.service.ListApplicationPorts(appID, organizationID)
+	if err != nil {
+		return nil, c.applicationPortError(err)
+	}
+
+	return &types.ApplicationPortsResponse{
+		Status:  "success",
+		Message: "Application ports retrieved successfully",
+		Data:    ports,
+	}, nil
+}
+
+// AddApplicationPort publishes a TCP or UDP port of an application. It takes
+// effect on the next deployment.
+func (c *DeployController) AddApplicationPort(f fuego.ContextWithBody[types.AddApplicationPortRequest]) (*types.ApplicationPortResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	port, err := c.service.AddApplicationPort(&data, organizationID)
+	if err != nil {
+		return nil, c.applicationPortError(err)
+	}
+
+	return &types.ApplicationPortResponse{
+		Status:  "success",
+		Message: "Application port added, redeploy the application to publish it",
+		Data:    *port,
+	}, nil
+}
+
+// DeleteApplicationPort removes a published port from an application.
+// Proxied ports are removed from Caddy right away, other ports are closed on
+// the next deployment.
+func (c *DeployController) DeleteApplicationPort(f fuego.ContextWithBody[types.DeleteApplicationPortRequest]) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	port, err := c.service.DeleteApplicationPort(data.ID, data.PortID, organizationID)
+	if err != nil {
+		return nil, c.applicationPortError(err)
+	}
+
+	if port.PublishMode == shared_types.PortPublishProxy {
+		if err := caddy.EnqueueReconcile(organizationID); err != nil {
+			c.logger.Log(logger.Warning, "failed to enqueue caddy reconcile after port removal", err.Error())
+		}
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Application port removed successfully",
+	}, nil
+}
+
+func (c *DeployController) applicationPortError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound), errors.Is(err, types.ErrApplicationPortNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrPortConflict):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrInvalidPortProtocol),
+		errors.Is(err, types.ErrInvalidPortPublishMode),
+		errors.Is(err, types.ErrInvalidPortNumber),
+		errors.Is(err, types.ErrPortReserved),
+		errors.Is(err, types.ErrPortInDynamicRange),
+		errors.Is(err, types.ErrPortServiceRequired),
+		errors.Is(err, types.ErrPortServerNotAssigned):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetAutoscalingPolicy returns the autoscaling policy of an application.
+func (c *DeployController) GetAutoscalingPolicy(f fuego.ContextNoBody) (*types.AutoscalingPolicyResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := c.service.GetAutoscalingPolicy(appID, organizationID)
+	if err != nil {
+		return nil, c.autoscalingError(err)
+	}
+
+	return &types.AutoscalingPolicyResponse{
+		Status:  "success",
+		Message: "Autoscaling policy retrieved successfully",
+		Data:    *policy,
+	}, nil
+}
+
+// UpdateAutoscalingPolicy creates or replaces the autoscaling policy of an
+// application. Policies can only be enabled on single-node swarms.
+func (c *DeployController) UpdateAutoscalingPolicy(f fuego.ContextWithBody[types.UpdateAutoscalingPolicyRequest]) (*types.AutoscalingPolicyResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if err := c.taskService.CheckAutoscalingSupported(f.Request().Context(), &data, organizationID); err != nil {
+		return nil, c.autoscalingError(err)
+	}
+
+	policy, err := c.service.UpdateAutoscalingPolicy(&data, organizationID)
+	if err != nil {
+		return nil, c.autoscalingError(err)
+	}
+
+	return &types.AutoscalingPolicyResponse{
+		Status:  "success",
+		Message: "Autoscaling policy saved successfully",
+		Data:    *policy,
+	}, nil
+}
+
+// DeleteAutoscalingPolicy removes the autoscaling policy of an application.
+func (c *DeployController) DeleteAutoscalingPolicy(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.service.DeleteAutoscalingPolicy(appID, organizationID); err != nil {
+		return nil, c.autoscalingError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Autoscaling policy deleted successfully",
+	}, nil
+}
+
+// GetScalingEvents lists the latest scaling decisions of an application
+// with their reasons.
+func (c *DeployController) GetScalingEvents(f fuego.ContextNoBody) (*types.ScalingEventsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := c.service.ListScalingEvents(appID, organizationID)
+	if err != nil {
+		return nil, c.autoscalingError(err)
+	}
+
+	return &types.ScalingEventsResponse{
+		Status:  "success",
+		Message: "Scaling events retrieved successfully",
+		Data:    events,
+	}, nil
+}
+
+func (c *DeployController) autoscalingError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrAutoscalingPolicyNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrAutoscalingUnsupported),
+		errors.Is(err, types.ErrAutoscalingMultiNode),
+		errors.Is(err, types.ErrInvalidAutoscalingReplicas),
+		errors.Is(err, types.ErrInvalidAutoscalingMetric),
+		errors.Is(err, types.ErrInvalidAutoscalingTarget),
+		errors.Is(err, types.ErrInvalidAutoscalingCooldown):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
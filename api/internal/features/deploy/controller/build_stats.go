@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetApplicationBuildStats returns phase timings, cache hit rate and failure
+// rates over the latest deployments of an application.
+func (c *DeployController) GetApplicationBuildStats(f fuego.ContextNoBody) (*types.ApplicationBuildStatsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	limit := 0
+	if raw := f.QueryParam("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return nil, fuego.BadRequestError{
+				Detail: "invalid limit",
+				Err:    err,
+			}
+		}
+	}
+
+	stats, err := c.service.GetApplicationBuildStats(appID, organizationID, limit)
+	if err != nil {
+		return nil, c.buildStatsError(err)
+	}
+
+	return &types.ApplicationBuildStatsResponse{
+		Status:  "success",
+		Message: "Application build stats retrieved successfully",
+		Data:    *stats,
+	}, nil
+}
+
+func (c *DeployController) buildStatsError(err error) error {
+	if errors.Is(err, types.ErrApplicationNotFound) {
+		return fuego.NotFoundError{Detail: err.Error()}
+	}
+	c.logger.Log(logger.Error, err.Error(), "")
+	return fuego.HTTPError{
+		Err:    err,
+		Detail: err.Error(),
+		Status: http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetCaddySnapshots lists the proxy config snapshots of the organization.
+func (c *DeployController) GetCaddySnapshots(f fuego.ContextNoBody) (*types.CaddySnapshotsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := c.service.ListCaddySnapshots(organizationID)
+	if err != nil {
+		return nil, c.caddySnapshotError(err)
+	}
+
+	return &types.CaddySnapshotsResponse{
+		Status:  "success",
+		Message: "Proxy config snapshots retrieved successfully",
+		Data:    snapshots,
+	}, nil
+}
+
+// DiffCaddySnapshots compares two proxy config snapshots with secret values
+// redacted. When "to" is omitted the latest snapshot of the same server is used.
+func (c *DeployController) DiffCaddySnapshots(f fuego.ContextNoBody) (*types.CaddySnapshotDiffResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, err := uuid.Parse(f.QueryParam("from"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid from snapshot id",
+			Err:    err,
+		}
+	}
+
+	var toID *uuid.UUID
+	if raw := f.QueryParam("to"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fuego.BadRequestError{
+				Detail: "invalid to snapshot id",
+				Err:    err,
+			}
+		}
+		toID = &parsed
+	}
+
+	diff, err := c.service.DiffCaddySnapshots(fromID, toID, organizationID)
+	if err != nil {
+		return nil, c.caddySnapshotError(err)
+	}
+
+	return &types.CaddySnapshotDiffResponse{
+		Status:  "success",
+		Message: "Proxy config snapshots compared successfully",
+		Data:    diff,
+	}, nil
+}
+
+// RestoreCaddySnapshot loads an earlier proxy config snapshot back into its
+// server and returns the snapshot of the config it replaced.
+func (c *DeployController) RestoreCaddySnapshot(f fuego.ContextWithBody[types.RestoreCaddySnapshotRequest]) (*types.CaddySnapshotResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if data.SnapshotID == uuid.Nil {
+		return nil, fuego.BadRequestError{
+			Detail: types.ErrMissingID.Error(),
+			Err:    types.ErrMissingID,
+		}
+	}
+
+	snapshot, err := c.taskService.RestoreCaddySnapshot(f.Request().Context(), data.SnapshotID, user.ID, organizationID)
+	if err != nil {
+		return nil, c.caddySnapshotError(err)
+	}
+
+	return &types.CaddySnapshotResponse{
+		Status:  "success",
+		Message: "Proxy config snapshot restored",
+		Data:    *snapshot,
+	}, nil
+}
+
+func (c *DeployController) caddySnapshotError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrCaddySnapshotNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrCaddySnapshotServerChanged):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetCertificates lists the custom certificates of an application with
+// their expiry. Certificates and keys are never returned.
+func (c *DeployController) GetCertificates(f fuego.ContextNoBody) (*types.CertificatesResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	certificates, err := c.service.ListCertificates(appID, organizationID)
+	if err != nil {
+		return nil, c.certificateError(err)
+	}
+
+	return &types.CertificatesResponse{
+		Status:  "success",
+		Message: "Certificates retrieved successfully",
+		Data:    certificates,
+	}, nil
+}
+
+// UploadCertificate stores a certificate chain and key for a domain of an
+// application and makes Caddy serve it instead of an ACME certificate.
+func (c *DeployController) UploadCertificate(f fuego.ContextWithBody[types.UploadCertificateRequest]) (*types.CertificateResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	certificate, err := c.taskService.UploadCertificate(f.Request().Context(), &data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.certificateError(err)
+	}
+
+	return &types.CertificateResponse{
+		Status:  "success",
+		Message: "Certificate uploaded successfully",
+		Data:    *certificate,
+	}, nil
+}
+
+// DeleteCertificate removes the custom certificate of a domain. The domain
+// gets its certificates from ACME again.
+func (c *DeployController) DeleteCertificate(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.taskService.DeleteCertificate(f.Request().Context(), appID, f.QueryParam("domain"), organizationID); err != nil {
+		return nil, c.certificateError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Certificate removed successfully",
+	}, nil
+}
+
+func (c *DeployController) certificateError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrCertificateNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDomainNotInApplication),
+		errors.Is(err, types.ErrInvalidCertificate),
+		errors.Is(err, types.ErrInvalidPrivateKey),
+		errors.Is(err, types.ErrCertificateKeyMismatch),
+		errors.Is(err, types.ErrCertificateDomainMismatch),
+		errors.Is(err, types.ErrCertificateExpired),
+		errors.Is(err, types.ErrCertificateTooLarge),
+		errors.Is(err, types.ErrInvalidExpiryWarningDays):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// RestartComposeService restarts a single service of a compose application.
+func (c *DeployController) RestartComposeService(f fuego.ContextWithBody[types.ComposeServiceRequest]) (*types.ComposeServiceResponse, error) {
+	return c.runComposeServiceAction(f, tasks.ComposeServiceActionRestart, "Compose service restarted successfully")
+}
+
+// StopComposeService stops a single service of a compose application.
+func (c *DeployController) StopComposeService(f fuego.ContextWithBody[types.ComposeServiceRequest]) (*types.ComposeServiceResponse, error) {
+	return c.runComposeServiceAction(f, tasks.ComposeServiceActionStop, "Compose service stopped successfully")
+}
+
+// StartComposeService starts a single stopped service of a compose application.
+func (c *DeployController) StartComposeService(f fuego.ContextWithBody[types.ComposeServiceRequest]) (*types.ComposeServiceResponse, error) {
+	return c.runComposeServiceAction(f, tasks.ComposeServiceActionStart, "Compose service started successfully")
+}
+
+// ScaleComposeService sets the number of containers of a single compose service.
+func (c *DeployController) ScaleComposeService(f fuego.ContextWithBody[types.ScaleComposeServiceRequest]) (*types.ComposeServiceResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	app, err := c.getComposeApplication(data.ID.String(), organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := c.taskService.ScaleComposeService(c.ctx, app, data.ServiceName, data.Replicas)
+	if err != nil {
+		return nil, c.composeServiceError(err)
+	}
+
+	return &types.ComposeServiceResponse{
+		Status:  "success",
+		Message: "Compose service scaled successfully",
+		Data:    *svc,
+	}, nil
+}
+
+// GetComposeServiceLogs returns the most recent logs of a single compose service.
+func (c *DeployController) GetComposeServiceLogs(f fuego.ContextNoBody) (*types.ComposeServiceLogsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := c.getComposeApplication(f.QueryParam("id"), organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := f.QueryParam("service")
+	tail := 0
+	if raw := f.QueryParam("tail"); raw != "" {
+		tail, err = strconv.Atoi(raw)
+		if err != nil {
+			return nil, fuego.BadRequestError{
+				Detail: "invalid tail",
+				Err:    err,
+			}
+		}
+	}
+
+	logs, err := c.taskService.GetComposeServiceLogs(c.ctx, app, serviceName, tail)
+	if err != nil {
+		return nil, c.composeServiceError(err)
+	}
+
+	return &types.ComposeServiceLogsResponse{
+		Status:  "success",
+		Message: "Compose service logs retrieved successfully",
+		Data: types.ComposeServiceLogsResponseData{
+			ServiceName: serviceName,
+			Logs:        logs,
+		},
+	}, nil
+}
+
+func (c *DeployController) runComposeServiceAction(f fuego.ContextWithBody[types.ComposeServiceRequest], action tasks.ComposeServiceAction, message string) (*types.ComposeServiceResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	app, err := c.getComposeApplication(data.ID.String(), organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := c.taskService.RunComposeServiceAction(c.ctx, app, data.ServiceName, action)
+	if err != nil {
+		return nil, c.composeServiceError(err)
+	}
+
+	return &types.ComposeServiceResponse{
+		Status:  "success",
+		Message: message,
+		Data:    *svc,
+	}, nil
+}
+
+func (c *DeployController) getComposeApplication(id string, organizationID uuid.UUID) (*shared_types.Application, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	app, err := c.service.GetApplicationById(id, organizationID)
+	if err != nil {
+		return nil, fuego.NotFoundError{Detail: "application not found"}
+	}
+	if app.BuildPack != shared_types.DockerCompose {
+		return nil, c.composeServiceError(types.ErrNotComposeApplication)
+	}
+	return &app, nil
+}
+
+func (c *DeployController) composeServiceError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrComposeServiceNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrNotComposeApplication), errors.Is(err, types.ErrInvalidReplicaCount),
+		errors.Is(err, types.ErrGlobalServiceNotScalable):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetDatabaseBackups lists the backups of a managed database.
+func (c *DeployController) GetDatabaseBackups(f fuego.ContextNoBody) (*types.DatabaseBackupsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := c.service.ListDatabaseBackups(databaseID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseBackupsResponse{
+		Status:  "success",
+		Message: "Database backups retrieved successfully",
+		Data:    backups,
+	}, nil
+}
+
+// CreateDatabaseBackup queues an on-demand backup of a managed database.
+func (c *DeployController) CreateDatabaseBackup(f fuego.ContextNoBody) (*types.DatabaseBackupResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := c.taskService.StartDatabaseBackup(databaseID, user.ID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseBackupResponse{
+		Status:  "success",
+		Message: "Database backup queued",
+		Data:    *backup,
+	}, nil
+}
+
+// UpdateDatabaseBackupSchedule configures scheduled backups and their retention.
+func (c *DeployController) UpdateDatabaseBackupSchedule(f fuego.ContextWithBody[types.UpdateDatabaseBackupScheduleRequest]) (*types.DatabaseResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	database, err := c.service.UpdateDatabaseBackupSchedule(databaseID, &data, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseResponse{
+		Status:  "success",
+		Message: "Database backup schedule updated successfully",
+		Data:    *database,
+	}, nil
+}
+
+// RestoreDatabaseBackup queues restoring a backup into the same or a new database.
+func (c *DeployController) RestoreDatabaseBackup(f fuego.ContextWithBody[types.RestoreDatabaseBackupRequest]) (*types.DatabaseRestoreResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	backupID, err := uuid.Parse(f.PathParam("backup_id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid backup id",
+			Err:    err,
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	restore, err := c.taskService.RestoreDatabaseBackup(f.Request().Context(), databaseID, backupID, &data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseRestoreResponse{
+		Status:  "success",
+		Message: "Database restore queued",
+		Data:    *restore,
+	}, nil
+}
+
+// GetDatabaseRestores lists the restores into a managed database.
+func (c *DeployController) GetDatabaseRestores(f fuego.ContextNoBody) (*types.DatabaseRestoresResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	restores, err := c.service.ListDatabaseRestores(databaseID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseRestoresResponse{
+		Status:  "success",
+		Message: "Database restores retrieved successfully",
+		Data:    restores,
+	}, nil
+}
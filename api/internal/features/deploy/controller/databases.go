@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetDatabases lists the organization's managed databases.
+func (c *DeployController) GetDatabases(f fuego.ContextNoBody) (*types.DatabasesResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databases, err := c.service.ListDatabases(organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabasesResponse{
+		Status:  "success",
+		Message: "Databases retrieved successfully",
+		Data:    databases,
+	}, nil
+}
+
+// CreateDatabase provisions a managed database.
+func (c *DeployController) CreateDatabase(f fuego.ContextWithBody[types.CreateDatabaseRequest]) (*types.DatabaseResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	database, err := c.taskService.CreateDatabase(f.Request().Context(), &data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseResponse{
+		Status:  "success",
+		Message: "Database created successfully",
+		Data:    *database,
+	}, nil
+}
+
+// GetDatabase returns a managed database.
+func (c *DeployController) GetDatabase(f fuego.ContextNoBody) (*types.DatabaseResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := c.service.GetDatabase(databaseID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseResponse{
+		Status:  "success",
+		Message: "Database retrieved successfully",
+		Data:    *database,
+	}, nil
+}
+
+// DeleteDatabase removes a managed database and its data. Its backup dumps
+// are only removed from S3 when delete_backups is true.
+func (c *DeployController) DeleteDatabase(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	deleteBackups, _ := strconv.ParseBool(f.QueryParam("delete_backups"))
+	if err := c.taskService.DeleteDatabase(f.Request().Context(), databaseID, organizationID, deleteBackups); err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Database deleted successfully",
+	}, nil
+}
+
+// ResizeDatabase changes the resource limits of a managed database.
+func (c *DeployController) ResizeDatabase(f fuego.ContextWithBody[types.ResizeDatabaseRequest]) (*types.DatabaseResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	database, err := c.taskService.ResizeDatabase(f.Request().Context(), databaseID, &data, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseResponse{
+		Status:  "success",
+		Message: "Database resized successfully",
+		Data:    *database,
+	}, nil
+}
+
+// RotateDatabasePassword generates a new password for a managed database.
+func (c *DeployController) RotateDatabasePassword(f fuego.ContextWithBody[types.RotateDatabasePasswordRequest]) (*types.RotateDatabasePasswordResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	result, err := c.taskService.RotateDatabasePassword(f.Request().Context(), databaseID, &data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.RotateDatabasePasswordResponse{
+		Status:  "success",
+		Message: "Database password rotated successfully",
+		Data:    *result,
+	}, nil
+}
+
+// GetDatabaseConnection returns the connection info of a managed database.
+func (c *DeployController) GetDatabaseConnection(f fuego.ContextNoBody) (*types.DatabaseConnectionResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.service.GetDatabaseConnection(databaseID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseConnectionResponse{
+		Status:  "success",
+		Message: "Database connection info retrieved successfully",
+		Data:    *info,
+	}, nil
+}
+
+// GetDatabaseApplications lists the applications a database is attached to.
+func (c *DeployController) GetDatabaseApplications(f fuego.ContextNoBody) (*types.DatabaseApplicationsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := c.service.GetDatabaseApplications(databaseID, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.DatabaseApplicationsResponse{
+		Status:  "success",
+		Message: "Database applications retrieved successfully",
+		Data:    apps,
+	}, nil
+}
+
+// AttachDatabase injects a database's connection string into an application.
+func (c *DeployController) AttachDatabase(f fuego.ContextWithBody[types.AttachDatabaseRequest]) (*types.ApplicationDatabaseResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	attachment, err := c.service.AttachDatabase(databaseID, &data, organizationID)
+	if err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.ApplicationDatabaseResponse{
+		Status:  "success",
+		Message: "Database attached, redeploy the application to apply",
+		Data:    *attachment,
+	}, nil
+}
+
+// DetachDatabase removes a database from an application.
+func (c *DeployController) DetachDatabase(f fuego.ContextWithBody[types.DetachDatabaseRequest]) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	databaseID, err := parseDatabaseID(f.PathParam("database_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if err := c.service.DetachDatabase(databaseID, &data, organizationID); err != nil {
+		return nil, c.databaseError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Database detached, redeploy the application to apply",
+	}, nil
+}
+
+func parseDatabaseID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fuego.BadRequestError{
+			Detail: "invalid database id",
+			Err:    err,
+		}
+	}
+	return id, nil
+}
+
+func (c *DeployController) databaseError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound):
+		return fuego.NotFoundError{Detail: "application not found"}
+	case errors.Is(err, types.ErrDatabaseNotFound), errors.Is(err, types.ErrServerNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDatabaseBackupNotFound), errors.Is(err, types.ErrDatabaseRestoreNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDatabaseNameTaken),
+		errors.Is(err, types.ErrDatabaseInUse),
+		errors.Is(err, types.ErrDatabaseAlreadyAttached),
+		errors.Is(err, types.ErrDatabaseEnvVarTaken),
+		errors.Is(err, types.ErrDatabaseNotRunning),
+		errors.Is(err, types.ErrDatabaseBackupInProgress),
+		errors.Is(err, types.ErrDatabaseBackupNotRestorable):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrMissingName),
+		errors.Is(err, types.ErrInvalidDatabaseEngine),
+		errors.Is(err, types.ErrInvalidDatabaseVersion),
+		errors.Is(err, types.ErrInvalidDatabaseResources),
+		errors.Is(err, types.ErrDatabaseServerMismatch),
+		errors.Is(err, types.ErrDatabaseNotAttached),
+		errors.Is(err, types.ErrInvalidEnvVarName),
+		errors.Is(err, types.ErrDatabaseBackupUnsupported),
+		errors.Is(err, types.ErrInvalidBackupSchedule),
+		errors.Is(err, types.ErrInvalidBackupRetention),
+		errors.Is(err, types.ErrInvalidRestoreTarget),
+		errors.Is(err, types.ErrS3NotConfigured):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetDNSProviders lists the organization's DNS providers. Credential values
+// are never returned.
+func (c *DeployController) GetDNSProviders(f fuego.ContextNoBody) (*types.DNSProvidersResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := c.service.ListDNSProviders(organizationID)
+	if err != nil {
+		return nil, c.dnsProviderError(err)
+	}
+
+	return &types.DNSProvidersResponse{
+		Status:  "success",
+		Message: "DNS providers retrieved successfully",
+		Data:    providers,
+	}, nil
+}
+
+// CreateDNSProvider adds a DNS provider and moves the certificates of the
+// domains under its zones to DNS-01 challenges.
+func (c *DeployController) CreateDNSProvider(f fuego.ContextWithBody[types.CreateDNSProviderRequest]) (*types.DNSProviderResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	provider, err := c.service.CreateDNSProvider(&data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.dnsProviderError(err)
+	}
+	c.applyDNSProviders(f.Request().Context(), organizationID)
+
+	return &types.DNSProviderResponse{
+		Status:  "success",
+		Message: "DNS provider created successfully",
+		Data:    *provider,
+	}, nil
+}
+
+// UpdateDNSProvider changes the name, zones or credentials of a DNS provider.
+func (c *DeployController) UpdateDNSProvider(f fuego.ContextWithBody[types.UpdateDNSProviderRequest]) (*types.DNSProviderResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	provider, err := c.service.UpdateDNSProvider(&data, organizationID)
+	if err != nil {
+		return nil, c.dnsProviderError(err)
+	}
+	c.applyDNSProviders(f.Request().Context(), organizationID)
+
+	return &types.DNSProviderResponse{
+		Status:  "success",
+		Message: "DNS provider updated successfully",
+		Data:    *provider,
+	}, nil
+}
+
+// DeleteDNSProvider removes a DNS provider. Its domains go back to
+// certificates from HTTP challenges.
+func (c *DeployController) DeleteDNSProvider(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{Detail: "invalid dns provider id", Err: err}
+	}
+
+	if err := c.service.DeleteDNSProvider(organizationID, id); err != nil {
+		return nil, c.dnsProviderError(err)
+	}
+	c.applyDNSProviders(f.Request().Context(), organizationID)
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "DNS provider deleted successfully",
+	}, nil
+}
+
+// applyDNSProviders pushes the changed providers to Caddy. The change is
+// saved either way and the next reconciliation retries it, so a failure is
+// only logged.
+func (c *DeployController) applyDNSProviders(ctx context.Context, organizationID uuid.UUID) {
+	if err := c.taskService.ApplyDNSProviders(ctx, organizationID); err != nil {
+		c.logger.Log(logger.Warning, "failed to apply dns providers", err.Error())
+	}
+}
+
+func (c *DeployController) dnsProviderError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrDNSProviderNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDNSProviderNameTaken),
+		errors.Is(err, types.ErrDNSZoneTaken):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrUnsupportedDNSProvider),
+		errors.Is(err, types.ErrInvalidDNSProviderName),
+		errors.Is(err, types.ErrInvalidDNSZone),
+		errors.Is(err, types.ErrMissingDNSCredential),
+		errors.Is(err, types.ErrUnknownDNSCredential):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetEnvRevisions lists the environment variable revisions of an application.
+func (c *DeployController) GetEnvRevisions(f fuego.ContextNoBody) (*types.EnvRevisionsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		c.logger.Log(logger.Error, "invalid application id", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	revisions, err := c.service.ListEnvRevisions(appID, organizationID)
+	if err != nil {
+		return nil, c.envRevisionError(err)
+	}
+
+	return &types.EnvRevisionsResponse{
+		Status:  "success",
+		Message: "Environment revisions retrieved successfully",
+		Data:    revisions,
+	}, nil
+}
+
+// DiffEnvRevisions compares two environment revisions by key with masked values.
+// When "to" is omitted the latest revision is used.
+func (c *DeployController) DiffEnvRevisions(f fuego.ContextNoBody) (*types.EnvRevisionDiffResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		c.logger.Log(logger.Error, "invalid application id", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	fromID, err := uuid.Parse(f.QueryParam("from"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid from revision id",
+			Err:    err,
+		}
+	}
+
+	var toID *uuid.UUID
+	if raw := f.QueryParam("to"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fuego.BadRequestError{
+				Detail: "invalid to revision id",
+				Err:    err,
+			}
+		}
+		toID = &parsed
+	}
+
+	diff, err := c.service.DiffEnvRevisions(appID, fromID, toID, organizationID)
+	if err != nil {
+		return nil, c.envRevisionError(err)
+	}
+
+	return &types.EnvRevisionDiffResponse{
+		Status:  "success",
+		Message: "Environment revisions compared successfully",
+		Data:    diff,
+	}, nil
+}
+
+// RestoreEnvRevision restores an application's environment variables to an earlier revision.
+func (c *DeployController) RestoreEnvRevision(f fuego.ContextWithBody[types.RestoreEnvRevisionRequest]) (*types.EnvRevisionResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if data.ApplicationID == uuid.Nil || data.RevisionID == uuid.Nil {
+		return nil, fuego.BadRequestError{
+			Detail: types.ErrMissingID.Error(),
+			Err:    types.ErrMissingID,
+		}
+	}
+
+	revision, err := c.service.RestoreEnvRevision(&data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.envRevisionError(err)
+	}
+
+	return &types.EnvRevisionResponse{
+		Status:  "success",
+		Message: "Environment revision restored, redeploy the application to apply it",
+		Data:    *revision,
+	}, nil
+}
+
+func (c *DeployController) requireUserAndOrganization(w http.ResponseWriter, r *http.Request) (uuid.UUID, error) {
+	user := utils.GetUser(w, r)
+	if user == nil {
+		c.logger.Log(logger.Error, "user not found", "")
+		return uuid.Nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(r)
+	if organizationID == uuid.Nil {
+		c.logger.Log(logger.Error, "organization not found", "")
+		return uuid.Nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+	return organizationID, nil
+}
+
+func (c *DeployController) envRevisionError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound):
+		return fuego.NotFoundError{Detail: "application not found"}
+	case errors.Is(err, types.ErrEnvRevisionNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -1,15 +1,33 @@
 package controller
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-fuego/fuego"
 	"github.com/google/uuid"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
 	"github.com/nixopus/nixopus/api/internal/utils"
 )
 
+// composeStatusTimeout bounds how long reading the live status of compose
+// services may delay a response. The services are returned without a status
+// when it expires.
+const composeStatusTimeout = 3 * time.Second
+
+// attachComposeServiceStatus adds the live status of the compose services of
+// application, within composeStatusTimeout of the request.
+func (c *DeployController) attachComposeServiceStatus(r *http.Request, application *shared_types.Application) {
+	ctx, cancel := context.WithTimeout(r.Context(), composeStatusTimeout)
+	defer cancel()
+	if err := c.taskService.AttachComposeServiceStatus(ctx, application); err != nil {
+		c.logger.Log(logger.Warning, "failed to read compose service status", err.Error())
+	}
+}
+
 func (c *DeployController) GetApplicationById(f fuego.ContextNoBody) (*types.ApplicationResponse, error) {
 	id := f.QueryParam("id")
 
@@ -45,6 +63,9 @@ func (c *DeployController) GetApplicationById(f fuego.ContextNoBody) (*types.App
 		}
 	}
 
+	c.attachComposeServiceStatus(f.Request(), &application)
+	application.SetInternalHostnames()
+
 	return &types.ApplicationResponse{
 		Status:  "success",
 		Message: "Application Retrieved successfully",
@@ -50,6 +50,9 @@ func (c *DeployController) GetApplications(f fuego.ContextNoBody) (*types.ListAp
 			Status: http.StatusInternalServerError,
 		}
 	}
+	for i := range applications {
+		applications[i].SetInternalHostnames()
+	}
 	return &types.ListApplicationsResponse{
 		Status:  "success",
 		Message: "Applications",
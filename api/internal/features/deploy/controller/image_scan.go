@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetDeploymentSBOM returns the SBOM and vulnerability report of a deployment's image.
+func (c *DeployController) GetDeploymentSBOM(f fuego.ContextNoBody) (*types.DeploymentSBOMResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentID, err := uuid.Parse(f.PathParam("deployment_id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid deployment id",
+			Err:    err,
+		}
+	}
+
+	sbom, err := c.service.GetDeploymentSBOM(deploymentID, organizationID)
+	if err != nil {
+		return nil, c.imageScanError(err)
+	}
+
+	return &types.DeploymentSBOMResponse{
+		Status:  "success",
+		Message: "Deployment SBOM retrieved successfully",
+		Data:    *sbom,
+	}, nil
+}
+
+// FindPackageDeployments lists the deployments whose image contains a package.
+func (c *DeployController) FindPackageDeployments(f fuego.ContextNoBody) (*types.PackageDeploymentsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	deployments, err := c.service.FindPackageDeployments(organizationID, f.QueryParam("name"), f.QueryParam("version"))
+	if err != nil {
+		return nil, c.imageScanError(err)
+	}
+
+	return &types.PackageDeploymentsResponse{
+		Status:  "success",
+		Message: "Package deployments retrieved successfully",
+		Data:    deployments,
+	}, nil
+}
+
+func (c *DeployController) imageScanError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrSBOMNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrMissingPackageName):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
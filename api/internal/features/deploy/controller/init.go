@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/service"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
@@ -42,6 +43,7 @@ func NewDeployController(
 	github_service := github_service.NewGithubConnectorService(store, ctx, l, &github_storage.GithubConnectorStorage{DB: store.DB, Ctx: ctx})
 	taskService := tasks.NewTaskService(&deployStorage, l, github_service, store, notifier)
 	taskService.SetupCreateDeploymentQueue()
+	caddy.SetDomainStore(&deployStorage)
 
 	// TODO: Re-enable reconciler and health monitor once systemd-based Caddy
 	// support is fully validated on trail VMs.
@@ -54,6 +56,9 @@ func NewDeployController(
 	// healthMonitor.Start(ctx)
 
 	taskService.StartConsumers(ctx)
+	taskService.StartDatabaseBackupScheduler(ctx)
+	taskService.StartIdleMonitor(ctx)
+	taskService.StartAutoscaler(ctx)
 
 	return &DeployController{
 		store:         store,
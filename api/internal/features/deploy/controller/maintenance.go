@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetMaintenance lists the maintenance settings of an application.
+func (c *DeployController) GetMaintenance(f fuego.ContextNoBody) (*types.MaintenanceListResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := c.service.ListMaintenance(appID, organizationID)
+	if err != nil {
+		return nil, c.maintenanceError(err)
+	}
+
+	return &types.MaintenanceListResponse{
+		Status:  "success",
+		Message: "Maintenance settings retrieved successfully",
+		Data:    settings,
+	}, nil
+}
+
+// UpdateMaintenance turns maintenance mode of an application or one of its
+// domains on or off and swaps its Caddy routes.
+func (c *DeployController) UpdateMaintenance(f fuego.ContextWithBody[types.UpdateMaintenanceRequest]) (*types.MaintenanceResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	maintenance, err := c.taskService.UpdateMaintenance(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		return nil, c.maintenanceError(err)
+	}
+
+	message := "Maintenance mode disabled"
+	if maintenance.Enabled {
+		message = "Maintenance mode enabled"
+	}
+	return &types.MaintenanceResponse{
+		Status:  "success",
+		Message: message,
+		Data:    *maintenance,
+	}, nil
+}
+
+// DeleteMaintenance removes the maintenance setting of an application, or
+// the override of one of its domains.
+func (c *DeployController) DeleteMaintenance(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.taskService.DeleteMaintenance(f.Request().Context(), appID, f.QueryParam("domain"), organizationID); err != nil {
+		return nil, c.maintenanceError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Maintenance setting removed successfully",
+	}, nil
+}
+
+func (c *DeployController) maintenanceError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrMaintenanceNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrDomainNotInApplication),
+		errors.Is(err, types.ErrInvalidMaintenanceIP),
+		errors.Is(err, types.ErrInvalidRetryAfter),
+		errors.Is(err, types.ErrMaintenanceBodyTooLarge):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -24,37 +24,49 @@ func (c *DeployController) PreviewComposeServices(f fuego.ContextWithBody[types.
 	if data.Branch == "" {
 		return nil, fuego.BadRequestError{Detail: types.ErrMissingBranch.Error(), Err: types.ErrMissingBranch}
 	}
+	if err := c.validator.ValidateRequest(&data); err != nil {
+		return nil, fuego.BadRequestError{Detail: err.Error(), Err: err}
+	}
 
 	user := utils.GetUser(f.Response(), f.Request())
 	if user == nil {
 		return nil, fuego.UnauthorizedError{Detail: "authentication required"}
 	}
 
-	filePath := composeFilePath(data.BasePath, data.DockerfilePath)
-	c.logger.Log(logger.Info, "preview-compose: resolved file path", fmt.Sprintf("repo=%s branch=%s basePath=%q dockerfilePath=%q -> filePath=%q", data.Repository, data.Branch, data.BasePath, data.DockerfilePath, filePath))
+	filePaths := []string{composeFilePath(data.BasePath, data.DockerfilePath)}
+	for _, f := range data.ComposeFiles {
+		filePaths = append(filePaths, path.Join(composeBaseDir(data.BasePath), f))
+	}
+	c.logger.Log(logger.Info, "preview-compose: resolved file paths", fmt.Sprintf("repo=%s branch=%s basePath=%q dockerfilePath=%q -> files=%q profiles=%q", data.Repository, data.Branch, data.BasePath, data.DockerfilePath, filePaths, data.ComposeProfiles))
 
-	content, err := c.githubService.GetRepositoryFileContent(
-		user.ID.String(), data.Repository, data.Branch, filePath,
-	)
-	if err != nil {
-		c.logger.Log(logger.Warning, "preview-compose: failed to fetch from GitHub", err.Error())
-		return nil, fuego.HTTPError{
-			Err:    err,
-			Detail: err.Error(),
-			Status: http.StatusUnprocessableEntity,
+	layers := make([][]tasks.ParsedComposeService, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		content, err := c.githubService.GetRepositoryFileContent(
+			user.ID.String(), data.Repository, data.Branch, filePath,
+		)
+		if err != nil {
+			c.logger.Log(logger.Warning, "preview-compose: failed to fetch from GitHub", err.Error())
+			return nil, fuego.HTTPError{
+				Err:    err,
+				Detail: fmt.Sprintf("%s: %s", filePath, err.Error()),
+				Status: http.StatusUnprocessableEntity,
+			}
 		}
-	}
-	c.logger.Log(logger.Info, "preview-compose: fetched file", fmt.Sprintf("content length=%d bytes, first 200 chars: %s", len(content), truncate(string(content), 200)))
+		c.logger.Log(logger.Info, "preview-compose: fetched file", fmt.Sprintf("file=%s content length=%d bytes, first 200 chars: %s", filePath, len(content), truncate(string(content), 200)))
 
-	parsed, err := tasks.ParseComposeYAML(content)
-	if err != nil {
-		c.logger.Log(logger.Warning, "preview-compose: YAML parse error", err.Error())
-		return nil, fuego.HTTPError{
-			Err:    err,
-			Detail: err.Error(),
-			Status: http.StatusUnprocessableEntity,
+		parsed, err := tasks.ParseComposeYAML(content)
+		if err != nil {
+			c.logger.Log(logger.Warning, "preview-compose: YAML parse error", err.Error())
+			return nil, fuego.HTTPError{
+				Err:    err,
+				Detail: fmt.Sprintf("%s: %s", filePath, err.Error()),
+				Status: http.StatusUnprocessableEntity,
+			}
 		}
+		layers = append(layers, parsed)
 	}
+
+	parsed := tasks.FilterComposeProfiles(tasks.MergeComposeServices(layers...), data.ComposeProfiles)
 	c.logger.Log(logger.Info, "preview-compose: parsed services", fmt.Sprintf("count=%d", len(parsed)))
 	for _, p := range parsed {
 		c.logger.Log(logger.Info, "preview-compose: service detail", fmt.Sprintf("name=%s ports=%v", p.ServiceName, p.Ports))
@@ -77,14 +89,18 @@ func (c *DeployController) PreviewComposeServices(f fuego.ContextWithBody[types.
 }
 
 func composeFilePath(basePath, dockerfilePath string) string {
-	if basePath == "" || basePath == "/" {
-		basePath = "."
-	}
 	fileName := "docker-compose.yml"
 	if dockerfilePath != "" && dockerfilePath != "Dockerfile" {
 		fileName = dockerfilePath
 	}
-	return path.Join(basePath, fileName)
+	return path.Join(composeBaseDir(basePath), fileName)
+}
+
+func composeBaseDir(basePath string) string {
+	if basePath == "" || basePath == "/" {
+		return "."
+	}
+	return basePath
 }
 
 func truncate(s string, maxLen int) string {
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetProxySettings returns the proxy settings of an application.
+func (c *DeployController) GetProxySettings(f fuego.ContextNoBody) (*types.ProxySettingsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := c.service.GetProxySettings(appID, organizationID)
+	if err != nil {
+		return nil, c.proxySettingsError(err)
+	}
+
+	return &types.ProxySettingsResponse{
+		Status:  "success",
+		Message: "Proxy settings retrieved successfully",
+		Data:    *settings,
+	}, nil
+}
+
+// UpdateProxySettings replaces the proxy settings of an application and
+// applies them to its Caddy routes.
+func (c *DeployController) UpdateProxySettings(f fuego.ContextWithBody[types.UpdateProxySettingsRequest]) (*types.ProxySettingsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	settings, err := c.taskService.UpdateProxySettings(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		return nil, c.proxySettingsError(err)
+	}
+
+	return &types.ProxySettingsResponse{
+		Status:  "success",
+		Message: "Proxy settings updated successfully",
+		Data:    *settings,
+	}, nil
+}
+
+// DeleteProxySettings removes the proxy settings of an application.
+func (c *DeployController) DeleteProxySettings(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.taskService.DeleteProxySettings(f.Request().Context(), appID, organizationID); err != nil {
+		return nil, c.proxySettingsError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Proxy settings removed successfully",
+	}, nil
+}
+
+func (c *DeployController) proxySettingsError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrProxySettingsNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrInvalidProxyHeader),
+		errors.Is(err, types.ErrInvalidProxyEncoding),
+		errors.Is(err, types.ErrInvalidRequestBodyLimit),
+		errors.Is(err, types.ErrInvalidUpstreamTimeout),
+		errors.Is(err, types.ErrInvalidRateLimit),
+		errors.Is(err, types.ErrEmptyProxySettings):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetRedirects lists the redirect rules of an application.
+func (c *DeployController) GetRedirects(f fuego.ContextNoBody) (*types.RedirectsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	redirects, err := c.service.ListRedirects(appID, organizationID)
+	if err != nil {
+		return nil, c.redirectError(err)
+	}
+
+	return &types.RedirectsResponse{
+		Status:  "success",
+		Message: "Redirects retrieved successfully",
+		Data:    redirects,
+	}, nil
+}
+
+// CreateRedirect adds a redirect rule to an application and routes it.
+func (c *DeployController) CreateRedirect(f fuego.ContextWithBody[types.CreateRedirectRequest]) (*types.RedirectResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	redirect, err := c.taskService.CreateRedirect(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		return nil, c.redirectError(err)
+	}
+
+	return &types.RedirectResponse{
+		Status:  "success",
+		Message: "Redirect created successfully",
+		Data:    *redirect,
+	}, nil
+}
+
+// UpdateRedirect changes the source, target or options of a redirect rule.
+func (c *DeployController) UpdateRedirect(f fuego.ContextWithBody[types.UpdateRedirectRequest]) (*types.RedirectResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	redirect, err := c.taskService.UpdateRedirect(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		return nil, c.redirectError(err)
+	}
+
+	return &types.RedirectResponse{
+		Status:  "success",
+		Message: "Redirect updated successfully",
+		Data:    *redirect,
+	}, nil
+}
+
+// DeleteRedirect removes a redirect rule.
+func (c *DeployController) DeleteRedirect(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{Detail: "invalid redirect id", Err: err}
+	}
+
+	if err := c.taskService.DeleteRedirect(f.Request().Context(), organizationID, id); err != nil {
+		return nil, c.redirectError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Redirect deleted successfully",
+	}, nil
+}
+
+func (c *DeployController) redirectError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrRedirectNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrRedirectExists),
+		errors.Is(err, types.ErrRedirectDomainTaken):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrInvalidRedirectDomain),
+		errors.Is(err, types.ErrInvalidRedirectPath),
+		errors.Is(err, types.ErrInvalidRedirectStatus),
+		errors.Is(err, types.ErrRedirectLoop):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
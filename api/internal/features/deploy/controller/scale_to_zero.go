@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/go-fuego/fuego"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+)
+
+// GetScaleToZero returns the idle timeout of an application and whether it
+// is currently scaled to zero.
+func (c *DeployController) GetScaleToZero(f fuego.ContextNoBody) (*types.ScaleToZeroResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.service.GetScaleToZero(appID, organizationID)
+	if err != nil {
+		return nil, c.scaleToZeroError(err)
+	}
+
+	return &types.ScaleToZeroResponse{
+		Status:  "success",
+		Message: "Scale-to-zero settings retrieved successfully",
+		Data:    *state,
+	}, nil
+}
+
+// UpdateScaleToZero sets the idle timeout after which an application is
+// scaled to zero. A timeout of zero disables it.
+func (c *DeployController) UpdateScaleToZero(f fuego.ContextWithBody[types.UpdateScaleToZeroRequest]) (*types.ScaleToZeroResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	state, err := c.taskService.UpdateScaleToZero(f.Request().Context(), data.ApplicationID, data.IdleTimeoutMinutes, organizationID)
+	if err != nil {
+		return nil, c.scaleToZeroError(err)
+	}
+
+	return &types.ScaleToZeroResponse{
+		Status:  "success",
+		Message: "Scale-to-zero settings updated successfully",
+		Data:    *state,
+	}, nil
+}
+
+// WakeHandler serves requests Caddy routes to sleeping applications. It
+// scales the application of the request's host back up, holds the request
+// until the application is healthy and then proxies it through.
+func (c *DeployController) WakeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		app, err := c.storage.GetApplicationByDomain(strings.ToLower(host))
+		if err != nil {
+			http.Error(w, "application not found", http.StatusNotFound)
+			return
+		}
+		app, err = c.storage.GetApplicationById(app.ID.String(), app.OrganizationID)
+		if err != nil {
+			http.Error(w, "application not found", http.StatusNotFound)
+			return
+		}
+
+		// Waking continues when the client gives up, so the next request
+		// finds the application running.
+		dial, err := c.taskService.WakeApplication(context.WithoutCancel(r.Context()), &app)
+		if errors.Is(err, types.ErrApplicationNotSleeping) {
+			http.Error(w, "application not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			c.logger.Log(logger.Error, "failed to wake application "+app.Name, err.Error())
+			w.Header().Set("Retry-After", "10")
+			http.Error(w, "application is starting, try again shortly", http.StatusServiceUnavailable)
+			return
+		}
+
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.Out.URL.Scheme = "http"
+				pr.Out.URL.Host = dial
+				pr.Out.Host = pr.In.Host
+				pr.SetXForwarded()
+			},
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+func (c *DeployController) scaleToZeroError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrServerNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrScaleToZeroUnsupported),
+		errors.Is(err, types.ErrScaleToZeroPathPrefix),
+		errors.Is(err, types.ErrWakeProxyNotConfigured),
+		errors.Is(err, types.ErrInvalidIdleTimeout):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
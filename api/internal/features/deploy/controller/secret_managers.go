@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetSecretManagers lists the organization's secret manager connections. Tokens are never returned.
+func (c *DeployController) GetSecretManagers(f fuego.ContextNoBody) (*types.SecretManagersResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	managers, err := c.service.ListSecretManagers(organizationID)
+	if err != nil {
+		return nil, c.secretManagerError(err)
+	}
+
+	return &types.SecretManagersResponse{
+		Status:  "success",
+		Message: "Secret managers retrieved successfully",
+		Data:    managers,
+	}, nil
+}
+
+// UpsertSecretManager creates or updates the organization's connection to a secret manager.
+func (c *DeployController) UpsertSecretManager(f fuego.ContextWithBody[types.UpsertSecretManagerRequest]) (*types.SecretManagerResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	manager, err := c.service.UpsertSecretManager(&data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.secretManagerError(err)
+	}
+
+	return &types.SecretManagerResponse{
+		Status:  "success",
+		Message: "Secret manager saved successfully",
+		Data:    *manager,
+	}, nil
+}
+
+// DeleteSecretManager removes the organization's connection to a secret manager.
+func (c *DeployController) DeleteSecretManager(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	managerType := secrets.SecretManagerType(f.QueryParam("type"))
+	if err := c.service.DeleteSecretManager(organizationID, managerType); err != nil {
+		return nil, c.secretManagerError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Secret manager deleted successfully",
+	}, nil
+}
+
+// TestSecretManager verifies a secret manager connection by listing the keys at a path.
+func (c *DeployController) TestSecretManager(f fuego.ContextWithBody[types.TestSecretManagerRequest]) (*types.TestSecretManagerResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	keys, err := c.service.TestSecretManager(f.Request().Context(), &data, organizationID)
+	if err != nil {
+		if errors.Is(err, types.ErrSecretManagerNotFound) || errors.Is(err, types.ErrInvalidSecretManagerURL) {
+			return nil, c.secretManagerError(err)
+		}
+		// Only the status code is reported, so the endpoint cannot be used to
+		// read responses of, or connection errors from, arbitrary hosts.
+		c.logger.Log(logger.Warning, "secret manager test failed", err.Error())
+		detail := "failed to reach the secret manager"
+		var statusErr *secrets.StatusError
+		if errors.As(err, &statusErr) {
+			detail = "failed to read from secret manager: " + statusErr.Error()
+		}
+		return nil, fuego.HTTPError{
+			Err:    err,
+			Detail: detail,
+			Status: http.StatusBadGateway,
+		}
+	}
+
+	return &types.TestSecretManagerResponse{
+		Status:  "success",
+		Message: "Secret manager connection succeeded",
+		Data:    keys,
+	}, nil
+}
+
+func (c *DeployController) secretManagerError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrSecretManagerNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrUnsupportedSecretManager),
+		errors.Is(err, types.ErrInvalidSecretManagerURL),
+		errors.Is(err, types.ErrMissingSecretManagerToken):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
 	"github.com/nixopus/nixopus/api/internal/utils"
 )
 
@@ -31,7 +32,7 @@ func (c *DeployController) IsDomainAlreadyTaken(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	is_taken, err := c.service.IsDomainAlreadyTaken(request.Domain)
+	is_taken, err := c.service.IsDomainAlreadyTaken(request.Domain, utils.GetOrganizationID(r))
 
 	if err != nil {
 		c.logger.Log(logger.Error, err.Error(), err.Error())
@@ -39,7 +40,8 @@ func (c *DeployController) IsDomainAlreadyTaken(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	is_valid, err := c.service.IsDomainValid(request.Domain)
+	host, _, routeOK := shared_types.SplitDomainRoute(request.Domain)
+	is_valid, err := c.service.IsDomainValid(host)
 
 	if err != nil {
 		c.logger.Log(logger.Error, err.Error(), err.Error())
@@ -47,7 +49,7 @@ func (c *DeployController) IsDomainAlreadyTaken(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if is_taken || !is_valid {
+	if is_taken || !is_valid || !routeOK {
 		utils.SendJSONResponse(w, "success", "", false)
 		return
 	}
@@ -61,7 +63,7 @@ func (c *DeployController) IsPortAlreadyTaken(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	value, err := c.service.IsPortAlreadyTaken(request.Port)
+	value, err := c.service.IsPortAlreadyTaken(request.Port, utils.GetOrganizationID(r), request.ServerID)
 
 	if err != nil {
 		c.logger.Log(logger.Error, err.Error(), err.Error())
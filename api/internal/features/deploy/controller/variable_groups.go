@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetVariableGroups lists the organization's variable groups. With family_id only
+// organization-wide groups and groups of that family are returned.
+func (c *DeployController) GetVariableGroups(f fuego.ContextNoBody) (*types.VariableGroupsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	var familyID *uuid.UUID
+	if raw := f.QueryParam("family_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fuego.BadRequestError{
+				Detail: "invalid family id",
+				Err:    err,
+			}
+		}
+		familyID = &parsed
+	}
+
+	groups, err := c.service.ListVariableGroups(organizationID, familyID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.VariableGroupsResponse{
+		Status:  "success",
+		Message: "Variable groups retrieved successfully",
+		Data:    groups,
+	}, nil
+}
+
+// CreateVariableGroup creates a shared variable group.
+func (c *DeployController) CreateVariableGroup(f fuego.ContextWithBody[types.CreateVariableGroupRequest]) (*types.VariableGroupResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	group, err := c.service.CreateVariableGroup(&data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.VariableGroupResponse{
+		Status:  "success",
+		Message: "Variable group created successfully",
+		Data:    *group,
+	}, nil
+}
+
+// UpdateVariableGroup updates a variable group. The response lists the applications
+// using the group so the client can offer to redeploy them.
+func (c *DeployController) UpdateVariableGroup(f fuego.ContextWithBody[types.UpdateVariableGroupRequest]) (*types.UpdateVariableGroupResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if data.ID == uuid.Nil {
+		return nil, fuego.BadRequestError{
+			Detail: types.ErrMissingID.Error(),
+			Err:    types.ErrMissingID,
+		}
+	}
+
+	result, err := c.service.UpdateVariableGroup(&data, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.UpdateVariableGroupResponse{
+		Status:  "success",
+		Message: "Variable group updated successfully",
+		Data:    *result,
+	}, nil
+}
+
+// DeleteVariableGroup deletes a variable group and detaches it from all applications.
+func (c *DeployController) DeleteVariableGroup(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	groupID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid variable group id",
+			Err:    err,
+		}
+	}
+
+	if err := c.service.DeleteVariableGroup(groupID, organizationID); err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Variable group deleted successfully",
+	}, nil
+}
+
+// GetVariableGroupApplications lists the applications using a variable group.
+func (c *DeployController) GetVariableGroupApplications(f fuego.ContextNoBody) (*types.VariableGroupApplicationsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	groupID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid variable group id",
+			Err:    err,
+		}
+	}
+
+	if _, err := c.service.GetVariableGroup(groupID, organizationID); err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	apps, err := c.service.GetVariableGroupApplications(groupID, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.VariableGroupApplicationsResponse{
+		Status:  "success",
+		Message: "Variable group applications retrieved successfully",
+		Data:    apps,
+	}, nil
+}
+
+// RedeployVariableGroup queues a redeploy for every application using a variable group.
+func (c *DeployController) RedeployVariableGroup(f fuego.ContextWithBody[types.RedeployVariableGroupRequest]) (*types.RedeployVariableGroupResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if _, err := c.service.GetVariableGroup(data.ID, organizationID); err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	result, err := c.taskService.RedeployVariableGroupApplications(data.ID, data.Force, user.ID, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	status, msg := "success", "Redeploy queued for applications using the variable group"
+	if len(result.Failed) > 0 && len(result.Redeployed) == 0 {
+		status, msg = "failed", "All redeploys failed"
+	} else if len(result.Failed) > 0 {
+		status, msg = "partial", "Some applications failed to redeploy"
+	}
+
+	return &types.RedeployVariableGroupResponse{
+		Status:  status,
+		Message: msg,
+		Data:    *result,
+	}, nil
+}
+
+// GetApplicationVariableGroups lists the variable groups attached to an application.
+func (c *DeployController) GetApplicationVariableGroups(f fuego.ContextNoBody) (*types.ApplicationVariableGroupsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := uuid.Parse(f.QueryParam("id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+
+	groups, err := c.service.GetApplicationVariableGroups(appID, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.ApplicationVariableGroupsResponse{
+		Status:  "success",
+		Message: "Application variable groups retrieved successfully",
+		Data:    groups,
+	}, nil
+}
+
+// SetApplicationVariableGroups replaces the variable groups attached to an application.
+func (c *DeployController) SetApplicationVariableGroups(f fuego.ContextWithBody[types.SetApplicationVariableGroupsRequest]) (*types.ApplicationVariableGroupsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		c.logger.Log(logger.Error, "failed to read request body", err.Error())
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	groups, err := c.service.SetApplicationVariableGroups(&data, organizationID)
+	if err != nil {
+		return nil, c.variableGroupError(err)
+	}
+
+	return &types.ApplicationVariableGroupsResponse{
+		Status:  "success",
+		Message: "Application variable groups updated, redeploy the application to apply them",
+		Data:    groups,
+	}, nil
+}
+
+func (c *DeployController) variableGroupError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound):
+		return fuego.NotFoundError{Detail: "application not found"}
+	case errors.Is(err, types.ErrVariableGroupNotFound), errors.Is(err, types.ErrProjectFamilyNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrMissingName),
+		errors.Is(err, types.ErrVariableGroupNameTaken),
+		errors.Is(err, types.ErrVariableGroupFamilyMismatch),
+		errors.Is(err, types.ErrDuplicateVariableGroup):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
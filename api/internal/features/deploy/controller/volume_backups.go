@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// GetVolumeBackupPolicies lists the volume backup policies of an application.
+func (c *DeployController) GetVolumeBackupPolicies(f fuego.ContextNoBody) (*types.VolumeBackupPoliciesResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := c.service.ListVolumeBackupPolicies(appID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeBackupPoliciesResponse{
+		Status:  "success",
+		Message: "Volume backup policies retrieved successfully",
+		Data:    policies,
+	}, nil
+}
+
+// CreateVolumeBackupPolicy sets up backups of a volume or bind mounted path
+// of an application.
+func (c *DeployController) CreateVolumeBackupPolicy(f fuego.ContextWithBody[types.CreateVolumeBackupPolicyRequest]) (*types.VolumeBackupPolicyResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	if err := c.taskService.CheckVolumeBackupSource(f.Request().Context(), &data, organizationID); err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	policy, err := c.service.CreateVolumeBackupPolicy(&data, user.ID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeBackupPolicyResponse{
+		Status:  "success",
+		Message: "Volume backup policy created successfully",
+		Data:    *policy,
+	}, nil
+}
+
+// UpdateVolumeBackupPolicy replaces the schedule and retention of a policy.
+func (c *DeployController) UpdateVolumeBackupPolicy(f fuego.ContextWithBody[types.UpdateVolumeBackupPolicyRequest]) (*types.VolumeBackupPolicyResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	policyID, err := parseVolumeBackupPolicyID(f.PathParam("policy_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+			Err:    err,
+		}
+	}
+
+	policy, err := c.service.UpdateVolumeBackupPolicy(policyID, &data, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeBackupPolicyResponse{
+		Status:  "success",
+		Message: "Volume backup policy updated successfully",
+		Data:    *policy,
+	}, nil
+}
+
+// DeleteVolumeBackupPolicy removes a policy together with its backups.
+func (c *DeployController) DeleteVolumeBackupPolicy(f fuego.ContextNoBody) (*types.MessageResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	policyID, err := parseVolumeBackupPolicyID(f.PathParam("policy_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.taskService.DeleteVolumeBackupPolicy(f.Request().Context(), policyID, organizationID); err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.MessageResponse{
+		Status:  "success",
+		Message: "Volume backup policy deleted successfully",
+	}, nil
+}
+
+// RunVolumeBackup queues an on-demand backup of a policy's source.
+func (c *DeployController) RunVolumeBackup(f fuego.ContextNoBody) (*types.VolumeBackupResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	policyID, err := parseVolumeBackupPolicyID(f.PathParam("policy_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := c.taskService.StartVolumeBackup(policyID, user.ID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeBackupResponse{
+		Status:  "success",
+		Message: "Volume backup queued",
+		Data:    *backup,
+	}, nil
+}
+
+// GetVolumeBackups lists the volume backups of an application with their
+// size, duration and status.
+func (c *DeployController) GetVolumeBackups(f fuego.ContextNoBody) (*types.VolumeBackupsResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := c.service.ListVolumeBackups(appID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeBackupsResponse{
+		Status:  "success",
+		Message: "Volume backups retrieved successfully",
+		Data:    backups,
+	}, nil
+}
+
+// RestoreVolumeBackup queues replacing the contents of a volume with a backup.
+func (c *DeployController) RestoreVolumeBackup(f fuego.ContextNoBody) (*types.VolumeRestoreResponse, error) {
+	user := utils.GetUser(f.Response(), f.Request())
+	if user == nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "authentication required",
+		}
+	}
+
+	organizationID := utils.GetOrganizationID(f.Request())
+	if organizationID == uuid.Nil {
+		return nil, fuego.UnauthorizedError{
+			Detail: "organization not found",
+		}
+	}
+
+	backupID, err := uuid.Parse(f.PathParam("backup_id"))
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: "invalid backup id",
+			Err:    err,
+		}
+	}
+
+	restore, err := c.taskService.RestoreVolumeBackup(backupID, user.ID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeRestoreResponse{
+		Status:  "success",
+		Message: "Volume restore queued",
+		Data:    *restore,
+	}, nil
+}
+
+// GetVolumeRestores lists the volume restores of an application.
+func (c *DeployController) GetVolumeRestores(f fuego.ContextNoBody) (*types.VolumeRestoresResponse, error) {
+	organizationID, err := c.requireUserAndOrganization(f.Response(), f.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := parseApplicationQueryID(f.QueryParam("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	restores, err := c.service.ListVolumeRestores(appID, organizationID)
+	if err != nil {
+		return nil, c.volumeBackupError(err)
+	}
+
+	return &types.VolumeRestoresResponse{
+		Status:  "success",
+		Message: "Volume restores retrieved successfully",
+		Data:    restores,
+	}, nil
+}
+
+func parseApplicationQueryID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fuego.BadRequestError{
+			Detail: "invalid application id",
+			Err:    err,
+		}
+	}
+	return id, nil
+}
+
+func parseVolumeBackupPolicyID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fuego.BadRequestError{
+			Detail: "invalid policy id",
+			Err:    err,
+		}
+	}
+	return id, nil
+}
+
+func (c *DeployController) volumeBackupError(err error) error {
+	switch {
+	case errors.Is(err, types.ErrApplicationNotFound),
+		errors.Is(err, types.ErrVolumeBackupPolicyNotFound),
+		errors.Is(err, types.ErrVolumeBackupNotFound),
+		errors.Is(err, types.ErrServerNotFound):
+		return fuego.NotFoundError{Detail: err.Error()}
+	case errors.Is(err, types.ErrVolumeBackupPolicyExists),
+		errors.Is(err, types.ErrVolumeBackupInProgress):
+		return fuego.ConflictError{Detail: err.Error(), Err: err}
+	case errors.Is(err, types.ErrInvalidVolumeBackupSource),
+		errors.Is(err, types.ErrVolumeBackupSourceNotMounted),
+		errors.Is(err, types.ErrInvalidBackupSchedule),
+		errors.Is(err, types.ErrInvalidBackupRetention),
+		errors.Is(err, types.ErrVolumeBackupNotRestorable),
+		errors.Is(err, types.ErrS3NotConfigured):
+		return fuego.BadRequestError{Detail: err.Error(), Err: err}
+	default:
+		c.logger.Log(logger.Error, err.Error(), "")
+		return fuego.HTTPError{
+			Err:    err,
+			Detail: err.Error(),
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
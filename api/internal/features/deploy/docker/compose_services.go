@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// Labels set by docker compose on every container it creates.
+const (
+	ComposeWorkingDirLabel = "com.docker.compose.project.working_dir"
+	ComposeServiceLabel    = "com.docker.compose.service"
+)
+
+// ComposeRestartService restarts the containers of a single compose service.
+func (s *DockerService) ComposeRestartService(project ComposeProject, serviceName string, envVars map[string]string) (string, error) {
+	command, err := s.buildComposeCommand("restart "+utils.ShellQuote(serviceName), project, envVars)
+	if err != nil {
+		return "", err
+	}
+	return s.executeSSHCommandWithOutput(command, nil, "failed to restart docker compose service")
+}
+
+// ComposeStopService stops the containers of a single compose service without removing them.
+func (s *DockerService) ComposeStopService(project ComposeProject, serviceName string, envVars map[string]string) (string, error) {
+	command, err := s.buildComposeCommand("stop "+utils.ShellQuote(serviceName), project, envVars)
+	if err != nil {
+		return "", err
+	}
+	return s.executeSSHCommandWithOutput(command, nil, "failed to stop docker compose service")
+}
+
+// ComposeStartService starts the stopped containers of a single compose service.
+func (s *DockerService) ComposeStartService(project ComposeProject, serviceName string, envVars map[string]string) (string, error) {
+	command, err := s.buildComposeCommand("start "+utils.ShellQuote(serviceName), project, envVars)
+	if err != nil {
+		return "", err
+	}
+	return s.executeSSHCommandWithOutput(command, nil, "failed to start docker compose service")
+}
+
+// ComposeScaleService sets the number of containers of a single compose service.
+// Other services and the existing containers of this service are left untouched.
+func (s *DockerService) ComposeScaleService(project ComposeProject, serviceName string, replicas int, envVars map[string]string) (string, error) {
+	action := fmt.Sprintf("up -d --no-deps --no-recreate --scale %s %s",
+		utils.ShellQuote(fmt.Sprintf("%s=%d", serviceName, replicas)),
+		utils.ShellQuote(serviceName),
+	)
+	command, err := s.buildComposeCommand(action, project, envVars)
+	if err != nil {
+		return "", err
+	}
+	return s.executeSSHCommandWithOutput(command, nil, "failed to scale docker compose service")
+}
+
+// ListComposeContainers returns all containers, running or not, of the compose
+// project whose working directory is projectDir.
+func (s *DockerService) ListComposeContainers(projectDir string) ([]container.Summary, error) {
+	return s.Cli.ContainerList(s.Ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", ComposeWorkingDirLabel+"="+projectDir)),
+	})
+}
+
+// GetComposeServiceLogs returns the last tail lines of every container of a
+// compose service. Output of scaled services is grouped per container.
+func (s *DockerService) GetComposeServiceLogs(projectDir string, serviceName string, tail int) (string, error) {
+	containers, err := s.Cli.ContainerList(s.Ctx, container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", ComposeWorkingDirLabel+"="+projectDir),
+			filters.Arg("label", ComposeServiceLabel+"="+serviceName),
+		),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list service containers: %w", err)
+	}
+
+	var out bytes.Buffer
+	for _, c := range containers {
+		logs, err := s.readContainerLogs(c.ID, tail)
+		if err != nil {
+			return "", err
+		}
+		if len(containers) > 1 {
+			name := c.ID
+			if len(c.Names) > 0 {
+				name = c.Names[0]
+			}
+			fmt.Fprintf(&out, "==> %s <==\n", name)
+		}
+		out.Write(logs)
+	}
+	return out.String(), nil
+}
+
+func (s *DockerService) readContainerLogs(containerID string, tail int) ([]byte, error) {
+	inspect, err := s.Cli.ContainerInspect(s.Ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	reader, err := s.Cli.ContainerLogs(s.Ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       strconv.Itoa(tail),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if inspect.Config != nil && inspect.Config.Tty {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container logs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
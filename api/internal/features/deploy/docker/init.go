@@ -3,8 +3,10 @@ package docker
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -50,7 +52,7 @@ type cachedDockerService struct {
 }
 
 var (
-	// dockerServiceCache caches DockerService instances per organization UUID to avoid
+	// dockerServiceCache caches DockerService instances per organization or server UUID to avoid
 	// creating a new SSH tunnel + Docker client on every call. This prevents the DoS-like
 	// pattern of rapidly spawning tunnels during live dev sessions.
 	dockerServiceCache sync.Map // map[uuid.UUID]*cachedDockerService
@@ -84,13 +86,14 @@ type DockerRepository interface {
 	ContainerLogs(ctx context.Context, containerID string, opts container.LogsOptions) (io.ReadCloser, error)
 	RestartContainer(containerID string, opts container.StopOptions) error
 	UpdateContainerResources(containerID string, resources container.UpdateConfig) (container.ContainerUpdateOKBody, error)
-
-	ComposeUp(composeFilePath string, envVars map[string]string) (string, error)
-	ComposeUpWithCallback(composeFilePath string, envVars map[string]string, outputCallback func(string)) (string, error)
-	ComposeDown(composeFilePath string) error
-	ComposeDownWithCallback(composeFilePath string, outputCallback func(string)) error
-	ComposeRestart(composeFilePath string, envVars map[string]string, outputCallback func(string)) error
-	ComposeBuild(composeFilePath string, envVars map[string]string) error
+	GetContainerStats(containerID string) (container.StatsResponse, error)
+
+	ComposeUp(project ComposeProject, envVars map[string]string) (string, error)
+	ComposeUpWithCallback(project ComposeProject, envVars map[string]string, outputCallback func(string)) (string, error)
+	ComposeDown(project ComposeProject) error
+	ComposeDownWithCallback(project ComposeProject, outputCallback func(string)) error
+	ComposeRestart(project ComposeProject, envVars map[string]string, outputCallback func(string)) error
+	ComposeBuild(project ComposeProject, envVars map[string]string) error
 	RemoveImage(imageName string, opts image.RemoveOptions) error
 	PruneBuildCache(opts types.BuildCachePruneOptions) error
 	PruneImages(opts filters.Args) (image.PruneReport, error)
@@ -107,6 +110,7 @@ type DockerRepository interface {
 	GetClusterConfigs() ([]swarm.Config, error)
 	GetClusterVolumes() ([]*volume.Volume, error)
 	GetClusterNetworks() ([]network.Summary, error)
+	EnsurePrivateNetwork(name string) error
 	UpdateNodeAvailability(nodeID string, availability swarm.NodeAvailability) error
 	ScaleService(serviceID string, replicas uint64, rollback string) error
 	ListenEvents(opts events.ListOptions) (<-chan events.Message, <-chan error)
@@ -124,8 +128,14 @@ type DockerRepository interface {
 // NewDockerServiceWithServer creates a new instance of DockerService using SSH tunneling.
 // Requires organizationID to be provided - returns nil if SSH tunnel cannot be established.
 func NewDockerServiceWithServer(db *bun.DB, ctx context.Context, organizationID uuid.UUID) *DockerService {
+	return newDockerService(ctx, organizationID, uuid.Nil)
+}
+
+// newDockerService connects to the Docker daemon of serverID, or of the
+// organization's default server when serverID is uuid.Nil.
+func newDockerService(ctx context.Context, organizationID uuid.UUID, serverID uuid.UUID) *DockerService {
 	lgr := logger.NewLogger()
-	cli, tunnel := newDockerClientWithSSHTunnel(lgr, ctx, organizationID)
+	cli, tunnel := newDockerClientWithSSHTunnel(lgr, ctx, organizationID, serverID)
 
 	// If SSH tunnel failed, cli will be nil
 	if cli == nil {
@@ -134,6 +144,9 @@ func NewDockerServiceWithServer(db *bun.DB, ctx context.Context, organizationID
 	}
 
 	orgCtx := context.WithValue(context.Background(), shared_types.OrganizationIDKey, organizationID.String())
+	if serverID != uuid.Nil {
+		orgCtx = context.WithValue(orgCtx, shared_types.ServerIDKey, serverID.String())
+	}
 	svc := &DockerService{
 		Cli:       cli,
 		Ctx:       orgCtx,
@@ -154,14 +167,20 @@ func NewDockerServiceWithServer(db *bun.DB, ctx context.Context, organizationID
 	return svc
 }
 
-func newDockerClientWithSSHTunnel(lgr logger.Logger, ctx context.Context, organizationID uuid.UUID) (*client.Client, *SSHTunnel) {
+func newDockerClientWithSSHTunnel(lgr logger.Logger, ctx context.Context, organizationID uuid.UUID, serverID uuid.UUID) (*client.Client, *SSHTunnel) {
 	if organizationID == uuid.Nil {
 		lgr.Log(logger.Error, "Organization ID is required", "")
 		return nil, nil
 	}
 
-	// Get SSH manager for organization
-	sshManager, err := ssh.GetSSHManagerForOrganization(ctx, organizationID)
+	// Get SSH manager for the server, or the organization's default server
+	var sshManager *ssh.SSHManager
+	var err error
+	if serverID != uuid.Nil {
+		sshManager, err = ssh.GetSSHManagerForServer(ctx, organizationID, serverID)
+	} else {
+		sshManager, err = ssh.GetSSHManagerForOrganization(ctx, organizationID)
+	}
 	if err != nil {
 		lgr.Log(logger.Error, "Failed to get SSH manager for organization", err.Error())
 		return nil, nil
@@ -227,6 +246,31 @@ func getOrgMutex(orgID uuid.UUID) *sync.Mutex {
 // A per-org mutex serializes all callers so concurrent HandleFileWritten goroutines
 // don't race on cache invalidation (avoiding multiple Close) and only one creation runs.
 func GetDockerServiceForOrganization(ctx context.Context, orgID uuid.UUID) (*DockerService, error) {
+	return getCachedDockerService(ctx, orgID, func() (*DockerService, error) {
+		svc := NewDockerServiceWithServer(config.GlobalStore.DB, ctx, orgID)
+		if svc == nil {
+			return nil, fmt.Errorf("failed to create Docker service via SSH tunnel for organization %s", orgID.String())
+		}
+		return svc, nil
+	})
+}
+
+// GetDockerServiceForServer returns a DockerService for a specific server
+// (ssh_key.id) of the organization. It is cached per server the same way
+// GetDockerServiceForOrganization caches per organization.
+func GetDockerServiceForServer(ctx context.Context, orgID uuid.UUID, serverID uuid.UUID) (*DockerService, error) {
+	return getCachedDockerService(ctx, serverID, func() (*DockerService, error) {
+		svc := newDockerService(ctx, orgID, serverID)
+		if svc == nil {
+			return nil, fmt.Errorf("failed to create Docker service via SSH tunnel for server %s", serverID.String())
+		}
+		return svc, nil
+	})
+}
+
+// getCachedDockerService returns the cached DockerService stored under key,
+// replacing it with a new one from create when it is missing or stale.
+func getCachedDockerService(ctx context.Context, key uuid.UUID, create func() (*DockerService, error)) (*DockerService, error) {
 	if config.GlobalStore == nil {
 		return nil, fmt.Errorf("global store not initialized, ensure config.Init() has been called")
 	}
@@ -235,12 +279,12 @@ func GetDockerServiceForOrganization(ctx context.Context, orgID uuid.UUID) (*Doc
 		return nil, fmt.Errorf("database not initialized")
 	}
 
-	mu := getOrgMutex(orgID)
+	mu := getOrgMutex(key)
 	mu.Lock()
 	defer mu.Unlock()
 
 	// Check cache (fast path when valid)
-	if cached, ok := dockerServiceCache.Load(orgID); ok {
+	if cached, ok := dockerServiceCache.Load(key); ok {
 		entry := cached.(*cachedDockerService)
 		if time.Since(entry.createdAt) < cacheMaxAge && entry.service != nil && entry.service.Cli != nil {
 			if _, err := entry.service.Cli.Ping(ctx); err == nil {
@@ -248,7 +292,7 @@ func GetDockerServiceForOrganization(ctx context.Context, orgID uuid.UUID) (*Doc
 			}
 		}
 		// Invalid or expired: atomically remove and close (only we can do this while holding lock)
-		if old, ok := dockerServiceCache.LoadAndDelete(orgID); ok {
+		if old, ok := dockerServiceCache.LoadAndDelete(key); ok {
 			oldEntry := old.(*cachedDockerService)
 			if oldEntry.service != nil {
 				_ = oldEntry.service.Close()
@@ -256,13 +300,13 @@ func GetDockerServiceForOrganization(ctx context.Context, orgID uuid.UUID) (*Doc
 		}
 	}
 
-	// Cache miss: create (we hold the lock, so only one creation per org at a time)
-	svc := NewDockerServiceWithServer(config.GlobalStore.DB, ctx, orgID)
-	if svc == nil {
-		return nil, fmt.Errorf("failed to create Docker service via SSH tunnel for organization %s", orgID.String())
+	// Cache miss: create (we hold the lock, so only one creation per key at a time)
+	svc, err := create()
+	if err != nil {
+		return nil, err
 	}
 
-	dockerServiceCache.Store(orgID, &cachedDockerService{
+	dockerServiceCache.Store(key, &cachedDockerService{
 		service:   svc,
 		createdAt: time.Now(),
 	})
@@ -294,6 +338,7 @@ func CloseAllDockerServiceCaches() {
 }
 
 // GetDockerServiceFromContext extracts organization ID from context and returns the appropriate DockerRepository.
+// When the context carries a server ID (types.ServerIDKey) the Docker daemon of that server is used.
 // Returns an error if organization ID is not found in context.
 func GetDockerServiceFromContext(ctx context.Context) (DockerRepository, error) {
 	orgIDAny := ctx.Value(shared_types.OrganizationIDKey)
@@ -315,6 +360,11 @@ func GetDockerServiceFromContext(ctx context.Context) (DockerRepository, error)
 		return nil, fmt.Errorf("unexpected organization ID type in context: %T", v)
 	}
 
+	if serverIDStr, _ := ctx.Value(shared_types.ServerIDKey).(string); serverIDStr != "" {
+		if serverID, err := uuid.Parse(serverIDStr); err == nil && serverID != uuid.Nil {
+			return GetDockerServiceForServer(ctx, orgID, serverID)
+		}
+	}
 	return GetDockerServiceForOrganization(ctx, orgID)
 }
 
@@ -513,14 +563,14 @@ func (s *DockerService) ContainerLogs(Ctx context.Context, containerID string, o
 	return s.Cli.ContainerLogs(Ctx, containerID, opts)
 }
 
-// ComposeUp starts the Docker Compose services defined in the specified compose file
-func (s *DockerService) ComposeUp(composeFilePath string, envVars map[string]string) (string, error) {
-	return s.ComposeUpWithCallback(composeFilePath, envVars, nil)
+// ComposeUp starts the Docker Compose services defined in the specified compose project
+func (s *DockerService) ComposeUp(project ComposeProject, envVars map[string]string) (string, error) {
+	return s.ComposeUpWithCallback(project, envVars, nil)
 }
 
 // ComposeUpWithCallback starts Docker Compose services and streams output in real-time via callback
-func (s *DockerService) ComposeUpWithCallback(composeFilePath string, envVars map[string]string, outputCallback func(string)) (string, error) {
-	command, err := s.buildComposeCommand("up -d --remove-orphans", composeFilePath, envVars)
+func (s *DockerService) ComposeUpWithCallback(project ComposeProject, envVars map[string]string, outputCallback func(string)) (string, error) {
+	command, err := s.buildComposeCommand("up -d --remove-orphans", project, envVars)
 	if err != nil {
 		return "", err
 	}
@@ -528,13 +578,13 @@ func (s *DockerService) ComposeUpWithCallback(composeFilePath string, envVars ma
 }
 
 // ComposeDown stops and removes the Docker Compose services
-func (s *DockerService) ComposeDown(composeFilePath string) error {
-	return s.ComposeDownWithCallback(composeFilePath, nil)
+func (s *DockerService) ComposeDown(project ComposeProject) error {
+	return s.ComposeDownWithCallback(project, nil)
 }
 
 // ComposeDownWithCallback stops and removes Docker Compose services with streaming output
-func (s *DockerService) ComposeDownWithCallback(composeFilePath string, outputCallback func(string)) error {
-	command, err := s.buildComposeCommand("down", composeFilePath, nil)
+func (s *DockerService) ComposeDownWithCallback(project ComposeProject, outputCallback func(string)) error {
+	command, err := s.buildComposeCommand("down", project, nil)
 	if err != nil {
 		return err
 	}
@@ -543,8 +593,8 @@ func (s *DockerService) ComposeDownWithCallback(composeFilePath string, outputCa
 }
 
 // ComposeRestart restarts Docker Compose services with streaming output
-func (s *DockerService) ComposeRestart(composeFilePath string, envVars map[string]string, outputCallback func(string)) error {
-	command, err := s.buildComposeCommand("restart", composeFilePath, envVars)
+func (s *DockerService) ComposeRestart(project ComposeProject, envVars map[string]string, outputCallback func(string)) error {
+	command, err := s.buildComposeCommand("restart", project, envVars)
 	if err != nil {
 		return err
 	}
@@ -552,13 +602,55 @@ func (s *DockerService) ComposeRestart(composeFilePath string, envVars map[strin
 	return err
 }
 
+// ComposeProject describes the files and options that make up a compose deployment.
+type ComposeProject struct {
+	// Files are passed to docker compose in order. The first file is the base
+	// and its directory is the project directory; later files override it.
+	Files []string
+	// Profiles enables services that are assigned to compose profiles.
+	Profiles []string
+	// EnvFiles are used for variable interpolation instead of the project's .env file.
+	EnvFiles []string
+}
+
+// ComposeFile returns a project made of a single compose file.
+func ComposeFile(path string) ComposeProject {
+	return ComposeProject{Files: []string{path}}
+}
+
+// Dir returns the project directory, which docker compose derives from the first file.
+func (p ComposeProject) Dir() string {
+	if len(p.Files) == 0 {
+		return ""
+	}
+	return filepath.Dir(p.Files[0])
+}
+
+// args returns the global docker compose flags for the project.
+func (p ComposeProject) args() string {
+	var args []string
+	for _, f := range p.Files {
+		args = append(args, "-f "+utils.ShellQuote(f))
+	}
+	for _, f := range p.EnvFiles {
+		args = append(args, "--env-file "+utils.ShellQuote(f))
+	}
+	for _, profile := range p.Profiles {
+		args = append(args, "--profile "+utils.ShellQuote(profile))
+	}
+	return strings.Join(args, " ")
+}
+
 // buildComposeCommand builds a docker compose command with safe environment variables
-func (s *DockerService) buildComposeCommand(composeAction string, composeFilePath string, envVars map[string]string) (string, error) {
+func (s *DockerService) buildComposeCommand(composeAction string, project ComposeProject, envVars map[string]string) (string, error) {
+	if len(project.Files) == 0 {
+		return "", fmt.Errorf("no compose file specified")
+	}
 	envVarsStr, err := buildSafeEnvExports(envVars)
 	if err != nil {
 		return "", fmt.Errorf("invalid environment variables: %w", err)
 	}
-	return fmt.Sprintf("%sdocker compose -f %s %s 2>&1", envVarsStr, utils.ShellQuote(composeFilePath), composeAction), nil
+	return fmt.Sprintf("%sdocker compose %s %s 2>&1", envVarsStr, project.args(), composeAction), nil
 }
 
 func (s *DockerService) executeSSHCommandWithOutput(command string, outputCallback func(string), errorMsgPrefix string) (string, error) {
@@ -621,16 +713,15 @@ func (s *DockerService) streamOutput(reader io.Reader, outputBuilder *strings.Bu
 }
 
 // ComposeBuild builds the Docker Compose services
-func (s *DockerService) ComposeBuild(composeFilePath string, envVars map[string]string) error {
+func (s *DockerService) ComposeBuild(project ComposeProject, envVars map[string]string) error {
 	manager, err := ssh.GetSSHManagerFromContext(s.Ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get SSH manager: %w", err)
 	}
-	envVarsStr, err := buildSafeEnvExports(envVars)
+	command, err := s.buildComposeCommand("build", project, envVars)
 	if err != nil {
-		return fmt.Errorf("invalid environment variables: %w", err)
+		return err
 	}
-	command := fmt.Sprintf("%sdocker compose -f %s build", envVarsStr, utils.ShellQuote(composeFilePath))
 	output, err := manager.RunCommand(command)
 	if err != nil {
 		return fmt.Errorf("failed to build docker compose services: %v, output: %s", err, output)
@@ -700,6 +791,27 @@ func (s *DockerService) UpdateContainerResources(containerID string, resources c
 	return s.Cli.ContainerUpdate(s.Ctx, containerID, resources)
 }
 
+// GetContainerStats returns a single stats sample of a container. Docker
+// samples the CPU twice, so PreCPUStats is set and CPU usage can be derived.
+func (s *DockerService) GetContainerStats(containerID string) (container.StatsResponse, error) {
+	var stats container.StatsResponse
+	reader, err := s.Cli.ContainerStats(s.Ctx, containerID, false)
+	if err != nil {
+		return stats, err
+	}
+	defer reader.Body.Close()
+	err = json.NewDecoder(reader.Body).Decode(&stats)
+	return stats, err
+}
+
+// WithContext returns a copy of the service whose calls use ctx. The copy
+// shares the client and tunnel, so it must not be closed.
+func (s *DockerService) WithContext(ctx context.Context) *DockerService {
+	scoped := *s
+	scoped.Ctx = ctx
+	return &scoped
+}
+
 // Close cleans up the DockerService and any SSH tunnels
 func (s *DockerService) Close() error {
 	if s.sshTunnel != nil {
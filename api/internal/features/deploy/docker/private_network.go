@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// PrivateNetworkLabel marks the overlay networks Nixopus manages for private
+// traffic between applications.
+const PrivateNetworkLabel = "nixopus.private-network"
+
+// EnsurePrivateNetwork creates the attachable overlay network name if it does
+// not exist yet. Attachable networks can be joined by Swarm services and by
+// containers started with docker compose alike.
+func (s *DockerService) EnsurePrivateNetwork(name string) error {
+	networks, err := s.Cli.NetworkList(s.Ctx, network.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", name)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range networks {
+		if n.Name == name {
+			if n.Driver != "overlay" || !n.Attachable {
+				return fmt.Errorf("network %s exists but is not an attachable overlay network", name)
+			}
+			return nil
+		}
+	}
+
+	_, err = s.Cli.NetworkCreate(s.Ctx, name, network.CreateOptions{
+		Driver:     "overlay",
+		Attachable: true,
+		Labels:     map[string]string{PrivateNetworkLabel: "true"},
+	})
+	if err != nil && !errdefs.IsConflict(err) {
+		// A conflict means a concurrent deployment created the network first.
+		return fmt.Errorf("failed to create network %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveNetwork removes the network name. A network that no longer exists is
+// not an error.
+func (s *DockerService) RemoveNetwork(name string) error {
+	if err := s.Cli.NetworkRemove(s.Ctx, name); err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove network %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// StackNamespaceLabel is set by docker stack deploy on every service of a stack.
+const StackNamespaceLabel = "com.docker.stack.namespace"
+
+// StackDeploy creates or updates a Swarm stack from one or more compose files.
+// Services removed from the files are pruned, and running services are
+// updated with their rolling update policy.
+func (s *DockerService) StackDeploy(stackName string, files []string, envVars map[string]string, outputCallback func(string)) (string, error) {
+	if len(files) == 0 {
+		return "", fmt.Errorf("no compose file specified")
+	}
+	envVarsStr, err := buildSafeEnvExports(envVars)
+	if err != nil {
+		return "", fmt.Errorf("invalid environment variables: %w", err)
+	}
+
+	var args []string
+	for _, f := range files {
+		args = append(args, "-c "+utils.ShellQuote(f))
+	}
+	command := fmt.Sprintf("%sdocker stack deploy --prune --with-registry-auth %s %s 2>&1",
+		envVarsStr, strings.Join(args, " "), utils.ShellQuote(stackName))
+	return s.executeSSHCommandWithOutput(command, outputCallback, "failed to deploy docker stack")
+}
+
+// StackRemove removes a Swarm stack with its services, networks, configs and secrets.
+func (s *DockerService) StackRemove(stackName string) error {
+	command := "docker stack rm " + utils.ShellQuote(stackName) + " 2>&1"
+	_, err := s.executeSSHCommandWithOutput(command, nil, "failed to remove docker stack")
+	return err
+}
+
+// ListStackServices returns the Swarm services of a stack.
+func (s *DockerService) ListStackServices(stackName string) ([]swarm.Service, error) {
+	return s.Cli.ServiceList(s.Ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", StackNamespaceLabel+"="+stackName)),
+	})
+}
+
+// ForceUpdateService restarts every task of a Swarm service, following its
+// update policy, without changing the service spec.
+func (s *DockerService) ForceUpdateService(serviceID string) error {
+	svc, _, err := s.Cli.ServiceInspectWithRaw(s.Ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	spec := svc.Spec
+	spec.TaskTemplate.ForceUpdate++
+	_, err = s.Cli.ServiceUpdate(s.Ctx, serviceID, svc.Version, spec, types.ServiceUpdateOptions{})
+	return err
+}
+
+// GetServiceLogs returns the last tail lines of every task of a Swarm service.
+func (s *DockerService) GetServiceLogs(service swarm.Service, tail int) (string, error) {
+	reader, err := s.Cli.ServiceLogs(s.Ctx, service.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       strconv.Itoa(tail),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get service logs: %w", err)
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if spec := service.Spec.TaskTemplate.ContainerSpec; spec != nil && spec.TTY {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read service logs: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,16 @@
+package docker
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// RemoveVolume removes the named volume and its data. A volume that no longer
+// exists is not an error.
+func (s *DockerService) RemoveVolume(name string) error {
+	if err := s.Cli.VolumeRemove(s.Ctx, name, false); err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	return nil
+}
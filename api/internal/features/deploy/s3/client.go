@@ -72,6 +72,16 @@ func ImageS3Key(orgID, appID, deploymentID uuid.UUID) string {
 	return fmt.Sprintf("%s/%s/%s.tar.gz", orgID, appID, deploymentID)
 }
 
+// DatabaseBackupS3Key is where a gzipped database dump is stored.
+func DatabaseBackupS3Key(orgID, databaseID, backupID uuid.UUID) string {
+	return fmt.Sprintf("database-backups/%s/%s/%s.sql.gz", orgID, databaseID, backupID)
+}
+
+// VolumeBackupS3Key is where the gzipped tarball of a volume backup is stored.
+func VolumeBackupS3Key(orgID, appID, backupID uuid.UUID) string {
+	return fmt.Sprintf("volume-backups/%s/%s/%s.tar.gz", orgID, appID, backupID)
+}
+
 // UploadImage streams an image tarball to S3 using multipart upload.
 // The reader should produce a gzipped docker save output.
 func (s *ImageStore) UploadImage(ctx context.Context, key string, reader io.Reader) (int64, error) {
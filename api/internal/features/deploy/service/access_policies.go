@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListAccessPolicies returns the access policies of an application, with
+// the usernames but not the password hashes of their basic auth users.
+func (s *DeployService) ListAccessPolicies(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationAccessPolicy, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	policies, err := s.storage.GetApplicationAccessPolicies(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		policies[i].FillBasicAuthUsernames()
+	}
+	return policies, nil
+}
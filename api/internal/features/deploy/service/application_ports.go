@@ -0,0 +1,193 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// reservedPorts are used by SSH, the Caddy HTTP servers and the Caddy admin API.
+var reservedPorts = map[int]bool{22: true, 80: true, 443: true, 2019: true}
+
+// dynamicPortRangeStart is the first port of the range HTTP applications are
+// published on. See TaskService.getAvailablePort.
+const dynamicPortRangeStart = 49152
+
+// ListApplicationPorts returns the TCP/UDP ports of an application with their firewall hints.
+func (s *DeployService) ListApplicationPorts(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationPort, error) {
+	if _, err := s.getApplication(applicationID, organizationID); err != nil {
+		return nil, err
+	}
+	ports, err := s.storage.GetApplicationPorts(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ports {
+		ports[i].SetFirewallHints()
+	}
+	return ports, nil
+}
+
+// AddApplicationPort validates a port mapping, checks it against the ports
+// already published on the same servers and stores it. The mapping is applied
+// on the next deployment.
+func (s *DeployService) AddApplicationPort(req *types.AddApplicationPortRequest, organizationID uuid.UUID) (*shared_types.ApplicationPort, error) {
+	app, err := s.getApplication(req.ID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	port := &shared_types.ApplicationPort{
+		ID:            uuid.New(),
+		ApplicationID: app.ID,
+		ServerID:      req.ServerID,
+		ServiceName:   strings.TrimSpace(req.ServiceName),
+		Protocol:      shared_types.PortProtocol(strings.ToLower(string(req.Protocol))),
+		TargetPort:    req.TargetPort,
+		PublishedPort: req.PublishedPort,
+		PublishMode:   shared_types.PortPublishMode(strings.ToLower(string(req.PublishMode))),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if port.Protocol == "" {
+		port.Protocol = shared_types.PortProtocolTCP
+	}
+	if port.PublishMode == "" {
+		port.PublishMode = shared_types.PortPublishIngress
+	}
+	if app.BuildPack != shared_types.DockerCompose {
+		port.ServiceName = ""
+	}
+	if err := validateApplicationPort(port, app.BuildPack); err != nil {
+		return nil, err
+	}
+
+	appServers, err := s.applicationServerIDs(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	if port.ServerID != nil && !slices.Contains(appServers, *port.ServerID) {
+		return nil, types.ErrPortServerNotAssigned
+	}
+
+	published, err := s.storage.GetPublishedApplicationPorts(organizationID, port.Protocol, port.PublishedPort)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range published {
+		existingServers := appServers
+		if existing.ApplicationID != app.ID {
+			if existingServers, err = s.applicationServerIDs(existing.ApplicationID); err != nil {
+				return nil, err
+			}
+		}
+		if portsConflict(port, appServers, &existing, existingServers) {
+			return nil, types.ErrPortConflict
+		}
+	}
+
+	if err := s.storage.AddApplicationPort(port); err != nil {
+		s.logger.Log(logger.Error, "failed to save application port", err.Error())
+		return nil, err
+	}
+	port.SetFirewallHints()
+	return port, nil
+}
+
+// DeleteApplicationPort removes a port mapping and returns it. Published
+// ports are closed on the next deployment.
+func (s *DeployService) DeleteApplicationPort(applicationID uuid.UUID, portID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationPort, error) {
+	if _, err := s.getApplication(applicationID, organizationID); err != nil {
+		return nil, err
+	}
+	port, err := s.storage.GetApplicationPort(applicationID, portID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrApplicationPortNotFound
+		}
+		return nil, err
+	}
+	if err := s.storage.DeleteApplicationPort(applicationID, portID); err != nil {
+		return nil, err
+	}
+	return port, nil
+}
+
+func (s *DeployService) getApplication(applicationID uuid.UUID, organizationID uuid.UUID) (*shared_types.Application, error) {
+	app, err := s.storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return &app, nil
+}
+
+func (s *DeployService) applicationServerIDs(applicationID uuid.UUID) ([]uuid.UUID, error) {
+	servers, err := s.storage.GetApplicationServers(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		// Applications without assigned servers run on the organization's default server.
+		return []uuid.UUID{uuid.Nil}, nil
+	}
+	ids := make([]uuid.UUID, len(servers))
+	for i, srv := range servers {
+		ids[i] = srv.ServerID
+	}
+	return ids, nil
+}
+
+func validateApplicationPort(port *shared_types.ApplicationPort, buildPack shared_types.BuildPack) error {
+	if !shared_types.IsValidPortProtocol(string(port.Protocol)) {
+		return types.ErrInvalidPortProtocol
+	}
+	if !shared_types.IsValidPortPublishMode(string(port.PublishMode)) {
+		return types.ErrInvalidPortPublishMode
+	}
+	if port.TargetPort < 1 || port.TargetPort > 65535 || port.PublishedPort < 1 || port.PublishedPort > 65535 {
+		return types.ErrInvalidPortNumber
+	}
+	if reservedPorts[port.PublishedPort] {
+		return types.ErrPortReserved
+	}
+	if port.PublishedPort >= dynamicPortRangeStart {
+		return types.ErrPortInDynamicRange
+	}
+	if buildPack == shared_types.DockerCompose && port.ServiceName == "" {
+		return types.ErrPortServiceRequired
+	}
+	return nil
+}
+
+// portsConflict reports whether two ports with the same protocol and
+// published port would bind the same host port. Ports without a server are
+// published on every server of their application. Proxied ports listen on
+// the Caddy host and conflict with every other use of the port.
+func portsConflict(a *shared_types.ApplicationPort, aServers []uuid.UUID, b *shared_types.ApplicationPort, bServers []uuid.UUID) bool {
+	if a.Protocol != b.Protocol || a.PublishedPort != b.PublishedPort {
+		return false
+	}
+	if a.PublishMode == shared_types.PortPublishProxy || b.PublishMode == shared_types.PortPublishProxy {
+		return true
+	}
+	if a.ServerID != nil {
+		aServers = []uuid.UUID{*a.ServerID}
+	}
+	if b.ServerID != nil {
+		bServers = []uuid.UUID{*b.ServerID}
+	}
+	for _, id := range aServers {
+		if slices.Contains(bServers, id) {
+			return true
+		}
+	}
+	return false
+}
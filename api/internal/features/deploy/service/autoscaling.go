@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	// defaultAutoscalingCooldown is the time between two scaling actions
+	// when the request does not say.
+	defaultAutoscalingCooldown = 300
+	minAutoscalingCooldown     = 30
+	maxAutoscalingCooldown     = 86400
+	maxAutoscalingReplicas     = 100
+	// scalingEventsLimit is how many scaling events are listed.
+	scalingEventsLimit = 100
+)
+
+// GetAutoscalingPolicy returns the autoscaling policy of an application.
+func (s *DeployService) GetAutoscalingPolicy(applicationID uuid.UUID, organizationID uuid.UUID) (*shared_types.AutoscalingPolicy, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	policy, err := s.storage.GetAutoscalingPolicy(applicationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrAutoscalingPolicyNotFound
+	}
+	return policy, err
+}
+
+// UpdateAutoscalingPolicy creates or replaces the autoscaling policy of an
+// application. The time of the last scaling action is kept, so a changed
+// policy still honours the cooldown.
+func (s *DeployService) UpdateAutoscalingPolicy(req *types.UpdateAutoscalingPolicyRequest, organizationID uuid.UUID) (*shared_types.AutoscalingPolicy, error) {
+	app, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	if app.BuildPack == shared_types.DockerCompose || app.IsComposeStack() {
+		return nil, types.ErrAutoscalingUnsupported
+	}
+	if req.CooldownSeconds == 0 {
+		req.CooldownSeconds = defaultAutoscalingCooldown
+	}
+	if err := validateAutoscalingPolicy(req); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.storage.GetAutoscalingPolicy(app.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &shared_types.AutoscalingPolicy{
+			ApplicationID:  app.ID,
+			OrganizationID: organizationID,
+			CreatedAt:      time.Now(),
+		}
+	}
+	policy.Enabled = req.Enabled
+	policy.MinReplicas = req.MinReplicas
+	policy.MaxReplicas = req.MaxReplicas
+	policy.Metric = req.Metric
+	policy.TargetPercent = req.TargetPercent
+	policy.CooldownSeconds = req.CooldownSeconds
+	if err := s.storage.UpsertAutoscalingPolicy(policy); err != nil {
+		s.logger.Log(logger.Error, "failed to save autoscaling policy", err.Error())
+		return nil, err
+	}
+	return policy, nil
+}
+
+// DeleteAutoscalingPolicy removes the autoscaling policy of an application.
+// The service keeps its current replica count.
+func (s *DeployService) DeleteAutoscalingPolicy(applicationID uuid.UUID, organizationID uuid.UUID) error {
+	if _, err := s.GetAutoscalingPolicy(applicationID, organizationID); err != nil {
+		return err
+	}
+	return s.storage.DeleteAutoscalingPolicy(applicationID)
+}
+
+// ListScalingEvents returns the latest scaling decisions of an application,
+// newest first.
+func (s *DeployService) ListScalingEvents(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationScalingEvent, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetScalingEvents(applicationID, scalingEventsLimit)
+}
+
+func validateAutoscalingPolicy(req *types.UpdateAutoscalingPolicyRequest) error {
+	if req.MinReplicas < 1 || req.MaxReplicas < req.MinReplicas || req.MaxReplicas > maxAutoscalingReplicas {
+		return types.ErrInvalidAutoscalingReplicas
+	}
+	if !req.Metric.IsValid() {
+		return types.ErrInvalidAutoscalingMetric
+	}
+	if req.TargetPercent < 1 || req.TargetPercent > 100 {
+		return types.ErrInvalidAutoscalingTarget
+	}
+	if req.CooldownSeconds < minAutoscalingCooldown || req.CooldownSeconds > maxAutoscalingCooldown {
+		return types.ErrInvalidAutoscalingCooldown
+	}
+	return nil
+}
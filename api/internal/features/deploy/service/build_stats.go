@@ -0,0 +1,101 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// DefaultBuildStatsDeployments is how many recent deployments the build stats
+// cover when the caller does not ask for a number.
+const DefaultBuildStatsDeployments = 50
+
+var phaseOrder = []shared_types.DeploymentPhaseName{
+	shared_types.PhaseClone,
+	shared_types.PhaseBuild,
+	shared_types.PhasePush,
+	shared_types.PhaseDeploy,
+	shared_types.PhaseHealth,
+	shared_types.PhaseRoute,
+}
+
+// GetApplicationBuildStats aggregates the phases of the latest deployments of
+// an application: build time percentiles, cache hit rate and the failure rate
+// of every phase.
+func (s *DeployService) GetApplicationBuildStats(applicationID uuid.UUID, organizationID uuid.UUID, deployments int) (*types.ApplicationBuildStats, error) {
+	if _, err := s.getApplication(applicationID, organizationID); err != nil {
+		return nil, err
+	}
+	if deployments <= 0 {
+		deployments = DefaultBuildStatsDeployments
+	}
+	phases, err := s.storage.GetApplicationDeploymentPhases(applicationID, deployments)
+	if err != nil {
+		return nil, err
+	}
+	stats := buildStats(phases)
+	return &stats, nil
+}
+
+func buildStats(phases []shared_types.DeploymentPhase) types.ApplicationBuildStats {
+	var stats types.ApplicationBuildStats
+	deployments := make(map[uuid.UUID]bool)
+	durations := make(map[shared_types.DeploymentPhaseName][]int64)
+	byPhase := make(map[shared_types.DeploymentPhaseName]*types.PhaseStats)
+
+	for _, p := range phases {
+		deployments[p.ApplicationDeploymentID] = true
+		stats.BuildSteps += p.BuildSteps
+		stats.CachedSteps += p.CachedSteps
+		if p.Status == shared_types.PhaseRunning {
+			continue
+		}
+
+		ps, ok := byPhase[p.Phase]
+		if !ok {
+			ps = &types.PhaseStats{Phase: p.Phase}
+			byPhase[p.Phase] = ps
+		}
+		ps.Count++
+		if p.Status == shared_types.PhaseFailed {
+			ps.Failures++
+		}
+		if p.Status == shared_types.PhaseSucceeded {
+			durations[p.Phase] = append(durations[p.Phase], p.DurationMs)
+		}
+	}
+
+	stats.Deployments = len(deployments)
+	if stats.BuildSteps > 0 {
+		stats.CacheHitRate = float64(stats.CachedSteps) / float64(stats.BuildSteps)
+	}
+	for _, name := range phaseOrder {
+		ps, ok := byPhase[name]
+		if !ok {
+			continue
+		}
+		ps.FailureRate = float64(ps.Failures) / float64(ps.Count)
+		ps.P50Ms = percentile(durations[name], 50)
+		ps.P95Ms = percentile(durations[name], 95)
+		stats.Phases = append(stats.Phases, *ps)
+	}
+	stats.BuildP50Ms = percentile(durations[shared_types.PhaseBuild], 50)
+	stats.BuildP95Ms = percentile(durations[shared_types.PhaseBuild], 95)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of values.
+func percentile(values []int64, p int) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
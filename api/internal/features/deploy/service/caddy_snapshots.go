@@ -0,0 +1,71 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListCaddySnapshots returns the Caddy config snapshots of an organization,
+// newest first, without their configs.
+func (s *DeployService) ListCaddySnapshots(organizationID uuid.UUID) ([]shared_types.CaddyConfigSnapshot, error) {
+	return s.storage.GetCaddyConfigSnapshots(organizationID)
+}
+
+// DiffCaddySnapshots compares two Caddy config snapshots. When toID is nil
+// the comparison is made against the latest snapshot of the same server.
+// Secret values are redacted.
+func (s *DeployService) DiffCaddySnapshots(fromID uuid.UUID, toID *uuid.UUID, organizationID uuid.UUID) (types.CaddySnapshotDiffResponseData, error) {
+	from, err := s.getCaddySnapshot(organizationID, fromID)
+	if err != nil {
+		return types.CaddySnapshotDiffResponseData{}, err
+	}
+
+	var to *shared_types.CaddyConfigSnapshot
+	if toID != nil {
+		to, err = s.getCaddySnapshot(organizationID, *toID)
+	} else {
+		to, err = s.latestCaddySnapshot(organizationID, from.ServerHost)
+	}
+	if err != nil {
+		return types.CaddySnapshotDiffResponseData{}, err
+	}
+
+	changes, err := tasks.DiffCaddyConfigs(string(from.Config), string(to.Config))
+	if err != nil {
+		return types.CaddySnapshotDiffResponseData{}, err
+	}
+	return types.CaddySnapshotDiffResponseData{
+		From:    *from,
+		To:      *to,
+		Changes: changes,
+	}, nil
+}
+
+func (s *DeployService) getCaddySnapshot(organizationID uuid.UUID, snapshotID uuid.UUID) (*shared_types.CaddyConfigSnapshot, error) {
+	snapshot, err := s.storage.GetCaddyConfigSnapshotByID(organizationID, snapshotID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrCaddySnapshotNotFound
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (s *DeployService) latestCaddySnapshot(organizationID uuid.UUID, serverHost string) (*shared_types.CaddyConfigSnapshot, error) {
+	snapshots, err := s.storage.GetCaddyConfigSnapshots(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.ServerHost == serverHost {
+			return s.getCaddySnapshot(organizationID, snapshot.ID)
+		}
+	}
+	return nil, types.ErrCaddySnapshotNotFound
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListCertificates returns the custom certificates of an application
+// without their PEM blocks.
+func (s *DeployService) ListCertificates(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationCertificate, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetApplicationCertificates(applicationID)
+}
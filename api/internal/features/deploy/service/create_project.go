@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -45,11 +46,31 @@ func (s *DeployService) CreateProject(req *types.CreateProjectRequest, userID uu
 		source = shared_types.SourceGithub
 	}
 
+	composeDeployMode := req.ComposeDeployMode
+	if composeDeployMode == "" {
+		composeDeployMode = shared_types.ComposeDeployModeCompose
+	}
+
+	privateNetworkScope := req.PrivateNetworkScope
+	if privateNetworkScope == "" {
+		privateNetworkScope = shared_types.PrivateNetworkOrganization
+	}
+
+	sbomFormat := req.SBOMFormat
+	if sbomFormat == "" {
+		sbomFormat = shared_types.SBOMFormatNone
+	}
+
+	vulnThreshold := req.VulnThreshold
+	if vulnThreshold == "" {
+		vulnThreshold = shared_types.SeverityNone
+	}
+
 	application := shared_types.Application{
 		ID:                   uuid.New(),
 		Name:                 req.Name,
 		BuildVariables:       tasks.GetStringFromMap(req.BuildVariables),
-		EnvironmentVariables: tasks.GetStringFromMap(req.EnvironmentVariables),
+		EnvironmentVariables: shared_types.EncryptedString(tasks.GetStringFromMap(req.EnvironmentVariables)),
 		Environment:          req.Environment,
 		BuildPack:            req.BuildPack,
 		Repository:           req.Repository,
@@ -62,6 +83,14 @@ func (s *DeployService) CreateProject(req *types.CreateProjectRequest, userID uu
 		UpdatedAt:            now,
 		DockerfilePath:       req.DockerfilePath,
 		BasePath:             basePath,
+		ComposeFiles:         req.ComposeFiles,
+		ComposeProfiles:      req.ComposeProfiles,
+		ComposeEnvFiles:      req.ComposeEnvFiles,
+		ComposeDeployMode:    composeDeployMode,
+		PrivateNetworkScope:  privateNetworkScope,
+		SBOMFormat:           sbomFormat,
+		VulnThreshold:        vulnThreshold,
+		InternalAlias:        shared_types.InternalAliasFromName(req.Name),
 		OrganizationID:       organizationID,
 		FamilyID:             &familyID,
 		Source:               source,
@@ -75,12 +104,22 @@ func (s *DeployService) CreateProject(req *types.CreateProjectRequest, userID uu
 	}
 	defer tx.Rollback()
 
+	if err := s.storage.AssignInternalAlias(tx, &application); err != nil {
+		s.logger.Log(logger.Error, "failed to choose internal alias", err.Error())
+		return shared_types.Application{}, err
+	}
+
 	// Save the application to the database
 	if _, err := tx.NewInsert().Model(&application).Exec(s.Ctx); err != nil {
 		s.logger.Log(logger.Error, "failed to create application", err.Error())
 		return shared_types.Application{}, err
 	}
 
+	if _, err := s.storage.RecordEnvRevision(tx, application.ID, application.EnvironmentVariables, &userID, nil); err != nil {
+		s.logger.Log(logger.Error, "failed to record environment revision", err.Error())
+		return shared_types.Application{}, err
+	}
+
 	// Add domains to application_domains table within transaction
 	if len(domains) > 0 {
 		// Use transaction for domain operations
@@ -88,10 +127,19 @@ func (s *DeployService) CreateProject(req *types.CreateProjectRequest, userID uu
 			if domain == "" {
 				continue
 			}
+			taken, err := s.storage.IsDomainAlreadyTaken(domain, organizationID)
+			if err != nil {
+				return shared_types.Application{}, err
+			}
+			if taken {
+				return shared_types.Application{}, fmt.Errorf("domain %s is already taken", domain)
+			}
+			host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
 			appDomain := &shared_types.ApplicationDomain{
 				ID:            uuid.New(),
 				ApplicationID: application.ID,
-				Domain:        domain,
+				Domain:        host,
+				PathPrefix:    pathPrefix,
 				CreatedAt:     now,
 			}
 			if _, err := tx.NewInsert().Model(appDomain).Exec(s.Ctx); err != nil {
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListDatabaseBackups returns the backups of a database, newest first.
+func (s *DeployService) ListDatabaseBackups(databaseID uuid.UUID, organizationID uuid.UUID) ([]shared_types.DatabaseBackup, error) {
+	if _, err := s.GetDatabase(databaseID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.storage.GetDatabaseBackups(databaseID)
+}
+
+// ListDatabaseRestores returns the restores into a database, newest first.
+func (s *DeployService) ListDatabaseRestores(databaseID uuid.UUID, organizationID uuid.UUID) ([]shared_types.DatabaseRestore, error) {
+	if _, err := s.GetDatabase(databaseID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.storage.GetDatabaseRestores(databaseID)
+}
+
+// UpdateDatabaseBackupSchedule replaces the backup schedule and retention of
+// a database. Retention applies after the next successful backup.
+func (s *DeployService) UpdateDatabaseBackupSchedule(databaseID uuid.UUID, req *types.UpdateDatabaseBackupScheduleRequest, organizationID uuid.UUID) (*shared_types.Database, error) {
+	database, err := s.GetDatabase(databaseID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if req.RetentionCount < 1 || req.RetentionDays < 0 {
+		return nil, types.ErrInvalidBackupRetention
+	}
+	schedule := strings.TrimSpace(req.Schedule)
+	if schedule != "" {
+		if !database.Engine.SupportsBackups() {
+			return nil, types.ErrDatabaseBackupUnsupported
+		}
+		if !s3store.IsConfigured(config.AppConfig.S3) {
+			return nil, types.ErrS3NotConfigured
+		}
+		if _, err := tasks.ParseBackupSchedule(schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	database.BackupSchedule = schedule
+	database.BackupRetentionCount = req.RetentionCount
+	database.BackupRetentionDays = req.RetentionDays
+	if err := s.storage.UpdateDatabase(database); err != nil {
+		s.logger.Log(logger.Error, "failed to update database backup schedule", err.Error())
+		return nil, err
+	}
+	return database, nil
+}
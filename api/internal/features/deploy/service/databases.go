@@ -0,0 +1,144 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+var envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ListDatabases returns the managed databases of the organization.
+func (s *DeployService) ListDatabases(organizationID uuid.UUID) ([]shared_types.Database, error) {
+	return s.storage.GetDatabases(organizationID)
+}
+
+// GetDatabase returns a managed database scoped to the organization.
+func (s *DeployService) GetDatabase(databaseID uuid.UUID, organizationID uuid.UUID) (*shared_types.Database, error) {
+	database, err := s.storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return nil, types.ErrDatabaseNotFound
+	}
+	return database, nil
+}
+
+// GetDatabaseConnection returns how applications attached to a database
+// connect to it.
+func (s *DeployService) GetDatabaseConnection(databaseID uuid.UUID, organizationID uuid.UUID) (*types.DatabaseConnectionInfo, error) {
+	database, err := s.GetDatabase(databaseID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.DatabaseConnectionInfo{
+		Host:             database.Hostname(),
+		Port:             database.Engine.Port(),
+		DatabaseName:     database.DatabaseName,
+		Username:         database.Username,
+		Password:         string(database.Password),
+		ConnectionString: database.ConnectionString(),
+		Network:          database.NetworkName(),
+	}, nil
+}
+
+// GetDatabaseApplications returns the applications a database is attached to.
+func (s *DeployService) GetDatabaseApplications(databaseID uuid.UUID, organizationID uuid.UUID) ([]types.DatabaseApplication, error) {
+	if _, err := s.GetDatabase(databaseID, organizationID); err != nil {
+		return nil, err
+	}
+	apps, err := s.storage.GetApplicationsByDatabase(databaseID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.storage.GetDatabaseAttachments(databaseID)
+	if err != nil {
+		return nil, err
+	}
+	return tasks.DatabaseApplications(apps, attachments), nil
+}
+
+// AttachDatabase injects the connection string of a database into an
+// application from its next deployment on. The application must only run on
+// the database's server, as its private network does not reach other servers.
+func (s *DeployService) AttachDatabase(databaseID uuid.UUID, req *types.AttachDatabaseRequest, organizationID uuid.UUID) (*shared_types.ApplicationDatabase, error) {
+	database, err := s.GetDatabase(databaseID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	app, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	envVar := strings.TrimSpace(req.EnvVar)
+	if envVar == "" {
+		envVar = database.Engine.DefaultEnvVar()
+	}
+	if !envVarNamePattern.MatchString(envVar) {
+		return nil, types.ErrInvalidEnvVarName
+	}
+
+	if err := s.storage.EnsureApplicationServers(app.ID, organizationID); err != nil {
+		return nil, err
+	}
+	servers, err := s.storage.GetApplicationServers(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if server.ServerID != database.ServerID {
+			return nil, types.ErrDatabaseServerMismatch
+		}
+	}
+
+	existing, err := s.storage.GetApplicationDatabases(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range existing {
+		if attachment.DatabaseID == database.ID {
+			return nil, types.ErrDatabaseAlreadyAttached
+		}
+		if attachment.EnvVar == envVar {
+			return nil, types.ErrDatabaseEnvVarTaken
+		}
+	}
+
+	attachment := &shared_types.ApplicationDatabase{
+		ID:            uuid.New(),
+		ApplicationID: app.ID,
+		DatabaseID:    database.ID,
+		EnvVar:        envVar,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.storage.AttachDatabase(attachment); err != nil {
+		s.logger.Log(logger.Error, "failed to attach database", err.Error())
+		return nil, err
+	}
+	attachment.Database = database
+	return attachment, nil
+}
+
+// DetachDatabase stops injecting a database into an application from its
+// next deployment on.
+func (s *DeployService) DetachDatabase(databaseID uuid.UUID, req *types.DetachDatabaseRequest, organizationID uuid.UUID) error {
+	if _, err := s.GetDatabase(databaseID, organizationID); err != nil {
+		return err
+	}
+	if _, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID); err != nil {
+		return types.ErrApplicationNotFound
+	}
+	detached, err := s.storage.DetachDatabase(req.ApplicationID, databaseID)
+	if err != nil {
+		return err
+	}
+	if !detached {
+		return types.ErrDatabaseNotAttached
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListDNSProviders returns the organization's DNS providers without their
+// credential values.
+func (s *DeployService) ListDNSProviders(organizationID uuid.UUID) ([]shared_types.OrganizationDNSProvider, error) {
+	providers, err := s.storage.GetOrganizationDNSProviders(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range providers {
+		providers[i].CredentialKeys = dnsCredentialKeys(providers[i].Credentials)
+	}
+	return providers, nil
+}
+
+// CreateDNSProvider adds a DNS provider to the organization.
+func (s *DeployService) CreateDNSProvider(req *types.CreateDNSProviderRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.OrganizationDNSProvider, error) {
+	providerType := shared_types.DNSProviderType(strings.ToLower(string(req.Provider)))
+	if !providerType.IsValid() {
+		return nil, types.ErrUnsupportedDNSProvider
+	}
+
+	now := time.Now()
+	provider := &shared_types.OrganizationDNSProvider{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Provider:       providerType,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.setDNSProviderFields(provider, req.Name, req.Zones, req.Credentials, nil); err != nil {
+		return nil, err
+	}
+	if err := s.storage.CreateOrganizationDNSProvider(provider); err != nil {
+		s.logger.Log(logger.Error, "failed to save dns provider", err.Error())
+		return nil, err
+	}
+	provider.CredentialKeys = dnsCredentialKeys(provider.Credentials)
+	return provider, nil
+}
+
+// UpdateDNSProvider changes the name, zones and credentials of a DNS
+// provider. Empty credential fields keep their stored value.
+func (s *DeployService) UpdateDNSProvider(req *types.UpdateDNSProviderRequest, organizationID uuid.UUID) (*shared_types.OrganizationDNSProvider, error) {
+	provider, err := s.getDNSProvider(organizationID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := decodeDNSCredentials(provider.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setDNSProviderFields(provider, req.Name, req.Zones, req.Credentials, stored); err != nil {
+		return nil, err
+	}
+	provider.UpdatedAt = time.Now()
+	if err := s.storage.UpdateOrganizationDNSProvider(provider); err != nil {
+		s.logger.Log(logger.Error, "failed to update dns provider", err.Error())
+		return nil, err
+	}
+	provider.CredentialKeys = dnsCredentialKeys(provider.Credentials)
+	return provider, nil
+}
+
+// DeleteDNSProvider removes a DNS provider. Its domains go back to
+// certificates from HTTP challenges.
+func (s *DeployService) DeleteDNSProvider(organizationID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.getDNSProvider(organizationID, id); err != nil {
+		return err
+	}
+	return s.storage.DeleteOrganizationDNSProvider(organizationID, id)
+}
+
+func (s *DeployService) getDNSProvider(organizationID uuid.UUID, id uuid.UUID) (*shared_types.OrganizationDNSProvider, error) {
+	provider, err := s.storage.GetOrganizationDNSProvider(organizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrDNSProviderNotFound
+		}
+		return nil, err
+	}
+	return provider, nil
+}
+
+// setDNSProviderFields validates name, zones and credentials against the
+// other providers of the organization and sets them on provider. stored
+// holds the current credentials of an existing provider.
+func (s *DeployService) setDNSProviderFields(provider *shared_types.OrganizationDNSProvider, name string, zones []string, credentials, stored map[string]string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return types.ErrInvalidDNSProviderName
+	}
+
+	normalized := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+		if strings.HasPrefix(zone, "*") {
+			return types.ErrInvalidDNSZone
+		}
+		if valid, err := s.storage.IsDomainValid(zone); err != nil || !valid {
+			return types.ErrInvalidDNSZone
+		}
+		if !slices.Contains(normalized, zone) {
+			normalized = append(normalized, zone)
+		}
+	}
+	if len(normalized) == 0 {
+		return types.ErrInvalidDNSZone
+	}
+
+	others, err := s.storage.GetOrganizationDNSProviders(provider.OrganizationID)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID == provider.ID {
+			continue
+		}
+		if strings.EqualFold(other.Name, name) {
+			return types.ErrDNSProviderNameTaken
+		}
+		for _, zone := range normalized {
+			if slices.Contains(other.Zones, zone) {
+				return fmt.Errorf("%w: %s", types.ErrDNSZoneTaken, zone)
+			}
+		}
+	}
+
+	fields := provider.Provider.CredentialFields()
+	merged := make(map[string]string, len(fields))
+	for key, value := range stored {
+		merged[key] = value
+	}
+	for key, value := range credentials {
+		if _, ok := fields[key]; !ok {
+			return fmt.Errorf("%w: %s", types.ErrUnknownDNSCredential, key)
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			merged[key] = value
+		}
+	}
+	for key, required := range fields {
+		if required && merged[key] == "" {
+			return fmt.Errorf("%w: %s", types.ErrMissingDNSCredential, key)
+		}
+	}
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	provider.Name = name
+	provider.Zones = normalized
+	provider.Credentials = shared_types.EncryptedString(encoded)
+	return nil
+}
+
+func decodeDNSCredentials(credentials shared_types.EncryptedString) (map[string]string, error) {
+	fields := map[string]string{}
+	if credentials == "" {
+		return fields, nil
+	}
+	if err := json.Unmarshal([]byte(credentials), &fields); err != nil {
+		return nil, fmt.Errorf("failed to read dns provider credentials: %w", err)
+	}
+	return fields, nil
+}
+
+// dnsCredentialKeys returns the sorted names of the credential fields that
+// are set.
+func dnsCredentialKeys(credentials shared_types.EncryptedString) []string {
+	fields, err := decodeDNSCredentials(credentials)
+	if err != nil {
+		return []string{}
+	}
+	keys := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -66,6 +66,11 @@ func (s *DeployService) DuplicateProject(req *types.DuplicateProjectRequest, use
 		branch = strings.TrimSpace(req.Branch)
 	}
 
+	composeFiles := sourceProject.ComposeFiles
+	if req.ComposeFiles != nil {
+		composeFiles = req.ComposeFiles
+	}
+
 	now := time.Now()
 	newProject := shared_types.Application{
 		ID:                   uuid.New(),
@@ -84,6 +89,14 @@ func (s *DeployService) DuplicateProject(req *types.DuplicateProjectRequest, use
 		UpdatedAt:            now,
 		DockerfilePath:       sourceProject.DockerfilePath,
 		BasePath:             sourceProject.BasePath,
+		ComposeFiles:         composeFiles,
+		ComposeProfiles:      sourceProject.ComposeProfiles,
+		ComposeEnvFiles:      sourceProject.ComposeEnvFiles,
+		ComposeDeployMode:    sourceProject.ComposeDeployMode,
+		PrivateNetworkScope:  sourceProject.PrivateNetworkScope,
+		SBOMFormat:           sourceProject.SBOMFormat,
+		VulnThreshold:        sourceProject.VulnThreshold,
+		InternalAlias:        shared_types.InternalAliasFromName(newName),
 		OrganizationID:       organizationID,
 		FamilyID:             &familyID,
 		ProxyServer:          sourceProject.ProxyServer,
@@ -91,12 +104,21 @@ func (s *DeployService) DuplicateProject(req *types.DuplicateProjectRequest, use
 		Source:               sourceProject.Source,
 	}
 
+	if err := s.storage.AssignInternalAlias(nil, &newProject); err != nil {
+		s.logger.Log(logger.Error, "failed to choose internal alias", err.Error())
+		return shared_types.Application{}, err
+	}
+
 	// Save the new project
 	if err := s.storage.AddApplication(&newProject); err != nil {
 		s.logger.Log(logger.Error, "failed to create duplicate project", err.Error())
 		return shared_types.Application{}, err
 	}
 
+	if _, err := s.storage.RecordEnvRevision(nil, newProject.ID, newProject.EnvironmentVariables, &userID, nil); err != nil {
+		s.logger.Log(logger.Warning, "failed to record environment revision", err.Error())
+	}
+
 	routingStrategy := req.RoutingStrategy
 	if routingStrategy == "" {
 		routingStrategy = sourceProject.RoutingStrategy
@@ -118,6 +140,18 @@ func (s *DeployService) DuplicateProject(req *types.DuplicateProjectRequest, use
 		}
 	}
 
+	if attachments, err := s.storage.GetApplicationVariableGroups(sourceProject.ID); err != nil {
+		s.logger.Log(logger.Warning, "failed to load variable groups of source project", err.Error())
+	} else if len(attachments) > 0 {
+		groupIDs := make([]uuid.UUID, 0, len(attachments))
+		for _, attachment := range attachments {
+			groupIDs = append(groupIDs, attachment.VariableGroupID)
+		}
+		if err := s.storage.SetApplicationVariableGroups(newProject.ID, groupIDs); err != nil {
+			s.logger.Log(logger.Warning, "failed to copy variable groups to duplicate", err.Error())
+		}
+	}
+
 	// Create application status with draft status
 	appStatus := shared_types.ApplicationStatus{
 		ID:            uuid.New(),
@@ -168,7 +202,7 @@ func (s *DeployService) DuplicateProject(req *types.DuplicateProjectRequest, use
 		}
 		// Same environment: copy domains from source
 		for _, d := range sourceDomains {
-			domains = append(domains, d.Domain)
+			domains = append(domains, d.Route())
 		}
 	}
 
@@ -285,7 +319,7 @@ func (s *DeployService) AddApplicationToFamily(req *types.AddApplicationToFamily
 		ID:                   uuid.New(),
 		Name:                 req.Name,
 		BuildVariables:       tasks.GetStringFromMap(req.BuildVariables),
-		EnvironmentVariables: tasks.GetStringFromMap(req.EnvironmentVariables),
+		EnvironmentVariables: shared_types.EncryptedString(tasks.GetStringFromMap(req.EnvironmentVariables)),
 		Environment:          environment,
 		BuildPack:            buildPack,
 		Repository:           req.Repository,
@@ -303,12 +337,21 @@ func (s *DeployService) AddApplicationToFamily(req *types.AddApplicationToFamily
 		ProxyServer:          shared_types.Caddy,
 	}
 
+	if err := s.storage.AssignInternalAlias(nil, &application); err != nil {
+		s.logger.Log(logger.Error, "failed to choose internal alias", err.Error())
+		return shared_types.Application{}, err
+	}
+
 	// Save the application
 	if err := s.storage.AddApplication(&application); err != nil {
 		s.logger.Log(logger.Error, "failed to create application", err.Error())
 		return shared_types.Application{}, err
 	}
 
+	if _, err := s.storage.RecordEnvRevision(nil, application.ID, application.EnvironmentVariables, &userID, nil); err != nil {
+		s.logger.Log(logger.Warning, "failed to record environment revision", err.Error())
+	}
+
 	// Create application status with draft status
 	appStatus := shared_types.ApplicationStatus{
 		ID:            uuid.New(),
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/uptrace/bun"
+)
+
+const maskedEnvValue = "********"
+
+// ListEnvRevisions returns the environment revision history of an application, newest first.
+// Only the keys of each revision are exposed.
+func (s *DeployService) ListEnvRevisions(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationEnvRevision, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	revisions, err := s.storage.GetEnvRevisions(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		revisions[i].Keys = envKeys(tasks.GetMapFromString(string(revisions[i].Variables)))
+	}
+	return revisions, nil
+}
+
+// DiffEnvRevisions compares two revisions of an application. When toID is nil
+// the comparison is made against the latest revision. Values are masked.
+func (s *DeployService) DiffEnvRevisions(applicationID uuid.UUID, fromID uuid.UUID, toID *uuid.UUID, organizationID uuid.UUID) (types.EnvRevisionDiffResponseData, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return types.EnvRevisionDiffResponseData{}, types.ErrApplicationNotFound
+	}
+
+	from, err := s.getEnvRevision(applicationID, fromID)
+	if err != nil {
+		return types.EnvRevisionDiffResponseData{}, err
+	}
+
+	var to *shared_types.ApplicationEnvRevision
+	if toID != nil {
+		to, err = s.getEnvRevision(applicationID, *toID)
+		if err != nil {
+			return types.EnvRevisionDiffResponseData{}, err
+		}
+	} else {
+		revisions, err := s.storage.GetEnvRevisions(applicationID)
+		if err != nil {
+			return types.EnvRevisionDiffResponseData{}, err
+		}
+		if len(revisions) == 0 {
+			return types.EnvRevisionDiffResponseData{}, types.ErrEnvRevisionNotFound
+		}
+		to = &revisions[0]
+	}
+
+	return types.EnvRevisionDiffResponseData{
+		From: from.Revision,
+		To:   to.Revision,
+		Changes: diffEnvVars(
+			tasks.GetMapFromString(string(from.Variables)),
+			tasks.GetMapFromString(string(to.Variables)),
+		),
+	}, nil
+}
+
+// RestoreEnvRevision sets the application's environment variables back to an
+// earlier revision and records the restore as a new revision. The change takes
+// effect on the next deployment.
+func (s *DeployService) RestoreEnvRevision(req *types.RestoreEnvRevisionRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationEnvRevision, error) {
+	if _, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	target, err := s.getEnvRevision(req.ApplicationID, req.RevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored *shared_types.ApplicationEnvRevision
+	err = s.storage.RunInTransaction(func(tx bun.Tx) error {
+		if err := s.storage.UpdateApplicationEnvironmentVariables(tx, req.ApplicationID, target.Variables); err != nil {
+			return err
+		}
+		restored, err = s.storage.RecordEnvRevision(tx, req.ApplicationID, target.Variables, &userID, &target.Revision)
+		return err
+	})
+	if err != nil {
+		s.logger.Log(logger.Error, "failed to restore environment revision", err.Error())
+		return nil, err
+	}
+
+	restored.Keys = envKeys(tasks.GetMapFromString(string(restored.Variables)))
+	return restored, nil
+}
+
+func (s *DeployService) getEnvRevision(applicationID uuid.UUID, revisionID uuid.UUID) (*shared_types.ApplicationEnvRevision, error) {
+	revision, err := s.storage.GetEnvRevisionByID(applicationID, revisionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrEnvRevisionNotFound
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+func envKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffEnvVars returns the key level differences between two variable sets,
+// sorted by key, with values masked.
+func diffEnvVars(from, to map[string]string) []types.EnvVarDiff {
+	changes := []types.EnvVarDiff{}
+	for _, k := range envKeys(from) {
+		newValue, ok := to[k]
+		switch {
+		case !ok:
+			changes = append(changes, types.EnvVarDiff{Key: k, Change: types.EnvVarRemoved, OldValue: maskedEnvValue})
+		case newValue != from[k]:
+			changes = append(changes, types.EnvVarDiff{Key: k, Change: types.EnvVarChanged, OldValue: maskedEnvValue, NewValue: maskedEnvValue})
+		}
+	}
+	for _, k := range envKeys(to) {
+		if _, ok := from[k]; !ok {
+			changes = append(changes, types.EnvVarDiff{Key: k, Change: types.EnvVarAdded, NewValue: maskedEnvValue})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
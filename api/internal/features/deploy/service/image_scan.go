@@ -0,0 +1,40 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetDeploymentSBOM returns the SBOM and vulnerability report stored for a deployment.
+func (s *DeployService) GetDeploymentSBOM(deploymentID uuid.UUID, organizationID uuid.UUID) (*shared_types.DeploymentSBOM, error) {
+	deployment, err := s.storage.GetApplicationDeploymentById(deploymentID.String())
+	if err != nil {
+		return nil, types.ErrSBOMNotFound
+	}
+	if _, err := s.getApplication(deployment.ApplicationID, organizationID); err != nil {
+		return nil, types.ErrSBOMNotFound
+	}
+	sbom, err := s.storage.GetDeploymentSBOM(deploymentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrSBOMNotFound
+		}
+		return nil, err
+	}
+	return sbom, nil
+}
+
+// FindPackageDeployments returns the deployments of the organization whose
+// image contains a package, optionally at a specific version.
+func (s *DeployService) FindPackageDeployments(organizationID uuid.UUID, name string, version string) ([]types.PackageDeployment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, types.ErrMissingPackageName
+	}
+	return s.storage.FindPackageDeployments(organizationID, name, strings.TrimSpace(version))
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListMaintenance returns the application wide and per domain maintenance
+// settings of an application.
+func (s *DeployService) ListMaintenance(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationMaintenance, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetApplicationMaintenance(applicationID)
+}
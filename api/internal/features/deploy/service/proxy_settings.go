@@ -0,0 +1,25 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetProxySettings returns the proxy settings of an application.
+func (s *DeployService) GetProxySettings(applicationID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationProxySettings, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	settings, err := s.storage.GetApplicationProxySettings(applicationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrProxySettingsNotFound
+		}
+		return nil, err
+	}
+	return settings, nil
+}
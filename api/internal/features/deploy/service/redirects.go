@@ -0,0 +1,15 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListRedirects returns the redirect rules of an application.
+func (s *DeployService) ListRedirects(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationRedirect, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetApplicationRedirects(applicationID)
+}
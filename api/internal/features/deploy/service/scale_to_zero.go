@@ -0,0 +1,27 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetScaleToZero returns the idle timeout of an application and whether it is
+// asleep. Applications without one report a zero timeout.
+func (s *DeployService) GetScaleToZero(applicationID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationScaleToZero, error) {
+	app, err := s.storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	state, err := s.storage.GetApplicationScaleToZero(app.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &shared_types.ApplicationScaleToZero{
+			ApplicationID:  app.ID,
+			OrganizationID: organizationID,
+		}, nil
+	}
+	return state, err
+}
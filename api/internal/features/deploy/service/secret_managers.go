@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ListSecretManagers returns the organization's secret manager connections without tokens.
+func (s *DeployService) ListSecretManagers(organizationID uuid.UUID) ([]shared_types.OrganizationSecretManager, error) {
+	managers, err := s.storage.GetOrganizationSecretManagers(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range managers {
+		managers[i].TokenConfigured = managers[i].Token != ""
+	}
+	return managers, nil
+}
+
+// UpsertSecretManager creates or updates the organization's connection for a provider.
+func (s *DeployService) UpsertSecretManager(req *types.UpsertSecretManagerRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.OrganizationSecretManager, error) {
+	managerType := secrets.SecretManagerType(strings.ToLower(string(req.Type)))
+	if managerType != secrets.SecretManagerInfisical && managerType != secrets.SecretManagerVault {
+		return nil, types.ErrUnsupportedSecretManager
+	}
+
+	rawURL := strings.TrimSpace(req.URL)
+	if rawURL == "" {
+		return nil, types.ErrInvalidSecretManagerURL
+	}
+	if !validSecretManagerURL(rawURL) {
+		return nil, types.ErrInvalidSecretManagerURL
+	}
+
+	token := shared_types.EncryptedString(req.Token)
+	if token == "" {
+		existing, err := s.storage.GetOrganizationSecretManager(organizationID, managerType)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, types.ErrMissingSecretManagerToken
+			}
+			return nil, err
+		}
+		token = existing.Token
+	}
+
+	mount := strings.Trim(req.Mount, "/")
+	if managerType == secrets.SecretManagerVault && mount == "" {
+		mount = "secret"
+	}
+
+	now := time.Now()
+	manager := &shared_types.OrganizationSecretManager{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Type:           managerType,
+		URL:            strings.TrimRight(rawURL, "/"),
+		ProjectID:      req.ProjectID,
+		Environment:    req.Environment,
+		Mount:          mount,
+		Namespace:      req.Namespace,
+		Token:          token,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.storage.UpsertOrganizationSecretManager(manager); err != nil {
+		s.logger.Log(logger.Error, "failed to save secret manager", err.Error())
+		return nil, err
+	}
+
+	saved, err := s.storage.GetOrganizationSecretManager(organizationID, managerType)
+	if err != nil {
+		return nil, err
+	}
+	saved.TokenConfigured = saved.Token != ""
+	return saved, nil
+}
+
+// DeleteSecretManager removes the organization's connection for a provider.
+func (s *DeployService) DeleteSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) error {
+	if _, err := s.getSecretManager(organizationID, managerType); err != nil {
+		return err
+	}
+	return s.storage.DeleteOrganizationSecretManager(organizationID, managerType)
+}
+
+// TestSecretManager reads a path through the configured connection and returns the
+// key names found there.
+func (s *DeployService) TestSecretManager(ctx context.Context, req *types.TestSecretManagerRequest, organizationID uuid.UUID) ([]string, error) {
+	connection, err := s.getSecretManager(organizationID, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if !validSecretManagerURL(connection.URL) {
+		return nil, types.ErrInvalidSecretManagerURL
+	}
+
+	path := req.Path
+	if path == "" {
+		path = "/"
+	}
+	manager, err := secrets.NewSecretManager(connection.ManagerConfig(path))
+	if err != nil {
+		return nil, err
+	}
+	values, err := manager.GetSecrets(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// validSecretManagerURL reports whether rawURL is an absolute http or https
+// URL. Other schemes would let the test endpoint probe arbitrary services.
+func validSecretManagerURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
+func (s *DeployService) getSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) (*shared_types.OrganizationSecretManager, error) {
+	managerType = secrets.SecretManagerType(strings.ToLower(string(managerType)))
+	connection, err := s.storage.GetOrganizationSecretManager(organizationID, managerType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrSecretManagerNotFound
+		}
+		return nil, err
+	}
+	return connection, nil
+}
@@ -1,5 +1,7 @@
 package service
 
+import "github.com/google/uuid"
+
 func (s *DeployService) IsNameAlreadyTaken(name string) (bool, error) {
 	return s.storage.IsNameAlreadyTaken(name)
 }
@@ -8,10 +10,10 @@ func (s *DeployService) IsDomainValid(domain string) (bool, error) {
 	return s.storage.IsDomainValid(domain)
 }
 
-func (s *DeployService) IsDomainAlreadyTaken(domain string) (bool, error) {
-	return s.storage.IsDomainAlreadyTaken(domain)
+func (s *DeployService) IsDomainAlreadyTaken(domain string, organizationID uuid.UUID) (bool, error) {
+	return s.storage.IsDomainAlreadyTaken(domain, organizationID)
 }
 
-func (s *DeployService) IsPortAlreadyTaken(port int) (bool, error) {
-	return s.storage.IsPortAlreadyTaken(port)
+func (s *DeployService) IsPortAlreadyTaken(port int, organizationID uuid.UUID, serverID *uuid.UUID) (bool, error) {
+	return s.storage.IsPortAlreadyTaken(port, organizationID, serverID)
 }
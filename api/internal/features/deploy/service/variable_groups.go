@@ -0,0 +1,183 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// CreateVariableGroup creates a shared variable group at organization or family scope.
+func (s *DeployService) CreateVariableGroup(req *types.CreateVariableGroupRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.VariableGroup, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, types.ErrMissingName
+	}
+
+	if req.FamilyID != nil {
+		projects, err := s.storage.GetProjectsByFamilyID(*req.FamilyID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if len(projects) == 0 {
+			return nil, types.ErrProjectFamilyNotFound
+		}
+	}
+
+	taken, err := s.storage.IsVariableGroupNameTaken(organizationID, req.FamilyID, name, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, types.ErrVariableGroupNameTaken
+	}
+
+	now := time.Now()
+	group := &shared_types.VariableGroup{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		FamilyID:       req.FamilyID,
+		Name:           name,
+		Description:    req.Description,
+		Variables:      shared_types.EncryptedString(tasks.GetStringFromMap(req.Variables)),
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.storage.CreateVariableGroup(group); err != nil {
+		s.logger.Log(logger.Error, "failed to create variable group", err.Error())
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateVariableGroup updates a variable group and returns the applications that
+// use it, so the caller can offer to redeploy them.
+func (s *DeployService) UpdateVariableGroup(req *types.UpdateVariableGroupRequest, organizationID uuid.UUID) (*types.UpdateVariableGroupResponseData, error) {
+	group, err := s.GetVariableGroup(req.ID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" && name != group.Name {
+		taken, err := s.storage.IsVariableGroupNameTaken(organizationID, group.FamilyID, name, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, types.ErrVariableGroupNameTaken
+		}
+		group.Name = name
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if req.Variables != nil {
+		group.Variables = shared_types.EncryptedString(tasks.GetStringFromMap(req.Variables))
+	}
+	group.UpdatedAt = time.Now()
+
+	if err := s.storage.UpdateVariableGroup(group); err != nil {
+		s.logger.Log(logger.Error, "failed to update variable group", err.Error())
+		return nil, err
+	}
+
+	affected, err := s.GetVariableGroupApplications(group.ID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.UpdateVariableGroupResponseData{
+		Group:                *group,
+		AffectedApplications: affected,
+	}, nil
+}
+
+// DeleteVariableGroup deletes a variable group and detaches it from all applications.
+func (s *DeployService) DeleteVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) error {
+	if _, err := s.GetVariableGroup(groupID, organizationID); err != nil {
+		return err
+	}
+	return s.storage.DeleteVariableGroup(groupID, organizationID)
+}
+
+// GetVariableGroup returns a variable group scoped to the organization.
+func (s *DeployService) GetVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) (*shared_types.VariableGroup, error) {
+	group, err := s.storage.GetVariableGroupByID(groupID, organizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrVariableGroupNotFound
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+// ListVariableGroups returns the organization's variable groups, optionally
+// limited to those usable within a project family.
+func (s *DeployService) ListVariableGroups(organizationID uuid.UUID, familyID *uuid.UUID) ([]shared_types.VariableGroup, error) {
+	return s.storage.GetVariableGroups(organizationID, familyID)
+}
+
+// GetVariableGroupApplications returns the applications that use a variable group.
+func (s *DeployService) GetVariableGroupApplications(groupID uuid.UUID, organizationID uuid.UUID) ([]types.VariableGroupApplication, error) {
+	apps, err := s.storage.GetApplicationsByVariableGroup(groupID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.VariableGroupApplication, 0, len(apps))
+	for _, app := range apps {
+		result = append(result, types.VariableGroupApplication{
+			ID:          app.ID,
+			Name:        app.Name,
+			Environment: app.Environment,
+		})
+	}
+	return result, nil
+}
+
+// GetApplicationVariableGroups returns the groups attached to an application,
+// ordered from lowest to highest precedence.
+func (s *DeployService) GetApplicationVariableGroups(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.ApplicationVariableGroup, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetApplicationVariableGroups(applicationID)
+}
+
+// SetApplicationVariableGroups replaces the groups attached to an application.
+// Family scoped groups can only be attached to applications of that family.
+func (s *DeployService) SetApplicationVariableGroups(req *types.SetApplicationVariableGroupsRequest, organizationID uuid.UUID) ([]shared_types.ApplicationVariableGroup, error) {
+	app, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(req.VariableGroupIDs))
+	for _, groupID := range req.VariableGroupIDs {
+		if _, dup := seen[groupID]; dup {
+			return nil, types.ErrDuplicateVariableGroup
+		}
+		seen[groupID] = struct{}{}
+
+		group, err := s.GetVariableGroup(groupID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if group.FamilyID != nil && (app.FamilyID == nil || *group.FamilyID != *app.FamilyID) {
+			return nil, types.ErrVariableGroupFamilyMismatch
+		}
+	}
+
+	if err := s.storage.SetApplicationVariableGroups(app.ID, req.VariableGroupIDs); err != nil {
+		s.logger.Log(logger.Error, "failed to set application variable groups", err.Error())
+		return nil, err
+	}
+	return s.storage.GetApplicationVariableGroups(app.ID)
+}
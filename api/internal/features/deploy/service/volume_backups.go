@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// defaultVolumeBackupRetention is how many successful backups a policy
+// keeps when the request does not say.
+const defaultVolumeBackupRetention = 7
+
+// ListVolumeBackupPolicies returns the volume backup policies of an application.
+func (s *DeployService) ListVolumeBackupPolicies(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.VolumeBackupPolicy, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetVolumeBackupPolicies(applicationID)
+}
+
+// ListVolumeBackups returns the volume backups of an application, newest first.
+func (s *DeployService) ListVolumeBackups(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.VolumeBackup, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetVolumeBackups(applicationID)
+}
+
+// ListVolumeRestores returns the volume restores of an application, newest first.
+func (s *DeployService) ListVolumeRestores(applicationID uuid.UUID, organizationID uuid.UUID) ([]shared_types.VolumeRestore, error) {
+	if _, err := s.storage.GetApplicationById(applicationID.String(), organizationID); err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	return s.storage.GetVolumeRestores(applicationID)
+}
+
+// CreateVolumeBackupPolicy sets up backups of a volume or bind mounted path
+// of an application. Each source can have one policy per application.
+func (s *DeployService) CreateVolumeBackupPolicy(req *types.CreateVolumeBackupPolicyRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackupPolicy, error) {
+	app, err := s.storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	sourceType, source, err := tasks.ParseVolumeBackupSource(req.SourceType, req.Source)
+	if err != nil {
+		return nil, err
+	}
+	if req.RetentionCount == 0 {
+		req.RetentionCount = defaultVolumeBackupRetention
+	}
+	schedule, err := validateVolumeBackupSchedule(req.Schedule, req.RetentionCount, req.RetentionDays)
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.storage.IsVolumeBackupSourceTaken(app.ID, sourceType, source)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, types.ErrVolumeBackupPolicyExists
+	}
+
+	now := time.Now()
+	policy := &shared_types.VolumeBackupPolicy{
+		ID:             uuid.New(),
+		ApplicationID:  app.ID,
+		OrganizationID: organizationID,
+		SourceType:     sourceType,
+		Source:         source,
+		Schedule:       schedule,
+		RetentionCount: req.RetentionCount,
+		RetentionDays:  req.RetentionDays,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.storage.CreateVolumeBackupPolicy(policy); err != nil {
+		s.logger.Log(logger.Error, "failed to create volume backup policy", err.Error())
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdateVolumeBackupPolicy replaces the schedule and retention of a policy.
+// Retention applies after the next successful backup.
+func (s *DeployService) UpdateVolumeBackupPolicy(policyID uuid.UUID, req *types.UpdateVolumeBackupPolicyRequest, organizationID uuid.UUID) (*shared_types.VolumeBackupPolicy, error) {
+	policy, err := s.storage.GetVolumeBackupPolicy(policyID, organizationID)
+	if err != nil {
+		return nil, types.ErrVolumeBackupPolicyNotFound
+	}
+	schedule, err := validateVolumeBackupSchedule(req.Schedule, req.RetentionCount, req.RetentionDays)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Schedule = schedule
+	policy.RetentionCount = req.RetentionCount
+	policy.RetentionDays = req.RetentionDays
+	if err := s.storage.UpdateVolumeBackupPolicy(policy); err != nil {
+		s.logger.Log(logger.Error, "failed to update volume backup policy", err.Error())
+		return nil, err
+	}
+	return policy, nil
+}
+
+// validateVolumeBackupSchedule checks the retention and, when set, the cron
+// schedule of a policy, and returns the trimmed schedule.
+func validateVolumeBackupSchedule(schedule string, retentionCount int, retentionDays int) (string, error) {
+	if retentionCount < 1 || retentionDays < 0 {
+		return "", types.ErrInvalidBackupRetention
+	}
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return "", nil
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		return "", types.ErrS3NotConfigured
+	}
+	if _, err := tasks.ParseBackupSchedule(schedule); err != nil {
+		return "", err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationAccessPolicies returns the application wide and per domain
+// access policies of an application.
+func (s *DeployStorage) GetApplicationAccessPolicies(applicationID uuid.UUID) ([]shared_types.ApplicationAccessPolicy, error) {
+	var policies []shared_types.ApplicationAccessPolicy
+	err := s.DB.NewSelect().
+		Model(&policies).
+		Where("aap.application_id = ?", applicationID).
+		Order("aap.domain ASC").
+		Scan(s.Ctx)
+	return policies, err
+}
+
+// UpsertApplicationAccessPolicy inserts or overwrites the access policy of
+// an application or one of its domains and sets its UpdatedAt.
+func (s *DeployStorage) UpsertApplicationAccessPolicy(policy *shared_types.ApplicationAccessPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(policy).
+		On("CONFLICT (application_id, domain) DO UPDATE").
+		Set("allowed_ips = EXCLUDED.allowed_ips").
+		Set("denied_ips = EXCLUDED.denied_ips").
+		Set("basic_auth_users = EXCLUDED.basic_auth_users").
+		Set("basic_auth_realm = EXCLUDED.basic_auth_realm").
+		Set("forward_auth_url = EXCLUDED.forward_auth_url").
+		Set("forward_auth_copy_headers = EXCLUDED.forward_auth_copy_headers").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteApplicationAccessPolicy removes the access policy of an
+// application, or of one of its domains when domain is set.
+func (s *DeployStorage) DeleteApplicationAccessPolicy(applicationID uuid.UUID, domain string) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationAccessPolicy)(nil)).
+		Where("application_id = ? AND domain = ?", applicationID, domain).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetOrganizationAccessPolicies returns the access policies of every
+// application of an organization.
+func (s *DeployStorage) GetOrganizationAccessPolicies(organizationID uuid.UUID) ([]shared_types.ApplicationAccessPolicy, error) {
+	var policies []shared_types.ApplicationAccessPolicy
+	err := s.DB.NewSelect().
+		Model(&policies).
+		Where("aap.organization_id = ?", organizationID).
+		Scan(s.Ctx)
+	return policies, err
+}
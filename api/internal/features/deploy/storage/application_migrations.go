@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/uptrace/bun"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+var activeMigrationStatuses = []shared_types.MigrationStatus{
+	shared_types.MigrationPending,
+	shared_types.MigrationRunning,
+	shared_types.MigrationAwaitingConfirmation,
+	shared_types.MigrationConfirming,
+	shared_types.MigrationRollingBack,
+}
+
+// CreateApplicationMigration inserts a migration unless the application
+// already has an active one, in which case ErrMigrationInProgress is returned.
+// Concurrent starts for the same application are serialized by an advisory
+// lock held until the transaction ends.
+func (s *DeployStorage) CreateApplicationMigration(migration *shared_types.ApplicationMigration) error {
+	return s.RunInTransaction(func(tx bun.Tx) error {
+		if _, err := tx.ExecContext(s.Ctx, "SELECT pg_advisory_xact_lock(hashtext(?))", "application_migration:"+migration.ApplicationID.String()); err != nil {
+			return err
+		}
+		active, err := tx.NewSelect().
+			Model((*shared_types.ApplicationMigration)(nil)).
+			Where("am.application_id = ?", migration.ApplicationID).
+			Where("am.status IN (?)", bun.In(activeMigrationStatuses)).
+			Count(s.Ctx)
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return types.ErrMigrationInProgress
+		}
+		_, err = tx.NewInsert().Model(migration).Exec(s.Ctx)
+		return err
+	})
+}
+
+func (s *DeployStorage) UpdateApplicationMigration(migration *shared_types.ApplicationMigration) error {
+	migration.UpdatedAt = time.Now()
+	_, err := s.DB.NewUpdate().
+		Model(migration).
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+func (s *DeployStorage) GetApplicationMigration(id uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationMigration, error) {
+	var migration shared_types.ApplicationMigration
+	err := s.DB.NewSelect().
+		Model(&migration).
+		Where("am.id = ?", id).
+		Where("am.organization_id = ?", organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+// GetApplicationMigrations returns the migrations of an application, newest first.
+func (s *DeployStorage) GetApplicationMigrations(applicationID uuid.UUID) ([]shared_types.ApplicationMigration, error) {
+	var migrations []shared_types.ApplicationMigration
+	err := s.DB.NewSelect().
+		Model(&migrations).
+		Where("am.application_id = ?", applicationID).
+		Order("am.created_at DESC").
+		Scan(s.Ctx)
+	return migrations, err
+}
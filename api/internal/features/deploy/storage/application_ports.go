@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationPorts returns the TCP/UDP ports of an application.
+func (s *DeployStorage) GetApplicationPorts(applicationID uuid.UUID) ([]shared_types.ApplicationPort, error) {
+	var ports []shared_types.ApplicationPort
+	err := s.DB.NewSelect().
+		Model(&ports).
+		Relation("Upstreams").
+		Where("apt.application_id = ?", applicationID).
+		Order("apt.published_port ASC", "apt.protocol ASC").
+		Scan(s.Ctx)
+	return ports, err
+}
+
+// GetApplicationPort returns a single port of an application.
+func (s *DeployStorage) GetApplicationPort(applicationID uuid.UUID, portID uuid.UUID) (*shared_types.ApplicationPort, error) {
+	var port shared_types.ApplicationPort
+	err := s.DB.NewSelect().
+		Model(&port).
+		Where("apt.id = ? AND apt.application_id = ?", portID, applicationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &port, nil
+}
+
+func (s *DeployStorage) AddApplicationPort(port *shared_types.ApplicationPort) error {
+	_, err := s.DB.NewInsert().Model(port).Exec(s.Ctx)
+	return err
+}
+
+func (s *DeployStorage) DeleteApplicationPort(applicationID uuid.UUID, portID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationPort)(nil)).
+		Where("id = ? AND application_id = ?", portID, applicationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// UpdateApplicationPortUpstream records the host port Docker assigned to a
+// proxied port on a server.
+func (s *DeployStorage) UpdateApplicationPortUpstream(portID uuid.UUID, serverID uuid.UUID, upstreamPort int) error {
+	upstream := &shared_types.ApplicationPortUpstream{
+		PortID:       portID,
+		ServerID:     serverID,
+		UpstreamPort: upstreamPort,
+		UpdatedAt:    time.Now(),
+	}
+	_, err := s.DB.NewInsert().
+		Model(upstream).
+		On("CONFLICT (port_id, server_id) DO UPDATE").
+		Set("upstream_port = EXCLUDED.upstream_port").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(s.Ctx)
+	return err
+}
+
+// GetPublishedApplicationPorts returns the ports of an organization's
+// applications that publish the given port number and protocol.
+func (s *DeployStorage) GetPublishedApplicationPorts(organizationID uuid.UUID, protocol shared_types.PortProtocol, publishedPort int) ([]shared_types.ApplicationPort, error) {
+	var ports []shared_types.ApplicationPort
+	err := s.DB.NewSelect().
+		Model(&ports).
+		Join("JOIN applications AS a ON a.id = apt.application_id").
+		Where("a.organization_id = ?", organizationID).
+		Where("apt.protocol = ? AND apt.published_port = ?", protocol, publishedPort).
+		Scan(s.Ctx)
+	return ports, err
+}
+
+// GetProxiedApplicationPorts returns the organization's proxied ports that
+// have been deployed and can be routed by Caddy.
+func (s *DeployStorage) GetProxiedApplicationPorts(organizationID uuid.UUID) ([]shared_types.ApplicationPort, error) {
+	var ports []shared_types.ApplicationPort
+	err := s.DB.NewSelect().
+		Model(&ports).
+		Relation("Upstreams").
+		Join("JOIN applications AS a ON a.id = apt.application_id").
+		Where("a.organization_id = ?", organizationID).
+		Where("apt.publish_mode = ?", shared_types.PortPublishProxy).
+		Where("EXISTS (SELECT 1 FROM application_port_upstreams AS u WHERE u.port_id = apt.id)").
+		Order("apt.published_port ASC", "apt.protocol ASC").
+		Scan(s.Ctx)
+	return ports, err
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetAutoscalingPolicy returns the autoscaling policy of an application.
+func (s *DeployStorage) GetAutoscalingPolicy(applicationID uuid.UUID) (*shared_types.AutoscalingPolicy, error) {
+	var policy shared_types.AutoscalingPolicy
+	err := s.DB.NewSelect().
+		Model(&policy).
+		Where("aap.application_id = ?", applicationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpsertAutoscalingPolicy inserts or overwrites the autoscaling policy of an
+// application and sets its UpdatedAt. An existing last scale time is kept.
+func (s *DeployStorage) UpsertAutoscalingPolicy(policy *shared_types.AutoscalingPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(policy).
+		On("CONFLICT (application_id) DO UPDATE").
+		Set("enabled = EXCLUDED.enabled").
+		Set("min_replicas = EXCLUDED.min_replicas").
+		Set("max_replicas = EXCLUDED.max_replicas").
+		Set("metric = EXCLUDED.metric").
+		Set("target_percent = EXCLUDED.target_percent").
+		Set("cooldown_seconds = EXCLUDED.cooldown_seconds").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(s.Ctx)
+	return err
+}
+
+// SetAutoscalingLastScaledAt records when the autoscaler last scaled an
+// application, leaving the rest of its policy untouched.
+func (s *DeployStorage) SetAutoscalingLastScaledAt(applicationID uuid.UUID, scaledAt time.Time) error {
+	_, err := s.DB.NewUpdate().
+		Model((*shared_types.AutoscalingPolicy)(nil)).
+		Set("last_scaled_at = ?", scaledAt).
+		Where("application_id = ?", applicationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteAutoscalingPolicy removes the autoscaling policy of an application.
+func (s *DeployStorage) DeleteAutoscalingPolicy(applicationID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.AutoscalingPolicy)(nil)).
+		Where("application_id = ?", applicationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetEnabledAutoscalingPolicies returns every enabled autoscaling policy,
+// across all organizations.
+func (s *DeployStorage) GetEnabledAutoscalingPolicies() ([]shared_types.AutoscalingPolicy, error) {
+	var policies []shared_types.AutoscalingPolicy
+	err := s.DB.NewSelect().
+		Model(&policies).
+		Where("aap.enabled = true").
+		Scan(s.Ctx)
+	return policies, err
+}
+
+// CreateScalingEvent records a scaling decision.
+func (s *DeployStorage) CreateScalingEvent(event *shared_types.ApplicationScalingEvent) error {
+	_, err := s.DB.NewInsert().Model(event).Exec(s.Ctx)
+	return err
+}
+
+// GetScalingEvents returns the latest scaling decisions of an application,
+// newest first.
+func (s *DeployStorage) GetScalingEvents(applicationID uuid.UUID, limit int) ([]shared_types.ApplicationScalingEvent, error) {
+	var events []shared_types.ApplicationScalingEvent
+	err := s.DB.NewSelect().
+		Model(&events).
+		Where("ase.application_id = ?", applicationID).
+		Order("ase.created_at DESC").
+		Limit(limit).
+		Scan(s.Ctx)
+	return events, err
+}
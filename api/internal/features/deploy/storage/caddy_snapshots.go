@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// RecordCaddyConfigSnapshot stores snapshot as the next version for its
+// organization and server, and drops the versions beyond the latest keep.
+// When the latest version already holds the same config it is returned
+// unchanged, so callers can record before every change.
+func (s *DeployStorage) RecordCaddyConfigSnapshot(snapshot *shared_types.CaddyConfigSnapshot, keep int) (*shared_types.CaddyConfigSnapshot, error) {
+	var recorded *shared_types.CaddyConfigSnapshot
+	err := s.RunInTransaction(func(tx bun.Tx) error {
+		// Serialize version numbering per organization and server.
+		if _, err := tx.ExecContext(s.Ctx, "SELECT pg_advisory_xact_lock(hashtext(?))",
+			snapshot.OrganizationID.String()+"/"+snapshot.ServerHost); err != nil {
+			return err
+		}
+
+		var latest shared_types.CaddyConfigSnapshot
+		err := tx.NewSelect().
+			Model(&latest).
+			Where("ccs.organization_id = ? AND ccs.server_host = ?", snapshot.OrganizationID, snapshot.ServerHost).
+			Order("ccs.version DESC").
+			Limit(1).
+			Scan(s.Ctx)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		case latest.Config == snapshot.Config && snapshot.RestoredFrom == nil:
+			recorded = &latest
+			return nil
+		}
+
+		snapshot.ID = uuid.New()
+		snapshot.Version = latest.Version + 1
+		snapshot.CreatedAt = time.Now()
+		if _, err := tx.NewInsert().Model(snapshot).Exec(s.Ctx); err != nil {
+			return err
+		}
+		recorded = snapshot
+
+		_, err = tx.NewDelete().
+			Model((*shared_types.CaddyConfigSnapshot)(nil)).
+			Where("organization_id = ? AND server_host = ? AND version <= ?",
+				snapshot.OrganizationID, snapshot.ServerHost, snapshot.Version-keep).
+			Exec(s.Ctx)
+		return err
+	})
+	return recorded, err
+}
+
+// GetCaddyConfigSnapshots returns the Caddy config snapshots of an
+// organization, newest first, without their configs.
+func (s *DeployStorage) GetCaddyConfigSnapshots(organizationID uuid.UUID) ([]shared_types.CaddyConfigSnapshot, error) {
+	var snapshots []shared_types.CaddyConfigSnapshot
+	err := s.DB.NewSelect().
+		Model(&snapshots).
+		ExcludeColumn("config").
+		Where("ccs.organization_id = ?", organizationID).
+		Order("ccs.created_at DESC", "ccs.version DESC").
+		Scan(s.Ctx)
+	return snapshots, err
+}
+
+// GetCaddyConfigSnapshotByID returns a single Caddy config snapshot scoped
+// to the organization.
+func (s *DeployStorage) GetCaddyConfigSnapshotByID(organizationID uuid.UUID, snapshotID uuid.UUID) (*shared_types.CaddyConfigSnapshot, error) {
+	var snapshot shared_types.CaddyConfigSnapshot
+	err := s.DB.NewSelect().
+		Model(&snapshot).
+		Where("ccs.id = ? AND ccs.organization_id = ?", snapshotID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationCertificates returns the custom certificates of an
+// application.
+func (s *DeployStorage) GetApplicationCertificates(applicationID uuid.UUID) ([]shared_types.ApplicationCertificate, error) {
+	var certificates []shared_types.ApplicationCertificate
+	err := s.DB.NewSelect().
+		Model(&certificates).
+		Where("acrt.application_id = ?", applicationID).
+		Order("acrt.domain ASC").
+		Scan(s.Ctx)
+	return certificates, err
+}
+
+// GetOrganizationCertificates returns the custom certificates of every
+// application of an organization.
+func (s *DeployStorage) GetOrganizationCertificates(organizationID uuid.UUID) ([]shared_types.ApplicationCertificate, error) {
+	var certificates []shared_types.ApplicationCertificate
+	err := s.DB.NewSelect().
+		Model(&certificates).
+		Where("acrt.organization_id = ?", organizationID).
+		Order("acrt.domain ASC").
+		Scan(s.Ctx)
+	return certificates, err
+}
+
+// UpsertApplicationCertificate inserts or replaces the custom certificate
+// of a domain in the organization. A replaced certificate gets a new
+// expiry warning.
+func (s *DeployStorage) UpsertApplicationCertificate(certificate *shared_types.ApplicationCertificate) error {
+	certificate.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(certificate).
+		On("CONFLICT (organization_id, domain) DO UPDATE").
+		Set("application_id = EXCLUDED.application_id").
+		Set("certificate_pem = EXCLUDED.certificate_pem").
+		Set("private_key_pem = EXCLUDED.private_key_pem").
+		Set("subject = EXCLUDED.subject").
+		Set("issuer = EXCLUDED.issuer").
+		Set("dns_names = EXCLUDED.dns_names").
+		Set("fingerprint = EXCLUDED.fingerprint").
+		Set("not_before = EXCLUDED.not_before").
+		Set("not_after = EXCLUDED.not_after").
+		Set("expiry_warning_days = EXCLUDED.expiry_warning_days").
+		Set("expiry_warned_at = NULL").
+		Set("created_by = EXCLUDED.created_by").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteApplicationCertificate removes the custom certificate of a domain
+// of an application.
+func (s *DeployStorage) DeleteApplicationCertificate(applicationID uuid.UUID, domain string) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationCertificate)(nil)).
+		Where("application_id = ? AND domain = ?", applicationID, domain).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetExpiringCertificates returns the custom certificates that are within
+// their warning period at now and were not warned about yet.
+func (s *DeployStorage) GetExpiringCertificates(now time.Time) ([]shared_types.ApplicationCertificate, error) {
+	var certificates []shared_types.ApplicationCertificate
+	err := s.DB.NewSelect().
+		Model(&certificates).
+		Where("acrt.expiry_warned_at IS NULL").
+		Where("acrt.not_after <= ?::timestamp + acrt.expiry_warning_days * INTERVAL '1 day'", now).
+		Scan(s.Ctx)
+	return certificates, err
+}
+
+// MarkCertificateExpiryWarned records that the expiry warning of a
+// certificate was sent. It reports false when the certificate was replaced
+// or another instance already claimed the warning.
+func (s *DeployStorage) MarkCertificateExpiryWarned(id uuid.UUID, fingerprint string, at time.Time) (bool, error) {
+	res, err := s.DB.NewUpdate().
+		Model((*shared_types.ApplicationCertificate)(nil)).
+		Set("expiry_warned_at = ?", at).
+		Where("id = ? AND fingerprint = ? AND expiry_warned_at IS NULL", id, fingerprint).
+		Exec(s.Ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
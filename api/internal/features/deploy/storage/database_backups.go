@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetScheduledBackupDatabases returns the running databases of all
+// organizations that have a backup schedule.
+func (s *DeployStorage) GetScheduledBackupDatabases() ([]shared_types.Database, error) {
+	var databases []shared_types.Database
+	err := s.DB.NewSelect().
+		Model(&databases).
+		Where("backup_schedule != ''").
+		Where("status = ?", shared_types.DatabaseRunning).
+		Scan(s.Ctx)
+	return databases, err
+}
+
+// CreateDatabaseBackup inserts a new database backup.
+func (s *DeployStorage) CreateDatabaseBackup(backup *shared_types.DatabaseBackup) error {
+	_, err := s.DB.NewInsert().Model(backup).Exec(s.Ctx)
+	return err
+}
+
+// UpdateDatabaseBackup overwrites a database backup.
+func (s *DeployStorage) UpdateDatabaseBackup(backup *shared_types.DatabaseBackup) error {
+	_, err := s.DB.NewUpdate().
+		Model(backup).
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteDatabaseBackup removes a database backup record. The dump in S3 is
+// removed by the caller.
+func (s *DeployStorage) DeleteDatabaseBackup(backupID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.DatabaseBackup)(nil)).
+		Where("id = ?", backupID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetDatabaseBackup returns a database backup scoped to the organization.
+func (s *DeployStorage) GetDatabaseBackup(backupID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseBackup, error) {
+	var backup shared_types.DatabaseBackup
+	err := s.DB.NewSelect().
+		Model(&backup).
+		Where("id = ? AND organization_id = ?", backupID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// GetDatabaseBackups lists the backups of a database, newest first.
+func (s *DeployStorage) GetDatabaseBackups(databaseID uuid.UUID) ([]shared_types.DatabaseBackup, error) {
+	var backups []shared_types.DatabaseBackup
+	err := s.DB.NewSelect().
+		Model(&backups).
+		Where("database_id = ?", databaseID).
+		Order("created_at DESC").
+		Scan(s.Ctx)
+	return backups, err
+}
+
+// HasActiveDatabaseBackup reports whether a backup of the database is pending
+// or running.
+func (s *DeployStorage) HasActiveDatabaseBackup(databaseID uuid.UUID) (bool, error) {
+	return s.DB.NewSelect().
+		Model((*shared_types.DatabaseBackup)(nil)).
+		Where("database_id = ?", databaseID).
+		Where("status IN (?, ?)", shared_types.BackupPending, shared_types.BackupRunning).
+		Exists(s.Ctx)
+}
+
+// CreateDatabaseRestore inserts a new database restore.
+func (s *DeployStorage) CreateDatabaseRestore(restore *shared_types.DatabaseRestore) error {
+	_, err := s.DB.NewInsert().Model(restore).Exec(s.Ctx)
+	return err
+}
+
+// UpdateDatabaseRestore overwrites a database restore.
+func (s *DeployStorage) UpdateDatabaseRestore(restore *shared_types.DatabaseRestore) error {
+	_, err := s.DB.NewUpdate().
+		Model(restore).
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+// GetDatabaseRestore returns a database restore scoped to the organization.
+func (s *DeployStorage) GetDatabaseRestore(restoreID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseRestore, error) {
+	var restore shared_types.DatabaseRestore
+	err := s.DB.NewSelect().
+		Model(&restore).
+		Where("id = ? AND organization_id = ?", restoreID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &restore, nil
+}
+
+// GetDatabaseRestores lists the restores into a database, newest first.
+func (s *DeployStorage) GetDatabaseRestores(databaseID uuid.UUID) ([]shared_types.DatabaseRestore, error) {
+	var restores []shared_types.DatabaseRestore
+	err := s.DB.NewSelect().
+		Model(&restores).
+		Where("target_database_id = ?", databaseID).
+		Order("created_at DESC").
+		Scan(s.Ctx)
+	return restores, err
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// CreateDatabase inserts a new managed database.
+func (s *DeployStorage) CreateDatabase(database *shared_types.Database) error {
+	_, err := s.DB.NewInsert().Model(database).Exec(s.Ctx)
+	return err
+}
+
+// UpdateDatabase overwrites a managed database and sets its UpdatedAt.
+func (s *DeployStorage) UpdateDatabase(database *shared_types.Database) error {
+	database.UpdatedAt = time.Now()
+	_, err := s.DB.NewUpdate().
+		Model(database).
+		Where("id = ? AND organization_id = ?", database.ID, database.OrganizationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteDatabase removes a managed database. Attachments are removed by the
+// foreign key.
+func (s *DeployStorage) DeleteDatabase(databaseID uuid.UUID, organizationID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.Database)(nil)).
+		Where("id = ? AND organization_id = ?", databaseID, organizationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetDatabaseByID returns a managed database scoped to the organization.
+func (s *DeployStorage) GetDatabaseByID(databaseID uuid.UUID, organizationID uuid.UUID) (*shared_types.Database, error) {
+	var database shared_types.Database
+	err := s.DB.NewSelect().
+		Model(&database).
+		Where("id = ? AND organization_id = ?", databaseID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &database, nil
+}
+
+// GetDatabases lists the managed databases of an organization.
+func (s *DeployStorage) GetDatabases(organizationID uuid.UUID) ([]shared_types.Database, error) {
+	var databases []shared_types.Database
+	err := s.DB.NewSelect().
+		Model(&databases).
+		Where("organization_id = ?", organizationID).
+		Order("name ASC").
+		Scan(s.Ctx)
+	return databases, err
+}
+
+// IsDatabaseNameTaken reports whether the organization already has a database named name.
+func (s *DeployStorage) IsDatabaseNameTaken(organizationID uuid.UUID, name string) (bool, error) {
+	count, err := s.DB.NewSelect().
+		Model((*shared_types.Database)(nil)).
+		Where("organization_id = ?", organizationID).
+		Where("name = ?", name).
+		Count(s.Ctx)
+	return count > 0, err
+}
+
+// GetApplicationDatabases returns the databases attached to an application.
+func (s *DeployStorage) GetApplicationDatabases(applicationID uuid.UUID) ([]shared_types.ApplicationDatabase, error) {
+	var attachments []shared_types.ApplicationDatabase
+	err := s.DB.NewSelect().
+		Model(&attachments).
+		Relation("Database").
+		Where("adb.application_id = ?", applicationID).
+		Order("adb.created_at ASC").
+		Scan(s.Ctx)
+	return attachments, err
+}
+
+// GetDatabaseAttachments returns the attachments of a database.
+func (s *DeployStorage) GetDatabaseAttachments(databaseID uuid.UUID) ([]shared_types.ApplicationDatabase, error) {
+	var attachments []shared_types.ApplicationDatabase
+	err := s.DB.NewSelect().
+		Model(&attachments).
+		Where("database_id = ?", databaseID).
+		Scan(s.Ctx)
+	return attachments, err
+}
+
+// GetApplicationsByDatabase returns the applications a database is attached to.
+func (s *DeployStorage) GetApplicationsByDatabase(databaseID uuid.UUID, organizationID uuid.UUID) ([]shared_types.Application, error) {
+	var applications []shared_types.Application
+	err := s.DB.NewSelect().
+		Model(&applications).
+		Relation("Status").
+		Join("JOIN application_databases AS adb ON adb.application_id = a.id").
+		Where("adb.database_id = ?", databaseID).
+		Where("a.organization_id = ?", organizationID).
+		Order("a.name ASC").
+		Scan(s.Ctx)
+	return applications, err
+}
+
+// AttachDatabase attaches a database to an application.
+func (s *DeployStorage) AttachDatabase(attachment *shared_types.ApplicationDatabase) error {
+	_, err := s.DB.NewInsert().Model(attachment).Exec(s.Ctx)
+	return err
+}
+
+// DetachDatabase removes a database from an application. It reports whether
+// the database was attached.
+func (s *DeployStorage) DetachDatabase(applicationID uuid.UUID, databaseID uuid.UUID) (bool, error) {
+	res, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationDatabase)(nil)).
+		Where("application_id = ? AND database_id = ?", applicationID, databaseID).
+		Exec(s.Ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func (s *DeployStorage) AddDeploymentPhase(phase *shared_types.DeploymentPhase) error {
+	_, err := s.DB.NewInsert().Model(phase).Exec(s.Ctx)
+	return err
+}
+
+// UpdateDeploymentPhase stores how a phase ended.
+func (s *DeployStorage) UpdateDeploymentPhase(phase *shared_types.DeploymentPhase) error {
+	_, err := s.DB.NewUpdate().
+		Model(phase).
+		Column("status", "ended_at", "duration_ms", "build_steps", "cached_steps").
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+// GetApplicationDeploymentPhases returns the phases of the latest deployments
+// of an application.
+func (s *DeployStorage) GetApplicationDeploymentPhases(applicationID uuid.UUID, deployments int) ([]shared_types.DeploymentPhase, error) {
+	latest := s.DB.NewSelect().
+		Model((*shared_types.ApplicationDeployment)(nil)).
+		Column("ad.id").
+		Where("ad.application_id = ?", applicationID).
+		Order("ad.created_at DESC").
+		Limit(deployments)
+
+	var phases []shared_types.DeploymentPhase
+	err := s.DB.NewSelect().
+		Model(&phases).
+		Where("adp.application_deployment_id IN (?)", latest).
+		Order("adp.started_at ASC").
+		Scan(s.Ctx)
+	return phases, err
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/uptrace/bun"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// SaveDeploymentSBOM stores the SBOM of a deployment together with its packages.
+func (s *DeployStorage) SaveDeploymentSBOM(sbom *shared_types.DeploymentSBOM, packages []shared_types.DeploymentPackage) error {
+	return s.RunInTransaction(func(tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(sbom).Exec(s.Ctx); err != nil {
+			return err
+		}
+		if len(packages) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&packages).Exec(s.Ctx)
+		return err
+	})
+}
+
+func (s *DeployStorage) GetDeploymentSBOM(deploymentID uuid.UUID) (*shared_types.DeploymentSBOM, error) {
+	var sbom shared_types.DeploymentSBOM
+	err := s.DB.NewSelect().
+		Model(&sbom).
+		Where("ads.application_deployment_id = ?", deploymentID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sbom, nil
+}
+
+// FindPackageDeployments returns the deployments of an organization whose
+// image contains the named package, optionally at a specific version.
+func (s *DeployStorage) FindPackageDeployments(organizationID uuid.UUID, name string, version string) ([]types.PackageDeployment, error) {
+	var results []types.PackageDeployment
+	query := s.DB.NewSelect().
+		TableExpr("application_deployment_packages AS adpk").
+		ColumnExpr("adpk.application_deployment_id, adpk.application_id, a.name AS application_name").
+		ColumnExpr("adpk.name, adpk.version, adpk.type, adpk.purl, adpk.created_at").
+		Join("JOIN applications AS a ON a.id = adpk.application_id").
+		Where("a.organization_id = ?", organizationID).
+		Where("adpk.name = ?", name)
+	if version != "" {
+		query = query.Where("adpk.version = ?", version)
+	}
+	err := query.Order("adpk.created_at DESC").Limit(500).Scan(s.Ctx, &results)
+	return results, err
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetOrganizationDNSProviders returns the DNS providers of an organization.
+func (s *DeployStorage) GetOrganizationDNSProviders(organizationID uuid.UUID) ([]shared_types.OrganizationDNSProvider, error) {
+	var providers []shared_types.OrganizationDNSProvider
+	err := s.DB.NewSelect().
+		Model(&providers).
+		Where("organization_id = ?", organizationID).
+		Order("name ASC").
+		Scan(s.Ctx)
+	return providers, err
+}
+
+// GetOrganizationDNSProvider returns a DNS provider of an organization.
+func (s *DeployStorage) GetOrganizationDNSProvider(organizationID uuid.UUID, id uuid.UUID) (*shared_types.OrganizationDNSProvider, error) {
+	var provider shared_types.OrganizationDNSProvider
+	err := s.DB.NewSelect().
+		Model(&provider).
+		Where("organization_id = ? AND id = ?", organizationID, id).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// CreateOrganizationDNSProvider stores a new DNS provider.
+func (s *DeployStorage) CreateOrganizationDNSProvider(provider *shared_types.OrganizationDNSProvider) error {
+	_, err := s.DB.NewInsert().Model(provider).Exec(s.Ctx)
+	return err
+}
+
+// UpdateOrganizationDNSProvider replaces the name, zones and credentials of
+// a DNS provider.
+func (s *DeployStorage) UpdateOrganizationDNSProvider(provider *shared_types.OrganizationDNSProvider) error {
+	_, err := s.DB.NewUpdate().
+		Model(provider).
+		Column("name", "zones", "credentials", "updated_at").
+		Where("organization_id = ? AND id = ?", provider.OrganizationID, provider.ID).
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteOrganizationDNSProvider removes a DNS provider of an organization.
+func (s *DeployStorage) DeleteOrganizationDNSProvider(organizationID uuid.UUID, id uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.OrganizationDNSProvider)(nil)).
+		Where("organization_id = ? AND id = ?", organizationID, id).
+		Exec(s.Ctx)
+	return err
+}
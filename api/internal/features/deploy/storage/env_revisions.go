@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// RecordEnvRevision stores variables as the next revision for the application.
+// When the latest revision already holds the same variables it is returned
+// unchanged, so callers can invoke it on every deploy without creating noise.
+// Pass a transaction as db to record the revision atomically with other writes;
+// nil runs it in a transaction of its own.
+func (s *DeployStorage) RecordEnvRevision(db bun.IDB, applicationID uuid.UUID, variables shared_types.EncryptedString, createdBy *uuid.UUID, restoredFrom *int) (*shared_types.ApplicationEnvRevision, error) {
+	if db == nil {
+		var revision *shared_types.ApplicationEnvRevision
+		err := s.RunInTransaction(func(tx bun.Tx) error {
+			var err error
+			revision, err = s.RecordEnvRevision(tx, applicationID, variables, createdBy, restoredFrom)
+			return err
+		})
+		return revision, err
+	}
+
+	// Serialize revision numbering per application.
+	if _, err := db.NewSelect().
+		TableExpr("applications").
+		Column("id").
+		Where("id = ?", applicationID).
+		For("UPDATE").
+		Exec(s.Ctx); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.getLatestEnvRevision(db, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Variables == variables && restoredFrom == nil {
+		return latest, nil
+	}
+
+	next := 1
+	if latest != nil {
+		next = latest.Revision + 1
+	}
+
+	revision := &shared_types.ApplicationEnvRevision{
+		ID:            uuid.New(),
+		ApplicationID: applicationID,
+		Revision:      next,
+		Variables:     variables,
+		CreatedBy:     createdBy,
+		RestoredFrom:  restoredFrom,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := db.NewInsert().Model(revision).Exec(s.Ctx); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+func (s *DeployStorage) getLatestEnvRevision(db bun.IDB, applicationID uuid.UUID) (*shared_types.ApplicationEnvRevision, error) {
+	var revision shared_types.ApplicationEnvRevision
+	err := db.NewSelect().
+		Model(&revision).
+		Where("application_id = ?", applicationID).
+		Order("revision DESC").
+		Limit(1).
+		Scan(s.Ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetEnvRevisions returns all environment revisions of an application, newest first.
+func (s *DeployStorage) GetEnvRevisions(applicationID uuid.UUID) ([]shared_types.ApplicationEnvRevision, error) {
+	var revisions []shared_types.ApplicationEnvRevision
+	err := s.DB.NewSelect().
+		Model(&revisions).
+		Where("application_id = ?", applicationID).
+		Order("revision DESC").
+		Scan(s.Ctx)
+	return revisions, err
+}
+
+// GetEnvRevisionByID returns a single environment revision scoped to the application.
+func (s *DeployStorage) GetEnvRevisionByID(applicationID uuid.UUID, revisionID uuid.UUID) (*shared_types.ApplicationEnvRevision, error) {
+	var revision shared_types.ApplicationEnvRevision
+	err := s.DB.NewSelect().
+		Model(&revision).
+		Where("id = ? AND application_id = ?", revisionID, applicationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// UpdateApplicationEnvironmentVariables overwrites the environment variables column,
+// including when the new value is empty.
+func (s *DeployStorage) UpdateApplicationEnvironmentVariables(db bun.IDB, applicationID uuid.UUID, variables shared_types.EncryptedString) error {
+	if db == nil {
+		db = s.DB
+	}
+	_, err := db.NewUpdate().
+		Model((*shared_types.Application)(nil)).
+		Set("environment_variables = ?", variables).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", applicationID).
+		Exec(s.Ctx)
+	return err
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
 	sshstorage "github.com/nixopus/nixopus/api/internal/features/ssh/storage"
+	"github.com/nixopus/nixopus/api/internal/secrets"
 	shared_types "github.com/nixopus/nixopus/api/internal/types"
 )
 
@@ -25,8 +26,9 @@ type DeployStorage struct {
 type DeployRepository interface {
 	RunInTransaction(fn func(tx bun.Tx) error) error
 	IsNameAlreadyTaken(name string) (bool, error)
-	IsDomainAlreadyTaken(domain string) (bool, error)
-	IsPortAlreadyTaken(port int) (bool, error)
+	IsDomainAlreadyTaken(domain string, organizationID uuid.UUID) (bool, error)
+	GetDomainsByHost(host string, organizationID uuid.UUID) ([]shared_types.ApplicationDomain, error)
+	IsPortAlreadyTaken(port int, organizationID uuid.UUID, serverID *uuid.UUID) (bool, error)
 	IsDomainValid(domain string) (bool, error)
 	AddApplication(application *shared_types.Application) error
 	AddApplicationLogs(applicationLogs *shared_types.ApplicationLogs) error
@@ -47,6 +49,7 @@ type DeployRepository interface {
 	GetLogs(applicationID string, page, pageSize int, level string, startTime, endTime time.Time, searchTerm string) ([]shared_types.ApplicationLogs, int, error)
 	AddApplicationDomains(applicationID uuid.UUID, domains []string) error
 	RemoveApplicationDomain(applicationID uuid.UUID, domain string) error
+	SetApplicationDomainStripPathPrefix(applicationID uuid.UUID, domain string, strip bool) error
 	GetApplicationDomains(applicationID uuid.UUID) ([]shared_types.ApplicationDomain, error)
 	GetDeploymentLogs(deploymentID string, page, pageSize int, level string, startTime, endTime time.Time, searchTerm string) ([]shared_types.ApplicationLogs, int, error)
 	GetApplicationByRepositoryID(repositoryID uint64) (shared_types.Application, error)
@@ -71,6 +74,129 @@ type DeployRepository interface {
 	EnsureApplicationServers(appID uuid.UUID, orgID uuid.UUID) error
 	CopyApplicationServers(srcAppID, dstAppID uuid.UUID) error
 	DeleteApplicationDeploymentByID(id uuid.UUID) error
+	AssignInternalAlias(db bun.IDB, application *shared_types.Application) error
+	RecordEnvRevision(db bun.IDB, applicationID uuid.UUID, variables shared_types.EncryptedString, createdBy *uuid.UUID, restoredFrom *int) (*shared_types.ApplicationEnvRevision, error)
+	GetEnvRevisions(applicationID uuid.UUID) ([]shared_types.ApplicationEnvRevision, error)
+	GetEnvRevisionByID(applicationID uuid.UUID, revisionID uuid.UUID) (*shared_types.ApplicationEnvRevision, error)
+	UpdateApplicationEnvironmentVariables(db bun.IDB, applicationID uuid.UUID, variables shared_types.EncryptedString) error
+	CreateVariableGroup(group *shared_types.VariableGroup) error
+	UpdateVariableGroup(group *shared_types.VariableGroup) error
+	DeleteVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) error
+	GetVariableGroupByID(groupID uuid.UUID, organizationID uuid.UUID) (*shared_types.VariableGroup, error)
+	GetVariableGroups(organizationID uuid.UUID, familyID *uuid.UUID) ([]shared_types.VariableGroup, error)
+	IsVariableGroupNameTaken(organizationID uuid.UUID, familyID *uuid.UUID, name string, excludeID uuid.UUID) (bool, error)
+	GetApplicationVariableGroups(applicationID uuid.UUID) ([]shared_types.ApplicationVariableGroup, error)
+	SetApplicationVariableGroups(applicationID uuid.UUID, groupIDs []uuid.UUID) error
+	GetApplicationsByVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) ([]shared_types.Application, error)
+	GetOrganizationSecretManagers(organizationID uuid.UUID) ([]shared_types.OrganizationSecretManager, error)
+	GetOrganizationSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) (*shared_types.OrganizationSecretManager, error)
+	UpsertOrganizationSecretManager(manager *shared_types.OrganizationSecretManager) error
+	DeleteOrganizationSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) error
+	GetOrganizationDNSProviders(organizationID uuid.UUID) ([]shared_types.OrganizationDNSProvider, error)
+	GetOrganizationDNSProvider(organizationID uuid.UUID, id uuid.UUID) (*shared_types.OrganizationDNSProvider, error)
+	CreateOrganizationDNSProvider(provider *shared_types.OrganizationDNSProvider) error
+	UpdateOrganizationDNSProvider(provider *shared_types.OrganizationDNSProvider) error
+	DeleteOrganizationDNSProvider(organizationID uuid.UUID, id uuid.UUID) error
+	GetApplicationCertificates(applicationID uuid.UUID) ([]shared_types.ApplicationCertificate, error)
+	GetOrganizationCertificates(organizationID uuid.UUID) ([]shared_types.ApplicationCertificate, error)
+	UpsertApplicationCertificate(certificate *shared_types.ApplicationCertificate) error
+	DeleteApplicationCertificate(applicationID uuid.UUID, domain string) error
+	GetExpiringCertificates(now time.Time) ([]shared_types.ApplicationCertificate, error)
+	MarkCertificateExpiryWarned(id uuid.UUID, fingerprint string, at time.Time) (bool, error)
+	GetApplicationRedirects(applicationID uuid.UUID) ([]shared_types.ApplicationRedirect, error)
+	GetApplicationRedirect(organizationID uuid.UUID, id uuid.UUID) (*shared_types.ApplicationRedirect, error)
+	GetRedirectsBySourceDomain(domain string) ([]shared_types.ApplicationRedirect, error)
+	GetOrganizationRedirects(organizationID uuid.UUID) ([]shared_types.ApplicationRedirect, error)
+	CreateApplicationRedirect(redirect *shared_types.ApplicationRedirect) error
+	UpdateApplicationRedirect(redirect *shared_types.ApplicationRedirect) error
+	DeleteApplicationRedirect(organizationID uuid.UUID, id uuid.UUID) error
+	GetApplicationAccessPolicies(applicationID uuid.UUID) ([]shared_types.ApplicationAccessPolicy, error)
+	UpsertApplicationAccessPolicy(policy *shared_types.ApplicationAccessPolicy) error
+	DeleteApplicationAccessPolicy(applicationID uuid.UUID, domain string) error
+	GetOrganizationAccessPolicies(organizationID uuid.UUID) ([]shared_types.ApplicationAccessPolicy, error)
+	GetApplicationProxySettings(applicationID uuid.UUID) (*shared_types.ApplicationProxySettings, error)
+	UpsertApplicationProxySettings(settings *shared_types.ApplicationProxySettings) error
+	DeleteApplicationProxySettings(applicationID uuid.UUID) error
+	GetOrganizationProxySettings(organizationID uuid.UUID) ([]shared_types.ApplicationProxySettings, error)
+	RecordCaddyConfigSnapshot(snapshot *shared_types.CaddyConfigSnapshot, keep int) (*shared_types.CaddyConfigSnapshot, error)
+	GetCaddyConfigSnapshots(organizationID uuid.UUID) ([]shared_types.CaddyConfigSnapshot, error)
+	GetCaddyConfigSnapshotByID(organizationID uuid.UUID, snapshotID uuid.UUID) (*shared_types.CaddyConfigSnapshot, error)
+	GetApplicationPorts(applicationID uuid.UUID) ([]shared_types.ApplicationPort, error)
+	GetApplicationPort(applicationID uuid.UUID, portID uuid.UUID) (*shared_types.ApplicationPort, error)
+	AddApplicationPort(port *shared_types.ApplicationPort) error
+	DeleteApplicationPort(applicationID uuid.UUID, portID uuid.UUID) error
+	UpdateApplicationPortUpstream(portID uuid.UUID, serverID uuid.UUID, upstreamPort int) error
+	GetPublishedApplicationPorts(organizationID uuid.UUID, protocol shared_types.PortProtocol, publishedPort int) ([]shared_types.ApplicationPort, error)
+	GetProxiedApplicationPorts(organizationID uuid.UUID) ([]shared_types.ApplicationPort, error)
+	AddDeploymentPhase(phase *shared_types.DeploymentPhase) error
+	UpdateDeploymentPhase(phase *shared_types.DeploymentPhase) error
+	GetApplicationDeploymentPhases(applicationID uuid.UUID, deployments int) ([]shared_types.DeploymentPhase, error)
+	SaveDeploymentSBOM(sbom *shared_types.DeploymentSBOM, packages []shared_types.DeploymentPackage) error
+	GetDeploymentSBOM(deploymentID uuid.UUID) (*shared_types.DeploymentSBOM, error)
+	FindPackageDeployments(organizationID uuid.UUID, name string, version string) ([]types.PackageDeployment, error)
+	CreateApplicationMigration(migration *shared_types.ApplicationMigration) error
+	UpdateApplicationMigration(migration *shared_types.ApplicationMigration) error
+	GetApplicationMigration(id uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationMigration, error)
+	GetApplicationMigrations(applicationID uuid.UUID) ([]shared_types.ApplicationMigration, error)
+	CreateDatabase(database *shared_types.Database) error
+	UpdateDatabase(database *shared_types.Database) error
+	DeleteDatabase(databaseID uuid.UUID, organizationID uuid.UUID) error
+	GetDatabaseByID(databaseID uuid.UUID, organizationID uuid.UUID) (*shared_types.Database, error)
+	GetDatabases(organizationID uuid.UUID) ([]shared_types.Database, error)
+	IsDatabaseNameTaken(organizationID uuid.UUID, name string) (bool, error)
+	GetApplicationDatabases(applicationID uuid.UUID) ([]shared_types.ApplicationDatabase, error)
+	GetDatabaseAttachments(databaseID uuid.UUID) ([]shared_types.ApplicationDatabase, error)
+	GetApplicationsByDatabase(databaseID uuid.UUID, organizationID uuid.UUID) ([]shared_types.Application, error)
+	AttachDatabase(attachment *shared_types.ApplicationDatabase) error
+	DetachDatabase(applicationID uuid.UUID, databaseID uuid.UUID) (bool, error)
+	GetDefaultServerID(orgID uuid.UUID) (uuid.UUID, error)
+	GetActiveServerIDs(orgID uuid.UUID) ([]uuid.UUID, error)
+	GetScheduledBackupDatabases() ([]shared_types.Database, error)
+	CreateDatabaseBackup(backup *shared_types.DatabaseBackup) error
+	UpdateDatabaseBackup(backup *shared_types.DatabaseBackup) error
+	DeleteDatabaseBackup(backupID uuid.UUID) error
+	GetDatabaseBackup(backupID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseBackup, error)
+	GetDatabaseBackups(databaseID uuid.UUID) ([]shared_types.DatabaseBackup, error)
+	HasActiveDatabaseBackup(databaseID uuid.UUID) (bool, error)
+	CreateDatabaseRestore(restore *shared_types.DatabaseRestore) error
+	UpdateDatabaseRestore(restore *shared_types.DatabaseRestore) error
+	GetDatabaseRestore(restoreID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseRestore, error)
+	GetDatabaseRestores(databaseID uuid.UUID) ([]shared_types.DatabaseRestore, error)
+	CreateVolumeBackupPolicy(policy *shared_types.VolumeBackupPolicy) error
+	UpdateVolumeBackupPolicy(policy *shared_types.VolumeBackupPolicy) error
+	DeleteVolumeBackupPolicy(policyID uuid.UUID, organizationID uuid.UUID) error
+	GetVolumeBackupPolicy(policyID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackupPolicy, error)
+	GetVolumeBackupPolicies(applicationID uuid.UUID) ([]shared_types.VolumeBackupPolicy, error)
+	GetScheduledVolumeBackupPolicies() ([]shared_types.VolumeBackupPolicy, error)
+	IsVolumeBackupSourceTaken(applicationID uuid.UUID, sourceType shared_types.VolumeBackupSourceType, source string) (bool, error)
+	CreateVolumeBackup(backup *shared_types.VolumeBackup) error
+	UpdateVolumeBackup(backup *shared_types.VolumeBackup) error
+	DeleteVolumeBackup(backupID uuid.UUID) error
+	GetVolumeBackup(backupID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackup, error)
+	GetVolumeBackups(applicationID uuid.UUID) ([]shared_types.VolumeBackup, error)
+	GetPolicyVolumeBackups(policyID uuid.UUID) ([]shared_types.VolumeBackup, error)
+	HasActiveVolumeBackup(policyID uuid.UUID) (bool, error)
+	CreateVolumeRestore(restore *shared_types.VolumeRestore) error
+	UpdateVolumeRestore(restore *shared_types.VolumeRestore) error
+	GetVolumeRestore(restoreID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeRestore, error)
+	GetVolumeRestores(applicationID uuid.UUID) ([]shared_types.VolumeRestore, error)
+	GetApplicationScaleToZero(applicationID uuid.UUID) (*shared_types.ApplicationScaleToZero, error)
+	UpsertApplicationScaleToZero(state *shared_types.ApplicationScaleToZero) error
+	DeleteApplicationScaleToZero(applicationID uuid.UUID) error
+	GetScaleToZeroApplications() ([]shared_types.ApplicationScaleToZero, error)
+	GetSleepingApplicationIDs(organizationID uuid.UUID) (map[uuid.UUID]bool, error)
+	GetApplicationByDomain(domain string) (shared_types.Application, error)
+	GetApplicationMaintenance(applicationID uuid.UUID) ([]shared_types.ApplicationMaintenance, error)
+	UpsertApplicationMaintenance(maintenance *shared_types.ApplicationMaintenance) error
+	DeleteApplicationMaintenance(applicationID uuid.UUID, domain string) error
+	GetOrganizationMaintenance(organizationID uuid.UUID) ([]shared_types.ApplicationMaintenance, error)
+	GetAutoscalingPolicy(applicationID uuid.UUID) (*shared_types.AutoscalingPolicy, error)
+	UpsertAutoscalingPolicy(policy *shared_types.AutoscalingPolicy) error
+	SetAutoscalingLastScaledAt(applicationID uuid.UUID, scaledAt time.Time) error
+	DeleteAutoscalingPolicy(applicationID uuid.UUID) error
+	GetEnabledAutoscalingPolicies() ([]shared_types.AutoscalingPolicy, error)
+	CreateScalingEvent(event *shared_types.ApplicationScalingEvent) error
+	GetScalingEvents(applicationID uuid.UUID, limit int) ([]shared_types.ApplicationScalingEvent, error)
 }
 
 func (s *DeployStorage) RunInTransaction(fn func(tx bun.Tx) error) error {
@@ -105,31 +231,117 @@ func (s *DeployStorage) IsNameAlreadyTaken(name string) (bool, error) {
 	return count > 0, err
 }
 
-func (s *DeployStorage) IsDomainAlreadyTaken(domain string) (bool, error) {
+// IsDomainAlreadyTaken reports whether an application already routes
+// domain, which is a host or a host with a path prefix such as
+// example.com/api. Different path prefixes of one host do not conflict,
+// but only applications of the organization owning a host can share it.
+// A host used as the alias source domain of a redirect rule is taken too.
+func (s *DeployStorage) IsDomainAlreadyTaken(domain string, organizationID uuid.UUID) (bool, error) {
 	if domain == "" {
 		return false, nil
 	}
+	host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
 	var count int
 	err := s.DB.NewSelect().
-		TableExpr("application_domains").
+		TableExpr("application_domains AS ad").
+		Join("JOIN applications AS a ON a.id = ad.application_id").
 		ColumnExpr("count(*)").
-		Where("domain = ?", domain).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("ad.domain = ? AND ad.path_prefix = ?", host, pathPrefix).
+				WhereOr("LOWER(ad.domain) = LOWER(?) AND a.organization_id <> ?", host, organizationID)
+		}).
 		Scan(s.Ctx, &count)
 
 	if err != nil {
 		return false, err
 	}
+	if count > 0 {
+		return true, nil
+	}
+
+	// The same rule is enforced by triggers on both tables, see migration 218.
+	return s.DB.NewSelect().
+		TableExpr("application_redirects AS r").
+		Where("LOWER(r.source_domain) = LOWER(?)", host).
+		Where("NOT EXISTS (SELECT 1 FROM application_domains AS d WHERE d.application_id = r.application_id AND LOWER(d.domain) = LOWER(r.source_domain))").
+		Exists(s.Ctx)
+}
+
+// GetDomainsByHost returns every application domain of the organization
+// on host, whatever its path prefix.
+func (s *DeployStorage) GetDomainsByHost(host string, organizationID uuid.UUID) ([]shared_types.ApplicationDomain, error) {
+	var domains []shared_types.ApplicationDomain
+	err := s.DB.NewSelect().
+		Model(&domains).
+		Join("JOIN applications AS a ON a.id = ad.application_id").
+		Where("LOWER(ad.domain) = LOWER(?)", host).
+		Where("a.organization_id = ?", organizationID).
+		Order("ad.path_prefix ASC").
+		Scan(s.Ctx)
+	return domains, err
+}
 
-	return count > 0, nil
+// applicationOrganizationID returns the organization owning an application.
+func (s *DeployStorage) applicationOrganizationID(applicationID uuid.UUID) (uuid.UUID, error) {
+	var organizationID uuid.UUID
+	err := s.DB.NewSelect().
+		TableExpr("applications").
+		Column("organization_id").
+		Where("id = ?", applicationID).
+		Scan(s.Ctx, &organizationID)
+	return organizationID, err
 }
 
-func (s *DeployStorage) IsPortAlreadyTaken(port int) (bool, error) {
+// newApplicationDomain returns the application domain of route, a host
+// optionally followed by a path prefix.
+func newApplicationDomain(applicationID uuid.UUID, route string) (*shared_types.ApplicationDomain, error) {
+	host, pathPrefix, ok := shared_types.SplitDomainRoute(route)
+	if !ok {
+		return nil, fmt.Errorf("invalid path prefix in domain %s", route)
+	}
+	return &shared_types.ApplicationDomain{
+		ID:            uuid.New(),
+		ApplicationID: applicationID,
+		Domain:        host,
+		PathPrefix:    pathPrefix,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// IsPortAlreadyTaken reports whether an application listens on port or one
+// of the organization's applications publishes it as a TCP/UDP port. With
+// serverID only ports published on that server count, along with proxied
+// ports, which are routed by every server.
+func (s *DeployStorage) IsPortAlreadyTaken(port int, organizationID uuid.UUID, serverID *uuid.UUID) (bool, error) {
 	var count int
 	err := s.DB.NewSelect().
 		TableExpr("applications").
 		ColumnExpr("count(*)").
 		Where("port = ?", port).
 		Scan(s.Ctx, &count)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	q := s.DB.NewSelect().
+		TableExpr("application_ports AS apt").
+		Join("JOIN applications AS a ON a.id = apt.application_id").
+		ColumnExpr("count(*)").
+		Where("apt.published_port = ? AND a.organization_id = ?", port, organizationID)
+	if serverID != nil {
+		defaultServer, err := s.GetDefaultServerID(organizationID)
+		if err != nil {
+			return false, err
+		}
+		// Ports without a server are published on every server of their
+		// application, which is the default server when it has none.
+		q = q.Where(`apt.publish_mode = ? OR apt.server_id = ? OR (apt.server_id IS NULL AND (
+			EXISTS (SELECT 1 FROM application_servers AS aps WHERE aps.application_id = apt.application_id AND aps.server_id = ?)
+			OR (? AND NOT EXISTS (SELECT 1 FROM application_servers AS aps WHERE aps.application_id = apt.application_id))))`,
+			shared_types.PortPublishProxy, *serverID, *serverID, *serverID == defaultServer)
+	}
+	err = q.Scan(s.Ctx, &count)
 
 	return count > 0, err
 }
@@ -151,17 +363,22 @@ func (s *DeployStorage) IsDomainValid(domain string) (bool, error) {
 	if len(labels) < 2 {
 		return false, nil
 	}
-	for _, label := range labels {
+	for i, label := range labels {
+		// A leading "*" makes a wildcard subdomain such as
+		// *.preview.example.com; it cannot cover a whole TLD.
+		if i == 0 && label == "*" && len(labels) > 2 {
+			continue
+		}
 		if label == "" || len(label) > 63 {
 			return false, nil
 		}
-		for i, c := range label {
+		for j, c := range label {
 			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
 			isHyphen := c == '-'
 			if !isAlnum && !isHyphen {
 				return false, nil
 			}
-			if isHyphen && (i == 0 || i == len(label)-1) {
+			if isHyphen && (j == 0 || j == len(label)-1) {
 				return false, nil
 			}
 		}
@@ -333,6 +550,15 @@ func (s *DeployStorage) GetApplicationById(id string, organizationID uuid.UUID)
 		Model(&application).
 		Relation("Status").
 		Relation("Domains.ComposeService").
+		Relation("ComposeServices", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("cs.service_name ASC")
+		}).
+		Relation("VariableGroups", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("avg.priority ASC")
+		}).
+		Relation("Ports", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("apt.published_port ASC")
+		}).
 		Where("a.id = ? AND a.organization_id = ?", id, organizationID).
 		Scan(s.Ctx)
 
@@ -352,6 +578,9 @@ func (s *DeployStorage) GetApplicationDeploymentById(deploymentID string) (share
 	err := s.DB.NewSelect().
 		Model(&deployment).
 		Relation("Status").
+		Relation("Phases", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("adp.started_at ASC")
+		}).
 		Where("ad.id = ?", deploymentID).
 		Scan(s.Ctx)
 
@@ -403,6 +632,22 @@ func (s *DeployStorage) DeleteDeployment(deployment *types.DeleteDeploymentReque
 			return fmt.Errorf("failed to delete deployments: %w", err)
 		}
 
+		_, err = tx.NewDelete().
+			Table("application_variable_groups").
+			Where("application_id = ?", deployment.ID).
+			Exec(s.Ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete application variable groups: %w", err)
+		}
+
+		_, err = tx.NewDelete().
+			Table("application_env_revisions").
+			Where("application_id = ?", deployment.ID).
+			Exec(s.Ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete environment revisions: %w", err)
+		}
+
 		_, err = tx.NewDelete().
 			Table("application_domains").
 			Where("application_id = ?", deployment.ID).
@@ -717,13 +962,17 @@ func (s *DeployStorage) AddApplicationDomains(applicationID uuid.UUID, domains [
 	if len(domains) == 0 {
 		return nil
 	}
+	organizationID, err := s.applicationOrganizationID(applicationID)
+	if err != nil {
+		return err
+	}
 
 	// Check for duplicate domains globally
 	for _, domain := range domains {
 		if domain == "" {
 			continue
 		}
-		exists, err := s.IsDomainAlreadyTaken(domain)
+		exists, err := s.IsDomainAlreadyTaken(domain, organizationID)
 		if err != nil {
 			return err
 		}
@@ -737,13 +986,11 @@ func (s *DeployStorage) AddApplicationDomains(applicationID uuid.UUID, domains [
 		if domain == "" {
 			continue
 		}
-		appDomain := &shared_types.ApplicationDomain{
-			ID:            uuid.New(),
-			ApplicationID: applicationID,
-			Domain:        domain,
-			CreatedAt:     time.Now(),
+		appDomain, err := newApplicationDomain(applicationID, domain)
+		if err != nil {
+			return err
 		}
-		_, err := s.DB.NewInsert().Model(appDomain).Exec(s.Ctx)
+		_, err = s.DB.NewInsert().Model(appDomain).Exec(s.Ctx)
 		if err != nil {
 			return fmt.Errorf("failed to add domain %s: %w", domain, err)
 		}
@@ -752,11 +999,25 @@ func (s *DeployStorage) AddApplicationDomains(applicationID uuid.UUID, domains [
 	return nil
 }
 
-// RemoveApplicationDomain removes a domain from an application.
+// RemoveApplicationDomain removes a domain, or the mount of a host path
+// such as example.com/api, from an application.
 func (s *DeployStorage) RemoveApplicationDomain(applicationID uuid.UUID, domain string) error {
+	host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
 	_, err := s.DB.NewDelete().
 		Model((*shared_types.ApplicationDomain)(nil)).
-		Where("application_id = ? AND domain = ?", applicationID, domain).
+		Where("application_id = ? AND domain = ? AND path_prefix = ?", applicationID, host, pathPrefix).
+		Exec(s.Ctx)
+	return err
+}
+
+// SetApplicationDomainStripPathPrefix sets whether the path prefix of a
+// mounted domain is removed from requests.
+func (s *DeployStorage) SetApplicationDomainStripPathPrefix(applicationID uuid.UUID, domain string, strip bool) error {
+	host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
+	_, err := s.DB.NewUpdate().
+		Model((*shared_types.ApplicationDomain)(nil)).
+		Set("strip_path_prefix = ?", strip).
+		Where("application_id = ? AND LOWER(domain) = LOWER(?) AND path_prefix = ?", applicationID, host, pathPrefix).
 		Exec(s.Ctx)
 	return err
 }
@@ -922,11 +1183,12 @@ func (s *DeployStorage) GetComposeServiceByName(applicationID uuid.UUID, service
 
 // UpdateApplicationDomainService updates the compose service linkage and port override on an existing domain.
 func (s *DeployStorage) UpdateApplicationDomainService(applicationID uuid.UUID, domain string, composeServiceID *uuid.UUID, port *int) error {
+	host, pathPrefix, _ := shared_types.SplitDomainRoute(domain)
 	_, err := s.DB.NewUpdate().
 		Model((*shared_types.ApplicationDomain)(nil)).
 		Set("compose_service_id = ?", composeServiceID).
 		Set("port = ?", port).
-		Where("application_id = ? AND LOWER(domain) = LOWER(?)", applicationID, domain).
+		Where("application_id = ? AND LOWER(domain) = LOWER(?) AND path_prefix = ?", applicationID, host, pathPrefix).
 		Exec(s.Ctx)
 	return err
 }
@@ -937,7 +1199,11 @@ func (s *DeployStorage) AddApplicationDomainWithService(applicationID uuid.UUID,
 		return nil
 	}
 
-	exists, err := s.IsDomainAlreadyTaken(domain)
+	organizationID, err := s.applicationOrganizationID(applicationID)
+	if err != nil {
+		return err
+	}
+	exists, err := s.IsDomainAlreadyTaken(domain, organizationID)
 	if err != nil {
 		return err
 	}
@@ -945,14 +1211,12 @@ func (s *DeployStorage) AddApplicationDomainWithService(applicationID uuid.UUID,
 		return fmt.Errorf("domain %s is already taken", domain)
 	}
 
-	appDomain := &shared_types.ApplicationDomain{
-		ID:               uuid.New(),
-		ApplicationID:    applicationID,
-		Domain:           domain,
-		ComposeServiceID: composeServiceID,
-		Port:             port,
-		CreatedAt:        time.Now(),
+	appDomain, err := newApplicationDomain(applicationID, domain)
+	if err != nil {
+		return err
 	}
+	appDomain.ComposeServiceID = composeServiceID
+	appDomain.Port = port
 	_, err = s.DB.NewInsert().Model(appDomain).Exec(s.Ctx)
 	if err != nil {
 		return fmt.Errorf("failed to add domain %s: %w", domain, err)
@@ -1015,6 +1279,32 @@ func (s *DeployStorage) SetApplicationServers(appID uuid.UUID, serverIDs []uuid.
 	})
 }
 
+// GetDefaultServerID returns the ID of the organization's default server.
+func (s *DeployStorage) GetDefaultServerID(orgID uuid.UUID) (uuid.UUID, error) {
+	sshStorage := sshstorage.SSHKeyStorage{DB: s.DB, Ctx: s.Ctx}
+	key, err := sshStorage.GetDefaultSSHKeyByOrganizationID(orgID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("cannot find default server for org %s: %w", orgID, err)
+	}
+	return key.ID, nil
+}
+
+// GetActiveServerIDs returns the IDs of the organization's active servers.
+func (s *DeployStorage) GetActiveServerIDs(orgID uuid.UUID) ([]uuid.UUID, error) {
+	sshStorage := sshstorage.SSHKeyStorage{DB: s.DB, Ctx: s.Ctx}
+	keys, err := sshStorage.ListSSHKeysByOrganizationID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uuid.UUID
+	for _, key := range keys {
+		if key.IsActive {
+			ids = append(ids, key.ID)
+		}
+	}
+	return ids, nil
+}
+
 // EnsureApplicationServers inserts the org's default server as primary if no application_servers rows exist yet.
 func (s *DeployStorage) EnsureApplicationServers(appID uuid.UUID, orgID uuid.UUID) error {
 	count, err := s.DB.NewSelect().
@@ -1028,16 +1318,15 @@ func (s *DeployStorage) EnsureApplicationServers(appID uuid.UUID, orgID uuid.UUI
 		return nil
 	}
 
-	sshStorage := sshstorage.SSHKeyStorage{DB: s.DB, Ctx: s.Ctx}
-	key, err := sshStorage.GetDefaultSSHKeyByOrganizationID(orgID)
+	serverID, err := s.GetDefaultServerID(orgID)
 	if err != nil {
-		return fmt.Errorf("cannot find default server for org %s: %w", orgID, err)
+		return err
 	}
 
 	row := shared_types.ApplicationServer{
 		ID:            uuid.New(),
 		ApplicationID: appID,
-		ServerID:      key.ID,
+		ServerID:      serverID,
 		IsPrimary:     true,
 		CreatedAt:     time.Now(),
 	}
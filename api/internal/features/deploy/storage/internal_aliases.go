@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"slices"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/uptrace/bun"
+)
+
+// privateNetworkOwnerSQL is the ID the private network of an application
+// row belongs to, matching Application.PrivateNetworkOwner.
+const privateNetworkOwnerSQL = "(CASE WHEN a.private_network_scope = 'family' AND a.family_id IS NOT NULL THEN a.family_id ELSE a.organization_id END)"
+
+// AssignInternalAlias sets the internal alias of application to the first
+// of its current alias, or the one from its name, and that alias with a
+// numeric suffix that no other application on the same private network
+// uses. Docker DNS would otherwise spread traffic over both. Pass the
+// transaction that writes application as db; nil reads through the store.
+func (s *DeployStorage) AssignInternalAlias(db bun.IDB, application *shared_types.Application) error {
+	if db == nil {
+		db = s.DB
+	}
+	base := application.InternalAlias
+	if base == "" {
+		base = shared_types.InternalAliasFromName(application.Name)
+	}
+	if base == "" {
+		base = application.PrivateAlias()
+	}
+
+	var taken []string
+	err := db.NewSelect().
+		Model((*shared_types.Application)(nil)).
+		Column("a.internal_alias").
+		Where(privateNetworkOwnerSQL+" = ?", application.PrivateNetworkOwner()).
+		Where("a.id <> ?", application.ID).
+		Scan(s.Ctx, &taken)
+	if err != nil {
+		return err
+	}
+
+	alias := base
+	for n := 2; slices.Contains(taken, alias); n++ {
+		alias = shared_types.InternalAliasWithSuffix(base, n)
+	}
+	application.InternalAlias = alias
+	return nil
+}
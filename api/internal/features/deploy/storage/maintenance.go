@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationMaintenance returns the application wide and per domain
+// maintenance settings of an application.
+func (s *DeployStorage) GetApplicationMaintenance(applicationID uuid.UUID) ([]shared_types.ApplicationMaintenance, error) {
+	var settings []shared_types.ApplicationMaintenance
+	err := s.DB.NewSelect().
+		Model(&settings).
+		Where("amt.application_id = ?", applicationID).
+		Order("amt.domain ASC").
+		Scan(s.Ctx)
+	return settings, err
+}
+
+// UpsertApplicationMaintenance inserts or overwrites the maintenance setting
+// of an application or one of its domains and sets its UpdatedAt.
+func (s *DeployStorage) UpsertApplicationMaintenance(maintenance *shared_types.ApplicationMaintenance) error {
+	maintenance.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(maintenance).
+		On("CONFLICT (application_id, domain) DO UPDATE").
+		Set("enabled = EXCLUDED.enabled").
+		Set("html_body = EXCLUDED.html_body").
+		Set("retry_after_seconds = EXCLUDED.retry_after_seconds").
+		Set("allowed_ips = EXCLUDED.allowed_ips").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteApplicationMaintenance removes the maintenance setting of an
+// application, or of one of its domains when domain is set.
+func (s *DeployStorage) DeleteApplicationMaintenance(applicationID uuid.UUID, domain string) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationMaintenance)(nil)).
+		Where("application_id = ? AND domain = ?", applicationID, domain).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetOrganizationMaintenance returns the maintenance settings of every
+// application of an organization.
+func (s *DeployStorage) GetOrganizationMaintenance(organizationID uuid.UUID) ([]shared_types.ApplicationMaintenance, error) {
+	var settings []shared_types.ApplicationMaintenance
+	err := s.DB.NewSelect().
+		Model(&settings).
+		Where("amt.organization_id = ?", organizationID).
+		Scan(s.Ctx)
+	return settings, err
+}
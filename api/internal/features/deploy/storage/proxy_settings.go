@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationProxySettings returns the proxy settings of an application.
+// It returns sql.ErrNoRows when the application has none.
+func (s *DeployStorage) GetApplicationProxySettings(applicationID uuid.UUID) (*shared_types.ApplicationProxySettings, error) {
+	settings := &shared_types.ApplicationProxySettings{}
+	err := s.DB.NewSelect().
+		Model(settings).
+		Where("aps.application_id = ?", applicationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpsertApplicationProxySettings inserts or overwrites the proxy settings
+// of an application and sets their UpdatedAt.
+func (s *DeployStorage) UpsertApplicationProxySettings(settings *shared_types.ApplicationProxySettings) error {
+	settings.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(settings).
+		On("CONFLICT (application_id) DO UPDATE").
+		Set("response_headers = EXCLUDED.response_headers").
+		Set("remove_response_headers = EXCLUDED.remove_response_headers").
+		Set("encodings = EXCLUDED.encodings").
+		Set("max_request_body_bytes = EXCLUDED.max_request_body_bytes").
+		Set("dial_timeout_seconds = EXCLUDED.dial_timeout_seconds").
+		Set("response_timeout_seconds = EXCLUDED.response_timeout_seconds").
+		Set("rate_limit_requests = EXCLUDED.rate_limit_requests").
+		Set("rate_limit_window_seconds = EXCLUDED.rate_limit_window_seconds").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteApplicationProxySettings removes the proxy settings of an
+// application.
+func (s *DeployStorage) DeleteApplicationProxySettings(applicationID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationProxySettings)(nil)).
+		Where("application_id = ?", applicationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetOrganizationProxySettings returns the proxy settings of every
+// application of an organization.
+func (s *DeployStorage) GetOrganizationProxySettings(organizationID uuid.UUID) ([]shared_types.ApplicationProxySettings, error) {
+	var settings []shared_types.ApplicationProxySettings
+	err := s.DB.NewSelect().
+		Model(&settings).
+		Where("aps.organization_id = ?", organizationID).
+		Scan(s.Ctx)
+	return settings, err
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// redirectAliasConstraint is raised by the trigger that keeps an alias
+// source domain to a single application.
+const redirectAliasConstraint = "application_redirects_alias_domain"
+
+// GetApplicationRedirects returns the redirect rules of an application.
+func (s *DeployStorage) GetApplicationRedirects(applicationID uuid.UUID) ([]shared_types.ApplicationRedirect, error) {
+	var redirects []shared_types.ApplicationRedirect
+	err := s.DB.NewSelect().
+		Model(&redirects).
+		Where("ardr.application_id = ?", applicationID).
+		Order("ardr.source_domain ASC", "ardr.source_path ASC").
+		Scan(s.Ctx)
+	return redirects, err
+}
+
+// GetApplicationRedirect returns a redirect rule of an organization.
+func (s *DeployStorage) GetApplicationRedirect(organizationID uuid.UUID, id uuid.UUID) (*shared_types.ApplicationRedirect, error) {
+	var redirect shared_types.ApplicationRedirect
+	err := s.DB.NewSelect().
+		Model(&redirect).
+		Where("ardr.organization_id = ? AND ardr.id = ?", organizationID, id).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+// GetRedirectsBySourceDomain returns the redirect rules of every
+// organization whose source domain is domain.
+func (s *DeployStorage) GetRedirectsBySourceDomain(domain string) ([]shared_types.ApplicationRedirect, error) {
+	var redirects []shared_types.ApplicationRedirect
+	err := s.DB.NewSelect().
+		Model(&redirects).
+		Where("ardr.source_domain = ?", domain).
+		Scan(s.Ctx)
+	return redirects, err
+}
+
+// GetOrganizationRedirects returns the redirect rules of every application
+// of an organization.
+func (s *DeployStorage) GetOrganizationRedirects(organizationID uuid.UUID) ([]shared_types.ApplicationRedirect, error) {
+	var redirects []shared_types.ApplicationRedirect
+	err := s.DB.NewSelect().
+		Model(&redirects).
+		Where("ardr.organization_id = ?", organizationID).
+		Scan(s.Ctx)
+	return redirects, err
+}
+
+// CreateApplicationRedirect stores a new redirect rule.
+func (s *DeployStorage) CreateApplicationRedirect(redirect *shared_types.ApplicationRedirect) error {
+	_, err := s.DB.NewInsert().Model(redirect).Exec(s.Ctx)
+	return redirectError(err)
+}
+
+// UpdateApplicationRedirect replaces the source, target and options of a
+// redirect rule.
+func (s *DeployStorage) UpdateApplicationRedirect(redirect *shared_types.ApplicationRedirect) error {
+	_, err := s.DB.NewUpdate().
+		Model(redirect).
+		Column("source_domain", "source_path", "target_domain", "target_path", "status_code", "preserve_path", "updated_at").
+		Where("organization_id = ? AND id = ?", redirect.OrganizationID, redirect.ID).
+		Exec(s.Ctx)
+	return redirectError(err)
+}
+
+// redirectError reports a rejected alias source domain as
+// ErrRedirectDomainTaken.
+func redirectError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == redirectAliasConstraint {
+		return types.ErrRedirectDomainTaken
+	}
+	return err
+}
+
+// DeleteApplicationRedirect removes a redirect rule of an organization.
+func (s *DeployStorage) DeleteApplicationRedirect(organizationID uuid.UUID, id uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationRedirect)(nil)).
+		Where("organization_id = ? AND id = ?", organizationID, id).
+		Exec(s.Ctx)
+	return err
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetApplicationScaleToZero returns the scale-to-zero state of an application.
+func (s *DeployStorage) GetApplicationScaleToZero(applicationID uuid.UUID) (*shared_types.ApplicationScaleToZero, error) {
+	var state shared_types.ApplicationScaleToZero
+	err := s.DB.NewSelect().
+		Model(&state).
+		Where("astz.application_id = ?", applicationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// UpsertApplicationScaleToZero inserts or overwrites the scale-to-zero state
+// of an application and sets its UpdatedAt.
+func (s *DeployStorage) UpsertApplicationScaleToZero(state *shared_types.ApplicationScaleToZero) error {
+	state.UpdatedAt = time.Now()
+	_, err := s.DB.NewInsert().
+		Model(state).
+		On("CONFLICT (application_id) DO UPDATE").
+		Set("idle_timeout_minutes = EXCLUDED.idle_timeout_minutes").
+		Set("sleeping = EXCLUDED.sleeping").
+		Set("sleep_replicas = EXCLUDED.sleep_replicas").
+		Set("upstream_dial = EXCLUDED.upstream_dial").
+		Set("request_count = EXCLUDED.request_count").
+		Set("last_request_at = EXCLUDED.last_request_at").
+		Set("slept_at = EXCLUDED.slept_at").
+		Set("woken_at = EXCLUDED.woken_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteApplicationScaleToZero removes the scale-to-zero state of an application.
+func (s *DeployStorage) DeleteApplicationScaleToZero(applicationID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.ApplicationScaleToZero)(nil)).
+		Where("application_id = ?", applicationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetScaleToZeroApplications returns the scale-to-zero state of every
+// application with an idle timeout, across all organizations.
+func (s *DeployStorage) GetScaleToZeroApplications() ([]shared_types.ApplicationScaleToZero, error) {
+	var states []shared_types.ApplicationScaleToZero
+	err := s.DB.NewSelect().
+		Model(&states).
+		Where("astz.idle_timeout_minutes > 0").
+		Scan(s.Ctx)
+	return states, err
+}
+
+// GetSleepingApplicationIDs returns the applications of an organization that
+// are currently scaled to zero.
+func (s *DeployStorage) GetSleepingApplicationIDs(organizationID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var ids []uuid.UUID
+	err := s.DB.NewSelect().
+		Model((*shared_types.ApplicationScaleToZero)(nil)).
+		Column("application_id").
+		Where("organization_id = ? AND sleeping = true", organizationID).
+		Scan(s.Ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	sleeping := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		sleeping[id] = true
+	}
+	return sleeping, nil
+}
+
+// GetApplicationByDomain returns the application that serves the whole of
+// a domain, not one of its path prefixes.
+func (s *DeployStorage) GetApplicationByDomain(domain string) (shared_types.Application, error) {
+	var application shared_types.Application
+	err := s.DB.NewSelect().
+		Model(&application).
+		Join("JOIN application_domains AS ad ON ad.application_id = a.id").
+		Where("LOWER(ad.domain) = LOWER(?) AND ad.path_prefix = ''", domain).
+		Limit(1).
+		Scan(s.Ctx)
+	return application, err
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// GetOrganizationSecretManagers returns the secret manager connections of an organization.
+func (s *DeployStorage) GetOrganizationSecretManagers(organizationID uuid.UUID) ([]shared_types.OrganizationSecretManager, error) {
+	var managers []shared_types.OrganizationSecretManager
+	err := s.DB.NewSelect().
+		Model(&managers).
+		Where("organization_id = ?", organizationID).
+		Order("type ASC").
+		Scan(s.Ctx)
+	return managers, err
+}
+
+// GetOrganizationSecretManager returns the organization's connection for a provider.
+func (s *DeployStorage) GetOrganizationSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) (*shared_types.OrganizationSecretManager, error) {
+	var manager shared_types.OrganizationSecretManager
+	err := s.DB.NewSelect().
+		Model(&manager).
+		Where("organization_id = ? AND type = ?", organizationID, managerType).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &manager, nil
+}
+
+// UpsertOrganizationSecretManager creates or replaces the organization's connection for a provider.
+func (s *DeployStorage) UpsertOrganizationSecretManager(manager *shared_types.OrganizationSecretManager) error {
+	_, err := s.DB.NewInsert().
+		Model(manager).
+		On("CONFLICT (organization_id, type) DO UPDATE").
+		Set("url = EXCLUDED.url").
+		Set("project_id = EXCLUDED.project_id").
+		Set("environment = EXCLUDED.environment").
+		Set("mount = EXCLUDED.mount").
+		Set("namespace = EXCLUDED.namespace").
+		Set("token = EXCLUDED.token").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteOrganizationSecretManager removes the organization's connection for a provider.
+func (s *DeployStorage) DeleteOrganizationSecretManager(organizationID uuid.UUID, managerType secrets.SecretManagerType) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.OrganizationSecretManager)(nil)).
+		Where("organization_id = ? AND type = ?", organizationID, managerType).
+		Exec(s.Ctx)
+	return err
+}
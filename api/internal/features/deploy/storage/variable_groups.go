@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// CreateVariableGroup inserts a new variable group.
+func (s *DeployStorage) CreateVariableGroup(group *shared_types.VariableGroup) error {
+	_, err := s.DB.NewInsert().Model(group).Exec(s.Ctx)
+	return err
+}
+
+// UpdateVariableGroup overwrites the editable fields of a variable group,
+// including empty values.
+func (s *DeployStorage) UpdateVariableGroup(group *shared_types.VariableGroup) error {
+	_, err := s.DB.NewUpdate().
+		Model(group).
+		Column("name", "description", "variables", "updated_at").
+		Where("id = ? AND organization_id = ?", group.ID, group.OrganizationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteVariableGroup removes a variable group and detaches it from all applications.
+func (s *DeployStorage) DeleteVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) error {
+	return s.RunInTransaction(func(tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*shared_types.ApplicationVariableGroup)(nil)).
+			Where("variable_group_id = ?", groupID).
+			Exec(s.Ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewDelete().
+			Model((*shared_types.VariableGroup)(nil)).
+			Where("id = ? AND organization_id = ?", groupID, organizationID).
+			Exec(s.Ctx)
+		return err
+	})
+}
+
+// GetVariableGroupByID returns a variable group scoped to the organization.
+func (s *DeployStorage) GetVariableGroupByID(groupID uuid.UUID, organizationID uuid.UUID) (*shared_types.VariableGroup, error) {
+	var group shared_types.VariableGroup
+	err := s.DB.NewSelect().
+		Model(&group).
+		Where("id = ? AND organization_id = ?", groupID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetVariableGroups lists the variable groups of an organization. When familyID is
+// set only organization-wide groups and groups of that family are returned.
+func (s *DeployStorage) GetVariableGroups(organizationID uuid.UUID, familyID *uuid.UUID) ([]shared_types.VariableGroup, error) {
+	var groups []shared_types.VariableGroup
+	q := s.DB.NewSelect().
+		Model(&groups).
+		Where("organization_id = ?", organizationID)
+	if familyID != nil {
+		q = q.Where("family_id IS NULL OR family_id = ?", *familyID)
+	}
+	err := q.Order("name ASC").Scan(s.Ctx)
+	return groups, err
+}
+
+// IsVariableGroupNameTaken reports whether another group in the same scope already uses name.
+func (s *DeployStorage) IsVariableGroupNameTaken(organizationID uuid.UUID, familyID *uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	q := s.DB.NewSelect().
+		Model((*shared_types.VariableGroup)(nil)).
+		Where("organization_id = ?", organizationID).
+		Where("name = ?", name).
+		Where("id != ?", excludeID)
+	if familyID != nil {
+		q = q.Where("family_id = ?", *familyID)
+	} else {
+		q = q.Where("family_id IS NULL")
+	}
+	count, err := q.Count(s.Ctx)
+	return count > 0, err
+}
+
+// GetApplicationVariableGroups returns the groups attached to an application with
+// their variables, ordered from lowest to highest precedence.
+func (s *DeployStorage) GetApplicationVariableGroups(applicationID uuid.UUID) ([]shared_types.ApplicationVariableGroup, error) {
+	var attachments []shared_types.ApplicationVariableGroup
+	err := s.DB.NewSelect().
+		Model(&attachments).
+		Relation("VariableGroup").
+		Where("avg.application_id = ?", applicationID).
+		Order("avg.priority ASC").
+		Scan(s.Ctx)
+	return attachments, err
+}
+
+// SetApplicationVariableGroups replaces the groups attached to an application.
+// Later entries in groupIDs take precedence over earlier ones.
+func (s *DeployStorage) SetApplicationVariableGroups(applicationID uuid.UUID, groupIDs []uuid.UUID) error {
+	return s.RunInTransaction(func(tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*shared_types.ApplicationVariableGroup)(nil)).
+			Where("application_id = ?", applicationID).
+			Exec(s.Ctx); err != nil {
+			return err
+		}
+		now := time.Now()
+		for i, groupID := range groupIDs {
+			attachment := shared_types.ApplicationVariableGroup{
+				ID:              uuid.New(),
+				ApplicationID:   applicationID,
+				VariableGroupID: groupID,
+				Priority:        i,
+				CreatedAt:       now,
+			}
+			if _, err := tx.NewInsert().Model(&attachment).Exec(s.Ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetApplicationsByVariableGroup returns the applications that have the group attached.
+func (s *DeployStorage) GetApplicationsByVariableGroup(groupID uuid.UUID, organizationID uuid.UUID) ([]shared_types.Application, error) {
+	var applications []shared_types.Application
+	err := s.DB.NewSelect().
+		Model(&applications).
+		Relation("Status").
+		Join("JOIN application_variable_groups AS avg ON avg.application_id = a.id").
+		Where("avg.variable_group_id = ?", groupID).
+		Where("a.organization_id = ?", organizationID).
+		Order("a.name ASC").
+		Scan(s.Ctx)
+	return applications, err
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// CreateVolumeBackupPolicy inserts a new volume backup policy.
+func (s *DeployStorage) CreateVolumeBackupPolicy(policy *shared_types.VolumeBackupPolicy) error {
+	_, err := s.DB.NewInsert().Model(policy).Exec(s.Ctx)
+	return err
+}
+
+// UpdateVolumeBackupPolicy overwrites a volume backup policy and sets its UpdatedAt.
+func (s *DeployStorage) UpdateVolumeBackupPolicy(policy *shared_types.VolumeBackupPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := s.DB.NewUpdate().
+		Model(policy).
+		Where("id = ? AND organization_id = ?", policy.ID, policy.OrganizationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteVolumeBackupPolicy removes a volume backup policy. Its backups and
+// restores are removed by the foreign keys.
+func (s *DeployStorage) DeleteVolumeBackupPolicy(policyID uuid.UUID, organizationID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.VolumeBackupPolicy)(nil)).
+		Where("id = ? AND organization_id = ?", policyID, organizationID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetVolumeBackupPolicy returns a volume backup policy scoped to the organization.
+func (s *DeployStorage) GetVolumeBackupPolicy(policyID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackupPolicy, error) {
+	var policy shared_types.VolumeBackupPolicy
+	err := s.DB.NewSelect().
+		Model(&policy).
+		Where("id = ? AND organization_id = ?", policyID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetVolumeBackupPolicies lists the volume backup policies of an application.
+func (s *DeployStorage) GetVolumeBackupPolicies(applicationID uuid.UUID) ([]shared_types.VolumeBackupPolicy, error) {
+	var policies []shared_types.VolumeBackupPolicy
+	err := s.DB.NewSelect().
+		Model(&policies).
+		Where("application_id = ?", applicationID).
+		Order("created_at ASC").
+		Scan(s.Ctx)
+	return policies, err
+}
+
+// GetScheduledVolumeBackupPolicies returns the policies of all organizations
+// that have a backup schedule.
+func (s *DeployStorage) GetScheduledVolumeBackupPolicies() ([]shared_types.VolumeBackupPolicy, error) {
+	var policies []shared_types.VolumeBackupPolicy
+	err := s.DB.NewSelect().
+		Model(&policies).
+		Where("schedule != ''").
+		Scan(s.Ctx)
+	return policies, err
+}
+
+// IsVolumeBackupSourceTaken reports whether the application already has a
+// policy for the source.
+func (s *DeployStorage) IsVolumeBackupSourceTaken(applicationID uuid.UUID, sourceType shared_types.VolumeBackupSourceType, source string) (bool, error) {
+	return s.DB.NewSelect().
+		Model((*shared_types.VolumeBackupPolicy)(nil)).
+		Where("application_id = ?", applicationID).
+		Where("source_type = ? AND source = ?", sourceType, source).
+		Exists(s.Ctx)
+}
+
+// CreateVolumeBackup inserts a new volume backup.
+func (s *DeployStorage) CreateVolumeBackup(backup *shared_types.VolumeBackup) error {
+	_, err := s.DB.NewInsert().Model(backup).Exec(s.Ctx)
+	return err
+}
+
+// UpdateVolumeBackup overwrites a volume backup.
+func (s *DeployStorage) UpdateVolumeBackup(backup *shared_types.VolumeBackup) error {
+	_, err := s.DB.NewUpdate().
+		Model(backup).
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+// DeleteVolumeBackup removes a volume backup record. The archive in S3 is
+// removed by the caller.
+func (s *DeployStorage) DeleteVolumeBackup(backupID uuid.UUID) error {
+	_, err := s.DB.NewDelete().
+		Model((*shared_types.VolumeBackup)(nil)).
+		Where("id = ?", backupID).
+		Exec(s.Ctx)
+	return err
+}
+
+// GetVolumeBackup returns a volume backup scoped to the organization.
+func (s *DeployStorage) GetVolumeBackup(backupID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackup, error) {
+	var backup shared_types.VolumeBackup
+	err := s.DB.NewSelect().
+		Model(&backup).
+		Where("id = ? AND organization_id = ?", backupID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// GetVolumeBackups lists the volume backups of an application, newest first.
+func (s *DeployStorage) GetVolumeBackups(applicationID uuid.UUID) ([]shared_types.VolumeBackup, error) {
+	var backups []shared_types.VolumeBackup
+	err := s.DB.NewSelect().
+		Model(&backups).
+		Where("application_id = ?", applicationID).
+		Order("created_at DESC").
+		Scan(s.Ctx)
+	return backups, err
+}
+
+// GetPolicyVolumeBackups lists the backups of a policy, newest first.
+func (s *DeployStorage) GetPolicyVolumeBackups(policyID uuid.UUID) ([]shared_types.VolumeBackup, error) {
+	var backups []shared_types.VolumeBackup
+	err := s.DB.NewSelect().
+		Model(&backups).
+		Where("policy_id = ?", policyID).
+		Order("created_at DESC").
+		Scan(s.Ctx)
+	return backups, err
+}
+
+// HasActiveVolumeBackup reports whether a backup of the policy is pending or
+// running.
+func (s *DeployStorage) HasActiveVolumeBackup(policyID uuid.UUID) (bool, error) {
+	return s.DB.NewSelect().
+		Model((*shared_types.VolumeBackup)(nil)).
+		Where("policy_id = ?", policyID).
+		Where("status IN (?, ?)", shared_types.BackupPending, shared_types.BackupRunning).
+		Exists(s.Ctx)
+}
+
+// CreateVolumeRestore inserts a new volume restore.
+func (s *DeployStorage) CreateVolumeRestore(restore *shared_types.VolumeRestore) error {
+	_, err := s.DB.NewInsert().Model(restore).Exec(s.Ctx)
+	return err
+}
+
+// UpdateVolumeRestore overwrites a volume restore.
+func (s *DeployStorage) UpdateVolumeRestore(restore *shared_types.VolumeRestore) error {
+	_, err := s.DB.NewUpdate().
+		Model(restore).
+		WherePK().
+		Exec(s.Ctx)
+	return err
+}
+
+// GetVolumeRestore returns a volume restore scoped to the organization.
+func (s *DeployStorage) GetVolumeRestore(restoreID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeRestore, error) {
+	var restore shared_types.VolumeRestore
+	err := s.DB.NewSelect().
+		Model(&restore).
+		Where("id = ? AND organization_id = ?", restoreID, organizationID).
+		Scan(s.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &restore, nil
+}
+
+// GetVolumeRestores lists the volume restores of an application, newest first.
+func (s *DeployStorage) GetVolumeRestores(applicationID uuid.UUID) ([]shared_types.VolumeRestore, error) {
+	var restores []shared_types.VolumeRestore
+	err := s.DB.NewSelect().
+		Model(&restores).
+		Where("application_id = ?", applicationID).
+		Order("created_at DESC").
+		Scan(s.Ctx)
+	return restores, err
+}
@@ -0,0 +1,158 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/http/httpguts"
+)
+
+// UpdateAccessPolicy saves the access policy of an application or one of
+// its domains and puts it in front of the affected Caddy routes.
+func (t *TaskService) UpdateAccessPolicy(ctx context.Context, req *types.UpdateAccessPolicyRequest, organizationID uuid.UUID) (*shared_types.ApplicationAccessPolicy, error) {
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	domain, err := maintenanceDomain(&app, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	current, err := t.findAccessPolicy(app.ID, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	policy := &shared_types.ApplicationAccessPolicy{
+		ID:             uuid.New(),
+		ApplicationID:  app.ID,
+		OrganizationID: organizationID,
+		Domain:         domain,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := BuildAccessPolicy(policy, req, current); err != nil {
+		return nil, err
+	}
+	if err := t.Storage.UpsertApplicationAccessPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := t.reconcileRoutes(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	policy.FillBasicAuthUsernames()
+	return policy, nil
+}
+
+// DeleteAccessPolicy removes the access policy of an application or of one
+// of its domains. A domain then follows the application policy again.
+func (t *TaskService) DeleteAccessPolicy(ctx context.Context, applicationID uuid.UUID, domain string, organizationID uuid.UUID) error {
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	domain, err = maintenanceDomain(&app, domain)
+	if err != nil {
+		return err
+	}
+	current, err := t.findAccessPolicy(app.ID, domain)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return types.ErrAccessPolicyNotFound
+	}
+
+	if err := t.Storage.DeleteApplicationAccessPolicy(app.ID, domain); err != nil {
+		return err
+	}
+	return t.reconcileRoutes(ctx, organizationID)
+}
+
+// findAccessPolicy returns the stored policy of an application domain, or
+// nil when it has none.
+func (t *TaskService) findAccessPolicy(applicationID uuid.UUID, domain string) (*shared_types.ApplicationAccessPolicy, error) {
+	policies, err := t.Storage.GetApplicationAccessPolicies(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].Domain == domain {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// BuildAccessPolicy validates req and fills the checks of policy from it.
+// Passwords are hashed with bcrypt; a user sent without one keeps its hash
+// from current, which may be nil.
+func BuildAccessPolicy(policy *shared_types.ApplicationAccessPolicy, req *types.UpdateAccessPolicyRequest, current *shared_types.ApplicationAccessPolicy) error {
+	var err error
+	if policy.AllowedIPs, err = parseIPRanges(req.AllowedIPs, types.ErrInvalidAccessIP); err != nil {
+		return err
+	}
+	if policy.DeniedIPs, err = parseIPRanges(req.DeniedIPs, types.ErrInvalidAccessIP); err != nil {
+		return err
+	}
+
+	hashes := make(map[string]string)
+	if current != nil {
+		for _, user := range current.BasicAuthUsers {
+			hashes[user.Username] = user.PasswordHash
+		}
+	}
+	policy.BasicAuthUsers = []shared_types.BasicAuthUser{}
+	seen := make(map[string]bool, len(req.BasicAuthUsers))
+	for _, user := range req.BasicAuthUsers {
+		username := strings.TrimSpace(user.Username)
+		if username == "" || strings.Contains(username, ":") || seen[username] {
+			return types.ErrInvalidBasicAuthUser
+		}
+		seen[username] = true
+
+		hash, ok := hashes[username]
+		if user.Password != "" {
+			generated, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return types.ErrInvalidBasicAuthUser
+			}
+			hash, ok = string(generated), true
+		}
+		if !ok {
+			return types.ErrInvalidBasicAuthUser
+		}
+		policy.BasicAuthUsers = append(policy.BasicAuthUsers, shared_types.BasicAuthUser{Username: username, PasswordHash: hash})
+	}
+	policy.BasicAuthRealm = strings.TrimSpace(req.BasicAuthRealm)
+	if strings.ContainsFunc(policy.BasicAuthRealm, func(r rune) bool { return r == '"' || r == '\\' || r < ' ' || r == 0x7f }) {
+		return types.ErrInvalidBasicAuthRealm
+	}
+
+	policy.ForwardAuthURL = strings.TrimSpace(req.ForwardAuthURL)
+	policy.ForwardAuthCopyHeaders = []string{}
+	if policy.ForwardAuthURL != "" {
+		for _, name := range req.ForwardAuthCopyHeaders {
+			if name = strings.TrimSpace(name); name != "" && !httpguts.ValidHeaderFieldName(name) {
+				return types.ErrInvalidForwardAuthHeader
+			}
+		}
+		forward, err := caddy.ParseForwardAuthURL(policy.ForwardAuthURL, req.ForwardAuthCopyHeaders)
+		if err != nil {
+			return types.ErrInvalidForwardAuthURL
+		}
+		policy.ForwardAuthCopyHeaders = forward.CopyHeaders
+	}
+
+	if len(policy.AllowedIPs) == 0 && len(policy.DeniedIPs) == 0 && len(policy.BasicAuthUsers) == 0 && policy.ForwardAuthURL == "" {
+		return types.ErrEmptyAccessPolicy
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBuildAccessPolicy(t *testing.T) {
+	current := &shared_types.ApplicationAccessPolicy{
+		BasicAuthUsers: []shared_types.BasicAuthUser{{Username: "admin", PasswordHash: "stored-hash"}},
+	}
+	req := &types.UpdateAccessPolicyRequest{
+		AllowedIPs:             []string{" 10.1.2.3/8 ", "10.0.0.0/8"},
+		DeniedIPs:              []string{"2001:db8::1"},
+		BasicAuthUsers:         []types.BasicAuthUserRequest{{Username: "admin"}, {Username: "ops", Password: "s3cret"}},
+		ForwardAuthURL:         "http://auth:9091/verify",
+		ForwardAuthCopyHeaders: []string{"remote-user"},
+	}
+	policy := &shared_types.ApplicationAccessPolicy{}
+	if err := BuildAccessPolicy(policy, req, current); err != nil {
+		t.Fatalf("BuildAccessPolicy: %v", err)
+	}
+	if !slices.Equal(policy.AllowedIPs, []string{"10.0.0.0/8"}) || !slices.Equal(policy.DeniedIPs, []string{"2001:db8::1"}) {
+		t.Errorf("ip lists = %v %v", policy.AllowedIPs, policy.DeniedIPs)
+	}
+	if len(policy.BasicAuthUsers) != 2 || policy.BasicAuthUsers[0].PasswordHash != "stored-hash" {
+		t.Fatalf("users = %+v", policy.BasicAuthUsers)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(policy.BasicAuthUsers[1].PasswordHash), []byte("s3cret")); err != nil {
+		t.Errorf("password was not hashed: %v", err)
+	}
+	if !slices.Equal(policy.ForwardAuthCopyHeaders, []string{"Remote-User"}) {
+		t.Errorf("copy headers = %v", policy.ForwardAuthCopyHeaders)
+	}
+
+	tests := []struct {
+		name string
+		req  types.UpdateAccessPolicyRequest
+		want error
+	}{
+		{name: "nothing checked", want: types.ErrEmptyAccessPolicy},
+		{name: "bad ip", req: types.UpdateAccessPolicyRequest{DeniedIPs: []string{"10.0.0.300"}}, want: types.ErrInvalidAccessIP},
+		{name: "new user without password", req: types.UpdateAccessPolicyRequest{BasicAuthUsers: []types.BasicAuthUserRequest{{Username: "dev"}}}, want: types.ErrInvalidBasicAuthUser},
+		{name: "username with colon", req: types.UpdateAccessPolicyRequest{BasicAuthUsers: []types.BasicAuthUserRequest{{Username: "a:b", Password: "x"}}}, want: types.ErrInvalidBasicAuthUser},
+		{name: "quoted realm", req: types.UpdateAccessPolicyRequest{AllowedIPs: []string{"10.0.0.1"}, BasicAuthRealm: `a"b`}, want: types.ErrInvalidBasicAuthRealm},
+		{name: "bad forward auth url", req: types.UpdateAccessPolicyRequest{ForwardAuthURL: "auth:9091"}, want: types.ErrInvalidForwardAuthURL},
+		{name: "bad copy header", req: types.UpdateAccessPolicyRequest{ForwardAuthURL: "http://auth", ForwardAuthCopyHeaders: []string{"Bad Header"}}, want: types.ErrInvalidForwardAuthHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := BuildAccessPolicy(&shared_types.ApplicationAccessPolicy{}, &tt.req, nil); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
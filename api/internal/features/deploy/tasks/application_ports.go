@@ -0,0 +1,260 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/pkg/sftp"
+	"gopkg.in/yaml.v3"
+)
+
+// applicationPorts returns the TCP/UDP ports to publish on the server of the
+// current context: ports without a server and ports pinned to it.
+func (t *TaskService) applicationPorts(ctx context.Context, app *shared_types.Application) ([]shared_types.ApplicationPort, error) {
+	ports, err := t.Storage.GetApplicationPorts(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	serverID, _ := ctx.Value(shared_types.ServerIDKey).(string)
+	return portsForServer(ports, serverID), nil
+}
+
+// deployServerID returns the server a deployment running with ctx targets:
+// the server of a fan-out, or the organization's default server.
+func (t *TaskService) deployServerID(ctx context.Context, organizationID uuid.UUID) (uuid.UUID, error) {
+	if serverID, _ := ctx.Value(shared_types.ServerIDKey).(string); serverID != "" {
+		return uuid.Parse(serverID)
+	}
+	return t.Storage.GetDefaultServerID(organizationID)
+}
+
+func portsForServer(ports []shared_types.ApplicationPort, serverID string) []shared_types.ApplicationPort {
+	var out []shared_types.ApplicationPort
+	for _, p := range ports {
+		if p.ServerID == nil || p.ServerID.String() == serverID {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// swarmPortConfigs converts the ports of a service to Swarm port configs.
+// Proxied ports are published on a port chosen by Swarm, which Caddy dials.
+func swarmPortConfigs(ports []shared_types.ApplicationPort, serviceName string) []swarm.PortConfig {
+	var configs []swarm.PortConfig
+	for _, p := range ports {
+		if p.ServiceName != serviceName {
+			continue
+		}
+		config := swarm.PortConfig{
+			Protocol:      swarm.PortConfigProtocol(p.Protocol),
+			TargetPort:    uint32(p.TargetPort),
+			PublishedPort: uint32(p.PublishedPort),
+			PublishMode:   swarm.PortConfigPublishModeIngress,
+		}
+		switch p.PublishMode {
+		case shared_types.PortPublishHost:
+			config.PublishMode = swarm.PortConfigPublishModeHost
+		case shared_types.PortPublishProxy:
+			config.PublishedPort = 0
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+type composePortsFile struct {
+	Services map[string]composeServicePorts `yaml:"services"`
+}
+
+type composeServicePorts struct {
+	Ports []composePortMapping `yaml:"ports"`
+}
+
+type composePortMapping struct {
+	Target    int    `yaml:"target"`
+	Published string `yaml:"published,omitempty"`
+	Protocol  string `yaml:"protocol"`
+	Mode      string `yaml:"mode"`
+}
+
+// composePortsOverride returns a compose file that publishes ports on their
+// services. Proxied ports get no published port so Docker picks one.
+func composePortsOverride(ports []shared_types.ApplicationPort) ([]byte, error) {
+	override := composePortsFile{Services: make(map[string]composeServicePorts)}
+	for _, p := range ports {
+		mapping := composePortMapping{
+			Target:   p.TargetPort,
+			Protocol: string(p.Protocol),
+			Mode:     string(shared_types.PortPublishIngress),
+		}
+		switch p.PublishMode {
+		case shared_types.PortPublishHost:
+			mapping.Mode = string(shared_types.PortPublishHost)
+			mapping.Published = strconv.Itoa(p.PublishedPort)
+		case shared_types.PortPublishIngress:
+			mapping.Published = strconv.Itoa(p.PublishedPort)
+		}
+		svc := override.Services[p.ServiceName]
+		svc.Ports = append(svc.Ports, mapping)
+		override.Services[p.ServiceName] = svc
+	}
+
+	data, err := yaml.Marshal(override)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write ports override: %w", err)
+	}
+	return data, nil
+}
+
+// attachComposePorts writes a compose override that publishes the
+// application's TCP/UDP ports and appends it to the project files.
+func (t *TaskService) attachComposePorts(ctx context.Context, app *shared_types.Application, project *docker.ComposeProject) error {
+	ports, err := t.applicationPorts(ctx, app)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+
+	override, err := composePortsOverride(ports)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(project.Dir(), ".nixopus-ports.yml")
+	err = utils.WithSFTPClientFromPool(ctx, func(client *sftp.Client) error {
+		return writeRemoteFile(client, path, override)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write ports override: %w", err)
+	}
+	project.Files = append(project.Files, path)
+	return nil
+}
+
+// swarmUpstreamPort returns the port Swarm published for a proxied port.
+func swarmUpstreamPort(endpoint []swarm.PortConfig, p shared_types.ApplicationPort) int {
+	for _, pc := range endpoint {
+		if pc.TargetPort == uint32(p.TargetPort) && string(pc.Protocol) == string(p.Protocol) &&
+			pc.PublishMode == swarm.PortConfigPublishModeIngress && pc.PublishedPort > 0 {
+			return int(pc.PublishedPort)
+		}
+	}
+	return 0
+}
+
+// composeUpstreamPort returns the host port Docker published for a proxied
+// port of a compose service.
+func composeUpstreamPort(containers []container.Summary, p shared_types.ApplicationPort) int {
+	for _, c := range containers {
+		if c.Labels[docker.ComposeServiceLabel] != p.ServiceName {
+			continue
+		}
+		for _, cp := range c.Ports {
+			if int(cp.PrivatePort) == p.TargetPort && cp.Type == string(p.Protocol) && cp.PublicPort > 0 {
+				return int(cp.PublicPort)
+			}
+		}
+	}
+	return 0
+}
+
+// recordProxyUpstreams stores the host port every proxied port was published
+// on, on the server of ctx, and asks the reconciler to update the Caddy
+// layer4 routes.
+func (t *TaskService) recordProxyUpstreams(ctx context.Context, app *shared_types.Application, lookup func(shared_types.ApplicationPort) int, taskCtx *TaskContext) {
+	ports, err := t.applicationPorts(ctx, app)
+	if err != nil {
+		taskCtx.AddLog("Warning: failed to load application ports: " + err.Error())
+		return
+	}
+	serverID, err := t.deployServerID(ctx, app.OrganizationID)
+	if err != nil {
+		taskCtx.AddLog("Warning: failed to resolve the deployment server: " + err.Error())
+		return
+	}
+
+	proxied := false
+	for _, p := range ports {
+		if p.PublishMode != shared_types.PortPublishProxy {
+			continue
+		}
+		proxied = true
+		upstream := lookup(p)
+		if upstream == 0 {
+			taskCtx.AddLog(fmt.Sprintf("Warning: port %d/%s was not published, it will not be proxied", p.TargetPort, p.Protocol))
+			continue
+		}
+		if upstream == p.UpstreamPortOn(serverID) {
+			continue
+		}
+		if err := t.Storage.UpdateApplicationPortUpstream(p.ID, serverID, upstream); err != nil {
+			taskCtx.AddLog("Warning: failed to record proxied port: " + err.Error())
+		}
+	}
+
+	if proxied {
+		if err := caddy.EnqueueReconcile(app.OrganizationID); err != nil {
+			t.Logger.Log(logger.Warning, "failed to enqueue caddy reconcile for proxied ports", err.Error())
+		}
+	}
+}
+
+// recordComposeProxyUpstreams records the proxied ports of a compose
+// application after it was deployed in compose or stack mode.
+func (t *TaskService) recordComposeProxyUpstreams(ctx context.Context, app *shared_types.Application, project docker.ComposeProject, taskCtx *TaskContext) {
+	ports, err := t.applicationPorts(ctx, app)
+	if err != nil {
+		taskCtx.AddLog("Warning: failed to load application ports: " + err.Error())
+		return
+	}
+	if !slices.ContainsFunc(ports, func(p shared_types.ApplicationPort) bool {
+		return p.PublishMode == shared_types.PortPublishProxy
+	}) {
+		return
+	}
+
+	dockerSvc, err := t.getStackDockerService(ctx)
+	if err != nil {
+		taskCtx.AddLog("Warning: failed to get docker service: " + err.Error())
+		return
+	}
+
+	if app.IsComposeStack() {
+		services, err := dockerSvc.ListStackServices(app.ComposeStackName())
+		if err != nil {
+			taskCtx.AddLog("Warning: failed to list stack services: " + err.Error())
+			return
+		}
+		t.recordProxyUpstreams(ctx, app, func(p shared_types.ApplicationPort) int {
+			for _, svc := range services {
+				if svc.Spec.Name == app.StackServiceName(p.ServiceName) {
+					return swarmUpstreamPort(svc.Endpoint.Ports, p)
+				}
+			}
+			return 0
+		}, taskCtx)
+		return
+	}
+
+	containers, err := dockerSvc.ListComposeContainers(project.Dir())
+	if err != nil {
+		taskCtx.AddLog("Warning: failed to list compose containers: " + err.Error())
+		return
+	}
+	t.recordProxyUpstreams(ctx, app, func(p shared_types.ApplicationPort) int {
+		return composeUpstreamPort(containers, p)
+	}, taskCtx)
+}
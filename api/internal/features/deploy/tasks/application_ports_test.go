@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/google/uuid"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPortsForServer(t *testing.T) {
+	serverA := uuid.New()
+	serverB := uuid.New()
+	ports := []shared_types.ApplicationPort{
+		{PublishedPort: 5432},
+		{PublishedPort: 6379, ServerID: &serverA},
+		{PublishedPort: 53, ServerID: &serverB},
+	}
+
+	got := portsForServer(ports, serverA.String())
+	if len(got) != 2 || got[0].PublishedPort != 5432 || got[1].PublishedPort != 6379 {
+		t.Errorf("ports for server A = %+v", got)
+	}
+	if got := portsForServer(ports, ""); len(got) != 1 {
+		t.Errorf("ports without a server in context = %+v, want only unpinned ports", got)
+	}
+}
+
+func TestSwarmPortConfigs(t *testing.T) {
+	ports := []shared_types.ApplicationPort{
+		{Protocol: shared_types.PortProtocolTCP, TargetPort: 5432, PublishedPort: 5432, PublishMode: shared_types.PortPublishIngress},
+		{Protocol: shared_types.PortProtocolUDP, TargetPort: 53, PublishedPort: 1053, PublishMode: shared_types.PortPublishHost},
+		{Protocol: shared_types.PortProtocolTCP, TargetPort: 6379, PublishedPort: 6379, PublishMode: shared_types.PortPublishProxy},
+		{ServiceName: "db", Protocol: shared_types.PortProtocolTCP, TargetPort: 3306, PublishedPort: 3306},
+	}
+
+	configs := swarmPortConfigs(ports, "")
+	want := []swarm.PortConfig{
+		{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 5432, PublishedPort: 5432, PublishMode: swarm.PortConfigPublishModeIngress},
+		{Protocol: swarm.PortConfigProtocolUDP, TargetPort: 53, PublishedPort: 1053, PublishMode: swarm.PortConfigPublishModeHost},
+		{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 6379, PublishedPort: 0, PublishMode: swarm.PortConfigPublishModeIngress},
+	}
+	if len(configs) != len(want) {
+		t.Fatalf("got %d port configs, want %d", len(configs), len(want))
+	}
+	for i := range want {
+		if configs[i] != want[i] {
+			t.Errorf("port config %d = %+v, want %+v", i, configs[i], want[i])
+		}
+	}
+}
+
+func TestComposePortsOverride(t *testing.T) {
+	data, err := composePortsOverride([]shared_types.ApplicationPort{
+		{ServiceName: "db", Protocol: shared_types.PortProtocolTCP, TargetPort: 5432, PublishedPort: 15432, PublishMode: shared_types.PortPublishHost},
+		{ServiceName: "dns", Protocol: shared_types.PortProtocolUDP, TargetPort: 53, PublishedPort: 1053, PublishMode: shared_types.PortPublishProxy},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got composePortsFile
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	db := got.Services["db"].Ports
+	if len(db) != 1 || db[0].Published != "15432" || db[0].Mode != "host" || db[0].Target != 5432 {
+		t.Errorf("db ports = %+v", db)
+	}
+	dns := got.Services["dns"].Ports
+	if len(dns) != 1 || dns[0].Published != "" || dns[0].Protocol != "udp" {
+		t.Errorf("proxied dns port should leave the host port to Docker: %+v", dns)
+	}
+}
+
+func TestUpstreamPorts(t *testing.T) {
+	port := shared_types.ApplicationPort{ServiceName: "db", Protocol: shared_types.PortProtocolTCP, TargetPort: 5432}
+
+	endpoint := []swarm.PortConfig{
+		{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 5432, PublishedPort: 5432, PublishMode: swarm.PortConfigPublishModeHost},
+		{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 5432, PublishedPort: 30001, PublishMode: swarm.PortConfigPublishModeIngress},
+	}
+	if got := swarmUpstreamPort(endpoint, port); got != 30001 {
+		t.Errorf("swarm upstream port = %d, want 30001", got)
+	}
+
+	containers := []container.Summary{
+		{Labels: map[string]string{"com.docker.compose.service": "web"}, Ports: []container.Port{{PrivatePort: 5432, PublicPort: 40000, Type: "tcp"}}},
+		{Labels: map[string]string{"com.docker.compose.service": "db"}, Ports: []container.Port{
+			{PrivatePort: 5432, PublicPort: 40001, Type: "udp"},
+			{PrivatePort: 5432, PublicPort: 40002, Type: "tcp"},
+		}},
+	}
+	if got := composeUpstreamPort(containers, port); got != 40002 {
+		t.Errorf("compose upstream port = %d, want 40002", got)
+	}
+}
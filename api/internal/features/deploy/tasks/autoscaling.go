@@ -0,0 +1,370 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/queue"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+const (
+	// autoscaleInterval is how often every enabled policy is evaluated.
+	autoscaleInterval = 30 * time.Second
+	// autoscaleTolerance ignores usage within 10% of the target, so small
+	// fluctuations do not change the replica count.
+	autoscaleTolerance = 0.1
+	// autoscaleLockTTL bounds how long a crashed instance can hold the
+	// scaler of an application.
+	autoscaleLockTTL    = 2 * time.Minute
+	autoscaleLockPrefix = "autoscale:lock:"
+)
+
+// releaseLockScript deletes a lock only if it still holds the caller's
+// token, so an expired lock taken over by another instance is left alone.
+const releaseLockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// AutoscaleTaskPayload identifies the application whose autoscaling policy
+// a queued evaluation runs.
+type AutoscaleTaskPayload struct {
+	ApplicationID  string `json:"application_id"`
+	OrganizationID string `json:"organization_id"`
+}
+
+// StartAutoscaler queues an evaluation of every enabled autoscaling policy
+// every autoscaleInterval. Each API instance runs the loop; the job names
+// are deduplicated so each policy is evaluated once per interval.
+func (t *TaskService) StartAutoscaler(ctx context.Context) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			timer := time.NewTimer(now.Truncate(autoscaleInterval).Add(autoscaleInterval).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case tick := <-timer.C:
+				t.enqueueAutoscaling(tick.UTC())
+			}
+		}
+	}()
+}
+
+func (t *TaskService) enqueueAutoscaling(now time.Time) {
+	if AutoscaleQueue == nil || TaskAutoscale == nil {
+		return
+	}
+	policies, err := t.Storage.GetEnabledAutoscalingPolicies()
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to load autoscaling policies", err.Error())
+		return
+	}
+	slot := now.Truncate(autoscaleInterval)
+	for _, policy := range policies {
+		msg := TaskAutoscale.WithArgs(context.Background(), AutoscaleTaskPayload{
+			ApplicationID:  policy.ApplicationID.String(),
+			OrganizationID: policy.OrganizationID.String(),
+		})
+		msg.Name = fmt.Sprintf("autoscale-%s-%d", policy.ApplicationID, slot.Unix())
+		if err := AutoscaleQueue.Add(msg); err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+			t.Logger.Log(logger.Error, "failed to queue autoscaling", err.Error())
+		}
+	}
+}
+
+// HandleAutoscale evaluates the autoscaling policy of an application and
+// scales its service when the average usage is off target. Only one
+// instance evaluates an application at a time.
+//
+// Usage is sampled from the containers of the server's own Docker daemon,
+// so autoscaling supports single-node swarms only. Enabling a policy on a
+// larger swarm is rejected, and applications whose swarm grew since are
+// left alone.
+func (t *TaskService) HandleAutoscale(ctx context.Context, payload AutoscaleTaskPayload) error {
+	applicationID, err := uuid.Parse(payload.ApplicationID)
+	if err != nil {
+		return fmt.Errorf("invalid application id: %w", err)
+	}
+	organizationID, err := uuid.Parse(payload.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("invalid organization id: %w", err)
+	}
+
+	release, acquired, err := acquireAutoscaleLock(ctx, applicationID)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+	defer release()
+
+	policy, err := t.Storage.GetAutoscalingPolicy(applicationID)
+	if err != nil || !policy.Enabled {
+		return nil
+	}
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return nil
+	}
+	if app.BuildPack == shared_types.DockerCompose || app.IsComposeStack() {
+		return nil
+	}
+	// Applications scaled to zero are woken by requests, not by usage.
+	if state, err := t.Storage.GetApplicationScaleToZero(app.ID); err == nil && state.Sleeping {
+		return nil
+	}
+
+	dockerSvc, svc, err := t.applicationService(ctx, &app)
+	if err != nil {
+		return err
+	}
+	if svc == nil {
+		return nil
+	}
+	nodes, err := dockerSvc.GetClusterNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list swarm nodes: %w", err)
+	}
+	if activeNodes(nodes) > 1 {
+		t.Logger.Log(logger.Warning, "skipping autoscaling, the swarm has more than one active node", app.ID.String())
+		return nil
+	}
+	current := serviceReplicas(svc)
+	if current == 0 {
+		return nil
+	}
+
+	observed, samples, err := serviceUsage(dockerSvc, svc, policy.Metric)
+	if err != nil {
+		return err
+	}
+	if samples == 0 {
+		return nil
+	}
+
+	desired, reason := autoscaleDecision(policy, current, observed)
+	if desired == current {
+		return nil
+	}
+	inBounds := current >= uint64(policy.MinReplicas) && current <= uint64(policy.MaxReplicas)
+	if inBounds && policy.LastScaledAt != nil &&
+		time.Since(*policy.LastScaledAt) < time.Duration(policy.CooldownSeconds)*time.Second {
+		return nil
+	}
+
+	event := &shared_types.ApplicationScalingEvent{
+		ID:              uuid.New(),
+		ApplicationID:   app.ID,
+		OrganizationID:  app.OrganizationID,
+		FromReplicas:    int(current),
+		ToReplicas:      int(desired),
+		Metric:          policy.Metric,
+		ObservedPercent: math.Round(observed*10) / 10,
+		TargetPercent:   policy.TargetPercent,
+		Reason:          reason,
+		CreatedAt:       time.Now(),
+	}
+	scaleErr := dockerSvc.ScaleService(svc.ID, desired, "")
+	if scaleErr != nil {
+		event.Error = scaleErr.Error()
+	}
+	if err := t.Storage.CreateScalingEvent(event); err != nil {
+		t.Logger.Log(logger.Error, "failed to record scaling event", err.Error())
+	}
+	if scaleErr != nil {
+		return fmt.Errorf("failed to scale service: %w", scaleErr)
+	}
+
+	if err := t.Storage.SetAutoscalingLastScaledAt(app.ID, time.Now()); err != nil {
+		t.Logger.Log(logger.Error, "failed to record last scale time", err.Error())
+	}
+	t.Logger.Log(logger.Info, fmt.Sprintf("scaled %s from %d to %d replicas: %s", app.Name, current, desired, reason), app.ID.String())
+	return nil
+}
+
+// CheckAutoscalingSupported rejects enabling a policy for an application
+// whose swarm has more than one active node, where HandleAutoscale cannot
+// sample the usage of every replica.
+func (t *TaskService) CheckAutoscalingSupported(ctx context.Context, req *types.UpdateAutoscalingPolicyRequest, organizationID uuid.UUID) error {
+	if !req.Enabled {
+		return nil
+	}
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	serverID, err := t.primaryApplicationServer(&app)
+	if err != nil {
+		return err
+	}
+	dockerSvc, err := t.getDockerService(serverContext(ctx, organizationID, serverID))
+	if err != nil {
+		return err
+	}
+	nodes, err := dockerSvc.GetClusterNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list swarm nodes: %w", err)
+	}
+	if activeNodes(nodes) > 1 {
+		return types.ErrAutoscalingMultiNode
+	}
+	return nil
+}
+
+// autoscaleDecision returns the replica count policy wants for a service
+// running current replicas at observed percent usage, and why. Replicas
+// outside the policy bounds are brought back within them first.
+func autoscaleDecision(policy *shared_types.AutoscalingPolicy, current uint64, observed float64) (uint64, string) {
+	minReplicas, maxReplicas := uint64(policy.MinReplicas), uint64(policy.MaxReplicas)
+	if current < minReplicas {
+		return minReplicas, fmt.Sprintf("replicas below the minimum of %d", minReplicas)
+	}
+	if current > maxReplicas {
+		return maxReplicas, fmt.Sprintf("replicas above the maximum of %d", maxReplicas)
+	}
+
+	ratio := observed / float64(policy.TargetPercent)
+	if math.Abs(ratio-1) <= autoscaleTolerance {
+		return current, ""
+	}
+	desired := uint64(math.Ceil(float64(current) * ratio))
+	desired = max(min(desired, maxReplicas), minReplicas)
+	if desired == current {
+		return current, ""
+	}
+
+	direction := "above"
+	if ratio < 1 {
+		direction = "below"
+	}
+	return desired, fmt.Sprintf("average %s usage %.1f%% is %s the target of %d%%", policy.Metric, observed, direction, policy.TargetPercent)
+}
+
+// activeNodes counts the ready swarm nodes tasks can be scheduled on.
+func activeNodes(nodes []swarm.Node) int {
+	active := 0
+	for _, node := range nodes {
+		if node.Status.State == swarm.NodeStateReady && node.Spec.Availability == swarm.NodeAvailabilityActive {
+			active++
+		}
+	}
+	return active
+}
+
+// serviceUsage returns the average usage of metric, in percent, across the
+// running containers of svc that the Docker daemon of dockerSvc sees, and
+// how many containers were sampled.
+func serviceUsage(dockerSvc docker.DockerRepository, svc *swarm.Service, metric shared_types.AutoscalingMetric) (float64, int, error) {
+	containers, err := dockerSvc.ListContainers(container.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", "com.docker.swarm.service.name="+svc.Spec.Name),
+			filters.Arg("status", "running"),
+		),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var cpuLimit int64
+	if resources := svc.Spec.TaskTemplate.Resources; resources != nil && resources.Limits != nil {
+		cpuLimit = resources.Limits.NanoCPUs
+	}
+
+	var total float64
+	samples := 0
+	for _, c := range containers {
+		stats, err := dockerSvc.GetContainerStats(c.ID)
+		if err != nil {
+			continue
+		}
+		var percent float64
+		var ok bool
+		if metric == shared_types.AutoscalingMetricMemory {
+			percent, ok = memoryPercent(stats)
+		} else {
+			percent, ok = cpuPercent(stats, cpuLimit)
+		}
+		if ok {
+			total += percent
+			samples++
+		}
+	}
+	if samples == 0 {
+		return 0, 0, nil
+	}
+	return total / float64(samples), samples, nil
+}
+
+// cpuPercent returns the CPU usage of a container as a percentage of its
+// CPU limit, or of one CPU when it has none.
+func cpuPercent(stats container.StatsResponse, limitNanoCPUs int64) (float64, bool) {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta < 0 || systemDelta <= 0 {
+		return 0, false
+	}
+	online := float64(stats.CPUStats.OnlineCPUs)
+	if online == 0 {
+		online = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if online == 0 {
+		online = 1
+	}
+	cores := cpuDelta / systemDelta * online
+	capacity := 1.0
+	if limitNanoCPUs > 0 {
+		capacity = float64(limitNanoCPUs) / 1e9
+	}
+	return cores / capacity * 100, true
+}
+
+// memoryPercent returns the memory usage of a container, without its page
+// cache, as a percentage of its memory limit.
+func memoryPercent(stats container.StatsResponse) (float64, bool) {
+	mem := stats.MemoryStats
+	if mem.Limit == 0 {
+		return 0, false
+	}
+	used := mem.Usage
+	// cgroup v2 reports inactive_file, cgroup v1 total_inactive_file.
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if cache, ok := mem.Stats[key]; ok && cache < used {
+			used -= cache
+			break
+		}
+	}
+	return float64(used) / float64(mem.Limit) * 100, true
+}
+
+// acquireAutoscaleLock takes the Redis lock that makes one API instance the
+// active scaler of an application. Without Redis every call acquires it.
+func acquireAutoscaleLock(ctx context.Context, applicationID uuid.UUID) (func(), bool, error) {
+	rc := queue.RedisClient()
+	if rc == nil {
+		return func() {}, true, nil
+	}
+	key := autoscaleLockPrefix + applicationID.String()
+	token := uuid.NewString()
+	acquired, err := rc.SetNX(ctx, key, token, autoscaleLockTTL).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to take autoscale lock: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+	return func() {
+		rc.Eval(context.Background(), releaseLockScript, []string{key}, token)
+	}, true, nil
+}
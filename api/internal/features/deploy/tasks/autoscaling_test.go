@@ -0,0 +1,92 @@
+package tasks
+
+import (
+	"math"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestAutoscaleDecision(t *testing.T) {
+	policy := &shared_types.AutoscalingPolicy{
+		MinReplicas:   2,
+		MaxReplicas:   6,
+		Metric:        shared_types.AutoscalingMetricCPU,
+		TargetPercent: 50,
+	}
+	tests := []struct {
+		name     string
+		current  uint64
+		observed float64
+		want     uint64
+	}{
+		{"below minimum", 1, 10, 2},
+		{"above maximum", 9, 90, 6},
+		{"within tolerance", 3, 54, 3},
+		{"scale up", 3, 80, 5},
+		{"scale up capped", 4, 200, 6},
+		{"scale down", 4, 20, 2},
+		{"scale down floored", 3, 1, 2},
+	}
+	for _, tt := range tests {
+		got, reason := autoscaleDecision(policy, tt.current, tt.observed)
+		if got != tt.want {
+			t.Errorf("%s: got %d replicas, want %d", tt.name, got, tt.want)
+		}
+		if (got != tt.current) != (reason != "") {
+			t.Errorf("%s: unexpected reason %q", tt.name, reason)
+		}
+	}
+}
+
+func TestContainerUsage(t *testing.T) {
+	var stats container.StatsResponse
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1_000
+	stats.CPUStats.CPUUsage.TotalUsage = 3_000
+	stats.PreCPUStats.SystemUsage = 10_000
+	stats.CPUStats.SystemUsage = 18_000
+	stats.CPUStats.OnlineCPUs = 4
+
+	// 2000/8000 of 4 CPUs is one CPU.
+	if got, ok := cpuPercent(stats, 0); !ok || math.Abs(got-100) > 0.001 {
+		t.Errorf("cpu without limit = %v, %v; want 100", got, ok)
+	}
+	if got, ok := cpuPercent(stats, 2_000_000_000); !ok || math.Abs(got-50) > 0.001 {
+		t.Errorf("cpu with 2 CPU limit = %v, %v; want 50", got, ok)
+	}
+
+	stats.MemoryStats.Usage = 600
+	stats.MemoryStats.Limit = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 100}
+	if got, ok := memoryPercent(stats); !ok || math.Abs(got-50) > 0.001 {
+		t.Errorf("memory = %v, %v; want 50", got, ok)
+	}
+
+	stats.MemoryStats.Limit = 0
+	if _, ok := memoryPercent(stats); ok {
+		t.Error("memory without limit should not be sampled")
+	}
+}
+
+func TestActiveNodes(t *testing.T) {
+	node := func(state swarm.NodeState, availability swarm.NodeAvailability) swarm.Node {
+		return swarm.Node{
+			Spec:   swarm.NodeSpec{Availability: availability},
+			Status: swarm.NodeStatus{State: state},
+		}
+	}
+	nodes := []swarm.Node{
+		node(swarm.NodeStateReady, swarm.NodeAvailabilityActive),
+		node(swarm.NodeStateDown, swarm.NodeAvailabilityActive),
+		node(swarm.NodeStateReady, swarm.NodeAvailabilityDrain),
+	}
+	if got := activeNodes(nodes); got != 1 {
+		t.Errorf("activeNodes = %d, want 1", got)
+	}
+	nodes = append(nodes, node(swarm.NodeStateReady, swarm.NodeAvailabilityActive))
+	if got := activeNodes(nodes); got != 2 {
+		t.Errorf("activeNodes = %d, want 2", got)
+	}
+}
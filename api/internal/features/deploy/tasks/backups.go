@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/robfig/cron/v3"
+)
+
+// ParseBackupSchedule parses a standard five field cron expression.
+func ParseBackupSchedule(schedule string) (cron.Schedule, error) {
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", types.ErrInvalidBackupSchedule, err.Error())
+	}
+	return parsed, nil
+}
+
+// BackupDue reports whether schedule fires at minute.
+func BackupDue(schedule cron.Schedule, minute time.Time) bool {
+	return schedule.Next(minute.Add(-time.Second)).Equal(minute)
+}
+
+// expiredBackups returns the backups, listed newest first, that retention
+// removes: successful backups past the latest count or older than days, and
+// failed backups a later backup has succeeded. The latest successful backup
+// is always kept and backups in progress are never touched. state returns
+// the status and creation time of a backup.
+func expiredBackups[T any](backups []T, state func(T) (shared_types.BackupStatus, time.Time), count int, days int, now time.Time) []T {
+	if count < 1 {
+		count = 1
+	}
+	var cutoff time.Time
+	if days > 0 {
+		cutoff = now.AddDate(0, 0, -days)
+	}
+
+	var expired []T
+	kept := 0
+	for _, backup := range backups {
+		status, createdAt := state(backup)
+		switch status {
+		case shared_types.BackupSucceeded:
+			if kept > 0 && (kept >= count || createdAt.Before(cutoff)) {
+				expired = append(expired, backup)
+				continue
+			}
+			kept++
+		case shared_types.BackupFailed:
+			if kept > 0 {
+				expired = append(expired, backup)
+			}
+		}
+	}
+	return expired
+}
@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestParseBackupSchedule(t *testing.T) {
+	if _, err := ParseBackupSchedule("0 3 * * *"); err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+	for _, schedule := range []string{"", "daily", "0 0 3 * * *"} {
+		if _, err := ParseBackupSchedule(schedule); !errors.Is(err, types.ErrInvalidBackupSchedule) {
+			t.Errorf("ParseBackupSchedule(%q) error = %v, want ErrInvalidBackupSchedule", schedule, err)
+		}
+	}
+}
+
+func TestBackupDue(t *testing.T) {
+	schedule, err := ParseBackupSchedule("30 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 14, hour, minute, 0, 0, time.UTC)
+	}
+	if !BackupDue(schedule, at(3, 30)) {
+		t.Error("backup should be due at 03:30")
+	}
+	for _, minute := range []time.Time{at(3, 29), at(3, 31), at(4, 30)} {
+		if BackupDue(schedule, minute) {
+			t.Errorf("backup should not be due at %s", minute.Format("15:04"))
+		}
+	}
+}
+
+func TestExpiredBackups(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	backup := func(status shared_types.BackupStatus, daysAgo int) shared_types.DatabaseBackup {
+		return shared_types.DatabaseBackup{ID: uuid.New(), Status: status, CreatedAt: now.AddDate(0, 0, -daysAgo)}
+	}
+	// Newest first, as listed by storage.
+	backups := []shared_types.DatabaseBackup{
+		backup(shared_types.BackupRunning, 0),
+		backup(shared_types.BackupFailed, 1),
+		backup(shared_types.BackupSucceeded, 2),
+		backup(shared_types.BackupFailed, 3),
+		backup(shared_types.BackupSucceeded, 4),
+		backup(shared_types.BackupSucceeded, 10),
+	}
+
+	assertExpired := func(name string, got []shared_types.DatabaseBackup, want ...int) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: expired %d backups, want %d", name, len(got), len(want))
+		}
+		for i, index := range want {
+			if got[i].ID != backups[index].ID {
+				t.Errorf("%s: expired[%d] is not backup %d", name, i, index)
+			}
+		}
+	}
+
+	assertExpired("count", expiredBackups(backups, databaseBackupState, 2, 0, now), 3, 5)
+	assertExpired("days", expiredBackups(backups, databaseBackupState, 10, 5, now), 3, 5)
+	assertExpired("keeps latest", expiredBackups(backups[5:], databaseBackupState, 1, 1, now))
+
+	onlyFailed := []shared_types.DatabaseBackup{backup(shared_types.BackupFailed, 1)}
+	if expired := expiredBackups(onlyFailed, databaseBackupState, 1, 0, now); len(expired) != 0 {
+		t.Error("failed backups are kept until a later backup succeeds")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/google/uuid"
 	"github.com/moby/term"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
 	sshpkg "github.com/nixopus/nixopus/api/internal/features/ssh"
 	shared_types "github.com/nixopus/nixopus/api/internal/types"
@@ -110,9 +112,18 @@ func (s *TaskService) BuildImage(b BuildConfig) (string, error) {
 	}
 	b.TaskContext.AddLog("Build output processing completed")
 
-	b.TaskContext.LogAndUpdateStatus("Image built successfully", shared_types.Deploying)
-
 	commitTag := CommitImageTag(b.Application.Name, b.ApplicationDeployment.CommitHash)
+	if err := s.ScanImage(b.Context, b.TaskPayload, commitTag, b.TaskContext); err != nil {
+		// A failed scan only blocks the deploy when a vulnerability threshold is set.
+		if errors.Is(err, types.ErrVulnThresholdExceeded) || blocksOnVulnerabilities(&b.Application) {
+			b.TaskContext.LogAndUpdateStatus("Image scan blocked the deployment: "+err.Error(), shared_types.Failed)
+			s.emitBuildFailed(b, err)
+			return "", err
+		}
+		b.TaskContext.AddLog("Warning: " + err.Error())
+	}
+
+	b.TaskContext.LogAndUpdateStatus("Image built successfully", shared_types.Deploying)
 	return commitTag, nil
 }
 
@@ -0,0 +1,204 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	caddyv2 "github.com/caddyserver/caddy/v2"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// redactedConfigValue replaces secret values in config diffs.
+const redactedConfigValue = "[REDACTED]"
+
+// RestoreCaddySnapshot loads an earlier Caddy config snapshot back into the
+// server it was taken from. The config in use is recorded first, so the
+// restore can itself be undone. Only the proxy is rolled back: domains still
+// stored for applications are added again by the next reconciliation.
+func (t *TaskService) RestoreCaddySnapshot(ctx context.Context, snapshotID uuid.UUID, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.CaddyConfigSnapshot, error) {
+	target, err := t.Storage.GetCaddyConfigSnapshotByID(organizationID, snapshotID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrCaddySnapshotNotFound
+		}
+		return nil, err
+	}
+
+	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+	host, err := caddy.ConfigServerHost(orgCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if host != target.ServerHost {
+		return nil, types.ErrCaddySnapshotServerChanged
+	}
+
+	var config caddyv2.Config
+	if err := json.Unmarshal([]byte(target.Config), &config); err != nil {
+		return nil, fmt.Errorf("failed to decode proxy config snapshot: %w", err)
+	}
+
+	restored, err := caddy.RecordConfigSnapshot(orgCtx, t.Storage, nil, &t.Logger, nil, shared_types.CaddyConfigSnapshot{
+		Reason:       shared_types.CaddySnapshotRestore,
+		CreatedBy:    &userID,
+		RestoredFrom: &target.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot the proxy config in use: %w", err)
+	}
+
+	if err := caddy.RestoreCaddyConfig(orgCtx, nil, &t.Logger, &config); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// DiffCaddyConfigs returns the differences between two Caddy JSON configs,
+// ordered by path, with secret values redacted. Arrays are compared by
+// index.
+func DiffCaddyConfigs(from, to string) ([]types.CaddyConfigChange, error) {
+	fromValue, err := decodeConfigValue(from)
+	if err != nil {
+		return nil, err
+	}
+	toValue, err := decodeConfigValue(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []types.CaddyConfigChange{}
+	err = diffConfigValues("", "", fromValue, toValue, &changes)
+	return changes, err
+}
+
+func decodeConfigValue(config string) (any, error) {
+	if strings.TrimSpace(config) == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(config)))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode proxy config: %w", err)
+	}
+	return value, nil
+}
+
+// diffConfigValues appends the differences between from and to, found at
+// path under key, to changes.
+func diffConfigValues(path, key string, from, to any, changes *[]types.CaddyConfigChange) error {
+	switch {
+	case from == nil && to == nil:
+		return nil
+	case from == nil:
+		return appendConfigChange(changes, path, key, types.CaddyConfigAdded, nil, to)
+	case to == nil:
+		return appendConfigChange(changes, path, key, types.CaddyConfigRemoved, from, nil)
+	}
+
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if fromIsMap && toIsMap {
+		keys := slices.Collect(maps.Keys(fromMap))
+		for k := range toMap {
+			if _, ok := fromMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if err := diffConfigValues(path+"/"+escapePointer(k), k, fromMap[k], toMap[k], changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	fromList, fromIsList := from.([]any)
+	toList, toIsList := to.([]any)
+	if fromIsList && toIsList {
+		for i := range max(len(fromList), len(toList)) {
+			var a, b any
+			if i < len(fromList) {
+				a = fromList[i]
+			}
+			if i < len(toList) {
+				b = toList[i]
+			}
+			if err := diffConfigValues(path+"/"+strconv.Itoa(i), key, a, b, changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+	return appendConfigChange(changes, path, key, types.CaddyConfigChanged, from, to)
+}
+
+func appendConfigChange(changes *[]types.CaddyConfigChange, path, key string, change types.CaddyConfigChangeType, from, to any) error {
+	c := types.CaddyConfigChange{Path: path, Change: change}
+	var err error
+	if from != nil {
+		if c.OldValue, err = json.Marshal(redactConfigValue(key, from)); err != nil {
+			return err
+		}
+	}
+	if to != nil {
+		if c.NewValue, err = json.Marshal(redactConfigValue(key, to)); err != nil {
+			return err
+		}
+	}
+	*changes = append(*changes, c)
+	return nil
+}
+
+// redactConfigValue returns value, found under key, with the values of
+// secret keys replaced.
+func redactConfigValue(key string, value any) any {
+	if secretConfigKey(key) {
+		return redactedConfigValue
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, item := range v {
+			redacted[k] = redactConfigValue(k, item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = redactConfigValue("", item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// secretConfigKey reports whether the value of a Caddy config key is a
+// credential, such as a DNS provider token, a basic auth hash or a
+// certificate key.
+func secretConfigKey(key string) bool {
+	return strings.EqualFold(key, "key") || secrets.IsSecretKeyName(key)
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
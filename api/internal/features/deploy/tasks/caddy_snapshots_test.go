@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+)
+
+func TestDiffCaddyConfigs(t *testing.T) {
+	from := `{
+		"apps": {
+			"http": {"servers": {"nixopus": {"listen": [":443"], "routes": [
+				{"match": [{"host": ["example.com"]}], "handle": [{"handler": "reverse_proxy", "upstreams": [{"dial": "10.0.0.5:31000"}]}]}
+			]}}},
+			"tls": {"automation": {"policies": [{"issuers": [{"module": "acme", "challenges": {"dns": {"provider": {"name": "cloudflare", "api_token": "old-token"}}}}]}]}}
+		}
+	}`
+	to := `{
+		"apps": {
+			"http": {"servers": {"nixopus": {"listen": [":443"], "routes": [
+				{"match": [{"host": ["example.com"]}], "handle": [{"handler": "reverse_proxy", "upstreams": [{"dial": "10.0.0.5:31001"}]}]},
+				{"match": [{"host": ["admin.example.com"]}], "handle": [{"handler": "authentication", "providers": {"http_basic": {"accounts": [{"username": "admin", "password": "hash"}]}}}]}
+			]}}},
+			"tls": {"automation": {"policies": [{"issuers": [{"module": "acme", "challenges": {"dns": {"provider": {"name": "cloudflare", "api_token": "new-token"}}}}]}]}}
+		}
+	}`
+
+	changes, err := DiffCaddyConfigs(from, to)
+	if err != nil {
+		t.Fatalf("DiffCaddyConfigs: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+
+	want := []struct {
+		path   string
+		change types.CaddyConfigChangeType
+	}{
+		{"/apps/http/servers/nixopus/routes/0/handle/0/upstreams/0/dial", types.CaddyConfigChanged},
+		{"/apps/http/servers/nixopus/routes/1", types.CaddyConfigAdded},
+		{"/apps/tls/automation/policies/0/issuers/0/challenges/dns/provider/api_token", types.CaddyConfigChanged},
+	}
+	for i, w := range want {
+		if changes[i].Path != w.path || changes[i].Change != w.change {
+			t.Errorf("change %d = %s %s, want %s %s", i, changes[i].Change, changes[i].Path, w.change, w.path)
+		}
+	}
+
+	if string(changes[0].OldValue) != `"10.0.0.5:31000"` || string(changes[0].NewValue) != `"10.0.0.5:31001"` {
+		t.Errorf("upstream values = %s, %s", changes[0].OldValue, changes[0].NewValue)
+	}
+	if changes[1].OldValue != nil || strings.Contains(string(changes[1].NewValue), "hash") ||
+		!strings.Contains(string(changes[1].NewValue), `"username":"admin"`) {
+		t.Errorf("added route should keep the username and redact the password, got %s", changes[1].NewValue)
+	}
+	for _, v := range []string{string(changes[2].OldValue), string(changes[2].NewValue)} {
+		if v != `"`+redactedConfigValue+`"` {
+			t.Errorf("token should be redacted, got %s", v)
+		}
+	}
+}
+
+func TestDiffCaddyConfigsUnchanged(t *testing.T) {
+	config := `{"apps": {"http": {"servers": {"nixopus": {"listen": [":443"]}}}}}`
+	changes, err := DiffCaddyConfigs(config, config)
+	if err != nil {
+		t.Fatalf("DiffCaddyConfigs: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	if _, err := DiffCaddyConfigs(config, "{"); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
@@ -0,0 +1,200 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	// defaultExpiryWarningDays is how many days before it expires a custom
+	// certificate is warned about when the upload does not say.
+	defaultExpiryWarningDays = 14
+	maxExpiryWarningDays     = 90
+	maxCertificatePEMBytes   = 64 * 1024
+)
+
+// UploadCertificate validates a certificate chain and key for a domain of
+// an application, stores them and makes Caddy serve them.
+func (t *TaskService) UploadCertificate(ctx context.Context, req *types.UploadCertificateRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationCertificate, error) {
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	domain, err := certificateDomain(&app, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	warningDays := req.ExpiryWarningDays
+	if warningDays == 0 {
+		warningDays = defaultExpiryWarningDays
+	}
+	if warningDays < 1 || warningDays > maxExpiryWarningDays {
+		return nil, types.ErrInvalidExpiryWarningDays
+	}
+
+	certificate, err := ParseCustomCertificate(domain, req.CertificatePEM, req.PrivateKeyPEM, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	certificate.ID = uuid.New()
+	certificate.ApplicationID = app.ID
+	certificate.OrganizationID = organizationID
+	certificate.ExpiryWarningDays = warningDays
+	certificate.CreatedBy = userID
+	certificate.CreatedAt = now
+	certificate.UpdatedAt = now
+	if err := t.Storage.UpsertApplicationCertificate(certificate); err != nil {
+		return nil, err
+	}
+	if err := t.reconcileRoutes(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// DeleteCertificate removes the custom certificate of a domain of an
+// application. The domain gets its certificates from ACME again.
+func (t *TaskService) DeleteCertificate(ctx context.Context, applicationID uuid.UUID, domain string, organizationID uuid.UUID) error {
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	domain = strings.ToLower(strings.TrimSpace(domain))
+
+	certificates, err := t.Storage.GetApplicationCertificates(app.ID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, c := range certificates {
+		if c.Domain == domain {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return types.ErrCertificateNotFound
+	}
+
+	if err := t.Storage.DeleteApplicationCertificate(app.ID, domain); err != nil {
+		return err
+	}
+	return t.reconcileRoutes(ctx, organizationID)
+}
+
+// certificateDomain normalizes domain and checks that it is the host of one
+// of app's domains. Certificates are per host, so an application mounted
+// under a path prefix still gets its certificate for the bare host.
+func certificateDomain(app *shared_types.Application, domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return "", types.ErrDomainNotInApplication
+	}
+	for _, d := range app.Domains {
+		if strings.ToLower(d.Domain) == domain {
+			return domain, nil
+		}
+	}
+	return "", types.ErrDomainNotInApplication
+}
+
+// ParseCustomCertificate checks that certPEM is a certificate chain whose
+// leaf is valid for domain at now and matches the private key in keyPEM.
+// It returns the certificate with its PEM blocks normalized and its
+// metadata set.
+func ParseCustomCertificate(domain, certPEM, keyPEM string, now time.Time) (*shared_types.ApplicationCertificate, error) {
+	if len(certPEM) > maxCertificatePEMBytes || len(keyPEM) > maxCertificatePEMBytes {
+		return nil, types.ErrCertificateTooLarge
+	}
+
+	var chain bytes.Buffer
+	var leaf *x509.Certificate
+	rest := []byte(certPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, types.ErrInvalidCertificate
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, types.ErrInvalidCertificate
+		}
+		if leaf == nil {
+			leaf = cert
+		}
+		pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})
+	}
+	if leaf == nil {
+		return nil, types.ErrInvalidCertificate
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil || !strings.HasSuffix(keyBlock.Type, "PRIVATE KEY") || !isPrivateKey(keyBlock.Bytes) {
+		return nil, types.ErrInvalidPrivateKey
+	}
+	key := pem.EncodeToMemory(&pem.Block{Type: keyBlock.Type, Bytes: keyBlock.Bytes})
+	if _, err := tls.X509KeyPair(chain.Bytes(), key); err != nil {
+		return nil, types.ErrCertificateKeyMismatch
+	}
+
+	if !certificateCovers(leaf, domain) {
+		return nil, types.ErrCertificateDomainMismatch
+	}
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return nil, types.ErrCertificateExpired
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	return &shared_types.ApplicationCertificate{
+		Domain:         domain,
+		CertificatePEM: shared_types.EncryptedString(chain.String()),
+		PrivateKeyPEM:  shared_types.EncryptedString(key),
+		Subject:        leaf.Subject.String(),
+		Issuer:         leaf.Issuer.String(),
+		DNSNames:       leaf.DNSNames,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		NotBefore:      leaf.NotBefore.UTC(),
+		NotAfter:       leaf.NotAfter.UTC(),
+	}, nil
+}
+
+// certificateCovers reports whether leaf is valid for domain. A wildcard
+// domain needs the same wildcard name in the certificate.
+func certificateCovers(leaf *x509.Certificate, domain string) bool {
+	if strings.HasPrefix(domain, "*.") {
+		for _, name := range leaf.DNSNames {
+			if strings.EqualFold(name, domain) {
+				return true
+			}
+		}
+		return false
+	}
+	return leaf.VerifyHostname(domain) == nil
+}
+
+func isPrivateKey(der []byte) bool {
+	if _, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return true
+	}
+	_, err := x509.ParseECPrivateKey(der)
+	return err == nil
+}
@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func testCertificate(t *testing.T, names []string, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestParseCustomCertificate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	valid := func(names ...string) (string, string) {
+		return testCertificate(t, names, now.AddDate(0, -1, 0), now.AddDate(1, 0, 0))
+	}
+
+	cert, key := valid("app.example.com", "www.example.com")
+	parsed, err := ParseCustomCertificate("www.example.com", "\n"+cert, key, now)
+	if err != nil {
+		t.Fatalf("ParseCustomCertificate: %v", err)
+	}
+	if parsed.Subject != "CN=app.example.com" || len(parsed.Fingerprint) != 64 || !parsed.NotAfter.Equal(now.AddDate(1, 0, 0)) {
+		t.Errorf("unexpected metadata: %+v", parsed)
+	}
+	if string(parsed.CertificatePEM) != cert {
+		t.Errorf("certificate was not normalized: %q", parsed.CertificatePEM)
+	}
+
+	wildcardCert, wildcardKey := valid("*.preview.example.com")
+	if _, err := ParseCustomCertificate("pr-1.preview.example.com", wildcardCert, wildcardKey, now); err != nil {
+		t.Errorf("wildcard certificate should cover a subdomain: %v", err)
+	}
+	if _, err := ParseCustomCertificate("*.preview.example.com", wildcardCert, wildcardKey, now); err != nil {
+		t.Errorf("wildcard certificate should cover the wildcard domain: %v", err)
+	}
+	if _, err := ParseCustomCertificate("*.preview.example.com", cert, key, now); !errors.Is(err, types.ErrCertificateDomainMismatch) {
+		t.Errorf("wildcard domain with a plain certificate: err = %v", err)
+	}
+
+	_, otherKey := valid("app.example.com")
+	expiredCert, expiredKey := testCertificate(t, []string{"app.example.com"}, now.AddDate(-2, 0, 0), now.AddDate(0, 0, -1))
+	tests := []struct {
+		name   string
+		domain string
+		cert   string
+		key    string
+		want   error
+	}{
+		{"wrong domain", "api.example.com", cert, key, types.ErrCertificateDomainMismatch},
+		{"key mismatch", "app.example.com", cert, otherKey, types.ErrCertificateKeyMismatch},
+		{"expired", "app.example.com", expiredCert, expiredKey, types.ErrCertificateExpired},
+		{"no certificate", "app.example.com", "not a certificate", key, types.ErrInvalidCertificate},
+		{"key in chain", "app.example.com", cert + key, key, types.ErrInvalidCertificate},
+		{"no key", "app.example.com", cert, cert, types.ErrInvalidPrivateKey},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCustomCertificate(tt.domain, tt.cert, tt.key, now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCertificateDomain(t *testing.T) {
+	app := &shared_types.Application{Domains: []*shared_types.ApplicationDomain{
+		{Domain: "example.com", PathPrefix: "/api"},
+	}}
+	tests := []struct {
+		name    string
+		domain  string
+		want    string
+		wantErr bool
+	}{
+		{name: "host of a path-mounted domain", domain: " Example.com ", want: "example.com"},
+		{name: "route with path prefix", domain: "example.com/api", wantErr: true},
+		{name: "other host", domain: "other.com", wantErr: true},
+		{name: "empty", domain: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := certificateDomain(app, tt.domain)
+			if tt.wantErr {
+				if !errors.Is(err, types.ErrDomainNotInApplication) {
+					t.Fatalf("certificateDomain(%q) error = %v, want ErrDomainNotInApplication", tt.domain, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("certificateDomain(%q) = %q, %v; want %q", tt.domain, got, err, tt.want)
+			}
+		})
+	}
+}
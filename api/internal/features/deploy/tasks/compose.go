@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
 	shared_types "github.com/nixopus/nixopus/api/internal/types"
@@ -21,19 +22,52 @@ func (t *TaskService) deployDockerCompose(ctx context.Context, TaskPayload share
 
 	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, TaskPayload.Application.OrganizationID.String())
 
-	composeFilePath := t.buildComposeFilePath(TaskPayload, repoPath, taskCtx)
+	project := t.buildComposeProject(TaskPayload, repoPath, taskCtx)
 
-	if err := t.discoverAndPersistComposeServices(orgCtx, composeFilePath, TaskPayload, taskCtx); err != nil {
-		taskCtx.AddLog("Warning: failed to discover compose services: " + err.Error())
+	if err := t.removeOtherComposeMode(orgCtx, &TaskPayload.Application, project, t.createOutputCallback(taskCtx), taskCtx); err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to remove the previous deployment: "+err.Error(), shared_types.Failed)
+		return err
+	}
+
+	// Stack services are recorded from Swarm once the stack is deployed.
+	isStack := TaskPayload.Application.IsComposeStack()
+	if !isStack {
+		if err := t.discoverAndPersistComposeServices(orgCtx, project, TaskPayload, taskCtx); err != nil {
+			taskCtx.AddLog("Warning: failed to discover compose services: " + err.Error())
+		}
+	}
+
+	skipped, err := t.attachComposePrivateNetwork(orgCtx, &TaskPayload.Application, &project)
+	if err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to prepare private network: "+err.Error(), shared_types.Failed)
+		return err
+	}
+	for _, name := range skipped {
+		taskCtx.AddLog(fmt.Sprintf("Warning: compose service %s uses network_mode and was not added to the private network", name))
 	}
 
-	envVars := GetMapFromString(TaskPayload.Application.EnvironmentVariables)
+	if err := t.attachComposePorts(orgCtx, &TaskPayload.Application, &project); err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to prepare published ports: "+err.Error(), shared_types.Failed)
+		return err
+	}
+
+	envVars, err := t.ResolveEnvironmentVariables(orgCtx, TaskPayload.Application)
+	if err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to resolve environment variables: "+err.Error(), shared_types.Failed)
+		return err
+	}
 	outputCallback := t.createOutputCallback(taskCtx)
 
 	deploymentTypeEnum := shared_types.DeploymentType(deploymentType)
-	if err := t.executeComposeDeployment(orgCtx, deploymentTypeEnum, composeFilePath, envVars, outputCallback, taskCtx); err != nil {
+	if isStack {
+		err = t.deployComposeStack(orgCtx, TaskPayload, deploymentTypeEnum, project, envVars, outputCallback, taskCtx)
+	} else {
+		err = t.executeComposeDeployment(orgCtx, deploymentTypeEnum, project, envVars, outputCallback, taskCtx)
+	}
+	if err != nil {
 		return err
 	}
+	t.recordComposeProxyUpstreams(orgCtx, &TaskPayload.Application, project, taskCtx)
 
 	if err := t.addDomainsForCompose(orgCtx, TaskPayload, taskCtx); err != nil {
 		return err
@@ -62,20 +96,44 @@ func (t *TaskService) cloneRepositoryForCompose(ctx context.Context, TaskPayload
 	return repoPath, nil
 }
 
-func (t *TaskService) buildComposeFilePath(TaskPayload shared_types.TaskPayload, repoPath string, taskCtx *TaskContext) string {
-	basePath := TaskPayload.Application.BasePath
+func (t *TaskService) buildComposeProject(TaskPayload shared_types.TaskPayload, repoPath string, taskCtx *TaskContext) docker.ComposeProject {
+	project := composeProjectForApplication(&TaskPayload.Application, repoPath)
+	taskCtx.AddLog("Starting Docker Compose services from: " + strings.Join(project.Files, ", "))
+	if len(project.EnvFiles) > 0 {
+		taskCtx.AddLog("Using env files: " + strings.Join(project.EnvFiles, ", "))
+	}
+	if len(project.Profiles) > 0 {
+		taskCtx.AddLog("Enabled compose profiles: " + strings.Join(project.Profiles, ", "))
+	}
+	return project
+}
+
+// composeProjectForApplication returns the compose project of an application
+// checked out at repoPath. The base compose file comes first, followed by the
+// application's override files. All files are relative to the base path.
+func composeProjectForApplication(app *shared_types.Application, repoPath string) docker.ComposeProject {
+	basePath := app.BasePath
 	if basePath == "" || basePath == "/" {
 		basePath = "."
 	}
+	dir := filepath.Join(repoPath, basePath)
 
 	composeFileName := "docker-compose.yml"
-	if TaskPayload.Application.DockerfilePath != "" && TaskPayload.Application.DockerfilePath != "Dockerfile" {
-		composeFileName = TaskPayload.Application.DockerfilePath
+	if app.DockerfilePath != "" && app.DockerfilePath != "Dockerfile" {
+		composeFileName = app.DockerfilePath
 	}
 
-	composeFilePath := filepath.Join(repoPath, basePath, composeFileName)
-	taskCtx.AddLog("Starting Docker Compose services from: " + composeFilePath)
-	return composeFilePath
+	project := docker.ComposeProject{
+		Files:    []string{filepath.Join(dir, composeFileName)},
+		Profiles: app.ComposeProfiles,
+	}
+	for _, f := range app.ComposeFiles {
+		project.Files = append(project.Files, filepath.Join(dir, f))
+	}
+	for _, f := range app.ComposeEnvFiles {
+		project.EnvFiles = append(project.EnvFiles, filepath.Join(dir, f))
+	}
+	return project
 }
 
 func (t *TaskService) createOutputCallback(taskCtx *TaskContext) func(string) {
@@ -86,20 +144,20 @@ func (t *TaskService) createOutputCallback(taskCtx *TaskContext) func(string) {
 	}
 }
 
-func (t *TaskService) executeComposeDeployment(ctx context.Context, deploymentType shared_types.DeploymentType, composeFilePath string, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext) error {
+func (t *TaskService) executeComposeDeployment(ctx context.Context, deploymentType shared_types.DeploymentType, project docker.ComposeProject, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext) error {
 	switch deploymentType {
 	case shared_types.DeploymentTypeCreate:
-		return t.composeUp(ctx, composeFilePath, envVars, outputCallback, taskCtx, "Starting Docker Compose services", "Docker Compose services started successfully")
+		return t.composeUp(ctx, project, envVars, outputCallback, taskCtx, "Starting Docker Compose services", "Docker Compose services started successfully")
 
 	case shared_types.DeploymentTypeReDeploy, shared_types.DeploymentTypeUpdate, shared_types.DeploymentTypeRollback:
-		if err := t.composeDown(ctx, composeFilePath, outputCallback, taskCtx); err != nil {
+		if err := t.composeDown(ctx, project, outputCallback, taskCtx); err != nil {
 			return err
 		}
 		taskCtx.AddLog("Existing services stopped, starting with new code")
-		return t.composeUp(ctx, composeFilePath, envVars, outputCallback, taskCtx, "Starting Docker Compose services", "Docker Compose services restarted successfully")
+		return t.composeUp(ctx, project, envVars, outputCallback, taskCtx, "Starting Docker Compose services", "Docker Compose services restarted successfully")
 
 	case shared_types.DeploymentTypeRestart:
-		return t.composeRestart(ctx, composeFilePath, envVars, outputCallback, taskCtx)
+		return t.composeRestart(ctx, project, envVars, outputCallback, taskCtx)
 
 	default:
 		taskCtx.LogAndUpdateStatus("Unknown deployment type: "+string(deploymentType), shared_types.Failed)
@@ -107,7 +165,7 @@ func (t *TaskService) executeComposeDeployment(ctx context.Context, deploymentTy
 	}
 }
 
-func (t *TaskService) composeUp(ctx context.Context, composeFilePath string, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext, startMsg, successMsg string) error {
+func (t *TaskService) composeUp(ctx context.Context, project docker.ComposeProject, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext, startMsg, successMsg string) error {
 	taskCtx.AddLog(startMsg)
 
 	dockerSvc, err := t.getDockerService(ctx)
@@ -117,13 +175,13 @@ func (t *TaskService) composeUp(ctx context.Context, composeFilePath string, env
 	}
 
 	if ds, ok := dockerSvc.(*docker.DockerService); ok {
-		_, err := ds.ComposeUpWithCallback(composeFilePath, envVars, outputCallback)
+		_, err := ds.ComposeUpWithCallback(project, envVars, outputCallback)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to start docker compose services: "+err.Error(), shared_types.Failed)
 			return err
 		}
 	} else {
-		output, err := dockerSvc.ComposeUp(composeFilePath, envVars)
+		output, err := dockerSvc.ComposeUp(project, envVars)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to start docker compose services: "+err.Error(), shared_types.Failed)
 			return err
@@ -137,7 +195,7 @@ func (t *TaskService) composeUp(ctx context.Context, composeFilePath string, env
 	return nil
 }
 
-func (t *TaskService) composeDown(ctx context.Context, composeFilePath string, outputCallback func(string), taskCtx *TaskContext) error {
+func (t *TaskService) composeDown(ctx context.Context, project docker.ComposeProject, outputCallback func(string), taskCtx *TaskContext) error {
 	taskCtx.AddLog("Stopping existing Docker Compose services")
 
 	dockerSvc, err := t.getDockerService(ctx)
@@ -147,13 +205,13 @@ func (t *TaskService) composeDown(ctx context.Context, composeFilePath string, o
 	}
 
 	if ds, ok := dockerSvc.(*docker.DockerService); ok {
-		err := ds.ComposeDownWithCallback(composeFilePath, outputCallback)
+		err := ds.ComposeDownWithCallback(project, outputCallback)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to stop docker compose services: "+err.Error(), shared_types.Failed)
 			return err
 		}
 	} else {
-		err := dockerSvc.ComposeDown(composeFilePath)
+		err := dockerSvc.ComposeDown(project)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to stop docker compose services: "+err.Error(), shared_types.Failed)
 			return err
@@ -163,7 +221,45 @@ func (t *TaskService) composeDown(ctx context.Context, composeFilePath string, o
 	return nil
 }
 
-func (t *TaskService) composeRestart(ctx context.Context, composeFilePath string, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext) error {
+// removeOtherComposeMode tears down what the application left running in
+// the deploy mode it no longer uses: the compose containers when it is
+// deployed as a stack, or the stack when it is deployed with compose.
+// Otherwise both would run side by side and hold the same ports.
+func (t *TaskService) removeOtherComposeMode(ctx context.Context, app *shared_types.Application, project docker.ComposeProject, outputCallback func(string), taskCtx *TaskContext) error {
+	dockerSvc, err := t.getDockerService(ctx)
+	if err != nil {
+		return err
+	}
+	ds, ok := dockerSvc.(*docker.DockerService)
+	if !ok {
+		return nil
+	}
+
+	if app.IsComposeStack() {
+		containers, err := ds.ListComposeContainers(project.Dir())
+		if err != nil {
+			return fmt.Errorf("failed to list compose containers: %w", err)
+		}
+		if len(containers) == 0 {
+			return nil
+		}
+		taskCtx.AddLog("Removing the compose containers of the previous deploy mode")
+		return ds.ComposeDownWithCallback(project, outputCallback)
+	}
+
+	stackName := app.ComposeStackName()
+	services, err := ds.ListStackServices(stackName)
+	if err != nil {
+		return fmt.Errorf("failed to list stack services: %w", err)
+	}
+	if len(services) == 0 {
+		return nil
+	}
+	taskCtx.AddLog("Removing stack " + stackName + " of the previous deploy mode")
+	return ds.StackRemove(stackName)
+}
+
+func (t *TaskService) composeRestart(ctx context.Context, project docker.ComposeProject, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext) error {
 	taskCtx.AddLog("Restarting Docker Compose services")
 
 	dockerSvc, err := t.getDockerService(ctx)
@@ -173,16 +269,16 @@ func (t *TaskService) composeRestart(ctx context.Context, composeFilePath string
 	}
 
 	if ds, ok := dockerSvc.(*docker.DockerService); ok {
-		err := ds.ComposeRestart(composeFilePath, envVars, outputCallback)
+		err := ds.ComposeRestart(project, envVars, outputCallback)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to restart docker compose services: "+err.Error(), shared_types.Failed)
 			return err
 		}
 	} else {
-		if err := t.composeDown(ctx, composeFilePath, outputCallback, taskCtx); err != nil {
+		if err := t.composeDown(ctx, project, outputCallback, taskCtx); err != nil {
 			return err
 		}
-		output, err := dockerSvc.ComposeUp(composeFilePath, envVars)
+		output, err := dockerSvc.ComposeUp(project, envVars)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to start docker compose services: "+err.Error(), shared_types.Failed)
 			return err
@@ -196,14 +292,18 @@ func (t *TaskService) composeRestart(ctx context.Context, composeFilePath string
 	return nil
 }
 
-func (t *TaskService) discoverAndPersistComposeServices(ctx context.Context, composeFilePath string, TaskPayload shared_types.TaskPayload, taskCtx *TaskContext) error {
-	parsed, err := ParseComposeFile(composeFilePath)
+func (t *TaskService) discoverAndPersistComposeServices(ctx context.Context, project docker.ComposeProject, TaskPayload shared_types.TaskPayload, taskCtx *TaskContext) error {
+	parsed, err := ParseComposeProject(project.Files, project.Profiles)
 	if err != nil {
 		return err
 	}
 
-	appID := TaskPayload.Application.ID
+	return t.persistComposeServices(ctx, TaskPayload.Application.ID, buildComposeServices(parsed), taskCtx)
+}
 
+// persistComposeServices stores the services of a compose application and
+// unlinks the domains of services that no longer exist.
+func (t *TaskService) persistComposeServices(ctx context.Context, appID uuid.UUID, services []shared_types.ComposeService, taskCtx *TaskContext) error {
 	oldServices, err := t.Storage.GetComposeServices(appID)
 	if err != nil {
 		taskCtx.AddLog("Warning: failed to load existing compose services: " + err.Error())
@@ -213,17 +313,22 @@ func (t *TaskService) discoverAndPersistComposeServices(ctx context.Context, com
 		taskCtx.AddLog("Warning: failed to load existing domains: " + err.Error())
 	}
 
-	services := buildRoutableServices(parsed, taskCtx)
 	if err := t.Storage.UpsertComposeServices(appID, services); err != nil {
 		return fmt.Errorf("failed to persist compose services: %w", err)
 	}
 
-	if len(services) == 0 {
-		taskCtx.AddLog("No routable services found in compose file (none expose host ports)")
-	}
+	routable := 0
 	for _, svc := range services {
+		if svc.Port == 0 {
+			taskCtx.AddLog(fmt.Sprintf("Discovered compose service: %s (no host port exposed, not routable)", svc.ServiceName))
+			continue
+		}
+		routable++
 		taskCtx.AddLog(fmt.Sprintf("Discovered compose service: %s (port %d)", svc.ServiceName, svc.Port))
 	}
+	if routable == 0 {
+		taskCtx.AddLog("No routable services found in compose file (none expose host ports)")
+	}
 
 	newServiceNames := make(map[string]bool, len(services))
 	for _, svc := range services {
@@ -234,17 +339,16 @@ func (t *TaskService) discoverAndPersistComposeServices(ctx context.Context, com
 	return nil
 }
 
-func buildRoutableServices(parsed []ParsedComposeService, taskCtx *TaskContext) []shared_types.ComposeService {
+// buildComposeServices returns every service of the compose file. Services
+// without a host port are kept with port 0 so they can still be managed
+// individually, but domains cannot be routed to them.
+func buildComposeServices(parsed []ParsedComposeService) []shared_types.ComposeService {
 	var services []shared_types.ComposeService
 	for _, p := range parsed {
 		port := 0
 		if len(p.Ports) > 0 {
 			port = p.Ports[0]
 		}
-		if port == 0 {
-			taskCtx.AddLog(fmt.Sprintf("Skipping compose service %q: no host port exposed", p.ServiceName))
-			continue
-		}
 		services = append(services, shared_types.ComposeService{
 			ServiceName: p.ServiceName,
 			Port:        port,
@@ -257,7 +361,7 @@ func collectOrphanedDomains(svc shared_types.ComposeService, domains []shared_ty
 	var orphaned []string
 	for _, d := range domains {
 		if d.ComposeServiceID != nil && *d.ComposeServiceID == svc.ID && d.Domain != "" {
-			orphaned = append(orphaned, d.Domain)
+			orphaned = append(orphaned, d.Route())
 		}
 	}
 	return orphaned
@@ -294,6 +398,7 @@ func (t *TaskService) addDomainsForCompose(ctx context.Context, TaskPayload shar
 	if len(domains) == 0 {
 		return nil
 	}
+	taskCtx.StartPhase(shared_types.PhaseRoute)
 
 	upstreamHost, err := GetSSHHostForOrganization(ctx, TaskPayload.Application.OrganizationID)
 	if err != nil {
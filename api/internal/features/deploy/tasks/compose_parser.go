@@ -3,6 +3,8 @@ package tasks
 import (
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,6 +14,11 @@ import (
 type ParsedComposeService struct {
 	ServiceName string
 	Ports       []int
+	Profiles    []string
+	// NetworkMode is set when the service uses the host or another container's network stack.
+	NetworkMode string
+	// Networks are the networks the service explicitly joins.
+	Networks []string
 }
 
 type composeFile struct {
@@ -19,8 +26,11 @@ type composeFile struct {
 }
 
 type composeServiceDef struct {
-	Ports  []interface{} `yaml:"ports"`
-	Expose []interface{} `yaml:"expose"`
+	Ports       []interface{} `yaml:"ports"`
+	Expose      []interface{} `yaml:"expose"`
+	Profiles    []string      `yaml:"profiles"`
+	NetworkMode string        `yaml:"network_mode"`
+	Networks    interface{}   `yaml:"networks"`
 }
 
 // ParseComposeFile reads a docker-compose YAML file and extracts service names
@@ -34,6 +44,80 @@ func ParseComposeFile(filePath string) ([]ParsedComposeService, error) {
 	return ParseComposeYAML(data)
 }
 
+// ParseComposeProject parses a base compose file and its overrides, merges the
+// services the way docker compose does and drops services whose profiles are
+// not enabled.
+func ParseComposeProject(files []string, profiles []string) ([]ParsedComposeService, error) {
+	layers := make([][]ParsedComposeService, 0, len(files))
+	for _, f := range files {
+		parsed, err := ParseComposeFile(f)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, parsed)
+	}
+	return FilterComposeProfiles(MergeComposeServices(layers...), profiles), nil
+}
+
+// MergeComposeServices merges the services of several compose files, later
+// files overriding earlier ones. Ports, profiles and networks are combined
+// without duplicates, as docker compose does for these keys. The result is
+// sorted by service name.
+func MergeComposeServices(layers ...[]ParsedComposeService) []ParsedComposeService {
+	merged := make(map[string]*ParsedComposeService)
+	var names []string
+	for _, layer := range layers {
+		for _, svc := range layer {
+			existing, ok := merged[svc.ServiceName]
+			if !ok {
+				copied := ParsedComposeService{ServiceName: svc.ServiceName}
+				existing = &copied
+				merged[svc.ServiceName] = existing
+				names = append(names, svc.ServiceName)
+			}
+			for _, p := range svc.Ports {
+				if !slices.Contains(existing.Ports, p) {
+					existing.Ports = append(existing.Ports, p)
+				}
+			}
+			for _, p := range svc.Profiles {
+				if !slices.Contains(existing.Profiles, p) {
+					existing.Profiles = append(existing.Profiles, p)
+				}
+			}
+			for _, n := range svc.Networks {
+				if !slices.Contains(existing.Networks, n) {
+					existing.Networks = append(existing.Networks, n)
+				}
+			}
+			if svc.NetworkMode != "" {
+				existing.NetworkMode = svc.NetworkMode
+			}
+		}
+	}
+
+	sort.Strings(names)
+	result := make([]ParsedComposeService, 0, len(names))
+	for _, name := range names {
+		result = append(result, *merged[name])
+	}
+	return result
+}
+
+// FilterComposeProfiles keeps services without profiles and services assigned
+// to at least one of the enabled profiles.
+func FilterComposeProfiles(services []ParsedComposeService, enabled []string) []ParsedComposeService {
+	var result []ParsedComposeService
+	for _, svc := range services {
+		if len(svc.Profiles) == 0 || slices.ContainsFunc(svc.Profiles, func(p string) bool {
+			return slices.Contains(enabled, p)
+		}) {
+			result = append(result, svc)
+		}
+	}
+	return result
+}
+
 func ParseComposeYAML(data []byte) ([]ParsedComposeService, error) {
 	var cf composeFile
 	if err := yaml.Unmarshal(data, &cf); err != nil {
@@ -49,12 +133,35 @@ func ParseComposeYAML(data []byte) ([]ParsedComposeService, error) {
 		result = append(result, ParsedComposeService{
 			ServiceName: name,
 			Ports:       ports,
+			Profiles:    svc.Profiles,
+			NetworkMode: svc.NetworkMode,
+			Networks:    extractNetworkNames(svc.Networks),
 		})
 	}
 
 	return result, nil
 }
 
+// extractNetworkNames handles both the list and the mapping form of a
+// service's networks.
+func extractNetworkNames(raw interface{}) []string {
+	var names []string
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, n := range v {
+			if name, ok := n.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case map[string]interface{}:
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	return names
+}
+
 // extractExposePorts handles the expose directive which lists container ports
 // (e.g. expose: ["3000"] or expose: [3000]).
 func extractExposePorts(raw []interface{}) []int {
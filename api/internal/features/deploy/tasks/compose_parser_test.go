@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeComposeServicesWithOverrideAndProfiles(t *testing.T) {
+	base, err := ParseComposeYAML([]byte(`
+services:
+  web:
+    ports: ["8080:80"]
+  worker:
+    image: worker
+  debug:
+    image: debug
+    profiles: ["debug"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	override, err := ParseComposeYAML([]byte(`
+services:
+  web:
+    ports: ["8443:443"]
+  metrics:
+    ports: ["9100:9100"]
+    profiles: ["monitoring"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := MergeComposeServices(base, override)
+
+	var names []string
+	for _, svc := range merged {
+		names = append(names, svc.ServiceName)
+		if svc.ServiceName == "web" && !slices.Equal(svc.Ports, []int{8080, 8443}) {
+			t.Errorf("web ports = %v, want [8080 8443]", svc.Ports)
+		}
+	}
+	if want := []string{"debug", "metrics", "web", "worker"}; !slices.Equal(names, want) {
+		t.Fatalf("merged services = %v, want %v", names, want)
+	}
+
+	names = nil
+	for _, svc := range FilterComposeProfiles(merged, []string{"monitoring"}) {
+		names = append(names, svc.ServiceName)
+	}
+	if want := []string{"metrics", "web", "worker"}; !slices.Equal(names, want) {
+		t.Errorf("services with monitoring profile = %v, want %v", names, want)
+	}
+}
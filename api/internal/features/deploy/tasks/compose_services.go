@@ -0,0 +1,270 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	MaxComposeServiceReplicas = 20
+	DefaultComposeLogTail     = 200
+	MaxComposeLogTail         = 5000
+)
+
+// ComposeServiceAction is an operation on a single compose service.
+type ComposeServiceAction string
+
+const (
+	ComposeServiceActionRestart ComposeServiceAction = "restart"
+	ComposeServiceActionStop    ComposeServiceAction = "stop"
+	ComposeServiceActionStart   ComposeServiceAction = "start"
+)
+
+// RunComposeServiceAction restarts, stops or starts one service of a compose
+// application. The other services keep running.
+func (t *TaskService) RunComposeServiceAction(ctx context.Context, app *shared_types.Application, serviceName string, action ComposeServiceAction) (*shared_types.ComposeService, error) {
+	svc, err := findComposeService(app, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, shared_types.OrganizationIDKey, app.OrganizationID.String())
+	if app.IsComposeStack() {
+		dockerSvc, err := docker.GetDockerServiceForOrganization(ctx, app.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.runStackServiceAction(dockerSvc, app, svc.ServiceName, action); err != nil {
+			t.Logger.Log(logger.Error, fmt.Sprintf("failed to %s stack service %s", action, svc.ServiceName), err.Error())
+			return nil, err
+		}
+		t.attachComposeServiceStatus(dockerSvc, app)
+		return svc, nil
+	}
+
+	dockerSvc, envVars, err := t.composeServiceDependencies(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	project := composeProjectForApplication(app, applicationRepoPath(app))
+	switch action {
+	case ComposeServiceActionRestart:
+		_, err = dockerSvc.ComposeRestartService(project, svc.ServiceName, envVars)
+	case ComposeServiceActionStop:
+		_, err = dockerSvc.ComposeStopService(project, svc.ServiceName, envVars)
+	case ComposeServiceActionStart:
+		_, err = dockerSvc.ComposeStartService(project, svc.ServiceName, envVars)
+	default:
+		return nil, fmt.Errorf("unknown compose service action: %s", action)
+	}
+	if err != nil {
+		t.Logger.Log(logger.Error, fmt.Sprintf("failed to %s compose service %s", action, svc.ServiceName), err.Error())
+		return nil, err
+	}
+
+	t.attachComposeServiceStatus(dockerSvc, app)
+	return svc, nil
+}
+
+// ScaleComposeService sets the number of containers of one service of a compose application.
+func (t *TaskService) ScaleComposeService(ctx context.Context, app *shared_types.Application, serviceName string, replicas int) (*shared_types.ComposeService, error) {
+	if replicas < 0 || replicas > MaxComposeServiceReplicas {
+		return nil, types.ErrInvalidReplicaCount
+	}
+
+	svc, err := findComposeService(app, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, shared_types.OrganizationIDKey, app.OrganizationID.String())
+	if app.IsComposeStack() {
+		dockerSvc, err := docker.GetDockerServiceForOrganization(ctx, app.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		swarmSvc, err := stackService(dockerSvc, app, svc.ServiceName)
+		if err == nil {
+			err = scaleStackService(dockerSvc, swarmSvc, replicas)
+		}
+		if err != nil {
+			t.Logger.Log(logger.Error, "failed to scale stack service "+svc.ServiceName, err.Error())
+			return nil, err
+		}
+		t.attachComposeServiceStatus(dockerSvc, app)
+		return svc, nil
+	}
+
+	dockerSvc, envVars, err := t.composeServiceDependencies(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	// New containers must join the private network and publish the same
+	// ports as the ones from the last deployment.
+	project := composeProjectForApplication(app, applicationRepoPath(app))
+	if _, err := t.attachComposePrivateNetwork(ctx, app, &project); err != nil {
+		return nil, err
+	}
+	if err := t.attachComposePorts(ctx, app, &project); err != nil {
+		return nil, err
+	}
+	if _, err := dockerSvc.ComposeScaleService(project, svc.ServiceName, replicas, envVars); err != nil {
+		t.Logger.Log(logger.Error, "failed to scale compose service "+svc.ServiceName, err.Error())
+		return nil, err
+	}
+
+	t.attachComposeServiceStatus(dockerSvc, app)
+	return svc, nil
+}
+
+// GetComposeServiceLogs returns the most recent log lines of one service of a compose application.
+func (t *TaskService) GetComposeServiceLogs(ctx context.Context, app *shared_types.Application, serviceName string, tail int) (string, error) {
+	svc, err := findComposeService(app, serviceName)
+	if err != nil {
+		return "", err
+	}
+
+	if tail <= 0 {
+		tail = DefaultComposeLogTail
+	}
+	if tail > MaxComposeLogTail {
+		tail = MaxComposeLogTail
+	}
+
+	dockerSvc, err := docker.GetDockerServiceForOrganization(ctx, app.OrganizationID)
+	if err != nil {
+		return "", err
+	}
+
+	if app.IsComposeStack() {
+		swarmSvc, err := stackService(dockerSvc, app, svc.ServiceName)
+		if err != nil {
+			return "", err
+		}
+		return dockerSvc.GetServiceLogs(*swarmSvc, tail)
+	}
+
+	projectDir := composeProjectForApplication(app, applicationRepoPath(app)).Dir()
+	return dockerSvc.GetComposeServiceLogs(projectDir, svc.ServiceName, tail)
+}
+
+// AttachComposeServiceStatus sets the status of every service of a compose
+// application from its containers on the deploy server. Applications of other
+// build packs are left unchanged. The Docker calls are bounded by ctx.
+func (t *TaskService) AttachComposeServiceStatus(ctx context.Context, app *shared_types.Application) error {
+	if app.BuildPack != shared_types.DockerCompose || len(app.ComposeServices) == 0 {
+		return nil
+	}
+
+	// The service is cached and outlives ctx, so it is created without it.
+	dockerSvc, err := docker.GetDockerServiceForOrganization(context.WithoutCancel(ctx), app.OrganizationID)
+	if err != nil {
+		return err
+	}
+	return t.attachComposeServiceStatus(dockerSvc.WithContext(ctx), app)
+}
+
+func (t *TaskService) attachComposeServiceStatus(dockerSvc *docker.DockerService, app *shared_types.Application) error {
+	if app.IsComposeStack() {
+		return t.attachStackServiceStatus(dockerSvc, app)
+	}
+	projectDir := composeProjectForApplication(app, applicationRepoPath(app)).Dir()
+	containers, err := dockerSvc.ListComposeContainers(projectDir)
+	if err != nil {
+		t.Logger.Log(logger.Warning, "failed to list compose containers", err.Error())
+		return err
+	}
+
+	statuses := summarizeComposeServices(containers)
+	for _, svc := range app.ComposeServices {
+		status, ok := statuses[svc.ServiceName]
+		if !ok {
+			status = shared_types.ComposeServiceStatus{State: shared_types.ComposeServiceNotCreated}
+		}
+		svc.Status = &status
+	}
+	return nil
+}
+
+func (t *TaskService) composeServiceDependencies(ctx context.Context, app *shared_types.Application) (*docker.DockerService, map[string]string, error) {
+	envVars, err := t.ResolveEnvironmentVariables(ctx, *app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dockerSvc, err := docker.GetDockerServiceForOrganization(ctx, app.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dockerSvc, envVars, nil
+}
+
+func findComposeService(app *shared_types.Application, serviceName string) (*shared_types.ComposeService, error) {
+	if app.BuildPack != shared_types.DockerCompose {
+		return nil, types.ErrNotComposeApplication
+	}
+	for _, svc := range app.ComposeServices {
+		if svc.ServiceName == serviceName {
+			return svc, nil
+		}
+	}
+	return nil, types.ErrComposeServiceNotFound
+}
+
+// summarizeComposeServices groups compose containers by service and reduces
+// them to a single state per service.
+func summarizeComposeServices(containers []container.Summary) map[string]shared_types.ComposeServiceStatus {
+	type counts struct {
+		total, running, unhealthy, restarting int
+	}
+	byService := make(map[string]*counts)
+	for _, c := range containers {
+		name := c.Labels[docker.ComposeServiceLabel]
+		if name == "" {
+			continue
+		}
+		n, ok := byService[name]
+		if !ok {
+			n = &counts{}
+			byService[name] = n
+		}
+		n.total++
+		switch c.State {
+		case "running":
+			n.running++
+			if strings.Contains(c.Status, "(unhealthy)") {
+				n.unhealthy++
+			}
+		case "restarting":
+			n.restarting++
+		}
+	}
+
+	statuses := make(map[string]shared_types.ComposeServiceStatus, len(byService))
+	for name, n := range byService {
+		status := shared_types.ComposeServiceStatus{Running: n.running, Replicas: n.total}
+		switch {
+		case n.running == n.total && n.unhealthy > 0:
+			status.State = shared_types.ComposeServiceUnhealthy
+		case n.running == n.total:
+			status.State = shared_types.ComposeServiceRunning
+		case n.restarting > 0:
+			status.State = shared_types.ComposeServiceRestarting
+		case n.running > 0:
+			status.State = shared_types.ComposeServiceDegraded
+		default:
+			status.State = shared_types.ComposeServiceStopped
+		}
+		statuses[name] = status
+	}
+	return statuses
+}
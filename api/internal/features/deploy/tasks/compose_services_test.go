@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func composeContainer(service, state, status string) container.Summary {
+	return container.Summary{
+		Labels: map[string]string{docker.ComposeServiceLabel: service},
+		State:  state,
+		Status: status,
+	}
+}
+
+func TestSummarizeComposeServices(t *testing.T) {
+	containers := []container.Summary{
+		composeContainer("web", "running", "Up 5 minutes (healthy)"),
+		composeContainer("worker", "running", "Up 2 minutes"),
+		composeContainer("worker", "exited", "Exited (1) 10 seconds ago"),
+		composeContainer("api", "running", "Up 1 minute (unhealthy)"),
+		composeContainer("cron", "exited", "Exited (0) 1 hour ago"),
+		composeContainer("queue", "restarting", "Restarting (1) 3 seconds ago"),
+		{Labels: map[string]string{}, State: "running"},
+	}
+
+	got := summarizeComposeServices(containers)
+
+	want := map[string]shared_types.ComposeServiceStatus{
+		"web":    {State: shared_types.ComposeServiceRunning, Running: 1, Replicas: 1},
+		"worker": {State: shared_types.ComposeServiceDegraded, Running: 1, Replicas: 2},
+		"api":    {State: shared_types.ComposeServiceUnhealthy, Running: 1, Replicas: 1},
+		"cron":   {State: shared_types.ComposeServiceStopped, Running: 0, Replicas: 1},
+		"queue":  {State: shared_types.ComposeServiceRestarting, Running: 0, Replicas: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d services, want %d: %v", len(got), len(want), got)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %+v, want %+v", name, got[name], w)
+		}
+	}
+}
+
+func TestFindComposeService(t *testing.T) {
+	app := &shared_types.Application{
+		BuildPack:       shared_types.DockerCompose,
+		ComposeServices: []*shared_types.ComposeService{{ServiceName: "web"}, {ServiceName: "worker"}},
+	}
+
+	svc, err := findComposeService(app, "worker")
+	if err != nil || svc.ServiceName != "worker" {
+		t.Fatalf("findComposeService(worker) = %v, %v", svc, err)
+	}
+	if _, err := findComposeService(app, "db"); err == nil {
+		t.Error("expected an error for an unknown service")
+	}
+
+	app.BuildPack = shared_types.DockerFile
+	if _, err := findComposeService(app, "web"); err == nil {
+		t.Error("expected an error for a non compose application")
+	}
+}
@@ -0,0 +1,325 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/joho/godotenv"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/pkg/sftp"
+)
+
+// deployComposeStack deploys a compose application as a Swarm stack. Create,
+// redeploy, update and rollback all run docker stack deploy, which performs a
+// rolling update of the services that changed. Restart forces a rolling
+// restart of every service without touching their spec.
+func (t *TaskService) deployComposeStack(ctx context.Context, TaskPayload shared_types.TaskPayload, deploymentType shared_types.DeploymentType, project docker.ComposeProject, envVars map[string]string, outputCallback func(string), taskCtx *TaskContext) error {
+	app := &TaskPayload.Application
+	stackName := app.ComposeStackName()
+
+	dockerSvc, err := t.getStackDockerService(ctx)
+	if err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to get docker service: "+err.Error(), shared_types.Failed)
+		return err
+	}
+
+	if deploymentType == shared_types.DeploymentTypeRestart {
+		if err := t.restartStack(dockerSvc, stackName, taskCtx); err != nil {
+			taskCtx.LogAndUpdateStatus("Failed to restart stack services: "+err.Error(), shared_types.Failed)
+			return err
+		}
+		return nil
+	}
+
+	files, stackEnv, err := t.prepareStackFiles(ctx, project, envVars, taskCtx)
+	if err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to prepare stack files: "+err.Error(), shared_types.Failed)
+		return err
+	}
+
+	taskCtx.AddLog("Deploying Swarm stack " + stackName)
+	if _, err := dockerSvc.StackDeploy(stackName, files, stackEnv, outputCallback); err != nil {
+		taskCtx.LogAndUpdateStatus("Failed to deploy stack: "+err.Error(), shared_types.Failed)
+		return err
+	}
+	taskCtx.AddLog("Swarm stack " + stackName + " deployed successfully")
+
+	if err := t.syncStackServices(ctx, dockerSvc, TaskPayload, taskCtx); err != nil {
+		taskCtx.AddLog("Warning: failed to record stack services: " + err.Error())
+	}
+	return nil
+}
+
+func (t *TaskService) getStackDockerService(ctx context.Context) (*docker.DockerService, error) {
+	dockerSvc, err := t.getDockerService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ds, ok := dockerSvc.(*docker.DockerService)
+	if !ok {
+		return nil, fmt.Errorf("stack deployments are not supported by this docker service")
+	}
+	return ds, nil
+}
+
+func (t *TaskService) restartStack(dockerSvc *docker.DockerService, stackName string, taskCtx *TaskContext) error {
+	services, err := dockerSvc.ListStackServices(stackName)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("stack %s has no services, deploy it first", stackName)
+	}
+	for _, svc := range services {
+		taskCtx.AddLog("Restarting stack service " + svc.Spec.Name)
+		if err := dockerSvc.ForceUpdateService(svc.ID); err != nil {
+			return err
+		}
+	}
+	taskCtx.AddLog("Stack services restarted successfully")
+	return nil
+}
+
+// prepareStackFiles writes a stack-compatible copy of every compose file next
+// to the base file and returns their paths. docker stack deploy has no
+// --env-file flag, so the env files are read and merged under the
+// application's variables for interpolation.
+func (t *TaskService) prepareStackFiles(ctx context.Context, project docker.ComposeProject, envVars map[string]string, taskCtx *TaskContext) ([]string, map[string]string, error) {
+	var files []string
+	stackEnv := make(map[string]string)
+
+	err := utils.WithSFTPClientFromPool(ctx, func(client *sftp.Client) error {
+		sources := make([][]byte, len(project.Files))
+		for i, f := range project.Files {
+			data, err := readRemoteFile(client, f)
+			if err != nil {
+				return fmt.Errorf("failed to read compose file %s: %w", f, err)
+			}
+			sources[i] = data
+		}
+
+		translated, warnings, err := TranslateComposeForStack(sources, project.Profiles)
+		if err != nil {
+			return err
+		}
+		for _, w := range warnings {
+			taskCtx.AddLog("Warning: " + w)
+		}
+
+		for i, data := range translated {
+			path := filepath.Join(project.Dir(), fmt.Sprintf(".nixopus-stack-%d.yml", i))
+			if err := writeRemoteFile(client, path, data); err != nil {
+				return fmt.Errorf("failed to write stack file: %w", err)
+			}
+			files = append(files, path)
+		}
+
+		for _, f := range project.EnvFiles {
+			data, err := readRemoteFile(client, f)
+			if err != nil {
+				return fmt.Errorf("failed to read env file %s: %w", f, err)
+			}
+			values, err := godotenv.Unmarshal(string(data))
+			if err != nil {
+				return fmt.Errorf("failed to parse env file %s: %w", f, err)
+			}
+			for k, v := range values {
+				stackEnv[k] = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for k, v := range envVars {
+		stackEnv[k] = v
+	}
+	return files, stackEnv, nil
+}
+
+func readRemoteFile(client *sftp.Client, path string) ([]byte, error) {
+	f, err := client.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func writeRemoteFile(client *sftp.Client, path string, data []byte) error {
+	f, err := client.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
+
+// syncStackServices records the services of the deployed stack as the
+// application's compose services, with the port Swarm published for each.
+func (t *TaskService) syncStackServices(ctx context.Context, dockerSvc *docker.DockerService, TaskPayload shared_types.TaskPayload, taskCtx *TaskContext) error {
+	stackName := TaskPayload.Application.ComposeStackName()
+	swarmServices, err := dockerSvc.ListStackServices(stackName)
+	if err != nil {
+		return err
+	}
+	return t.persistComposeServices(ctx, TaskPayload.Application.ID, stackComposeServices(stackName, swarmServices), taskCtx)
+}
+
+// stackComposeServices converts the Swarm services of a stack to compose
+// services. The stack prefix is removed from the service names.
+func stackComposeServices(stackName string, swarmServices []swarm.Service) []shared_types.ComposeService {
+	var services []shared_types.ComposeService
+	for _, svc := range swarmServices {
+		name := strings.TrimPrefix(svc.Spec.Name, stackName+"_")
+		port := 0
+		for _, p := range svc.Endpoint.Ports {
+			if p.PublishedPort > 0 {
+				port = int(p.PublishedPort)
+				break
+			}
+		}
+		services = append(services, shared_types.ComposeService{ServiceName: name, Port: port})
+	}
+	return services
+}
+
+// stackServiceStatus summarizes the tasks of a Swarm service.
+func stackServiceStatus(running, desired int) shared_types.ComposeServiceStatus {
+	status := shared_types.ComposeServiceStatus{Running: running, Replicas: desired}
+	switch {
+	case desired == 0:
+		status.State = shared_types.ComposeServiceStopped
+	case running >= desired:
+		status.State = shared_types.ComposeServiceRunning
+	case running > 0:
+		status.State = shared_types.ComposeServiceDegraded
+	default:
+		status.State = shared_types.ComposeServiceRestarting
+	}
+	return status
+}
+
+func (t *TaskService) attachStackServiceStatus(dockerSvc *docker.DockerService, app *shared_types.Application) error {
+	swarmServices, err := dockerSvc.ListStackServices(app.ComposeStackName())
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]swarm.Service, len(swarmServices))
+	for _, svc := range swarmServices {
+		byName[svc.Spec.Name] = svc
+	}
+
+	for _, svc := range app.ComposeServices {
+		swarmSvc, ok := byName[app.StackServiceName(svc.ServiceName)]
+		if !ok {
+			svc.Status = &shared_types.ComposeServiceStatus{State: shared_types.ComposeServiceNotCreated}
+			continue
+		}
+		running, desired, err := dockerSvc.GetServiceHealth(swarmSvc)
+		if err != nil {
+			return err
+		}
+		if swarmSvc.Spec.Mode.Global != nil && running > 0 {
+			// Global services run one task per eligible node and have no replica count.
+			desired = running
+		}
+		status := stackServiceStatus(running, desired)
+		svc.Status = &status
+	}
+	return nil
+}
+
+// stackService returns the Swarm service of a compose service deployed as part of a stack.
+func stackService(dockerSvc *docker.DockerService, app *shared_types.Application, serviceName string) (*swarm.Service, error) {
+	svc, err := dockerSvc.GetServiceByName(app.StackServiceName(serviceName))
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, types.ErrComposeServiceNotFound
+	}
+	return svc, nil
+}
+
+func (t *TaskService) runStackServiceAction(dockerSvc *docker.DockerService, app *shared_types.Application, serviceName string, action ComposeServiceAction) error {
+	svc, err := stackService(dockerSvc, app, serviceName)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case ComposeServiceActionRestart:
+		return dockerSvc.ForceUpdateService(svc.ID)
+	case ComposeServiceActionStop:
+		if svc.Spec.Mode.Replicated == nil {
+			return types.ErrGlobalServiceNotScalable
+		}
+		if replicas := serviceReplicas(svc); replicas > 0 {
+			spec := stoppedServiceSpec(svc.Spec, replicas)
+			return dockerSvc.UpdateService(svc.ID, spec, "")
+		}
+		return nil
+	case ComposeServiceActionStart:
+		if svc.Spec.Mode.Replicated == nil {
+			return types.ErrGlobalServiceNotScalable
+		}
+		if serviceReplicas(svc) > 0 {
+			return nil
+		}
+		return dockerSvc.UpdateService(svc.ID, startedServiceSpec(svc.Spec), "")
+	default:
+		return fmt.Errorf("unknown compose service action: %s", action)
+	}
+}
+
+// stoppedReplicasLabel keeps the replica count of a stack service stopped
+// through a compose service action, so starting it brings all of them back.
+const stoppedReplicasLabel = "nixopus.stopped_replicas"
+
+// stoppedServiceSpec scales spec to zero and remembers replicas.
+func stoppedServiceSpec(spec swarm.ServiceSpec, replicas uint64) swarm.ServiceSpec {
+	spec.Labels = maps.Clone(spec.Labels)
+	if spec.Labels == nil {
+		spec.Labels = make(map[string]string)
+	}
+	spec.Labels[stoppedReplicasLabel] = strconv.FormatUint(replicas, 10)
+	mode := *spec.Mode.Replicated
+	mode.Replicas = new(uint64)
+	spec.Mode.Replicated = &mode
+	return spec
+}
+
+// startedServiceSpec scales spec back to the replicas it had when stopped,
+// or to one replica when it was scaled down another way.
+func startedServiceSpec(spec swarm.ServiceSpec) swarm.ServiceSpec {
+	replicas := uint64(1)
+	if n, err := strconv.ParseUint(spec.Labels[stoppedReplicasLabel], 10, 64); err == nil && n > 0 {
+		replicas = n
+	}
+	spec.Labels = maps.Clone(spec.Labels)
+	delete(spec.Labels, stoppedReplicasLabel)
+	mode := *spec.Mode.Replicated
+	mode.Replicas = &replicas
+	spec.Mode.Replicated = &mode
+	return spec
+}
+
+func scaleStackService(dockerSvc *docker.DockerService, svc *swarm.Service, replicas int) error {
+	if svc.Spec.Mode.Replicated == nil {
+		return types.ErrGlobalServiceNotScalable
+	}
+	return dockerSvc.ScaleService(svc.ID, uint64(replicas), "")
+}
@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTranslateComposeForStack(t *testing.T) {
+	base := []byte(`
+name: shop
+services:
+  web:
+    build: .
+    container_name: shop-web
+    restart: on-failure:3
+    mem_limit: 512m
+    depends_on:
+      db:
+        condition: service_healthy
+    ports: ["8080:80"]
+  db:
+    image: postgres:16
+    restart: unless-stopped
+  debug:
+    image: busybox
+    profiles: ["debug"]
+`)
+	override := []byte(`
+services:
+  web:
+    image: registry.example.com/shop/web:latest
+  debug:
+    command: ["sleep", "infinity"]
+`)
+
+	out, warnings, err := TranslateComposeForStack([][]byte{base, override}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("got %d files, want 2", len(out))
+	}
+
+	var doc struct {
+		Name     string                            `yaml:"name"`
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(out[0], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "" {
+		t.Error("top-level name should be removed")
+	}
+	if _, ok := doc.Services["debug"]; ok {
+		t.Error("debug should be skipped, its profile is not enabled")
+	}
+	web := doc.Services["web"]
+	for _, key := range []string{"build", "container_name", "restart", "mem_limit", "depends_on"} {
+		if _, ok := web[key]; ok {
+			t.Errorf("web still has %s", key)
+		}
+	}
+	deploy := web["deploy"].(map[string]interface{})
+	policy := deploy["restart_policy"].(map[string]interface{})
+	if policy["condition"] != "on-failure" || policy["max_attempts"] != 3 {
+		t.Errorf("web restart_policy = %v", policy)
+	}
+	limits := deploy["resources"].(map[string]interface{})["limits"].(map[string]interface{})
+	if limits["memory"] != "512m" {
+		t.Errorf("web memory limit = %v", limits["memory"])
+	}
+	dbPolicy := doc.Services["db"]["deploy"].(map[string]interface{})["restart_policy"].(map[string]interface{})
+	if dbPolicy["condition"] != "any" {
+		t.Errorf("db restart condition = %v, want any", dbPolicy["condition"])
+	}
+
+	if err := yaml.Unmarshal(out[1], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Services["debug"]; ok {
+		t.Error("debug should be skipped in the override file too")
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"build was ignored", "container_name was removed", "depends_on was removed", "service debug was skipped"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings do not mention %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestTranslateComposeForStackRequiresImage(t *testing.T) {
+	_, _, err := TranslateComposeForStack([][]byte{[]byte("services:\n  web:\n    build: .\n")}, nil)
+	if !errors.Is(err, types.ErrComposeStackImageRequired) {
+		t.Fatalf("err = %v, want ErrComposeStackImageRequired", err)
+	}
+}
+
+func TestStackComposeServices(t *testing.T) {
+	services := stackComposeServices("nixopus-abc", []swarm.Service{
+		{
+			Spec:     swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "nixopus-abc_web"}},
+			Endpoint: swarm.Endpoint{Ports: []swarm.PortConfig{{TargetPort: 80, PublishedPort: 8080}}},
+		},
+		{Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "nixopus-abc_worker"}}},
+	})
+
+	want := []shared_types.ComposeService{{ServiceName: "web", Port: 8080}, {ServiceName: "worker", Port: 0}}
+	if len(services) != len(want) {
+		t.Fatalf("got %d services, want %d", len(services), len(want))
+	}
+	for i, w := range want {
+		if services[i].ServiceName != w.ServiceName || services[i].Port != w.Port {
+			t.Errorf("service %d = %s:%d, want %s:%d", i, services[i].ServiceName, services[i].Port, w.ServiceName, w.Port)
+		}
+	}
+}
+
+func TestStackServiceStatus(t *testing.T) {
+	tests := []struct {
+		running, desired int
+		want             shared_types.ComposeServiceState
+	}{
+		{2, 2, shared_types.ComposeServiceRunning},
+		{1, 2, shared_types.ComposeServiceDegraded},
+		{0, 2, shared_types.ComposeServiceRestarting},
+		{0, 0, shared_types.ComposeServiceStopped},
+	}
+	for _, tt := range tests {
+		if got := stackServiceStatus(tt.running, tt.desired).State; got != tt.want {
+			t.Errorf("stackServiceStatus(%d, %d) = %s, want %s", tt.running, tt.desired, got, tt.want)
+		}
+	}
+}
+
+func TestStoppedServiceSpecRestoresReplicas(t *testing.T) {
+	replicas := uint64(3)
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Labels: map[string]string{"com.docker.stack.namespace": "app"}},
+		Mode:        swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+	}
+
+	stopped := stoppedServiceSpec(spec, replicas)
+	if *stopped.Mode.Replicated.Replicas != 0 || stopped.Labels[stoppedReplicasLabel] != "3" {
+		t.Fatalf("stopped spec = %d replicas, labels %v", *stopped.Mode.Replicated.Replicas, stopped.Labels)
+	}
+	if *spec.Mode.Replicated.Replicas != 3 || spec.Labels[stoppedReplicasLabel] != "" {
+		t.Error("stopping should not change the original spec")
+	}
+
+	started := startedServiceSpec(stopped)
+	if *started.Mode.Replicated.Replicas != 3 {
+		t.Errorf("started with %d replicas, want 3", *started.Mode.Replicated.Replicas)
+	}
+	if _, ok := started.Labels[stoppedReplicasLabel]; ok || started.Labels["com.docker.stack.namespace"] != "app" {
+		t.Errorf("started labels = %v", started.Labels)
+	}
+
+	zero := uint64(0)
+	scaledDown := swarm.ServiceSpec{Mode: swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &zero}}}
+	if got := *startedServiceSpec(scaledDown).Mode.Replicated.Replicas; got != 1 {
+		t.Errorf("service stopped another way started with %d replicas, want 1", got)
+	}
+}
@@ -0,0 +1,230 @@
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"gopkg.in/yaml.v3"
+)
+
+// stackUnsupportedServiceKeys are compose service keys that docker stack
+// deploy ignores or rejects. They are removed from the stack file.
+var stackUnsupportedServiceKeys = map[string]string{
+	"container_name": "Swarm names the tasks of a service itself",
+	"links":          "services reach each other by name on the stack network",
+	"external_links": "services reach each other by name on the stack network",
+	"network_mode":   "Swarm services always run on overlay networks",
+	"devices":        "Swarm services cannot access host devices",
+	"privileged":     "Swarm services cannot run privileged",
+	"cgroup_parent":  "it is not supported by Swarm",
+	"userns_mode":    "it is not supported by Swarm",
+	"security_opt":   "it is not supported by Swarm",
+	"cpu_shares":     "use deploy.resources instead",
+	"cpuset":         "use deploy.resources instead",
+	"volumes_from":   "volumes must be declared on every service that uses them",
+	"pull_policy":    "Swarm always resolves the image from its registry",
+}
+
+// TranslateComposeForStack rewrites compose files so they can be passed to
+// docker stack deploy. Keys with a Swarm equivalent are moved under deploy,
+// unsupported keys are removed, and services whose profiles are not enabled
+// are dropped. Every change is reported as a warning.
+//
+// Files are translated together because a service can be split across a base
+// file and its overrides. The result has one document per input file.
+func TranslateComposeForStack(files [][]byte, profiles []string) ([][]byte, []string, error) {
+	docs := make([]map[string]interface{}, len(files))
+	serviceProfiles := make(map[string][]string)
+	serviceImages := make(map[string]bool)
+	for i, data := range files {
+		doc := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+		}
+		docs[i] = doc
+		for name, svc := range stackServices(doc) {
+			for _, p := range stringList(svc["profiles"]) {
+				if !slices.Contains(serviceProfiles[name], p) {
+					serviceProfiles[name] = append(serviceProfiles[name], p)
+				}
+			}
+			if image, ok := svc["image"].(string); ok && image != "" {
+				serviceImages[name] = true
+			}
+		}
+	}
+
+	var warnings []string
+	warned := make(map[string]bool)
+	warn := func(msg string) {
+		if !warned[msg] {
+			warned[msg] = true
+			warnings = append(warnings, msg)
+		}
+	}
+
+	out := make([][]byte, len(docs))
+	for i, doc := range docs {
+		if _, ok := doc["name"]; ok {
+			delete(doc, "name")
+			warn("top-level name was removed, the stack is named by Nixopus")
+		}
+
+		services := stackServices(doc)
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			svc := services[name]
+			if p := serviceProfiles[name]; len(p) > 0 && !slices.ContainsFunc(p, func(s string) bool { return slices.Contains(profiles, s) }) {
+				delete(doc["services"].(map[string]interface{}), name)
+				warn(fmt.Sprintf("service %s was skipped, none of its profiles (%s) are enabled", name, strings.Join(p, ", ")))
+				continue
+			}
+			delete(svc, "profiles")
+
+			if err := translateStackService(name, svc, serviceImages[name], warn); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to write stack file: %w", err)
+		}
+		out[i] = data
+	}
+	return out, warnings, nil
+}
+
+func translateStackService(name string, svc map[string]interface{}, hasImage bool, warn func(string)) error {
+	if _, ok := svc["build"]; ok {
+		if !hasImage {
+			return fmt.Errorf("service %s: %w", name, types.ErrComposeStackImageRequired)
+		}
+		delete(svc, "build")
+		warn(fmt.Sprintf("service %s: build was ignored, its image must be pushed to a registry every node can pull from", name))
+	}
+
+	keys := make([]string, 0, len(stackUnsupportedServiceKeys))
+	for key := range stackUnsupportedServiceKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := svc[key]; ok {
+			delete(svc, key)
+			warn(fmt.Sprintf("service %s: %s was removed, %s", name, key, stackUnsupportedServiceKeys[key]))
+		}
+	}
+
+	if _, ok := svc["depends_on"]; ok {
+		delete(svc, "depends_on")
+		warn(fmt.Sprintf("service %s: depends_on was removed, Swarm does not order service startup", name))
+	}
+
+	if restart, ok := svc["restart"]; ok {
+		delete(svc, "restart")
+		policy := nestedMap(svc, "deploy", "restart_policy")
+		if _, set := policy["condition"]; !set {
+			condition, attempts := stackRestartCondition(fmt.Sprint(restart))
+			policy["condition"] = condition
+			if attempts > 0 {
+				policy["max_attempts"] = attempts
+			}
+			warn(fmt.Sprintf("service %s: restart %q was translated to deploy.restart_policy.condition %q", name, restart, condition))
+		}
+	}
+
+	if scale, ok := svc["scale"]; ok {
+		delete(svc, "scale")
+		deploy := nestedMap(svc, "deploy")
+		if _, set := deploy["replicas"]; !set {
+			deploy["replicas"] = scale
+			warn(fmt.Sprintf("service %s: scale was translated to deploy.replicas", name))
+		}
+	}
+
+	resources := []struct{ key, section, field string }{
+		{"cpus", "limits", "cpus"},
+		{"mem_limit", "limits", "memory"},
+		{"mem_reservation", "reservations", "memory"},
+	}
+	for _, r := range resources {
+		value, ok := svc[r.key]
+		if !ok {
+			continue
+		}
+		delete(svc, r.key)
+		target := nestedMap(svc, "deploy", "resources", r.section)
+		if _, set := target[r.field]; !set {
+			target[r.field] = fmt.Sprint(value)
+			warn(fmt.Sprintf("service %s: %s was translated to deploy.resources.%s.%s", name, r.key, r.section, r.field))
+		}
+	}
+	return nil
+}
+
+// stackRestartCondition maps a compose restart policy to a Swarm restart
+// condition and its maximum number of attempts.
+func stackRestartCondition(restart string) (string, int) {
+	switch {
+	case restart == "no":
+		return "none", 0
+	case strings.HasPrefix(restart, "on-failure"):
+		attempts := 0
+		if _, n, ok := strings.Cut(restart, ":"); ok {
+			attempts, _ = strconv.Atoi(n)
+		}
+		return "on-failure", attempts
+	default:
+		return "any", 0
+	}
+}
+
+// stackServices returns the service definitions of a compose document keyed by name.
+func stackServices(doc map[string]interface{}) map[string]map[string]interface{} {
+	services := make(map[string]map[string]interface{})
+	raw, _ := doc["services"].(map[string]interface{})
+	for name, svc := range raw {
+		if m, ok := svc.(map[string]interface{}); ok {
+			services[name] = m
+		} else {
+			m := map[string]interface{}{}
+			raw[name] = m
+			services[name] = m
+		}
+	}
+	return services
+}
+
+// nestedMap returns the map at path below m, creating missing levels.
+func nestedMap(m map[string]interface{}, path ...string) map[string]interface{} {
+	for _, key := range path {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	return m
+}
+
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -18,6 +18,9 @@ type ContextTask struct {
 	UserId         uuid.UUID
 	OrganizationId uuid.UUID
 	Application    *shared_types.Application
+	// restoredEnvRevision is set when the deployment restores the environment
+	// variables of an earlier revision (rollback).
+	restoredEnvRevision *int
 }
 
 const (
@@ -50,11 +53,31 @@ func (c *ContextTask) GetApplicationData(
 		source = shared_types.SourceGithub
 	}
 
+	composeDeployMode := deployment.ComposeDeployMode
+	if composeDeployMode == "" {
+		composeDeployMode = shared_types.ComposeDeployModeCompose
+	}
+
+	privateNetworkScope := deployment.PrivateNetworkScope
+	if privateNetworkScope == "" {
+		privateNetworkScope = shared_types.PrivateNetworkOrganization
+	}
+
+	sbomFormat := deployment.SBOMFormat
+	if sbomFormat == "" {
+		sbomFormat = shared_types.SBOMFormatNone
+	}
+
+	vulnThreshold := deployment.VulnThreshold
+	if vulnThreshold == "" {
+		vulnThreshold = shared_types.SeverityNone
+	}
+
 	application := shared_types.Application{
 		ID:                   uuid.New(),
 		Name:                 deployment.Name,
 		BuildVariables:       GetStringFromMap(deployment.BuildVariables),
-		EnvironmentVariables: GetStringFromMap(deployment.EnvironmentVariables),
+		EnvironmentVariables: shared_types.EncryptedString(GetStringFromMap(deployment.EnvironmentVariables)),
 		Environment:          deployment.Environment,
 		BuildPack:            deployment.BuildPack,
 		Repository:           deployment.Repository,
@@ -67,6 +90,14 @@ func (c *ContextTask) GetApplicationData(
 		UpdatedAt:            time.Now(),
 		DockerfilePath:       deployment.DockerfilePath,
 		BasePath:             deployment.BasePath,
+		ComposeFiles:         deployment.ComposeFiles,
+		ComposeProfiles:      deployment.ComposeProfiles,
+		ComposeEnvFiles:      deployment.ComposeEnvFiles,
+		ComposeDeployMode:    composeDeployMode,
+		PrivateNetworkScope:  privateNetworkScope,
+		SBOMFormat:           sbomFormat,
+		VulnThreshold:        vulnThreshold,
+		InternalAlias:        shared_types.InternalAliasFromName(deployment.Name),
 		OrganizationID:       c.OrganizationId,
 		Source:               source,
 	}
@@ -95,14 +126,20 @@ func (c *ContextTask) GetDeploymentConfig(applicationID uuid.UUID) shared_types.
 
 // PersistCreateApplicationDeploymentData atomically persists the application and
 // deployment data within a single transaction to prevent orphaned records.
-func (c *ContextTask) PersistCreateApplicationDeploymentData(application shared_types.Application, applicationDeployment shared_types.ApplicationDeployment) error {
+func (c *ContextTask) PersistCreateApplicationDeploymentData(application shared_types.Application, applicationDeployment *shared_types.ApplicationDeployment) error {
 	return c.TaskService.Storage.RunInTransaction(func(tx bun.Tx) error {
 		ctx := context.Background()
+		if err := c.TaskService.Storage.AssignInternalAlias(tx, &application); err != nil {
+			return err
+		}
 		if _, err := tx.NewInsert().Model(&application).Exec(ctx); err != nil {
 			c.TaskService.Logger.Log(logger.Error, types.LogFailedToCreateApplicationRecord+err.Error(), "")
 			return err
 		}
-		if _, err := tx.NewInsert().Model(&applicationDeployment).Exec(ctx); err != nil {
+		if err := c.attachEnvRevision(tx, application, applicationDeployment); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(applicationDeployment).Exec(ctx); err != nil {
 			c.TaskService.Logger.Log(logger.Error, types.LogFailedToCreateApplicationDeployment+err.Error(), "")
 			return err
 		}
@@ -110,14 +147,30 @@ func (c *ContextTask) PersistCreateApplicationDeploymentData(application shared_
 	})
 }
 
-func (c *ContextTask) PersistUpdateApplicationDeploymentData(application shared_types.Application, applicationDeployment shared_types.ApplicationDeployment) error {
+func (c *ContextTask) PersistUpdateApplicationDeploymentData(application shared_types.Application, applicationDeployment *shared_types.ApplicationDeployment) error {
 	return c.TaskService.Storage.RunInTransaction(func(tx bun.Tx) error {
 		ctx := context.Background()
+		// A changed private network scope may put the alias next to another application's.
+		if err := c.TaskService.Storage.AssignInternalAlias(tx, &application); err != nil {
+			return err
+		}
 		if _, err := tx.NewUpdate().Model(&application).OmitZero().WherePK().Exec(ctx); err != nil {
 			c.TaskService.Logger.Log(logger.Error, types.LogFailedToUpdateApplicationRecord+err.Error(), "")
 			return err
 		}
-		if _, err := tx.NewInsert().Model(&applicationDeployment).Exec(ctx); err != nil {
+		// OmitZero skips an emptied variable set or compose option list, so write those columns explicitly.
+		if err := c.TaskService.Storage.UpdateApplicationEnvironmentVariables(tx, application.ID, application.EnvironmentVariables); err != nil {
+			c.TaskService.Logger.Log(logger.Error, types.LogFailedToUpdateApplicationRecord+err.Error(), "")
+			return err
+		}
+		if _, err := tx.NewUpdate().Model(&application).Column("compose_files", "compose_profiles", "compose_env_files").WherePK().Exec(ctx); err != nil {
+			c.TaskService.Logger.Log(logger.Error, types.LogFailedToUpdateApplicationRecord+err.Error(), "")
+			return err
+		}
+		if err := c.attachEnvRevision(tx, application, applicationDeployment); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(applicationDeployment).Exec(ctx); err != nil {
 			c.TaskService.Logger.Log(logger.Error, types.LogFailedToUpdateApplicationDeployment+err.Error(), "")
 			return err
 		}
@@ -125,6 +178,23 @@ func (c *ContextTask) PersistUpdateApplicationDeploymentData(application shared_
 	})
 }
 
+// attachEnvRevision records the application's current environment variables as
+// a revision (reusing the latest one when unchanged) and links it to the deployment.
+func (c *ContextTask) attachEnvRevision(tx bun.Tx, application shared_types.Application, applicationDeployment *shared_types.ApplicationDeployment) error {
+	var createdBy *uuid.UUID
+	if c.UserId != uuid.Nil {
+		userID := c.UserId
+		createdBy = &userID
+	}
+	revision, err := c.TaskService.Storage.RecordEnvRevision(tx, application.ID, application.EnvironmentVariables, createdBy, c.restoredEnvRevision)
+	if err != nil {
+		c.TaskService.Logger.Log(logger.Error, types.LogFailedToRecordEnvRevision+err.Error(), "")
+		return err
+	}
+	applicationDeployment.EnvRevisionID = &revision.ID
+	return nil
+}
+
 // PersistApplicationDeploymentStatus creates and persists the initial application deployment status.
 // It returns the created status record or an error if the operation fails.
 func (c *ContextTask) PersistCreateDeploymentStatus(applicationDeployment shared_types.ApplicationDeployment) (*shared_types.ApplicationDeploymentStatus, error) {
@@ -163,7 +233,7 @@ func (c *ContextTask) PrepareCreateDeploymentContext() (shared_types.TaskPayload
 	deployment := c.ContextConfig.(*types.CreateDeploymentRequest)
 	application := c.GetApplicationData(deployment, &now)
 	applicationDeployment := c.GetDeploymentConfig(application.ID)
-	err := c.PersistCreateApplicationDeploymentData(application, applicationDeployment)
+	err := c.PersistCreateApplicationDeploymentData(application, &applicationDeployment)
 	if err != nil {
 		return shared_types.TaskPayload{}, err
 	}
@@ -224,7 +294,7 @@ func (c *ContextTask) PrepareCreateDeploymentContext() (shared_types.TaskPayload
 func (c *ContextTask) PrepareUpdateDeploymentContext() (shared_types.TaskPayload, error) {
 	application := c.mergeDeploymentUpdates()
 	applicationDeployment := c.GetDeploymentConfig(c.Application.ID)
-	err := c.PersistUpdateApplicationDeploymentData(application, applicationDeployment)
+	err := c.PersistUpdateApplicationDeploymentData(application, &applicationDeployment)
 	if err != nil {
 		return shared_types.TaskPayload{}, err
 	}
@@ -269,7 +339,7 @@ func (c *ContextTask) mergeDeploymentUpdates() shared_types.Application {
 	}
 
 	if deployment.EnvironmentVariables != nil {
-		application.EnvironmentVariables = GetStringFromMap(deployment.EnvironmentVariables)
+		application.EnvironmentVariables = shared_types.EncryptedString(GetStringFromMap(deployment.EnvironmentVariables))
 	}
 
 	if deployment.PreRunCommand != "" {
@@ -294,6 +364,34 @@ func (c *ContextTask) mergeDeploymentUpdates() shared_types.Application {
 		application.BasePath = deployment.BasePath
 	}
 
+	if deployment.ComposeFiles != nil {
+		application.ComposeFiles = deployment.ComposeFiles
+	}
+
+	if deployment.ComposeProfiles != nil {
+		application.ComposeProfiles = deployment.ComposeProfiles
+	}
+
+	if deployment.ComposeEnvFiles != nil {
+		application.ComposeEnvFiles = deployment.ComposeEnvFiles
+	}
+
+	if deployment.ComposeDeployMode != "" {
+		application.ComposeDeployMode = deployment.ComposeDeployMode
+	}
+
+	if deployment.PrivateNetworkScope != "" {
+		application.PrivateNetworkScope = deployment.PrivateNetworkScope
+	}
+
+	if deployment.SBOMFormat != "" {
+		application.SBOMFormat = deployment.SBOMFormat
+	}
+
+	if deployment.VulnThreshold != "" {
+		application.VulnThreshold = deployment.VulnThreshold
+	}
+
 	application.UpdatedAt = time.Now()
 
 	return *application
@@ -306,7 +404,7 @@ func (c *ContextTask) PrepareReDeploymentContext() (shared_types.TaskPayload, er
 
 	applicationDeployment := c.GetDeploymentConfig(app.ID)
 
-	if err := c.PersistUpdateApplicationDeploymentData(app, applicationDeployment); err != nil {
+	if err := c.PersistUpdateApplicationDeploymentData(app, &applicationDeployment); err != nil {
 		return shared_types.TaskPayload{}, err
 	}
 
@@ -341,12 +439,22 @@ func (c *ContextTask) PrepareRollbackContext() (shared_types.TaskPayload, error)
 	app := *c.Application
 	app.UpdatedAt = time.Now()
 
+	// Restore the environment variables the target deployment ran with.
+	if dep.EnvRevisionID != nil {
+		revision, err := c.TaskService.Storage.GetEnvRevisionByID(app.ID, *dep.EnvRevisionID)
+		if err != nil {
+			return shared_types.TaskPayload{}, err
+		}
+		app.EnvironmentVariables = revision.Variables
+		c.restoredEnvRevision = &revision.Revision
+	}
+
 	applicationDeployment := c.GetDeploymentConfig(app.ID)
 	applicationDeployment.CommitHash = dep.CommitHash
 	applicationDeployment.ImageS3Key = dep.ImageS3Key
 	applicationDeployment.ContainerID = dep.ContainerID
 
-	if err := c.PersistUpdateApplicationDeploymentData(app, applicationDeployment); err != nil {
+	if err := c.PersistUpdateApplicationDeploymentData(app, &applicationDeployment); err != nil {
 		return shared_types.TaskPayload{}, err
 	}
 
@@ -375,7 +483,7 @@ func (c *ContextTask) PrepareRestartContext() (shared_types.TaskPayload, error)
 	app.UpdatedAt = time.Now()
 
 	applicationDeployment := c.GetDeploymentConfig(app.ID)
-	if err := c.PersistUpdateApplicationDeploymentData(app, applicationDeployment); err != nil {
+	if err := c.PersistUpdateApplicationDeploymentData(app, &applicationDeployment); err != nil {
 		return shared_types.TaskPayload{}, err
 	}
 
@@ -406,7 +514,13 @@ func (c *ContextTask) PrepareDeployProjectContext() (shared_types.TaskPayload, e
 	applicationDeployment := c.GetDeploymentConfig(app.ID)
 
 	// For a draft deployment, we add the deployment record (not update the application)
-	if err := c.TaskService.Storage.AddApplicationDeployment(&applicationDeployment); err != nil {
+	if err := c.TaskService.Storage.RunInTransaction(func(tx bun.Tx) error {
+		if err := c.attachEnvRevision(tx, app, &applicationDeployment); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(&applicationDeployment).Exec(context.Background())
+		return err
+	}); err != nil {
 		return shared_types.TaskPayload{}, err
 	}
 
@@ -103,9 +103,9 @@ func (t *TaskService) HandleCreateDockerfileDeployment(ctx context.Context, Task
 	}
 
 	taskCtx.AddLog("Container updated successfully for application " + TaskPayload.Application.Name + " with container id " + containerResult.ContainerID)
-	taskCtx.LogAndUpdateStatus("Deployment completed successfully", shared_types.Deployed)
 
 	if len(TaskPayload.Application.Domains) > 0 {
+		taskCtx.StartPhase(shared_types.PhaseRoute)
 		port, err := strconv.Atoi(containerResult.AvailablePort)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to convert port to int: "+err.Error(), shared_types.Failed)
@@ -140,7 +140,12 @@ func (t *TaskService) HandleCreateDockerfileDeployment(ctx context.Context, Task
 		for _, r := range routes {
 			taskCtx.AddLog("Domain " + r.Domain + " added successfully with TLS")
 		}
+		taskCtx.FinishPhase()
 	}
+
+	// Deployed is terminal for status watchers, so it is only set once the
+	// domains are routed.
+	taskCtx.LogAndUpdateStatus("Deployment completed successfully", shared_types.Deployed)
 	return nil
 }
 
@@ -0,0 +1,534 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+const (
+	databaseBackupActionBackup  = "backup"
+	databaseBackupActionRestore = "restore"
+)
+
+const (
+	// databaseReadyTimeout bounds how long a restore waits for a freshly
+	// provisioned database to accept connections.
+	databaseReadyTimeout  = 3 * time.Minute
+	databaseReadyInterval = 3 * time.Second
+)
+
+// DatabaseBackupTaskPayload is the queued job of a backup or a restore.
+// Scheduled backups only carry the DatabaseID, their record is created when
+// the job runs.
+type DatabaseBackupTaskPayload struct {
+	Action         string `json:"action"`
+	OrganizationID string `json:"organization_id"`
+	DatabaseID     string `json:"database_id,omitempty"`
+	BackupID       string `json:"backup_id,omitempty"`
+	RestoreID      string `json:"restore_id,omitempty"`
+}
+
+// StartDatabaseBackup records an on-demand backup of a database and queues it.
+// Only managed databases can be backed up. Containers that merely run a
+// Postgres or MySQL image are not supported, as their credentials and
+// database names are not known.
+func (t *TaskService) StartDatabaseBackup(databaseID uuid.UUID, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseBackup, error) {
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return nil, types.ErrDatabaseNotFound
+	}
+	if !database.Engine.SupportsBackups() {
+		return nil, types.ErrDatabaseBackupUnsupported
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		return nil, types.ErrS3NotConfigured
+	}
+	active, err := t.Storage.HasActiveDatabaseBackup(database.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, types.ErrDatabaseBackupInProgress
+	}
+
+	backup := newDatabaseBackup(database, shared_types.BackupManual)
+	backup.CreatedBy = &userID
+	if err := t.Storage.CreateDatabaseBackup(backup); err != nil {
+		return nil, err
+	}
+	err = DatabaseBackupQueue.Add(TaskDatabaseBackup.WithArgs(context.Background(), DatabaseBackupTaskPayload{
+		Action:         databaseBackupActionBackup,
+		OrganizationID: organizationID.String(),
+		DatabaseID:     database.ID.String(),
+		BackupID:       backup.ID.String(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue database backup: %w", err)
+	}
+	return backup, nil
+}
+
+// RestoreDatabaseBackup queues loading a backup into the database it was
+// taken of, or into a new database provisioned on the same server with the
+// same engine, version and limits.
+func (t *TaskService) RestoreDatabaseBackup(ctx context.Context, databaseID uuid.UUID, backupID uuid.UUID, request *types.RestoreDatabaseBackupRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.DatabaseRestore, error) {
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return nil, types.ErrDatabaseNotFound
+	}
+	backup, err := t.Storage.GetDatabaseBackup(backupID, organizationID)
+	if err != nil || backup.DatabaseID != database.ID {
+		return nil, types.ErrDatabaseBackupNotFound
+	}
+	if backup.Status != shared_types.BackupSucceeded {
+		return nil, types.ErrDatabaseBackupNotRestorable
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		return nil, types.ErrS3NotConfigured
+	}
+
+	target := database
+	switch request.Target {
+	case "same":
+	case "new":
+		serverID := database.ServerID
+		target, err = t.CreateDatabase(ctx, &types.CreateDatabaseRequest{
+			Name:          request.Name,
+			Engine:        string(database.Engine),
+			Version:       database.Version,
+			ServerID:      &serverID,
+			CPULimit:      database.CPULimit,
+			MemoryLimitMB: database.MemoryLimitMB,
+		}, userID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, types.ErrInvalidRestoreTarget
+	}
+
+	restore := &shared_types.DatabaseRestore{
+		ID:               uuid.New(),
+		BackupID:         backup.ID,
+		SourceDatabaseID: database.ID,
+		TargetDatabaseID: target.ID,
+		OrganizationID:   organizationID,
+		Status:           shared_types.RestorePending,
+		CreatedBy:        userID,
+		CreatedAt:        time.Now(),
+	}
+	if err := t.Storage.CreateDatabaseRestore(restore); err != nil {
+		return nil, err
+	}
+	err = DatabaseBackupQueue.Add(TaskDatabaseBackup.WithArgs(context.Background(), DatabaseBackupTaskPayload{
+		Action:         databaseBackupActionRestore,
+		OrganizationID: organizationID.String(),
+		DatabaseID:     target.ID.String(),
+		BackupID:       backup.ID.String(),
+		RestoreID:      restore.ID.String(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue database restore: %w", err)
+	}
+	return restore, nil
+}
+
+// HandleDatabaseBackup runs a queued backup or restore.
+func (t *TaskService) HandleDatabaseBackup(ctx context.Context, payload DatabaseBackupTaskPayload) error {
+	organizationID, err := uuid.Parse(payload.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("invalid organization id: %w", err)
+	}
+	databaseID, err := uuid.Parse(payload.DatabaseID)
+	if err != nil {
+		return fmt.Errorf("invalid database id: %w", err)
+	}
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", types.ErrDatabaseNotFound, databaseID)
+	}
+
+	switch payload.Action {
+	case databaseBackupActionBackup:
+		return t.runDatabaseBackup(ctx, database, payload.BackupID)
+	case databaseBackupActionRestore:
+		restoreID, err := uuid.Parse(payload.RestoreID)
+		if err != nil {
+			return fmt.Errorf("invalid restore id: %w", err)
+		}
+		restore, err := t.Storage.GetDatabaseRestore(restoreID, organizationID)
+		if err != nil {
+			return types.ErrDatabaseRestoreNotFound
+		}
+		return t.runDatabaseRestore(ctx, database, restore)
+	default:
+		return fmt.Errorf("unknown database backup action %q", payload.Action)
+	}
+}
+
+// StartDatabaseBackupScheduler queues the backups of databases whose
+// schedule matches, once at the start of every minute, until ctx is done.
+// Jobs are named after the database and the minute so that API instances
+// running the scheduler side by side queue each backup once.
+func (t *TaskService) StartDatabaseBackupScheduler(ctx context.Context) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case tick := <-timer.C:
+				t.enqueueScheduledDatabaseBackups(tick.UTC())
+			}
+		}
+	}()
+}
+
+func (t *TaskService) enqueueScheduledDatabaseBackups(now time.Time) {
+	if DatabaseBackupQueue == nil || TaskDatabaseBackup == nil {
+		return
+	}
+	databases, err := t.Storage.GetScheduledBackupDatabases()
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to load scheduled database backups", err.Error())
+		return
+	}
+	minute := now.Truncate(time.Minute)
+	for _, database := range databases {
+		if !database.Engine.SupportsBackups() {
+			continue
+		}
+		schedule, err := ParseBackupSchedule(database.BackupSchedule)
+		if err != nil {
+			t.Logger.Log(logger.Warning, "skipping database with invalid backup schedule", database.ID.String())
+			continue
+		}
+		if !BackupDue(schedule, minute) {
+			continue
+		}
+		msg := TaskDatabaseBackup.WithArgs(context.Background(), DatabaseBackupTaskPayload{
+			Action:         databaseBackupActionBackup,
+			OrganizationID: database.OrganizationID.String(),
+			DatabaseID:     database.ID.String(),
+		})
+		msg.Name = fmt.Sprintf("database-backup-%s-%d", database.ID, minute.Unix())
+		if err := DatabaseBackupQueue.Add(msg); err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+			t.Logger.Log(logger.Error, "failed to enqueue scheduled database backup: "+err.Error(), database.ID.String())
+		}
+	}
+}
+
+func newDatabaseBackup(database *shared_types.Database, trigger shared_types.BackupTrigger) *shared_types.DatabaseBackup {
+	return &shared_types.DatabaseBackup{
+		ID:             uuid.New(),
+		DatabaseID:     database.ID,
+		OrganizationID: database.OrganizationID,
+		Engine:         database.Engine,
+		Status:         shared_types.BackupPending,
+		Trigger:        trigger,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// runDatabaseBackup streams a dump of the database to S3. Scheduled jobs
+// have no backupID and record their backup here, unless one is already in
+// progress.
+func (t *TaskService) runDatabaseBackup(ctx context.Context, database *shared_types.Database, backupID string) error {
+	var backup *shared_types.DatabaseBackup
+	if backupID == "" {
+		active, err := t.Storage.HasActiveDatabaseBackup(database.ID)
+		if err != nil {
+			return err
+		}
+		if active {
+			t.Logger.Log(logger.Info, "skipping scheduled backup, another one is in progress", database.ID.String())
+			return nil
+		}
+		backup = newDatabaseBackup(database, shared_types.BackupScheduled)
+		if err := t.Storage.CreateDatabaseBackup(backup); err != nil {
+			return err
+		}
+	} else {
+		id, err := uuid.Parse(backupID)
+		if err != nil {
+			return fmt.Errorf("invalid backup id: %w", err)
+		}
+		if backup, err = t.Storage.GetDatabaseBackup(id, database.OrganizationID); err != nil {
+			return types.ErrDatabaseBackupNotFound
+		}
+	}
+
+	startedAt := time.Now()
+	backup.Status = shared_types.BackupRunning
+	backup.StartedAt = &startedAt
+	if err := t.Storage.UpdateDatabaseBackup(backup); err != nil {
+		return err
+	}
+
+	key, size, err := t.dumpDatabaseToS3(ctx, database, backup)
+	completedAt := time.Now()
+	backup.CompletedAt = &completedAt
+	if err != nil {
+		backup.Status = shared_types.BackupFailed
+		backup.Error = err.Error()
+		if updateErr := t.Storage.UpdateDatabaseBackup(backup); updateErr != nil {
+			t.Logger.Log(logger.Error, "failed to update database backup", updateErr.Error())
+		}
+		t.emitDatabaseBackupFailed(database, backup, err)
+		return err
+	}
+
+	backup.Status = shared_types.BackupSucceeded
+	backup.S3Key = key
+	backup.SizeBytes = size
+	if err := t.Storage.UpdateDatabaseBackup(backup); err != nil {
+		return err
+	}
+	t.applyDatabaseBackupRetention(ctx, database)
+	return nil
+}
+
+// dumpDatabaseToS3 runs the engine's dump tool inside the database's
+// container over SSH and streams its gzipped output to S3, like
+// ExportImageToS3 does for images.
+func (t *TaskService) dumpDatabaseToS3(ctx context.Context, database *shared_types.Database, backup *shared_types.DatabaseBackup) (string, int64, error) {
+	if !database.Engine.SupportsBackups() {
+		return "", 0, types.ErrDatabaseBackupUnsupported
+	}
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create S3 image store: %w", err)
+	}
+	_, containerID, err := databaseContainer(ctx, database)
+	if err != nil {
+		return "", 0, err
+	}
+
+	key := s3store.DatabaseBackupS3Key(database.OrganizationID, database.ID, backup.ID)
+	size, err := uploadCommandOutput(serverContext(ctx, database.OrganizationID, database.ServerID), store, databaseDumpCommand(database, containerID), key)
+	if err != nil {
+		return "", 0, fmt.Errorf("database dump failed: %w", err)
+	}
+	return key, size, nil
+}
+
+// runDatabaseRestore loads a backup into database once it accepts
+// connections.
+func (t *TaskService) runDatabaseRestore(ctx context.Context, database *shared_types.Database, restore *shared_types.DatabaseRestore) error {
+	restore.Status = shared_types.RestoreRunning
+	if err := t.Storage.UpdateDatabaseRestore(restore); err != nil {
+		return err
+	}
+
+	err := t.restoreDatabaseFromS3(ctx, database, restore)
+	completedAt := time.Now()
+	restore.CompletedAt = &completedAt
+	if err != nil {
+		restore.Status = shared_types.RestoreFailed
+		restore.Error = err.Error()
+		if updateErr := t.Storage.UpdateDatabaseRestore(restore); updateErr != nil {
+			t.Logger.Log(logger.Error, "failed to update database restore", updateErr.Error())
+		}
+		t.emitDatabaseRestoreFailed(database, restore, err)
+		return err
+	}
+	restore.Status = shared_types.RestoreSucceeded
+	return t.Storage.UpdateDatabaseRestore(restore)
+}
+
+func (t *TaskService) restoreDatabaseFromS3(ctx context.Context, database *shared_types.Database, restore *shared_types.DatabaseRestore) error {
+	backup, err := t.Storage.GetDatabaseBackup(restore.BackupID, restore.OrganizationID)
+	if err != nil {
+		return types.ErrDatabaseBackupNotFound
+	}
+	containerID, err := waitForDatabase(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 image store: %w", err)
+	}
+	cmd := databaseRestoreCommand(database, containerID)
+	if err := downloadToCommand(serverContext(ctx, database.OrganizationID, database.ServerID), store, backup.S3Key, cmd); err != nil {
+		return fmt.Errorf("database restore failed: %w", err)
+	}
+	return nil
+}
+
+// waitForDatabase waits until the database accepts connections over TCP and
+// returns its container. The images only listen on TCP once their first
+// start initialization is done, so a new database is not restored into while
+// it is still being set up.
+func waitForDatabase(ctx context.Context, database *shared_types.Database) (string, error) {
+	deadline := time.Now().Add(databaseReadyTimeout)
+	for {
+		dockerSvc, containerID, err := databaseContainer(ctx, database)
+		if err == nil {
+			if err = dockerSvc.ExecInContainer(containerID, databaseReadyCommand(database), nil); err == nil {
+				return containerID, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%w: %s", types.ErrDatabaseNotRunning, err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(databaseReadyInterval):
+		}
+	}
+}
+
+// applyDatabaseBackupRetention removes the backups of a database that its
+// retention rules expire. Records are only removed once their dump is gone
+// from S3, so a failed removal is retried after the next backup.
+func (t *TaskService) applyDatabaseBackupRetention(ctx context.Context, database *shared_types.Database) {
+	backups, err := t.Storage.GetDatabaseBackups(database.ID)
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to list database backups", err.Error())
+		return
+	}
+	expired := expiredBackups(backups, databaseBackupState, database.BackupRetentionCount, database.BackupRetentionDays, time.Now())
+	if len(expired) == 0 {
+		return
+	}
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to create S3 image store", err.Error())
+		return
+	}
+	for _, backup := range expired {
+		if backup.S3Key != "" {
+			if err := store.DeleteImage(ctx, backup.S3Key); err != nil {
+				t.Logger.Log(logger.Warning, "failed to remove expired backup from S3: "+err.Error(), backup.ID.String())
+				continue
+			}
+		}
+		if err := t.Storage.DeleteDatabaseBackup(backup.ID); err != nil {
+			t.Logger.Log(logger.Error, "failed to remove expired backup: "+err.Error(), backup.ID.String())
+		}
+	}
+}
+
+// deleteDatabaseBackupObjects removes the dumps of a database from S3 before
+// the database and its backup records are deleted. It only runs when the
+// caller opted in, as the dumps are the last copy of the data.
+func (t *TaskService) deleteDatabaseBackupObjects(ctx context.Context, databaseID uuid.UUID) {
+	backups, err := t.Storage.GetDatabaseBackups(databaseID)
+	if err != nil || len(backups) == 0 || !s3store.IsConfigured(config.AppConfig.S3) {
+		return
+	}
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		t.Logger.Log(logger.Warning, "failed to create S3 image store", err.Error())
+		return
+	}
+	for _, backup := range backups {
+		if backup.S3Key == "" {
+			continue
+		}
+		if err := store.DeleteImage(ctx, backup.S3Key); err != nil {
+			t.Logger.Log(logger.Warning, "failed to remove database backup from S3: "+err.Error(), backup.ID.String())
+		}
+	}
+}
+
+// databaseDumpCommand dumps the database from inside its container and
+// gzips it on the server. pipefail makes a failing dump fail the command
+// rather than uploading a truncated backup.
+func databaseDumpCommand(database *shared_types.Database, containerID string) string {
+	var dump string
+	switch database.Engine {
+	case shared_types.DatabaseMySQL:
+		dump = fmt.Sprintf("docker exec -e MYSQL_PWD=%s %s mysqldump --single-transaction --no-tablespaces -u %s %s",
+			utils.ShellQuote(string(database.Password)), utils.ShellQuote(containerID),
+			utils.ShellQuote(database.Username), utils.ShellQuote(database.DatabaseName))
+	default:
+		dump = fmt.Sprintf("docker exec %s pg_dump --clean --if-exists --no-owner --no-privileges -U %s -d %s",
+			utils.ShellQuote(containerID), utils.ShellQuote(database.Username), utils.ShellQuote(database.DatabaseName))
+	}
+	return "bash -o pipefail -c " + utils.ShellQuote(dump+" | gzip")
+}
+
+// databaseRestoreCommand loads a gzipped dump from stdin into the database.
+// Dumps name no database, so they load into whichever database is targeted.
+func databaseRestoreCommand(database *shared_types.Database, containerID string) string {
+	var load string
+	switch database.Engine {
+	case shared_types.DatabaseMySQL:
+		load = fmt.Sprintf("docker exec -i -e MYSQL_PWD=%s %s mysql -u %s %s",
+			utils.ShellQuote(string(database.Password)), utils.ShellQuote(containerID),
+			utils.ShellQuote(database.Username), utils.ShellQuote(database.DatabaseName))
+	default:
+		load = fmt.Sprintf("docker exec -i %s psql -q -v ON_ERROR_STOP=1 -U %s -d %s",
+			utils.ShellQuote(containerID), utils.ShellQuote(database.Username), utils.ShellQuote(database.DatabaseName))
+	}
+	return "bash -o pipefail -c " + utils.ShellQuote("gunzip | "+load)
+}
+
+// databaseReadyCommand succeeds once the database accepts TCP connections.
+func databaseReadyCommand(database *shared_types.Database) []string {
+	if database.Engine == shared_types.DatabaseMySQL {
+		return []string{"mysqladmin", "ping", "-h", "127.0.0.1", "--silent"}
+	}
+	return []string{"pg_isready", "-h", "127.0.0.1", "-U", database.Username, "-d", database.DatabaseName}
+}
+
+func databaseBackupState(backup shared_types.DatabaseBackup) (shared_types.BackupStatus, time.Time) {
+	return backup.Status, backup.CreatedAt
+}
+
+func (t *TaskService) emitDatabaseBackupFailed(database *shared_types.Database, backup *shared_types.DatabaseBackup, err error) {
+	if t.Notifier == nil {
+		return
+	}
+	userID := database.CreatedBy
+	if backup.CreatedBy != nil {
+		userID = *backup.CreatedBy
+	}
+	t.Notifier.Emit(shared_types.NotificationEvent{
+		Type:           shared_types.EventDatabaseBackupFailed,
+		UserID:         userID.String(),
+		OrganizationID: database.OrganizationID.String(),
+		Data: map[string]interface{}{
+			"database_name": database.Name,
+			"database_id":   database.ID.String(),
+			"backup_id":     backup.ID.String(),
+			"trigger":       string(backup.Trigger),
+			"error_message": err.Error(),
+		},
+	})
+}
+
+func (t *TaskService) emitDatabaseRestoreFailed(database *shared_types.Database, restore *shared_types.DatabaseRestore, err error) {
+	if t.Notifier == nil {
+		return
+	}
+	t.Notifier.Emit(shared_types.NotificationEvent{
+		Type:           shared_types.EventDatabaseRestoreFailed,
+		UserID:         restore.CreatedBy.String(),
+		OrganizationID: database.OrganizationID.String(),
+		Data: map[string]interface{}{
+			"database_name": database.Name,
+			"database_id":   database.ID.String(),
+			"backup_id":     restore.BackupID.String(),
+			"restore_id":    restore.ID.String(),
+			"error_message": err.Error(),
+		},
+	})
+}
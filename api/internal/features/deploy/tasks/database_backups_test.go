@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestDatabaseDumpAndRestoreCommands(t *testing.T) {
+	postgres := testDatabase(shared_types.DatabasePostgres)
+	dump := databaseDumpCommand(postgres, "abc123")
+	for _, want := range []string{"bash -o pipefail -c ", "docker exec '\"'\"'abc123'\"'\"' pg_dump", "--clean --if-exists", "| gzip"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("postgres dump %q does not contain %q", dump, want)
+		}
+	}
+	restore := databaseRestoreCommand(postgres, "abc123")
+	for _, want := range []string{"gunzip | docker exec -i", "psql -q -v ON_ERROR_STOP=1"} {
+		if !strings.Contains(restore, want) {
+			t.Errorf("postgres restore %q does not contain %q", restore, want)
+		}
+	}
+
+	mysql := testDatabase(shared_types.DatabaseMySQL)
+	dump = databaseDumpCommand(mysql, "abc123")
+	for _, want := range []string{"MYSQL_PWD=", "s3cret", "mysqldump --single-transaction --no-tablespaces"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("mysql dump %q does not contain %q", dump, want)
+		}
+	}
+	if restore := databaseRestoreCommand(mysql, "abc123"); !strings.Contains(restore, "mysql -u") {
+		t.Errorf("mysql restore = %q", restore)
+	}
+}
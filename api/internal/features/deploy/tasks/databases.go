@@ -0,0 +1,484 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	sshpkg "github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// DatabaseLabel marks the Swarm service of a managed database with its ID.
+const DatabaseLabel = "nixopus.database.id"
+
+// databaseUsername is the user created in Postgres and MySQL databases.
+const databaseUsername = "nixopus"
+
+// databasePasswordBytes is the entropy of generated passwords. They are hex
+// encoded so they never need escaping in URLs, SQL or commands.
+const databasePasswordBytes = 16
+
+var databaseVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+var databaseIdentifierInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateDatabase provisions a managed database on the requested server, or
+// on the organization's default server.
+func (t *TaskService) CreateDatabase(ctx context.Context, request *types.CreateDatabaseRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.Database, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, types.ErrMissingName
+	}
+	if !shared_types.IsValidDatabaseEngine(request.Engine) {
+		return nil, types.ErrInvalidDatabaseEngine
+	}
+	engine := shared_types.DatabaseEngine(request.Engine)
+	version := request.Version
+	if version == "" {
+		version = engine.DefaultVersion()
+	}
+	if !databaseVersionPattern.MatchString(version) {
+		return nil, types.ErrInvalidDatabaseVersion
+	}
+	if request.CPULimit < 0 || request.MemoryLimitMB < 0 {
+		return nil, types.ErrInvalidDatabaseResources
+	}
+
+	taken, err := t.Storage.IsDatabaseNameTaken(organizationID, name)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, types.ErrDatabaseNameTaken
+	}
+
+	var serverID uuid.UUID
+	if request.ServerID != nil {
+		serverID = *request.ServerID
+	} else if serverID, err = t.Storage.GetDefaultServerID(organizationID); err != nil {
+		return nil, err
+	}
+	if _, err := sshpkg.GetSSHManagerForServer(ctx, organizationID, serverID); err != nil {
+		return nil, fmt.Errorf("%w: %s", types.ErrServerNotFound, serverID)
+	}
+
+	password, err := generateDatabasePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	database := &shared_types.Database{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		ServerID:       serverID,
+		Name:           name,
+		Engine:         engine,
+		Version:        version,
+		Password:       shared_types.EncryptedString(password),
+		CPULimit:       request.CPULimit,
+		MemoryLimitMB:  request.MemoryLimitMB,
+		Status:         shared_types.DatabaseProvisioning,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if engine != shared_types.DatabaseRedis {
+		database.DatabaseName = databaseIdentifier(name)
+		database.Username = databaseUsername
+	}
+	if err := t.Storage.CreateDatabase(database); err != nil {
+		return nil, err
+	}
+
+	if err := t.applyDatabaseService(ctx, database); err != nil {
+		return database, t.failDatabase(database, err)
+	}
+	database.Status = shared_types.DatabaseRunning
+	database.Error = ""
+	if err := t.Storage.UpdateDatabase(database); err != nil {
+		return nil, err
+	}
+	return database, nil
+}
+
+// ResizeDatabase replaces the CPU and memory limits of a database. Swarm
+// restarts the database with the new limits.
+func (t *TaskService) ResizeDatabase(ctx context.Context, databaseID uuid.UUID, request *types.ResizeDatabaseRequest, organizationID uuid.UUID) (*shared_types.Database, error) {
+	if request.CPULimit < 0 || request.MemoryLimitMB < 0 {
+		return nil, types.ErrInvalidDatabaseResources
+	}
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return nil, types.ErrDatabaseNotFound
+	}
+
+	database.CPULimit = request.CPULimit
+	database.MemoryLimitMB = request.MemoryLimitMB
+	if err := t.applyDatabaseService(ctx, database); err != nil {
+		return nil, t.failDatabase(database, err)
+	}
+	database.Status = shared_types.DatabaseRunning
+	database.Error = ""
+	if err := t.Storage.UpdateDatabase(database); err != nil {
+		return nil, err
+	}
+	return database, nil
+}
+
+// RotateDatabasePassword changes the password inside the running database
+// and stores it. Attached applications keep the old connection string until
+// they are redeployed, which request.Redeploy queues.
+func (t *TaskService) RotateDatabasePassword(ctx context.Context, databaseID uuid.UUID, request *types.RotateDatabasePasswordRequest, userID uuid.UUID, organizationID uuid.UUID) (*types.RotateDatabasePasswordResponseData, error) {
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return nil, types.ErrDatabaseNotFound
+	}
+	dockerSvc, containerID, err := databaseContainer(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := generateDatabasePassword()
+	if err != nil {
+		return nil, err
+	}
+	oldPassword := database.Password
+
+	// The new password is stored first so it is never lost: if changing it
+	// inside the database fails, the old one is stored again.
+	database.Password = shared_types.EncryptedString(password)
+	if err := t.Storage.UpdateDatabase(database); err != nil {
+		return nil, err
+	}
+	cmd := rotatePasswordCommand(database, string(oldPassword), password)
+	if err := dockerSvc.ExecInContainer(containerID, cmd, nil); err != nil {
+		database.Password = oldPassword
+		if restoreErr := t.Storage.UpdateDatabase(database); restoreErr != nil {
+			t.Logger.Log(logger.Error, "failed to restore database password", restoreErr.Error())
+		}
+		return nil, fmt.Errorf("failed to change database password: %w", err)
+	}
+
+	// Postgres and MySQL only read their credentials from the environment on
+	// first start, so only Redis needs the new password in its service.
+	if database.Engine == shared_types.DatabaseRedis {
+		if err := t.applyDatabaseService(ctx, database); err != nil {
+			return nil, err
+		}
+	}
+
+	apps, err := t.Storage.GetApplicationsByDatabase(database.ID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := t.Storage.GetDatabaseAttachments(database.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.RotateDatabasePasswordResponseData{
+		Database:             *database,
+		AffectedApplications: DatabaseApplications(apps, attachments),
+	}
+	if request.Redeploy {
+		result.Redeploy = t.redeployApplications(apps, request.Force, userID, organizationID)
+	}
+	return result, nil
+}
+
+// DeleteDatabase removes the database's service, its data volume and its
+// network. Backup dumps are kept in S3 unless deleteBackups is set.
+// Databases attached to applications cannot be deleted.
+func (t *TaskService) DeleteDatabase(ctx context.Context, databaseID uuid.UUID, organizationID uuid.UUID, deleteBackups bool) error {
+	database, err := t.Storage.GetDatabaseByID(databaseID, organizationID)
+	if err != nil {
+		return types.ErrDatabaseNotFound
+	}
+	attachments, err := t.Storage.GetDatabaseAttachments(database.ID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) > 0 {
+		return types.ErrDatabaseInUse
+	}
+
+	dockerSvc, err := databaseDockerService(ctx, database)
+	if err != nil {
+		return err
+	}
+	service, err := dockerSvc.GetServiceByName(database.ServiceName())
+	if err != nil {
+		return err
+	}
+	if service != nil {
+		if err := dockerSvc.DeleteService(service.ID); err != nil {
+			return fmt.Errorf("failed to remove database service: %w", err)
+		}
+	}
+	if err := removeDatabaseVolume(dockerSvc, database.VolumeName()); err != nil {
+		return err
+	}
+	if err := dockerSvc.RemoveNetwork(database.NetworkName()); err != nil {
+		return err
+	}
+	if deleteBackups {
+		t.deleteDatabaseBackupObjects(ctx, database.ID)
+	}
+	return t.Storage.DeleteDatabase(database.ID, organizationID)
+}
+
+// DatabaseApplications pairs the applications of a database with the
+// variable each one receives the connection string in.
+func DatabaseApplications(apps []shared_types.Application, attachments []shared_types.ApplicationDatabase) []types.DatabaseApplication {
+	envVars := make(map[uuid.UUID]string, len(attachments))
+	for _, attachment := range attachments {
+		envVars[attachment.ApplicationID] = attachment.EnvVar
+	}
+	result := make([]types.DatabaseApplication, 0, len(apps))
+	for _, app := range apps {
+		result = append(result, types.DatabaseApplication{
+			ID:          app.ID,
+			Name:        app.Name,
+			Environment: app.Environment,
+			EnvVar:      envVars[app.ID],
+		})
+	}
+	return result
+}
+
+// applyDatabaseService creates the database's network and service, or
+// updates the service when it already exists.
+func (t *TaskService) applyDatabaseService(ctx context.Context, database *shared_types.Database) error {
+	dockerSvc, err := databaseDockerService(ctx, database)
+	if err != nil {
+		return err
+	}
+	if err := dockerSvc.EnsurePrivateNetwork(database.NetworkName()); err != nil {
+		return err
+	}
+
+	spec := databaseServiceSpec(database)
+	existing, err := dockerSvc.GetServiceByName(spec.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := dockerSvc.UpdateService(existing.ID, spec, ""); err != nil {
+			return fmt.Errorf("failed to update database service: %w", err)
+		}
+		return nil
+	}
+	if err := dockerSvc.CreateService(swarm.Service{Spec: spec}); err != nil {
+		return fmt.Errorf("failed to create database service: %w", err)
+	}
+	return nil
+}
+
+func (t *TaskService) failDatabase(database *shared_types.Database, cause error) error {
+	database.Status = shared_types.DatabaseFailed
+	database.Error = cause.Error()
+	if err := t.Storage.UpdateDatabase(database); err != nil {
+		t.Logger.Log(logger.Error, "failed to update database status", err.Error())
+	}
+	return cause
+}
+
+// applicationDatabaseNetworks returns the networks of the databases attached
+// to the application.
+func (t *TaskService) applicationDatabaseNetworks(app *shared_types.Application) ([]string, error) {
+	attachments, err := t.Storage.GetApplicationDatabases(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	var networks []string
+	for _, attachment := range attachments {
+		if attachment.Database != nil {
+			networks = append(networks, attachment.Database.NetworkName())
+		}
+	}
+	return networks, nil
+}
+
+func databaseNetworkAttachments(networks []string) []swarm.NetworkAttachmentConfig {
+	attachments := make([]swarm.NetworkAttachmentConfig, 0, len(networks))
+	for _, name := range networks {
+		attachments = append(attachments, swarm.NetworkAttachmentConfig{Target: name})
+	}
+	return attachments
+}
+
+// composeDatabaseNetworkKey is the key of the i-th database network inside
+// the generated compose override.
+func composeDatabaseNetworkKey(i int) string {
+	return fmt.Sprintf("nixopus_db_%d", i)
+}
+
+// addDatabaseVariables sets the connection string of each attached database
+// in resolved, unless the application defines the variable itself.
+func addDatabaseVariables(resolved map[string]string, attachments []shared_types.ApplicationDatabase, appVars map[string]string) {
+	for _, attachment := range attachments {
+		if attachment.Database == nil {
+			continue
+		}
+		if _, ok := appVars[attachment.EnvVar]; ok {
+			continue
+		}
+		resolved[attachment.EnvVar] = attachment.Database.ConnectionString()
+	}
+}
+
+// databaseDockerService returns the Docker client of the database's server.
+func databaseDockerService(ctx context.Context, database *shared_types.Database) (*docker.DockerService, error) {
+	return docker.GetDockerServiceForServer(ctx, database.OrganizationID, database.ServerID)
+}
+
+// databaseContainer returns the Docker client of the database's server and
+// the ID of the database's running container.
+func databaseContainer(ctx context.Context, database *shared_types.Database) (*docker.DockerService, string, error) {
+	dockerSvc, err := databaseDockerService(ctx, database)
+	if err != nil {
+		return nil, "", err
+	}
+	service, err := dockerSvc.GetServiceByName(database.ServiceName())
+	if err != nil || service == nil {
+		return nil, "", types.ErrDatabaseNotRunning
+	}
+	containerID, err := dockerSvc.GetRunningTaskContainerID(service.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", types.ErrDatabaseNotRunning, err.Error())
+	}
+	return dockerSvc, containerID, nil
+}
+
+// databaseServiceSpec is the Swarm service of a database. It publishes no
+// ports and is only reachable under its hostname on its private network.
+// The data volume is local to a node, so the service is kept on the manager
+// rather than being rescheduled onto a node with an empty volume.
+func databaseServiceSpec(database *shared_types.Database) swarm.ServiceSpec {
+	replicas := uint64(1)
+	password := string(database.Password)
+
+	containerSpec := &swarm.ContainerSpec{
+		Image:  database.Image(),
+		Labels: map[string]string{DatabaseLabel: database.ID.String()},
+		Mounts: []mount.Mount{{
+			Type:   mount.TypeVolume,
+			Source: database.VolumeName(),
+			Target: database.Engine.DataPath(),
+		}},
+	}
+	switch database.Engine {
+	case shared_types.DatabasePostgres:
+		containerSpec.Env = []string{
+			"POSTGRES_USER=" + database.Username,
+			"POSTGRES_PASSWORD=" + password,
+			"POSTGRES_DB=" + database.DatabaseName,
+		}
+	case shared_types.DatabaseMySQL:
+		containerSpec.Env = []string{
+			"MYSQL_USER=" + database.Username,
+			"MYSQL_PASSWORD=" + password,
+			"MYSQL_DATABASE=" + database.DatabaseName,
+			"MYSQL_RANDOM_ROOT_PASSWORD=yes",
+		}
+	case shared_types.DatabaseRedis:
+		containerSpec.Args = []string{"redis-server", "--appendonly", "yes", "--requirepass", password}
+	}
+
+	var resources *swarm.ResourceRequirements
+	if database.CPULimit > 0 || database.MemoryLimitMB > 0 {
+		resources = &swarm.ResourceRequirements{Limits: &swarm.Limit{
+			NanoCPUs:    int64(database.CPULimit * 1e9),
+			MemoryBytes: database.MemoryLimitMB * 1024 * 1024,
+		}}
+	}
+
+	return swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   database.ServiceName(),
+			Labels: map[string]string{DatabaseLabel: database.ID.String()},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: containerSpec,
+			Resources:     resources,
+			RestartPolicy: &swarm.RestartPolicy{
+				Condition: swarm.RestartPolicyConditionAny,
+			},
+			Placement: &swarm.Placement{
+				Constraints: []string{"node.role == manager"},
+			},
+			Networks: []swarm.NetworkAttachmentConfig{{
+				Target:  database.NetworkName(),
+				Aliases: []string{database.Hostname()},
+			}},
+		},
+		EndpointSpec: &swarm.EndpointSpec{Mode: swarm.ResolutionModeVIP},
+	}
+}
+
+// rotatePasswordCommand changes the password of the database's user from
+// inside its container.
+func rotatePasswordCommand(database *shared_types.Database, oldPassword, newPassword string) []string {
+	switch database.Engine {
+	case shared_types.DatabasePostgres:
+		return []string{
+			"psql", "-v", "ON_ERROR_STOP=1", "-U", database.Username, "-d", database.DatabaseName,
+			"-c", fmt.Sprintf("ALTER USER CURRENT_USER WITH PASSWORD '%s'", newPassword),
+		}
+	case shared_types.DatabaseMySQL:
+		return []string{
+			"mysql", "-u" + database.Username, "-p" + oldPassword,
+			"-e", fmt.Sprintf("ALTER USER CURRENT_USER() IDENTIFIED BY '%s'", newPassword),
+		}
+	default:
+		return []string{"redis-cli", "--no-auth-warning", "-a", oldPassword, "CONFIG", "SET", "requirepass", newPassword}
+	}
+}
+
+// removeDatabaseVolume removes the data volume once the containers of the
+// removed service have released it.
+func removeDatabaseVolume(dockerSvc *docker.DockerService, name string) error {
+	var err error
+	for attempt := 0; attempt < 15; attempt++ {
+		if err = dockerSvc.RemoveVolume(name); err == nil {
+			return nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return err
+}
+
+// databaseIdentifier turns a database name into a name Postgres and MySQL
+// accept without quoting.
+func databaseIdentifier(name string) string {
+	id := strings.Trim(databaseIdentifierInvalidChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if len(id) > 63 {
+		id = strings.TrimRight(id[:63], "_")
+	}
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "db_" + id
+	}
+	return strings.TrimRight(id, "_")
+}
+
+func generateDatabasePassword() (string, error) {
+	b := make([]byte, databasePasswordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,11 +3,14 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/docker/docker/api/types/image"
 	"github.com/google/uuid"
 	"github.com/nixopus/nixopus/api/internal/config"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
 	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
@@ -39,6 +42,16 @@ func (s *TaskService) DeleteDeployment(ctx context.Context, deployment *types.De
 	if err != nil {
 		s.Logger.Log(logger.Error, "Failed to get docker service", err.Error())
 	} else {
+		if application.IsComposeStack() {
+			stackName := application.ComposeStackName()
+			s.Logger.Log(logger.Info, "Removing stack", stackName)
+			if ds, ok := dockerService.(*docker.DockerService); !ok {
+				s.Logger.Log(logger.Error, "Failed to remove stack", "stack removal is not supported by this docker service")
+			} else if err := ds.StackRemove(stackName); err != nil {
+				s.Logger.Log(logger.Error, "Failed to remove stack", err.Error())
+			}
+		}
+
 		services, err := dockerService.GetClusterServices()
 		if err != nil {
 			s.Logger.Log(logger.Error, "Failed to get services", err.Error())
@@ -101,13 +114,25 @@ func (s *TaskService) DeleteDeployment(ctx context.Context, deployment *types.De
 		s.Logger.Log(logger.Error, "Failed to remove repository", err.Error())
 	}
 
-	if len(application.Domains) > 0 {
-		var domainNames []string
-		for _, appDomain := range application.Domains {
-			if appDomain.Domain != "" {
-				domainNames = append(domainNames, appDomain.Domain)
+	var domainNames []string
+	for _, appDomain := range application.Domains {
+		if appDomain.Domain != "" {
+			domainNames = append(domainNames, appDomain.Route())
+		}
+	}
+	// Alias domains of the application's redirect rules only route to it.
+	if redirects, err := s.Storage.GetApplicationRedirects(application.ID); err != nil {
+		s.Logger.Log(logger.Warning, "failed to load redirects", err.Error())
+	} else {
+		for _, r := range redirects {
+			if r.SourceDomain != "" && !slices.ContainsFunc(domainNames, func(d string) bool {
+				return strings.EqualFold(d, r.SourceDomain)
+			}) {
+				domainNames = append(domainNames, r.SourceDomain)
 			}
 		}
+	}
+	if len(domainNames) > 0 {
 		if err := caddy.RemoveDomainsWithRetry(orgCtx, nil, &s.Logger, domainNames); err != nil {
 			s.Logger.Log(logger.Warning, "failed to remove domains from proxy, enqueueing for retry", err.Error())
 			if enqErr := caddy.EnqueuePendingRemoval(organizationID, domainNames...); enqErr != nil {
@@ -0,0 +1,13 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ApplyDNSProviders updates the certificate policies of the organization's
+// Caddy after its DNS providers changed.
+func (t *TaskService) ApplyDNSProviders(ctx context.Context, organizationID uuid.UUID) error {
+	return t.reconcileRoutes(ctx, organizationID)
+}
@@ -100,6 +100,16 @@ func filterServers(all []shared_types.ApplicationServer, targetIDs []uuid.UUID)
 	return out
 }
 
+// primaryServer returns the server marked as primary, or the first server if none is.
+func primaryServer(servers []shared_types.ApplicationServer) shared_types.ApplicationServer {
+	for _, s := range servers {
+		if s.IsPrimary {
+			return s
+		}
+	}
+	return servers[0]
+}
+
 // fanOut runs fn for each server in parallel. Each goroutine gets a fresh child deployment.
 // Returns errors.Join of all goroutine errors (nil if all succeed).
 func (t *TaskService) fanOut(
@@ -108,6 +118,12 @@ func (t *TaskService) fanOut(
 	servers []shared_types.ApplicationServer,
 	fn func(ctx context.Context, d shared_types.TaskPayload) error,
 ) error {
+	// The Swarm manager schedules a stack across the nodes, so it is deployed
+	// once through the primary server instead of once per server.
+	if d.Application.IsComposeStack() {
+		servers = []shared_types.ApplicationServer{primaryServer(servers)}
+	}
+
 	errs := make([]error, len(servers))
 	var wg sync.WaitGroup
 	for i, srv := range servers {
@@ -0,0 +1,246 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	sshpkg "github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/pkg/sftp"
+)
+
+// imageScanEnabled reports whether built images of the application are scanned.
+// Blocking on vulnerabilities needs the SBOM, so a threshold alone enables it.
+func imageScanEnabled(app *shared_types.Application) bool {
+	return (app.SBOMFormat != "" && app.SBOMFormat != shared_types.SBOMFormatNone) || blocksOnVulnerabilities(app)
+}
+
+// blocksOnVulnerabilities reports whether the application has a vulnerability threshold.
+func blocksOnVulnerabilities(app *shared_types.Application) bool {
+	return app.VulnThreshold != "" && app.VulnThreshold != shared_types.SeverityNone
+}
+
+// ScanImage generates an SBOM for a built image by running Syft on the
+// deploy host and matches it against the local Grype database. The results
+// are stored with the deployment. It returns ErrVulnThresholdExceeded when a
+// finding reaches the application's threshold.
+func (s *TaskService) ScanImage(ctx context.Context, payload shared_types.TaskPayload, image string, taskCtx *TaskContext) error {
+	app := &payload.Application
+	if !imageScanEnabled(app) {
+		return nil
+	}
+	format := app.SBOMFormat
+	if format == "" || format == shared_types.SBOMFormatNone {
+		format = shared_types.SBOMFormatCycloneDX
+	}
+
+	taskCtx.AddLog("Scanning image " + image + " for packages and vulnerabilities")
+	report, err := s.runImageScan(ctx, payload.ApplicationDeployment.ID, image, format)
+	if err != nil {
+		return fmt.Errorf("image scan failed: %w", err)
+	}
+
+	findings, err := parseGrypeFindings(report.vulnerabilities)
+	if err != nil {
+		return fmt.Errorf("failed to read vulnerability report: %w", err)
+	}
+	packages, err := parseCycloneDXPackages(report.cyclonedx)
+	if err != nil {
+		return fmt.Errorf("failed to read sbom: %w", err)
+	}
+
+	document := report.cyclonedx
+	if format == shared_types.SBOMFormatSPDX {
+		document = report.spdx
+	}
+	sbom := &shared_types.DeploymentSBOM{
+		ID:                      uuid.New(),
+		ApplicationDeploymentID: payload.ApplicationDeployment.ID,
+		ApplicationID:           app.ID,
+		Image:                   image,
+		Format:                  format,
+		Document:                json.RawMessage(document),
+		Vulnerabilities:         findings,
+		CreatedAt:               time.Now(),
+	}
+	summarizeFindings(sbom, app.VulnThreshold)
+
+	now := time.Now()
+	for i := range packages {
+		packages[i].ID = uuid.New()
+		packages[i].ApplicationDeploymentID = sbom.ApplicationDeploymentID
+		packages[i].ApplicationID = app.ID
+		packages[i].CreatedAt = now
+	}
+	if err := s.Storage.SaveDeploymentSBOM(sbom, packages); err != nil {
+		return fmt.Errorf("failed to save sbom: %w", err)
+	}
+
+	taskCtx.AddLog(fmt.Sprintf("Image scan found %d packages and %d vulnerabilities (critical: %d, high: %d, medium: %d, low: %d)",
+		len(packages), len(findings), sbom.CriticalCount, sbom.HighCount, sbom.MediumCount, sbom.LowCount))
+	if sbom.Blocked {
+		return fmt.Errorf("%w (%s)", types.ErrVulnThresholdExceeded, app.VulnThreshold)
+	}
+	return nil
+}
+
+type imageScanReport struct {
+	cyclonedx       []byte
+	spdx            []byte
+	vulnerabilities []byte
+}
+
+// runImageScan runs the scanner containers on the deploy host and reads back
+// their reports. The Grype database is only updated from DBUpdateURL, which
+// is expected to point at a local mirror.
+func (s *TaskService) runImageScan(ctx context.Context, deploymentID uuid.UUID, image string, format shared_types.SBOMFormat) (*imageScanReport, error) {
+	manager, err := sshpkg.GetSSHManagerFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSH manager: %w", err)
+	}
+
+	cfg := config.AppConfig.ImageScan
+	dir := "/tmp/nixopus-scan-" + deploymentID.String()
+	defer manager.RunCommand("rm -rf " + utils.ShellQuote(dir))
+
+	syftCmd := fmt.Sprintf("mkdir -p %s && docker run --rm -v /var/run/docker.sock:/var/run/docker.sock -v %s:/out %s %s -q -o cyclonedx-json=/out/cyclonedx.json",
+		utils.ShellQuote(dir), utils.ShellQuote(dir), utils.ShellQuote(cfg.SyftImage), utils.ShellQuote("docker:"+image))
+	if format == shared_types.SBOMFormatSPDX {
+		syftCmd += " -o spdx-json=/out/spdx.json"
+	}
+	if output, err := manager.RunCommand(syftCmd); err != nil {
+		return nil, fmt.Errorf("sbom generation failed: %s", strings.TrimSpace(output))
+	}
+
+	dbEnv := "-e GRYPE_DB_AUTO_UPDATE=false"
+	if cfg.DBUpdateURL != "" {
+		dbEnv = "-e GRYPE_DB_AUTO_UPDATE=true -e GRYPE_DB_UPDATE_URL=" + utils.ShellQuote(cfg.DBUpdateURL)
+	}
+	grypeCmd := fmt.Sprintf("docker run --rm -v %s:/out -v %s:/grype-db -e GRYPE_DB_CACHE_DIR=/grype-db %s %s sbom:/out/cyclonedx.json -q -o json --file /out/vulnerabilities.json",
+		utils.ShellQuote(dir), utils.ShellQuote(cfg.DBDir), dbEnv, utils.ShellQuote(cfg.GrypeImage))
+	if output, err := manager.RunCommand(grypeCmd); err != nil {
+		return nil, fmt.Errorf("vulnerability matching failed: %s", strings.TrimSpace(output))
+	}
+
+	report := &imageScanReport{}
+	err = utils.WithSFTPClientFromPool(ctx, func(client *sftp.Client) error {
+		var err error
+		if report.cyclonedx, err = readRemoteFile(client, path.Join(dir, "cyclonedx.json")); err != nil {
+			return err
+		}
+		if format == shared_types.SBOMFormatSPDX {
+			if report.spdx, err = readRemoteFile(client, path.Join(dir, "spdx.json")); err != nil {
+				return err
+			}
+		}
+		report.vulnerabilities, err = readRemoteFile(client, path.Join(dir, "vulnerabilities.json"))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan reports: %w", err)
+	}
+	return report, nil
+}
+
+type cycloneDXDocument struct {
+	Components []struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		PURL    string `json:"purl"`
+	} `json:"components"`
+}
+
+// parseCycloneDXPackages returns the packages listed in a CycloneDX SBOM.
+// The package type is taken from the purl when there is one.
+func parseCycloneDXPackages(data []byte) ([]shared_types.DeploymentPackage, error) {
+	var doc cycloneDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var packages []shared_types.DeploymentPackage
+	for _, c := range doc.Components {
+		if c.Name == "" || c.Type == "operating-system" {
+			continue
+		}
+		pkgType := c.Type
+		if rest, ok := strings.CutPrefix(c.PURL, "pkg:"); ok {
+			if i := strings.IndexByte(rest, '/'); i > 0 {
+				pkgType = rest[:i]
+			}
+		}
+		packages = append(packages, shared_types.DeploymentPackage{
+			Name:    c.Name,
+			Version: c.Version,
+			Type:    pkgType,
+			PURL:    c.PURL,
+		})
+	}
+	return packages, nil
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+			Fix      struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// parseGrypeFindings returns the vulnerability matches of a Grype JSON report.
+func parseGrypeFindings(data []byte) ([]shared_types.VulnerabilityFinding, error) {
+	var report grypeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	findings := make([]shared_types.VulnerabilityFinding, 0, len(report.Matches))
+	for _, m := range report.Matches {
+		finding := shared_types.VulnerabilityFinding{
+			ID:       m.Vulnerability.ID,
+			Severity: shared_types.Severity(strings.ToLower(m.Vulnerability.Severity)),
+			Package:  m.Artifact.Name,
+			Version:  m.Artifact.Version,
+		}
+		if len(m.Vulnerability.Fix.Versions) > 0 {
+			finding.FixVersion = m.Vulnerability.Fix.Versions[0]
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// summarizeFindings counts the findings of an SBOM by severity and marks it
+// blocked when one of them reaches threshold.
+func summarizeFindings(sbom *shared_types.DeploymentSBOM, threshold shared_types.Severity) {
+	for _, f := range sbom.Vulnerabilities {
+		switch f.Severity {
+		case shared_types.SeverityCritical:
+			sbom.CriticalCount++
+		case shared_types.SeverityHigh:
+			sbom.HighCount++
+		case shared_types.SeverityMedium:
+			sbom.MediumCount++
+		case shared_types.SeverityLow:
+			sbom.LowCount++
+		}
+		if threshold.Blocks(f.Severity) {
+			sbom.Blocked = true
+		}
+	}
+}
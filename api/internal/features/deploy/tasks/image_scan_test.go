@@ -0,0 +1,101 @@
+package tasks
+
+import (
+	"testing"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestParseCycloneDXPackages(t *testing.T) {
+	doc := []byte(`{
+		"bomFormat": "CycloneDX",
+		"components": [
+			{"type": "operating-system", "name": "alpine", "version": "3.20.0"},
+			{"type": "library", "name": "openssl", "version": "3.3.0-r2", "purl": "pkg:apk/alpine/openssl@3.3.0-r2?arch=x86_64"},
+			{"type": "library", "name": "golang.org/x/net", "version": "v0.25.0", "purl": "pkg:golang/golang.org/x/net@v0.25.0"},
+			{"type": "library", "name": "unnamed-purl", "version": "1.0"}
+		]
+	}`)
+
+	packages, err := parseCycloneDXPackages(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 3 {
+		t.Fatalf("got %d packages, want 3: %+v", len(packages), packages)
+	}
+	if packages[0].Name != "openssl" || packages[0].Type != "apk" || packages[0].Version != "3.3.0-r2" {
+		t.Errorf("openssl package = %+v", packages[0])
+	}
+	if packages[1].Type != "golang" {
+		t.Errorf("go package type = %q, want golang", packages[1].Type)
+	}
+	if packages[2].Type != "library" {
+		t.Errorf("package without purl type = %q, want library", packages[2].Type)
+	}
+}
+
+func TestParseGrypeFindings(t *testing.T) {
+	report := []byte(`{
+		"matches": [
+			{
+				"vulnerability": {"id": "CVE-2024-0001", "severity": "High", "fix": {"versions": ["3.3.1-r0"], "state": "fixed"}},
+				"artifact": {"name": "openssl", "version": "3.3.0-r2"}
+			},
+			{
+				"vulnerability": {"id": "GHSA-xxxx", "severity": "Unknown", "fix": {"versions": [], "state": "unknown"}},
+				"artifact": {"name": "golang.org/x/net", "version": "v0.25.0"}
+			}
+		]
+	}`)
+
+	findings, err := parseGrypeFindings(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2", len(findings))
+	}
+	want := shared_types.VulnerabilityFinding{ID: "CVE-2024-0001", Severity: shared_types.SeverityHigh, Package: "openssl", Version: "3.3.0-r2", FixVersion: "3.3.1-r0"}
+	if findings[0] != want {
+		t.Errorf("finding = %+v, want %+v", findings[0], want)
+	}
+	if findings[1].Severity != "unknown" || findings[1].FixVersion != "" {
+		t.Errorf("finding without fix = %+v", findings[1])
+	}
+}
+
+func TestSummarizeFindings(t *testing.T) {
+	findings := []shared_types.VulnerabilityFinding{
+		{Severity: shared_types.SeverityCritical},
+		{Severity: shared_types.SeverityMedium},
+		{Severity: shared_types.SeverityMedium},
+		{Severity: "unknown"},
+	}
+
+	tests := []struct {
+		threshold shared_types.Severity
+		blocked   bool
+	}{
+		{shared_types.SeverityNone, false},
+		{"", false},
+		{shared_types.SeverityCritical, true},
+		{shared_types.SeverityHigh, true},
+	}
+	for _, tt := range tests {
+		sbom := &shared_types.DeploymentSBOM{Vulnerabilities: findings}
+		summarizeFindings(sbom, tt.threshold)
+		if sbom.CriticalCount != 1 || sbom.MediumCount != 2 || sbom.HighCount != 0 || sbom.LowCount != 0 {
+			t.Errorf("threshold %q: counts = %d/%d/%d/%d", tt.threshold, sbom.CriticalCount, sbom.HighCount, sbom.MediumCount, sbom.LowCount)
+		}
+		if sbom.Blocked != tt.blocked {
+			t.Errorf("threshold %q: blocked = %v, want %v", tt.threshold, sbom.Blocked, tt.blocked)
+		}
+	}
+
+	low := &shared_types.DeploymentSBOM{Vulnerabilities: []shared_types.VulnerabilityFinding{{Severity: shared_types.SeverityLow}}}
+	summarizeFindings(low, shared_types.SeverityMedium)
+	if low.Blocked {
+		t.Error("a low finding should not reach a medium threshold")
+	}
+}
@@ -28,6 +28,14 @@ var (
 	TaskRestart           *taskq.Task
 	LiveDevQueue          taskq.Queue
 	TaskLiveDev           *taskq.Task
+	MigrationQueue        taskq.Queue
+	TaskMigration         *taskq.Task
+	DatabaseBackupQueue   taskq.Queue
+	TaskDatabaseBackup    *taskq.Task
+	VolumeBackupQueue     taskq.Queue
+	TaskVolumeBackup      *taskq.Task
+	AutoscaleQueue        taskq.Queue
+	TaskAutoscale         *taskq.Task
 )
 
 var (
@@ -43,6 +51,14 @@ var (
 	TASK_RESTART            = "task_restart_deployment"
 	QUEUE_LIVE_DEV          = "live-dev"
 	TASK_LIVE_DEV           = "task_live_dev"
+	QUEUE_MIGRATION         = "application-migration"
+	TASK_MIGRATION          = "task_application_migration"
+	QUEUE_DATABASE_BACKUP   = "database-backup"
+	TASK_DATABASE_BACKUP    = "task_database_backup"
+	QUEUE_VOLUME_BACKUP     = "volume-backup"
+	TASK_VOLUME_BACKUP      = "task_volume_backup"
+	QUEUE_AUTOSCALE         = "autoscale"
+	TASK_AUTOSCALE          = "task_autoscale"
 )
 
 func (t *TaskService) SetupCreateDeploymentQueue() {
@@ -189,6 +205,108 @@ func (t *TaskService) SetupCreateDeploymentQueue() {
 				return nil
 			},
 		})
+
+		// Copying images and volumes between servers can take long, the
+		// reservation outlasts it so a running migration is not redelivered.
+		MigrationQueue = queue.RegisterQueue(&taskq.QueueOptions{
+			Name:                QUEUE_MIGRATION,
+			ConsumerIdleTimeout: 10 * time.Minute,
+			MinNumWorker:        1,
+			MaxNumWorker:        4,
+			ReservationSize:     1,
+			ReservationTimeout:  6 * time.Hour,
+			WaitTimeout:         5 * time.Second,
+			BufferSize:          16,
+		})
+
+		TaskMigration = taskq.RegisterTask(&taskq.TaskOptions{
+			Name:       TASK_MIGRATION,
+			RetryLimit: 1,
+			Handler: func(ctx context.Context, data MigrationTaskPayload) error {
+				t.Logger.Log(logger.Info, "starting migration "+data.Action, data.MigrationID)
+				if err := t.HandleMigration(ctx, data); err != nil {
+					t.Logger.Log(logger.Error, "migration "+data.Action+" failed: "+err.Error(), data.MigrationID)
+					return err
+				}
+				t.Logger.Log(logger.Info, "migration "+data.Action+" completed", data.MigrationID)
+				return nil
+			},
+		})
+
+		// Dumps and restores of large databases can take long, the
+		// reservation outlasts them so a running job is not redelivered.
+		DatabaseBackupQueue = queue.RegisterQueue(&taskq.QueueOptions{
+			Name:                QUEUE_DATABASE_BACKUP,
+			ConsumerIdleTimeout: 10 * time.Minute,
+			MinNumWorker:        1,
+			MaxNumWorker:        4,
+			ReservationSize:     1,
+			ReservationTimeout:  6 * time.Hour,
+			WaitTimeout:         5 * time.Second,
+			BufferSize:          16,
+		})
+
+		TaskDatabaseBackup = taskq.RegisterTask(&taskq.TaskOptions{
+			Name:       TASK_DATABASE_BACKUP,
+			RetryLimit: 1,
+			Handler: func(ctx context.Context, data DatabaseBackupTaskPayload) error {
+				t.Logger.Log(logger.Info, "starting database "+data.Action, data.DatabaseID)
+				if err := t.HandleDatabaseBackup(ctx, data); err != nil {
+					t.Logger.Log(logger.Error, "database "+data.Action+" failed: "+err.Error(), data.DatabaseID)
+					return err
+				}
+				t.Logger.Log(logger.Info, "database "+data.Action+" completed", data.DatabaseID)
+				return nil
+			},
+		})
+
+		VolumeBackupQueue = queue.RegisterQueue(&taskq.QueueOptions{
+			Name:                QUEUE_VOLUME_BACKUP,
+			ConsumerIdleTimeout: 10 * time.Minute,
+			MinNumWorker:        1,
+			MaxNumWorker:        4,
+			ReservationSize:     1,
+			ReservationTimeout:  6 * time.Hour,
+			WaitTimeout:         5 * time.Second,
+			BufferSize:          16,
+		})
+
+		TaskVolumeBackup = taskq.RegisterTask(&taskq.TaskOptions{
+			Name:       TASK_VOLUME_BACKUP,
+			RetryLimit: 1,
+			Handler: func(ctx context.Context, data VolumeBackupTaskPayload) error {
+				t.Logger.Log(logger.Info, "starting volume "+data.Action, data.PolicyID)
+				if err := t.HandleVolumeBackup(ctx, data); err != nil {
+					t.Logger.Log(logger.Error, "volume "+data.Action+" failed: "+err.Error(), data.PolicyID)
+					return err
+				}
+				t.Logger.Log(logger.Info, "volume "+data.Action+" completed", data.PolicyID)
+				return nil
+			},
+		})
+
+		AutoscaleQueue = queue.RegisterQueue(&taskq.QueueOptions{
+			Name:                QUEUE_AUTOSCALE,
+			ConsumerIdleTimeout: 10 * time.Minute,
+			MinNumWorker:        1,
+			MaxNumWorker:        4,
+			ReservationSize:     1,
+			ReservationTimeout:  5 * time.Minute,
+			WaitTimeout:         5 * time.Second,
+			BufferSize:          32,
+		})
+
+		TaskAutoscale = taskq.RegisterTask(&taskq.TaskOptions{
+			Name:       TASK_AUTOSCALE,
+			RetryLimit: 1,
+			Handler: func(ctx context.Context, data AutoscaleTaskPayload) error {
+				if err := t.HandleAutoscale(ctx, data); err != nil {
+					t.Logger.Log(logger.Error, "autoscale failed: "+err.Error(), data.ApplicationID)
+					return err
+				}
+				return nil
+			},
+		})
 	})
 }
 
@@ -0,0 +1,169 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	// defaultMaintenanceRetryAfter is the Retry-After sent when a setting
+	// does not choose one.
+	defaultMaintenanceRetryAfter = 300
+	maxMaintenanceRetryAfter     = 86400
+	maxMaintenanceBodyBytes      = 64 * 1024
+)
+
+// UpdateMaintenance saves the maintenance mode of an application or one of
+// its domains and swaps the affected Caddy routes.
+func (t *TaskService) UpdateMaintenance(ctx context.Context, req *types.UpdateMaintenanceRequest, organizationID uuid.UUID) (*shared_types.ApplicationMaintenance, error) {
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	domain, err := maintenanceDomain(&app, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	allowedIPs, err := ParseMaintenanceAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	retryAfter := req.RetryAfterSeconds
+	if retryAfter == 0 {
+		retryAfter = defaultMaintenanceRetryAfter
+	}
+	if retryAfter < 0 || retryAfter > maxMaintenanceRetryAfter {
+		return nil, types.ErrInvalidRetryAfter
+	}
+	if len(req.HTMLBody) > maxMaintenanceBodyBytes {
+		return nil, types.ErrMaintenanceBodyTooLarge
+	}
+
+	now := time.Now()
+	maintenance := &shared_types.ApplicationMaintenance{
+		ID:                uuid.New(),
+		ApplicationID:     app.ID,
+		OrganizationID:    organizationID,
+		Domain:            domain,
+		Enabled:           req.Enabled,
+		HTMLBody:          req.HTMLBody,
+		RetryAfterSeconds: retryAfter,
+		AllowedIPs:        allowedIPs,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := t.Storage.UpsertApplicationMaintenance(maintenance); err != nil {
+		return nil, err
+	}
+	if err := t.reconcileRoutes(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	return maintenance, nil
+}
+
+// DeleteMaintenance removes the maintenance setting of an application or of
+// one of its domains. A domain then follows the application setting again.
+func (t *TaskService) DeleteMaintenance(ctx context.Context, applicationID uuid.UUID, domain string, organizationID uuid.UUID) error {
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	domain, err = maintenanceDomain(&app, domain)
+	if err != nil {
+		return err
+	}
+
+	settings, err := t.Storage.GetApplicationMaintenance(app.ID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, s := range settings {
+		if s.Domain == domain {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return types.ErrMaintenanceNotFound
+	}
+
+	if err := t.Storage.DeleteApplicationMaintenance(app.ID, domain); err != nil {
+		return err
+	}
+	return t.reconcileRoutes(ctx, organizationID)
+}
+
+// reconcileRoutes reconciles the organization's Caddy routes, which treats
+// maintenance pages and DNS providers as part of the desired state.
+func (t *TaskService) reconcileRoutes(ctx context.Context, organizationID uuid.UUID) error {
+	result, err := t.reconciler.ReconcileOrganization(ctx, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to update proxy routes: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Logger.Log(logger.Warning, "proxy reconciliation reported errors", strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
+
+// maintenanceDomain normalizes domain and checks that it belongs to app. An
+// empty domain stands for the whole application.
+func maintenanceDomain(app *shared_types.Application, domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return "", nil
+	}
+	for _, d := range app.Domains {
+		if strings.ToLower(d.Route()) == domain {
+			return domain, nil
+		}
+	}
+	return "", types.ErrDomainNotInApplication
+}
+
+// ParseMaintenanceAllowedIPs validates a maintenance allowlist and returns
+// it as canonical IP addresses and CIDR ranges, without duplicates.
+func ParseMaintenanceAllowedIPs(entries []string) ([]string, error) {
+	return parseIPRanges(entries, types.ErrInvalidMaintenanceIP)
+}
+
+// parseIPRanges returns entries as canonical IP addresses and CIDR ranges,
+// without duplicates, or invalid when one of them is neither.
+func parseIPRanges(entries []string, invalid error) ([]string, error) {
+	allowed := []string{}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var canonical string
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, invalid
+			}
+			canonical = prefix.Masked().String()
+		} else {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, invalid
+			}
+			canonical = addr.String()
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			allowed = append(allowed, canonical)
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,26 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+)
+
+func TestParseMaintenanceAllowedIPs(t *testing.T) {
+	got, err := ParseMaintenanceAllowedIPs([]string{" 10.0.0.1 ", "192.168.1.7/24", "", "10.0.0.1", "2001:db8::1"})
+	if err != nil {
+		t.Fatalf("ParseMaintenanceAllowedIPs: %v", err)
+	}
+	want := []string{"10.0.0.1", "192.168.1.0/24", "2001:db8::1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"10.0.0.256", "example.com", "10.0.0.0/33"} {
+		if _, err := ParseMaintenanceAllowedIPs([]string{invalid}); !errors.Is(err, types.ErrInvalidMaintenanceIP) {
+			t.Errorf("%q: expected ErrInvalidMaintenanceIP, got %v", invalid, err)
+		}
+	}
+}
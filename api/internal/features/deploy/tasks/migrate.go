@@ -0,0 +1,952 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	sshpkg "github.com/nixopus/nixopus/api/internal/features/ssh"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+// migrationHelperImage runs tar on both servers to copy named volumes.
+const migrationHelperImage = "alpine:3"
+
+const (
+	migrationActionRun      = "run"
+	migrationActionConfirm  = "confirm"
+	migrationActionRollback = "rollback"
+)
+
+// MigrationTaskPayload is the queued job of a migration. Action is one of
+// run, confirm or rollback.
+type MigrationTaskPayload struct {
+	MigrationID    string `json:"migration_id"`
+	OrganizationID string `json:"organization_id"`
+	Action         string `json:"action"`
+}
+
+// StartMigration records a migration of an application to another server
+// and queues it. The image and named volumes are copied to the target, the
+// application is deployed there and its routes are switched. The source is
+// left in place until the migration is confirmed.
+func (t *TaskService) StartMigration(ctx context.Context, request *types.MigrateApplicationRequest, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationMigration, error) {
+	if request.Transfer != "" && !shared_types.IsValidMigrationTransfer(request.Transfer) {
+		return nil, types.ErrInvalidMigrationTransfer
+	}
+
+	app, err := t.Storage.GetApplicationById(request.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+	if err := t.Storage.EnsureApplicationServers(app.ID, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to resolve application servers: %w", err)
+	}
+	servers, err := t.Storage.GetApplicationServers(app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve application servers: %w", err)
+	}
+
+	sourceID := primaryServer(servers).ServerID
+	if request.SourceServerID != nil {
+		sourceID = *request.SourceServerID
+	}
+	if sourceID == request.TargetServerID {
+		return nil, types.ErrMigrationSameServer
+	}
+	for _, s := range servers {
+		if s.ServerID == request.TargetServerID {
+			return nil, types.ErrMigrationTargetAssigned
+		}
+	}
+	for _, id := range []uuid.UUID{sourceID, request.TargetServerID} {
+		if _, err := sshpkg.GetSSHManagerForServer(ctx, organizationID, id); err != nil {
+			return nil, fmt.Errorf("%w: %s", types.ErrServerNotFound, id)
+		}
+	}
+
+	transfer, err := t.migrationTransfer(&app, shared_types.MigrationTransfer(request.Transfer))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	migration := &shared_types.ApplicationMigration{
+		ID:                uuid.New(),
+		ApplicationID:     app.ID,
+		OrganizationID:    organizationID,
+		SourceServerID:    sourceID,
+		TargetServerID:    request.TargetServerID,
+		Transfer:          transfer,
+		StopSource:        request.StopSource,
+		Status:            shared_types.MigrationPending,
+		Steps:             []shared_types.MigrationStep{},
+		Volumes:           []string{},
+		SourceRoutes:      []shared_types.MigrationRoute{},
+		PreviousServerIDs: []uuid.UUID{},
+		CreatedBy:         userID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	for _, s := range servers {
+		migration.PreviousServerIDs = append(migration.PreviousServerIDs, s.ServerID)
+		if s.IsPrimary {
+			id := s.ServerID
+			migration.PreviousPrimaryID = &id
+		}
+	}
+
+	if err := t.Storage.CreateApplicationMigration(migration); err != nil {
+		return nil, err
+	}
+	if err := t.enqueueMigration(migration, migrationActionRun); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// ConfirmMigration queues the removal of the application and its copied
+// volumes from the source server.
+func (t *TaskService) ConfirmMigration(migrationID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationMigration, error) {
+	migration, err := t.Storage.GetApplicationMigration(migrationID, organizationID)
+	if err != nil {
+		return nil, types.ErrMigrationNotFound
+	}
+	if migration.Status != shared_types.MigrationAwaitingConfirmation {
+		return nil, types.ErrMigrationNotAwaitingConfirmation
+	}
+	migration.Status = shared_types.MigrationConfirming
+	if err := t.Storage.UpdateApplicationMigration(migration); err != nil {
+		return nil, err
+	}
+	if err := t.enqueueMigration(migration, migrationActionConfirm); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// RollbackMigration queues moving the application back to the source server.
+// Migrations roll back on their own when a step fails, this covers the ones
+// awaiting confirmation and failed rollbacks.
+func (t *TaskService) RollbackMigration(migrationID uuid.UUID, organizationID uuid.UUID) (*shared_types.ApplicationMigration, error) {
+	migration, err := t.Storage.GetApplicationMigration(migrationID, organizationID)
+	if err != nil {
+		return nil, types.ErrMigrationNotFound
+	}
+	if migration.Status != shared_types.MigrationAwaitingConfirmation && migration.Status != shared_types.MigrationFailed {
+		return nil, types.ErrMigrationCannotRollback
+	}
+	migration.Status = shared_types.MigrationRollingBack
+	if err := t.Storage.UpdateApplicationMigration(migration); err != nil {
+		return nil, err
+	}
+	if err := t.enqueueMigration(migration, migrationActionRollback); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+func (t *TaskService) enqueueMigration(migration *shared_types.ApplicationMigration, action string) error {
+	err := MigrationQueue.Add(TaskMigration.WithArgs(context.Background(), MigrationTaskPayload{
+		MigrationID:    migration.ID.String(),
+		OrganizationID: migration.OrganizationID.String(),
+		Action:         action,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue migration: %w", err)
+	}
+	return nil
+}
+
+// migrationTransfer picks how the image reaches the target. Compose
+// applications are deployed from source on the target, so their images are
+// never transferred.
+func (t *TaskService) migrationTransfer(app *shared_types.Application, requested shared_types.MigrationTransfer) (shared_types.MigrationTransfer, error) {
+	if app.BuildPack == shared_types.DockerCompose {
+		return shared_types.MigrationTransferSSH, nil
+	}
+	if requested == shared_types.MigrationTransferSSH {
+		return requested, nil
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		if requested == shared_types.MigrationTransferS3 {
+			return "", types.ErrS3NotConfigured
+		}
+		return shared_types.MigrationTransferSSH, nil
+	}
+	deployment, err := t.Storage.GetLatestS3Deployment(app.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to query S3 deployment: %w", err)
+	}
+	if deployment == nil {
+		if requested == shared_types.MigrationTransferS3 {
+			return "", types.ErrNoImageToMigrate
+		}
+		return shared_types.MigrationTransferSSH, nil
+	}
+	return shared_types.MigrationTransferS3, nil
+}
+
+// HandleMigration runs a queued migration action.
+func (t *TaskService) HandleMigration(ctx context.Context, payload MigrationTaskPayload) error {
+	migrationID, err := uuid.Parse(payload.MigrationID)
+	if err != nil {
+		return err
+	}
+	organizationID, err := uuid.Parse(payload.OrganizationID)
+	if err != nil {
+		return err
+	}
+	migration, err := t.Storage.GetApplicationMigration(migrationID, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to load migration: %w", err)
+	}
+	app, err := t.Storage.GetApplicationById(migration.ApplicationID.String(), organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to load application: %w", err)
+	}
+
+	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+	r := &migrationRunner{
+		t:         t,
+		m:         migration,
+		app:       app,
+		orgCtx:    orgCtx,
+		sourceCtx: context.WithValue(orgCtx, shared_types.ServerIDKey, migration.SourceServerID.String()),
+		targetCtx: context.WithValue(orgCtx, shared_types.ServerIDKey, migration.TargetServerID.String()),
+	}
+
+	// A redelivered job finds the migration past the state it was queued for.
+	switch {
+	case payload.Action == migrationActionRun && migration.Status == shared_types.MigrationPending:
+		return r.run()
+	case payload.Action == migrationActionConfirm && migration.Status == shared_types.MigrationConfirming:
+		return r.confirm()
+	case payload.Action == migrationActionRollback && migration.Status == shared_types.MigrationRollingBack:
+		return r.rollback()
+	}
+	t.Logger.Log(logger.Warning, fmt.Sprintf("skipping %s of migration in status %s", payload.Action, migration.Status), payload.MigrationID)
+	return nil
+}
+
+// migrationRunner carries one migration through its steps. Every step is
+// saved with the migration as it starts and ends so progress can be polled.
+type migrationRunner struct {
+	t         *TaskService
+	m         *shared_types.ApplicationMigration
+	app       shared_types.Application
+	orgCtx    context.Context
+	sourceCtx context.Context
+	targetCtx context.Context
+	taskCtx   *TaskContext
+	// targetPort is the published port of the service deployed on the target.
+	targetPort string
+}
+
+func (r *migrationRunner) save() {
+	if err := r.t.Storage.UpdateApplicationMigration(r.m); err != nil {
+		r.t.Logger.Log(logger.Error, "failed to save migration: "+err.Error(), r.m.ID.String())
+	}
+}
+
+func (r *migrationRunner) log(message string) {
+	r.t.Logger.Log(logger.Info, message, r.m.ID.String())
+	if r.taskCtx != nil {
+		r.taskCtx.AddLog(message)
+	}
+}
+
+func (r *migrationRunner) startStep(step shared_types.MigrationStepName) {
+	r.m.Steps = append(r.m.Steps, shared_types.MigrationStep{
+		Step:      step,
+		Status:    shared_types.MigrationStepRunning,
+		StartedAt: time.Now(),
+	})
+	r.save()
+}
+
+func (r *migrationRunner) finishStep(status shared_types.MigrationStepStatus, message string) {
+	if len(r.m.Steps) == 0 {
+		return
+	}
+	now := time.Now()
+	step := &r.m.Steps[len(r.m.Steps)-1]
+	step.Status = status
+	step.Message = message
+	step.EndedAt = &now
+	r.save()
+}
+
+// run copies the application to the target and switches traffic to it. A
+// failed step rolls the migration back.
+func (r *migrationRunner) run() error {
+	r.m.Status = shared_types.MigrationRunning
+	r.save()
+
+	if err := r.snapshotRoutes(); err != nil {
+		return r.fail(err)
+	}
+	if err := r.createDeployment(); err != nil {
+		return r.fail(err)
+	}
+
+	steps := []struct {
+		name shared_types.MigrationStepName
+		fn   func() (string, error)
+	}{
+		{shared_types.MigrationStepImage, r.transferImage},
+		{shared_types.MigrationStepVolumes, r.copyVolumes},
+		{shared_types.MigrationStepDeploy, r.deploy},
+		{shared_types.MigrationStepRoutes, r.switchRoutes},
+	}
+	for _, s := range steps {
+		r.startStep(s.name)
+		message, err := s.fn()
+		if err != nil {
+			r.finishStep(shared_types.MigrationStepFailed, err.Error())
+			return r.fail(err)
+		}
+		status := shared_types.MigrationStepSucceeded
+		if strings.HasPrefix(message, "skipped") {
+			status = shared_types.MigrationStepSkipped
+		}
+		r.finishStep(status, message)
+	}
+
+	if err := r.assignServers(migratedServerIDs(r.m.PreviousServerIDs, r.m.SourceServerID, r.m.TargetServerID), r.migratedPrimary()); err != nil {
+		return r.fail(err)
+	}
+	r.taskCtx.LogAndUpdateStatus("Application deployed on the target server", shared_types.Deployed)
+
+	r.m.Status = shared_types.MigrationAwaitingConfirmation
+	r.save()
+	r.log("Migration finished, the source server keeps the application until the migration is confirmed")
+	r.taskCtx.FlushLogs()
+	return nil
+}
+
+// fail records err and rolls back what the migration changed so far.
+func (r *migrationRunner) fail(err error) error {
+	r.m.Error = err.Error()
+	r.log("Migration failed: " + err.Error())
+	if r.taskCtx != nil {
+		r.taskCtx.LogAndUpdateStatus("Migration failed, rolling back", shared_types.Failed)
+	}
+	if rollbackErr := r.rollback(); rollbackErr != nil {
+		return errors.Join(err, rollbackErr)
+	}
+	return err
+}
+
+func (r *migrationRunner) snapshotRoutes() error {
+	if len(r.app.Domains) == 0 {
+		return nil
+	}
+	current, err := caddy.GetCurrentDomains(r.orgCtx, nil, &r.t.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to read current proxy routes: %w", err)
+	}
+	r.m.SourceRoutes = applicationRoutes(current, r.app.Domains)
+	r.save()
+	return nil
+}
+
+// createDeployment records the deployment on the target so its logs and
+// status show up with the application's other deployments.
+func (r *migrationRunner) createDeployment() error {
+	now := time.Now()
+	deployment := shared_types.ApplicationDeployment{
+		ID:            uuid.New(),
+		ApplicationID: r.app.ID,
+		ServerID:      &r.m.TargetServerID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	deployments, err := r.t.Storage.GetApplicationDeployments(r.app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load deployments: %w", err)
+	}
+	if latest := latestDeployment(deployments); latest != nil {
+		deployment.CommitHash = latest.CommitHash
+		deployment.ImageS3Key = latest.ImageS3Key
+		deployment.ImageSize = latest.ImageSize
+	}
+	if err := r.t.Storage.AddApplicationDeployment(&deployment); err != nil {
+		return fmt.Errorf("failed to create deployment record: %w", err)
+	}
+	status := shared_types.ApplicationDeploymentStatus{
+		ID:                      uuid.New(),
+		ApplicationDeploymentID: deployment.ID,
+		Status:                  shared_types.Deploying,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	}
+	if err := r.t.Storage.AddApplicationDeploymentStatus(&status); err != nil {
+		return fmt.Errorf("failed to create deployment status: %w", err)
+	}
+
+	r.m.DeploymentID = &deployment.ID
+	r.save()
+	r.taskCtx = r.t.NewTaskContext(r.payload(deployment, &status))
+	r.taskCtx.LogAndUpdateStatus(fmt.Sprintf("Migrating application from server %s to %s", r.m.SourceServerID, r.m.TargetServerID), shared_types.Deploying)
+	return nil
+}
+
+func (r *migrationRunner) payload(deployment shared_types.ApplicationDeployment, status *shared_types.ApplicationDeploymentStatus) shared_types.TaskPayload {
+	return shared_types.TaskPayload{
+		CorrelationID:         uuid.NewString(),
+		Application:           r.app,
+		ApplicationDeployment: deployment,
+		Status:                status,
+	}
+}
+
+func (r *migrationRunner) transferImage() (string, error) {
+	if r.app.BuildPack == shared_types.DockerCompose {
+		return "skipped: compose images are pulled or built by the deployment on the target", nil
+	}
+	latestTag := r.app.Name + ":latest"
+
+	if r.m.Transfer == shared_types.MigrationTransferS3 {
+		deployment, err := r.t.Storage.GetLatestS3Deployment(r.app.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to query S3 deployment: %w", err)
+		}
+		if deployment == nil {
+			return "", types.ErrNoImageToMigrate
+		}
+		if err := r.t.LoadImageFromS3(r.targetCtx, deployment.ImageS3Key, r.taskCtx); err != nil {
+			return "", err
+		}
+		commitTag := CommitImageTag(r.app.Name, deployment.CommitHash)
+		if commitTag != latestTag {
+			if err := runOnServer(r.targetCtx, "docker tag "+utils.ShellQuote(commitTag)+" "+utils.ShellQuote(latestTag)); err != nil {
+				return "", fmt.Errorf("failed to tag image: %w", err)
+			}
+		}
+		return "loaded " + deployment.ImageS3Key + " from S3", nil
+	}
+
+	r.log("Streaming image " + latestTag + " from the source server")
+	err := streamBetweenServers(r.sourceCtx, r.targetCtx,
+		"docker save "+utils.ShellQuote(latestTag)+" | gzip",
+		"gunzip | docker load")
+	if err != nil {
+		return "", fmt.Errorf("failed to copy image: %w", err)
+	}
+	return "streamed " + latestTag + " over SSH", nil
+}
+
+// copyVolumes copies the named volumes mounted by the application's
+// containers on the source to volumes of the same name on the target. Volumes
+// that already exist on the target are refused, since a rollback removes
+// every volume the migration copied.
+func (r *migrationRunner) copyVolumes() (string, error) {
+	key, value := applicationContainerLabel(&r.app)
+	output, err := runOnServerOutput(r.sourceCtx, fmt.Sprintf(
+		`docker ps -aq --filter label=%s | xargs -r docker inspect --format '{{range .Mounts}}{{if eq .Type "volume"}}{{println .Name}}{{end}}{{end}}'`,
+		utils.ShellQuote(key+"="+value)))
+	if err != nil {
+		return "", fmt.Errorf("failed to list volumes: %w", err)
+	}
+	volumes := parseVolumeNames(output)
+	if len(volumes) == 0 {
+		return "skipped: the application has no named volumes", nil
+	}
+	for _, volume := range volumes {
+		existing, err := runOnServerOutput(r.targetCtx, fmt.Sprintf(
+			"docker volume inspect --format '{{.Name}}' %s 2>/dev/null || true", utils.ShellQuote(volume)))
+		if err != nil {
+			return "", fmt.Errorf("failed to check volume %s on the target: %w", volume, err)
+		}
+		if strings.TrimSpace(existing) != "" {
+			return "", fmt.Errorf("%w: %s", types.ErrMigrationVolumeExists, volume)
+		}
+	}
+
+	if r.m.StopSource {
+		if err := r.stopSource(); err != nil {
+			return "", err
+		}
+	}
+
+	for _, volume := range volumes {
+		r.log("Copying volume " + volume)
+		// Recorded first so a partly copied volume is removed on rollback.
+		r.m.Volumes = append(r.m.Volumes, volume)
+		r.save()
+		quoted := utils.ShellQuote(volume)
+		err := streamBetweenServers(r.sourceCtx, r.targetCtx,
+			fmt.Sprintf("docker run --rm -v %s:/from:ro %s tar -C /from -czf - .", quoted, migrationHelperImage),
+			fmt.Sprintf("docker volume create %s >/dev/null && docker run --rm -i -v %s:/to %s tar -C /to -xzf -", quoted, quoted, migrationHelperImage))
+		if err != nil {
+			return "", fmt.Errorf("failed to copy volume %s: %w", volume, err)
+		}
+	}
+	return fmt.Sprintf("copied %d volume(s): %s", len(volumes), strings.Join(volumes, ", ")), nil
+}
+
+// stopSource scales the application down on the source and remembers the
+// replicas of every service, or the stopped compose containers, for rollback.
+func (r *migrationRunner) stopSource() error {
+	r.log("Stopping the application on the source server")
+	replicas, err := applicationReplicas(r.sourceCtx, &r.app)
+	if err != nil {
+		return err
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+	r.m.SourceReplicas = replicas
+	r.save()
+	return stopApplication(r.sourceCtx, &r.app, replicas)
+}
+
+func (r *migrationRunner) startSource() error {
+	return startApplication(r.sourceCtx, &r.app, r.m.SourceReplicas)
+}
+
+func (r *migrationRunner) deploy() (string, error) {
+	deployment, err := r.t.Storage.GetApplicationDeploymentById(r.m.DeploymentID.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to load deployment: %w", err)
+	}
+	payload := r.payload(deployment, deployment.Status)
+
+	if r.app.BuildPack == shared_types.DockerCompose {
+		// The compose deployment routes the domains to the target itself.
+		if err := r.t.deployDockerCompose(r.targetCtx, payload, string(shared_types.DeploymentTypeCreate)); err != nil {
+			return "", err
+		}
+		return "deployed the compose application on the target", nil
+	}
+
+	result, err := r.t.AtomicUpdateContainer(r.targetCtx, payload, r.taskCtx)
+	if err != nil {
+		return "", err
+	}
+	r.targetPort = result.AvailablePort
+	return "service " + result.ContainerName + " is running on port " + result.AvailablePort, nil
+}
+
+func (r *migrationRunner) switchRoutes() (string, error) {
+	if len(r.app.Domains) == 0 {
+		return "skipped: the application has no domains", nil
+	}
+	if r.app.BuildPack == shared_types.DockerCompose {
+		return "routed by the compose deployment", nil
+	}
+
+	port, err := strconv.Atoi(r.targetPort)
+	if err != nil {
+		return "", fmt.Errorf("invalid target port %q: %w", r.targetPort, err)
+	}
+	upstreamHost, err := GetSSHHostForOrganization(r.targetCtx, r.app.OrganizationID)
+	if err != nil {
+		return "", err
+	}
+	var routes []caddy.DomainRoute
+	for _, d := range r.app.Domains {
+		if d.Domain == "" {
+			continue
+		}
+		routes = append(routes, caddy.DomainRoute{Domain: d.Domain, UpstreamDial: caddy.FormatDial(upstreamHost, port)})
+	}
+	if err := caddy.AddDomainsAtomic(r.orgCtx, nil, &r.t.Logger, routes); err != nil {
+		return "", fmt.Errorf("failed to switch proxy routes: %w", err)
+	}
+	for _, route := range routes {
+		r.log("Domain " + route.Domain + " now routes to " + route.UpstreamDial)
+	}
+	return fmt.Sprintf("routed %d domain(s) to %s", len(routes), caddy.FormatDial(upstreamHost, port)), nil
+}
+
+// migratedPrimary returns the primary server after the migration: the target
+// when the source was primary, otherwise the previous primary.
+func (r *migrationRunner) migratedPrimary() *uuid.UUID {
+	if r.m.PreviousPrimaryID == nil || *r.m.PreviousPrimaryID == r.m.SourceServerID {
+		return &r.m.TargetServerID
+	}
+	return r.m.PreviousPrimaryID
+}
+
+func (r *migrationRunner) assignServers(serverIDs []uuid.UUID, primary *uuid.UUID) error {
+	if len(serverIDs) == 0 {
+		return nil
+	}
+	if err := r.t.Storage.SetApplicationServers(r.app.ID, serverIDs, primary, r.app.RoutingStrategy); err != nil {
+		return fmt.Errorf("failed to update application servers: %w", err)
+	}
+	return nil
+}
+
+// confirm removes the application and the copied volumes from the source.
+// A failed cleanup leaves the migration awaiting confirmation so it can be
+// confirmed again.
+func (r *migrationRunner) confirm() error {
+	r.startStep(shared_types.MigrationStepCleanup)
+	if err := r.removeApplication(r.sourceCtx); err != nil {
+		return r.failCleanup(err)
+	}
+	if len(r.m.Volumes) > 0 {
+		if err := removeVolumes(r.sourceCtx, r.m.Volumes); err != nil {
+			return r.failCleanup(err)
+		}
+	}
+	r.finishStep(shared_types.MigrationStepSucceeded, "removed the application from the source server")
+
+	now := time.Now()
+	r.m.Status = shared_types.MigrationCompleted
+	r.m.Error = ""
+	r.m.CompletedAt = &now
+	r.save()
+	return nil
+}
+
+func (r *migrationRunner) failCleanup(err error) error {
+	r.finishStep(shared_types.MigrationStepFailed, err.Error())
+	r.m.Status = shared_types.MigrationAwaitingConfirmation
+	r.m.Error = err.Error()
+	r.save()
+	return err
+}
+
+// rollback moves traffic back to the source and removes what the migration
+// created on the target. Every part is attempted even if an earlier one
+// fails; the migration ends up failed when any of them did.
+func (r *migrationRunner) rollback() error {
+	r.m.Status = shared_types.MigrationRollingBack
+	r.startStep(shared_types.MigrationStepRollback)
+
+	var errs []error
+	if len(r.m.SourceRoutes) > 0 {
+		routes := make([]caddy.DomainRoute, 0, len(r.m.SourceRoutes))
+		for _, route := range r.m.SourceRoutes {
+			routes = append(routes, caddy.DomainRoute{Domain: route.Domain, UpstreamDial: route.UpstreamDial})
+		}
+		if err := caddy.AddDomainsAtomic(r.orgCtx, nil, &r.t.Logger, routes); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore proxy routes: %w", err))
+		}
+	}
+	if err := r.startSource(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to restart the source: %w", err))
+	}
+	if r.m.DeploymentID != nil {
+		if err := r.removeApplication(r.targetCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove the application from the target: %w", err))
+		}
+	}
+	if len(r.m.Volumes) > 0 {
+		if err := removeVolumes(r.targetCtx, r.m.Volumes); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove copied volumes from the target: %w", err))
+		}
+	}
+	if err := r.assignServers(r.m.PreviousServerIDs, r.m.PreviousPrimaryID); err != nil {
+		errs = append(errs, err)
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		r.finishStep(shared_types.MigrationStepFailed, err.Error())
+		r.m.Status = shared_types.MigrationFailed
+		r.m.Error = err.Error()
+	} else {
+		r.finishStep(shared_types.MigrationStepSucceeded, "the application runs on the source server again")
+		r.m.Status = shared_types.MigrationRolledBack
+	}
+	now := time.Now()
+	r.m.CompletedAt = &now
+	r.save()
+	if r.taskCtx != nil {
+		r.taskCtx.FlushLogs()
+	}
+	return err
+}
+
+// removeApplication removes the application's service, stack or compose
+// project from the server of ctx.
+func (r *migrationRunner) removeApplication(ctx context.Context) error {
+	switch {
+	case r.app.IsComposeStack():
+		return runOnServer(ctx, "docker stack rm "+utils.ShellQuote(r.app.ComposeStackName()))
+	case r.app.BuildPack == shared_types.DockerCompose:
+		dockerSvc, err := docker.GetDockerServiceFromContext(ctx)
+		if err != nil {
+			return err
+		}
+		ds, ok := dockerSvc.(*docker.DockerService)
+		if !ok {
+			return fmt.Errorf("compose is not supported by this docker service")
+		}
+		return ds.ComposeDownWithCallback(composeProjectForApplication(&r.app, applicationRepoPath(&r.app)), nil)
+	default:
+		name := utils.ShellQuote(r.app.Name)
+		return runOnServer(ctx, fmt.Sprintf("if docker service inspect %s >/dev/null 2>&1; then docker service rm %s; fi", name, name))
+	}
+}
+
+// removeVolumes removes volumes from the server of ctx. Containers of a
+// removed stack take a moment to go away, so it waits for them first.
+func removeVolumes(ctx context.Context, volumes []string) error {
+	cmd := fmt.Sprintf("for i in $(seq 1 30); do docker volume rm %s >/dev/null 2>&1 && exit 0; sleep 2; done; docker volume rm %s",
+		quoteAll(volumes), quoteAll(volumes))
+	return runOnServer(ctx, cmd)
+}
+
+// streamBetweenServers pipes the output of srcCmd on the server of srcCtx
+// into dstCmd on the server of dstCtx without staging it anywhere.
+func streamBetweenServers(srcCtx, dstCtx context.Context, srcCmd, dstCmd string) error {
+	srcManager, err := sshpkg.GetSSHManagerFromContext(srcCtx)
+	if err != nil {
+		return fmt.Errorf("failed to get SSH manager for the source: %w", err)
+	}
+	dstManager, err := sshpkg.GetSSHManagerFromContext(dstCtx)
+	if err != nil {
+		return fmt.Errorf("failed to get SSH manager for the target: %w", err)
+	}
+
+	srcClient, releaseSrc, err := srcManager.Borrow("")
+	if err != nil {
+		return fmt.Errorf("failed to connect to the source: %w", err)
+	}
+	defer releaseSrc()
+	dstClient, releaseDst, err := dstManager.Borrow("")
+	if err != nil {
+		return fmt.Errorf("failed to connect to the target: %w", err)
+	}
+	defer releaseDst()
+
+	srcSession, err := srcClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session on the source: %w", err)
+	}
+	defer srcSession.Close()
+	dstSession, err := dstClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session on the target: %w", err)
+	}
+	defer dstSession.Close()
+
+	stdout, err := srcSession.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	var srcStderr bytes.Buffer
+	srcSession.Stderr = &srcStderr
+	dstSession.Stdin = stdout
+
+	if err := srcSession.Start(srcCmd); err != nil {
+		return fmt.Errorf("failed to start on the source: %w", err)
+	}
+	output, dstErr := dstSession.CombinedOutput(dstCmd)
+	if dstErr != nil {
+		// Nothing reads the source output anymore, closing unblocks it.
+		srcSession.Close()
+	}
+	srcErr := srcSession.Wait()
+
+	if dstErr != nil {
+		return fmt.Errorf("%w: %s", dstErr, strings.TrimSpace(string(output)))
+	}
+	if srcErr != nil {
+		return fmt.Errorf("%w: %s", srcErr, strings.TrimSpace(srcStderr.String()))
+	}
+	return nil
+}
+
+// applicationReplicas returns the replicas of every service of the
+// application on the server of ctx. Compose applications outside a stack run
+// plain containers, which are returned with one replica each.
+func applicationReplicas(ctx context.Context, app *shared_types.Application) (map[string]uint64, error) {
+	if app.BuildPack == shared_types.DockerCompose && !app.IsComposeStack() {
+		key, value := applicationContainerLabel(app)
+		output, err := runOnServerOutput(ctx, fmt.Sprintf("docker ps -q --filter label=%s --format '{{.Names}}'", utils.ShellQuote(key+"="+value)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+		replicas := make(map[string]uint64)
+		for _, name := range parseVolumeNames(output) {
+			replicas[name] = 1
+		}
+		return replicas, nil
+	}
+
+	inspect := "docker service inspect --format '{{.Spec.Name}} {{.Spec.Mode.Replicated.Replicas}}' "
+	if app.IsComposeStack() {
+		inspect = fmt.Sprintf("docker service ls -q --filter label=com.docker.stack.namespace=%s | xargs -r ", utils.ShellQuote(app.ComposeStackName())) + inspect
+	} else {
+		inspect += utils.ShellQuote(app.Name)
+	}
+	output, err := runOnServerOutput(ctx, inspect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect services: %w", err)
+	}
+	return parseServiceReplicas(output), nil
+}
+
+// stopApplication scales the services returned by applicationReplicas to
+// zero, or stops the containers.
+func stopApplication(ctx context.Context, app *shared_types.Application, replicas map[string]uint64) error {
+	if len(replicas) == 0 {
+		return nil
+	}
+	if app.BuildPack == shared_types.DockerCompose && !app.IsComposeStack() {
+		return runOnServer(ctx, "docker stop "+quoteAll(sortedKeys(replicas)))
+	}
+	zero := make(map[string]uint64, len(replicas))
+	for name := range replicas {
+		zero[name] = 0
+	}
+	return runOnServer(ctx, "docker service scale -d "+scaleArgs(zero))
+}
+
+// startApplication brings back what stopApplication stopped.
+func startApplication(ctx context.Context, app *shared_types.Application, replicas map[string]uint64) error {
+	if len(replicas) == 0 {
+		return nil
+	}
+	if app.BuildPack == shared_types.DockerCompose && !app.IsComposeStack() {
+		return runOnServer(ctx, "docker start "+quoteAll(sortedKeys(replicas)))
+	}
+	return runOnServer(ctx, "docker service scale -d "+scaleArgs(replicas))
+}
+
+func runOnServer(ctx context.Context, cmd string) error {
+	_, err := runOnServerOutput(ctx, cmd)
+	return err
+}
+
+func runOnServerOutput(ctx context.Context, cmd string) (string, error) {
+	manager, err := sshpkg.GetSSHManagerFromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get SSH manager: %w", err)
+	}
+	output, err := manager.RunCommand(cmd)
+	if err != nil {
+		return output, fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// applicationContainerLabel returns the label that marks the containers of
+// the application.
+func applicationContainerLabel(app *shared_types.Application) (string, string) {
+	switch {
+	case app.IsComposeStack():
+		return "com.docker.stack.namespace", app.ComposeStackName()
+	case app.BuildPack == shared_types.DockerCompose:
+		return docker.ComposeWorkingDirLabel, composeProjectForApplication(app, applicationRepoPath(app)).Dir()
+	default:
+		return "com.docker.swarm.service.name", app.Name
+	}
+}
+
+// applicationRoutes returns the routes of current that serve one of domains.
+func applicationRoutes(current []caddy.DomainRoute, domains []*shared_types.ApplicationDomain) []shared_types.MigrationRoute {
+	names := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		names[d.Domain] = true
+	}
+	routes := []shared_types.MigrationRoute{}
+	for _, route := range current {
+		// A maintenance page without an upstream has no route to restore.
+		if names[route.Domain] && route.UpstreamDial != "" {
+			routes = append(routes, shared_types.MigrationRoute{Domain: route.Domain, UpstreamDial: route.UpstreamDial})
+		}
+	}
+	return routes
+}
+
+// migratedServerIDs replaces source with target in the application's servers.
+func migratedServerIDs(previous []uuid.UUID, source, target uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{target}
+	for _, id := range previous {
+		if id != source && id != target {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// latestDeployment returns the newest top-level deployment.
+func latestDeployment(deployments []shared_types.ApplicationDeployment) *shared_types.ApplicationDeployment {
+	var latest *shared_types.ApplicationDeployment
+	for i := range deployments {
+		d := &deployments[i]
+		if d.ParentDeploymentID != nil {
+			continue
+		}
+		if latest == nil || d.CreatedAt.After(latest.CreatedAt) {
+			latest = d
+		}
+	}
+	return latest
+}
+
+// parseVolumeNames returns the unique, non-empty lines of output in order.
+func parseVolumeNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseServiceReplicas reads "name replicas" lines. Global services have no
+// replica count and are left out.
+func parseServiceReplicas(output string) map[string]uint64 {
+	replicas := make(map[string]uint64)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		replicas[fields[0]] = n
+	}
+	return replicas
+}
+
+func scaleArgs(replicas map[string]uint64) string {
+	args := make([]string, 0, len(replicas))
+	for _, name := range sortedKeys(replicas) {
+		args = append(args, utils.ShellQuote(fmt.Sprintf("%s=%d", name, replicas[name])))
+	}
+	return strings.Join(args, " ")
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = utils.ShellQuote(v)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
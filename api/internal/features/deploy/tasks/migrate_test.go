@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestMigratedServerIDs(t *testing.T) {
+	source, target, other := uuid.New(), uuid.New(), uuid.New()
+
+	got := migratedServerIDs([]uuid.UUID{source, other}, source, target)
+	want := []uuid.UUID{target, other}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("migratedServerIDs = %v, want %v", got, want)
+	}
+}
+
+func TestApplicationRoutes(t *testing.T) {
+	current := []caddy.DomainRoute{
+		{Domain: "app.example.com", UpstreamDial: "10.0.0.1:3000"},
+		{Domain: "other.example.com", UpstreamDial: "10.0.0.1:4000"},
+	}
+	domains := []*shared_types.ApplicationDomain{{Domain: "app.example.com"}}
+
+	got := applicationRoutes(current, domains)
+	if len(got) != 1 || got[0].Domain != "app.example.com" || got[0].UpstreamDial != "10.0.0.1:3000" {
+		t.Errorf("applicationRoutes = %+v", got)
+	}
+}
+
+func TestLatestDeployment(t *testing.T) {
+	parent := uuid.New()
+	now := time.Now()
+	deployments := []shared_types.ApplicationDeployment{
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), CreatedAt: now, ParentDeploymentID: &parent},
+	}
+
+	got := latestDeployment(deployments)
+	if got == nil || got.ID != deployments[1].ID {
+		t.Errorf("latestDeployment = %+v, want the newest top-level deployment", got)
+	}
+	if latestDeployment(nil) != nil {
+		t.Error("latestDeployment(nil) should be nil")
+	}
+}
+
+func TestParseVolumeNames(t *testing.T) {
+	got := parseVolumeNames("data\n\n cache \ndata\n")
+	want := []string{"data", "cache"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVolumeNames = %v, want %v", got, want)
+	}
+}
+
+func TestParseServiceReplicasAndScaleArgs(t *testing.T) {
+	replicas := parseServiceReplicas("stack_web 3\nstack_agent\nstack_db 1\nbroken x\n")
+	want := map[string]uint64{"stack_web": 3, "stack_db": 1}
+	if !reflect.DeepEqual(replicas, want) {
+		t.Errorf("parseServiceReplicas = %v, want %v", replicas, want)
+	}
+
+	if got := scaleArgs(replicas); got != "'stack_db=1' 'stack_web=3'" {
+		t.Errorf("scaleArgs = %q", got)
+	}
+}
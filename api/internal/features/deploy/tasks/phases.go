@@ -0,0 +1,186 @@
+package tasks
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+var (
+	// BuildKit prints "#5 [2/4] RUN ..." (or "#5 [builder 2/4] ...") when a
+	// step starts and "#5 CACHED" when it was served from the cache.
+	buildKitStepPattern   = regexp.MustCompile(`^#(\d+) \[(?:\S+ )?\d+/\d+\]`)
+	buildKitCachedPattern = regexp.MustCompile(`^#(\d+) CACHED`)
+	// The classic builder prints "Step 2/4 : RUN ..." and " ---> Using cache".
+	classicStepPattern   = regexp.MustCompile(`^Step \d+/\d+ :`)
+	classicCachedPattern = regexp.MustCompile(`^-+> Using cache`)
+)
+
+// phaseTracker holds the running phase of a deployment. It has its own lock
+// so build output parsing does not contend with log buffering.
+type phaseTracker struct {
+	mu      sync.Mutex
+	current *shared_types.DeploymentPhase
+	steps   map[string]bool
+}
+
+// StartPhase ends the running phase as succeeded and starts the given one.
+// Starting the phase that is already running does nothing.
+func (tc *TaskContext) StartPhase(phase shared_types.DeploymentPhaseName) {
+	tc.phases.mu.Lock()
+	defer tc.phases.mu.Unlock()
+	tc.startPhaseLocked(phase, time.Now())
+}
+
+// FinishPhase ends the running phase as succeeded.
+func (tc *TaskContext) FinishPhase() {
+	tc.phases.mu.Lock()
+	defer tc.phases.mu.Unlock()
+	tc.endPhaseLocked(shared_types.PhaseSucceeded, time.Now())
+}
+
+// CurrentPhase returns the name of the running phase, or "" when none is running.
+func (tc *TaskContext) CurrentPhase() shared_types.DeploymentPhaseName {
+	tc.phases.mu.Lock()
+	defer tc.phases.mu.Unlock()
+	if tc.phases.current == nil {
+		return ""
+	}
+	return tc.phases.current.Phase
+}
+
+// RecordPhase stores a phase that ran outside the main deployment flow, such
+// as the asynchronous image export.
+func (tc *TaskContext) RecordPhase(phase shared_types.DeploymentPhaseName, startedAt, endedAt time.Time, status shared_types.DeploymentPhaseStatus) {
+	p := tc.newPhase(phase, startedAt)
+	phaseEnd(p, status, endedAt)
+	if tc.persistPhases() {
+		if err := tc.service.Storage.AddDeploymentPhase(p); err != nil {
+			tc.service.Logger.Log(logger.Error, "Failed to record deployment phase: "+err.Error(), "")
+		}
+	}
+}
+
+// observeStatus moves between phases on deployment status transitions.
+func (tc *TaskContext) observeStatus(status shared_types.Status) {
+	tc.phases.mu.Lock()
+	defer tc.phases.mu.Unlock()
+
+	now := time.Now()
+	switch status {
+	case shared_types.Cloning:
+		tc.startPhaseLocked(shared_types.PhaseClone, now)
+	case shared_types.Building:
+		tc.startPhaseLocked(shared_types.PhaseBuild, now)
+	case shared_types.Deploying:
+		if cur := tc.phases.current; cur == nil || cur.Phase != shared_types.PhaseHealth {
+			tc.startPhaseLocked(shared_types.PhaseDeploy, now)
+		}
+	case shared_types.Deployed, shared_types.Running:
+		tc.endPhaseLocked(shared_types.PhaseSucceeded, now)
+	case shared_types.Failed:
+		tc.endPhaseLocked(shared_types.PhaseFailed, now)
+	case shared_types.Cancelled:
+		tc.endPhaseLocked(shared_types.PhaseCancelled, now)
+	}
+}
+
+// observeLog starts the health phase when the rollout waits for the service
+// and counts Docker build steps in the output of the running phase.
+func (tc *TaskContext) observeLog(message string) {
+	tc.phases.mu.Lock()
+	defer tc.phases.mu.Unlock()
+
+	if strings.HasPrefix(message, "Waiting for service to become healthy") {
+		tc.startPhaseLocked(shared_types.PhaseHealth, time.Now())
+		return
+	}
+	if tc.phases.current == nil {
+		return
+	}
+	for _, line := range strings.Split(message, "\n") {
+		tc.countBuildStep(strings.TrimSpace(strings.TrimPrefix(line, "Build: ")))
+	}
+}
+
+func (tc *TaskContext) countBuildStep(line string) {
+	p := tc.phases.current
+	if m := buildKitStepPattern.FindStringSubmatch(line); m != nil {
+		if !tc.phases.steps[m[1]] {
+			tc.phases.steps[m[1]] = true
+			p.BuildSteps++
+		}
+		return
+	}
+	if m := buildKitCachedPattern.FindStringSubmatch(line); m != nil {
+		if tc.phases.steps[m[1]] {
+			p.CachedSteps++
+		}
+		return
+	}
+	if classicStepPattern.MatchString(line) {
+		p.BuildSteps++
+		return
+	}
+	if classicCachedPattern.MatchString(line) {
+		p.CachedSteps++
+	}
+}
+
+func (tc *TaskContext) startPhaseLocked(phase shared_types.DeploymentPhaseName, now time.Time) {
+	if cur := tc.phases.current; cur != nil && cur.Phase == phase {
+		return
+	}
+	tc.endPhaseLocked(shared_types.PhaseSucceeded, now)
+
+	p := tc.newPhase(phase, now)
+	tc.phases.current = p
+	tc.phases.steps = make(map[string]bool)
+	if tc.persistPhases() {
+		if err := tc.service.Storage.AddDeploymentPhase(p); err != nil {
+			tc.service.Logger.Log(logger.Error, "Failed to add deployment phase: "+err.Error(), "")
+		}
+	}
+}
+
+func (tc *TaskContext) endPhaseLocked(status shared_types.DeploymentPhaseStatus, now time.Time) {
+	p := tc.phases.current
+	if p == nil {
+		return
+	}
+	tc.phases.current = nil
+	phaseEnd(p, status, now)
+	if tc.persistPhases() {
+		if err := tc.service.Storage.UpdateDeploymentPhase(p); err != nil {
+			tc.service.Logger.Log(logger.Error, "Failed to update deployment phase: "+err.Error(), "")
+		}
+	}
+}
+
+func (tc *TaskContext) newPhase(phase shared_types.DeploymentPhaseName, startedAt time.Time) *shared_types.DeploymentPhase {
+	return &shared_types.DeploymentPhase{
+		ID:                      uuid.New(),
+		ApplicationDeploymentID: tc.deploymentID,
+		ApplicationID:           tc.applicationID,
+		Phase:                   phase,
+		Status:                  shared_types.PhaseRunning,
+		StartedAt:               startedAt,
+	}
+}
+
+// persistPhases reports whether phases are stored. Contexts without a
+// deployment record only track them in memory.
+func (tc *TaskContext) persistPhases() bool {
+	return tc.service != nil && tc.deploymentID != uuid.Nil
+}
+
+func phaseEnd(p *shared_types.DeploymentPhase, status shared_types.DeploymentPhaseStatus, endedAt time.Time) {
+	p.Status = status
+	p.EndedAt = &endedAt
+	p.DurationMs = endedAt.Sub(p.StartedAt).Milliseconds()
+}
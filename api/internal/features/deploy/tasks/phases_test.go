@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"testing"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestPhaseTransitions(t *testing.T) {
+	tc := &TaskContext{}
+
+	tc.observeStatus(shared_types.Cloning)
+	if got := tc.CurrentPhase(); got != shared_types.PhaseClone {
+		t.Fatalf("phase after cloning = %q", got)
+	}
+	tc.observeStatus(shared_types.Building)
+	tc.observeStatus(shared_types.Deploying)
+	tc.observeLog("Waiting for service to become healthy (timeout: 2m0s)")
+	if got := tc.CurrentPhase(); got != shared_types.PhaseHealth {
+		t.Fatalf("phase while waiting for health = %q", got)
+	}
+	tc.observeStatus(shared_types.Deploying)
+	if got := tc.CurrentPhase(); got != shared_types.PhaseHealth {
+		t.Errorf("deploying status should not end the health phase, got %q", got)
+	}
+	tc.observeStatus(shared_types.Deployed)
+	if got := tc.CurrentPhase(); got != "" {
+		t.Errorf("phase after deployed = %q, want none", got)
+	}
+
+	tc.StartPhase(shared_types.PhaseRoute)
+	tc.observeStatus(shared_types.Failed)
+	if got := tc.CurrentPhase(); got != "" {
+		t.Errorf("phase after failure = %q, want none", got)
+	}
+}
+
+func TestBuildStepParsing(t *testing.T) {
+	tests := []struct {
+		name       string
+		logs       []string
+		wantSteps  int
+		wantCached int
+	}{
+		{
+			name: "buildkit",
+			logs: []string{
+				"Build: #1 [internal] load build definition from Dockerfile",
+				"Build: #5 [1/3] FROM docker.io/library/golang:1.24",
+				"Build: #6 [builder 2/3] COPY . .",
+				"Build: #6 CACHED",
+				"Build: #7 [builder 3/3] RUN go build ./...",
+				"Build: #7 0.512 go: downloading example.com/mod",
+				"Build: #7 [builder 3/3] RUN go build ./...",
+				"Build: #7 DONE 12.3s",
+			},
+			wantSteps:  3,
+			wantCached: 1,
+		},
+		{
+			name: "classic",
+			logs: []string{
+				"Build: Step 1/3 : FROM alpine\n",
+				"Build:  ---> 9c6f07244728\n",
+				"Build: Step 2/3 : RUN apk add curl\n",
+				"Build:  ---> Using cache\n",
+				"Build: Step 3/3 : CMD [\"sh\"]\n",
+				"Build:  ---> Running in 3f1a2b\n",
+			},
+			wantSteps:  3,
+			wantCached: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &TaskContext{}
+			tc.observeStatus(shared_types.Building)
+			for _, line := range tt.logs {
+				tc.observeLog(line)
+			}
+			p := tc.phases.current
+			if p.BuildSteps != tt.wantSteps || p.CachedSteps != tt.wantCached {
+				t.Errorf("steps = %d cached = %d, want %d and %d", p.BuildSteps, p.CachedSteps, tt.wantSteps, tt.wantCached)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/pkg/sftp"
+	"gopkg.in/yaml.v3"
+)
+
+// composePrivateNetworkKey is the key of the private network inside the
+// generated compose override. The network itself is external.
+const composePrivateNetworkKey = "nixopus_private"
+
+// ensurePrivateNetwork creates the application's private network on the
+// deploy server if needed and returns its name.
+func (t *TaskService) ensurePrivateNetwork(ctx context.Context, app *shared_types.Application) (string, error) {
+	dockerSvc, err := t.getDockerService(ctx)
+	if err != nil {
+		return "", err
+	}
+	name := app.PrivateNetworkName()
+	if err := dockerSvc.EnsurePrivateNetwork(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// privateNetworkAttachment joins a Swarm service to the application's private
+// network under its internal hostname.
+func privateNetworkAttachment(app *shared_types.Application, networkName string) swarm.NetworkAttachmentConfig {
+	return swarm.NetworkAttachmentConfig{
+		Target:  networkName,
+		Aliases: []string{app.InternalHostname("")},
+	}
+}
+
+// attachComposePrivateNetwork writes a compose override that joins every
+// service of the project to the application's private network and to the
+// networks of its attached databases, and appends it to the project files.
+// It returns the services that could not join.
+func (t *TaskService) attachComposePrivateNetwork(ctx context.Context, app *shared_types.Application, project *docker.ComposeProject) ([]string, error) {
+	networkName, err := t.ensurePrivateNetwork(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	databaseNetworks, err := t.applicationDatabaseNetworks(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []string
+	err = utils.WithSFTPClientFromPool(ctx, func(client *sftp.Client) error {
+		layers := make([][]ParsedComposeService, 0, len(project.Files))
+		for _, f := range project.Files {
+			data, err := readRemoteFile(client, f)
+			if err != nil {
+				return fmt.Errorf("failed to read compose file %s: %w", f, err)
+			}
+			parsed, err := ParseComposeYAML(data)
+			if err != nil {
+				return err
+			}
+			layers = append(layers, parsed)
+		}
+		services := FilterComposeProfiles(MergeComposeServices(layers...), project.Profiles)
+
+		var override []byte
+		override, skipped, err = composePrivateNetworkOverride(app, networkName, databaseNetworks, services)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(project.Dir(), ".nixopus-network.yml")
+		if err := writeRemoteFile(client, path, override); err != nil {
+			return fmt.Errorf("failed to write network override: %w", err)
+		}
+		project.Files = append(project.Files, path)
+		return nil
+	})
+	return skipped, err
+}
+
+type composeNetworkOverride struct {
+	Services map[string]composeServiceNetworks `yaml:"services"`
+	Networks map[string]composeExternalNetwork `yaml:"networks"`
+}
+
+type composeServiceNetworks struct {
+	Networks map[string]*composeNetworkAttachment `yaml:"networks"`
+}
+
+type composeNetworkAttachment struct {
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+type composeExternalNetwork struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+// composePrivateNetworkOverride returns a compose file that adds every service
+// to the private network with the alias <service>.<app alias>.internal, and
+// to each of databaseNetworks. Services that did not declare networks keep
+// the default network. Services using another network stack through
+// network_mode cannot join and are returned as skipped.
+func composePrivateNetworkOverride(app *shared_types.Application, networkName string, databaseNetworks []string, services []ParsedComposeService) ([]byte, []string, error) {
+	override := composeNetworkOverride{
+		Services: make(map[string]composeServiceNetworks),
+		Networks: map[string]composeExternalNetwork{
+			composePrivateNetworkKey: {Name: networkName, External: true},
+		},
+	}
+	for i, name := range databaseNetworks {
+		override.Networks[composeDatabaseNetworkKey(i)] = composeExternalNetwork{Name: name, External: true}
+	}
+
+	var skipped []string
+	for _, svc := range services {
+		if svc.NetworkMode != "" {
+			skipped = append(skipped, svc.ServiceName)
+			continue
+		}
+		networks := map[string]*composeNetworkAttachment{
+			composePrivateNetworkKey: {Aliases: []string{app.InternalHostname(svc.ServiceName)}},
+		}
+		for i := range databaseNetworks {
+			networks[composeDatabaseNetworkKey(i)] = nil
+		}
+		if len(svc.Networks) == 0 {
+			networks["default"] = nil
+		}
+		override.Services[svc.ServiceName] = composeServiceNetworks{Networks: networks}
+	}
+
+	data, err := yaml.Marshal(override)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write network override: %w", err)
+	}
+	return data, skipped, nil
+}
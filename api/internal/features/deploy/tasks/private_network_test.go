@@ -0,0 +1,92 @@
+package tasks
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+func TestComposePrivateNetworkOverride(t *testing.T) {
+	app := &shared_types.Application{Name: "Shop API", BuildPack: shared_types.DockerCompose}
+	services := []ParsedComposeService{
+		{ServiceName: "web"},
+		{ServiceName: "worker", Networks: []string{"backend"}},
+		{ServiceName: "agent", NetworkMode: "host"},
+	}
+
+	data, skipped, err := composePrivateNetworkOverride(app, "nixopus-org-abc", nil, services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(skipped, []string{"agent"}) {
+		t.Errorf("skipped = %v, want [agent]", skipped)
+	}
+
+	var got composeNetworkOverride
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if n := got.Networks[composePrivateNetworkKey]; n.Name != "nixopus-org-abc" || !n.External {
+		t.Errorf("private network = %+v", n)
+	}
+	if _, ok := got.Services["agent"]; ok {
+		t.Error("agent uses network_mode and must not join the private network")
+	}
+
+	web := got.Services["web"].Networks
+	if _, ok := web["default"]; !ok {
+		t.Error("web declares no networks and should keep the default network")
+	}
+	if aliases := web[composePrivateNetworkKey].Aliases; !slices.Equal(aliases, []string{"web.shop-api.internal"}) {
+		t.Errorf("web aliases = %v", aliases)
+	}
+	if _, ok := got.Services["worker"].Networks["default"]; ok {
+		t.Error("worker declares its own networks and should not be added to default")
+	}
+}
+
+func TestPrivateNetworkName(t *testing.T) {
+	orgID := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	familyID := uuid.MustParse("aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee")
+	app := &shared_types.Application{OrganizationID: orgID, FamilyID: &familyID}
+
+	if got := app.PrivateNetworkName(); got != "nixopus-org-111111112222" {
+		t.Errorf("organization scope network = %s", got)
+	}
+	app.PrivateNetworkScope = shared_types.PrivateNetworkFamily
+	if got := app.PrivateNetworkName(); got != "nixopus-family-aaaaaaaabbbb" {
+		t.Errorf("family scope network = %s", got)
+	}
+	app.FamilyID = nil
+	if got := app.PrivateNetworkName(); got != "nixopus-org-111111112222" {
+		t.Errorf("family scope without family = %s, want the organization network", got)
+	}
+}
+
+func TestInternalAliasWithSuffix(t *testing.T) {
+	if got := shared_types.InternalAliasWithSuffix("shop-api", 2); got != "shop-api-2" {
+		t.Errorf("suffixed alias = %s", got)
+	}
+	long := shared_types.InternalAliasFromName(strings.Repeat("a", 60) + "-" + strings.Repeat("b", 10))
+	got := shared_types.InternalAliasWithSuffix(long, 12)
+	if len(got) > 63 || !strings.HasSuffix(got, "a-12") {
+		t.Errorf("long suffixed alias = %s (%d)", got, len(got))
+	}
+}
+
+func TestPrivateNetworkOwner(t *testing.T) {
+	orgID := uuid.New()
+	familyID := uuid.New()
+	app := &shared_types.Application{OrganizationID: orgID, FamilyID: &familyID}
+	if app.PrivateNetworkOwner() != orgID {
+		t.Error("organization scoped application should be owned by its organization")
+	}
+	app.PrivateNetworkScope = shared_types.PrivateNetworkFamily
+	if app.PrivateNetworkOwner() != familyID {
+		t.Error("family scoped application should be owned by its family")
+	}
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	// defaultRateLimitWindow is the window of a rate limit that does not
+	// choose one.
+	defaultRateLimitWindow = 60
+	maxRateLimitWindow     = 86400
+	maxUpstreamTimeout     = 3600
+)
+
+// proxyEncodings are the response encodings Caddy can be asked to use.
+var proxyEncodings = []string{"gzip", "zstd"}
+
+// UpdateProxySettings replaces the proxy settings of an application and
+// applies them to the Caddy routes of its domains.
+func (t *TaskService) UpdateProxySettings(ctx context.Context, req *types.UpdateProxySettingsRequest, organizationID uuid.UUID) (*shared_types.ApplicationProxySettings, error) {
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	now := time.Now()
+	settings := &shared_types.ApplicationProxySettings{
+		ID:             uuid.New(),
+		ApplicationID:  app.ID,
+		OrganizationID: organizationID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := BuildProxySettings(settings, req); err != nil {
+		return nil, err
+	}
+	if err := t.Storage.UpsertApplicationProxySettings(settings); err != nil {
+		return nil, err
+	}
+	if err := t.reconcileRoutes(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// DeleteProxySettings removes the proxy settings of an application, which
+// puts the Caddy defaults back.
+func (t *TaskService) DeleteProxySettings(ctx context.Context, applicationID uuid.UUID, organizationID uuid.UUID) error {
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	if _, err := t.Storage.GetApplicationProxySettings(app.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ErrProxySettingsNotFound
+		}
+		return err
+	}
+
+	if err := t.Storage.DeleteApplicationProxySettings(app.ID); err != nil {
+		return err
+	}
+	return t.reconcileRoutes(ctx, organizationID)
+}
+
+// BuildProxySettings validates req and fills settings from it, with header
+// names canonicalized.
+func BuildProxySettings(settings *shared_types.ApplicationProxySettings, req *types.UpdateProxySettingsRequest) error {
+	settings.ResponseHeaders = make(map[string]string, len(req.ResponseHeaders))
+	for name, value := range req.ResponseHeaders {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) ||
+			strings.ContainsAny(value, "{}") {
+			return types.ErrInvalidProxyHeader
+		}
+		settings.ResponseHeaders[name] = value
+	}
+	settings.RemoveResponseHeaders = []string{}
+	for _, name := range req.RemoveResponseHeaders {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if !httpguts.ValidHeaderFieldName(name) {
+			return types.ErrInvalidProxyHeader
+		}
+		if _, set := settings.ResponseHeaders[name]; set {
+			return types.ErrInvalidProxyHeader
+		}
+		if !slices.Contains(settings.RemoveResponseHeaders, name) {
+			settings.RemoveResponseHeaders = append(settings.RemoveResponseHeaders, name)
+		}
+	}
+
+	settings.Encodings = []string{}
+	for _, encoding := range req.Encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if !slices.Contains(proxyEncodings, encoding) {
+			return types.ErrInvalidProxyEncoding
+		}
+		if !slices.Contains(settings.Encodings, encoding) {
+			settings.Encodings = append(settings.Encodings, encoding)
+		}
+	}
+
+	if req.MaxRequestBodyBytes < 0 {
+		return types.ErrInvalidRequestBodyLimit
+	}
+	settings.MaxRequestBodyBytes = req.MaxRequestBodyBytes
+
+	for _, timeout := range []int{req.DialTimeoutSeconds, req.ResponseTimeoutSeconds} {
+		if timeout < 0 || timeout > maxUpstreamTimeout {
+			return types.ErrInvalidUpstreamTimeout
+		}
+	}
+	settings.DialTimeoutSeconds = req.DialTimeoutSeconds
+	settings.ResponseTimeoutSeconds = req.ResponseTimeoutSeconds
+
+	if req.RateLimitRequests < 0 {
+		return types.ErrInvalidRateLimit
+	}
+	settings.RateLimitRequests = req.RateLimitRequests
+	settings.RateLimitWindowSeconds = 0
+	if req.RateLimitRequests > 0 {
+		window := req.RateLimitWindowSeconds
+		if window == 0 {
+			window = defaultRateLimitWindow
+		}
+		if window < 0 || window > maxRateLimitWindow {
+			return types.ErrInvalidRateLimit
+		}
+		settings.RateLimitWindowSeconds = window
+	}
+
+	if len(settings.ResponseHeaders) == 0 && len(settings.RemoveResponseHeaders) == 0 &&
+		len(settings.Encodings) == 0 && settings.MaxRequestBodyBytes == 0 &&
+		settings.DialTimeoutSeconds == 0 && settings.ResponseTimeoutSeconds == 0 &&
+		settings.RateLimitRequests == 0 {
+		return types.ErrEmptyProxySettings
+	}
+	return nil
+}
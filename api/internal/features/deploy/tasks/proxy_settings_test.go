@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestBuildProxySettings(t *testing.T) {
+	settings := &shared_types.ApplicationProxySettings{}
+	err := BuildProxySettings(settings, &types.UpdateProxySettingsRequest{
+		ResponseHeaders:       map[string]string{"access-control-allow-origin": " https://example.com "},
+		RemoveResponseHeaders: []string{"server", "Server"},
+		Encodings:             []string{"ZSTD", "gzip", "zstd"},
+		RateLimitRequests:     10,
+	})
+	if err != nil {
+		t.Fatalf("BuildProxySettings: %v", err)
+	}
+	if settings.ResponseHeaders["Access-Control-Allow-Origin"] != "https://example.com" {
+		t.Errorf("headers = %v", settings.ResponseHeaders)
+	}
+	if !slices.Equal(settings.RemoveResponseHeaders, []string{"Server"}) || !slices.Equal(settings.Encodings, []string{"zstd", "gzip"}) {
+		t.Errorf("removed headers = %v, encodings = %v", settings.RemoveResponseHeaders, settings.Encodings)
+	}
+	if settings.RateLimitWindowSeconds != defaultRateLimitWindow {
+		t.Errorf("window = %d, want %d", settings.RateLimitWindowSeconds, defaultRateLimitWindow)
+	}
+
+	tests := []struct {
+		name string
+		req  types.UpdateProxySettingsRequest
+		want error
+	}{
+		{name: "nothing set", want: types.ErrEmptyProxySettings},
+		{name: "placeholder value", req: types.UpdateProxySettingsRequest{ResponseHeaders: map[string]string{"X-Env": "{env.SECRET}"}}, want: types.ErrInvalidProxyHeader},
+		{name: "set and removed", req: types.UpdateProxySettingsRequest{ResponseHeaders: map[string]string{"Server": "x"}, RemoveResponseHeaders: []string{"server"}}, want: types.ErrInvalidProxyHeader},
+		{name: "unknown encoding", req: types.UpdateProxySettingsRequest{Encodings: []string{"br"}}, want: types.ErrInvalidProxyEncoding},
+		{name: "negative body limit", req: types.UpdateProxySettingsRequest{MaxRequestBodyBytes: -1}, want: types.ErrInvalidRequestBodyLimit},
+		{name: "long timeout", req: types.UpdateProxySettingsRequest{DialTimeoutSeconds: 7200}, want: types.ErrInvalidUpstreamTimeout},
+		{name: "bad window", req: types.UpdateProxySettingsRequest{RateLimitRequests: 5, RateLimitWindowSeconds: -1}, want: types.ErrInvalidRateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := BuildProxySettings(&shared_types.ApplicationProxySettings{}, &tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -122,9 +122,9 @@ func (s *TaskService) HandleReDeployDockerfileDeployment(ctx context.Context, Ta
 	}
 
 	taskCtx.AddLog("Container updated successfully for application " + TaskPayload.Application.Name + " with container id " + containerResult.ContainerID)
-	taskCtx.LogAndUpdateStatus("Redeploy completed successfully", shared_types.Deployed)
 
 	if len(TaskPayload.Application.Domains) > 0 {
+		taskCtx.StartPhase(shared_types.PhaseRoute)
 		port, err := strconv.Atoi(containerResult.AvailablePort)
 		if err != nil {
 			taskCtx.LogAndUpdateStatus("Failed to convert port to int: "+err.Error(), shared_types.Failed)
@@ -159,8 +159,12 @@ func (s *TaskService) HandleReDeployDockerfileDeployment(ctx context.Context, Ta
 		for _, r := range routes {
 			taskCtx.AddLog("Domain " + r.Domain + " added successfully with TLS")
 		}
+		taskCtx.FinishPhase()
 	}
 
+	// Deployed is terminal for status watchers, so it is only set once the
+	// domains are routed.
+	taskCtx.LogAndUpdateStatus("Redeploy completed successfully", shared_types.Deployed)
 	return nil
 }
 
@@ -0,0 +1,276 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// defaultRedirectStatus is the status of a rule that does not choose one.
+const defaultRedirectStatus = 301
+
+// CreateRedirect adds a redirect rule to an application and routes it in
+// Caddy.
+func (t *TaskService) CreateRedirect(ctx context.Context, req *types.CreateRedirectRequest, organizationID uuid.UUID) (*shared_types.ApplicationRedirect, error) {
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	now := time.Now()
+	redirect := &shared_types.ApplicationRedirect{
+		ID:             uuid.New(),
+		ApplicationID:  app.ID,
+		OrganizationID: organizationID,
+		SourceDomain:   req.SourceDomain,
+		SourcePath:     req.SourcePath,
+		TargetDomain:   req.TargetDomain,
+		TargetPath:     req.TargetPath,
+		StatusCode:     req.StatusCode,
+		PreservePath:   req.PreservePath,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = t.saveRedirect(ctx, &app, redirect, func() error {
+		return t.Storage.CreateApplicationRedirect(redirect)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// UpdateRedirect replaces the source, target and options of a redirect rule
+// and updates its Caddy routes.
+func (t *TaskService) UpdateRedirect(ctx context.Context, req *types.UpdateRedirectRequest, organizationID uuid.UUID) (*shared_types.ApplicationRedirect, error) {
+	redirect, err := t.getRedirect(organizationID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	app, err := t.Storage.GetApplicationById(redirect.ApplicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	redirect.SourceDomain = req.SourceDomain
+	redirect.SourcePath = req.SourcePath
+	redirect.TargetDomain = req.TargetDomain
+	redirect.TargetPath = req.TargetPath
+	redirect.StatusCode = req.StatusCode
+	redirect.PreservePath = req.PreservePath
+	redirect.UpdatedAt = time.Now()
+	err = t.saveRedirect(ctx, &app, redirect, func() error {
+		return t.Storage.UpdateApplicationRedirect(redirect)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// DeleteRedirect removes a redirect rule. An alias domain left without
+// rules is removed from Caddy.
+func (t *TaskService) DeleteRedirect(ctx context.Context, organizationID uuid.UUID, id uuid.UUID) error {
+	if _, err := t.getRedirect(organizationID, id); err != nil {
+		return err
+	}
+	return t.updateRedirects(ctx, organizationID, func() error {
+		return t.Storage.DeleteApplicationRedirect(organizationID, id)
+	})
+}
+
+func (t *TaskService) getRedirect(organizationID uuid.UUID, id uuid.UUID) (*shared_types.ApplicationRedirect, error) {
+	redirect, err := t.Storage.GetApplicationRedirect(organizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrRedirectNotFound
+		}
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// saveRedirect validates redirect against app and its other rules, then
+// stores it with save.
+func (t *TaskService) saveRedirect(ctx context.Context, app *shared_types.Application, redirect *shared_types.ApplicationRedirect, save func() error) error {
+	appDomains := make([]string, 0, len(app.Domains))
+	for _, d := range app.Domains {
+		appDomains = append(appDomains, strings.ToLower(d.Route()))
+	}
+	if err := NormalizeRedirect(redirect, appDomains); err != nil {
+		return err
+	}
+	for _, domain := range []string{redirect.SourceDomain, redirect.TargetDomain} {
+		if domain == "" {
+			continue
+		}
+		if valid, err := t.Storage.IsDomainValid(domain); err != nil || !valid {
+			return types.ErrInvalidRedirectDomain
+		}
+	}
+	if err := t.checkRedirectSource(app, redirect, appDomains); err != nil {
+		return err
+	}
+	return t.updateRedirects(ctx, redirect.OrganizationID, save)
+}
+
+// checkRedirectSource makes sure no other rule of app has the same source,
+// and that an alias source domain is not used by another application.
+func (t *TaskService) checkRedirectSource(app *shared_types.Application, redirect *shared_types.ApplicationRedirect, appDomains []string) error {
+	existing, err := t.Storage.GetApplicationRedirects(app.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID != redirect.ID && other.SourceDomain == redirect.SourceDomain && other.SourcePath == redirect.SourcePath {
+			return types.ErrRedirectExists
+		}
+	}
+
+	if redirect.SourceDomain == "" || slices.Contains(appDomains, redirect.SourceDomain) {
+		return nil
+	}
+	routed, err := t.Storage.GetDomainsByHost(redirect.SourceDomain, app.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if len(routed) > 0 {
+		return types.ErrRedirectDomainTaken
+	}
+	others, err := t.Storage.GetRedirectsBySourceDomain(redirect.SourceDomain)
+	if err != nil {
+		return err
+	}
+	ownAlias := false
+	for _, other := range others {
+		if other.ApplicationID != app.ID {
+			return types.ErrRedirectDomainTaken
+		}
+		ownAlias = true
+	}
+	if ownAlias {
+		return nil
+	}
+	// Hosts of other organizations are not listed above.
+	taken, err := t.Storage.IsDomainAlreadyTaken(redirect.SourceDomain, app.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return types.ErrRedirectDomainTaken
+	}
+	return nil
+}
+
+// updateRedirects runs change and reconciles the organization's routes.
+// Alias domains that no rule uses anymore are removed from Caddy first,
+// since reconciliation only adds and updates routes.
+func (t *TaskService) updateRedirects(ctx context.Context, organizationID uuid.UUID, change func() error) error {
+	before, err := caddy.RedirectsByDomain(t.Storage, organizationID)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after, err := caddy.RedirectsByDomain(t.Storage, organizationID)
+	if err != nil {
+		return err
+	}
+
+	var dropped []string
+	for _, alias := range slices.Sorted(maps.Keys(before.Aliases)) {
+		if !after.Aliases[alias] {
+			dropped = append(dropped, alias)
+		}
+	}
+	if len(dropped) > 0 {
+		orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+		if err := caddy.RemoveDomainsWithRetry(orgCtx, nil, &t.Logger, dropped); err != nil {
+			t.Logger.Log(logger.Warning, "failed to remove alias domains from proxy, enqueueing for retry", err.Error())
+			if enqErr := caddy.EnqueuePendingRemoval(organizationID, dropped...); enqErr != nil {
+				t.Logger.Log(logger.Error, "failed to enqueue pending removal", enqErr.Error())
+			}
+		}
+	}
+	return t.reconcileRoutes(ctx, organizationID)
+}
+
+// NormalizeRedirect validates the paths, status and targets of redirect
+// and normalizes them in place. appDomains are the lowercase domains of its
+// application.
+func NormalizeRedirect(redirect *shared_types.ApplicationRedirect, appDomains []string) error {
+	redirect.SourceDomain = normalizeRedirectDomain(redirect.SourceDomain)
+	redirect.TargetDomain = normalizeRedirectDomain(redirect.TargetDomain)
+	if strings.HasPrefix(redirect.TargetDomain, "*") {
+		return types.ErrInvalidRedirectDomain
+	}
+
+	var err error
+	if redirect.SourcePath, err = normalizeRedirectPath(redirect.SourcePath); err != nil {
+		return err
+	}
+	if redirect.TargetPath, err = normalizeRedirectPath(redirect.TargetPath); err != nil {
+		return err
+	}
+
+	if redirect.StatusCode == 0 {
+		redirect.StatusCode = defaultRedirectStatus
+	}
+	switch redirect.StatusCode {
+	case 301, 302, 307, 308:
+	default:
+		return types.ErrInvalidRedirectStatus
+	}
+
+	if redirectLoops(redirect, appDomains) {
+		return types.ErrRedirectLoop
+	}
+	return nil
+}
+
+func normalizeRedirectDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// normalizeRedirectPath checks that path is empty or absolute and drops its
+// trailing slash. The root path becomes empty.
+func normalizeRedirectPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n*?#{}\\") {
+		return "", types.ErrInvalidRedirectPath
+	}
+	return strings.TrimRight(path, "/"), nil
+}
+
+// redirectLoops reports whether redirect sends requests to a location it
+// redirects again. An application wide host redirect to one of appDomains
+// is fine, since that domain does not get the rule.
+func redirectLoops(redirect *shared_types.ApplicationRedirect, appDomains []string) bool {
+	if redirect.TargetDomain != "" && redirect.TargetDomain != redirect.SourceDomain {
+		if redirect.SourceDomain != "" || !slices.Contains(appDomains, redirect.TargetDomain) {
+			return false
+		}
+		if redirect.SourcePath == "" {
+			return false
+		}
+	}
+	if redirect.SourcePath == "" {
+		return true
+	}
+	return redirect.TargetPath == redirect.SourcePath ||
+		strings.HasPrefix(redirect.TargetPath, redirect.SourcePath+"/")
+}
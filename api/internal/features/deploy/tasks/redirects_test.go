@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestNormalizeRedirect(t *testing.T) {
+	appDomains := []string{"example.com", "www.example.com"}
+
+	redirect := &shared_types.ApplicationRedirect{
+		SourceDomain: " WWW.Example.com. ",
+		SourcePath:   "/docs/",
+		TargetDomain: "Example.com",
+		TargetPath:   "/",
+	}
+	if err := NormalizeRedirect(redirect, appDomains); err != nil {
+		t.Fatalf("NormalizeRedirect: %v", err)
+	}
+	if redirect.SourceDomain != "www.example.com" || redirect.SourcePath != "/docs" ||
+		redirect.TargetDomain != "example.com" || redirect.TargetPath != "" || redirect.StatusCode != 301 {
+		t.Errorf("unexpected normalized redirect: %+v", redirect)
+	}
+
+	tests := []struct {
+		name     string
+		redirect shared_types.ApplicationRedirect
+		want     error
+	}{
+		{"www to apex", shared_types.ApplicationRedirect{SourceDomain: "www.example.com", TargetDomain: "example.com"}, nil},
+		{"application wide to one of its domains", shared_types.ApplicationRedirect{TargetDomain: "example.com"}, nil},
+		{"path on the same host", shared_types.ApplicationRedirect{SourcePath: "/old", TargetPath: "/new", StatusCode: 308}, nil},
+		{"relative path", shared_types.ApplicationRedirect{SourcePath: "old", TargetPath: "/new"}, types.ErrInvalidRedirectPath},
+		{"placeholder in path", shared_types.ApplicationRedirect{SourcePath: "/old", TargetPath: "/{http.request.host}"}, types.ErrInvalidRedirectPath},
+		{"unsupported status", shared_types.ApplicationRedirect{SourcePath: "/old", TargetPath: "/new", StatusCode: 303}, types.ErrInvalidRedirectStatus},
+		{"wildcard target", shared_types.ApplicationRedirect{TargetDomain: "*.example.com"}, types.ErrInvalidRedirectDomain},
+		{"host to itself", shared_types.ApplicationRedirect{SourceDomain: "example.com", TargetDomain: "example.com"}, types.ErrRedirectLoop},
+		{"whole host without target", shared_types.ApplicationRedirect{TargetPath: "/new"}, types.ErrRedirectLoop},
+		{"path into itself", shared_types.ApplicationRedirect{SourcePath: "/docs", TargetPath: "/docs/v2"}, types.ErrRedirectLoop},
+		{"path into itself on an application domain", shared_types.ApplicationRedirect{SourcePath: "/docs", TargetDomain: "example.com", TargetPath: "/docs"}, types.ErrRedirectLoop},
+		{"path to another host", shared_types.ApplicationRedirect{SourcePath: "/docs", TargetDomain: "docs.example.org", TargetPath: "/docs"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redirect := tt.redirect
+			if err := NormalizeRedirect(&redirect, appDomains); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
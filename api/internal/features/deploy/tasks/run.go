@@ -113,7 +113,11 @@ func (s *TaskService) AtomicUpdateContainer(ctx context.Context, r shared_types.
 	}
 
 	// Create service spec
-	serviceSpec, availablePort := s.createServiceSpec(ctx, r, taskContext)
+	serviceSpec, availablePort, err := s.createServiceSpec(ctx, r, taskContext)
+	if err != nil {
+		taskContext.LogAndUpdateStatus("Failed to prepare service: "+err.Error(), shared_types.Failed)
+		return AtomicUpdateContainerResult{}, err
+	}
 	if availablePort == "" {
 		taskContext.LogAndUpdateStatus("Failed to get available port", shared_types.Failed)
 		return AtomicUpdateContainerResult{}, types.ErrFailedToGetAvailablePort
@@ -138,7 +142,12 @@ func (s *TaskService) AtomicUpdateContainer(ctx context.Context, r shared_types.
 		return AtomicUpdateContainerResult{}, types.ErrFailedToUpdateContainer
 	}
 
-	taskContext.LogAndUpdateStatus("Service update completed successfully", shared_types.Deployed)
+	// Callers set Deployed once the service is routed.
+	taskContext.AddLog("Service update completed successfully")
+
+	s.recordProxyUpstreams(ctx, &r.Application, func(p shared_types.ApplicationPort) int {
+		return swarmUpstreamPort(serviceInfo.Endpoint.Ports, p)
+	}, taskContext)
 
 	// Update deployment record
 	r.ApplicationDeployment.ContainerID = serviceInfo.ID
@@ -225,18 +234,38 @@ func CreateOrUpdateService(ctx context.Context, serviceSpec swarm.ServiceSpec, e
 }
 
 // createServiceSpec creates a swarm service specification
-func (s *TaskService) createServiceSpec(ctx context.Context, r shared_types.TaskPayload, taskContext *TaskContext) (swarm.ServiceSpec, string) {
+func (s *TaskService) createServiceSpec(ctx context.Context, r shared_types.TaskPayload, taskContext *TaskContext) (swarm.ServiceSpec, string, error) {
 	availablePort, err := s.getAvailablePort(ctx)
 	if err != nil {
 		taskContext.LogAndUpdateStatus("Failed to get available port: "+err.Error(), shared_types.Failed)
-		return swarm.ServiceSpec{}, ""
+		return swarm.ServiceSpec{}, "", nil
+	}
+
+	resolvedEnv, err := s.ResolveEnvironmentVariables(ctx, r.Application)
+	if err != nil {
+		return swarm.ServiceSpec{}, "", fmt.Errorf("failed to resolve environment variables: %w", err)
 	}
 
 	var env_vars []string
-	for k, v := range GetMapFromString(r.Application.EnvironmentVariables) {
+	for k, v := range resolvedEnv {
 		env_vars = append(env_vars, fmt.Sprintf("%s=%s", k, v))
 	}
 
+	networkName, err := s.ensurePrivateNetwork(ctx, &r.Application)
+	if err != nil {
+		return swarm.ServiceSpec{}, "", fmt.Errorf("failed to prepare private network: %w", err)
+	}
+
+	ports, err := s.applicationPorts(ctx, &r.Application)
+	if err != nil {
+		return swarm.ServiceSpec{}, "", fmt.Errorf("failed to load application ports: %w", err)
+	}
+
+	databaseNetworks, err := s.applicationDatabaseNetworks(&r.Application)
+	if err != nil {
+		return swarm.ServiceSpec{}, "", fmt.Errorf("failed to load attached databases: %w", err)
+	}
+
 	replicas := uint64(1)
 	port, _ := strconv.Atoi(availablePort)
 
@@ -260,21 +289,26 @@ func (s *TaskService) createServiceSpec(ctx context.Context, r shared_types.Task
 			RestartPolicy: &swarm.RestartPolicy{
 				Condition: swarm.RestartPolicyConditionAny,
 			},
+			Networks: append(
+				[]swarm.NetworkAttachmentConfig{privateNetworkAttachment(&r.Application, networkName)},
+				databaseNetworkAttachments(databaseNetworks)...,
+			),
 		},
 		EndpointSpec: &swarm.EndpointSpec{
 			Mode: swarm.ResolutionModeVIP,
-			Ports: []swarm.PortConfig{
+			// The HTTP port comes first, domains are routed to the first published port.
+			Ports: append([]swarm.PortConfig{
 				{
 					Protocol:      swarm.PortConfigProtocolTCP,
 					TargetPort:    uint32(r.Application.Port),
 					PublishedPort: uint32(port),
 					PublishMode:   swarm.PortConfigPublishModeHost,
 				},
-			},
+			}, swarmPortConfigs(ports, "")...),
 		},
 	}
 
-	return serviceSpec, availablePort
+	return serviceSpec, availablePort, nil
 }
 
 // getServiceInfo retrieves service information
@@ -1,8 +1,11 @@
 package tasks
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nixopus/nixopus/api/internal/config"
@@ -91,6 +94,7 @@ func (s *TaskService) ExportAndRecordImage(ctx context.Context, payload shared_t
 	deploymentCopy := payload.ApplicationDeployment
 	go func() {
 		s.Logger.Log(logger.Info, "Starting async S3 image export", deploymentCopy.ID.String())
+		startedAt := time.Now()
 		key, size, err := s.ExportImageToS3(ctx, ExportConfig{
 			ImageTag:     commitTag,
 			OrgID:        payload.Application.OrganizationID,
@@ -98,9 +102,11 @@ func (s *TaskService) ExportAndRecordImage(ctx context.Context, payload shared_t
 			DeploymentID: deploymentCopy.ID,
 		}, taskCtx)
 		if err != nil {
+			taskCtx.RecordPhase(shared_types.PhasePush, startedAt, time.Now(), shared_types.PhaseFailed)
 			s.Logger.Log(logger.Warning, "Failed to export image to S3 (non-fatal): "+err.Error(), deploymentCopy.ID.String())
 			return
 		}
+		taskCtx.RecordPhase(shared_types.PhasePush, startedAt, time.Now(), shared_types.PhaseSucceeded)
 
 		deploymentCopy.ImageS3Key = key
 		deploymentCopy.ImageSize = size
@@ -155,3 +161,92 @@ func (s *TaskService) LoadImageFromS3(ctx context.Context, s3Key string, taskCtx
 	taskCtx.AddLog("Image loaded from S3: " + string(output))
 	return nil
 }
+
+// uploadCommandOutput runs cmd on the server of ctx and streams its output to
+// S3 under key. The object is removed again when the command fails, so a
+// truncated upload is never left behind. Returns the uploaded size in bytes.
+func uploadCommandOutput(ctx context.Context, store *s3store.ImageStore, cmd string, key string) (int64, error) {
+	sshManager, err := sshpkg.GetSSHManagerFromContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get SSH manager: %w", err)
+	}
+
+	clientConn, release, err := sshManager.Borrow("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+	defer release()
+
+	session, err := clientConn.NewSession()
+	if err != nil {
+		if sshpkg.IsClosedConnectionError(err) {
+			sshManager.CloseConnection("")
+		}
+		return 0, fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := session.Start(cmd); err != nil {
+		return 0, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	size, err := store.UploadImage(ctx, key, stdout)
+	waitErr := session.Wait()
+
+	if waitErr != nil {
+		if err == nil {
+			if deleteErr := store.DeleteImage(ctx, key); deleteErr != nil {
+				return 0, fmt.Errorf("command failed: %w (output: %s), and removing the incomplete upload failed: %s",
+					waitErr, strings.TrimSpace(stderr.String()), deleteErr.Error())
+			}
+		}
+		return 0, fmt.Errorf("command failed: %w (output: %s)", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload to S3: %w", err)
+	}
+	return size, nil
+}
+
+// downloadToCommand streams the S3 object under key into the stdin of cmd on
+// the server of ctx.
+func downloadToCommand(ctx context.Context, store *s3store.ImageStore, key string, cmd string) error {
+	body, err := store.DownloadImage(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to download from S3: %w", err)
+	}
+	defer body.Close()
+
+	sshManager, err := sshpkg.GetSSHManagerFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get SSH manager: %w", err)
+	}
+
+	clientConn, release, err := sshManager.Borrow("")
+	if err != nil {
+		return fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+	defer release()
+
+	session, err := clientConn.NewSession()
+	if err != nil {
+		if sshpkg.IsClosedConnectionError(err) {
+			sshManager.CloseConnection("")
+		}
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdin = body
+	output, err := session.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("command failed: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,440 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+const (
+	// minIdleTimeoutMinutes keeps applications from being put to sleep
+	// between two polls of the Caddy request counters.
+	minIdleTimeoutMinutes = 5
+	// wakeTimeout bounds how long a request is held while its application
+	// scales back up.
+	wakeTimeout      = 2 * time.Minute
+	wakePollInterval = time.Second
+)
+
+// UpdateScaleToZero sets the idle timeout of an application. A timeout of
+// zero disables scale-to-zero and wakes the application if it is asleep.
+func (t *TaskService) UpdateScaleToZero(ctx context.Context, applicationID uuid.UUID, idleTimeoutMinutes int, organizationID uuid.UUID) (*shared_types.ApplicationScaleToZero, error) {
+	app, err := t.Storage.GetApplicationById(applicationID.String(), organizationID)
+	if err != nil {
+		return nil, types.ErrApplicationNotFound
+	}
+
+	state, err := t.Storage.GetApplicationScaleToZero(app.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if idleTimeoutMinutes == 0 {
+		if state == nil {
+			return disabledScaleToZero(app), nil
+		}
+		if state.Sleeping {
+			if _, err := t.WakeApplication(ctx, &app); err != nil {
+				return nil, err
+			}
+		}
+		if err := t.Storage.DeleteApplicationScaleToZero(app.ID); err != nil {
+			return nil, err
+		}
+		return disabledScaleToZero(app), nil
+	}
+
+	if app.BuildPack == shared_types.DockerCompose || app.IsComposeStack() {
+		return nil, types.ErrScaleToZeroUnsupported
+	}
+	// The wake listener finds the application to wake by host alone.
+	if slices.ContainsFunc(app.Domains, func(d *shared_types.ApplicationDomain) bool { return d.PathPrefix != "" }) {
+		return nil, types.ErrScaleToZeroPathPrefix
+	}
+	if config.AppConfig.Proxy.WakeDial == "" {
+		return nil, types.ErrWakeProxyNotConfigured
+	}
+	if idleTimeoutMinutes < minIdleTimeoutMinutes {
+		return nil, types.ErrInvalidIdleTimeout
+	}
+
+	if state == nil {
+		now := time.Now()
+		state = &shared_types.ApplicationScaleToZero{
+			ApplicationID:  app.ID,
+			OrganizationID: organizationID,
+			LastRequestAt:  now,
+			CreatedAt:      now,
+		}
+	}
+	state.IdleTimeoutMinutes = idleTimeoutMinutes
+	if err := t.Storage.UpsertApplicationScaleToZero(state); err != nil {
+		return nil, err
+	}
+
+	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+	if err := caddy.EnsurePerHostMetrics(orgCtx, nil, &t.Logger); err != nil {
+		t.Logger.Log(logger.Warning, "failed to enable per host caddy metrics", err.Error())
+	}
+	return state, nil
+}
+
+func disabledScaleToZero(app shared_types.Application) *shared_types.ApplicationScaleToZero {
+	return &shared_types.ApplicationScaleToZero{
+		ApplicationID:  app.ID,
+		OrganizationID: app.OrganizationID,
+	}
+}
+
+// StartIdleMonitor checks the request counts of applications with an idle
+// timeout at the start of every minute, and scales idle ones to zero.
+func (t *TaskService) StartIdleMonitor(ctx context.Context) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case tick := <-timer.C:
+				t.checkIdleApplications(ctx, tick)
+			}
+		}
+	}()
+}
+
+func (t *TaskService) checkIdleApplications(ctx context.Context, now time.Time) {
+	if config.AppConfig.Proxy.WakeDial == "" {
+		return
+	}
+	states, err := t.Storage.GetScaleToZeroApplications()
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to load scale-to-zero applications", err.Error())
+		return
+	}
+
+	byOrganization := make(map[uuid.UUID][]shared_types.ApplicationScaleToZero)
+	for _, state := range states {
+		byOrganization[state.OrganizationID] = append(byOrganization[state.OrganizationID], state)
+	}
+
+	for organizationID, orgStates := range byOrganization {
+		orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+		counts, err := caddy.GetHostRequestCounts(orgCtx, nil, &t.Logger)
+		if err != nil {
+			t.Logger.Log(logger.Warning, "failed to read caddy request counts", err.Error())
+			continue
+		}
+		if len(counts) == 0 {
+			// Without per host metrics every application looks idle.
+			if err := caddy.EnsurePerHostMetrics(orgCtx, nil, &t.Logger); err != nil {
+				t.Logger.Log(logger.Warning, "failed to enable per host caddy metrics", err.Error())
+			}
+			continue
+		}
+		for i := range orgStates {
+			t.checkIdleApplication(ctx, &orgStates[i], counts, now)
+		}
+	}
+}
+
+func (t *TaskService) checkIdleApplication(ctx context.Context, state *shared_types.ApplicationScaleToZero, counts map[string]float64, now time.Time) {
+	app, err := t.Storage.GetApplicationById(state.ApplicationID.String(), state.OrganizationID)
+	if err != nil {
+		t.Logger.Log(logger.Warning, "failed to load scale-to-zero application", state.ApplicationID.String())
+		return
+	}
+
+	var total float64
+	for _, d := range app.Domains {
+		total += counts[strings.ToLower(d.Domain)]
+	}
+	if int64(total) != state.RequestCount {
+		state.RequestCount = int64(total)
+		state.LastRequestAt = now
+		if err := t.Storage.UpsertApplicationScaleToZero(state); err != nil {
+			t.Logger.Log(logger.Error, "failed to record application requests", err.Error())
+		}
+		return
+	}
+
+	if state.Sleeping {
+		t.resetRedeployedApplication(ctx, &app)
+		return
+	}
+	if now.Sub(state.LastRequestAt) < time.Duration(state.IdleTimeoutMinutes)*time.Minute {
+		return
+	}
+	if err := t.sleepApplication(ctx, &app); err != nil {
+		t.Logger.Log(logger.Error, fmt.Sprintf("failed to scale %s to zero", app.Name), err.Error())
+	}
+}
+
+// resetRedeployedApplication marks a sleeping application awake when its
+// service runs again, as a redeploy does, so it is not held behind the wake
+// listener.
+func (t *TaskService) resetRedeployedApplication(ctx context.Context, app *shared_types.Application) {
+	unlock := t.lockScale(app.ID)
+	defer unlock()
+
+	state, err := t.Storage.GetApplicationScaleToZero(app.ID)
+	if err != nil || !state.Sleeping {
+		return
+	}
+	_, svc, err := t.applicationService(ctx, app)
+	if err != nil || svc == nil || serviceReplicas(svc) == 0 {
+		return
+	}
+	now := time.Now()
+	state.Sleeping = false
+	state.WokenAt = &now
+	state.LastRequestAt = now
+	if err := t.Storage.UpsertApplicationScaleToZero(state); err != nil {
+		t.Logger.Log(logger.Error, "failed to mark application awake", err.Error())
+	}
+}
+
+// sleepApplication points the domains of app at the wake listener and scales
+// its service to zero. The current route and replica count are saved so
+// WakeApplication can restore them.
+func (t *TaskService) sleepApplication(ctx context.Context, app *shared_types.Application) error {
+	unlock := t.lockScale(app.ID)
+	defer unlock()
+
+	state, err := t.Storage.GetApplicationScaleToZero(app.ID)
+	if err != nil {
+		return err
+	}
+	if state.Sleeping || state.IdleTimeoutMinutes == 0 {
+		return nil
+	}
+
+	dockerSvc, svc, err := t.applicationService(ctx, app)
+	if err != nil {
+		return err
+	}
+	if svc == nil || serviceReplicas(svc) == 0 {
+		return nil
+	}
+
+	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, app.OrganizationID.String())
+	upstreamDial, domains, err := t.currentApplicationDial(orgCtx, app)
+	if err != nil {
+		return err
+	}
+	if upstreamDial == "" {
+		return nil
+	}
+
+	previous := *state
+	now := time.Now()
+	state.Sleeping = true
+	state.SleepReplicas = int(serviceReplicas(svc))
+	state.UpstreamDial = upstreamDial
+	state.SleptAt = &now
+	if err := t.Storage.UpsertApplicationScaleToZero(state); err != nil {
+		return err
+	}
+
+	if err := caddy.AddDomainsWithRetry(orgCtx, nil, &t.Logger, domainRoutes(domains, config.AppConfig.Proxy.WakeDial)); err != nil {
+		t.revertScaleToZero(&previous)
+		return fmt.Errorf("failed to route domains to the wake listener: %w", err)
+	}
+	if err := dockerSvc.ScaleService(svc.ID, 0, ""); err != nil {
+		if routeErr := caddy.AddDomainsWithRetry(orgCtx, nil, &t.Logger, domainRoutes(domains, upstreamDial)); routeErr != nil {
+			t.Logger.Log(logger.Error, "failed to restore application routes", routeErr.Error())
+		}
+		t.revertScaleToZero(&previous)
+		return fmt.Errorf("failed to scale service to zero: %w", err)
+	}
+
+	t.Logger.Log(logger.Info, fmt.Sprintf("scaled idle application %s to zero", app.Name), app.ID.String())
+	return nil
+}
+
+func (t *TaskService) revertScaleToZero(previous *shared_types.ApplicationScaleToZero) {
+	if err := t.Storage.UpsertApplicationScaleToZero(previous); err != nil {
+		t.Logger.Log(logger.Error, "failed to revert scale-to-zero state", err.Error())
+	}
+}
+
+// WakeApplication scales a sleeping application back up, waits until it
+// accepts connections and restores its domains' routes. It returns the
+// upstream dial of the application. Concurrent callers wait for the first
+// one, so a burst of requests scales the service only once. Applications
+// that never slept return ErrApplicationNotSleeping.
+func (t *TaskService) WakeApplication(ctx context.Context, app *shared_types.Application) (string, error) {
+	unlock := t.lockScale(app.ID)
+	defer unlock()
+
+	state, err := t.Storage.GetApplicationScaleToZero(app.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", types.ErrApplicationNotSleeping
+	}
+	if err != nil {
+		return "", err
+	}
+	if !state.Sleeping {
+		// A concurrent request may have woken it already.
+		if state.UpstreamDial == "" {
+			return "", types.ErrApplicationNotSleeping
+		}
+		return state.UpstreamDial, nil
+	}
+
+	dockerSvc, svc, err := t.applicationService(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	if svc == nil {
+		return "", fmt.Errorf("service %s not found", app.Name)
+	}
+	replicas := uint64(max(state.SleepReplicas, 1))
+	if serviceReplicas(svc) < replicas {
+		if err := dockerSvc.ScaleService(svc.ID, replicas, ""); err != nil {
+			return "", fmt.Errorf("failed to scale service up: %w", err)
+		}
+	}
+	if err := waitForServiceReady(ctx, dockerSvc, app.Name, state.UpstreamDial); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	state.Sleeping = false
+	state.WokenAt = &now
+	state.LastRequestAt = now
+	if err := t.Storage.UpsertApplicationScaleToZero(state); err != nil {
+		return "", err
+	}
+
+	orgCtx := context.WithValue(ctx, shared_types.OrganizationIDKey, app.OrganizationID.String())
+	domains := make([]string, 0, len(app.Domains))
+	for _, d := range app.Domains {
+		domains = append(domains, d.Domain)
+	}
+	if err := caddy.AddDomainsWithRetry(orgCtx, nil, &t.Logger, domainRoutes(domains, state.UpstreamDial)); err != nil {
+		t.Logger.Log(logger.Warning, "failed to restore application routes, queueing reconcile", err.Error())
+		if err := caddy.EnqueueReconcile(app.OrganizationID); err != nil {
+			t.Logger.Log(logger.Error, "failed to queue caddy reconcile", err.Error())
+		}
+	}
+
+	t.Logger.Log(logger.Info, fmt.Sprintf("woke application %s", app.Name), app.ID.String())
+	return state.UpstreamDial, nil
+}
+
+// waitForServiceReady waits until every replica of the service runs and its
+// upstream accepts TCP connections.
+func waitForServiceReady(ctx context.Context, dockerSvc docker.DockerRepository, serviceName string, dial string) error {
+	ctx, cancel := context.WithTimeout(ctx, wakeTimeout)
+	defer cancel()
+	ticker := time.NewTicker(wakePollInterval)
+	defer ticker.Stop()
+	for {
+		if svc, err := dockerSvc.GetServiceByName(serviceName); err == nil && svc != nil {
+			running, desired, err := dockerSvc.GetServiceHealth(*svc)
+			if err == nil && desired > 0 && running >= desired {
+				conn, err := net.DialTimeout("tcp", dial, wakePollInterval)
+				if err == nil {
+					conn.Close()
+					return nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return types.ErrApplicationWakeFailed
+		case <-ticker.C:
+		}
+	}
+}
+
+// applicationService returns the Docker client of the primary server of app
+// and its Swarm service, which is nil when the service does not exist.
+func (t *TaskService) applicationService(ctx context.Context, app *shared_types.Application) (docker.DockerRepository, *swarm.Service, error) {
+	serverID, err := t.primaryApplicationServer(app)
+	if err != nil {
+		return nil, nil, err
+	}
+	dockerSvc, err := t.getDockerService(serverContext(ctx, app.OrganizationID, serverID))
+	if err != nil {
+		return nil, nil, err
+	}
+	svc, err := dockerSvc.GetServiceByName(app.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to inspect service: %w", err)
+	}
+	return dockerSvc, svc, nil
+}
+
+// currentApplicationDial returns the upstream Caddy routes the domains of
+// app to, and those domains. The dial is empty when no domain is routed.
+func (t *TaskService) currentApplicationDial(orgCtx context.Context, app *shared_types.Application) (string, []string, error) {
+	current, err := caddy.GetCurrentDomains(orgCtx, nil, &t.Logger)
+	if err != nil {
+		return "", nil, err
+	}
+	routed := make(map[string]string, len(current))
+	for _, r := range current {
+		routed[strings.ToLower(r.Domain)] = r.UpstreamDial
+	}
+
+	var dial string
+	var domains []string
+	for _, d := range app.Domains {
+		if d.Domain == "" {
+			continue
+		}
+		domains = append(domains, d.Domain)
+		if upstream, ok := routed[strings.ToLower(d.Domain)]; ok && dial == "" {
+			dial = upstream
+		}
+	}
+	if dial == config.AppConfig.Proxy.WakeDial {
+		return "", nil, nil
+	}
+	return dial, domains, nil
+}
+
+func domainRoutes(domains []string, dial string) []caddy.DomainRoute {
+	routes := make([]caddy.DomainRoute, 0, len(domains))
+	for _, domain := range domains {
+		if domain == "" {
+			continue
+		}
+		routes = append(routes, caddy.DomainRoute{Domain: domain, UpstreamDial: dial})
+	}
+	return routes
+}
+
+func serviceReplicas(svc *swarm.Service) uint64 {
+	if svc.Spec.Mode.Replicated == nil || svc.Spec.Mode.Replicated.Replicas == nil {
+		return 0
+	}
+	return *svc.Spec.Mode.Replicated.Replicas
+}
+
+// lockScale locks sleeping and waking of an application and returns the
+// unlock function.
+func (t *TaskService) lockScale(applicationID uuid.UUID) func() {
+	value, _ := t.scaleLocks.LoadOrStore(applicationID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestDomainRoutes(t *testing.T) {
+	routes := domainRoutes([]string{"app.example.com", "", "www.example.com"}, "10.0.0.1:8080")
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	for _, r := range routes {
+		if r.UpstreamDial != "10.0.0.1:8080" {
+			t.Errorf("route %s dials %s", r.Domain, r.UpstreamDial)
+		}
+	}
+}
+
+func TestServiceReplicas(t *testing.T) {
+	three := uint64(3)
+	replicated := &swarm.Service{}
+	replicated.Spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &three}
+	if got := serviceReplicas(replicated); got != 3 {
+		t.Errorf("replicated service = %d, want 3", got)
+	}
+
+	global := &swarm.Service{}
+	global.Spec.Mode.Global = &swarm.GlobalService{}
+	if got := serviceReplicas(global); got != 0 {
+		t.Errorf("global service = %d, want 0", got)
+	}
+}
@@ -0,0 +1,75 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+)
+
+const secretResolveTimeout = 30 * time.Second
+
+// secretFetcher loads every secret stored at a reference's provider and path.
+type secretFetcher func(ctx context.Context, provider secrets.SecretManagerType, path string) (map[string]string, error)
+
+// resolveSecretReferences replaces secret:// references in env with values read
+// from the organization's secret managers. Values are resolved in memory only;
+// errors name the variable and reference but never a secret value.
+func (t *TaskService) resolveSecretReferences(ctx context.Context, organizationID uuid.UUID, env map[string]string) error {
+	return resolveSecretReferences(ctx, env, func(ctx context.Context, provider secrets.SecretManagerType, path string) (map[string]string, error) {
+		connection, err := t.Storage.GetOrganizationSecretManager(organizationID, provider)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("no %s secret manager is configured for this organization", provider)
+			}
+			return nil, err
+		}
+		manager, err := secrets.NewSecretManager(connection.ManagerConfig(path))
+		if err != nil {
+			return nil, err
+		}
+		return manager.GetSecrets(ctx, "")
+	})
+}
+
+func resolveSecretReferences(ctx context.Context, env map[string]string, fetch secretFetcher) error {
+	ctx, cancel := context.WithTimeout(ctx, secretResolveTimeout)
+	defer cancel()
+
+	type location struct {
+		provider secrets.SecretManagerType
+		path     string
+	}
+	cache := make(map[location]map[string]string)
+
+	for name, value := range env {
+		if !secrets.IsReference(value) {
+			continue
+		}
+		ref, err := secrets.ParseReference(value)
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+
+		loc := location{provider: ref.Provider, path: ref.Path}
+		values, ok := cache[loc]
+		if !ok {
+			values, err = fetch(ctx, ref.Provider, ref.Path)
+			if err != nil {
+				return fmt.Errorf("environment variable %s: failed to read %s: %w", name, ref, err)
+			}
+			cache[loc] = values
+		}
+
+		resolved, ok := values[ref.Key]
+		if !ok {
+			return fmt.Errorf("environment variable %s: secret %s not found", name, ref)
+		}
+		env[name] = resolved
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/secrets"
+)
+
+func TestResolveSecretReferences(t *testing.T) {
+	calls := 0
+	fetch := func(ctx context.Context, provider secrets.SecretManagerType, path string) (map[string]string, error) {
+		calls++
+		if provider == secrets.SecretManagerVault && path == "/app/prod" {
+			return map[string]string{"DATABASE_URL": "postgres://prod", "REDIS_URL": "redis://prod"}, nil
+		}
+		return map[string]string{}, nil
+	}
+
+	env := map[string]string{
+		"DATABASE_URL": "secret://vault/app/prod/DATABASE_URL",
+		"CACHE_URL":    "secret://vault/app/prod/REDIS_URL",
+		"PORT":         "3000",
+	}
+	if err := resolveSecretReferences(context.Background(), env, fetch); err != nil {
+		t.Fatalf("resolveSecretReferences() error = %v", err)
+	}
+
+	if env["DATABASE_URL"] != "postgres://prod" || env["CACHE_URL"] != "redis://prod" || env["PORT"] != "3000" {
+		t.Fatalf("unexpected env: %v", env)
+	}
+	if calls != 1 {
+		t.Fatalf("fetch called %d times, want 1", calls)
+	}
+}
+
+func TestResolveSecretReferencesErrorsDoNotLeakValues(t *testing.T) {
+	fetch := func(ctx context.Context, provider secrets.SecretManagerType, path string) (map[string]string, error) {
+		return map[string]string{"OTHER": "super-secret-value"}, nil
+	}
+
+	env := map[string]string{"API_KEY": "secret://infisical/api/API_KEY"}
+	err := resolveSecretReferences(context.Background(), env, fetch)
+	if err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+	if strings.Contains(err.Error(), "super-secret-value") {
+		t.Fatalf("error leaked secret value: %v", err)
+	}
+	if !strings.Contains(err.Error(), "API_KEY") {
+		t.Fatalf("error should name the variable: %v", err)
+	}
+}
+
+func TestResolveSecretReferencesFetchError(t *testing.T) {
+	fetch := func(ctx context.Context, provider secrets.SecretManagerType, path string) (map[string]string, error) {
+		return nil, errors.New("permission denied")
+	}
+
+	env := map[string]string{"API_KEY": "secret://vault/api/API_KEY"}
+	if err := resolveSecretReferences(context.Background(), env, fetch); err == nil {
+		t.Fatal("expected fetch error to propagate")
+	}
+}
@@ -42,13 +42,18 @@ type StagingSourceResolver struct{}
 
 func (r *StagingSourceResolver) Resolve(ctx context.Context, config SourceResolveConfig) (string, error) {
 	app := config.Application
-	stagingPath := filepath.Join(
+	return applicationRepoPath(&app), nil
+}
+
+// applicationRepoPath returns where an application's source is checked out on
+// the deploy server. It matches the path used by GetClonePath.
+func applicationRepoPath(app *shared_types.Application) string {
+	return filepath.Join(
 		"/var/nixopus/repos",
 		app.UserID.String(),
 		string(app.Environment),
 		app.ID.String(),
 	)
-	return stagingPath, nil
 }
 
 type ObjectStore interface {
@@ -95,12 +100,7 @@ func (r *S3SourceResolver) Resolve(ctx context.Context, cfg SourceResolveConfig)
 		return "", fmt.Errorf("no files found in S3 workspace for application %s", app.ID)
 	}
 
-	stagingPath := filepath.Join(
-		"/var/nixopus/repos",
-		app.UserID.String(),
-		string(app.Environment),
-		app.ID.String(),
-	)
+	stagingPath := applicationRepoPath(&app)
 
 	err = utils.WithSFTPClientFromPool(orgCtx, func(sftpClient *sftp.Client) error {
 		if err := sftpClient.MkdirAll(stagingPath); err != nil {
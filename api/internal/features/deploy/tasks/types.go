@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/caddy"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/docker"
 	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
 	deploy_types "github.com/nixopus/nixopus/api/internal/features/deploy/types"
@@ -32,6 +33,11 @@ type TaskService struct {
 	OnLiveDevDeployed OnLiveDevDeployedFunc
 	OnLiveDevLog      OnLiveDevLogFunc
 	cancellations     sync.Map
+	// scaleLocks serializes sleeping and waking an application.
+	scaleLocks sync.Map
+	// reconciler applies proxy state that depends on more than one
+	// application's routes, such as maintenance pages.
+	reconciler *caddy.Reconciler
 }
 
 func NewTaskService(storage storage.DeployRepository, logger logger.Logger, githubService *github_service.GithubConnectorService, store *shared_storage.Store, notifier shared_types.Notifier) *TaskService {
@@ -42,6 +48,7 @@ func NewTaskService(storage storage.DeployRepository, logger logger.Logger, gith
 		Store:             store,
 		Notifier:          notifier,
 		OnLiveDevDeployed: nil,
+		reconciler:        caddy.NewReconciler(storage, logger),
 	}
 }
 
@@ -65,6 +65,7 @@ type TaskContext struct {
 	statusID      uuid.UUID
 	onLogCallback func(applicationID uuid.UUID, logLine string) // for live dev real-time streaming
 	logBuffer     []shared_types.ApplicationLogs
+	phases        phaseTracker
 }
 
 func (s *TaskService) NewTaskContext(result shared_types.TaskPayload) *TaskContext {
@@ -103,6 +104,7 @@ func (tc *TaskContext) UpdateStatus(status shared_types.Status) {
 	if err != nil {
 		tc.service.Logger.Log(logger.Error, "Failed to update application deployment status: "+err.Error(), "")
 	}
+	tc.observeStatus(status)
 }
 
 func (tc *TaskContext) AddLog(logMessage string) {
@@ -120,6 +122,8 @@ func (tc *TaskContext) AddLog(logMessage string) {
 	needsFlush := len(tc.logBuffer) >= logBatchSize
 	tc.mu.Unlock()
 
+	tc.observeLog(logMessage)
+
 	if needsFlush {
 		tc.FlushLogs()
 	}
@@ -325,3 +329,10 @@ func GetSSHHostForOrganization(ctx context.Context, organizationID uuid.UUID) (s
 	}
 	return upstreamHost, nil
 }
+
+// serverContext scopes ctx to a server of the organization, so SSH and
+// Docker clients taken from it reach that server.
+func serverContext(ctx context.Context, organizationID uuid.UUID, serverID uuid.UUID) context.Context {
+	ctx = context.WithValue(ctx, shared_types.OrganizationIDKey, organizationID.String())
+	return context.WithValue(ctx, shared_types.ServerIDKey, serverID.String())
+}
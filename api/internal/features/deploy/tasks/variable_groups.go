@@ -0,0 +1,91 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+// ResolveEnvironmentVariables returns the effective environment of an application:
+// attached variable groups merged from lowest to highest priority, then the
+// connection strings of attached databases, with the application's own
+// variables taking precedence over both. secret:// references are then
+// replaced with values from the organization's secret managers. The result
+// must never be persisted or logged.
+func (t *TaskService) ResolveEnvironmentVariables(ctx context.Context, application shared_types.Application) (map[string]string, error) {
+	attachments, err := t.Storage.GetApplicationVariableGroups(application.ID)
+	if err != nil {
+		return nil, err
+	}
+	databases, err := t.Storage.GetApplicationDatabases(application.ID)
+	if err != nil {
+		return nil, err
+	}
+	appVars := GetMapFromString(string(application.EnvironmentVariables))
+	resolved := mergeEnvironmentVariables(attachments, appVars)
+	addDatabaseVariables(resolved, databases, appVars)
+	if err := t.resolveSecretReferences(ctx, application.OrganizationID, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+func mergeEnvironmentVariables(attachments []shared_types.ApplicationVariableGroup, appVars map[string]string) map[string]string {
+	resolved := make(map[string]string)
+	for _, attachment := range attachments {
+		if attachment.VariableGroup == nil {
+			continue
+		}
+		for k, v := range GetMapFromString(string(attachment.VariableGroup.Variables)) {
+			resolved[k] = v
+		}
+	}
+	for k, v := range appVars {
+		resolved[k] = v
+	}
+	return resolved
+}
+
+// RedeployVariableGroupApplications queues a redeploy for every application that
+// uses the variable group. Applications that were never deployed are skipped.
+func (t *TaskService) RedeployVariableGroupApplications(groupID uuid.UUID, force bool, userID uuid.UUID, organizationID uuid.UUID) (*types.VariableGroupRedeployResult, error) {
+	apps, err := t.Storage.GetApplicationsByVariableGroup(groupID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return t.redeployApplications(apps, force, userID, organizationID), nil
+}
+
+// redeployApplications queues a redeploy for each of apps, skipping
+// applications that were never deployed.
+func (t *TaskService) redeployApplications(apps []shared_types.Application, force bool, userID uuid.UUID, organizationID uuid.UUID) *types.VariableGroupRedeployResult {
+	result := &types.VariableGroupRedeployResult{}
+	for _, app := range apps {
+		appResult := types.RecoverAppResult{
+			ApplicationID:   app.ID,
+			ApplicationName: app.Name,
+		}
+
+		if app.Status == nil || app.Status.Status == shared_types.Draft {
+			appResult.Reason = "application has not been deployed yet"
+			result.Skipped = append(result.Skipped, appResult)
+			continue
+		}
+
+		_, err := t.ReDeployApplication(&types.ReDeployApplicationRequest{
+			ID:    app.ID,
+			Force: force,
+		}, userID, organizationID)
+		if err != nil {
+			t.Logger.Log(logger.Error, "failed to redeploy application", err.Error())
+			appResult.Reason = err.Error()
+			result.Failed = append(result.Failed, appResult)
+			continue
+		}
+		result.Redeployed = append(result.Redeployed, appResult)
+	}
+	return result
+}
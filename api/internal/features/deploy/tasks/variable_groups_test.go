@@ -0,0 +1,33 @@
+package tasks
+
+import (
+	"testing"
+
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestMergeEnvironmentVariablesPrecedence(t *testing.T) {
+	attachments := []shared_types.ApplicationVariableGroup{
+		{Priority: 0, VariableGroup: &shared_types.VariableGroup{Variables: `{"SENTRY_DSN":"org","SMTP_HOST":"smtp.org"}`}},
+		{Priority: 1, VariableGroup: &shared_types.VariableGroup{Variables: `{"SMTP_HOST":"smtp.family","DATABASE_URL":"postgres://family"}`}},
+		{Priority: 2},
+	}
+	appVars := map[string]string{"DATABASE_URL": "postgres://app", "PORT": "3000"}
+
+	got := mergeEnvironmentVariables(attachments, appVars)
+
+	want := map[string]string{
+		"SENTRY_DSN":   "org",
+		"SMTP_HOST":    "smtp.family",
+		"DATABASE_URL": "postgres://app",
+		"PORT":         "3000",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d variables, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
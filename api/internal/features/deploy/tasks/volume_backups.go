@@ -0,0 +1,616 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/config"
+	s3store "github.com/nixopus/nixopus/api/internal/features/deploy/s3"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+const (
+	volumeBackupActionBackup  = "backup"
+	volumeBackupActionRestore = "restore"
+)
+
+const (
+	// volumeRestoreStopTimeout bounds how long a restore waits for the
+	// application's containers to exit after scaling it down.
+	volumeRestoreStopTimeout  = 2 * time.Minute
+	volumeRestoreStopInterval = 2 * time.Second
+)
+
+var volumeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// VolumeBackupTaskPayload is the queued job of a volume backup or restore.
+// Scheduled backups only carry the PolicyID, their record is created when
+// the job runs.
+type VolumeBackupTaskPayload struct {
+	Action         string `json:"action"`
+	OrganizationID string `json:"organization_id"`
+	PolicyID       string `json:"policy_id"`
+	BackupID       string `json:"backup_id,omitempty"`
+	RestoreID      string `json:"restore_id,omitempty"`
+}
+
+// ParseVolumeBackupSource validates what a volume backup policy archives: a
+// Docker volume name, or an absolute, clean path other than the root for
+// bind mounts.
+func ParseVolumeBackupSource(sourceType string, source string) (shared_types.VolumeBackupSourceType, string, error) {
+	source = strings.TrimSpace(source)
+	switch shared_types.VolumeBackupSourceType(sourceType) {
+	case shared_types.VolumeBackupSourceVolume:
+		if !volumeNamePattern.MatchString(source) {
+			return "", "", types.ErrInvalidVolumeBackupSource
+		}
+		return shared_types.VolumeBackupSourceVolume, source, nil
+	case shared_types.VolumeBackupSourceBind:
+		if !path.IsAbs(source) || path.Clean(source) != source || source == "/" {
+			return "", "", types.ErrInvalidVolumeBackupSource
+		}
+		return shared_types.VolumeBackupSourceBind, source, nil
+	default:
+		return "", "", types.ErrInvalidVolumeBackupSource
+	}
+}
+
+// CheckVolumeBackupSource checks that the source of a new policy is mounted
+// by the application's containers, so a policy cannot archive or overwrite
+// other host paths or the volumes of other applications.
+func (t *TaskService) CheckVolumeBackupSource(ctx context.Context, req *types.CreateVolumeBackupPolicyRequest, organizationID uuid.UUID) error {
+	sourceType, source, err := ParseVolumeBackupSource(req.SourceType, req.Source)
+	if err != nil {
+		return err
+	}
+	app, err := t.Storage.GetApplicationById(req.ApplicationID.String(), organizationID)
+	if err != nil {
+		return types.ErrApplicationNotFound
+	}
+	serverID, err := t.primaryApplicationServer(&app)
+	if err != nil {
+		return err
+	}
+	return checkSourceMounted(serverContext(ctx, organizationID, serverID), &app, sourceType, source)
+}
+
+// StartVolumeBackup records an on-demand backup of a policy's source and
+// queues it.
+func (t *TaskService) StartVolumeBackup(policyID uuid.UUID, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeBackup, error) {
+	policy, err := t.Storage.GetVolumeBackupPolicy(policyID, organizationID)
+	if err != nil {
+		return nil, types.ErrVolumeBackupPolicyNotFound
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		return nil, types.ErrS3NotConfigured
+	}
+	active, err := t.Storage.HasActiveVolumeBackup(policy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, types.ErrVolumeBackupInProgress
+	}
+
+	backup := newVolumeBackup(policy, shared_types.BackupManual)
+	backup.CreatedBy = &userID
+	if err := t.Storage.CreateVolumeBackup(backup); err != nil {
+		return nil, err
+	}
+	err = VolumeBackupQueue.Add(TaskVolumeBackup.WithArgs(context.Background(), VolumeBackupTaskPayload{
+		Action:         volumeBackupActionBackup,
+		OrganizationID: organizationID.String(),
+		PolicyID:       policy.ID.String(),
+		BackupID:       backup.ID.String(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue volume backup: %w", err)
+	}
+	return backup, nil
+}
+
+// RestoreVolumeBackup queues replacing the contents of a backed up volume or
+// path with a backup. The application is stopped while its data is replaced.
+func (t *TaskService) RestoreVolumeBackup(backupID uuid.UUID, userID uuid.UUID, organizationID uuid.UUID) (*shared_types.VolumeRestore, error) {
+	backup, err := t.Storage.GetVolumeBackup(backupID, organizationID)
+	if err != nil {
+		return nil, types.ErrVolumeBackupNotFound
+	}
+	if backup.Status != shared_types.BackupSucceeded {
+		return nil, types.ErrVolumeBackupNotRestorable
+	}
+	if !s3store.IsConfigured(config.AppConfig.S3) {
+		return nil, types.ErrS3NotConfigured
+	}
+	active, err := t.Storage.HasActiveVolumeBackup(backup.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, types.ErrVolumeBackupInProgress
+	}
+
+	restore := &shared_types.VolumeRestore{
+		ID:             uuid.New(),
+		BackupID:       backup.ID,
+		ApplicationID:  backup.ApplicationID,
+		OrganizationID: organizationID,
+		Status:         shared_types.RestorePending,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+	}
+	if err := t.Storage.CreateVolumeRestore(restore); err != nil {
+		return nil, err
+	}
+	err = VolumeBackupQueue.Add(TaskVolumeBackup.WithArgs(context.Background(), VolumeBackupTaskPayload{
+		Action:         volumeBackupActionRestore,
+		OrganizationID: organizationID.String(),
+		PolicyID:       backup.PolicyID.String(),
+		BackupID:       backup.ID.String(),
+		RestoreID:      restore.ID.String(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue volume restore: %w", err)
+	}
+	return restore, nil
+}
+
+// DeleteVolumeBackupPolicy removes a policy along with its backups and their
+// archives in S3.
+func (t *TaskService) DeleteVolumeBackupPolicy(ctx context.Context, policyID uuid.UUID, organizationID uuid.UUID) error {
+	policy, err := t.Storage.GetVolumeBackupPolicy(policyID, organizationID)
+	if err != nil {
+		return types.ErrVolumeBackupPolicyNotFound
+	}
+	active, err := t.Storage.HasActiveVolumeBackup(policy.ID)
+	if err != nil {
+		return err
+	}
+	if active {
+		return types.ErrVolumeBackupInProgress
+	}
+
+	backups, err := t.Storage.GetPolicyVolumeBackups(policy.ID)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && s3store.IsConfigured(config.AppConfig.S3) {
+		store, err := s3store.NewImageStore(config.AppConfig.S3)
+		if err != nil {
+			return fmt.Errorf("failed to create S3 image store: %w", err)
+		}
+		for _, backup := range backups {
+			if backup.S3Key == "" {
+				continue
+			}
+			if err := store.DeleteImage(ctx, backup.S3Key); err != nil {
+				t.Logger.Log(logger.Warning, "failed to remove volume backup from S3: "+err.Error(), backup.ID.String())
+			}
+		}
+	}
+	return t.Storage.DeleteVolumeBackupPolicy(policy.ID, organizationID)
+}
+
+// EnqueueScheduledVolumeBackup queues the scheduled backup of a policy for
+// minute. The job is named after the policy and the minute so that API
+// instances running the scheduler side by side queue it once.
+func EnqueueScheduledVolumeBackup(policy *shared_types.VolumeBackupPolicy, minute time.Time) error {
+	if VolumeBackupQueue == nil || TaskVolumeBackup == nil {
+		return nil
+	}
+	msg := TaskVolumeBackup.WithArgs(context.Background(), VolumeBackupTaskPayload{
+		Action:         volumeBackupActionBackup,
+		OrganizationID: policy.OrganizationID.String(),
+		PolicyID:       policy.ID.String(),
+	})
+	msg.Name = fmt.Sprintf("volume-backup-%s-%d", policy.ID, minute.Unix())
+	if err := VolumeBackupQueue.Add(msg); err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		return err
+	}
+	return nil
+}
+
+// HandleVolumeBackup runs a queued volume backup or restore.
+func (t *TaskService) HandleVolumeBackup(ctx context.Context, payload VolumeBackupTaskPayload) error {
+	organizationID, err := uuid.Parse(payload.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("invalid organization id: %w", err)
+	}
+	policyID, err := uuid.Parse(payload.PolicyID)
+	if err != nil {
+		return fmt.Errorf("invalid policy id: %w", err)
+	}
+	policy, err := t.Storage.GetVolumeBackupPolicy(policyID, organizationID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", types.ErrVolumeBackupPolicyNotFound, policyID)
+	}
+	app, err := t.Storage.GetApplicationById(policy.ApplicationID.String(), organizationID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", types.ErrApplicationNotFound, policy.ApplicationID)
+	}
+
+	switch payload.Action {
+	case volumeBackupActionBackup:
+		return t.runVolumeBackup(ctx, &app, policy, payload.BackupID)
+	case volumeBackupActionRestore:
+		restoreID, err := uuid.Parse(payload.RestoreID)
+		if err != nil {
+			return fmt.Errorf("invalid restore id: %w", err)
+		}
+		restore, err := t.Storage.GetVolumeRestore(restoreID, organizationID)
+		if err != nil {
+			return types.ErrVolumeRestoreNotFound
+		}
+		return t.runVolumeRestore(ctx, &app, policy, restore)
+	default:
+		return fmt.Errorf("unknown volume backup action %q", payload.Action)
+	}
+}
+
+func newVolumeBackup(policy *shared_types.VolumeBackupPolicy, trigger shared_types.BackupTrigger) *shared_types.VolumeBackup {
+	return &shared_types.VolumeBackup{
+		ID:             uuid.New(),
+		PolicyID:       policy.ID,
+		ApplicationID:  policy.ApplicationID,
+		OrganizationID: policy.OrganizationID,
+		SourceType:     policy.SourceType,
+		Source:         policy.Source,
+		Status:         shared_types.BackupPending,
+		Trigger:        trigger,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// runVolumeBackup streams a tarball of the policy's source on the
+// application's primary server to S3. Scheduled jobs have no backupID and
+// record their backup here, unless one is already in progress.
+func (t *TaskService) runVolumeBackup(ctx context.Context, app *shared_types.Application, policy *shared_types.VolumeBackupPolicy, backupID string) error {
+	var backup *shared_types.VolumeBackup
+	if backupID == "" {
+		active, err := t.Storage.HasActiveVolumeBackup(policy.ID)
+		if err != nil {
+			return err
+		}
+		if active {
+			t.Logger.Log(logger.Info, "skipping scheduled volume backup, another one is in progress", policy.ID.String())
+			return nil
+		}
+		backup = newVolumeBackup(policy, shared_types.BackupScheduled)
+		if err := t.Storage.CreateVolumeBackup(backup); err != nil {
+			return err
+		}
+	} else {
+		id, err := uuid.Parse(backupID)
+		if err != nil {
+			return fmt.Errorf("invalid backup id: %w", err)
+		}
+		if backup, err = t.Storage.GetVolumeBackup(id, policy.OrganizationID); err != nil {
+			return types.ErrVolumeBackupNotFound
+		}
+	}
+
+	startedAt := time.Now()
+	backup.Status = shared_types.BackupRunning
+	backup.StartedAt = &startedAt
+	if err := t.Storage.UpdateVolumeBackup(backup); err != nil {
+		return err
+	}
+
+	err := t.archiveVolumeToS3(ctx, app, backup)
+	completedAt := time.Now()
+	backup.CompletedAt = &completedAt
+	backup.DurationMs = completedAt.Sub(startedAt).Milliseconds()
+	if err != nil {
+		backup.Status = shared_types.BackupFailed
+		backup.Error = err.Error()
+		if updateErr := t.Storage.UpdateVolumeBackup(backup); updateErr != nil {
+			t.Logger.Log(logger.Error, "failed to update volume backup", updateErr.Error())
+		}
+		t.emitVolumeBackupFailed(app, policy, backup, err)
+		return err
+	}
+
+	backup.Status = shared_types.BackupSucceeded
+	if err := t.Storage.UpdateVolumeBackup(backup); err != nil {
+		return err
+	}
+	t.applyVolumeBackupRetention(ctx, policy)
+	return nil
+}
+
+// archiveVolumeToS3 tars the backup's source on the application's primary
+// server and streams it to S3, recording the server, key and size on backup.
+func (t *TaskService) archiveVolumeToS3(ctx context.Context, app *shared_types.Application, backup *shared_types.VolumeBackup) error {
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 image store: %w", err)
+	}
+	serverID, err := t.primaryApplicationServer(app)
+	if err != nil {
+		return err
+	}
+	backup.ServerID = &serverID
+
+	serverCtx := serverContext(ctx, backup.OrganizationID, serverID)
+	if err := checkSourceMounted(serverCtx, app, backup.SourceType, backup.Source); err != nil {
+		return err
+	}
+
+	key := s3store.VolumeBackupS3Key(backup.OrganizationID, backup.ApplicationID, backup.ID)
+	size, err := uploadCommandOutput(serverCtx, store, volumeArchiveCommand(backup.SourceType, backup.Source), key)
+	if err != nil {
+		return fmt.Errorf("volume backup failed: %w", err)
+	}
+	backup.S3Key = key
+	backup.SizeBytes = size
+	return nil
+}
+
+// runVolumeRestore stops the application, replaces the contents of the
+// backed up source and starts the application again, also when the restore
+// failed.
+func (t *TaskService) runVolumeRestore(ctx context.Context, app *shared_types.Application, policy *shared_types.VolumeBackupPolicy, restore *shared_types.VolumeRestore) error {
+	restore.Status = shared_types.RestoreRunning
+	if err := t.Storage.UpdateVolumeRestore(restore); err != nil {
+		return err
+	}
+
+	err := t.restoreVolumeFromS3(ctx, app, restore)
+	completedAt := time.Now()
+	restore.CompletedAt = &completedAt
+	if err != nil {
+		restore.Status = shared_types.RestoreFailed
+		restore.Error = err.Error()
+		if updateErr := t.Storage.UpdateVolumeRestore(restore); updateErr != nil {
+			t.Logger.Log(logger.Error, "failed to update volume restore", updateErr.Error())
+		}
+		t.emitVolumeRestoreFailed(app, policy, restore, err)
+		return err
+	}
+	restore.Status = shared_types.RestoreSucceeded
+	return t.Storage.UpdateVolumeRestore(restore)
+}
+
+func (t *TaskService) restoreVolumeFromS3(ctx context.Context, app *shared_types.Application, restore *shared_types.VolumeRestore) error {
+	backup, err := t.Storage.GetVolumeBackup(restore.BackupID, restore.OrganizationID)
+	if err != nil {
+		return types.ErrVolumeBackupNotFound
+	}
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 image store: %w", err)
+	}
+	serverID, err := t.primaryApplicationServer(app)
+	if err != nil {
+		return err
+	}
+	serverCtx := serverContext(ctx, restore.OrganizationID, serverID)
+	if err := checkSourceMounted(serverCtx, app, backup.SourceType, backup.Source); err != nil {
+		return err
+	}
+
+	replicas, err := applicationReplicas(serverCtx, app)
+	if err != nil {
+		return err
+	}
+	if err := stopApplication(serverCtx, app, replicas); err != nil {
+		return fmt.Errorf("failed to stop application: %w", err)
+	}
+	err = waitForApplicationStopped(serverCtx, app)
+	if err == nil {
+		if err = downloadToCommand(serverCtx, store, backup.S3Key, volumeRestoreCommand(backup.SourceType, backup.Source)); err != nil {
+			err = fmt.Errorf("volume restore failed: %w", err)
+		}
+	}
+	if startErr := startApplication(serverCtx, app, replicas); startErr != nil {
+		startErr = fmt.Errorf("failed to start application: %w", startErr)
+		if err == nil {
+			return startErr
+		}
+		t.Logger.Log(logger.Error, startErr.Error(), app.ID.String())
+	}
+	return err
+}
+
+// primaryApplicationServer returns the primary server of the application, where
+// its volumes are backed up from and restored to and its service is scaled.
+func (t *TaskService) primaryApplicationServer(app *shared_types.Application) (uuid.UUID, error) {
+	if err := t.Storage.EnsureApplicationServers(app.ID, app.OrganizationID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to resolve application servers: %w", err)
+	}
+	servers, err := t.Storage.GetApplicationServers(app.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to retrieve application servers: %w", err)
+	}
+	if len(servers) == 0 {
+		return uuid.Nil, types.ErrServerNotFound
+	}
+	return primaryServer(servers).ServerID, nil
+}
+
+// checkSourceMounted returns ErrVolumeBackupSourceNotMounted unless source
+// is a volume mounted by the application's containers or service on the
+// server of ctx, or a path that is, or lies under, one of their bind mounts.
+func checkSourceMounted(ctx context.Context, app *shared_types.Application, sourceType shared_types.VolumeBackupSourceType, source string) error {
+	mountType, containerField := "bind", ".Source"
+	if sourceType == shared_types.VolumeBackupSourceVolume {
+		mountType, containerField = "volume", ".Name"
+	}
+	key, value := applicationContainerLabel(app)
+	cmd := fmt.Sprintf(
+		`docker ps -aq --filter label=%s | xargs -r docker inspect --format '{{range .Mounts}}{{if eq .Type "%s"}}{{println %s}}{{end}}{{end}}'`,
+		utils.ShellQuote(key+"="+value), mountType, containerField)
+	if !app.IsComposeStack() && app.BuildPack != shared_types.DockerCompose {
+		// Containers of a service scaled to zero are gone, its spec still
+		// lists the mounts.
+		cmd += fmt.Sprintf(
+			`; docker service inspect --format '{{range .Spec.TaskTemplate.ContainerSpec.Mounts}}{{if eq .Type "%s"}}{{println .Source}}{{end}}{{end}}' %s 2>/dev/null || true`,
+			mountType, utils.ShellQuote(app.Name))
+	}
+	output, err := runOnServerOutput(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to list mounts: %w", err)
+	}
+	if !sourceMounted(sourceType, source, parseVolumeNames(output)) {
+		return fmt.Errorf("%w: %s", types.ErrVolumeBackupSourceNotMounted, source)
+	}
+	return nil
+}
+
+// sourceMounted reports whether a volume source is one of mounts, or a
+// bind source one of mounts or a path under one.
+func sourceMounted(sourceType shared_types.VolumeBackupSourceType, source string, mounts []string) bool {
+	if sourceType == shared_types.VolumeBackupSourceVolume {
+		return slices.Contains(mounts, source)
+	}
+	return bindSourceMounted(source, mounts)
+}
+
+// bindSourceMounted reports whether source is one of mounts or a path under one.
+func bindSourceMounted(source string, mounts []string) bool {
+	for _, mount := range mounts {
+		mount = path.Clean(mount)
+		if mount == "/" {
+			continue
+		}
+		if source == mount || strings.HasPrefix(source, mount+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForApplicationStopped waits until no container of the application
+// runs on the server of ctx, as services scale down asynchronously.
+func waitForApplicationStopped(ctx context.Context, app *shared_types.Application) error {
+	key, value := applicationContainerLabel(app)
+	cmd := fmt.Sprintf("docker ps -q --filter label=%s", utils.ShellQuote(key+"="+value))
+	deadline := time.Now().Add(volumeRestoreStopTimeout)
+	for {
+		output, err := runOnServerOutput(ctx, cmd)
+		if err == nil && strings.TrimSpace(output) == "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for the application to stop")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(volumeRestoreStopInterval):
+		}
+	}
+}
+
+// applyVolumeBackupRetention removes the backups of a policy that its
+// retention rules expire, like applyDatabaseBackupRetention.
+func (t *TaskService) applyVolumeBackupRetention(ctx context.Context, policy *shared_types.VolumeBackupPolicy) {
+	backups, err := t.Storage.GetPolicyVolumeBackups(policy.ID)
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to list volume backups", err.Error())
+		return
+	}
+	expired := expiredBackups(backups, volumeBackupState, policy.RetentionCount, policy.RetentionDays, time.Now())
+	if len(expired) == 0 {
+		return
+	}
+	store, err := s3store.NewImageStore(config.AppConfig.S3)
+	if err != nil {
+		t.Logger.Log(logger.Error, "failed to create S3 image store", err.Error())
+		return
+	}
+	for _, backup := range expired {
+		if backup.S3Key != "" {
+			if err := store.DeleteImage(ctx, backup.S3Key); err != nil {
+				t.Logger.Log(logger.Warning, "failed to remove expired volume backup from S3: "+err.Error(), backup.ID.String())
+				continue
+			}
+		}
+		if err := t.Storage.DeleteVolumeBackup(backup.ID); err != nil {
+			t.Logger.Log(logger.Error, "failed to remove expired volume backup: "+err.Error(), backup.ID.String())
+		}
+	}
+}
+
+// volumeArchiveCommand writes a gzipped tarball of the source to stdout. A
+// missing volume fails the command instead of docker creating an empty one.
+func volumeArchiveCommand(sourceType shared_types.VolumeBackupSourceType, source string) string {
+	quoted := utils.ShellQuote(source)
+	if sourceType == shared_types.VolumeBackupSourceBind {
+		return fmt.Sprintf("tar -C %s -czf - .", quoted)
+	}
+	return fmt.Sprintf("docker volume inspect %s >/dev/null && docker run --rm -v %s:/from:ro %s tar -C /from -czf - .",
+		quoted, quoted, migrationHelperImage)
+}
+
+// volumeRestoreCommand replaces the contents of the source with a gzipped
+// tarball read from stdin. The archive is extracted to a staging directory
+// first, so a broken download leaves the current contents in place.
+func volumeRestoreCommand(sourceType shared_types.VolumeBackupSourceType, source string) string {
+	quoted := utils.ShellQuote(source)
+	if sourceType == shared_types.VolumeBackupSourceBind {
+		script := fmt.Sprintf(`staging=$(mktemp -d) && trap 'rm -rf "$staging"' EXIT && tar -C "$staging" -xzf - && mkdir -p %s && find %s -mindepth 1 -delete && cp -a "$staging"/. %s/`,
+			quoted, quoted, quoted)
+		return "sh -c " + utils.ShellQuote(script)
+	}
+	script := "mkdir /staging && tar -C /staging -xzf - && find /to -mindepth 1 -delete && cp -a /staging/. /to/"
+	return fmt.Sprintf("docker volume create %s >/dev/null && docker run --rm -i -v %s:/to %s sh -c %s",
+		quoted, quoted, migrationHelperImage, utils.ShellQuote(script))
+}
+
+func volumeBackupState(backup shared_types.VolumeBackup) (shared_types.BackupStatus, time.Time) {
+	return backup.Status, backup.CreatedAt
+}
+
+func (t *TaskService) emitVolumeBackupFailed(app *shared_types.Application, policy *shared_types.VolumeBackupPolicy, backup *shared_types.VolumeBackup, err error) {
+	if t.Notifier == nil {
+		return
+	}
+	userID := policy.CreatedBy
+	if backup.CreatedBy != nil {
+		userID = *backup.CreatedBy
+	}
+	t.Notifier.Emit(shared_types.NotificationEvent{
+		Type:           shared_types.EventVolumeBackupFailed,
+		UserID:         userID.String(),
+		OrganizationID: policy.OrganizationID.String(),
+		Data: map[string]interface{}{
+			"app_name":      app.Name,
+			"app_id":        app.ID.String(),
+			"source":        policy.Source,
+			"backup_id":     backup.ID.String(),
+			"trigger":       string(backup.Trigger),
+			"error_message": err.Error(),
+		},
+	})
+}
+
+func (t *TaskService) emitVolumeRestoreFailed(app *shared_types.Application, policy *shared_types.VolumeBackupPolicy, restore *shared_types.VolumeRestore, err error) {
+	if t.Notifier == nil {
+		return
+	}
+	t.Notifier.Emit(shared_types.NotificationEvent{
+		Type:           shared_types.EventVolumeRestoreFailed,
+		UserID:         restore.CreatedBy.String(),
+		OrganizationID: policy.OrganizationID.String(),
+		Data: map[string]interface{}{
+			"app_name":      app.Name,
+			"app_id":        app.ID.String(),
+			"source":        policy.Source,
+			"backup_id":     restore.BackupID.String(),
+			"restore_id":    restore.ID.String(),
+			"error_message": err.Error(),
+		},
+	})
+}
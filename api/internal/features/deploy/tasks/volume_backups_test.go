@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nixopus/nixopus/api/internal/features/deploy/types"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+)
+
+func TestParseVolumeBackupSource(t *testing.T) {
+	tests := []struct {
+		sourceType string
+		source     string
+		want       string
+		valid      bool
+	}{
+		{"volume", "app_data", "app_data", true},
+		{"volume", " pg-data.1 ", "pg-data.1", true},
+		{"volume", "", "", false},
+		{"volume", "-data", "", false},
+		{"volume", "data/../etc", "", false},
+		{"volume", "data; rm -rf /", "", false},
+		{"bind", "/srv/app/uploads", "/srv/app/uploads", true},
+		{"bind", "/", "", false},
+		{"bind", "srv/app", "", false},
+		{"bind", "/srv/app/", "", false},
+		{"bind", "/srv/../etc", "", false},
+		{"tmpfs", "/tmp", "", false},
+	}
+	for _, tt := range tests {
+		sourceType, source, err := ParseVolumeBackupSource(tt.sourceType, tt.source)
+		if !tt.valid {
+			if !errors.Is(err, types.ErrInvalidVolumeBackupSource) {
+				t.Errorf("ParseVolumeBackupSource(%q, %q) error = %v, want ErrInvalidVolumeBackupSource", tt.sourceType, tt.source, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVolumeBackupSource(%q, %q) error = %v", tt.sourceType, tt.source, err)
+			continue
+		}
+		if string(sourceType) != tt.sourceType || source != tt.want {
+			t.Errorf("ParseVolumeBackupSource(%q, %q) = %q, %q", tt.sourceType, tt.source, sourceType, source)
+		}
+	}
+}
+
+func TestVolumeArchiveAndRestoreCommands(t *testing.T) {
+	archive := volumeArchiveCommand(shared_types.VolumeBackupSourceVolume, "app_data")
+	for _, want := range []string{"docker volume inspect 'app_data'", "-v 'app_data':/from:ro", "tar -C /from -czf - ."} {
+		if !strings.Contains(archive, want) {
+			t.Errorf("volume archive %q does not contain %q", archive, want)
+		}
+	}
+	restore := volumeRestoreCommand(shared_types.VolumeBackupSourceVolume, "app_data")
+	for _, want := range []string{"docker volume create 'app_data'", "docker run --rm -i -v 'app_data':/to", "tar -C /staging -xzf -", "find /to -mindepth 1 -delete"} {
+		if !strings.Contains(restore, want) {
+			t.Errorf("volume restore %q does not contain %q", restore, want)
+		}
+	}
+	if strings.Index(restore, "tar -C /staging") > strings.Index(restore, "find /to") {
+		t.Errorf("volume restore %q clears the volume before extracting the archive", restore)
+	}
+
+	if archive := volumeArchiveCommand(shared_types.VolumeBackupSourceBind, "/srv/data"); archive != "tar -C '/srv/data' -czf - ." {
+		t.Errorf("bind archive = %q", archive)
+	}
+	restore = volumeRestoreCommand(shared_types.VolumeBackupSourceBind, "/srv/data")
+	for _, want := range []string{"sh -c ", "mktemp -d", "find '\"'\"'/srv/data'\"'\"' -mindepth 1 -delete"} {
+		if !strings.Contains(restore, want) {
+			t.Errorf("bind restore %q does not contain %q", restore, want)
+		}
+	}
+}
+
+func TestBindSourceMounted(t *testing.T) {
+	mounts := []string{"/srv/app/uploads", "/var/lib/app/", "/"}
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"/srv/app/uploads", true},
+		{"/srv/app/uploads/images", true},
+		{"/var/lib/app", true},
+		{"/srv/app", false},
+		{"/srv/app/uploads-old", false},
+		{"/etc", false},
+		{"/root/.ssh", false},
+	}
+	for _, tt := range tests {
+		if got := bindSourceMounted(tt.source, mounts); got != tt.want {
+			t.Errorf("bindSourceMounted(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestSourceMounted(t *testing.T) {
+	mounts := []string{"app_data", "shared_uploads"}
+	if !sourceMounted(shared_types.VolumeBackupSourceVolume, "app_data", mounts) {
+		t.Error("a volume mounted by the application should be accepted")
+	}
+	if sourceMounted(shared_types.VolumeBackupSourceVolume, "nixopus-db-postgres", mounts) {
+		t.Error("a volume of another application should be rejected")
+	}
+	if sourceMounted(shared_types.VolumeBackupSourceVolume, "app", mounts) {
+		t.Error("a volume name prefix should be rejected")
+	}
+	if !sourceMounted(shared_types.VolumeBackupSourceBind, "/srv/app/x", []string{"/srv/app"}) {
+		t.Error("a path under a bind mount should be accepted")
+	}
+}
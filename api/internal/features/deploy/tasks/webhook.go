@@ -61,7 +61,7 @@ func (t *TaskService) EnqueueWebhookTask(payload shared_types.WebhookPayload) er
 			PreRunCommand:        application.PreRunCommand,
 			PostRunCommand:       application.PostRunCommand,
 			BuildVariables:       GetMapFromString(application.BuildVariables),
-			EnvironmentVariables: GetMapFromString(application.EnvironmentVariables),
+			EnvironmentVariables: GetMapFromString(string(application.EnvironmentVariables)),
 			Port:                 application.Port,
 			DockerfilePath:       application.DockerfilePath,
 			BasePath:             application.BasePath,
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/secrets"
 	shared_types "github.com/nixopus/nixopus/api/internal/types"
 )
 
@@ -21,6 +24,8 @@ type IsDomainValidRequest struct {
 
 type IsPortAlreadyTakenRequest struct {
 	Port int `json:"port"`
+	// ServerID limits the check to the ports published on a server.
+	ServerID *uuid.UUID `json:"server_id,omitempty"`
 }
 
 // ComposeDomain maps a domain to a specific compose service or port override.
@@ -31,55 +36,71 @@ type ComposeDomain struct {
 }
 
 type CreateDeploymentRequest struct {
-	Name                 string                          `json:"name"`
-	Domains              []string                        `json:"domains,omitempty"`
-	ComposeDomains       []ComposeDomain                 `json:"compose_domains,omitempty"`
-	Environment          shared_types.Environment        `json:"environment"`
-	BuildPack            shared_types.BuildPack          `json:"build_pack"`
-	Repository           string                          `json:"repository"`
-	Branch               string                          `json:"branch"`
-	PreRunCommand        string                          `json:"pre_run_command"`
-	PostRunCommand       string                          `json:"post_run_command"`
-	BuildVariables       map[string]string               `json:"build_variables"`
-	EnvironmentVariables map[string]string               `json:"environment_variables"`
-	Port                 int                             `json:"port"`
-	DockerfilePath       string                          `json:"dockerfile_path,omitempty"`
-	BasePath             string                          `json:"base_path,omitempty"`
-	Source               shared_types.Source             `json:"source,omitempty"`
-	ServerIDs            []uuid.UUID                     `json:"server_ids,omitempty"`
-	PrimaryServerID      *uuid.UUID                      `json:"primary_server_id,omitempty"`
-	RoutingStrategy      shared_types.RoutingStrategy    `json:"routing_strategy,omitempty"`
-	TargetServerIDs      []uuid.UUID                     `json:"target_server_ids,omitempty"`
+	Name                 string                           `json:"name"`
+	Domains              []string                         `json:"domains,omitempty"`
+	ComposeDomains       []ComposeDomain                  `json:"compose_domains,omitempty"`
+	Environment          shared_types.Environment         `json:"environment"`
+	BuildPack            shared_types.BuildPack           `json:"build_pack"`
+	Repository           string                           `json:"repository"`
+	Branch               string                           `json:"branch"`
+	PreRunCommand        string                           `json:"pre_run_command"`
+	PostRunCommand       string                           `json:"post_run_command"`
+	BuildVariables       map[string]string                `json:"build_variables"`
+	EnvironmentVariables map[string]string                `json:"environment_variables"`
+	Port                 int                              `json:"port"`
+	DockerfilePath       string                           `json:"dockerfile_path,omitempty"`
+	BasePath             string                           `json:"base_path,omitempty"`
+	ComposeFiles         []string                         `json:"compose_files,omitempty"`
+	ComposeProfiles      []string                         `json:"compose_profiles,omitempty"`
+	ComposeEnvFiles      []string                         `json:"compose_env_files,omitempty"`
+	ComposeDeployMode    shared_types.ComposeDeployMode   `json:"compose_deploy_mode,omitempty"`
+	PrivateNetworkScope  shared_types.PrivateNetworkScope `json:"private_network_scope,omitempty"`
+	SBOMFormat           shared_types.SBOMFormat          `json:"sbom_format,omitempty"`
+	VulnThreshold        shared_types.Severity            `json:"vulnerability_threshold,omitempty"`
+	Source               shared_types.Source              `json:"source,omitempty"`
+	ServerIDs            []uuid.UUID                      `json:"server_ids,omitempty"`
+	PrimaryServerID      *uuid.UUID                       `json:"primary_server_id,omitempty"`
+	RoutingStrategy      shared_types.RoutingStrategy     `json:"routing_strategy,omitempty"`
+	TargetServerIDs      []uuid.UUID                      `json:"target_server_ids,omitempty"`
 }
 
 // CreateProjectRequest is used to create a project (application) without triggering deployment.
 type CreateProjectRequest struct {
-	Name                 string                       `json:"name"`
-	Domains              []string                     `json:"domains,omitempty"`
-	ComposeDomains       []ComposeDomain              `json:"compose_domains,omitempty"`
-	ComposeServices      []PreviewComposeService      `json:"compose_services,omitempty"`
-	Environment          shared_types.Environment     `json:"environment,omitempty"`
-	BuildPack            shared_types.BuildPack       `json:"build_pack,omitempty"`
-	Repository           string                       `json:"repository"`
-	Branch               string                       `json:"branch,omitempty"`
-	PreRunCommand        string                       `json:"pre_run_command,omitempty"`
-	PostRunCommand       string                       `json:"post_run_command,omitempty"`
-	BuildVariables       map[string]string            `json:"build_variables,omitempty"`
-	EnvironmentVariables map[string]string            `json:"environment_variables,omitempty"`
-	Port                 int                          `json:"port,omitempty"`
-	DockerfilePath       string                       `json:"dockerfile_path,omitempty"`
-	BasePath             string                       `json:"base_path,omitempty"`
-	Source               shared_types.Source          `json:"source,omitempty"`
-	ServerIDs            []uuid.UUID                  `json:"server_ids,omitempty"`
-	PrimaryServerID      *uuid.UUID                   `json:"primary_server_id,omitempty"`
-	RoutingStrategy      shared_types.RoutingStrategy `json:"routing_strategy,omitempty"`
+	Name                 string                           `json:"name"`
+	Domains              []string                         `json:"domains,omitempty"`
+	ComposeDomains       []ComposeDomain                  `json:"compose_domains,omitempty"`
+	ComposeServices      []PreviewComposeService          `json:"compose_services,omitempty"`
+	Environment          shared_types.Environment         `json:"environment,omitempty"`
+	BuildPack            shared_types.BuildPack           `json:"build_pack,omitempty"`
+	Repository           string                           `json:"repository"`
+	Branch               string                           `json:"branch,omitempty"`
+	PreRunCommand        string                           `json:"pre_run_command,omitempty"`
+	PostRunCommand       string                           `json:"post_run_command,omitempty"`
+	BuildVariables       map[string]string                `json:"build_variables,omitempty"`
+	EnvironmentVariables map[string]string                `json:"environment_variables,omitempty"`
+	Port                 int                              `json:"port,omitempty"`
+	DockerfilePath       string                           `json:"dockerfile_path,omitempty"`
+	BasePath             string                           `json:"base_path,omitempty"`
+	ComposeFiles         []string                         `json:"compose_files,omitempty"`
+	ComposeProfiles      []string                         `json:"compose_profiles,omitempty"`
+	ComposeEnvFiles      []string                         `json:"compose_env_files,omitempty"`
+	ComposeDeployMode    shared_types.ComposeDeployMode   `json:"compose_deploy_mode,omitempty"`
+	PrivateNetworkScope  shared_types.PrivateNetworkScope `json:"private_network_scope,omitempty"`
+	SBOMFormat           shared_types.SBOMFormat          `json:"sbom_format,omitempty"`
+	VulnThreshold        shared_types.Severity            `json:"vulnerability_threshold,omitempty"`
+	Source               shared_types.Source              `json:"source,omitempty"`
+	ServerIDs            []uuid.UUID                      `json:"server_ids,omitempty"`
+	PrimaryServerID      *uuid.UUID                       `json:"primary_server_id,omitempty"`
+	RoutingStrategy      shared_types.RoutingStrategy     `json:"routing_strategy,omitempty"`
 }
 
 type PreviewComposeRequest struct {
-	Repository     string `json:"repository"`
-	Branch         string `json:"branch"`
-	BasePath       string `json:"base_path,omitempty"`
-	DockerfilePath string `json:"dockerfile_path,omitempty"`
+	Repository      string   `json:"repository"`
+	Branch          string   `json:"branch"`
+	BasePath        string   `json:"base_path,omitempty"`
+	DockerfilePath  string   `json:"dockerfile_path,omitempty"`
+	ComposeFiles    []string `json:"compose_files,omitempty"`
+	ComposeProfiles []string `json:"compose_profiles,omitempty"`
 }
 
 type PreviewComposeService struct {
@@ -97,21 +118,28 @@ type DeployProjectRequest struct {
 }
 
 type UpdateDeploymentRequest struct {
-	Name                 string                       `json:"name,omitempty"`
-	Environment          shared_types.Environment     `json:"environment,omitempty"`
-	BuildPack            shared_types.BuildPack       `json:"build_pack,omitempty"`
-	PreRunCommand        string                       `json:"pre_run_command,omitempty"`
-	PostRunCommand       string                       `json:"post_run_command,omitempty"`
-	BuildVariables       map[string]string            `json:"build_variables,omitempty"`
-	EnvironmentVariables map[string]string            `json:"environment_variables,omitempty"`
-	Port                 int                          `json:"port,omitempty"`
-	ID                   uuid.UUID                    `json:"id,omitempty"`
-	Force                bool                         `json:"force,omitempty"`
-	DockerfilePath       string                       `json:"dockerfile_path,omitempty"`
-	BasePath             string                       `json:"base_path,omitempty"`
-	Domains              []string                     `json:"domains,omitempty"`
-	ComposeDomains       []ComposeDomain              `json:"compose_domains,omitempty"`
-	RoutingStrategy      shared_types.RoutingStrategy `json:"routing_strategy,omitempty"`
+	Name                 string                           `json:"name,omitempty"`
+	Environment          shared_types.Environment         `json:"environment,omitempty"`
+	BuildPack            shared_types.BuildPack           `json:"build_pack,omitempty"`
+	PreRunCommand        string                           `json:"pre_run_command,omitempty"`
+	PostRunCommand       string                           `json:"post_run_command,omitempty"`
+	BuildVariables       map[string]string                `json:"build_variables,omitempty"`
+	EnvironmentVariables map[string]string                `json:"environment_variables,omitempty"`
+	Port                 int                              `json:"port,omitempty"`
+	ID                   uuid.UUID                        `json:"id,omitempty"`
+	Force                bool                             `json:"force,omitempty"`
+	DockerfilePath       string                           `json:"dockerfile_path,omitempty"`
+	BasePath             string                           `json:"base_path,omitempty"`
+	ComposeFiles         []string                         `json:"compose_files,omitempty"`
+	ComposeProfiles      []string                         `json:"compose_profiles,omitempty"`
+	ComposeEnvFiles      []string                         `json:"compose_env_files,omitempty"`
+	ComposeDeployMode    shared_types.ComposeDeployMode   `json:"compose_deploy_mode,omitempty"`
+	PrivateNetworkScope  shared_types.PrivateNetworkScope `json:"private_network_scope,omitempty"`
+	SBOMFormat           shared_types.SBOMFormat          `json:"sbom_format,omitempty"`
+	VulnThreshold        shared_types.Severity            `json:"vulnerability_threshold,omitempty"`
+	Domains              []string                         `json:"domains,omitempty"`
+	ComposeDomains       []ComposeDomain                  `json:"compose_domains,omitempty"`
+	RoutingStrategy      shared_types.RoutingStrategy     `json:"routing_strategy,omitempty"`
 }
 
 type DeleteDeploymentRequest struct {
@@ -136,10 +164,13 @@ type RestartDeploymentRequest struct {
 
 // DuplicateProjectRequest is used to create a duplicate of an existing project with a different environment.
 type DuplicateProjectRequest struct {
-	SourceProjectID uuid.UUID                    `json:"source_project_id"`
-	Domains         []string                     `json:"domains,omitempty"`
-	Environment     shared_types.Environment     `json:"environment"`
-	Branch          string                       `json:"branch,omitempty"`
+	SourceProjectID uuid.UUID                `json:"source_project_id"`
+	Domains         []string                 `json:"domains,omitempty"`
+	Environment     shared_types.Environment `json:"environment"`
+	Branch          string                   `json:"branch,omitempty"`
+	// ComposeFiles replaces the source project's compose override files, so
+	// environments can share a compose base and differ only by override.
+	ComposeFiles    []string                     `json:"compose_files,omitempty"`
 	ServerIDs       []uuid.UUID                  `json:"server_ids,omitempty"`
 	PrimaryServerID *uuid.UUID                   `json:"primary_server_id,omitempty"`
 	RoutingStrategy shared_types.RoutingStrategy `json:"routing_strategy,omitempty"`
@@ -286,6 +317,818 @@ type ApplicationServersResponse struct {
 	Data    []shared_types.ApplicationServer `json:"data"`
 }
 
+// EnvRevisionsResponse is the typed response for environment revision listing.
+type EnvRevisionsResponse struct {
+	Status  string                                `json:"status"`
+	Message string                                `json:"message"`
+	Data    []shared_types.ApplicationEnvRevision `json:"data"`
+}
+
+// EnvVarChangeType describes how a key differs between two revisions.
+type EnvVarChangeType string
+
+const (
+	EnvVarAdded   EnvVarChangeType = "added"
+	EnvVarRemoved EnvVarChangeType = "removed"
+	EnvVarChanged EnvVarChangeType = "changed"
+)
+
+// EnvVarDiff is a single key difference between two revisions. Values are masked.
+type EnvVarDiff struct {
+	Key      string           `json:"key"`
+	Change   EnvVarChangeType `json:"change"`
+	OldValue string           `json:"old_value,omitempty"`
+	NewValue string           `json:"new_value,omitempty"`
+}
+
+// EnvRevisionDiffResponseData contains the two compared revisions and their differences.
+type EnvRevisionDiffResponseData struct {
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Changes []EnvVarDiff `json:"changes"`
+}
+
+// EnvRevisionDiffResponse is the typed response for comparing two environment revisions.
+type EnvRevisionDiffResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    EnvRevisionDiffResponseData `json:"data"`
+}
+
+// RestoreEnvRevisionRequest restores an application's environment variables to an earlier revision.
+type RestoreEnvRevisionRequest struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+	RevisionID    uuid.UUID `json:"revision_id"`
+}
+
+// EnvRevisionResponse is the typed response for a single environment revision.
+type EnvRevisionResponse struct {
+	Status  string                              `json:"status"`
+	Message string                              `json:"message"`
+	Data    shared_types.ApplicationEnvRevision `json:"data"`
+}
+
+// CaddySnapshotsResponse is the typed response for Caddy config snapshot listing.
+type CaddySnapshotsResponse struct {
+	Status  string                             `json:"status"`
+	Message string                             `json:"message"`
+	Data    []shared_types.CaddyConfigSnapshot `json:"data"`
+}
+
+// CaddyConfigChangeType describes how a value differs between two snapshots.
+type CaddyConfigChangeType string
+
+const (
+	CaddyConfigAdded   CaddyConfigChangeType = "added"
+	CaddyConfigRemoved CaddyConfigChangeType = "removed"
+	CaddyConfigChanged CaddyConfigChangeType = "changed"
+)
+
+// CaddyConfigChange is a single difference between two Caddy configs. Path
+// is a JSON pointer into the config. Secret values are redacted.
+type CaddyConfigChange struct {
+	Path     string                `json:"path"`
+	Change   CaddyConfigChangeType `json:"change"`
+	OldValue json.RawMessage       `json:"old_value,omitempty"`
+	NewValue json.RawMessage       `json:"new_value,omitempty"`
+}
+
+// CaddySnapshotDiffResponseData contains the two compared snapshots and their differences.
+type CaddySnapshotDiffResponseData struct {
+	From    shared_types.CaddyConfigSnapshot `json:"from"`
+	To      shared_types.CaddyConfigSnapshot `json:"to"`
+	Changes []CaddyConfigChange              `json:"changes"`
+}
+
+// CaddySnapshotDiffResponse is the typed response for comparing two Caddy config snapshots.
+type CaddySnapshotDiffResponse struct {
+	Status  string                        `json:"status"`
+	Message string                        `json:"message"`
+	Data    CaddySnapshotDiffResponseData `json:"data"`
+}
+
+// RestoreCaddySnapshotRequest loads an earlier Caddy config snapshot back into its server.
+type RestoreCaddySnapshotRequest struct {
+	SnapshotID uuid.UUID `json:"snapshot_id"`
+}
+
+// CaddySnapshotResponse is the typed response for a single Caddy config snapshot.
+type CaddySnapshotResponse struct {
+	Status  string                           `json:"status"`
+	Message string                           `json:"message"`
+	Data    shared_types.CaddyConfigSnapshot `json:"data"`
+}
+
+// CreateVariableGroupRequest creates a shared variable group. Without a FamilyID the
+// group is available to every application in the organization.
+type CreateVariableGroupRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	FamilyID    *uuid.UUID        `json:"family_id,omitempty"`
+	Variables   map[string]string `json:"variables"`
+}
+
+// UpdateVariableGroupRequest updates a shared variable group. Nil fields are left unchanged.
+type UpdateVariableGroupRequest struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+// RedeployVariableGroupRequest redeploys every application using a variable group.
+type RedeployVariableGroupRequest struct {
+	ID    uuid.UUID `json:"id"`
+	Force bool      `json:"force"`
+}
+
+// SetApplicationVariableGroupsRequest replaces the variable groups attached to an
+// application. Groups listed later take precedence over earlier ones; the
+// application's own variables always win.
+type SetApplicationVariableGroupsRequest struct {
+	ApplicationID    uuid.UUID   `json:"application_id"`
+	VariableGroupIDs []uuid.UUID `json:"variable_group_ids"`
+}
+
+// VariableGroupResponse is the typed response for single variable group operations.
+type VariableGroupResponse struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    shared_types.VariableGroup `json:"data"`
+}
+
+// VariableGroupsResponse is the typed response for variable group listing.
+type VariableGroupsResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    []shared_types.VariableGroup `json:"data"`
+}
+
+// VariableGroupApplication is an application using a variable group.
+type VariableGroupApplication struct {
+	ID          uuid.UUID                `json:"id"`
+	Name        string                   `json:"name"`
+	Environment shared_types.Environment `json:"environment"`
+}
+
+// UpdateVariableGroupResponseData contains the updated group and the applications
+// that need a redeploy to pick up the change.
+type UpdateVariableGroupResponseData struct {
+	Group                shared_types.VariableGroup `json:"group"`
+	AffectedApplications []VariableGroupApplication `json:"affected_applications"`
+}
+
+// UpdateVariableGroupResponse is the typed response for variable group updates.
+type UpdateVariableGroupResponse struct {
+	Status  string                          `json:"status"`
+	Message string                          `json:"message"`
+	Data    UpdateVariableGroupResponseData `json:"data"`
+}
+
+// VariableGroupApplicationsResponse is the typed response for listing applications using a group.
+type VariableGroupApplicationsResponse struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    []VariableGroupApplication `json:"data"`
+}
+
+// VariableGroupRedeployResult reports the outcome of redeploying a group's applications.
+type VariableGroupRedeployResult struct {
+	Redeployed []RecoverAppResult `json:"redeployed"`
+	Skipped    []RecoverAppResult `json:"skipped"`
+	Failed     []RecoverAppResult `json:"failed"`
+}
+
+// RedeployVariableGroupResponse is the typed response for redeploying a group's applications.
+type RedeployVariableGroupResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    VariableGroupRedeployResult `json:"data"`
+}
+
+// ApplicationVariableGroupsResponse is the typed response for an application's attached groups.
+type ApplicationVariableGroupsResponse struct {
+	Status  string                                  `json:"status"`
+	Message string                                  `json:"message"`
+	Data    []shared_types.ApplicationVariableGroup `json:"data"`
+}
+
+// UpsertSecretManagerRequest connects an organization to an external secret manager.
+// URL is the Infisical URL or Vault address. An empty Token keeps the stored one.
+type UpsertSecretManagerRequest struct {
+	Type        secrets.SecretManagerType `json:"type"`
+	URL         string                    `json:"url"`
+	Token       string                    `json:"token,omitempty"`
+	ProjectID   string                    `json:"project_id,omitempty"`
+	Environment string                    `json:"environment,omitempty"`
+	Mount       string                    `json:"mount,omitempty"`
+	Namespace   string                    `json:"namespace,omitempty"`
+}
+
+// TestSecretManagerRequest checks that a secret manager connection can read a path.
+type TestSecretManagerRequest struct {
+	Type secrets.SecretManagerType `json:"type"`
+	Path string                    `json:"path"`
+}
+
+// SecretManagerResponse is the typed response for single secret manager operations.
+type SecretManagerResponse struct {
+	Status  string                                 `json:"status"`
+	Message string                                 `json:"message"`
+	Data    shared_types.OrganizationSecretManager `json:"data"`
+}
+
+// SecretManagersResponse is the typed response for secret manager listing.
+type SecretManagersResponse struct {
+	Status  string                                   `json:"status"`
+	Message string                                   `json:"message"`
+	Data    []shared_types.OrganizationSecretManager `json:"data"`
+}
+
+// TestSecretManagerResponse lists the secret keys readable at the tested path. Values are never returned.
+type TestSecretManagerResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Data    []string `json:"data"`
+}
+
+// CreateDNSProviderRequest adds a DNS provider whose credentials Caddy uses
+// to solve DNS-01 challenges for the domains under Zones.
+type CreateDNSProviderRequest struct {
+	Name        string                       `json:"name"`
+	Provider    shared_types.DNSProviderType `json:"provider"`
+	Zones       []string                     `json:"zones"`
+	Credentials map[string]string            `json:"credentials"`
+}
+
+// UpdateDNSProviderRequest changes a DNS provider. Credential fields left
+// empty keep their stored value.
+type UpdateDNSProviderRequest struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	Zones       []string          `json:"zones"`
+	Credentials map[string]string `json:"credentials,omitempty"`
+}
+
+// DNSProviderResponse is the typed response for single DNS provider operations.
+type DNSProviderResponse struct {
+	Status  string                               `json:"status"`
+	Message string                               `json:"message"`
+	Data    shared_types.OrganizationDNSProvider `json:"data"`
+}
+
+// DNSProvidersResponse is the typed response for DNS provider listing.
+type DNSProvidersResponse struct {
+	Status  string                                 `json:"status"`
+	Message string                                 `json:"message"`
+	Data    []shared_types.OrganizationDNSProvider `json:"data"`
+}
+
+// ComposeServiceRequest targets a single service of a docker compose application.
+type ComposeServiceRequest struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+}
+
+// ScaleComposeServiceRequest sets the number of containers of a compose service.
+type ScaleComposeServiceRequest struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Replicas    int       `json:"replicas"`
+}
+
+// ComposeServiceResponse is the typed response for single compose service operations.
+type ComposeServiceResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    shared_types.ComposeService `json:"data"`
+}
+
+type ComposeServiceLogsResponseData struct {
+	ServiceName string `json:"service_name"`
+	Logs        string `json:"logs"`
+}
+
+// ComposeServiceLogsResponse is the typed response for compose service logs.
+type ComposeServiceLogsResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    ComposeServiceLogsResponseData `json:"data"`
+}
+
+// AddApplicationPortRequest publishes a TCP or UDP port of an application.
+// ServiceName selects the compose service. Without a ServerID the port is
+// published on every server of the application.
+type AddApplicationPortRequest struct {
+	ID            uuid.UUID                    `json:"id"`
+	ServiceName   string                       `json:"service_name,omitempty"`
+	Protocol      shared_types.PortProtocol    `json:"protocol"`
+	TargetPort    int                          `json:"target_port"`
+	PublishedPort int                          `json:"published_port"`
+	PublishMode   shared_types.PortPublishMode `json:"publish_mode"`
+	ServerID      *uuid.UUID                   `json:"server_id,omitempty"`
+}
+
+// DeleteApplicationPortRequest removes a published port from an application.
+type DeleteApplicationPortRequest struct {
+	ID     uuid.UUID `json:"id"`
+	PortID uuid.UUID `json:"port_id"`
+}
+
+// ApplicationPortResponse is the typed response for single application port operations.
+type ApplicationPortResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    shared_types.ApplicationPort `json:"data"`
+}
+
+// ApplicationPortsResponse is the typed response for application port listing.
+type ApplicationPortsResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    []shared_types.ApplicationPort `json:"data"`
+}
+
+// PhaseStats aggregates the runs of one deployment phase.
+type PhaseStats struct {
+	Phase       shared_types.DeploymentPhaseName `json:"phase"`
+	Count       int                              `json:"count"`
+	Failures    int                              `json:"failures"`
+	FailureRate float64                          `json:"failure_rate"`
+	P50Ms       int64                            `json:"p50_ms"`
+	P95Ms       int64                            `json:"p95_ms"`
+}
+
+// ApplicationBuildStats summarizes the phases of an application's latest deployments.
+type ApplicationBuildStats struct {
+	Deployments  int          `json:"deployments"`
+	BuildP50Ms   int64        `json:"build_p50_ms"`
+	BuildP95Ms   int64        `json:"build_p95_ms"`
+	BuildSteps   int          `json:"build_steps"`
+	CachedSteps  int          `json:"cached_steps"`
+	CacheHitRate float64      `json:"cache_hit_rate"`
+	Phases       []PhaseStats `json:"phases"`
+}
+
+// ApplicationBuildStatsResponse is the typed response for application build analytics.
+type ApplicationBuildStatsResponse struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    ApplicationBuildStats `json:"data"`
+}
+
+// PackageDeployment is a deployment whose image contains a package.
+type PackageDeployment struct {
+	ApplicationDeploymentID uuid.UUID `json:"application_deployment_id" bun:"application_deployment_id"`
+	ApplicationID           uuid.UUID `json:"application_id" bun:"application_id"`
+	ApplicationName         string    `json:"application_name" bun:"application_name"`
+	Name                    string    `json:"name" bun:"name"`
+	Version                 string    `json:"version" bun:"version"`
+	Type                    string    `json:"type" bun:"type"`
+	PURL                    string    `json:"purl" bun:"purl"`
+	CreatedAt               time.Time `json:"created_at" bun:"created_at"`
+}
+
+// PackageDeploymentsResponse is the typed response for package lookups.
+type PackageDeploymentsResponse struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    []PackageDeployment `json:"data"`
+}
+
+// DeploymentSBOMResponse is the typed response for a deployment's SBOM.
+type DeploymentSBOMResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    shared_types.DeploymentSBOM `json:"data"`
+}
+
+// MigrateApplicationRequest starts moving an application to another server.
+// SourceServerID defaults to the application's primary server. Transfer
+// defaults to s3 when the last image was exported there and ssh otherwise.
+// StopSource scales the source down while volumes are copied so their data
+// is consistent, at the cost of downtime until the target is up.
+type MigrateApplicationRequest struct {
+	ApplicationID  uuid.UUID  `json:"application_id"`
+	TargetServerID uuid.UUID  `json:"target_server_id"`
+	SourceServerID *uuid.UUID `json:"source_server_id,omitempty"`
+	Transfer       string     `json:"transfer,omitempty"`
+	StopSource     bool       `json:"stop_source,omitempty"`
+}
+
+// MigrationActionRequest confirms or rolls back a migration.
+type MigrationActionRequest struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// ApplicationMigrationResponse is the typed response for a single migration.
+type ApplicationMigrationResponse struct {
+	Status  string                            `json:"status"`
+	Message string                            `json:"message"`
+	Data    shared_types.ApplicationMigration `json:"data"`
+}
+
+// ApplicationMigrationsResponse is the typed response for the migrations of an application.
+type ApplicationMigrationsResponse struct {
+	Status  string                              `json:"status"`
+	Message string                              `json:"message"`
+	Data    []shared_types.ApplicationMigration `json:"data"`
+}
+
+// CreateDatabaseRequest provisions a managed database. Version defaults to the
+// engine's current major version and ServerID to the organization's default
+// server. Zero resource limits leave the database unlimited.
+type CreateDatabaseRequest struct {
+	Name          string     `json:"name"`
+	Engine        string     `json:"engine"`
+	Version       string     `json:"version,omitempty"`
+	ServerID      *uuid.UUID `json:"server_id,omitempty"`
+	CPULimit      float64    `json:"cpu_limit,omitempty"`
+	MemoryLimitMB int64      `json:"memory_limit_mb,omitempty"`
+}
+
+// ResizeDatabaseRequest replaces the resource limits of a database. Zero
+// removes a limit.
+type ResizeDatabaseRequest struct {
+	CPULimit      float64 `json:"cpu_limit"`
+	MemoryLimitMB int64   `json:"memory_limit_mb"`
+}
+
+// RotateDatabasePasswordRequest generates a new password for a database.
+// Attached applications only see it after a redeploy, which Redeploy queues.
+type RotateDatabasePasswordRequest struct {
+	Redeploy bool `json:"redeploy,omitempty"`
+	Force    bool `json:"force,omitempty"`
+}
+
+// AttachDatabaseRequest injects the connection string of a database into an
+// application. EnvVar defaults to DATABASE_URL, or REDIS_URL for Redis.
+type AttachDatabaseRequest struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+	EnvVar        string    `json:"env_var,omitempty"`
+}
+
+// DetachDatabaseRequest removes a database from an application.
+type DetachDatabaseRequest struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+}
+
+// DatabaseConnectionInfo is how applications on the database's private
+// network connect to it.
+type DatabaseConnectionInfo struct {
+	Host             string `json:"host"`
+	Port             int    `json:"port"`
+	DatabaseName     string `json:"database_name,omitempty"`
+	Username         string `json:"username,omitempty"`
+	Password         string `json:"password"`
+	ConnectionString string `json:"connection_string"`
+	Network          string `json:"network"`
+}
+
+// DatabaseApplication is an application a database is attached to.
+type DatabaseApplication struct {
+	ID          uuid.UUID                `json:"id"`
+	Name        string                   `json:"name"`
+	Environment shared_types.Environment `json:"environment"`
+	EnvVar      string                   `json:"env_var"`
+}
+
+// RotateDatabasePasswordResponseData contains the database and the
+// applications that need a redeploy to use the new password.
+type RotateDatabasePasswordResponseData struct {
+	Database             shared_types.Database        `json:"database"`
+	AffectedApplications []DatabaseApplication        `json:"affected_applications"`
+	Redeploy             *VariableGroupRedeployResult `json:"redeploy,omitempty"`
+}
+
+// DatabaseResponse is the typed response for single database operations.
+type DatabaseResponse struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    shared_types.Database `json:"data"`
+}
+
+// DatabasesResponse is the typed response for database listing.
+type DatabasesResponse struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Data    []shared_types.Database `json:"data"`
+}
+
+// DatabaseConnectionResponse is the typed response for database connection info.
+type DatabaseConnectionResponse struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    DatabaseConnectionInfo `json:"data"`
+}
+
+// DatabaseApplicationsResponse is the typed response for listing the
+// applications a database is attached to.
+type DatabaseApplicationsResponse struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    []DatabaseApplication `json:"data"`
+}
+
+// ApplicationDatabaseResponse is the typed response for attaching a database.
+type ApplicationDatabaseResponse struct {
+	Status  string                           `json:"status"`
+	Message string                           `json:"message"`
+	Data    shared_types.ApplicationDatabase `json:"data"`
+}
+
+// RotateDatabasePasswordResponse is the typed response for password rotation.
+type RotateDatabasePasswordResponse struct {
+	Status  string                             `json:"status"`
+	Message string                             `json:"message"`
+	Data    RotateDatabasePasswordResponseData `json:"data"`
+}
+
+// UpdateDatabaseBackupScheduleRequest configures scheduled backups. Schedule
+// is a standard five field cron expression in UTC, empty to disable scheduled
+// backups. RetentionCount keeps that many of the latest successful backups,
+// RetentionDays additionally expires older ones when set.
+type UpdateDatabaseBackupScheduleRequest struct {
+	Schedule       string `json:"schedule"`
+	RetentionCount int    `json:"retention_count"`
+	RetentionDays  int    `json:"retention_days,omitempty"`
+}
+
+// RestoreDatabaseBackupRequest restores a backup. Target is "same" to replace
+// the contents of the backed up database, or "new" to provision a database
+// named Name on the same server and restore into it.
+type RestoreDatabaseBackupRequest struct {
+	Target string `json:"target"`
+	Name   string `json:"name,omitempty"`
+}
+
+// DatabaseBackupResponse is the typed response for single backup operations.
+type DatabaseBackupResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    shared_types.DatabaseBackup `json:"data"`
+}
+
+// DatabaseBackupsResponse is the typed response for backup listing.
+type DatabaseBackupsResponse struct {
+	Status  string                        `json:"status"`
+	Message string                        `json:"message"`
+	Data    []shared_types.DatabaseBackup `json:"data"`
+}
+
+// DatabaseRestoreResponse is the typed response for single restore operations.
+type DatabaseRestoreResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    shared_types.DatabaseRestore `json:"data"`
+}
+
+// DatabaseRestoresResponse is the typed response for restore listing.
+type DatabaseRestoresResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    []shared_types.DatabaseRestore `json:"data"`
+}
+
+// CreateVolumeBackupPolicyRequest sets up backups of a named Docker volume
+// or of a bind mounted path on the application's server. SourceType is
+// "volume" or "bind", Source the volume name or the absolute path. Schedule
+// and retention work like those of database backups, an empty Schedule only
+// allows on-demand backups.
+type CreateVolumeBackupPolicyRequest struct {
+	ApplicationID  uuid.UUID `json:"application_id"`
+	SourceType     string    `json:"source_type"`
+	Source         string    `json:"source"`
+	Schedule       string    `json:"schedule,omitempty"`
+	RetentionCount int       `json:"retention_count,omitempty"`
+	RetentionDays  int       `json:"retention_days,omitempty"`
+}
+
+// UpdateVolumeBackupPolicyRequest replaces the schedule and retention of a
+// volume backup policy.
+type UpdateVolumeBackupPolicyRequest struct {
+	Schedule       string `json:"schedule"`
+	RetentionCount int    `json:"retention_count"`
+	RetentionDays  int    `json:"retention_days,omitempty"`
+}
+
+// VolumeBackupPolicyResponse is the typed response for single volume backup
+// policy operations.
+type VolumeBackupPolicyResponse struct {
+	Status  string                          `json:"status"`
+	Message string                          `json:"message"`
+	Data    shared_types.VolumeBackupPolicy `json:"data"`
+}
+
+// VolumeBackupPoliciesResponse is the typed response for volume backup
+// policy listing.
+type VolumeBackupPoliciesResponse struct {
+	Status  string                            `json:"status"`
+	Message string                            `json:"message"`
+	Data    []shared_types.VolumeBackupPolicy `json:"data"`
+}
+
+// VolumeBackupResponse is the typed response for single volume backup operations.
+type VolumeBackupResponse struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    shared_types.VolumeBackup `json:"data"`
+}
+
+// VolumeBackupsResponse is the typed response for volume backup listing.
+type VolumeBackupsResponse struct {
+	Status  string                      `json:"status"`
+	Message string                      `json:"message"`
+	Data    []shared_types.VolumeBackup `json:"data"`
+}
+
+// VolumeRestoreResponse is the typed response for single volume restore operations.
+type VolumeRestoreResponse struct {
+	Status  string                     `json:"status"`
+	Message string                     `json:"message"`
+	Data    shared_types.VolumeRestore `json:"data"`
+}
+
+// VolumeRestoresResponse is the typed response for volume restore listing.
+type VolumeRestoresResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    []shared_types.VolumeRestore `json:"data"`
+}
+
+// UpdateScaleToZeroRequest sets how long an application may go without
+// requests before it is scaled to zero. Zero disables scale-to-zero.
+type UpdateScaleToZeroRequest struct {
+	ApplicationID      uuid.UUID `json:"application_id" validate:"required"`
+	IdleTimeoutMinutes int       `json:"idle_timeout_minutes"`
+}
+
+type ScaleToZeroResponse struct {
+	Status  string                              `json:"status"`
+	Message string                              `json:"message"`
+	Data    shared_types.ApplicationScaleToZero `json:"data"`
+}
+
+// UpdateMaintenanceRequest sets the maintenance mode of an application, or
+// of one of its domains when Domain is set.
+type UpdateMaintenanceRequest struct {
+	ApplicationID     uuid.UUID `json:"application_id" validate:"required"`
+	Domain            string    `json:"domain,omitempty"`
+	Enabled           bool      `json:"enabled"`
+	HTMLBody          string    `json:"html_body,omitempty"`
+	RetryAfterSeconds int       `json:"retry_after_seconds,omitempty"`
+	AllowedIPs        []string  `json:"allowed_ips,omitempty"`
+}
+
+type MaintenanceResponse struct {
+	Status  string                              `json:"status"`
+	Message string                              `json:"message"`
+	Data    shared_types.ApplicationMaintenance `json:"data"`
+}
+
+type MaintenanceListResponse struct {
+	Status  string                                `json:"status"`
+	Message string                                `json:"message"`
+	Data    []shared_types.ApplicationMaintenance `json:"data"`
+}
+
+// UpdateAutoscalingPolicyRequest creates or replaces the autoscaling policy
+// of an application.
+type UpdateAutoscalingPolicyRequest struct {
+	ApplicationID   uuid.UUID                      `json:"application_id" validate:"required"`
+	Enabled         bool                           `json:"enabled"`
+	MinReplicas     int                            `json:"min_replicas"`
+	MaxReplicas     int                            `json:"max_replicas"`
+	Metric          shared_types.AutoscalingMetric `json:"metric"`
+	TargetPercent   int                            `json:"target_percent"`
+	CooldownSeconds int                            `json:"cooldown_seconds,omitempty"`
+}
+
+type AutoscalingPolicyResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    shared_types.AutoscalingPolicy `json:"data"`
+}
+
+type ScalingEventsResponse struct {
+	Status  string                                 `json:"status"`
+	Message string                                 `json:"message"`
+	Data    []shared_types.ApplicationScalingEvent `json:"data"`
+}
+
+// UploadCertificateRequest uploads a certificate chain and its private key
+// for a domain of an application.
+type UploadCertificateRequest struct {
+	ApplicationID uuid.UUID `json:"application_id" validate:"required"`
+	Domain        string    `json:"domain"`
+	// CertificatePEM is the leaf certificate followed by its intermediates.
+	CertificatePEM string `json:"certificate_pem"`
+	PrivateKeyPEM  string `json:"private_key_pem"`
+	// ExpiryWarningDays defaults to 14.
+	ExpiryWarningDays int `json:"expiry_warning_days,omitempty"`
+}
+
+type CertificateResponse struct {
+	Status  string                              `json:"status"`
+	Message string                              `json:"message"`
+	Data    shared_types.ApplicationCertificate `json:"data"`
+}
+
+type CertificatesResponse struct {
+	Status  string                                `json:"status"`
+	Message string                                `json:"message"`
+	Data    []shared_types.ApplicationCertificate `json:"data"`
+}
+
+// CreateRedirectRequest adds a redirect rule to an application. An empty
+// SourceDomain applies the rule to every domain of the application, and a
+// SourceDomain outside of them makes an alias that only redirects.
+type CreateRedirectRequest struct {
+	ApplicationID uuid.UUID `json:"application_id" validate:"required"`
+	SourceDomain  string    `json:"source_domain,omitempty"`
+	SourcePath    string    `json:"source_path,omitempty"`
+	TargetDomain  string    `json:"target_domain,omitempty"`
+	TargetPath    string    `json:"target_path,omitempty"`
+	// StatusCode defaults to 301.
+	StatusCode   int  `json:"status_code,omitempty"`
+	PreservePath bool `json:"preserve_path"`
+}
+
+// UpdateRedirectRequest replaces the source, target and options of a
+// redirect rule.
+type UpdateRedirectRequest struct {
+	ID           uuid.UUID `json:"id" validate:"required"`
+	SourceDomain string    `json:"source_domain,omitempty"`
+	SourcePath   string    `json:"source_path,omitempty"`
+	TargetDomain string    `json:"target_domain,omitempty"`
+	TargetPath   string    `json:"target_path,omitempty"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	PreservePath bool      `json:"preserve_path"`
+}
+
+type RedirectResponse struct {
+	Status  string                           `json:"status"`
+	Message string                           `json:"message"`
+	Data    shared_types.ApplicationRedirect `json:"data"`
+}
+
+type RedirectsResponse struct {
+	Status  string                             `json:"status"`
+	Message string                             `json:"message"`
+	Data    []shared_types.ApplicationRedirect `json:"data"`
+}
+
+// UpdateAccessPolicyRequest sets the access policy of an application, or of
+// one of its domains when Domain is set. A basic auth user sent without a
+// password keeps its current one.
+type UpdateAccessPolicyRequest struct {
+	ApplicationID          uuid.UUID              `json:"application_id" validate:"required"`
+	Domain                 string                 `json:"domain,omitempty"`
+	AllowedIPs             []string               `json:"allowed_ips,omitempty"`
+	DeniedIPs              []string               `json:"denied_ips,omitempty"`
+	BasicAuthUsers         []BasicAuthUserRequest `json:"basic_auth_users,omitempty"`
+	BasicAuthRealm         string                 `json:"basic_auth_realm,omitempty"`
+	ForwardAuthURL         string                 `json:"forward_auth_url,omitempty"`
+	ForwardAuthCopyHeaders []string               `json:"forward_auth_copy_headers,omitempty"`
+}
+
+type BasicAuthUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+type AccessPolicyResponse struct {
+	Status  string                               `json:"status"`
+	Message string                               `json:"message"`
+	Data    shared_types.ApplicationAccessPolicy `json:"data"`
+}
+
+type AccessPoliciesResponse struct {
+	Status  string                                 `json:"status"`
+	Message string                                 `json:"message"`
+	Data    []shared_types.ApplicationAccessPolicy `json:"data"`
+}
+
+// UpdateProxySettingsRequest replaces the proxy settings of an application.
+type UpdateProxySettingsRequest struct {
+	ApplicationID          uuid.UUID         `json:"application_id" validate:"required"`
+	ResponseHeaders        map[string]string `json:"response_headers,omitempty"`
+	RemoveResponseHeaders  []string          `json:"remove_response_headers,omitempty"`
+	Encodings              []string          `json:"encodings,omitempty"`
+	MaxRequestBodyBytes    int64             `json:"max_request_body_bytes,omitempty"`
+	DialTimeoutSeconds     int               `json:"dial_timeout_seconds,omitempty"`
+	ResponseTimeoutSeconds int               `json:"response_timeout_seconds,omitempty"`
+	RateLimitRequests      int               `json:"rate_limit_requests,omitempty"`
+	// RateLimitWindowSeconds defaults to 60.
+	RateLimitWindowSeconds int `json:"rate_limit_window_seconds,omitempty"`
+}
+
+type ProxySettingsResponse struct {
+	Status  string                                `json:"status"`
+	Message string                                `json:"message"`
+	Data    shared_types.ApplicationProxySettings `json:"data"`
+}
+
 type CancelDeploymentRequest struct {
 	DeploymentID uuid.UUID `json:"deployment_id"`
 }
@@ -359,10 +1202,138 @@ var (
 	ErrProjectFamilyNotFound            = errors.New("project family not found")
 	ErrDomainLimitReached               = errors.New("maximum of 5 domains per application reached")
 	ErrDomainAlreadyExists              = errors.New("domain already exists for this application")
+	ErrInvalidPathPrefix                = errors.New("path prefix of the domain contains characters that are not allowed")
 	ErrPaymentRequired                  = errors.New("payment required: deployment limit reached, please upgrade your plan")
 	ErrDeploymentNotCancellable         = errors.New("deployment is not in a cancellable state")
 	ErrDeploymentNotRunning             = errors.New("deployment not found or not running on this instance")
 	ErrPermissionDenied                 = errors.New("permission denied")
+	ErrEnvRevisionNotFound              = errors.New("environment revision not found")
+	ErrCaddySnapshotNotFound            = errors.New("proxy config snapshot not found")
+	ErrCaddySnapshotServerChanged       = errors.New("proxy config snapshot was taken on another server than the one in use")
+	ErrVariableGroupNotFound            = errors.New("variable group not found")
+	ErrVariableGroupNameTaken           = errors.New("a variable group with this name already exists")
+	ErrVariableGroupFamilyMismatch      = errors.New("variable group belongs to a different project family")
+	ErrDuplicateVariableGroup           = errors.New("variable group is attached more than once")
+	ErrUnsupportedSecretManager         = errors.New("unsupported secret manager type, expected infisical or vault")
+	ErrSecretManagerNotFound            = errors.New("secret manager is not configured")
+	ErrInvalidSecretManagerURL          = errors.New("a valid http or https secret manager url is required")
+	ErrMissingSecretManagerToken        = errors.New("secret manager token is required")
+	ErrNotComposeApplication            = errors.New("application is not a docker compose application")
+	ErrComposeServiceNotFound           = errors.New("compose service not found")
+	ErrInvalidReplicaCount              = errors.New("replicas must be between 0 and 20")
+	ErrInvalidComposeFilePath           = errors.New("compose files and env files must be relative paths inside the repository")
+	ErrInvalidComposeProfile            = errors.New("invalid compose profile name")
+	ErrInvalidComposeDeployMode         = errors.New("invalid compose deploy mode, must be compose or stack")
+	ErrComposeStackImageRequired        = errors.New("stack deployments need an image for every service that has a build section")
+	ErrGlobalServiceNotScalable         = errors.New("services in global mode run one task per node and cannot be scaled")
+	ErrInvalidPrivateNetworkScope       = errors.New("invalid private network scope, must be organization or family")
+	ErrInvalidPortProtocol              = errors.New("invalid port protocol, must be tcp or udp")
+	ErrInvalidPortPublishMode           = errors.New("invalid port publish mode, must be ingress, host or proxy")
+	ErrInvalidPortNumber                = errors.New("ports must be between 1 and 65535")
+	ErrPortReserved                     = errors.New("port is reserved by Nixopus")
+	ErrPortInDynamicRange               = errors.New("published ports from 49152 to 65535 are assigned to applications automatically")
+	ErrPortConflict                     = errors.New("port is already published on the same server")
+	ErrApplicationPortNotFound          = errors.New("application port not found")
+	ErrPortServiceRequired              = errors.New("service_name is required for compose applications")
+	ErrPortServerNotAssigned            = errors.New("server is not assigned to the application")
+	ErrInvalidSBOMFormat                = errors.New("invalid sbom format, must be none, cyclonedx or spdx")
+	ErrInvalidVulnThreshold             = errors.New("invalid vulnerability threshold, must be none, negligible, low, medium, high or critical")
+	ErrVulnThresholdExceeded            = errors.New("image has vulnerabilities at or above the configured threshold")
+	ErrSBOMNotFound                     = errors.New("sbom not found for deployment")
+	ErrMissingPackageName               = errors.New("package name is required")
+	ErrMigrationSameServer              = errors.New("target server must differ from the source server")
+	ErrMigrationTargetAssigned          = errors.New("application is already assigned to the target server")
+	ErrMigrationInProgress              = errors.New("application already has a migration in progress")
+	ErrMigrationNotFound                = errors.New("migration not found")
+	ErrMigrationNotAwaitingConfirmation = errors.New("migration is not awaiting confirmation")
+	ErrMigrationCannotRollback          = errors.New("migration can only be rolled back while awaiting confirmation or after it failed")
+	ErrInvalidMigrationTransfer         = errors.New("invalid migration transfer, must be s3 or ssh")
+	ErrMigrationVolumeExists            = errors.New("volume already exists on the target server")
+	ErrServerNotFound                   = errors.New("server not found")
+	ErrNoImageToMigrate                 = errors.New("application has no deployed image to migrate")
+	ErrDatabaseNotFound                 = errors.New("database not found")
+	ErrDatabaseNameTaken                = errors.New("a database with this name already exists")
+	ErrInvalidDatabaseEngine            = errors.New("invalid database engine, must be postgres, mysql or redis")
+	ErrInvalidDatabaseVersion           = errors.New("invalid database version")
+	ErrInvalidDatabaseResources         = errors.New("database resource limits cannot be negative")
+	ErrDatabaseInUse                    = errors.New("database is attached to applications, detach it first")
+	ErrDatabaseServerMismatch           = errors.New("application runs on a server other than the database's server")
+	ErrDatabaseAlreadyAttached          = errors.New("database is already attached to this application")
+	ErrDatabaseEnvVarTaken              = errors.New("another database is already attached under this environment variable")
+	ErrDatabaseNotAttached              = errors.New("database is not attached to this application")
+	ErrDatabaseNotRunning               = errors.New("database is not running")
+	ErrInvalidEnvVarName                = errors.New("invalid environment variable name")
+	ErrDatabaseBackupNotFound           = errors.New("database backup not found")
+	ErrDatabaseRestoreNotFound          = errors.New("database restore not found")
+	ErrDatabaseBackupUnsupported        = errors.New("backups are only supported for postgres and mysql databases")
+	ErrDatabaseBackupInProgress         = errors.New("a backup of this database is already in progress")
+	ErrDatabaseBackupNotRestorable      = errors.New("only successful backups can be restored")
+	ErrInvalidBackupSchedule            = errors.New("invalid backup schedule, must be a five field cron expression")
+	ErrInvalidBackupRetention           = errors.New("backup retention must keep at least one backup and days cannot be negative")
+	ErrInvalidRestoreTarget             = errors.New("invalid restore target, must be same or new")
+	ErrVolumeBackupPolicyNotFound       = errors.New("volume backup policy not found")
+	ErrVolumeBackupPolicyExists         = errors.New("a backup policy for this volume already exists")
+	ErrInvalidVolumeBackupSource        = errors.New("invalid backup source, must be a volume name or an absolute path other than /")
+	ErrVolumeBackupSourceNotMounted     = errors.New("backup source is not mounted by the application's containers")
+	ErrVolumeBackupNotFound             = errors.New("volume backup not found")
+	ErrVolumeRestoreNotFound            = errors.New("volume restore not found")
+	ErrVolumeBackupInProgress           = errors.New("a backup or restore of this volume is already in progress")
+	ErrVolumeBackupNotRestorable        = errors.New("only successful volume backups can be restored")
+	ErrScaleToZeroUnsupported           = errors.New("scale-to-zero is only supported for single service applications")
+	ErrScaleToZeroPathPrefix            = errors.New("scale-to-zero is not supported for applications mounted under a path prefix")
+	ErrWakeProxyNotConfigured           = errors.New("scale-to-zero requires the wake listener, set WAKE_PORT and WAKE_DIAL")
+	ErrInvalidIdleTimeout               = errors.New("idle timeout must be 0 to disable or at least 5 minutes")
+	ErrApplicationWakeFailed            = errors.New("application did not become healthy in time")
+	ErrApplicationNotSleeping           = errors.New("application is not scaled to zero")
+	ErrMaintenanceNotFound              = errors.New("maintenance setting not found")
+	ErrDomainNotInApplication           = errors.New("domain does not belong to this application")
+	ErrInvalidMaintenanceIP             = errors.New("allowed IPs must be IP addresses or CIDR ranges")
+	ErrInvalidRetryAfter                = errors.New("retry after must be between 0 and 86400 seconds")
+	ErrMaintenanceBodyTooLarge          = errors.New("maintenance page must be at most 64 KiB")
+	ErrAutoscalingPolicyNotFound        = errors.New("autoscaling policy not found")
+	ErrAutoscalingUnsupported           = errors.New("autoscaling is only supported for single service applications")
+	ErrAutoscalingMultiNode             = errors.New("autoscaling is only supported on single-node swarms")
+	ErrInvalidAutoscalingReplicas       = errors.New("replicas must satisfy 1 <= min_replicas <= max_replicas <= 100")
+	ErrInvalidAutoscalingMetric         = errors.New("metric must be cpu or memory")
+	ErrInvalidAutoscalingTarget         = errors.New("target percent must be between 1 and 100")
+	ErrInvalidAutoscalingCooldown       = errors.New("cooldown must be between 30 and 86400 seconds")
+	ErrDNSProviderNotFound              = errors.New("dns provider not found")
+	ErrUnsupportedDNSProvider           = errors.New("unsupported dns provider, expected cloudflare, route53, digitalocean or rfc2136")
+	ErrInvalidDNSProviderName           = errors.New("dns provider name is required")
+	ErrDNSProviderNameTaken             = errors.New("a dns provider with this name already exists")
+	ErrInvalidDNSZone                   = errors.New("zones must be one or more valid domain names")
+	ErrDNSZoneTaken                     = errors.New("zone is already served by another dns provider")
+	ErrMissingDNSCredential             = errors.New("missing dns provider credential")
+	ErrUnknownDNSCredential             = errors.New("unknown dns provider credential")
+	ErrCertificateNotFound              = errors.New("certificate not found")
+	ErrInvalidCertificate               = errors.New("certificate_pem must contain a PEM encoded certificate chain")
+	ErrInvalidPrivateKey                = errors.New("private_key_pem must contain a PEM encoded private key")
+	ErrCertificateKeyMismatch           = errors.New("private key does not match the certificate")
+	ErrCertificateDomainMismatch        = errors.New("certificate is not valid for the domain")
+	ErrCertificateExpired               = errors.New("certificate is expired or not yet valid")
+	ErrCertificateTooLarge              = errors.New("certificate chain and key must each be at most 64 KiB")
+	ErrInvalidExpiryWarningDays         = errors.New("expiry warning days must be between 1 and 90")
+	ErrRedirectNotFound                 = errors.New("redirect not found")
+	ErrInvalidRedirectDomain            = errors.New("source and target domains must be valid domain names")
+	ErrInvalidRedirectPath              = errors.New("redirect paths must start with / and cannot contain spaces, *, ?, #, { or }")
+	ErrInvalidRedirectStatus            = errors.New("redirect status must be 301, 302, 307 or 308")
+	ErrRedirectLoop                     = errors.New("redirect would send requests back to itself")
+	ErrRedirectExists                   = errors.New("a redirect for this domain and path already exists")
+	ErrRedirectDomainTaken              = errors.New("domain is already used by another application")
+	ErrAccessPolicyNotFound             = errors.New("access policy not found")
+	ErrInvalidAccessIP                  = errors.New("allowed and denied IPs must be IP addresses or CIDR ranges")
+	ErrInvalidBasicAuthUser             = errors.New("basic auth users need a unique username without a colon and a password")
+	ErrInvalidBasicAuthRealm            = errors.New("basic auth realm cannot contain quotes or control characters")
+	ErrInvalidForwardAuthURL            = errors.New("forward auth url must be an http or https url with a host")
+	ErrInvalidForwardAuthHeader         = errors.New("forward auth headers must be valid header names")
+	ErrProxySettingsNotFound            = errors.New("proxy settings not found")
+	ErrInvalidProxyHeader               = errors.New("response headers must have valid names and values without braces, and cannot be both set and removed")
+	ErrInvalidProxyEncoding             = errors.New("encodings must be gzip or zstd")
+	ErrInvalidRequestBodyLimit          = errors.New("request body limit cannot be negative")
+	ErrInvalidUpstreamTimeout           = errors.New("upstream timeouts must be between 0 and 3600 seconds")
+	ErrInvalidRateLimit                 = errors.New("rate limit requests cannot be negative and its window must be between 1 and 86400 seconds")
+	ErrEmptyProxySettings               = errors.New("proxy settings must set headers, encodings, a body limit, timeouts or a rate limit")
+	ErrEmptyAccessPolicy                = errors.New("access policy must allow or deny IPs, or set basic auth or forward auth")
 )
 
 const (
@@ -384,6 +1355,7 @@ const (
 	LogFailedToCreateApplicationLogs             = "Failed to create application logs: %s"
 	LogFailedToUpdateApplicationRecord           = "Failed to update application record"
 	LogFailedToUpdateApplicationDeployment       = "Failed to update application deployment"
+	LogFailedToRecordEnvRevision                 = "Failed to record environment revision: "
 	LogFailedToParseRepositoryID                 = "Failed to parse repository ID: %s"
 	LogFailedToCloneRepository                   = "Failed to clone repository: %s"
 	LogFailedToCreateDeployment                  = "Failed to create deployment: %s"
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
+	"regexp"
 	"strings"
 
 	"errors"
@@ -50,6 +52,8 @@ func (v *Validator) ValidateRequest(req interface{}) error {
 		return validateAddApplicationToFamilyRequest(r)
 	case *types.CancelDeploymentRequest:
 		return validateCancelDeploymentRequest(*r)
+	case *types.PreviewComposeRequest:
+		return validateComposeOptions(r.ComposeFiles, r.ComposeProfiles, nil)
 	default:
 		return types.ErrInvalidRequestType
 	}
@@ -80,6 +84,21 @@ func validateDeploymentRequest(req *types.CreateDeploymentRequest) error {
 	if err := validateDomains(req.Domains); err != nil {
 		return err
 	}
+	if err := validateComposeOptions(req.ComposeFiles, req.ComposeProfiles, req.ComposeEnvFiles); err != nil {
+		return err
+	}
+	if req.ComposeDeployMode != "" && !shared_types.IsValidComposeDeployMode(string(req.ComposeDeployMode)) {
+		return types.ErrInvalidComposeDeployMode
+	}
+	if req.PrivateNetworkScope != "" && !shared_types.IsValidPrivateNetworkScope(string(req.PrivateNetworkScope)) {
+		return types.ErrInvalidPrivateNetworkScope
+	}
+	if req.SBOMFormat != "" && !shared_types.IsValidSBOMFormat(string(req.SBOMFormat)) {
+		return types.ErrInvalidSBOMFormat
+	}
+	if req.VulnThreshold != "" && !shared_types.IsValidSeverity(string(req.VulnThreshold)) {
+		return types.ErrInvalidVulnThreshold
+	}
 	if req.BasePath == "" {
 		req.BasePath = "/"
 	} else if req.BasePath[0] != '/' {
@@ -113,6 +132,21 @@ func validateUpdateDeploymentRequest(req *types.UpdateDeploymentRequest) error {
 	if req.Domains != nil && len(req.Domains) > 5 {
 		return errors.New("maximum 5 domains allowed per application")
 	}
+	if err := validateComposeOptions(req.ComposeFiles, req.ComposeProfiles, req.ComposeEnvFiles); err != nil {
+		return err
+	}
+	if req.ComposeDeployMode != "" && !shared_types.IsValidComposeDeployMode(string(req.ComposeDeployMode)) {
+		return types.ErrInvalidComposeDeployMode
+	}
+	if req.PrivateNetworkScope != "" && !shared_types.IsValidPrivateNetworkScope(string(req.PrivateNetworkScope)) {
+		return types.ErrInvalidPrivateNetworkScope
+	}
+	if req.SBOMFormat != "" && !shared_types.IsValidSBOMFormat(string(req.SBOMFormat)) {
+		return types.ErrInvalidSBOMFormat
+	}
+	if req.VulnThreshold != "" && !shared_types.IsValidSeverity(string(req.VulnThreshold)) {
+		return types.ErrInvalidVulnThreshold
+	}
 	return nil
 }
 
@@ -181,6 +215,21 @@ func validateCreateProjectRequest(req *types.CreateProjectRequest) error {
 	if req.DockerfilePath == "" {
 		req.DockerfilePath = "Dockerfile"
 	}
+	if err := validateComposeOptions(req.ComposeFiles, req.ComposeProfiles, req.ComposeEnvFiles); err != nil {
+		return err
+	}
+	if req.ComposeDeployMode != "" && !shared_types.IsValidComposeDeployMode(string(req.ComposeDeployMode)) {
+		return types.ErrInvalidComposeDeployMode
+	}
+	if req.PrivateNetworkScope != "" && !shared_types.IsValidPrivateNetworkScope(string(req.PrivateNetworkScope)) {
+		return types.ErrInvalidPrivateNetworkScope
+	}
+	if req.SBOMFormat != "" && !shared_types.IsValidSBOMFormat(string(req.SBOMFormat)) {
+		return types.ErrInvalidSBOMFormat
+	}
+	if req.VulnThreshold != "" && !shared_types.IsValidSeverity(string(req.VulnThreshold)) {
+		return types.ErrInvalidVulnThreshold
+	}
 	return nil
 }
 
@@ -206,6 +255,9 @@ func validateDuplicateProjectRequest(req types.DuplicateProjectRequest) error {
 	if !shared_types.IsValidEnvironment(string(req.Environment)) {
 		return types.ErrInvalidEnvironment
 	}
+	if err := validateComposeOptions(req.ComposeFiles, nil, nil); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -274,17 +326,22 @@ func isDomainValid(domain string) bool {
 	if len(labels) < 2 {
 		return false
 	}
-	for _, label := range labels {
+	for i, label := range labels {
+		// A leading "*" makes a wildcard subdomain such as
+		// *.preview.example.com; it cannot cover a whole TLD.
+		if i == 0 && label == "*" && len(labels) > 2 {
+			continue
+		}
 		if label == "" || len(label) > 63 {
 			return false
 		}
-		for i, c := range label {
+		for j, c := range label {
 			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
 			isHyphen := c == '-'
 			if !isAlnum && !isHyphen {
 				return false
 			}
-			if isHyphen && (i == 0 || i == len(label)-1) {
+			if isHyphen && (j == 0 || j == len(label)-1) {
 				return false
 			}
 		}
@@ -292,11 +349,34 @@ func isDomainValid(domain string) bool {
 	return true
 }
 
+// validateDomains checks domains, which may mount the application under a
+// path of the host such as example.com/api.
 func validateDomains(domains []string) error {
 	for _, d := range domains {
-		if !isDomainValid(d) {
+		host, _, ok := shared_types.SplitDomainRoute(d)
+		if !ok || !isDomainValid(host) {
 			return fmt.Errorf("invalid domain: %q", d)
 		}
 	}
 	return nil
 }
+
+// composeProfilePattern matches the profile names accepted by docker compose.
+var composeProfilePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// validateComposeOptions checks compose override files, env files and profiles.
+// Files must be relative to the application's base path and stay inside the repository.
+func validateComposeOptions(files, profiles, envFiles []string) error {
+	for _, f := range append(append([]string{}, files...), envFiles...) {
+		cleaned := path.Clean(strings.TrimSpace(f))
+		if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return types.ErrInvalidComposeFilePath
+		}
+	}
+	for _, p := range profiles {
+		if !composeProfilePattern.MatchString(p) {
+			return types.ErrInvalidComposeProfile
+		}
+	}
+	return nil
+}
@@ -59,11 +59,11 @@ func (dockerComposeModule) Execute(ctx context.Context, _ *ssh.SSH, step types.S
 }
 
 func composeUp(ctx context.Context, svc deploydocker.DockerRepository, file string) (string, func(), error) {
-	output, err := svc.ComposeUp(file, map[string]string{})
+	output, err := svc.ComposeUp(deploydocker.ComposeFile(file), map[string]string{})
 	if err != nil {
 		return "", nil, err
 	}
-	compensate := func() { _ = svc.ComposeDown(file) }
+	compensate := func() { _ = svc.ComposeDown(deploydocker.ComposeFile(file)) }
 	if output != "" {
 		return output, compensate, nil
 	}
@@ -71,15 +71,15 @@ func composeUp(ctx context.Context, svc deploydocker.DockerRepository, file stri
 }
 
 func composeDown(ctx context.Context, svc deploydocker.DockerRepository, file string) (string, func(), error) {
-	if err := svc.ComposeDown(file); err != nil {
+	if err := svc.ComposeDown(deploydocker.ComposeFile(file)); err != nil {
 		return "", nil, err
 	}
-	compensate := func() { _, _ = svc.ComposeUp(file, map[string]string{}) }
+	compensate := func() { _, _ = svc.ComposeUp(deploydocker.ComposeFile(file), map[string]string{}) }
 	return "compose down", compensate, nil
 }
 
 func composeBuild(ctx context.Context, svc deploydocker.DockerRepository, file string) (string, func(), error) {
-	if err := svc.ComposeBuild(file, map[string]string{}); err != nil {
+	if err := svc.ComposeBuild(deploydocker.ComposeFile(file), map[string]string{}); err != nil {
 		return "", nil, err
 	}
 	return "compose build", nil, nil
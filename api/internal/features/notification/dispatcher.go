@@ -215,6 +215,16 @@ func (d *Dispatcher) buildPlainTextBody(event shared_types.NotificationEvent) st
 	case shared_types.EventHealthCheckCritical:
 		return fmt.Sprintf("Health check critical for app %s endpoint %s (%s consecutive failures)",
 			getDataStr(event.Data, "app_id"), getDataStr(event.Data, "endpoint"), getDataStr(event.Data, "consecutive_fails"))
+	case shared_types.EventDatabaseBackupFailed:
+		return fmt.Sprintf("Backup of database %s failed: %s", getDataStr(event.Data, "database_name"), getDataStr(event.Data, "error_message"))
+	case shared_types.EventDatabaseRestoreFailed:
+		return fmt.Sprintf("Restore into database %s failed: %s", getDataStr(event.Data, "database_name"), getDataStr(event.Data, "error_message"))
+	case shared_types.EventVolumeBackupFailed:
+		return fmt.Sprintf("Backup of %s for %s failed: %s", getDataStr(event.Data, "source"), getDataStr(event.Data, "app_name"), getDataStr(event.Data, "error_message"))
+	case shared_types.EventVolumeRestoreFailed:
+		return fmt.Sprintf("Restore of %s for %s failed: %s", getDataStr(event.Data, "source"), getDataStr(event.Data, "app_name"), getDataStr(event.Data, "error_message"))
+	case shared_types.EventCertificateExpiring:
+		return fmt.Sprintf("Custom certificate for %s of %s expires on %s", getDataStr(event.Data, "domain"), getDataStr(event.Data, "app_name"), getDataStr(event.Data, "not_after"))
 	default:
 		return fmt.Sprintf("Notification: %s", event.Type)
 	}
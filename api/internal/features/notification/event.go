@@ -10,15 +10,20 @@ type preferenceMapping struct {
 }
 
 var eventPreferenceMap = map[shared_types.EventType]preferenceMapping{
-	shared_types.EventLoginAlert:          {Category: "security", Type: "login-alerts"},
-	shared_types.EventPasswordReset:       {Category: "security", Type: "password-changes"},
-	shared_types.EventVerificationEmail:   {Category: "security", Type: "security-alerts"},
-	shared_types.EventUserAddedToOrg:      {Category: "activity", Type: "team-updates"},
-	shared_types.EventUserRemovedFromOrg:  {Category: "activity", Type: "team-updates"},
-	shared_types.EventDeploySuccess:       {Category: "activity", Type: "team-updates"},
-	shared_types.EventDeployFailed:        {Category: "activity", Type: "team-updates"},
-	shared_types.EventBuildFailed:         {Category: "activity", Type: "team-updates"},
-	shared_types.EventHealthCheckCritical: {Category: "activity", Type: "team-updates"},
+	shared_types.EventLoginAlert:            {Category: "security", Type: "login-alerts"},
+	shared_types.EventPasswordReset:         {Category: "security", Type: "password-changes"},
+	shared_types.EventVerificationEmail:     {Category: "security", Type: "security-alerts"},
+	shared_types.EventUserAddedToOrg:        {Category: "activity", Type: "team-updates"},
+	shared_types.EventUserRemovedFromOrg:    {Category: "activity", Type: "team-updates"},
+	shared_types.EventDeploySuccess:         {Category: "activity", Type: "team-updates"},
+	shared_types.EventDeployFailed:          {Category: "activity", Type: "team-updates"},
+	shared_types.EventBuildFailed:           {Category: "activity", Type: "team-updates"},
+	shared_types.EventHealthCheckCritical:   {Category: "activity", Type: "team-updates"},
+	shared_types.EventDatabaseBackupFailed:  {Category: "activity", Type: "team-updates"},
+	shared_types.EventDatabaseRestoreFailed: {Category: "activity", Type: "team-updates"},
+	shared_types.EventVolumeBackupFailed:    {Category: "activity", Type: "team-updates"},
+	shared_types.EventVolumeRestoreFailed:   {Category: "activity", Type: "team-updates"},
+	shared_types.EventCertificateExpiring:   {Category: "activity", Type: "team-updates"},
 }
 
 // eventTemplate maps event types to the email template and subject to use.
@@ -64,6 +69,10 @@ func defaultChannelsForEvent(eventType shared_types.EventType) []string {
 		return []string{"slack", "discord", "agent"}
 	case shared_types.EventBuildFailed, shared_types.EventHealthCheckCritical:
 		return []string{"email", "slack", "discord", "agent"}
+	case shared_types.EventDatabaseBackupFailed, shared_types.EventDatabaseRestoreFailed,
+		shared_types.EventVolumeBackupFailed, shared_types.EventVolumeRestoreFailed,
+		shared_types.EventCertificateExpiring:
+		return []string{"slack", "discord"}
 	case shared_types.EventTrialExpired:
 		return []string{"system_email"}
 	default:
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/nixopus/nixopus/api/internal/features/audit/service"
 	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/nixopus/nixopus/api/internal/secrets"
 	"github.com/nixopus/nixopus/api/internal/storage"
 	"github.com/nixopus/nixopus/api/internal/types"
 )
@@ -58,6 +59,7 @@ func AuditMiddleware(next http.Handler, app *storage.App, l logger.Logger, resou
 
 				if len(bodyBytes) > 0 {
 					json.Unmarshal(bodyBytes, &requestBody)
+					redactSecrets(requestBody)
 				}
 			}
 		}
@@ -105,6 +107,28 @@ func AuditMiddleware(next http.Handler, app *storage.App, l logger.Logger, resou
 	})
 }
 
+// redactSecrets replaces the values of secret keys in a request body, at
+// any depth, with a placeholder. Keys ending in _id only reference secrets.
+func redactSecrets(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			name := strings.ToLower(key)
+			secret := !strings.HasSuffix(name, "_id") && !strings.HasSuffix(name, "_ids") &&
+				secrets.IsSecretKeyName(name)
+			if secret && field != nil {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			redactSecrets(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactSecrets(item)
+		}
+	}
+}
+
 // auditResponseWriter captures the status code
 type auditResponseWriter struct {
 	http.ResponseWriter
@@ -178,6 +202,8 @@ func mapResourceType(resourceType string) types.AuditResourceType {
 		return types.AuditResourceTerminal
 	case "integration", "integrations":
 		return types.AuditResourceIntegration
+	case "database", "databases":
+		return types.AuditResourceDatabase
 	default:
 		return types.AuditResourceOrganization // Default fallback
 	}
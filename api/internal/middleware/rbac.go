@@ -30,8 +30,22 @@ func InitRBACCache(c *cache.Cache) {
 // It extracts organization ID from header and validates permissions from the database.
 // Uses Redis cache to reduce database calls.
 func RBACMiddleware(next http.Handler, app *appStorage.App, resource string) http.Handler {
+	return permissionMiddleware(next, app, func(r *http.Request) string {
+		return buildRequiredPermission(resource, r.Method)
+	})
+}
+
+// RequirePermission validates a fixed permission whatever the HTTP method.
+// It guards read routes that return data only writers may see.
+func RequirePermission(next http.Handler, app *appStorage.App, permission string) http.Handler {
+	return permissionMiddleware(next, app, func(*http.Request) string {
+		return permission
+	})
+}
+
+func permissionMiddleware(next http.Handler, app *appStorage.App, permissionFor func(r *http.Request) string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requiredPermission := buildRequiredPermission(resource, r.Method)
+		requiredPermission := permissionFor(r)
 		log.Printf("DEBUG RBAC: %s %s -> required permission=%s", r.Method, r.URL.Path, requiredPermission)
 
 		organizationID := extractOrganizationID(w, r)
@@ -287,6 +301,7 @@ var rolePermissions = map[string][]string{
 		"execute:create", "execute:read", "execute:update", "execute:delete",
 		"machine:create", "machine:read", "machine:update", "machine:delete",
 		"mcp:create", "mcp:read", "mcp:update", "mcp:delete",
+		"database:create", "database:read", "database:update", "database:delete",
 	},
 	"admin": {
 		"user:create", "user:read", "user:update", "user:delete",
@@ -309,6 +324,7 @@ var rolePermissions = map[string][]string{
 		"execute:create", "execute:read", "execute:update", "execute:delete",
 		"machine:create", "machine:read", "machine:update", "machine:delete",
 		"mcp:create", "mcp:read", "mcp:update", "mcp:delete",
+		"database:create", "database:read", "database:update", "database:delete",
 	},
 	"member": {
 		"user:read",
@@ -330,6 +346,7 @@ var rolePermissions = map[string][]string{
 		"execute:create", "execute:read", "execute:update", "execute:delete",
 		"machine:create", "machine:read", "machine:update",
 		"mcp:read", "mcp:update",
+		"database:create", "database:read", "database:update", "database:delete",
 	},
 	"viewer": {
 		"user:read", "organization:read", "role:read", "permission:read",
@@ -337,7 +354,7 @@ var rolePermissions = map[string][]string{
 		"deploy:read", "container:read", "audit:read", "terminal:read",
 		"feature_flags:read", "dashboard:read", "extension:read",
 		"healthcheck:read", "server:read", "trail:read", "execute:read",
-		"machine:read", "mcp:read",
+		"machine:read", "mcp:read", "database:read",
 	},
 }
 
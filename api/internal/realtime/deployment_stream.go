@@ -0,0 +1,225 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	"github.com/nixopus/nixopus/api/internal/types"
+	"github.com/nixopus/nixopus/api/internal/utils"
+)
+
+const (
+	deploymentStreamBuffer    = 512
+	deploymentStreamHeartbeat = 15 * time.Second
+	// deploymentLogReplayLimit caps the logs replayed when a client connects
+	// to a deployment that is already running.
+	deploymentLogReplayLimit = 10000
+)
+
+// deploymentEvent is a log line or status change of a deployment, taken from
+// the application_changes notifications.
+type deploymentEvent struct {
+	Type      string `json:"-"`
+	ID        string `json:"id,omitempty"`
+	Log       string `json:"log,omitempty"`
+	Status    string `json:"status,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// deploymentStreamEnd is the last event of a stream. ExitCode is 0 when the
+// deployment succeeded so CI scripts can fail the job on anything else.
+type deploymentStreamEnd struct {
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// subscribeDeployment registers a listener for the events of a deployment.
+// The returned function removes it.
+func (s *SocketServer) subscribeDeployment(deploymentID string) (<-chan deploymentEvent, func()) {
+	ch := make(chan deploymentEvent, deploymentStreamBuffer)
+
+	s.deploymentStreamsMu.Lock()
+	if s.deploymentStreams[deploymentID] == nil {
+		s.deploymentStreams[deploymentID] = make(map[chan deploymentEvent]bool)
+	}
+	s.deploymentStreams[deploymentID][ch] = true
+	s.deploymentStreamsMu.Unlock()
+
+	return ch, func() {
+		s.deploymentStreamsMu.Lock()
+		delete(s.deploymentStreams[deploymentID], ch)
+		if len(s.deploymentStreams[deploymentID]) == 0 {
+			delete(s.deploymentStreams, deploymentID)
+		}
+		s.deploymentStreamsMu.Unlock()
+	}
+}
+
+// publishDeploymentEvent forwards application_logs and
+// application_deployment_status changes to the streams of their deployment.
+// Slow streams drop events rather than block the notification loop; a
+// dropped status change is picked up by the stream's next heartbeat.
+func (s *SocketServer) publishDeploymentEvent(table string, data map[string]interface{}) {
+	deploymentID, _ := data["application_deployment_id"].(string)
+	if deploymentID == "" {
+		return
+	}
+
+	var event deploymentEvent
+	switch table {
+	case "application_logs":
+		event.Type = "log"
+		event.ID, _ = data["id"].(string)
+		event.Log, _ = data["log"].(string)
+		event.CreatedAt, _ = data["created_at"].(string)
+	case "application_deployment_status":
+		event.Type = "status"
+		event.Status, _ = data["status"].(string)
+	default:
+		return
+	}
+
+	s.deploymentStreamsMu.Lock()
+	defer s.deploymentStreamsMu.Unlock()
+	for ch := range s.deploymentStreams[deploymentID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// HandleDeploymentStream streams the logs and status changes of a deployment
+// as Server-Sent Events until it reaches a terminal status. Logs written
+// before the client connected are replayed first. The stream ends with an
+// "end" event carrying the final status and an exit code.
+func (s *SocketServer) HandleDeploymentStream(w http.ResponseWriter, r *http.Request) {
+	user := utils.GetUser(w, r)
+	if user == nil {
+		return
+	}
+	organizationID := utils.GetOrganizationID(r)
+	if organizationID == uuid.Nil {
+		utils.SendErrorResponse(w, "organization not found", http.StatusUnauthorized)
+		return
+	}
+
+	deploymentID, err := uuid.Parse(r.PathValue("deployment_id"))
+	if err != nil {
+		utils.SendErrorResponse(w, "invalid deployment id", http.StatusBadRequest)
+		return
+	}
+
+	deployStorage := storage.DeployStorage{DB: s.db, Ctx: s.ctx}
+	deployment, err := deployStorage.GetApplicationDeploymentById(deploymentID.String())
+	if err != nil {
+		utils.SendErrorResponse(w, "deployment not found", http.StatusNotFound)
+		return
+	}
+	if _, err := deployStorage.GetApplicationById(deployment.ApplicationID.String(), organizationID); err != nil {
+		utils.SendErrorResponse(w, "deployment not found", http.StatusNotFound)
+		return
+	}
+
+	// Subscribe before reading the stored logs so nothing written in between is missed.
+	events, unsubscribe := s.subscribeDeployment(deploymentID.String())
+	defer unsubscribe()
+
+	logs, _, err := deployStorage.GetDeploymentLogs(deploymentID.String(), 1, deploymentLogReplayLimit, "", time.Time{}, time.Time{}, "")
+	if err != nil {
+		utils.SendErrorResponse(w, "failed to load deployment logs", http.StatusInternalServerError)
+		return
+	}
+	slices.Reverse(logs)
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	replayed := make(map[string]bool, len(logs))
+	for _, l := range logs {
+		replayed[l.ID.String()] = true
+		writeSSE(w, "log", deploymentEvent{ID: l.ID.String(), Log: l.Log, CreatedAt: l.CreatedAt.Format(time.RFC3339Nano)})
+	}
+
+	status := ""
+	if deployment.Status != nil {
+		status = string(deployment.Status.Status)
+		writeSSE(w, "status", deploymentEvent{Status: status})
+	}
+	if isTerminalDeploymentStatus(status) {
+		writeSSE(w, "end", deploymentStreamEndFor(status))
+		_ = rc.Flush()
+		return
+	}
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(deploymentStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		case <-heartbeat.C:
+			// Re-read the status in case its notification was dropped.
+			if latest, err := deployStorage.GetApplicationDeploymentById(deploymentID.String()); err == nil && latest.Status != nil {
+				if current := string(latest.Status.Status); isTerminalDeploymentStatus(current) {
+					writeSSE(w, "status", deploymentEvent{Status: current})
+					writeSSE(w, "end", deploymentStreamEndFor(current))
+					_ = rc.Flush()
+					return
+				}
+			}
+			fmt.Fprint(w, ": keepalive\n\n")
+		case event := <-events:
+			if event.Type == "log" && replayed[event.ID] {
+				continue
+			}
+			writeSSE(w, event.Type, event)
+			if event.Type == "status" && isTerminalDeploymentStatus(event.Status) {
+				writeSSE(w, "end", deploymentStreamEndFor(event.Status))
+				_ = rc.Flush()
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
+func isTerminalDeploymentStatus(status string) bool {
+	switch types.Status(status) {
+	case types.Deployed, types.Running, types.Failed, types.Cancelled, types.Stopped, types.PartialFailure:
+		return true
+	}
+	return false
+}
+
+func deploymentStreamEndFor(status string) deploymentStreamEnd {
+	end := deploymentStreamEnd{Status: status, ExitCode: 1}
+	if types.Status(status) == types.Deployed || types.Status(status) == types.Running {
+		end.ExitCode = 0
+	}
+	return end
+}
@@ -64,6 +64,9 @@ type SocketServer struct {
 
 	liveDevHandler   LiveDevNotificationHandler
 	liveDevHandlerMu sync.RWMutex
+
+	deploymentStreams   map[string]map[chan deploymentEvent]bool // deploymentID -> HTTP stream listeners
+	deploymentStreamsMu sync.Mutex
 }
 
 // NewSocketServer initializes and returns a new instance of SocketServer.
@@ -85,6 +88,7 @@ func NewSocketServer(deployController *deploy.DeployController, db *bun.DB, ctx
 		terminals:           make(map[*websocket.Conn]map[string]*terminal.Terminal),
 		dashboardMonitors:   make(map[*websocket.Conn]*dashboard.DashboardMonitor),
 		applicationMonitors: make(map[*websocket.Conn]*realtime.ApplicationMonitor),
+		deploymentStreams:   make(map[string]map[chan deploymentEvent]bool),
 	}
 	err := StartListeningAndNotify(&server.postgres_listener, ctx, server)
 	if err != nil {
@@ -157,6 +157,10 @@ func (s *SocketServer) handleNotifications(notificationChan <-chan *PostgresNoti
 				}
 			}
 
+			if parsedPayload.Data != nil {
+				s.publishDeploymentEvent(parsedPayload.Table, parsedPayload.Data)
+			}
+
 		case "live_dev_logs", "live_dev_status":
 			s.liveDevHandlerMu.RLock()
 			handler := s.liveDevHandler
@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	deploy "github.com/nixopus/nixopus/api/internal/features/deploy/controller"
+	"github.com/nixopus/nixopus/api/internal/middleware"
+)
+
+// RegisterDatabaseRoutes registers managed database routes
+func (router *Router) RegisterDatabaseRoutes(databaseGroup *fuego.Server, deployController *deploy.DeployController) {
+	fuego.Get(
+		databaseGroup,
+		"",
+		deployController.GetDatabases,
+		fuego.OptionSummary("List managed databases"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"",
+		deployController.CreateDatabase,
+		fuego.OptionSummary("Create managed database"),
+	)
+	fuego.Get(
+		databaseGroup,
+		"/{database_id}",
+		deployController.GetDatabase,
+		fuego.OptionSummary("Get managed database"),
+	)
+	fuego.Delete(
+		databaseGroup,
+		"/{database_id}",
+		deployController.DeleteDatabase,
+		fuego.OptionSummary("Delete managed database and its data"),
+		fuego.OptionQueryBool("delete_backups", "Also remove the database's backup dumps from S3"),
+	)
+	fuego.Put(
+		databaseGroup,
+		"/{database_id}/resources",
+		deployController.ResizeDatabase,
+		fuego.OptionSummary("Resize managed database"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"/{database_id}/rotate-password",
+		deployController.RotateDatabasePassword,
+		fuego.OptionSummary("Rotate managed database password"),
+	)
+	fuego.Get(
+		databaseGroup,
+		"/{database_id}/connection",
+		deployController.GetDatabaseConnection,
+		fuego.OptionSummary("Get managed database connection info"),
+		// The connection info carries the password, so viewers cannot read it.
+		fuego.OptionMiddleware(func(next http.Handler) http.Handler {
+			return middleware.RequirePermission(next, router.app, "database:update")
+		}),
+	)
+	fuego.Get(
+		databaseGroup,
+		"/{database_id}/applications",
+		deployController.GetDatabaseApplications,
+		fuego.OptionSummary("List applications using a managed database"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"/{database_id}/attach",
+		deployController.AttachDatabase,
+		fuego.OptionSummary("Attach managed database to application"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"/{database_id}/detach",
+		deployController.DetachDatabase,
+		fuego.OptionSummary("Detach managed database from application"),
+	)
+	fuego.Get(
+		databaseGroup,
+		"/{database_id}/backups",
+		deployController.GetDatabaseBackups,
+		fuego.OptionSummary("List managed database backups"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"/{database_id}/backups",
+		deployController.CreateDatabaseBackup,
+		fuego.OptionSummary("Back up managed database now"),
+	)
+	fuego.Put(
+		databaseGroup,
+		"/{database_id}/backups/schedule",
+		deployController.UpdateDatabaseBackupSchedule,
+		fuego.OptionSummary("Update managed database backup schedule and retention"),
+	)
+	fuego.Post(
+		databaseGroup,
+		"/{database_id}/backups/{backup_id}/restore",
+		deployController.RestoreDatabaseBackup,
+		fuego.OptionSummary("Restore managed database backup into the same or a new database"),
+	)
+	fuego.Get(
+		databaseGroup,
+		"/{database_id}/restores",
+		deployController.GetDatabaseRestores,
+		fuego.OptionSummary("List restores into managed database"),
+	)
+}
@@ -19,6 +19,139 @@ func (router *Router) RegisterDeployRoutes(deployGroup *fuego.Server, deployCont
 	)
 	deployApplicationGroup := fuego.Group(deployGroup, "/application")
 	router.RegisterDeployApplicationRoutes(deployApplicationGroup, deployController)
+	variableGroupsGroup := fuego.Group(deployGroup, "/variable-groups")
+	router.RegisterDeployVariableGroupRoutes(variableGroupsGroup, deployController)
+	secretManagersGroup := fuego.Group(deployGroup, "/secret-managers")
+	router.RegisterDeploySecretManagerRoutes(secretManagersGroup, deployController)
+	dnsProvidersGroup := fuego.Group(deployGroup, "/dns-providers")
+	router.RegisterDeployDNSProviderRoutes(dnsProvidersGroup, deployController)
+	proxySnapshotsGroup := fuego.Group(deployGroup, "/proxy-snapshots")
+	router.RegisterDeployProxySnapshotRoutes(proxySnapshotsGroup, deployController)
+}
+
+// RegisterDeployProxySnapshotRoutes registers organization proxy config snapshot routes
+func (router *Router) RegisterDeployProxySnapshotRoutes(proxySnapshotsGroup *fuego.Server, deployController *deploy.DeployController) {
+	fuego.Get(
+		proxySnapshotsGroup,
+		"",
+		deployController.GetCaddySnapshots,
+		fuego.OptionSummary("List proxy config snapshots"),
+	)
+	fuego.Get(
+		proxySnapshotsGroup,
+		"/diff",
+		deployController.DiffCaddySnapshots,
+		fuego.OptionSummary("Diff proxy config snapshots"),
+		fuego.OptionQuery("from", "Snapshot ID to compare from", fuego.ParamRequired()),
+		fuego.OptionQuery("to", "Snapshot ID to compare to, defaults to the latest snapshot of the same server"),
+	)
+	fuego.Post(
+		proxySnapshotsGroup,
+		"/restore",
+		deployController.RestoreCaddySnapshot,
+		fuego.OptionSummary("Restore proxy config snapshot"),
+	)
+}
+
+// RegisterDeployDNSProviderRoutes registers organization DNS provider routes
+func (router *Router) RegisterDeployDNSProviderRoutes(dnsProvidersGroup *fuego.Server, deployController *deploy.DeployController) {
+	fuego.Get(
+		dnsProvidersGroup,
+		"",
+		deployController.GetDNSProviders,
+		fuego.OptionSummary("List DNS providers"),
+	)
+	fuego.Post(
+		dnsProvidersGroup,
+		"",
+		deployController.CreateDNSProvider,
+		fuego.OptionSummary("Create DNS provider"),
+	)
+	fuego.Put(
+		dnsProvidersGroup,
+		"",
+		deployController.UpdateDNSProvider,
+		fuego.OptionSummary("Update DNS provider"),
+	)
+	fuego.Delete(
+		dnsProvidersGroup,
+		"",
+		deployController.DeleteDNSProvider,
+		fuego.OptionSummary("Delete DNS provider"),
+		fuego.OptionQuery("id", "DNS provider ID", fuego.ParamRequired()),
+	)
+}
+
+// RegisterDeploySecretManagerRoutes registers organization secret manager routes
+func (router *Router) RegisterDeploySecretManagerRoutes(secretManagersGroup *fuego.Server, deployController *deploy.DeployController) {
+	fuego.Get(
+		secretManagersGroup,
+		"",
+		deployController.GetSecretManagers,
+		fuego.OptionSummary("List secret managers"),
+	)
+	fuego.Put(
+		secretManagersGroup,
+		"",
+		deployController.UpsertSecretManager,
+		fuego.OptionSummary("Save secret manager"),
+	)
+	fuego.Delete(
+		secretManagersGroup,
+		"",
+		deployController.DeleteSecretManager,
+		fuego.OptionSummary("Delete secret manager"),
+		fuego.OptionQuery("type", "Secret manager type (infisical or vault)", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		secretManagersGroup,
+		"/test",
+		deployController.TestSecretManager,
+		fuego.OptionSummary("Test secret manager connection"),
+	)
+}
+
+// RegisterDeployVariableGroupRoutes registers shared variable group routes
+func (router *Router) RegisterDeployVariableGroupRoutes(variableGroupsGroup *fuego.Server, deployController *deploy.DeployController) {
+	fuego.Get(
+		variableGroupsGroup,
+		"",
+		deployController.GetVariableGroups,
+		fuego.OptionSummary("List variable groups"),
+		fuego.OptionQuery("family_id", "Only return groups usable within this project family"),
+	)
+	fuego.Post(
+		variableGroupsGroup,
+		"",
+		deployController.CreateVariableGroup,
+		fuego.OptionSummary("Create variable group"),
+	)
+	fuego.Put(
+		variableGroupsGroup,
+		"",
+		deployController.UpdateVariableGroup,
+		fuego.OptionSummary("Update variable group"),
+	)
+	fuego.Delete(
+		variableGroupsGroup,
+		"",
+		deployController.DeleteVariableGroup,
+		fuego.OptionSummary("Delete variable group"),
+		fuego.OptionQuery("id", "Variable group ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		variableGroupsGroup,
+		"/applications",
+		deployController.GetVariableGroupApplications,
+		fuego.OptionSummary("List applications using a variable group"),
+		fuego.OptionQuery("id", "Variable group ID", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		variableGroupsGroup,
+		"/redeploy",
+		deployController.RedeployVariableGroup,
+		fuego.OptionSummary("Redeploy applications using a variable group"),
+	)
 }
 
 // RegisterDeployApplicationRoutes registers application-specific deployment routes
@@ -140,6 +273,16 @@ func (router *Router) RegisterDeployApplicationRoutes(applicationGroup *fuego.Se
 		fuego.OptionQuery("end_time", "End time (RFC3339)"),
 		fuego.OptionQuery("search_term", "Search term"),
 	)
+	if router.socketServer != nil {
+		fuego.GetStd(
+			applicationGroup,
+			"/deployments/{deployment_id}/stream",
+			router.socketServer.HandleDeploymentStream,
+			fuego.OptionSummary("Stream deployment logs and status"),
+			fuego.OptionDescription("Server-Sent Events stream of a deployment's logs and status changes. "+
+				"Ends with an \"end\" event whose exit_code is 0 when the deployment succeeded."),
+		)
+	}
 	fuego.Get(
 		applicationGroup,
 		"/deployments",
@@ -202,4 +345,344 @@ func (router *Router) RegisterDeployApplicationRoutes(applicationGroup *fuego.Se
 		deployController.SetApplicationServers,
 		fuego.OptionSummary("Set application servers"),
 	)
+	fuego.Get(
+		applicationGroup,
+		"/env/revisions",
+		deployController.GetEnvRevisions,
+		fuego.OptionSummary("List environment variable revisions"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/env/revisions/diff",
+		deployController.DiffEnvRevisions,
+		fuego.OptionSummary("Diff environment variable revisions"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQuery("from", "Revision ID to compare from", fuego.ParamRequired()),
+		fuego.OptionQuery("to", "Revision ID to compare to, defaults to the latest revision"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/env/revisions/restore",
+		deployController.RestoreEnvRevision,
+		fuego.OptionSummary("Restore environment variable revision"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/variable-groups",
+		deployController.GetApplicationVariableGroups,
+		fuego.OptionSummary("Get application variable groups"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/variable-groups",
+		deployController.SetApplicationVariableGroups,
+		fuego.OptionSummary("Set application variable groups"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/compose-services/restart",
+		deployController.RestartComposeService,
+		fuego.OptionSummary("Restart compose service"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/compose-services/stop",
+		deployController.StopComposeService,
+		fuego.OptionSummary("Stop compose service"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/compose-services/start",
+		deployController.StartComposeService,
+		fuego.OptionSummary("Start compose service"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/compose-services/scale",
+		deployController.ScaleComposeService,
+		fuego.OptionSummary("Scale compose service"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/compose-services/logs",
+		deployController.GetComposeServiceLogs,
+		fuego.OptionSummary("Get compose service logs"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQuery("service", "Compose service name", fuego.ParamRequired()),
+		fuego.OptionQueryInt("tail", "Number of lines per container, defaults to 200"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/ports",
+		deployController.GetApplicationPorts,
+		fuego.OptionSummary("List application TCP/UDP ports"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/ports",
+		deployController.AddApplicationPort,
+		fuego.OptionSummary("Add application TCP/UDP port"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/ports",
+		deployController.DeleteApplicationPort,
+		fuego.OptionSummary("Remove application TCP/UDP port"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/build-stats",
+		deployController.GetApplicationBuildStats,
+		fuego.OptionSummary("Get application build analytics"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQueryInt("limit", "Number of recent deployments to include, defaults to 50"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/deployments/{deployment_id}/sbom",
+		deployController.GetDeploymentSBOM,
+		fuego.OptionSummary("Get deployment SBOM"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/packages",
+		deployController.FindPackageDeployments,
+		fuego.OptionSummary("Find deployments containing a package"),
+		fuego.OptionQuery("name", "Package name", fuego.ParamRequired()),
+		fuego.OptionQuery("version", "Package version"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/migrations",
+		deployController.MigrateApplication,
+		fuego.OptionSummary("Migrate application to another server"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/migrations",
+		deployController.GetApplicationMigrations,
+		fuego.OptionSummary("List application migrations"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/migrations/{migration_id}",
+		deployController.GetApplicationMigration,
+		fuego.OptionSummary("Get application migration"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/migrations/confirm",
+		deployController.ConfirmApplicationMigration,
+		fuego.OptionSummary("Confirm application migration and clean up the source server"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/migrations/rollback",
+		deployController.RollbackApplicationMigration,
+		fuego.OptionSummary("Roll back application migration"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/volume-backups/policies",
+		deployController.GetVolumeBackupPolicies,
+		fuego.OptionSummary("List volume backup policies"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/volume-backups/policies",
+		deployController.CreateVolumeBackupPolicy,
+		fuego.OptionSummary("Create volume backup policy"),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/volume-backups/policies/{policy_id}",
+		deployController.UpdateVolumeBackupPolicy,
+		fuego.OptionSummary("Update volume backup schedule and retention"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/volume-backups/policies/{policy_id}",
+		deployController.DeleteVolumeBackupPolicy,
+		fuego.OptionSummary("Delete volume backup policy and its backups"),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/volume-backups/policies/{policy_id}/run",
+		deployController.RunVolumeBackup,
+		fuego.OptionSummary("Back up a volume now"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/volume-backups",
+		deployController.GetVolumeBackups,
+		fuego.OptionSummary("List volume backups"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/volume-backups/{backup_id}/restore",
+		deployController.RestoreVolumeBackup,
+		fuego.OptionSummary("Restore volume backup"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/volume-backups/restores",
+		deployController.GetVolumeRestores,
+		fuego.OptionSummary("List volume restores"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/scale-to-zero",
+		deployController.GetScaleToZero,
+		fuego.OptionSummary("Get scale-to-zero settings"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/scale-to-zero",
+		deployController.UpdateScaleToZero,
+		fuego.OptionSummary("Update scale-to-zero idle timeout"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/maintenance",
+		deployController.GetMaintenance,
+		fuego.OptionSummary("List maintenance settings"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/maintenance",
+		deployController.UpdateMaintenance,
+		fuego.OptionSummary("Enable or disable maintenance mode"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/maintenance",
+		deployController.DeleteMaintenance,
+		fuego.OptionSummary("Remove maintenance setting"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQuery("domain", "Domain, empty for the whole application"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/certificates",
+		deployController.GetCertificates,
+		fuego.OptionSummary("List custom certificates"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/certificates",
+		deployController.UploadCertificate,
+		fuego.OptionSummary("Upload custom certificate for a domain"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/certificates",
+		deployController.DeleteCertificate,
+		fuego.OptionSummary("Remove custom certificate"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQuery("domain", "Domain", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/redirects",
+		deployController.GetRedirects,
+		fuego.OptionSummary("List redirect rules"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Post(
+		applicationGroup,
+		"/redirects",
+		deployController.CreateRedirect,
+		fuego.OptionSummary("Create redirect rule"),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/redirects",
+		deployController.UpdateRedirect,
+		fuego.OptionSummary("Update redirect rule"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/redirects",
+		deployController.DeleteRedirect,
+		fuego.OptionSummary("Delete redirect rule"),
+		fuego.OptionQuery("id", "Redirect ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/access",
+		deployController.GetAccessPolicies,
+		fuego.OptionSummary("List access policies"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/access",
+		deployController.UpdateAccessPolicy,
+		fuego.OptionSummary("Set access policy of an application or domain"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/access",
+		deployController.DeleteAccessPolicy,
+		fuego.OptionSummary("Remove access policy"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+		fuego.OptionQuery("domain", "Domain, empty for the whole application"),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/proxy-settings",
+		deployController.GetProxySettings,
+		fuego.OptionSummary("Get proxy settings"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/proxy-settings",
+		deployController.UpdateProxySettings,
+		fuego.OptionSummary("Update headers, compression, limits and timeouts of the proxy"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/proxy-settings",
+		deployController.DeleteProxySettings,
+		fuego.OptionSummary("Remove proxy settings"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/autoscaling",
+		deployController.GetAutoscalingPolicy,
+		fuego.OptionSummary("Get autoscaling policy"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Put(
+		applicationGroup,
+		"/autoscaling",
+		deployController.UpdateAutoscalingPolicy,
+		fuego.OptionSummary("Create or update autoscaling policy"),
+	)
+	fuego.Delete(
+		applicationGroup,
+		"/autoscaling",
+		deployController.DeleteAutoscalingPolicy,
+		fuego.OptionSummary("Delete autoscaling policy"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
+	fuego.Get(
+		applicationGroup,
+		"/autoscaling/events",
+		deployController.GetScalingEvents,
+		fuego.OptionSummary("List autoscaling decisions"),
+		fuego.OptionQuery("id", "Application ID", fuego.ParamRequired()),
+	)
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -201,6 +202,9 @@ func (router *Router) SetupRoutes() {
 	if router.schedulers != nil && router.schedulers.TrialExpiry != nil {
 		router.schedulers.TrialExpiry.SetNotifier(dispatcher)
 	}
+	if router.schedulers != nil && router.schedulers.CertificateExpiry != nil {
+		router.schedulers.CertificateExpiry.SetNotifier(dispatcher)
+	}
 
 	PORT := config.AppConfig.Server.Port
 	server := router.createServer(PORT)
@@ -215,6 +219,18 @@ func (router *Router) SetupRoutes() {
 	router.setupAuthentication(server)
 	router.registerProtectedRoutes(server, apiV1, dispatcher, deployController)
 
+	if wakePort := config.AppConfig.Proxy.WakePort; wakePort != "" {
+		// Only Caddy should reach the wake listener, so it is not bound to
+		// every interface.
+		wakeAddr := net.JoinHostPort(config.AppConfig.Proxy.WakeHost, wakePort)
+		go func() {
+			log.Printf("Wake listener starting on %s", wakeAddr)
+			if err := http.ListenAndServe(wakeAddr, deployController.WakeHandler()); err != nil {
+				log.Printf("Wake listener stopped: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("Server starting on port %s", PORT)
 	log.Printf("Swagger UI available at: http://localhost:%s/swagger/", PORT)
 	_ = os.Remove("doc/openapi.json")
@@ -316,6 +332,15 @@ func (router *Router) registerProtectedRoutes(server *fuego.Server, apiV1 api.Ve
 	})
 	router.RegisterDeployRoutes(deployGroup, deployController)
 
+	databaseGroup := fuego.Group(server, apiV1.Path+"/databases")
+	router.applyMiddleware(databaseGroup, MiddlewareConfig{
+		RBAC:         true,
+		FeatureFlag:  "deploy",
+		Audit:        true,
+		ResourceName: "database",
+	})
+	router.RegisterDatabaseRoutes(databaseGroup, deployController)
+
 	auditController := audit.NewAuditController(router.app.Store.DB, router.app.Ctx, router.logger)
 	auditGroup := fuego.Group(server, apiV1.Path+"/audit")
 	router.applyMiddleware(auditGroup, MiddlewareConfig{RBAC: true, FeatureFlag: "audit", Audit: true, ResourceName: "audit"})
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	deploy_storage "github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	shared_types "github.com/nixopus/nixopus/api/internal/types"
+	"github.com/robfig/cron/v3"
+	"github.com/uptrace/bun"
+)
+
+const certificateExpirySchedule = "0 * * * *"
+
+// CertificateExpiryScheduler warns once about every custom certificate that
+// is within its expiry warning period.
+type CertificateExpiryScheduler struct {
+	cron       *cron.Cron
+	storage    *deploy_storage.DeployStorage
+	logger     logger.Logger
+	notifierMu sync.RWMutex
+	notifier   shared_types.Notifier
+}
+
+func NewCertificateExpiryScheduler(db *bun.DB, ctx context.Context, l logger.Logger) *CertificateExpiryScheduler {
+	return &CertificateExpiryScheduler{
+		cron:    cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger))),
+		storage: &deploy_storage.DeployStorage{DB: db, Ctx: ctx},
+		logger:  l,
+	}
+}
+
+func (c *CertificateExpiryScheduler) SetNotifier(n shared_types.Notifier) {
+	c.notifierMu.Lock()
+	defer c.notifierMu.Unlock()
+	c.notifier = n
+}
+
+func (c *CertificateExpiryScheduler) getNotifier() shared_types.Notifier {
+	c.notifierMu.RLock()
+	defer c.notifierMu.RUnlock()
+	return c.notifier
+}
+
+func (c *CertificateExpiryScheduler) Start() {
+	_, err := c.cron.AddFunc(certificateExpirySchedule, c.run)
+	if err != nil {
+		c.logger.Log(logger.Error, fmt.Sprintf("certificate expiry scheduler: failed to register cron: %v", err), "")
+		return
+	}
+	c.cron.Start()
+	c.logger.Log(logger.Info, fmt.Sprintf("certificate expiry scheduler started with schedule: %s", certificateExpirySchedule), "")
+}
+
+func (c *CertificateExpiryScheduler) Stop() {
+	c.cron.Stop()
+}
+
+func (c *CertificateExpiryScheduler) run() {
+	now := time.Now().UTC()
+	certificates, err := c.storage.GetExpiringCertificates(now)
+	if err != nil {
+		c.logger.Log(logger.Error, fmt.Sprintf("certificate expiry scheduler: failed to load certificates: %v", err), "")
+		return
+	}
+
+	for _, certificate := range certificates {
+		// Every API instance runs the scheduler; the one that marks the
+		// certificate sends the warning.
+		claimed, err := c.storage.MarkCertificateExpiryWarned(certificate.ID, certificate.Fingerprint, now)
+		if err != nil {
+			c.logger.Log(logger.Error, fmt.Sprintf("certificate expiry scheduler: failed to mark certificate: %v", err), certificate.ID.String())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		daysLeft := int(math.Floor(certificate.NotAfter.Sub(now).Hours() / 24))
+		c.logger.Log(logger.Warning, fmt.Sprintf("custom certificate for %s expires in %d days", certificate.Domain, daysLeft), certificate.ApplicationID.String())
+
+		notifier := c.getNotifier()
+		if notifier == nil {
+			continue
+		}
+		appName := ""
+		if app, err := c.storage.GetApplicationById(certificate.ApplicationID.String(), certificate.OrganizationID); err == nil {
+			appName = app.Name
+		}
+		if err := notifier.Emit(shared_types.NotificationEvent{
+			Type:           shared_types.EventCertificateExpiring,
+			UserID:         certificate.CreatedBy.String(),
+			OrganizationID: certificate.OrganizationID.String(),
+			Data: map[string]interface{}{
+				"app_name":  appName,
+				"app_id":    certificate.ApplicationID.String(),
+				"domain":    certificate.Domain,
+				"not_after": certificate.NotAfter.Format(time.RFC3339),
+				"days_left": fmt.Sprintf("%d", daysLeft),
+			},
+		}); err != nil {
+			c.logger.Log(logger.Error, fmt.Sprintf("certificate expiry scheduler: failed to emit warning: %v", err), certificate.ID.String())
+		}
+	}
+}
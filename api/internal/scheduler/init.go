@@ -20,6 +20,8 @@ type Schedulers struct {
 	TrialExpiry         *TrialExpiryScheduler
 	StaleMachineCleanup *StaleMachineCleanupScheduler
 	MachineHealthCheck  *MachineHealthCheckScheduler
+	VolumeBackup        *VolumeBackupScheduler
+	CertificateExpiry   *CertificateExpiryScheduler
 }
 
 // InitSchedulers creates and configures all schedulers
@@ -44,6 +46,8 @@ func InitSchedulers(store *shared_storage.Store, ctx context.Context) *Scheduler
 	trialExpiryScheduler := NewTrialExpiryScheduler(store.DB, ctx, l, config.AppConfig.Trail.TrialPeriodDays)
 	staleMachineCleanup := NewStaleMachineCleanupScheduler(store.DB, ctx, l)
 	machineHealthCheck := NewMachineHealthCheckScheduler(store.DB, ctx, l)
+	volumeBackup := NewVolumeBackupScheduler(store.DB, ctx, l)
+	certificateExpiry := NewCertificateExpiryScheduler(store.DB, ctx, l)
 
 	return &Schedulers{
 		Main:                sched,
@@ -53,5 +57,7 @@ func InitSchedulers(store *shared_storage.Store, ctx context.Context) *Scheduler
 		TrialExpiry:         trialExpiryScheduler,
 		StaleMachineCleanup: staleMachineCleanup,
 		MachineHealthCheck:  machineHealthCheck,
+		VolumeBackup:        volumeBackup,
+		CertificateExpiry:   certificateExpiry,
 	}
 }
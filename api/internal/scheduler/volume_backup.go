@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	deploy_storage "github.com/nixopus/nixopus/api/internal/features/deploy/storage"
+	"github.com/nixopus/nixopus/api/internal/features/deploy/tasks"
+	"github.com/nixopus/nixopus/api/internal/features/logger"
+	"github.com/robfig/cron/v3"
+	"github.com/uptrace/bun"
+)
+
+const volumeBackupScheduleCheck = "* * * * *"
+
+// VolumeBackupScheduler queues the backups of volume backup policies whose
+// cron schedule fires in the current minute.
+type VolumeBackupScheduler struct {
+	cron    *cron.Cron
+	storage *deploy_storage.DeployStorage
+	logger  logger.Logger
+}
+
+func NewVolumeBackupScheduler(db *bun.DB, ctx context.Context, l logger.Logger) *VolumeBackupScheduler {
+	return &VolumeBackupScheduler{
+		cron:    cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger))),
+		storage: &deploy_storage.DeployStorage{DB: db, Ctx: ctx},
+		logger:  l,
+	}
+}
+
+func (v *VolumeBackupScheduler) Start() {
+	_, err := v.cron.AddFunc(volumeBackupScheduleCheck, v.run)
+	if err != nil {
+		v.logger.Log(logger.Error, fmt.Sprintf("volume backup scheduler: failed to register cron: %v", err), "")
+		return
+	}
+	v.cron.Start()
+	v.logger.Log(logger.Info, fmt.Sprintf("volume backup scheduler started with schedule: %s", volumeBackupScheduleCheck), "")
+}
+
+func (v *VolumeBackupScheduler) Stop() {
+	v.cron.Stop()
+}
+
+func (v *VolumeBackupScheduler) run() {
+	minute := time.Now().UTC().Truncate(time.Minute)
+
+	policies, err := v.storage.GetScheduledVolumeBackupPolicies()
+	if err != nil {
+		v.logger.Log(logger.Error, fmt.Sprintf("volume backup scheduler: failed to load policies: %v", err), "")
+		return
+	}
+
+	enqueued := 0
+	for i := range policies {
+		policy := &policies[i]
+		schedule, err := tasks.ParseBackupSchedule(policy.Schedule)
+		if err != nil {
+			v.logger.Log(logger.Warning, "volume backup scheduler: skipping policy with invalid schedule", policy.ID.String())
+			continue
+		}
+		if !tasks.BackupDue(schedule, minute) {
+			continue
+		}
+		if err := tasks.EnqueueScheduledVolumeBackup(policy, minute); err != nil {
+			v.logger.Log(logger.Error, fmt.Sprintf("volume backup scheduler: failed to enqueue backup: %v", err), policy.ID.String())
+			continue
+		}
+		enqueued++
+	}
+	if enqueued > 0 {
+		v.logger.Log(logger.Info, fmt.Sprintf("volume backup scheduler: enqueued %d backups", enqueued), "")
+	}
+}
@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix marks values produced by Encrypt so that legacy plaintext
+// rows can still be read after encryption is enabled.
+const encryptedPrefix = "enc:v1:"
+
+var ErrMasterKeyNotConfigured = errors.New("SECRETS_MASTER_KEY is not set, cannot decrypt stored secret")
+
+var (
+	masterKeyMu sync.RWMutex
+	masterKey   []byte
+)
+
+// InitMasterKey loads the at-rest encryption key from SECRETS_MASTER_KEY.
+// A 64 character hex string or base64 encoded 32 byte key is used as-is,
+// any other value is stretched with SHA-256. When unset, values are stored
+// in plaintext and a warning is logged.
+func InitMasterKey() {
+	raw := os.Getenv("SECRETS_MASTER_KEY")
+	if raw == "" {
+		log.Println("WARNING: SECRETS_MASTER_KEY is not set, environment variables will be stored unencrypted.")
+		SetMasterKey(nil)
+		return
+	}
+	SetMasterKey(deriveMasterKey(raw))
+}
+
+// SetMasterKey replaces the active encryption key. A nil key disables encryption.
+func SetMasterKey(key []byte) {
+	masterKeyMu.Lock()
+	defer masterKeyMu.Unlock()
+	masterKey = key
+}
+
+// MasterKeyConfigured reports whether at-rest encryption is enabled.
+func MasterKeyConfigured() bool {
+	masterKeyMu.RLock()
+	defer masterKeyMu.RUnlock()
+	return len(masterKey) > 0
+}
+
+func deriveMasterKey(raw string) []byte {
+	if len(raw) == 64 {
+		if key, err := hex.DecodeString(raw); err == nil {
+			return key
+		}
+	}
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == 32 {
+		return key
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:]
+}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt seals plaintext with AES-256-GCM using the master key.
+// Empty values and calls without a configured key return the input unchanged.
+func Encrypt(plaintext string) (string, error) {
+	masterKeyMu.RLock()
+	key := masterKey
+	masterKeyMu.RUnlock()
+
+	if len(key) == 0 || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the encryption
+// prefix are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	masterKeyMu.RLock()
+	key := masterKey
+	masterKeyMu.RUnlock()
+	if len(key) == 0 {
+		return "", ErrMasterKeyNotConfigured
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	SetMasterKey(deriveMasterKey("test-master-key"))
+	defer SetMasterKey(nil)
+
+	plaintext := `{"DATABASE_URL":"postgres://user:pass@db:5432/app"}`
+	sealed, err := Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatalf("Encrypt() = %q, want encrypted prefix", sealed)
+	}
+	if strings.Contains(sealed, "postgres://") {
+		t.Fatalf("Encrypt() leaked plaintext: %q", sealed)
+	}
+
+	opened, err := Decrypt(sealed)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if opened != plaintext {
+		t.Fatalf("Decrypt() = %q, want %q", opened, plaintext)
+	}
+}
+
+func TestDecryptPlaintextPassthrough(t *testing.T) {
+	SetMasterKey(nil)
+
+	got, err := Decrypt(`{"A":"1"}`)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if got != `{"A":"1"}` {
+		t.Fatalf("Decrypt() = %q", got)
+	}
+}
+
+func TestDecryptWithoutKey(t *testing.T) {
+	SetMasterKey(deriveMasterKey("k"))
+	sealed, err := Encrypt("value")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	SetMasterKey(nil)
+
+	if _, err := Decrypt(sealed); err != ErrMasterKeyNotConfigured {
+		t.Fatalf("Decrypt() error = %v, want ErrMasterKeyNotConfigured", err)
+	}
+}
+
+func TestEncryptWithoutKeyIsNoop(t *testing.T) {
+	SetMasterKey(nil)
+
+	got, err := Encrypt("value")
+	if err != nil || got != "value" {
+		t.Fatalf("Encrypt() = %q, %v; want passthrough", got, err)
+	}
+}
@@ -0,0 +1,20 @@
+package secrets
+
+import "strings"
+
+// KeyNameParts mark keys, such as JSON fields, whose values are secrets:
+// passwords, tokens, credentials and private keys. Audit logs and proxy
+// config diffs redact the values of these keys.
+var KeyNameParts = []string{"password", "secret", "token", "credential", "private_key", "api_key"}
+
+// IsSecretKeyName reports whether name contains one of KeyNameParts,
+// ignoring case.
+func IsSecretKeyName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range KeyNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
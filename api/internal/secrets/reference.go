@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReferenceScheme prefixes environment variable values that point at a secret
+// stored in an external secret manager, e.g. secret://vault/app/prod/DATABASE_URL.
+const ReferenceScheme = "secret://"
+
+// Reference identifies a single secret in an external secret manager.
+type Reference struct {
+	Provider SecretManagerType
+	Path     string
+	Key      string
+}
+
+// String returns the reference in its secret:// form. It never contains a secret value.
+func (r Reference) String() string {
+	path := strings.Trim(r.Path, "/")
+	if path == "" {
+		return fmt.Sprintf("%s%s/%s", ReferenceScheme, r.Provider, r.Key)
+	}
+	return fmt.Sprintf("%s%s/%s/%s", ReferenceScheme, r.Provider, path, r.Key)
+}
+
+// IsReference reports whether value uses the secret:// scheme.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferenceScheme)
+}
+
+// ParseReference parses secret://<provider>/<path>/<KEY>. Infisical references
+// may leave the path empty to look the key up at the root of the project.
+// Vault references need a path, as KV v2 keeps no secrets at the mount root.
+func ParseReference(value string) (Reference, error) {
+	if !IsReference(value) {
+		return Reference{}, fmt.Errorf("not a secret reference")
+	}
+
+	rest := strings.TrimPrefix(value, ReferenceScheme)
+	provider, remainder, found := strings.Cut(rest, "/")
+	if !found || provider == "" {
+		return Reference{}, fmt.Errorf("secret reference %q must be secret://<provider>/<path>/<key>", value)
+	}
+
+	remainder = strings.Trim(remainder, "/")
+	var path, key string
+	if idx := strings.LastIndex(remainder, "/"); idx >= 0 {
+		path, key = remainder[:idx], remainder[idx+1:]
+	} else {
+		key = remainder
+	}
+	if key == "" {
+		return Reference{}, fmt.Errorf("secret reference %q is missing a key", value)
+	}
+
+	ref := Reference{
+		Provider: SecretManagerType(strings.ToLower(provider)),
+		Path:     "/" + path,
+		Key:      key,
+	}
+	switch ref.Provider {
+	case SecretManagerInfisical:
+	case SecretManagerVault:
+		if path == "" {
+			return Reference{}, fmt.Errorf("secret reference %q must include the vault secret path", value)
+		}
+	default:
+		return Reference{}, fmt.Errorf("unsupported secret provider %q in reference", provider)
+	}
+	return ref, nil
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Reference
+		wantErr bool
+	}{
+		{"nested path", "secret://infisical/api/prod/DATABASE_URL", Reference{SecretManagerInfisical, "/api/prod", "DATABASE_URL"}, false},
+		{"root path", "secret://infisical/SENTRY_DSN", Reference{SecretManagerInfisical, "/", "SENTRY_DSN"}, false},
+		{"vault root path", "secret://vault/SENTRY_DSN", Reference{}, true},
+		{"provider case", "secret://Vault/app/KEY", Reference{SecretManagerVault, "/app", "KEY"}, false},
+		{"missing key", "secret://vault/", Reference{}, true},
+		{"missing provider", "secret:///app/KEY", Reference{}, true},
+		{"unknown provider", "secret://aws/app/KEY", Reference{}, true},
+		{"plain value", "postgres://db", Reference{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReference(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("ParseReference(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultManagerGetSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "dev-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/app/prod" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"data":{"DATABASE_URL":"postgres://db","WORKERS":4},"metadata":{"version":1}}}`))
+	}))
+	defer server.Close()
+
+	manager := NewVaultManager(&SecretManagerConfig{
+		VaultAddr:  server.URL,
+		VaultToken: "dev-token",
+		VaultMount: "secret",
+		SecretPath: "/app/prod",
+	})
+
+	got, err := manager.GetSecrets(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetSecrets() error = %v", err)
+	}
+	if got["DATABASE_URL"] != "postgres://db" || got["WORKERS"] != "4" {
+		t.Fatalf("GetSecrets() = %v", got)
+	}
+
+	if _, err := manager.GetSecret(context.Background(), "MISSING"); err == nil {
+		t.Fatal("GetSecret() expected error for missing key")
+	}
+
+	denied := NewVaultManager(&SecretManagerConfig{
+		VaultAddr:  server.URL,
+		VaultToken: "wrong-token",
+		SecretPath: "/app/prod",
+	})
+	_, err = denied.GetSecrets(context.Background(), "")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("GetSecrets() error = %v, want status 403", err)
+	}
+	if strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("GetSecrets() error %q includes the response body", err)
+	}
+}
@@ -18,6 +18,7 @@ type SecretManagerType string
 const (
 	SecretManagerNone      SecretManagerType = "none"
 	SecretManagerInfisical SecretManagerType = "infisical"
+	SecretManagerVault     SecretManagerType = "vault"
 )
 
 // SecretManagerConfig holds configuration for secret managers
@@ -30,6 +31,10 @@ type SecretManagerConfig struct {
 	ServiceName    string
 	InfisicalURL   string
 	InfisicalToken string
+	VaultAddr      string
+	VaultToken     string
+	VaultMount     string // KV v2 secrets engine mount (e.g., "secret")
+	VaultNamespace string // Vault Enterprise namespace, optional
 }
 
 // SecretManager interface for fetching secrets
@@ -62,6 +67,10 @@ func LoadSecretManagerConfig(serviceName string) *SecretManagerConfig {
 		ServiceName:    serviceName,
 		InfisicalURL:   getEnvOrDefault("INFISICAL_URL", "https://app.infisical.com"),
 		InfisicalToken: os.Getenv("INFISICAL_TOKEN"),
+		VaultAddr:      getEnvOrDefault("VAULT_ADDR", "http://127.0.0.1:8200"),
+		VaultToken:     os.Getenv("VAULT_TOKEN"),
+		VaultMount:     getEnvOrDefault("VAULT_MOUNT", "secret"),
+		VaultNamespace: os.Getenv("VAULT_NAMESPACE"),
 	}
 
 	return config
@@ -79,6 +88,11 @@ func NewSecretManager(config *SecretManagerConfig) (SecretManager, error) {
 			return nil, fmt.Errorf("INFISICAL_TOKEN is required when using Infisical")
 		}
 		return NewInfisicalManager(config), nil
+	case SecretManagerVault:
+		if config.VaultToken == "" {
+			return nil, fmt.Errorf("VAULT_TOKEN is required when using Vault")
+		}
+		return NewVaultManager(config), nil
 	default:
 		return &NoOpSecretManager{}, nil
 	}
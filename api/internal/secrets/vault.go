@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultManager implements SecretManager for the HashiCorp Vault KV v2 secrets engine.
+// Secrets are read from the path configured in SecretPath under VaultMount.
+type VaultManager struct {
+	config     *SecretManagerConfig
+	httpClient *http.Client
+}
+
+// StatusError is returned when a secret manager answers with an unexpected
+// HTTP status. It carries no part of the response body.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("secret manager responded with status %d", e.StatusCode)
+}
+
+func NewVaultManager(config *SecretManagerConfig) *VaultManager {
+	return &VaultManager{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			// Redirects are reported as a status rather than followed, so the
+			// token is only ever sent to the configured address.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+func (v *VaultManager) GetSecret(ctx context.Context, key string) (string, error) {
+	secrets, err := v.GetSecrets(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	value, exists := secrets[key]
+	if !exists {
+		return "", fmt.Errorf("secret %s not found", key)
+	}
+	return value, nil
+}
+
+func (v *VaultManager) GetSecrets(ctx context.Context, prefix string) (map[string]string, error) {
+	mount := strings.Trim(v.config.VaultMount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	secretPath := strings.Trim(v.config.SecretPath, "/")
+	if secretPath == "" {
+		return nil, fmt.Errorf("vault secret path is required")
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s",
+		strings.TrimRight(v.config.VaultAddr, "/"),
+		url.PathEscape(mount),
+		escapePath(secretPath),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.config.VaultToken)
+	if v.config.VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.VaultNamespace)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return make(map[string]string), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch secrets: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	var kv vaultKVResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets response: %w", err)
+	}
+
+	result := make(map[string]string, len(kv.Data.Data))
+	for key, value := range kv.Data.Data {
+		if prefix != "" && !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		switch val := value.(type) {
+		case string:
+			result[key] = val
+		default:
+			encoded, err := json.Marshal(val)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode secret %s: %w", key, err)
+			}
+			result[key] = string(encoded)
+		}
+	}
+	return result, nil
+}
+
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	"github.com/uptrace/bun"
+)
+
+// encryptedColumns are the columns read and written through
+// types.EncryptedString.
+var encryptedColumns = []struct{ table, column string }{
+	{"applications", "environment_variables"},
+	{"application_env_revisions", "variables"},
+	{"variable_groups", "variables"},
+	{"organization_secret_managers", "token"},
+	{"databases", "password"},
+	{"organization_dns_providers", "credentials"},
+	{"application_certificates", "certificate_pem"},
+	{"application_certificates", "private_key_pem"},
+	{"caddy_config_snapshots", "config"},
+}
+
+const encryptionBackfillBatch = 500
+
+// EncryptLegacySecrets encrypts the values of encrypted columns that were
+// stored in plaintext, before encryption was enabled or while no master key
+// was configured. It does nothing without a master key and skips values
+// that are already encrypted, so it is safe to run on every start. Rows are
+// updated one at a time, and reads handle plaintext and encrypted values
+// alike, so an interrupted run leaves every row readable.
+func EncryptLegacySecrets(ctx context.Context, db *bun.DB) error {
+	if !secrets.MasterKeyConfigured() {
+		return nil
+	}
+	for _, c := range encryptedColumns {
+		var exists bool
+		if err := db.NewRaw("SELECT to_regclass(?) IS NOT NULL", c.table).Scan(ctx, &exists); err != nil {
+			return err
+		}
+		if !exists {
+			// Not migrated yet, so nothing is stored in it.
+			continue
+		}
+		n, err := encryptColumn(ctx, db, c.table, c.column)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s.%s: %w", c.table, c.column, err)
+		}
+		if n > 0 {
+			log.Printf("Encrypted %d stored values in %s.%s", n, c.table, c.column)
+		}
+	}
+	return nil
+}
+
+func encryptColumn(ctx context.Context, db *bun.DB, table, column string) (int, error) {
+	encrypted := 0
+	var lastID uuid.UUID
+	for {
+		var rows []struct {
+			ID    uuid.UUID `bun:"id"`
+			Value string    `bun:"value"`
+		}
+		err := db.NewRaw(
+			"SELECT id, ? AS value FROM ? WHERE id > ? AND ? <> '' AND ? NOT LIKE 'enc:v1:%' ORDER BY id LIMIT ?",
+			bun.Ident(column), bun.Ident(table), lastID, bun.Ident(column), bun.Ident(column), encryptionBackfillBatch,
+		).Scan(ctx, &rows)
+		if err != nil {
+			return encrypted, err
+		}
+		if len(rows) == 0 {
+			return encrypted, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			sealed, err := secrets.Encrypt(row.Value)
+			if err != nil {
+				return encrypted, err
+			}
+			// The value is compared again so a concurrent edit, already
+			// encrypted by its writer, is left alone.
+			res, err := db.NewRaw(
+				"UPDATE ? SET ? = ? WHERE id = ? AND ? = ?",
+				bun.Ident(table), bun.Ident(column), sealed, row.ID, bun.Ident(column), row.Value,
+			).Exec(ctx)
+			if err != nil {
+				return encrypted, err
+			}
+			if affected, _ := res.RowsAffected(); affected > 0 {
+				encrypted++
+			}
+		}
+	}
+}
@@ -48,6 +48,10 @@ func (s *Store) Init(ctx context.Context) error {
 	s.DB.RegisterModel((*types.ExtensionExecution)(nil))
 	s.DB.RegisterModel((*types.ExecutionStep)(nil))
 
+	if err := EncryptLegacySecrets(ctx, s.DB); err != nil {
+		log.Printf("Warning: Failed to encrypt stored secrets: %v", err)
+	}
+
 	// Load extensions from templates directory
 	extensionLoader := loader.NewExtensionLoader(s.DB)
 	if err := extensionLoader.LoadExtensionsFromTemplates(ctx); err != nil {
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationAccessPolicy protects an application, or one of its domains
+// when Domain is set, before requests reach it. A domain policy takes
+// precedence over the application policy. Requests must pass every check
+// that is configured: the IP lists, then basic auth, then forward auth.
+type ApplicationAccessPolicy struct {
+	bun.BaseModel  `bun:"table:application_access_policies,alias:aap" swaggerignore:"true"`
+	ID             uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID  uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Domain         string    `json:"domain" bun:"domain,notnull,default:''"`
+	// AllowedIPs, when set, are the only client IPs and CIDR ranges let
+	// through. DeniedIPs are always rejected.
+	AllowedIPs []string `json:"allowed_ips" bun:"allowed_ips,array"`
+	DeniedIPs  []string `json:"denied_ips" bun:"denied_ips,array"`
+	// BasicAuthUsers holds the bcrypt hashed credentials. Only
+	// BasicAuthUsernames is returned.
+	BasicAuthUsers     []BasicAuthUser `json:"-" bun:"basic_auth_users,type:jsonb"`
+	BasicAuthUsernames []string        `json:"basic_auth_usernames" bun:"-"`
+	BasicAuthRealm     string          `json:"basic_auth_realm" bun:"basic_auth_realm,notnull,default:''"`
+	// ForwardAuthURL is asked about every request. A 2xx answer lets the
+	// request through with ForwardAuthCopyHeaders copied from the answer;
+	// any other answer is returned to the client.
+	ForwardAuthURL         string    `json:"forward_auth_url" bun:"forward_auth_url,notnull,default:''"`
+	ForwardAuthCopyHeaders []string  `json:"forward_auth_copy_headers" bun:"forward_auth_copy_headers,array"`
+	CreatedAt              time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt              time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// BasicAuthUser is an account of the basic auth of an access policy.
+type BasicAuthUser struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// FillBasicAuthUsernames sets BasicAuthUsernames from BasicAuthUsers, which
+// are never returned themselves.
+func (p *ApplicationAccessPolicy) FillBasicAuthUsernames() {
+	p.BasicAuthUsernames = make([]string, 0, len(p.BasicAuthUsers))
+	for _, user := range p.BasicAuthUsers {
+		p.BasicAuthUsernames = append(p.BasicAuthUsernames, user.Username)
+	}
+}
@@ -2,6 +2,9 @@ package types
 
 import (
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,37 +13,51 @@ import (
 
 type Application struct {
 	bun.BaseModel        `bun:"table:applications,alias:a" swaggerignore:"true"`
-	ID                   uuid.UUID                `json:"id" bun:"id,pk,type:uuid"`
-	Name                 string                   `json:"name" bun:"name,notnull"`
-	Port                 int                      `json:"port" bun:"port,notnull"`
-	Environment          Environment              `json:"environment" bun:"environment,notnull"`
-	ProxyServer          ProxyServer              `json:"proxy_server" bun:"proxy_server,notnull,default:caddy"`
-	BuildVariables       string                   `json:"build_variables" bun:"build_variables,notnull"`
-	EnvironmentVariables string                   `json:"environment_variables" bun:"environment_variables,notnull"`
-	BuildPack            BuildPack                `json:"build_pack" bun:"build_pack,notnull"`
-	Repository           string                   `json:"repository" bun:"repository,notnull"`
-	Branch               string                   `json:"branch" bun:"branch,notnull"`
-	PreRunCommand        string                   `json:"pre_run_command" bun:"pre_run_command,notnull"`
-	PostRunCommand       string                   `json:"post_run_command" bun:"post_run_command,notnull"`
-	DockerfilePath       string                   `json:"dockerfile_path" bun:"dockerfile_path,notnull,default:Dockerfile"`
-	BasePath             string                   `json:"base_path" bun:"base_path,notnull,default:/"`
-	UserID               uuid.UUID                `json:"user_id" bun:"user_id,notnull,type:uuid"`
-	OrganizationID       uuid.UUID                `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
-	FamilyID             *uuid.UUID               `json:"family_id,omitempty" bun:"family_id,type:uuid"`
-	CreatedAt            time.Time                `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
-	UpdatedAt            time.Time                `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
-	User                 *User                    `json:"-" bun:"rel:belongs-to,join:user_id=id"`
-	Status               *ApplicationStatus       `json:"status,omitempty" bun:"rel:has-one,join:id=application_id"`
-	Logs                 []*ApplicationLogs       `json:"logs,omitempty" bun:"rel:has-many,join:id=application_id"`
-	Deployments          []*ApplicationDeployment `json:"deployments,omitempty" bun:"rel:has-many,join:id=application_id"`
-	Organization         *Organization            `json:"-" bun:"rel:belongs-to,join:organization_id=id"`
-	Labels               []string                 `json:"labels,omitempty" bun:"labels,array"`
-	Domains              []*ApplicationDomain     `json:"domains,omitempty" bun:"rel:has-many,join:id=application_id"`
-	ComposeServices      []*ComposeService        `json:"compose_services,omitempty" bun:"rel:has-many,join:id=application_id"`
-	IsLiveDeployment     bool                     `json:"is_live_deployment" bun:"is_live_deployment,notnull,default:false"`
-	Source               Source                   `json:"source" bun:"source,notnull,default:'github'"`
-	RoutingStrategy      RoutingStrategy          `json:"routing_strategy" bun:"routing_strategy,notnull,default:'single'"`
-	Servers              []*ApplicationServer     `json:"servers,omitempty" bun:"rel:has-many,join:id=application_id"`
+	ID                   uuid.UUID                   `json:"id" bun:"id,pk,type:uuid"`
+	Name                 string                      `json:"name" bun:"name,notnull"`
+	Port                 int                         `json:"port" bun:"port,notnull"`
+	Environment          Environment                 `json:"environment" bun:"environment,notnull"`
+	ProxyServer          ProxyServer                 `json:"proxy_server" bun:"proxy_server,notnull,default:caddy"`
+	BuildVariables       string                      `json:"build_variables" bun:"build_variables,notnull"`
+	EnvironmentVariables EncryptedString             `json:"environment_variables" bun:"environment_variables,notnull"`
+	BuildPack            BuildPack                   `json:"build_pack" bun:"build_pack,notnull"`
+	Repository           string                      `json:"repository" bun:"repository,notnull"`
+	Branch               string                      `json:"branch" bun:"branch,notnull"`
+	PreRunCommand        string                      `json:"pre_run_command" bun:"pre_run_command,notnull"`
+	PostRunCommand       string                      `json:"post_run_command" bun:"post_run_command,notnull"`
+	DockerfilePath       string                      `json:"dockerfile_path" bun:"dockerfile_path,notnull,default:Dockerfile"`
+	BasePath             string                      `json:"base_path" bun:"base_path,notnull,default:/"`
+	ComposeFiles         []string                    `json:"compose_files,omitempty" bun:"compose_files,array"`
+	ComposeProfiles      []string                    `json:"compose_profiles,omitempty" bun:"compose_profiles,array"`
+	ComposeEnvFiles      []string                    `json:"compose_env_files,omitempty" bun:"compose_env_files,array"`
+	ComposeDeployMode    ComposeDeployMode           `json:"compose_deploy_mode" bun:"compose_deploy_mode,notnull,default:'compose'"`
+	PrivateNetworkScope  PrivateNetworkScope         `json:"private_network_scope" bun:"private_network_scope,notnull,default:'organization'"`
+	InternalAlias        string                      `json:"internal_alias" bun:"internal_alias,notnull"`
+	SBOMFormat           SBOMFormat                  `json:"sbom_format" bun:"sbom_format,notnull,default:'none'"`
+	VulnThreshold        Severity                    `json:"vulnerability_threshold" bun:"vulnerability_threshold,notnull,default:'none'"`
+	UserID               uuid.UUID                   `json:"user_id" bun:"user_id,notnull,type:uuid"`
+	OrganizationID       uuid.UUID                   `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	FamilyID             *uuid.UUID                  `json:"family_id,omitempty" bun:"family_id,type:uuid"`
+	CreatedAt            time.Time                   `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt            time.Time                   `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+	User                 *User                       `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+	Status               *ApplicationStatus          `json:"status,omitempty" bun:"rel:has-one,join:id=application_id"`
+	Logs                 []*ApplicationLogs          `json:"logs,omitempty" bun:"rel:has-many,join:id=application_id"`
+	Deployments          []*ApplicationDeployment    `json:"deployments,omitempty" bun:"rel:has-many,join:id=application_id"`
+	Organization         *Organization               `json:"-" bun:"rel:belongs-to,join:organization_id=id"`
+	Labels               []string                    `json:"labels,omitempty" bun:"labels,array"`
+	Domains              []*ApplicationDomain        `json:"domains,omitempty" bun:"rel:has-many,join:id=application_id"`
+	ComposeServices      []*ComposeService           `json:"compose_services,omitempty" bun:"rel:has-many,join:id=application_id"`
+	IsLiveDeployment     bool                        `json:"is_live_deployment" bun:"is_live_deployment,notnull,default:false"`
+	Source               Source                      `json:"source" bun:"source,notnull,default:'github'"`
+	RoutingStrategy      RoutingStrategy             `json:"routing_strategy" bun:"routing_strategy,notnull,default:'single'"`
+	Servers              []*ApplicationServer        `json:"servers,omitempty" bun:"rel:has-many,join:id=application_id"`
+	VariableGroups       []*ApplicationVariableGroup `json:"variable_groups,omitempty" bun:"rel:has-many,join:id=application_id"`
+	Ports                []*ApplicationPort          `json:"ports,omitempty" bun:"rel:has-many,join:id=application_id"`
+
+	// InternalHostnames are the names other applications on the same private
+	// network use to reach this one. They are derived and only set in responses.
+	InternalHostnames []string `json:"internal_hostnames,omitempty" bun:"-"`
 }
 
 type ApplicationDeployment struct {
@@ -62,6 +79,60 @@ type ApplicationDeployment struct {
 	ServerID           *uuid.UUID                   `json:"server_id,omitempty"            bun:"server_id,type:uuid"`
 	ParentDeploymentID *uuid.UUID                   `json:"parent_deployment_id,omitempty" bun:"parent_deployment_id,type:uuid"`
 	Children           []*ApplicationDeployment     `json:"children,omitempty"            bun:"rel:has-many,join:id=parent_deployment_id"`
+	EnvRevisionID      *uuid.UUID                   `json:"env_revision_id,omitempty"      bun:"env_revision_id,type:uuid"`
+	Phases             []*DeploymentPhase           `json:"phases,omitempty"               bun:"rel:has-many,join:id=application_deployment_id"`
+}
+
+// DeploymentPhase records when one phase of a deployment ran and how it ended.
+type DeploymentPhase struct {
+	bun.BaseModel           `bun:"table:application_deployment_phases,alias:adp" swaggerignore:"true"`
+	ID                      uuid.UUID             `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationDeploymentID uuid.UUID             `json:"application_deployment_id" bun:"application_deployment_id,notnull,type:uuid"`
+	ApplicationID           uuid.UUID             `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	Phase                   DeploymentPhaseName   `json:"phase" bun:"phase,notnull"`
+	Status                  DeploymentPhaseStatus `json:"status" bun:"status,notnull"`
+	StartedAt               time.Time             `json:"started_at" bun:"started_at,notnull"`
+	EndedAt                 *time.Time            `json:"ended_at,omitempty" bun:"ended_at"`
+	DurationMs              int64                 `json:"duration_ms" bun:"duration_ms,notnull"`
+	// BuildSteps and CachedSteps count the Docker build steps seen in the
+	// phase output and how many of them were served from the build cache.
+	BuildSteps  int `json:"build_steps" bun:"build_steps,notnull"`
+	CachedSteps int `json:"cached_steps" bun:"cached_steps,notnull"`
+}
+
+type DeploymentPhaseName string
+
+const (
+	PhaseClone  DeploymentPhaseName = "clone"
+	PhaseBuild  DeploymentPhaseName = "build"
+	PhasePush   DeploymentPhaseName = "push"
+	PhaseDeploy DeploymentPhaseName = "deploy"
+	PhaseHealth DeploymentPhaseName = "health"
+	PhaseRoute  DeploymentPhaseName = "route"
+)
+
+type DeploymentPhaseStatus string
+
+const (
+	PhaseRunning   DeploymentPhaseStatus = "running"
+	PhaseSucceeded DeploymentPhaseStatus = "succeeded"
+	PhaseFailed    DeploymentPhaseStatus = "failed"
+	PhaseCancelled DeploymentPhaseStatus = "cancelled"
+)
+
+// ApplicationEnvRevision is an immutable snapshot of an application's
+// environment variables. A new revision is recorded whenever the variables
+// change and every deployment references the revision it ran with.
+type ApplicationEnvRevision struct {
+	bun.BaseModel `bun:"table:application_env_revisions,alias:aer" swaggerignore:"true"`
+	ID            uuid.UUID       `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID uuid.UUID       `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	Revision      int             `json:"revision" bun:"revision,notnull"`
+	Variables     EncryptedString `json:"-" bun:"variables,notnull"`
+	Keys          []string        `json:"keys" bun:"-"`
+	CreatedBy     *uuid.UUID      `json:"created_by,omitempty" bun:"created_by,type:uuid"`
+	RestoredFrom  *int            `json:"restored_from,omitempty" bun:"restored_from"`
+	CreatedAt     time.Time       `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
 }
 
 type ApplicationStatus struct {
@@ -109,6 +180,99 @@ type ApplicationServer struct {
 	Server        *SSHKey   `json:"server,omitempty" bun:"rel:belongs-to,join:server_id=id"`
 }
 
+// ApplicationPort publishes a raw TCP or UDP port of an application. Ports
+// without a server apply to every server the application runs on.
+type ApplicationPort struct {
+	bun.BaseModel `bun:"table:application_ports,alias:apt" swaggerignore:"true"`
+	ID            uuid.UUID       `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID uuid.UUID       `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	ServerID      *uuid.UUID      `json:"server_id,omitempty" bun:"server_id,type:uuid"`
+	ServiceName   string          `json:"service_name,omitempty" bun:"service_name,notnull"`
+	Protocol      PortProtocol    `json:"protocol" bun:"protocol,notnull,default:'tcp'"`
+	TargetPort    int             `json:"target_port" bun:"target_port,notnull"`
+	PublishedPort int             `json:"published_port" bun:"published_port,notnull"`
+	PublishMode   PortPublishMode `json:"publish_mode" bun:"publish_mode,notnull,default:'ingress'"`
+	CreatedAt     time.Time       `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt     time.Time       `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+
+	// Upstreams are the host ports Caddy dials for a proxied port, one per
+	// server it was deployed on. They are recorded after every deployment.
+	Upstreams []ApplicationPortUpstream `json:"upstreams,omitempty" bun:"rel:has-many,join:id=port_id"`
+
+	Application *Application `json:"-" bun:"rel:belongs-to,join:application_id=id"`
+
+	// FirewallHints are the commands that open the port on the server. They
+	// are derived and only set in responses.
+	FirewallHints []string `json:"firewall_hints,omitempty" bun:"-"`
+}
+
+// UpstreamPortOn returns the host port p was published on on a server, or 0
+// when it has not been deployed there.
+func (p *ApplicationPort) UpstreamPortOn(serverID uuid.UUID) int {
+	for _, u := range p.Upstreams {
+		if u.ServerID == serverID {
+			return u.UpstreamPort
+		}
+	}
+	return 0
+}
+
+// ApplicationPortUpstream is the host port Docker assigned to a proxied port
+// on one server.
+type ApplicationPortUpstream struct {
+	bun.BaseModel `bun:"table:application_port_upstreams,alias:apu" swaggerignore:"true"`
+	PortID        uuid.UUID `json:"port_id" bun:"port_id,pk,type:uuid"`
+	ServerID      uuid.UUID `json:"server_id" bun:"server_id,pk,type:uuid"`
+	UpstreamPort  int       `json:"upstream_port" bun:"upstream_port,notnull"`
+	UpdatedAt     time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+type PortProtocol string
+
+const (
+	PortProtocolTCP PortProtocol = "tcp"
+	PortProtocolUDP PortProtocol = "udp"
+)
+
+func IsValidPortProtocol(protocol string) bool {
+	switch PortProtocol(protocol) {
+	case PortProtocolTCP, PortProtocolUDP:
+		return true
+	}
+	return false
+}
+
+// PortPublishMode selects how a port reaches the outside world.
+type PortPublishMode string
+
+const (
+	// PortPublishIngress publishes the port on every node through the Swarm routing mesh.
+	PortPublishIngress PortPublishMode = "ingress"
+	// PortPublishHost binds the port directly on the node running the container.
+	PortPublishHost PortPublishMode = "host"
+	// PortPublishProxy keeps the container port private and forwards the
+	// published port through the Caddy layer4 app.
+	PortPublishProxy PortPublishMode = "proxy"
+)
+
+func IsValidPortPublishMode(mode string) bool {
+	switch PortPublishMode(mode) {
+	case PortPublishIngress, PortPublishHost, PortPublishProxy:
+		return true
+	}
+	return false
+}
+
+// SetFirewallHints fills FirewallHints with the ufw and firewalld commands
+// that allow traffic to the published port.
+func (p *ApplicationPort) SetFirewallHints() {
+	port := strconv.Itoa(p.PublishedPort) + "/" + string(p.Protocol)
+	p.FirewallHints = []string{
+		"ufw allow " + port,
+		"firewall-cmd --permanent --add-port=" + port + " && firewall-cmd --reload",
+	}
+}
+
 type ComposeService struct {
 	bun.BaseModel `bun:"table:compose_services,alias:cs" swaggerignore:"true"`
 	ID            uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
@@ -120,13 +284,40 @@ type ComposeService struct {
 
 	Application *Application         `json:"-" bun:"rel:belongs-to,join:application_id=id"`
 	Domains     []*ApplicationDomain `json:"domains,omitempty" bun:"rel:has-many,join:id=compose_service_id"`
+
+	// Status is read from the docker host and is only set when requested.
+	Status *ComposeServiceStatus `json:"status,omitempty" bun:"-"`
 }
 
+type ComposeServiceState string
+
+const (
+	ComposeServiceRunning    ComposeServiceState = "running"
+	ComposeServiceUnhealthy  ComposeServiceState = "unhealthy"
+	ComposeServiceDegraded   ComposeServiceState = "degraded"
+	ComposeServiceRestarting ComposeServiceState = "restarting"
+	ComposeServiceStopped    ComposeServiceState = "stopped"
+	ComposeServiceNotCreated ComposeServiceState = "not_created"
+)
+
+// ComposeServiceStatus summarizes the containers of a single compose service.
+type ComposeServiceStatus struct {
+	State    ComposeServiceState `json:"state"`
+	Running  int                 `json:"running"`
+	Replicas int                 `json:"replicas"`
+}
+
+// ApplicationDomain routes a host to an application. A PathPrefix mounts the
+// application under that path of the host instead, so several applications
+// can share it; StripPathPrefix removes the prefix from requests before
+// they reach the application.
 type ApplicationDomain struct {
 	bun.BaseModel    `bun:"table:application_domains,alias:ad" swaggerignore:"true"`
 	ID               uuid.UUID  `json:"id" bun:"id,pk,type:uuid"`
 	ApplicationID    uuid.UUID  `json:"application_id" bun:"application_id,notnull,type:uuid"`
 	Domain           string     `json:"domain" bun:"domain,notnull"`
+	PathPrefix       string     `json:"path_prefix" bun:"path_prefix,notnull,default:''"`
+	StripPathPrefix  bool       `json:"strip_path_prefix" bun:"strip_path_prefix,notnull,default:false"`
 	ComposeServiceID *uuid.UUID `json:"compose_service_id,omitempty" bun:"compose_service_id,type:uuid"`
 	Port             *int       `json:"port,omitempty" bun:"port"`
 	CreatedAt        time.Time  `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
@@ -135,6 +326,30 @@ type ApplicationDomain struct {
 	ComposeService *ComposeService `json:"compose_service,omitempty" bun:"rel:belongs-to,join:compose_service_id=id"`
 }
 
+// Route returns the host and path prefix the domain routes, such as
+// example.com/api, which is how users refer to it.
+func (d *ApplicationDomain) Route() string {
+	return d.Domain + d.PathPrefix
+}
+
+// SplitDomainRoute splits a route such as example.com/api into its host and
+// path prefix. The prefix loses its trailing slash, and the root path
+// becomes empty. ok is false when the prefix has characters Caddy path
+// matchers treat specially.
+func SplitDomainRoute(route string) (host string, pathPrefix string, ok bool) {
+	route = strings.TrimSpace(route)
+	host, pathPrefix, found := strings.Cut(route, "/")
+	if !found {
+		return host, "", true
+	}
+	pathPrefix = strings.TrimRight("/"+pathPrefix, "/")
+	if strings.ContainsAny(pathPrefix, " \t\r\n*?#%{}\\") || strings.Contains(pathPrefix, "//") ||
+		slices.Contains(strings.Split(pathPrefix, "/"), "..") {
+		return host, pathPrefix, false
+	}
+	return host, pathPrefix, true
+}
+
 // ResolvePort returns the upstream port for this domain based on its linked
 // ComposeService or explicit port override. Returns 0 when the domain is
 // orphaned (service removed, no manual override) and should not be routed.
@@ -204,6 +419,137 @@ func IsValidBuildPack(bp string) bool {
 	return false
 }
 
+// ComposeDeployMode selects how a docker compose application is started.
+type ComposeDeployMode string
+
+const (
+	// ComposeDeployModeCompose runs the services with docker compose on a single host.
+	ComposeDeployModeCompose ComposeDeployMode = "compose"
+	// ComposeDeployModeStack deploys the services as a Swarm stack.
+	ComposeDeployModeStack ComposeDeployMode = "stack"
+)
+
+func IsValidComposeDeployMode(mode string) bool {
+	switch ComposeDeployMode(mode) {
+	case ComposeDeployModeCompose, ComposeDeployModeStack:
+		return true
+	}
+	return false
+}
+
+// IsComposeStack reports whether the application is a compose application
+// deployed as a Swarm stack.
+func (a *Application) IsComposeStack() bool {
+	return a.BuildPack == DockerCompose && a.ComposeDeployMode == ComposeDeployModeStack
+}
+
+// ComposeStackName returns the Swarm stack name of the application. It is
+// derived from the ID so renaming the application keeps the same stack.
+func (a *Application) ComposeStackName() string {
+	return "nixopus-" + strings.ReplaceAll(a.ID.String(), "-", "")[:12]
+}
+
+// StackServiceName returns the Swarm service name docker stack deploy gives
+// to a compose service of the application.
+func (a *Application) StackServiceName(serviceName string) string {
+	return a.ComposeStackName() + "_" + serviceName
+}
+
+// PrivateNetworkScope selects which applications share a private network.
+type PrivateNetworkScope string
+
+const (
+	// PrivateNetworkOrganization joins the network shared by every application of the organization.
+	PrivateNetworkOrganization PrivateNetworkScope = "organization"
+	// PrivateNetworkFamily joins a network shared only with the application's family.
+	PrivateNetworkFamily PrivateNetworkScope = "family"
+)
+
+func IsValidPrivateNetworkScope(scope string) bool {
+	switch PrivateNetworkScope(scope) {
+	case PrivateNetworkOrganization, PrivateNetworkFamily:
+		return true
+	}
+	return false
+}
+
+// InternalDomain is the suffix of every internal hostname.
+const InternalDomain = "internal"
+
+var internalAliasInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// InternalAliasFromName turns an application name into a DNS label.
+func InternalAliasFromName(name string) string {
+	alias := strings.Trim(internalAliasInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(alias) > 63 {
+		alias = strings.TrimRight(alias[:63], "-")
+	}
+	return alias
+}
+
+// InternalAliasWithSuffix returns alias followed by -n, shortened so the
+// result is still a DNS label. It names the nth application that wants the
+// same alias on one private network.
+func InternalAliasWithSuffix(alias string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	if len(alias)+len(suffix) > 63 {
+		alias = strings.TrimRight(alias[:63-len(suffix)], "-")
+	}
+	return alias + suffix
+}
+
+// PrivateNetworkOwner returns the ID the private network of the application
+// belongs to: its family for family scoped applications, else its
+// organization. Internal aliases are unique per owner.
+func (a *Application) PrivateNetworkOwner() uuid.UUID {
+	if a.PrivateNetworkScope == PrivateNetworkFamily && a.FamilyID != nil {
+		return *a.FamilyID
+	}
+	return a.OrganizationID
+}
+
+// PrivateNetworkName returns the overlay network the application joins.
+// Family scoped applications without a family use the organization network.
+func (a *Application) PrivateNetworkName() string {
+	if a.PrivateNetworkScope == PrivateNetworkFamily && a.FamilyID != nil {
+		return "nixopus-family-" + strings.ReplaceAll(a.FamilyID.String(), "-", "")[:12]
+	}
+	return "nixopus-org-" + strings.ReplaceAll(a.OrganizationID.String(), "-", "")[:12]
+}
+
+// PrivateAlias returns the DNS label of the application on its private network.
+func (a *Application) PrivateAlias() string {
+	if a.InternalAlias != "" {
+		return a.InternalAlias
+	}
+	if alias := InternalAliasFromName(a.Name); alias != "" {
+		return alias
+	}
+	return "app-" + strings.ReplaceAll(a.ID.String(), "-", "")[:12]
+}
+
+// InternalHostname returns the private hostname of the application, or of
+// one of its compose services when serviceName is set.
+func (a *Application) InternalHostname(serviceName string) string {
+	if serviceName == "" {
+		return a.PrivateAlias() + "." + InternalDomain
+	}
+	return serviceName + "." + a.PrivateAlias() + "." + InternalDomain
+}
+
+// SetInternalHostnames fills InternalHostnames. Compose applications get one
+// hostname per service.
+func (a *Application) SetInternalHostnames() {
+	a.InternalHostnames = nil
+	if a.BuildPack != DockerCompose {
+		a.InternalHostnames = []string{a.InternalHostname("")}
+		return
+	}
+	for _, svc := range a.ComposeServices {
+		a.InternalHostnames = append(a.InternalHostnames, a.InternalHostname(svc.ServiceName))
+	}
+}
+
 type Source string
 
 const (
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationMigration moves an application and its named volumes from one
+// server to another. The source is only cleaned up once the migration is
+// confirmed, until then it can be rolled back.
+type ApplicationMigration struct {
+	bun.BaseModel     `bun:"table:application_migrations,alias:am" swaggerignore:"true"`
+	ID                uuid.UUID         `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID     uuid.UUID         `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID    uuid.UUID         `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	SourceServerID    uuid.UUID         `json:"source_server_id" bun:"source_server_id,notnull,type:uuid"`
+	TargetServerID    uuid.UUID         `json:"target_server_id" bun:"target_server_id,notnull,type:uuid"`
+	DeploymentID      *uuid.UUID        `json:"deployment_id,omitempty" bun:"deployment_id,type:uuid"`
+	Transfer          MigrationTransfer `json:"transfer" bun:"transfer,notnull"`
+	StopSource        bool              `json:"stop_source" bun:"stop_source,notnull"`
+	Status            MigrationStatus   `json:"status" bun:"status,notnull"`
+	Steps             []MigrationStep   `json:"steps" bun:"steps,type:jsonb,notnull"`
+	Volumes           []string          `json:"volumes" bun:"volumes,type:jsonb,notnull"`
+	SourceRoutes      []MigrationRoute  `json:"source_routes" bun:"source_routes,type:jsonb,notnull"`
+	SourceReplicas    map[string]uint64 `json:"source_replicas,omitempty" bun:"source_replicas,type:jsonb"`
+	PreviousServerIDs []uuid.UUID       `json:"previous_server_ids" bun:"previous_server_ids,type:jsonb,notnull"`
+	PreviousPrimaryID *uuid.UUID        `json:"previous_primary_id,omitempty" bun:"previous_primary_id,type:uuid"`
+	Error             string            `json:"error,omitempty" bun:"error,notnull"`
+	CreatedBy         uuid.UUID         `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt         time.Time         `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt         time.Time         `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+	CompletedAt       *time.Time        `json:"completed_at,omitempty" bun:"completed_at"`
+}
+
+// MigrationTransfer is how the image of the application reaches the target server.
+type MigrationTransfer string
+
+const (
+	// MigrationTransferS3 loads the image exported to S3 by the last deployment.
+	MigrationTransferS3 MigrationTransfer = "s3"
+	// MigrationTransferSSH streams docker save from the source into docker load on the target.
+	MigrationTransferSSH MigrationTransfer = "ssh"
+)
+
+func IsValidMigrationTransfer(transfer string) bool {
+	switch MigrationTransfer(transfer) {
+	case MigrationTransferS3, MigrationTransferSSH:
+		return true
+	}
+	return false
+}
+
+type MigrationStatus string
+
+const (
+	MigrationPending              MigrationStatus = "pending"
+	MigrationRunning              MigrationStatus = "running"
+	MigrationAwaitingConfirmation MigrationStatus = "awaiting_confirmation"
+	MigrationConfirming           MigrationStatus = "confirming"
+	MigrationCompleted            MigrationStatus = "completed"
+	MigrationFailed               MigrationStatus = "failed"
+	MigrationRollingBack          MigrationStatus = "rolling_back"
+	MigrationRolledBack           MigrationStatus = "rolled_back"
+)
+
+// IsActive reports whether a migration still holds the application, which
+// blocks starting another one.
+func (s MigrationStatus) IsActive() bool {
+	switch s {
+	case MigrationPending, MigrationRunning, MigrationAwaitingConfirmation, MigrationConfirming, MigrationRollingBack:
+		return true
+	}
+	return false
+}
+
+type MigrationStepName string
+
+const (
+	MigrationStepImage    MigrationStepName = "image"
+	MigrationStepVolumes  MigrationStepName = "volumes"
+	MigrationStepDeploy   MigrationStepName = "deploy"
+	MigrationStepRoutes   MigrationStepName = "routes"
+	MigrationStepCleanup  MigrationStepName = "cleanup"
+	MigrationStepRollback MigrationStepName = "rollback"
+)
+
+type MigrationStepStatus string
+
+const (
+	MigrationStepRunning   MigrationStepStatus = "running"
+	MigrationStepSucceeded MigrationStepStatus = "succeeded"
+	MigrationStepFailed    MigrationStepStatus = "failed"
+	MigrationStepSkipped   MigrationStepStatus = "skipped"
+)
+
+// MigrationStep is the progress of one step of a migration.
+type MigrationStep struct {
+	Step      MigrationStepName   `json:"step"`
+	Status    MigrationStepStatus `json:"status"`
+	Message   string              `json:"message,omitempty"`
+	StartedAt time.Time           `json:"started_at"`
+	EndedAt   *time.Time          `json:"ended_at,omitempty"`
+}
+
+// MigrationRoute is a proxy route of the application as it was before the
+// migration switched it, restored on rollback.
+type MigrationRoute struct {
+	Domain       string `json:"domain"`
+	UpstreamDial string `json:"upstream_dial"`
+}
@@ -35,6 +35,7 @@ const (
 	AuditResourceAudit           AuditResourceType = "audit"
 	AuditResourceTerminal        AuditResourceType = "terminal"
 	AuditResourceIntegration     AuditResourceType = "integration"
+	AuditResourceDatabase        AuditResourceType = "database"
 )
 
 type AuditLog struct {
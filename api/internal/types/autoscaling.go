@@ -0,0 +1,56 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// AutoscalingMetric is the container usage an autoscaling policy tracks.
+type AutoscalingMetric string
+
+const (
+	AutoscalingMetricCPU    AutoscalingMetric = "cpu"
+	AutoscalingMetricMemory AutoscalingMetric = "memory"
+)
+
+// IsValid reports whether m is a supported autoscaling metric.
+func (m AutoscalingMetric) IsValid() bool {
+	return m == AutoscalingMetricCPU || m == AutoscalingMetricMemory
+}
+
+// AutoscalingPolicy scales the service of an application between
+// MinReplicas and MaxReplicas to keep the average usage of Metric across its
+// containers near TargetPercent.
+type AutoscalingPolicy struct {
+	bun.BaseModel   `bun:"table:application_autoscaling_policies,alias:aap" swaggerignore:"true"`
+	ApplicationID   uuid.UUID         `json:"application_id" bun:"application_id,pk,type:uuid"`
+	OrganizationID  uuid.UUID         `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Enabled         bool              `json:"enabled" bun:"enabled,notnull,default:true"`
+	MinReplicas     int               `json:"min_replicas" bun:"min_replicas,notnull"`
+	MaxReplicas     int               `json:"max_replicas" bun:"max_replicas,notnull"`
+	Metric          AutoscalingMetric `json:"metric" bun:"metric,notnull"`
+	TargetPercent   int               `json:"target_percent" bun:"target_percent,notnull"`
+	CooldownSeconds int               `json:"cooldown_seconds" bun:"cooldown_seconds,notnull"`
+	LastScaledAt    *time.Time        `json:"last_scaled_at,omitempty" bun:"last_scaled_at"`
+	CreatedAt       time.Time         `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt       time.Time         `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// ApplicationScalingEvent records a scaling decision of the autoscaler and
+// why it was made. Error is set when the service could not be scaled.
+type ApplicationScalingEvent struct {
+	bun.BaseModel   `bun:"table:application_scaling_events,alias:ase" swaggerignore:"true"`
+	ID              uuid.UUID         `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID   uuid.UUID         `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID  uuid.UUID         `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	FromReplicas    int               `json:"from_replicas" bun:"from_replicas,notnull"`
+	ToReplicas      int               `json:"to_replicas" bun:"to_replicas,notnull"`
+	Metric          AutoscalingMetric `json:"metric" bun:"metric,notnull"`
+	ObservedPercent float64           `json:"observed_percent" bun:"observed_percent,notnull"`
+	TargetPercent   int               `json:"target_percent" bun:"target_percent,notnull"`
+	Reason          string            `json:"reason" bun:"reason,notnull"`
+	Error           string            `json:"error,omitempty" bun:"error"`
+	CreatedAt       time.Time         `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// BackupStatus is the state of a database or volume backup.
+type BackupStatus string
+
+const (
+	BackupPending   BackupStatus = "pending"
+	BackupRunning   BackupStatus = "running"
+	BackupSucceeded BackupStatus = "succeeded"
+	BackupFailed    BackupStatus = "failed"
+)
+
+// BackupTrigger records whether a backup was scheduled or requested.
+type BackupTrigger string
+
+const (
+	BackupScheduled BackupTrigger = "scheduled"
+	BackupManual    BackupTrigger = "manual"
+)
+
+// RestoreStatus is the state of a database or volume restore.
+type RestoreStatus string
+
+const (
+	RestorePending   RestoreStatus = "pending"
+	RestoreRunning   RestoreStatus = "running"
+	RestoreSucceeded RestoreStatus = "succeeded"
+	RestoreFailed    RestoreStatus = "failed"
+)
+
+// VolumeBackupSourceType is what a volume backup policy archives.
+type VolumeBackupSourceType string
+
+const (
+	VolumeBackupSourceVolume VolumeBackupSourceType = "volume"
+	VolumeBackupSourceBind   VolumeBackupSourceType = "bind"
+)
+
+// VolumeBackupPolicy backs up a named Docker volume or a bind path of an
+// application on its primary server. Schedule is a cron expression, empty
+// when backups are only taken on demand.
+type VolumeBackupPolicy struct {
+	bun.BaseModel  `bun:"table:volume_backup_policies,alias:vbp" swaggerignore:"true"`
+	ID             uuid.UUID              `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID  uuid.UUID              `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID              `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	SourceType     VolumeBackupSourceType `json:"source_type" bun:"source_type,notnull"`
+	Source         string                 `json:"source" bun:"source,notnull"`
+	Schedule       string                 `json:"schedule" bun:"schedule,notnull,default:''"`
+	RetentionCount int                    `json:"retention_count" bun:"retention_count,notnull,default:7"`
+	RetentionDays  int                    `json:"retention_days" bun:"retention_days,notnull,default:0"`
+	CreatedBy      uuid.UUID              `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt      time.Time              `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time              `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// VolumeBackup is a gzipped tar archive of a policy's source, stored in S3.
+type VolumeBackup struct {
+	bun.BaseModel  `bun:"table:volume_backups,alias:vb" swaggerignore:"true"`
+	ID             uuid.UUID              `json:"id" bun:"id,pk,type:uuid"`
+	PolicyID       uuid.UUID              `json:"policy_id" bun:"policy_id,notnull,type:uuid"`
+	ApplicationID  uuid.UUID              `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID              `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	ServerID       *uuid.UUID             `json:"server_id,omitempty" bun:"server_id,type:uuid"`
+	SourceType     VolumeBackupSourceType `json:"source_type" bun:"source_type,notnull"`
+	Source         string                 `json:"source" bun:"source,notnull"`
+	S3Key          string                 `json:"s3_key,omitempty" bun:"s3_key,notnull,default:''"`
+	SizeBytes      int64                  `json:"size_bytes" bun:"size_bytes,notnull,default:0"`
+	DurationMs     int64                  `json:"duration_ms" bun:"duration_ms,notnull,default:0"`
+	Status         BackupStatus           `json:"status" bun:"status,notnull"`
+	Trigger        BackupTrigger          `json:"trigger" bun:"trigger,notnull"`
+	Error          string                 `json:"error,omitempty" bun:"error,notnull,default:''"`
+	CreatedBy      *uuid.UUID             `json:"created_by,omitempty" bun:"created_by,type:uuid"`
+	StartedAt      *time.Time             `json:"started_at,omitempty" bun:"started_at"`
+	CompletedAt    *time.Time             `json:"completed_at,omitempty" bun:"completed_at"`
+	CreatedAt      time.Time              `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// VolumeRestore replaces the contents of a policy's source with a backup
+// while the application is stopped.
+type VolumeRestore struct {
+	bun.BaseModel  `bun:"table:volume_restores,alias:vr" swaggerignore:"true"`
+	ID             uuid.UUID     `json:"id" bun:"id,pk,type:uuid"`
+	BackupID       uuid.UUID     `json:"backup_id" bun:"backup_id,notnull,type:uuid"`
+	ApplicationID  uuid.UUID     `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID     `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Status         RestoreStatus `json:"status" bun:"status,notnull"`
+	Error          string        `json:"error,omitempty" bun:"error,notnull,default:''"`
+	CreatedBy      uuid.UUID     `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt      time.Time     `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	CompletedAt    *time.Time    `json:"completed_at,omitempty" bun:"completed_at"`
+}
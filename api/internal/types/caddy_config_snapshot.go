@@ -0,0 +1,44 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CaddySnapshotReason names the change a Caddy config snapshot was taken
+// before.
+type CaddySnapshotReason string
+
+const (
+	CaddySnapshotAddDomains    CaddySnapshotReason = "add_domains"
+	CaddySnapshotRemoveDomains CaddySnapshotReason = "remove_domains"
+	CaddySnapshotReconcile     CaddySnapshotReason = "reconcile"
+	CaddySnapshotFullRebuild   CaddySnapshotReason = "full_rebuild"
+	CaddySnapshotRestore       CaddySnapshotReason = "restore"
+	CaddySnapshotCertificates  CaddySnapshotReason = "certificates"
+	CaddySnapshotDNSChallenges CaddySnapshotReason = "dns_challenges"
+	CaddySnapshotLayer4        CaddySnapshotReason = "layer4"
+	CaddySnapshotMetrics       CaddySnapshotReason = "metrics"
+)
+
+// CaddyConfigSnapshot is the Caddy config of a server as it was before a
+// change to it. Versions count up per organization and server, and a new
+// one is only recorded when the config differs from the latest.
+type CaddyConfigSnapshot struct {
+	bun.BaseModel  `bun:"table:caddy_config_snapshots,alias:ccs" swaggerignore:"true"`
+	ID             uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	OrganizationID uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	// ServerHost is the host of the Caddy admin API the config was read
+	// from.
+	ServerHost string              `json:"server_host" bun:"server_host,notnull"`
+	Version    int                 `json:"version" bun:"version,notnull"`
+	Reason     CaddySnapshotReason `json:"reason" bun:"reason,notnull"`
+	// Config is the JSON config. It holds certificate keys and DNS
+	// provider credentials, so it is stored encrypted and never returned.
+	Config       EncryptedString `json:"-" bun:"config,notnull"`
+	CreatedBy    *uuid.UUID      `json:"created_by,omitempty" bun:"created_by,type:uuid"`
+	RestoredFrom *int            `json:"restored_from,omitempty" bun:"restored_from"`
+	CreatedAt    time.Time       `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationCertificate is a certificate uploaded for a domain of an
+// application, such as an EV or corporate CA certificate. Caddy serves it
+// for the domain instead of getting one from ACME.
+type ApplicationCertificate struct {
+	bun.BaseModel  `bun:"table:application_certificates,alias:acrt" swaggerignore:"true"`
+	ID             uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID  uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Domain         string    `json:"domain" bun:"domain,notnull"`
+	// CertificatePEM is the leaf certificate followed by its intermediates.
+	CertificatePEM EncryptedString `json:"-" bun:"certificate_pem,notnull"`
+	PrivateKeyPEM  EncryptedString `json:"-" bun:"private_key_pem,notnull"`
+	Subject        string          `json:"subject" bun:"subject,notnull,default:''"`
+	Issuer         string          `json:"issuer" bun:"issuer,notnull,default:''"`
+	DNSNames       []string        `json:"dns_names" bun:"dns_names,array"`
+	// Fingerprint is the hex SHA-256 of the leaf certificate.
+	Fingerprint string    `json:"fingerprint" bun:"fingerprint,notnull"`
+	NotBefore   time.Time `json:"not_before" bun:"not_before,notnull"`
+	NotAfter    time.Time `json:"not_after" bun:"not_after,notnull"`
+	// ExpiryWarningDays is how many days before NotAfter a warning is sent.
+	ExpiryWarningDays int `json:"expiry_warning_days" bun:"expiry_warning_days,notnull,default:14"`
+	// ExpiryWarnedAt is set once the warning for this certificate was sent.
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at,omitempty" bun:"expiry_warned_at"`
+	CreatedBy      uuid.UUID  `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt      time.Time  `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time  `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
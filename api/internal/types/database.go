@@ -0,0 +1,207 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Database is a managed database resource. It runs as a single replica Swarm
+// service on one server, keeps its data in a named volume and is only
+// reachable from its own private network, which attached applications join.
+type Database struct {
+	bun.BaseModel  `bun:"table:databases,alias:mdb" swaggerignore:"true"`
+	ID             uuid.UUID       `json:"id" bun:"id,pk,type:uuid"`
+	OrganizationID uuid.UUID       `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	ServerID       uuid.UUID       `json:"server_id" bun:"server_id,notnull,type:uuid"`
+	Name           string          `json:"name" bun:"name,notnull"`
+	Engine         DatabaseEngine  `json:"engine" bun:"engine,notnull"`
+	Version        string          `json:"version" bun:"version,notnull"`
+	DatabaseName   string          `json:"database_name" bun:"database_name,notnull,default:''"`
+	Username       string          `json:"username" bun:"username,notnull,default:''"`
+	Password       EncryptedString `json:"-" bun:"password,notnull"`
+	CPULimit       float64         `json:"cpu_limit" bun:"cpu_limit,notnull,default:0"`
+	MemoryLimitMB  int64           `json:"memory_limit_mb" bun:"memory_limit_mb,notnull,default:0"`
+	// BackupSchedule is a cron expression for scheduled backups, empty when
+	// backups are only taken on demand.
+	BackupSchedule       string         `json:"backup_schedule" bun:"backup_schedule,notnull,default:''"`
+	BackupRetentionCount int            `json:"backup_retention_count" bun:"backup_retention_count,notnull,default:7"`
+	BackupRetentionDays  int            `json:"backup_retention_days" bun:"backup_retention_days,notnull,default:0"`
+	Status               DatabaseStatus `json:"status" bun:"status,notnull"`
+	Error                string         `json:"error,omitempty" bun:"error,notnull,default:''"`
+	CreatedBy            uuid.UUID      `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt            time.Time      `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt            time.Time      `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// ApplicationDatabase attaches a database to an application. The connection
+// string of the database is injected into the application under EnvVar.
+type ApplicationDatabase struct {
+	bun.BaseModel `bun:"table:application_databases,alias:adb" swaggerignore:"true"`
+	ID            uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	DatabaseID    uuid.UUID `json:"database_id" bun:"database_id,notnull,type:uuid"`
+	EnvVar        string    `json:"env_var" bun:"env_var,notnull"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	Database      *Database `json:"database,omitempty" bun:"rel:belongs-to,join:database_id=id"`
+}
+
+type DatabaseEngine string
+
+const (
+	DatabasePostgres DatabaseEngine = "postgres"
+	DatabaseMySQL    DatabaseEngine = "mysql"
+	DatabaseRedis    DatabaseEngine = "redis"
+)
+
+func IsValidDatabaseEngine(engine string) bool {
+	switch DatabaseEngine(engine) {
+	case DatabasePostgres, DatabaseMySQL, DatabaseRedis:
+		return true
+	}
+	return false
+}
+
+// DefaultVersion is the image tag used when no version is requested.
+func (e DatabaseEngine) DefaultVersion() string {
+	switch e {
+	case DatabasePostgres:
+		return "16"
+	case DatabaseMySQL:
+		return "8.4"
+	default:
+		return "7"
+	}
+}
+
+// Port is the port the engine listens on inside the private network.
+func (e DatabaseEngine) Port() int {
+	switch e {
+	case DatabasePostgres:
+		return 5432
+	case DatabaseMySQL:
+		return 3306
+	default:
+		return 6379
+	}
+}
+
+// DataPath is where the engine keeps its data inside the container.
+func (e DatabaseEngine) DataPath() string {
+	switch e {
+	case DatabasePostgres:
+		return "/var/lib/postgresql/data"
+	case DatabaseMySQL:
+		return "/var/lib/mysql"
+	default:
+		return "/data"
+	}
+}
+
+// DefaultEnvVar is the variable the connection string is injected under when
+// an attachment does not name one.
+func (e DatabaseEngine) DefaultEnvVar() string {
+	if e == DatabaseRedis {
+		return "REDIS_URL"
+	}
+	return "DATABASE_URL"
+}
+
+type DatabaseStatus string
+
+const (
+	DatabaseProvisioning DatabaseStatus = "provisioning"
+	DatabaseRunning      DatabaseStatus = "running"
+	DatabaseFailed       DatabaseStatus = "failed"
+)
+
+func (d *Database) shortID() string {
+	return strings.ReplaceAll(d.ID.String(), "-", "")[:12]
+}
+
+// ServiceName is the name of the database's Swarm service.
+func (d *Database) ServiceName() string {
+	return "nixopus-db-" + d.shortID()
+}
+
+// VolumeName is the named volume that holds the database's data.
+func (d *Database) VolumeName() string {
+	return "nixopus-db-" + d.shortID() + "-data"
+}
+
+// NetworkName is the private network of the database.
+func (d *Database) NetworkName() string {
+	return "nixopus-db-" + d.shortID() + "-net"
+}
+
+// Image is the image the database runs.
+func (d *Database) Image() string {
+	return string(d.Engine) + ":" + d.Version
+}
+
+// Hostname is the name of the database on its private network.
+func (d *Database) Hostname() string {
+	alias := InternalAliasFromName(d.Name)
+	if alias == "" {
+		alias = "db-" + d.shortID()
+	}
+	return alias + ".db." + InternalDomain
+}
+
+// ConnectionString returns the URL applications use to connect.
+func (d *Database) ConnectionString() string {
+	host := fmt.Sprintf("%s:%d", d.Hostname(), d.Engine.Port())
+	u := url.URL{Scheme: string(d.Engine), Host: host}
+	switch d.Engine {
+	case DatabaseRedis:
+		u.User = url.UserPassword("", string(d.Password))
+		u.Path = "/0"
+	default:
+		u.User = url.UserPassword(d.Username, string(d.Password))
+		u.Path = "/" + d.DatabaseName
+	}
+	return u.String()
+}
+
+// SupportsBackups reports whether logical backups can be taken of the engine.
+func (e DatabaseEngine) SupportsBackups() bool {
+	return e == DatabasePostgres || e == DatabaseMySQL
+}
+
+// DatabaseBackup is a logical dump of a database, stored gzipped in S3.
+type DatabaseBackup struct {
+	bun.BaseModel  `bun:"table:database_backups,alias:dbb" swaggerignore:"true"`
+	ID             uuid.UUID      `json:"id" bun:"id,pk,type:uuid"`
+	DatabaseID     uuid.UUID      `json:"database_id" bun:"database_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID      `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Engine         DatabaseEngine `json:"engine" bun:"engine,notnull"`
+	S3Key          string         `json:"s3_key,omitempty" bun:"s3_key,notnull,default:''"`
+	SizeBytes      int64          `json:"size_bytes" bun:"size_bytes,notnull,default:0"`
+	Status         BackupStatus   `json:"status" bun:"status,notnull"`
+	Trigger        BackupTrigger  `json:"trigger" bun:"trigger,notnull"`
+	Error          string         `json:"error,omitempty" bun:"error,notnull,default:''"`
+	CreatedBy      *uuid.UUID     `json:"created_by,omitempty" bun:"created_by,type:uuid"`
+	StartedAt      *time.Time     `json:"started_at,omitempty" bun:"started_at"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty" bun:"completed_at"`
+	CreatedAt      time.Time      `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// DatabaseRestore loads a backup into a database, either the one it was taken
+// of or another database of the same engine.
+type DatabaseRestore struct {
+	bun.BaseModel    `bun:"table:database_restores,alias:dbr" swaggerignore:"true"`
+	ID               uuid.UUID     `json:"id" bun:"id,pk,type:uuid"`
+	BackupID         uuid.UUID     `json:"backup_id" bun:"backup_id,notnull,type:uuid"`
+	SourceDatabaseID uuid.UUID     `json:"source_database_id" bun:"source_database_id,notnull,type:uuid"`
+	TargetDatabaseID uuid.UUID     `json:"target_database_id" bun:"target_database_id,notnull,type:uuid"`
+	OrganizationID   uuid.UUID     `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Status           RestoreStatus `json:"status" bun:"status,notnull"`
+	Error            string        `json:"error,omitempty" bun:"error,notnull,default:''"`
+	CreatedBy        uuid.UUID     `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt        time.Time     `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	CompletedAt      *time.Time    `json:"completed_at,omitempty" bun:"completed_at"`
+}
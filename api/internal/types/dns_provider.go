@@ -0,0 +1,65 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// DNSProviderType names the DNS API Caddy uses to solve DNS-01 challenges.
+// The Caddy build serving the organization must include the matching
+// caddy-dns module.
+type DNSProviderType string
+
+const (
+	DNSProviderCloudflare   DNSProviderType = "cloudflare"
+	DNSProviderRoute53      DNSProviderType = "route53"
+	DNSProviderDigitalOcean DNSProviderType = "digitalocean"
+	DNSProviderRFC2136      DNSProviderType = "rfc2136"
+)
+
+// dnsProviderCredentials lists, per provider, the credential fields Caddy
+// accepts and whether each is required.
+var dnsProviderCredentials = map[DNSProviderType]map[string]bool{
+	DNSProviderCloudflare:   {"api_token": true, "zone_token": false},
+	DNSProviderRoute53:      {"access_key_id": true, "secret_access_key": true, "region": false, "hosted_zone_id": false},
+	DNSProviderDigitalOcean: {"auth_token": true},
+	DNSProviderRFC2136:      {"server": true, "key_name": true, "key_alg": true, "key": true},
+}
+
+// IsValid reports whether t is a supported DNS provider.
+func (t DNSProviderType) IsValid() bool {
+	_, ok := dnsProviderCredentials[t]
+	return ok
+}
+
+// CredentialFields returns the credential fields of the provider, mapped to
+// whether they are required.
+func (t DNSProviderType) CredentialFields() map[string]bool {
+	return dnsProviderCredentials[t]
+}
+
+// OrganizationDNSProvider holds the credentials of a DNS API an
+// organization uses to get certificates for the domains under its zones
+// with DNS-01 challenges, which wildcard domains and servers unreachable
+// from the internet need.
+type OrganizationDNSProvider struct {
+	bun.BaseModel  `bun:"table:organization_dns_providers,alias:odp" swaggerignore:"true"`
+	ID             uuid.UUID       `json:"id" bun:"id,pk,type:uuid"`
+	OrganizationID uuid.UUID       `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Name           string          `json:"name" bun:"name,notnull"`
+	Provider       DNSProviderType `json:"provider" bun:"provider,notnull"`
+	// Zones are the domains whose subdomains, and themselves, get their
+	// certificates through this provider.
+	Zones []string `json:"zones" bun:"zones,array,notnull"`
+	// Credentials is the JSON object of credential fields Caddy passes to
+	// the provider.
+	Credentials EncryptedString `json:"-" bun:"credentials,notnull"`
+	// CredentialKeys lists the credential fields that are set. Values are
+	// never returned.
+	CredentialKeys []string  `json:"credential_keys" bun:"-"`
+	CreatedBy      uuid.UUID `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt      time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
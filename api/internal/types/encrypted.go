@@ -0,0 +1,41 @@
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/nixopus/nixopus/api/internal/secrets"
+)
+
+// EncryptedString is a string column that is encrypted with the secrets
+// master key when written and transparently decrypted when read. Rows written
+// before encryption was enabled are read back as plaintext.
+type EncryptedString string
+
+// Value implements driver.Valuer.
+func (e EncryptedString) Value() (driver.Value, error) {
+	return secrets.Encrypt(string(e))
+}
+
+// Scan implements sql.Scanner.
+func (e *EncryptedString) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*e = ""
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for EncryptedString", src)
+	}
+
+	plain, err := secrets.Decrypt(raw)
+	if err != nil {
+		return err
+	}
+	*e = EncryptedString(plain)
+	return nil
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// SBOMFormat is the document format of the SBOM generated for built images.
+type SBOMFormat string
+
+const (
+	SBOMFormatNone      SBOMFormat = "none"
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+	SBOMFormatSPDX      SBOMFormat = "spdx"
+)
+
+func IsValidSBOMFormat(format string) bool {
+	switch SBOMFormat(format) {
+	case SBOMFormatNone, SBOMFormatCycloneDX, SBOMFormatSPDX:
+		return true
+	}
+	return false
+}
+
+// Severity is the severity of a vulnerability. As an application setting it
+// is the lowest severity that blocks a deployment, "none" never blocks.
+type Severity string
+
+const (
+	SeverityNone       Severity = "none"
+	SeverityNegligible Severity = "negligible"
+	SeverityLow        Severity = "low"
+	SeverityMedium     Severity = "medium"
+	SeverityHigh       Severity = "high"
+	SeverityCritical   Severity = "critical"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityNegligible: 1,
+	SeverityLow:        2,
+	SeverityMedium:     3,
+	SeverityHigh:       4,
+	SeverityCritical:   5,
+}
+
+func IsValidSeverity(severity string) bool {
+	if Severity(severity) == SeverityNone {
+		return true
+	}
+	_, ok := severityRanks[Severity(severity)]
+	return ok
+}
+
+// Blocks reports whether a finding of severity s is at or above the
+// threshold t. Unknown severities never block.
+func (t Severity) Blocks(s Severity) bool {
+	threshold, ok := severityRanks[t]
+	if !ok {
+		return false
+	}
+	return severityRanks[s] >= threshold
+}
+
+// DeploymentSBOM is the SBOM and vulnerability report of the image built for a deployment.
+type DeploymentSBOM struct {
+	bun.BaseModel           `bun:"table:application_deployment_sboms,alias:ads" swaggerignore:"true"`
+	ID                      uuid.UUID              `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationDeploymentID uuid.UUID              `json:"application_deployment_id" bun:"application_deployment_id,notnull,type:uuid"`
+	ApplicationID           uuid.UUID              `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	Image                   string                 `json:"image" bun:"image,notnull"`
+	Format                  SBOMFormat             `json:"format" bun:"format,notnull"`
+	Document                json.RawMessage        `json:"document,omitempty" bun:"document,type:jsonb,notnull"`
+	Vulnerabilities         []VulnerabilityFinding `json:"vulnerabilities" bun:"vulnerabilities,type:jsonb,notnull"`
+	CriticalCount           int                    `json:"critical_count" bun:"critical_count,notnull"`
+	HighCount               int                    `json:"high_count" bun:"high_count,notnull"`
+	MediumCount             int                    `json:"medium_count" bun:"medium_count,notnull"`
+	LowCount                int                    `json:"low_count" bun:"low_count,notnull"`
+	Blocked                 bool                   `json:"blocked" bun:"blocked,notnull"`
+	CreatedAt               time.Time              `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// VulnerabilityFinding is a vulnerability matched to a package of an image.
+type VulnerabilityFinding struct {
+	ID         string   `json:"id"`
+	Severity   Severity `json:"severity"`
+	Package    string   `json:"package"`
+	Version    string   `json:"version"`
+	FixVersion string   `json:"fix_version,omitempty"`
+}
+
+// DeploymentPackage is a package found in the image of a deployment.
+type DeploymentPackage struct {
+	bun.BaseModel           `bun:"table:application_deployment_packages,alias:adpk" swaggerignore:"true"`
+	ID                      uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationDeploymentID uuid.UUID `json:"application_deployment_id" bun:"application_deployment_id,notnull,type:uuid"`
+	ApplicationID           uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	Name                    string    `json:"name" bun:"name,notnull"`
+	Version                 string    `json:"version" bun:"version,notnull"`
+	Type                    string    `json:"type" bun:"type,notnull"`
+	PURL                    string    `json:"purl,omitempty" bun:"purl,notnull"`
+	CreatedAt               time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationMaintenance is the maintenance mode of an application, or of
+// one of its domains when Domain is set. A domain setting takes precedence
+// over the application setting. While enabled, Caddy answers the domains
+// with a static maintenance page and only AllowedIPs reach the application.
+type ApplicationMaintenance struct {
+	bun.BaseModel     `bun:"table:application_maintenance,alias:amt" swaggerignore:"true"`
+	ID                uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID     uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID    uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Domain            string    `json:"domain" bun:"domain,notnull,default:''"`
+	Enabled           bool      `json:"enabled" bun:"enabled,notnull,default:false"`
+	HTMLBody          string    `json:"html_body" bun:"html_body,notnull,default:''"`
+	RetryAfterSeconds int       `json:"retry_after_seconds" bun:"retry_after_seconds,notnull,default:0"`
+	AllowedIPs        []string  `json:"allowed_ips" bun:"allowed_ips,array"`
+	CreatedAt         time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt         time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
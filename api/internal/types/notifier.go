@@ -4,17 +4,22 @@ package types
 type EventType string
 
 const (
-	EventDeploySuccess       EventType = "deploy.success"
-	EventDeployFailed        EventType = "deploy.failed"
-	EventBuildFailed         EventType = "deploy.build_failed"
-	EventContainerCrashed    EventType = "container.crashed"
-	EventHealthCheckCritical EventType = "healthcheck.critical"
-	EventLoginAlert          EventType = "auth.login"
-	EventPasswordReset       EventType = "auth.password_reset"
-	EventVerificationEmail   EventType = "auth.verification"
-	EventUserAddedToOrg      EventType = "org.user_added"
-	EventUserRemovedFromOrg  EventType = "org.user_removed"
-	EventTrialExpired        EventType = "trail.trial_expired"
+	EventDeploySuccess         EventType = "deploy.success"
+	EventDeployFailed          EventType = "deploy.failed"
+	EventBuildFailed           EventType = "deploy.build_failed"
+	EventContainerCrashed      EventType = "container.crashed"
+	EventHealthCheckCritical   EventType = "healthcheck.critical"
+	EventLoginAlert            EventType = "auth.login"
+	EventPasswordReset         EventType = "auth.password_reset"
+	EventVerificationEmail     EventType = "auth.verification"
+	EventUserAddedToOrg        EventType = "org.user_added"
+	EventUserRemovedFromOrg    EventType = "org.user_removed"
+	EventTrialExpired          EventType = "trail.trial_expired"
+	EventDatabaseBackupFailed  EventType = "database.backup_failed"
+	EventDatabaseRestoreFailed EventType = "database.restore_failed"
+	EventVolumeBackupFailed    EventType = "volume.backup_failed"
+	EventVolumeRestoreFailed   EventType = "volume.restore_failed"
+	EventCertificateExpiring   EventType = "certificate.expiring"
 )
 
 // NotificationEvent is the payload any service emits to trigger notifications.
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationProxySettings tunes how Caddy proxies every domain of an
+// application. Zero values leave the Caddy defaults in place.
+type ApplicationProxySettings struct {
+	bun.BaseModel  `bun:"table:application_proxy_settings,alias:aps" swaggerignore:"true"`
+	ID             uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID  uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	// ResponseHeaders are set on every response, replacing the values sent
+	// by the upstream. RemoveResponseHeaders are stripped from them.
+	ResponseHeaders       map[string]string `json:"response_headers" bun:"response_headers,type:jsonb"`
+	RemoveResponseHeaders []string          `json:"remove_response_headers" bun:"remove_response_headers,array"`
+	// Encodings are the compressions offered to clients, in order of
+	// preference: gzip and zstd.
+	Encodings           []string `json:"encodings" bun:"encodings,array"`
+	MaxRequestBodyBytes int64    `json:"max_request_body_bytes" bun:"max_request_body_bytes,notnull,default:0"`
+	// DialTimeoutSeconds bounds connecting to the upstream and
+	// ResponseTimeoutSeconds waiting for its response headers.
+	DialTimeoutSeconds     int `json:"dial_timeout_seconds" bun:"dial_timeout_seconds,notnull,default:0"`
+	ResponseTimeoutSeconds int `json:"response_timeout_seconds" bun:"response_timeout_seconds,notnull,default:0"`
+	// RateLimitRequests is how many requests a client IP may make per
+	// RateLimitWindowSeconds before getting 429.
+	RateLimitRequests      int       `json:"rate_limit_requests" bun:"rate_limit_requests,notnull,default:0"`
+	RateLimitWindowSeconds int       `json:"rate_limit_window_seconds" bun:"rate_limit_window_seconds,notnull,default:0"`
+	CreatedAt              time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt              time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationRedirect is a redirect rule of an application. It redirects
+// the requests of SourceDomain, or of every domain of the application when
+// SourceDomain is empty, whose path is SourcePath or below it. A
+// SourceDomain that is not a domain of the application is an alias: Caddy
+// routes it only to serve its redirects.
+type ApplicationRedirect struct {
+	bun.BaseModel  `bun:"table:application_redirects,alias:ardr" swaggerignore:"true"`
+	ID             uuid.UUID `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID  uuid.UUID `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	OrganizationID uuid.UUID `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	SourceDomain   string    `json:"source_domain" bun:"source_domain,notnull,default:''"`
+	// SourcePath is a path prefix such as /docs. Empty matches every path.
+	SourcePath string `json:"source_path" bun:"source_path,notnull,default:''"`
+	// TargetDomain is the host redirected to. Empty keeps the requested host.
+	TargetDomain string `json:"target_domain" bun:"target_domain,notnull,default:''"`
+	TargetPath   string `json:"target_path" bun:"target_path,notnull,default:''"`
+	// StatusCode is 301 or 308 for permanent and 302 or 307 for temporary
+	// redirects.
+	StatusCode int `json:"status_code" bun:"status_code,notnull,default:301"`
+	// PreservePath appends the rest of the requested path, after
+	// SourcePath, and the query string to TargetPath.
+	PreservePath bool      `json:"preserve_path" bun:"preserve_path,notnull,default:true"`
+	CreatedAt    time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt    time.Time `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ApplicationScaleToZero is the idle timeout of an application and whether
+// it is currently scaled to zero. While sleeping, the application's domains
+// route to the wake listener and UpstreamDial holds the route to restore.
+type ApplicationScaleToZero struct {
+	bun.BaseModel      `bun:"table:application_scale_to_zero,alias:astz" swaggerignore:"true"`
+	ApplicationID      uuid.UUID  `json:"application_id" bun:"application_id,pk,type:uuid"`
+	OrganizationID     uuid.UUID  `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	IdleTimeoutMinutes int        `json:"idle_timeout_minutes" bun:"idle_timeout_minutes,notnull"`
+	Sleeping           bool       `json:"sleeping" bun:"sleeping,notnull,default:false"`
+	SleepReplicas      int        `json:"sleep_replicas" bun:"sleep_replicas,notnull,default:0"`
+	UpstreamDial       string     `json:"upstream_dial" bun:"upstream_dial,notnull,default:''"`
+	RequestCount       int64      `json:"request_count" bun:"request_count,notnull,default:0"`
+	LastRequestAt      time.Time  `json:"last_request_at" bun:"last_request_at,notnull,default:current_timestamp"`
+	SleptAt            *time.Time `json:"slept_at,omitempty" bun:"slept_at"`
+	WokenAt            *time.Time `json:"woken_at,omitempty" bun:"woken_at"`
+	CreatedAt          time.Time  `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt          time.Time  `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixopus/nixopus/api/internal/secrets"
+	"github.com/uptrace/bun"
+)
+
+// OrganizationSecretManager holds an organization's connection to an external
+// secret manager used to resolve secret:// references in application
+// environment variables at deploy time. One connection per provider.
+type OrganizationSecretManager struct {
+	bun.BaseModel   `bun:"table:organization_secret_managers,alias:osm" swaggerignore:"true"`
+	ID              uuid.UUID                 `json:"id" bun:"id,pk,type:uuid"`
+	OrganizationID  uuid.UUID                 `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	Type            secrets.SecretManagerType `json:"type" bun:"type,notnull"`
+	URL             string                    `json:"url" bun:"url,notnull,default:''"`
+	ProjectID       string                    `json:"project_id,omitempty" bun:"project_id,notnull,default:''"`
+	Environment     string                    `json:"environment,omitempty" bun:"environment,notnull,default:''"`
+	Mount           string                    `json:"mount,omitempty" bun:"mount,notnull,default:''"`
+	Namespace       string                    `json:"namespace,omitempty" bun:"namespace,notnull,default:''"`
+	Token           EncryptedString           `json:"-" bun:"token,notnull"`
+	TokenConfigured bool                      `json:"token_configured" bun:"-"`
+	CreatedBy       uuid.UUID                 `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt       time.Time                 `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt       time.Time                 `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// ManagerConfig builds the secrets package configuration for reading secrets at path.
+func (m OrganizationSecretManager) ManagerConfig(path string) *secrets.SecretManagerConfig {
+	return &secrets.SecretManagerConfig{
+		Type:           m.Type,
+		Enabled:        true,
+		ProjectID:      m.ProjectID,
+		Environment:    m.Environment,
+		SecretPath:     path,
+		ServiceName:    "deploy",
+		InfisicalURL:   m.URL,
+		InfisicalToken: string(m.Token),
+		VaultAddr:      m.URL,
+		VaultToken:     string(m.Token),
+		VaultMount:     m.Mount,
+		VaultNamespace: m.Namespace,
+	}
+}
@@ -25,6 +25,7 @@ type Config struct {
 	S3           S3Config           `mapstructure:"s3"`
 	Timescale    TimescaleConfig    `mapstructure:"timescale"`
 	Resend       ResendConfig       `mapstructure:"resend"`
+	ImageScan    ImageScanConfig    `mapstructure:"image_scan"`
 }
 
 // LiveConfig holds configuration for the live gateway (WebSocket, file sync, build).
@@ -54,6 +55,17 @@ type S3Config struct {
 	UseSSL    bool   `mapstructure:"use_ssl"`
 }
 
+// ImageScanConfig configures the scanner containers run against built images.
+// Vulnerabilities are matched against the database in DBDir on the deploy
+// host. DBUpdateURL points the scanner at a local mirror of that database;
+// when empty the database is never updated by the scanner.
+type ImageScanConfig struct {
+	SyftImage   string `mapstructure:"syft_image"`
+	GrypeImage  string `mapstructure:"grype_image"`
+	DBDir       string `mapstructure:"db_dir"`
+	DBUpdateURL string `mapstructure:"db_update_url"`
+}
+
 type ResendConfig struct {
 	APIKey    string `mapstructure:"api_key"`
 	FromEmail string `mapstructure:"from_email"`
@@ -104,6 +116,13 @@ type SSHConfig struct {
 
 type ProxyConfig struct {
 	CaddyPort string `mapstructure:"caddy_port"`
+	// WakePort is the port of the listener that wakes applications scaled
+	// to zero. WakeDial is the host:port Caddy uses to reach it. WakeHost is
+	// the address the listener binds to, loopback unless Caddy reaches the
+	// API over a container network.
+	WakePort string `mapstructure:"wake_port"`
+	WakeDial string `mapstructure:"wake_dial"`
+	WakeHost string `mapstructure:"wake_host"`
 }
 
 type CORSConfig struct {
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// VariableGroup is a named set of environment variables shared by applications.
+// Groups without a FamilyID are available to every application in the
+// organization, family groups only to applications of that family.
+type VariableGroup struct {
+	bun.BaseModel  `bun:"table:variable_groups,alias:vg" swaggerignore:"true"`
+	ID             uuid.UUID       `json:"id" bun:"id,pk,type:uuid"`
+	OrganizationID uuid.UUID       `json:"organization_id" bun:"organization_id,notnull,type:uuid"`
+	FamilyID       *uuid.UUID      `json:"family_id,omitempty" bun:"family_id,type:uuid"`
+	Name           string          `json:"name" bun:"name,notnull"`
+	Description    string          `json:"description" bun:"description,notnull,default:''"`
+	Variables      EncryptedString `json:"variables" bun:"variables,notnull"`
+	CreatedBy      uuid.UUID       `json:"created_by" bun:"created_by,notnull,type:uuid"`
+	CreatedAt      time.Time       `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time       `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// ApplicationVariableGroup attaches a variable group to an application. Groups
+// with a higher Priority override lower ones; the application's own variables
+// override all groups.
+type ApplicationVariableGroup struct {
+	bun.BaseModel   `bun:"table:application_variable_groups,alias:avg" swaggerignore:"true"`
+	ID              uuid.UUID      `json:"id" bun:"id,pk,type:uuid"`
+	ApplicationID   uuid.UUID      `json:"application_id" bun:"application_id,notnull,type:uuid"`
+	VariableGroupID uuid.UUID      `json:"variable_group_id" bun:"variable_group_id,notnull,type:uuid"`
+	Priority        int            `json:"priority" bun:"priority,notnull,default:0"`
+	CreatedAt       time.Time      `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	VariableGroup   *VariableGroup `json:"variable_group,omitempty" bun:"rel:belongs-to,join:variable_group_id=id"`
+}
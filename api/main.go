@@ -95,6 +95,8 @@ func main() {
 	log.Println("Trial expiry scheduler started successfully")
 	schedulers.StaleMachineCleanup.Start()
 	schedulers.MachineHealthCheck.Start()
+	schedulers.VolumeBackup.Start()
+	schedulers.CertificateExpiry.Start()
 
 	router.SetupRoutes()
 
@@ -112,6 +114,8 @@ func main() {
 		schedulers.TrialExpiry.Stop()
 		schedulers.StaleMachineCleanup.Stop()
 		schedulers.MachineHealthCheck.Stop()
+		schedulers.VolumeBackup.Stop()
+		schedulers.CertificateExpiry.Stop()
 		os.Exit(0)
 	}()
 	log.Printf("Server starting on port %s", config.AppConfig.Server.Port)
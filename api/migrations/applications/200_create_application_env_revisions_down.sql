@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_application_deployment_env_revision_id;
+
+ALTER TABLE application_deployment DROP COLUMN IF EXISTS env_revision_id;
+
+DROP TABLE IF EXISTS application_env_revisions;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS application_env_revisions (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    variables TEXT NOT NULL DEFAULT '',
+    created_by UUID,
+    restored_from INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, revision)
+);
+
+ALTER TABLE application_deployment
+    ADD COLUMN IF NOT EXISTS env_revision_id UUID REFERENCES application_env_revisions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_application_deployment_env_revision_id ON application_deployment(env_revision_id);
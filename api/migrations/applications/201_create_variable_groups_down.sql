@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_variable_groups;
+DROP TABLE IF EXISTS variable_groups;
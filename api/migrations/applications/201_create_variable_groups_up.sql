@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS variable_groups (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    family_id UUID,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    variables TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_variable_groups_org_name
+    ON variable_groups(organization_id, name) WHERE family_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_variable_groups_family_name
+    ON variable_groups(organization_id, family_id, name) WHERE family_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS application_variable_groups (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    variable_group_id UUID NOT NULL REFERENCES variable_groups(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, variable_group_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_variable_groups_group_id ON application_variable_groups(variable_group_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS organization_secret_managers (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    project_id VARCHAR(255) NOT NULL DEFAULT '',
+    environment VARCHAR(255) NOT NULL DEFAULT '',
+    mount VARCHAR(255) NOT NULL DEFAULT '',
+    namespace VARCHAR(255) NOT NULL DEFAULT '',
+    token TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, type)
+);
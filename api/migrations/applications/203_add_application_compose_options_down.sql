@@ -0,0 +1,3 @@
+ALTER TABLE applications DROP COLUMN IF EXISTS compose_env_files;
+ALTER TABLE applications DROP COLUMN IF EXISTS compose_profiles;
+ALTER TABLE applications DROP COLUMN IF EXISTS compose_files;
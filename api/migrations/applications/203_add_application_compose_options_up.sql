@@ -0,0 +1,3 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose_files TEXT[] DEFAULT '{}';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose_profiles TEXT[] DEFAULT '{}';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose_env_files TEXT[] DEFAULT '{}';
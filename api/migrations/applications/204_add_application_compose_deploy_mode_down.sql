@@ -0,0 +1 @@
+ALTER TABLE applications DROP COLUMN IF EXISTS compose_deploy_mode;
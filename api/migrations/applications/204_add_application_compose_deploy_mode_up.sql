@@ -0,0 +1 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose_deploy_mode VARCHAR(20) NOT NULL DEFAULT 'compose';
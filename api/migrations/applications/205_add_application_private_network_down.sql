@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_applications_private_network_alias;
+ALTER TABLE applications DROP COLUMN IF EXISTS internal_alias;
+ALTER TABLE applications DROP COLUMN IF EXISTS private_network_scope;
@@ -0,0 +1,43 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS private_network_scope VARCHAR(20) NOT NULL DEFAULT 'organization';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS internal_alias VARCHAR(63) NOT NULL DEFAULT '';
+
+-- Give every application an alias from its name. Applications that would
+-- share one on a private network, the family's or else the organization's,
+-- get a numeric suffix in order of creation.
+DO $$
+DECLARE
+    app RECORD;
+    base TEXT;
+    candidate TEXT;
+    n INTEGER;
+BEGIN
+    FOR app IN
+        SELECT id, name, organization_id, family_id, private_network_scope
+        FROM applications
+        WHERE internal_alias = ''
+        ORDER BY created_at, id
+    LOOP
+        base := rtrim(left(trim(both '-' from regexp_replace(lower(app.name), '[^a-z0-9]+', '-', 'g')), 63), '-');
+        IF base = '' THEN
+            base := 'app-' || left(replace(app.id::text, '-', ''), 12);
+        END IF;
+
+        candidate := base;
+        n := 1;
+        WHILE EXISTS (
+            SELECT 1 FROM applications o
+            WHERE o.internal_alias = candidate
+              AND (CASE WHEN o.private_network_scope = 'family' AND o.family_id IS NOT NULL THEN o.family_id ELSE o.organization_id END)
+                = (CASE WHEN app.private_network_scope = 'family' AND app.family_id IS NOT NULL THEN app.family_id ELSE app.organization_id END)
+        ) LOOP
+            n := n + 1;
+            candidate := rtrim(left(base, 62 - length(n::text)), '-') || '-' || n;
+        END LOOP;
+
+        UPDATE applications SET internal_alias = candidate WHERE id = app.id;
+    END LOOP;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_applications_private_network_alias
+    ON applications ((CASE WHEN private_network_scope = 'family' AND family_id IS NOT NULL THEN family_id ELSE organization_id END), internal_alias)
+    WHERE internal_alias <> '';
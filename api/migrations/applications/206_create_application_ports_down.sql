@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_port_upstreams;
+DROP TABLE IF EXISTS application_ports;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS application_ports (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    server_id UUID,
+    service_name VARCHAR(255) NOT NULL DEFAULT '',
+    protocol VARCHAR(3) NOT NULL DEFAULT 'tcp',
+    target_port INTEGER NOT NULL,
+    published_port INTEGER NOT NULL,
+    publish_mode VARCHAR(20) NOT NULL DEFAULT 'ingress',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_ports_application_id ON application_ports(application_id);
+CREATE INDEX IF NOT EXISTS idx_application_ports_published ON application_ports(protocol, published_port);
+
+-- Docker assigns the host port of a proxied port on each server separately.
+CREATE TABLE IF NOT EXISTS application_port_upstreams (
+    port_id UUID NOT NULL REFERENCES application_ports(id) ON DELETE CASCADE,
+    server_id UUID NOT NULL,
+    upstream_port INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (port_id, server_id)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS application_deployment_phases (
+    id UUID PRIMARY KEY,
+    application_deployment_id UUID NOT NULL REFERENCES application_deployment(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    phase VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    build_steps INTEGER NOT NULL DEFAULT 0,
+    cached_steps INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_deployment_phases_deployment_id ON application_deployment_phases(application_deployment_id);
+CREATE INDEX IF NOT EXISTS idx_application_deployment_phases_application_id ON application_deployment_phases(application_id, started_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS application_deployment_packages;
+DROP TABLE IF EXISTS application_deployment_sboms;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS vulnerability_threshold;
+ALTER TABLE applications DROP COLUMN IF EXISTS sbom_format;
@@ -0,0 +1,34 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS sbom_format VARCHAR(20) NOT NULL DEFAULT 'none';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS vulnerability_threshold VARCHAR(20) NOT NULL DEFAULT 'none';
+
+CREATE TABLE IF NOT EXISTS application_deployment_sboms (
+    id UUID PRIMARY KEY,
+    application_deployment_id UUID NOT NULL UNIQUE REFERENCES application_deployment(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    image VARCHAR(255) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    document JSONB NOT NULL,
+    vulnerabilities JSONB NOT NULL DEFAULT '[]',
+    critical_count INTEGER NOT NULL DEFAULT 0,
+    high_count INTEGER NOT NULL DEFAULT 0,
+    medium_count INTEGER NOT NULL DEFAULT 0,
+    low_count INTEGER NOT NULL DEFAULT 0,
+    blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_deployment_sboms_application_id ON application_deployment_sboms(application_id);
+
+CREATE TABLE IF NOT EXISTS application_deployment_packages (
+    id UUID PRIMARY KEY,
+    application_deployment_id UUID NOT NULL REFERENCES application_deployment(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    version VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL DEFAULT '',
+    purl TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_deployment_packages_deployment_id ON application_deployment_packages(application_deployment_id);
+CREATE INDEX IF NOT EXISTS idx_application_deployment_packages_name_version ON application_deployment_packages(name, version);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS application_migrations (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    source_server_id UUID NOT NULL,
+    target_server_id UUID NOT NULL,
+    deployment_id UUID REFERENCES application_deployment(id) ON DELETE SET NULL,
+    transfer VARCHAR(20) NOT NULL,
+    stop_source BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(30) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    volumes JSONB NOT NULL DEFAULT '[]',
+    source_routes JSONB NOT NULL DEFAULT '[]',
+    source_replicas JSONB,
+    previous_server_ids JSONB NOT NULL DEFAULT '[]',
+    previous_primary_id UUID,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_migrations_application_id ON application_migrations(application_id, created_at DESC);
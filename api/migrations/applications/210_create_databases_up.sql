@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS databases (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    server_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    engine VARCHAR(20) NOT NULL,
+    version VARCHAR(50) NOT NULL,
+    database_name VARCHAR(255) NOT NULL DEFAULT '',
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    password TEXT NOT NULL,
+    cpu_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+    memory_limit_mb BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(30) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS application_databases (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    database_id UUID NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    env_var VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, database_id),
+    UNIQUE (application_id, env_var)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_databases_database_id ON application_databases(database_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS database_restores;
+DROP TABLE IF EXISTS database_backups;
+
+ALTER TABLE databases
+    DROP COLUMN IF EXISTS backup_schedule,
+    DROP COLUMN IF EXISTS backup_retention_count,
+    DROP COLUMN IF EXISTS backup_retention_days;
@@ -0,0 +1,37 @@
+ALTER TABLE databases
+    ADD COLUMN IF NOT EXISTS backup_schedule VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS backup_retention_count INTEGER NOT NULL DEFAULT 7,
+    ADD COLUMN IF NOT EXISTS backup_retention_days INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS database_backups (
+    id UUID PRIMARY KEY,
+    database_id UUID NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    engine VARCHAR(20) NOT NULL,
+    s3_key TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(30) NOT NULL,
+    trigger VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_backups_database_id ON database_backups(database_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS database_restores (
+    id UUID PRIMARY KEY,
+    backup_id UUID NOT NULL REFERENCES database_backups(id) ON DELETE CASCADE,
+    source_database_id UUID NOT NULL,
+    target_database_id UUID NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    status VARCHAR(30) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_restores_target_database_id ON database_restores(target_database_id, created_at DESC);
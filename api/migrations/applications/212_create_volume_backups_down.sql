@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS volume_restores;
+DROP TABLE IF EXISTS volume_backups;
+DROP TABLE IF EXISTS volume_backup_policies;
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS volume_backup_policies (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    source_type VARCHAR(20) NOT NULL,
+    source TEXT NOT NULL,
+    schedule VARCHAR(100) NOT NULL DEFAULT '',
+    retention_count INTEGER NOT NULL DEFAULT 7,
+    retention_days INTEGER NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, source_type, source)
+);
+
+CREATE TABLE IF NOT EXISTS volume_backups (
+    id UUID PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES volume_backup_policies(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    server_id UUID,
+    source_type VARCHAR(20) NOT NULL,
+    source TEXT NOT NULL,
+    s3_key TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(30) NOT NULL,
+    trigger VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_volume_backups_application_id ON volume_backups(application_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_volume_backups_policy_id ON volume_backups(policy_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS volume_restores (
+    id UUID PRIMARY KEY,
+    backup_id UUID NOT NULL REFERENCES volume_backups(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    status VARCHAR(30) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_volume_restores_application_id ON volume_restores(application_id, created_at DESC);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS application_scale_to_zero (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    idle_timeout_minutes INTEGER NOT NULL,
+    sleeping BOOLEAN NOT NULL DEFAULT FALSE,
+    sleep_replicas INTEGER NOT NULL DEFAULT 0,
+    upstream_dial TEXT NOT NULL DEFAULT '',
+    request_count BIGINT NOT NULL DEFAULT 0,
+    last_request_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    slept_at TIMESTAMP,
+    woken_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_scale_to_zero_organization_id ON application_scale_to_zero(organization_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS application_maintenance (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    html_body TEXT NOT NULL DEFAULT '',
+    retry_after_seconds INTEGER NOT NULL DEFAULT 0,
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, domain)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_maintenance_organization_id ON application_maintenance(organization_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_scaling_events;
+DROP TABLE IF EXISTS application_autoscaling_policies;
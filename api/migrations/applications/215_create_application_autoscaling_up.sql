@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS application_autoscaling_policies (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    min_replicas INTEGER NOT NULL,
+    max_replicas INTEGER NOT NULL,
+    metric VARCHAR(16) NOT NULL,
+    target_percent INTEGER NOT NULL,
+    cooldown_seconds INTEGER NOT NULL,
+    last_scaled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (min_replicas >= 1 AND max_replicas >= min_replicas),
+    CHECK (metric IN ('cpu', 'memory'))
+);
+
+CREATE TABLE IF NOT EXISTS application_scaling_events (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    from_replicas INTEGER NOT NULL,
+    to_replicas INTEGER NOT NULL,
+    metric VARCHAR(16) NOT NULL,
+    observed_percent DOUBLE PRECISION NOT NULL,
+    target_percent INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_scaling_events_application_id ON application_scaling_events(application_id, created_at DESC);
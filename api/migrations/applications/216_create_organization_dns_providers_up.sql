@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS organization_dns_providers (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    zones TEXT[] NOT NULL DEFAULT '{}',
+    credentials TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_dns_providers_organization_id ON organization_dns_providers(organization_id);
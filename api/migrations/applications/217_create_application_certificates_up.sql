@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS application_certificates (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    certificate_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    issuer TEXT NOT NULL DEFAULT '',
+    dns_names TEXT[] NOT NULL DEFAULT '{}',
+    fingerprint VARCHAR(64) NOT NULL,
+    not_before TIMESTAMP NOT NULL,
+    not_after TIMESTAMP NOT NULL,
+    expiry_warning_days INTEGER NOT NULL DEFAULT 14 CHECK (expiry_warning_days BETWEEN 1 AND 90),
+    expiry_warned_at TIMESTAMP,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, domain)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_certificates_application_id ON application_certificates(application_id);
+CREATE INDEX IF NOT EXISTS idx_application_certificates_organization_id ON application_certificates(organization_id);
+CREATE INDEX IF NOT EXISTS idx_application_certificates_not_after ON application_certificates(not_after) WHERE expiry_warned_at IS NULL;
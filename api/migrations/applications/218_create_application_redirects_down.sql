@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS application_domains_alias_domain ON application_domains;
+DROP TABLE IF EXISTS application_redirects;
+DROP FUNCTION IF EXISTS check_application_domain_alias();
+DROP FUNCTION IF EXISTS check_application_redirect_alias();
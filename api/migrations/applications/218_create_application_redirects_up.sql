@@ -0,0 +1,76 @@
+CREATE TABLE IF NOT EXISTS application_redirects (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    source_domain TEXT NOT NULL DEFAULT '',
+    source_path TEXT NOT NULL DEFAULT '',
+    target_domain TEXT NOT NULL DEFAULT '',
+    target_path TEXT NOT NULL DEFAULT '',
+    status_code INTEGER NOT NULL DEFAULT 301 CHECK (status_code IN (301, 302, 307, 308)),
+    preserve_path BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, source_domain, source_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_redirects_organization_id ON application_redirects(organization_id);
+CREATE INDEX IF NOT EXISTS idx_application_redirects_source_domain ON application_redirects(source_domain);
+
+-- An alias source domain, a host none of the rule's application domains
+-- use, belongs to that application alone: no other application may route it
+-- or use it as an alias. Both checks take the same per-host lock so that
+-- concurrent writes cannot both pass.
+CREATE OR REPLACE FUNCTION check_application_redirect_alias() RETURNS trigger AS $$
+BEGIN
+    IF NEW.source_domain = '' THEN
+        RETURN NEW;
+    END IF;
+    PERFORM pg_advisory_xact_lock(hashtext('domain_host:' || LOWER(NEW.source_domain)));
+    IF EXISTS (
+        SELECT 1 FROM application_domains d
+        WHERE d.application_id = NEW.application_id AND LOWER(d.domain) = LOWER(NEW.source_domain)
+    ) THEN
+        RETURN NEW;
+    END IF;
+    IF EXISTS (
+        SELECT 1 FROM application_domains d
+        WHERE LOWER(d.domain) = LOWER(NEW.source_domain)
+    ) OR EXISTS (
+        SELECT 1 FROM application_redirects r
+        WHERE LOWER(r.source_domain) = LOWER(NEW.source_domain) AND r.application_id <> NEW.application_id
+    ) THEN
+        RAISE EXCEPTION 'domain % is already used by another application', NEW.source_domain
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'application_redirects_alias_domain';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS application_redirects_alias_domain ON application_redirects;
+CREATE TRIGGER application_redirects_alias_domain
+    BEFORE INSERT OR UPDATE OF application_id, source_domain ON application_redirects
+    FOR EACH ROW EXECUTE FUNCTION check_application_redirect_alias();
+
+CREATE OR REPLACE FUNCTION check_application_domain_alias() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('domain_host:' || LOWER(NEW.domain)));
+    IF EXISTS (
+        SELECT 1 FROM application_redirects r
+        WHERE LOWER(r.source_domain) = LOWER(NEW.domain)
+          AND r.application_id <> NEW.application_id
+          AND NOT EXISTS (
+              SELECT 1 FROM application_domains d
+              WHERE d.application_id = r.application_id AND LOWER(d.domain) = LOWER(r.source_domain)
+          )
+    ) THEN
+        RAISE EXCEPTION 'domain % is a redirect alias of another application', NEW.domain
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'application_domains_alias_domain';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS application_domains_alias_domain ON application_domains;
+CREATE TRIGGER application_domains_alias_domain
+    BEFORE INSERT OR UPDATE OF application_id, domain ON application_domains
+    FOR EACH ROW EXECUTE FUNCTION check_application_domain_alias();
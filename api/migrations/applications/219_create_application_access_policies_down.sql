@@ -0,0 +1 @@
+DROP TABLE IF EXISTS application_access_policies;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS application_access_policies (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL DEFAULT '',
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    denied_ips TEXT[] NOT NULL DEFAULT '{}',
+    basic_auth_users JSONB NOT NULL DEFAULT '[]',
+    basic_auth_realm TEXT NOT NULL DEFAULT '',
+    forward_auth_url TEXT NOT NULL DEFAULT '',
+    forward_auth_copy_headers TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, domain)
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_access_policies_organization_id ON application_access_policies(organization_id);
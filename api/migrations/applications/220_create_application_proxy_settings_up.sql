@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS application_proxy_settings (
+    id UUID PRIMARY KEY,
+    application_id UUID NOT NULL UNIQUE REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    response_headers JSONB NOT NULL DEFAULT '{}',
+    remove_response_headers TEXT[] NOT NULL DEFAULT '{}',
+    encodings TEXT[] NOT NULL DEFAULT '{}',
+    max_request_body_bytes BIGINT NOT NULL DEFAULT 0,
+    dial_timeout_seconds INTEGER NOT NULL DEFAULT 0,
+    response_timeout_seconds INTEGER NOT NULL DEFAULT 0,
+    rate_limit_requests INTEGER NOT NULL DEFAULT 0,
+    rate_limit_window_seconds INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_proxy_settings_organization_id ON application_proxy_settings(organization_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_application_domains_domain_path_prefix;
+ALTER TABLE application_domains DROP COLUMN IF EXISTS strip_path_prefix;
+ALTER TABLE application_domains DROP COLUMN IF EXISTS path_prefix;
@@ -0,0 +1,6 @@
+ALTER TABLE application_domains ADD COLUMN IF NOT EXISTS path_prefix TEXT NOT NULL DEFAULT '';
+ALTER TABLE application_domains ADD COLUMN IF NOT EXISTS strip_path_prefix BOOLEAN NOT NULL DEFAULT false;
+
+-- A host can now be shared by applications mounted under different paths.
+ALTER TABLE application_domains DROP CONSTRAINT IF EXISTS application_domains_domain_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_application_domains_domain_path_prefix ON application_domains (domain, path_prefix);
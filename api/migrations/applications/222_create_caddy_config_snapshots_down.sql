@@ -0,0 +1 @@
+DROP TABLE IF EXISTS caddy_config_snapshots;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS caddy_config_snapshots (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    server_host TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    config TEXT NOT NULL,
+    created_by UUID,
+    restored_from INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, server_host, version)
+);
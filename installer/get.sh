@@ -360,6 +360,7 @@ gather_config() {
     [[ "$REDIS_URL" != *"nixopus-redis"* ]] && USE_BUNDLED_REDIS=false
     AUTH_SERVICE_SECRET="${AUTH_SERVICE_SECRET:-$(gen_secret)}"
     JWT_SECRET="${JWT_SECRET:-$(gen_secret)}"
+    SECRETS_MASTER_KEY="${SECRETS_MASTER_KEY:-$(gen_secret)}"
 
     # ── Agent / LLM configuration ──
     USE_AGENT="${USE_AGENT:-true}"
@@ -508,6 +509,7 @@ REDIS_PASSWORD=${REDIS_PASSWORD}
 
 AUTH_SERVICE_SECRET=${AUTH_SERVICE_SECRET}
 JWT_SECRET=${JWT_SECRET}
+SECRETS_MASTER_KEY=${SECRETS_MASTER_KEY}
 
 ALLOWED_ORIGIN=${ALLOWED_ORIGIN}
 API_URL=${API_URL}